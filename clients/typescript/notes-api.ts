@@ -0,0 +1,2146 @@
+// Code generated by apigen. DO NOT EDIT.
+
+export class ApiError extends Error {
+  constructor(
+    public readonly status: number,
+    public readonly code: string,
+    message: string,
+    public readonly body: unknown,
+  ) {
+    super(message || "HTTP " + status);
+  }
+}
+
+export interface ClientOptions {
+  /** The server root, without /api/v1. */
+  baseUrl: string;
+  /** Sent as a bearer token when set. */
+  token?: string;
+  fetch?: typeof fetch;
+}
+
+type Scalar = string | number | boolean | undefined;
+
+interface Call {
+  query?: Record<string, Scalar>;
+  headers?: Record<string, Scalar>;
+  body?: unknown;
+  contentType?: string;
+  /** How to read a successful response. */
+  response: "json" | "text" | "blob" | "none";
+}
+
+function prefixed(prefix: string, values?: Record<string, string>): Record<string, string> {
+  const out: Record<string, string> = {};
+  for (const [key, value] of Object.entries(values ?? {})) {
+    out[prefix + key] = value;
+  }
+  return out;
+}
+
+/** attach.Upload of the API. */
+export type Upload = {
+  id: string;
+  note_id: number;
+  name: string;
+  content_type: string;
+  size: number;
+  sha256: string;
+  offset: number;
+  created_at: string;
+  expires_at: string;
+};
+
+/** audit.Entry of the API. */
+export type Entry = {
+  id: number;
+  at: string;
+  actor: string;
+  action: string;
+  /** Target names what the action was about, e.g. note:1. */
+  target: string;
+  detail?: string;
+};
+
+/** cloudsync.Conflict of the API. */
+export type Conflict = {
+  path: string;
+  note_id: number;
+  copy_id: number;
+  at: string;
+};
+
+/** cloudsync.Status of the API. */
+export type Status = {
+  remote: string;
+  running: boolean;
+  last_run?: string | null;
+  last_error?: string;
+  pushed: number;
+  pulled: number;
+  imported: number;
+  deleted: number;
+  /** Locked counts notes left for a later run because someone else has locked them for editing. */
+  locked: number;
+  conflicts: Conflict[];
+};
+
+/** core.Attachment of the API. */
+export type Attachment = {
+  id: number;
+  note_id: number;
+  name: string;
+  content_type: string;
+  size: number;
+  sha256: string;
+  owner_id: string;
+  created_at: string;
+  /** Status is quarantined when a scan found the file suspicious; only admins can download it then, and ScanReason says why. */
+  status: "clean" | "quarantined";
+  scan_reason?: string;
+  /** Text is the text recognized in the file: found by OCR in images or transcribed from audio. It is searchable as part of the note. */
+  text?: string;
+};
+
+/** core.Block of the API. */
+export type Block = {
+  type: string;
+  text?: string;
+  level?: number;
+  language?: string;
+  items?: ChecklistItem[];
+  url?: string;
+  alt?: string;
+};
+
+/** core.ChangeRequest of the API. */
+export type ChangeRequest = {
+  id: number;
+  note_id: number;
+  notebook_id: number;
+  author_id: string;
+  status: "pending" | "approved" | "rejected";
+  /** Changes are the fields the edit sets, named as in PATCH /notes/{id}. */
+  changes: Record<string, unknown>;
+  created_at: string;
+  /** DecidedBy approved or rejected the request at DecidedAt, giving Reason for a rejection. */
+  decided_by?: string;
+  decided_at?: string | null;
+  reason?: string;
+};
+
+/** core.ChecklistItem of the API. */
+export type ChecklistItem = {
+  text: string;
+  checked: boolean;
+};
+
+/** core.Collection of the API. */
+export type Collection = {
+  ID: number;
+  OwnerID: string;
+  Name: string;
+  Schema: PropertyDef[];
+  CreatedAt: string;
+  UpdatedAt: string | null;
+};
+
+/** core.CollectionCreate of the API. */
+export type CollectionCreate = {
+  name: string;
+  schema: PropertyDef[];
+};
+
+/** core.CollectionUpdate of the API. */
+export type CollectionUpdate = {
+  name?: string | null;
+  schema?: PropertyDef[] | null;
+};
+
+/** core.Comment of the API. */
+export type Comment = {
+  id: number;
+  note_id: number;
+  author_id: string;
+  body: string;
+  reply_to?: number;
+  /** Resolved marks a thread as settled; ResolvedBy did so at ResolvedAt. */
+  resolved: boolean;
+  resolved_by?: string;
+  resolved_at?: string | null;
+  created_at: string;
+  updated_at?: string | null;
+};
+
+/** core.DiffLine of the API. */
+export type DiffLine = {
+  op: "=" | "-" | "+";
+  text: string;
+};
+
+/** core.DisplayDates of the API. */
+export type DisplayDates = {
+  Created: string;
+  Updated?: string;
+  RemindAt?: string;
+};
+
+/** core.InboundHook of the API. */
+export type InboundHook = {
+  id: number;
+  owner_id: string;
+  name: string;
+  token: string;
+  notebook_id?: number;
+  /** Title defaults to the hook name and Content to the payload as sent. */
+  title?: string;
+  content?: string;
+  tags?: string[];
+  created_at: string;
+  last_used_at?: string | null;
+};
+
+/** core.InboundHookInput of the API. */
+export type InboundHookInput = {
+  name: string;
+  notebook_id?: number;
+  title?: string;
+  content?: string;
+  tags?: string[];
+};
+
+/** core.Lifecycle of the API. */
+export type Lifecycle = {
+  states: string[];
+  /** Transitions maps each state to the states a note in it may move to. */
+  transitions: Record<string, string[]>;
+};
+
+/** core.NearbyNote of the API. */
+export type NearbyNote = {
+  ID: number;
+  /** PublicID is a UUIDv7 that routes accept in place of ID. */
+  PublicID: string;
+  Slug: string;
+  OwnerID: string;
+  NotebookID: number;
+  /** CollectionID is the collection whose schema the note follows. */
+  CollectionID?: number;
+  Path?: NotebookRef[];
+  Position: number;
+  Type: string;
+  Title: string;
+  Content: string;
+  Blocks?: Block[];
+  Language?: string;
+  Latitude?: number | null;
+  Longitude?: number | null;
+  JournalDate?: string;
+  Tags?: string[];
+  /** SourceURL is the page a clipped note was taken from. */
+  SourceURL?: string;
+  Pinned: boolean;
+  State?: string;
+  RemindAt?: string | null;
+  /** Color is a #rrggbb color for showing the note. */
+  Color?: string;
+  /** ExpiresAt is when the retention policy of the notebook the note was created in deletes it. */
+  ExpiresAt?: string | null;
+  /** Properties are typed key-value fields; see ValidateProperties. */
+  Properties?: Record<string, unknown>;
+  /** Reactions counts the users behind each emoji in ReactedBy, which is kept out of responses. */
+  Reactions?: Record<string, number>;
+  CreatedAt: string;
+  UpdatedAt: string | null;
+  /** Display has the dates formatted for the reader, when asked for with date_format or Accept-Language. */
+  Display?: DisplayDates | null;
+  DistanceMeters: number;
+};
+
+/** core.Note of the API. */
+export type Note = {
+  ID: number;
+  /** PublicID is a UUIDv7 that routes accept in place of ID. */
+  PublicID: string;
+  Slug: string;
+  OwnerID: string;
+  NotebookID: number;
+  /** CollectionID is the collection whose schema the note follows. */
+  CollectionID?: number;
+  Path?: NotebookRef[];
+  Position: number;
+  Type: string;
+  Title: string;
+  Content: string;
+  Blocks?: Block[];
+  Language?: string;
+  Latitude?: number | null;
+  Longitude?: number | null;
+  JournalDate?: string;
+  Tags?: string[];
+  /** SourceURL is the page a clipped note was taken from. */
+  SourceURL?: string;
+  Pinned: boolean;
+  State?: string;
+  RemindAt?: string | null;
+  /** Color is a #rrggbb color for showing the note. */
+  Color?: string;
+  /** ExpiresAt is when the retention policy of the notebook the note was created in deletes it. */
+  ExpiresAt?: string | null;
+  /** Properties are typed key-value fields; see ValidateProperties. */
+  Properties?: Record<string, unknown>;
+  /** Reactions counts the users behind each emoji in ReactedBy, which is kept out of responses. */
+  Reactions?: Record<string, number>;
+  CreatedAt: string;
+  UpdatedAt: string | null;
+  /** Display has the dates formatted for the reader, when asked for with date_format or Accept-Language. */
+  Display?: DisplayDates | null;
+};
+
+/** core.NoteCreate of the API. */
+export type NoteCreate = {
+  notebook_id?: number;
+  collection_id?: number;
+  type?: "note" | "snippet";
+  title: string;
+  content: string;
+  blocks?: Block[];
+  language?: string;
+  latitude?: number | null;
+  longitude?: number | null;
+  tags?: string[];
+  pinned?: boolean;
+  remind_at?: string | null;
+  color?: string;
+  properties?: Record<string, unknown>;
+};
+
+/** core.NoteLock of the API. */
+export type NoteLock = {
+  note_id: number;
+  holder_id: string;
+  acquired_at: string;
+  expires_at: string;
+};
+
+/** core.NoteTemplate of the API. */
+export type NoteTemplate = {
+  id: number;
+  owner_id: string;
+  name: string;
+  title: string;
+  content: string;
+  tags?: string[];
+  notebook_id?: number;
+  schedule?: string;
+  timezone?: string;
+  /** NextRunAt is when the schedule next creates a note. */
+  next_run_at?: string | null;
+  last_run_at?: string | null;
+  /** LastNoteID is the note the schedule created last. */
+  last_note_id?: number;
+  created_at: string;
+  updated_at?: string | null;
+};
+
+/** core.NoteTemplateInput of the API. */
+export type NoteTemplateInput = {
+  name: string;
+  title: string;
+  content: string;
+  tags?: string[];
+  notebook_id?: number;
+  schedule?: string;
+  timezone?: string;
+};
+
+/** core.NoteUpdate of the API. */
+export type NoteUpdate = {
+  title?: string | null;
+  content?: string | null;
+  blocks?: Block[] | null;
+  language?: string | null;
+  latitude?: number | null;
+  longitude?: number | null;
+  tags?: string[] | null;
+  pinned?: boolean | null;
+  remind_at?: string | null;
+  /** Color is a #rrggbb color; "" removes it. */
+  color?: string | null;
+  /** CollectionID moves the note into a collection; 0 removes it. */
+  collection_id?: number | null;
+  /** Properties are merged into the note's; null removes a property. */
+  properties?: Record<string, unknown>;
+};
+
+/** core.NoteView of the API. */
+export type NoteView = {
+  user_id: string;
+  views: number;
+  first_viewed_at: string;
+  last_viewed_at: string;
+};
+
+/** core.Notebook of the API. */
+export type Notebook = {
+  ID: number;
+  ParentID: number;
+  Name: string;
+  OwnerID: string;
+  Shares?: Share[];
+  Path?: NotebookRef[];
+  CreatedAt: string;
+  UpdatedAt: string | null;
+  /** UniqueTitles forbids two notes of the notebook to share a title. */
+  UniqueTitles?: boolean;
+  /** RequireApproval turns edits of its notes by anyone but the owner into change requests the owner approves or rejects. */
+  RequireApproval?: boolean;
+};
+
+/** core.NotebookCreate of the API. */
+export type NotebookCreate = {
+  name: string;
+  parent_id?: number;
+  unique_titles?: boolean;
+};
+
+/** core.NotebookRef of the API. */
+export type NotebookRef = {
+  ID: number;
+  Name: string;
+};
+
+/** core.NotebookSettings of the API. */
+export type NotebookSettings = {
+  /** Tags are added to those of every new note. */
+  tags?: string[];
+  /** Color is given to new notes that do not pick one. */
+  color?: string;
+  /** TemplateID is a note template whose title, content and tags fill in new notes that leave their title or content empty. */
+  template_id?: number;
+  /** RetentionDays, when set, deletes new notes that many days after they are created. */
+  retention_days?: number;
+};
+
+/** core.NotebookUpdate of the API. */
+export type NotebookUpdate = {
+  name?: string | null;
+  parent_id?: number | null;
+  /** UniqueTitles turns the unique title constraint on or off. */
+  unique_titles?: boolean | null;
+  /** RequireApproval turns approval mode on or off; only the owner can. */
+  require_approval?: boolean | null;
+};
+
+/** core.NotificationPreferences of the API. */
+export type NotificationPreferences = {
+  /** Watched delivers notifications about watched notes. */
+  watched: boolean;
+  /** Digest opts into the weekly digest email. */
+  digest: boolean;
+};
+
+/** core.NotificationPreferencesUpdate of the API. */
+export type NotificationPreferencesUpdate = {
+  watched?: boolean | null;
+  digest?: boolean | null;
+};
+
+/** core.Preferences of the API. */
+export type Preferences = {
+  /** DefaultNotebookID receives notes created without a notebook. */
+  default_notebook_id: number;
+  locale: string;
+  /** Timezone is an IANA zone name used for digests and reminders. */
+  timezone: string;
+  /** PageSize is the note list limit used when a request gives none. */
+  page_size: number;
+  /** Email receives the weekly digest. */
+  email: string;
+  notifications: NotificationPreferences;
+  privacy: PrivacyPreferences;
+  /** SearchLanguage is the analyzer of the user's searches; empty uses the one of the instance. */
+  search_language: "simple" | "english" | "russian";
+};
+
+/** core.PreferencesUpdate of the API. */
+export type PreferencesUpdate = {
+  default_notebook_id?: number | null;
+  locale?: string | null;
+  timezone?: string | null;
+  page_size?: number | null;
+  email?: string | null;
+  notifications?: NotificationPreferencesUpdate | null;
+  privacy?: PrivacyPreferencesUpdate | null;
+  search_language?: string | null;
+};
+
+/** core.PrivacyPreferences of the API. */
+export type PrivacyPreferences = {
+  /** RecentNotes keeps the notes the user viewed for GET /notes/recent. */
+  recent_notes: boolean;
+};
+
+/** core.PrivacyPreferencesUpdate of the API. */
+export type PrivacyPreferencesUpdate = {
+  recent_notes?: boolean | null;
+};
+
+/** core.PropertyDef of the API. */
+export type PropertyDef = {
+  name: string;
+  type: "string" | "number" | "bool" | "date";
+  required?: boolean;
+};
+
+/** core.ReviewState of the API. */
+export type ReviewState = {
+  note_id: number;
+  interval_days: number;
+  reviews: number;
+  last_reviewed_at?: string | null;
+  due_at: string;
+};
+
+/** core.Rule of the API. */
+export type Rule = {
+  id: number;
+  owner_id: string;
+  name: string;
+  enabled: boolean;
+  when: RuleTrigger;
+  then: RuleAction[];
+  /** Runs counts the events the rule ran on, LastRunAt is the latest. */
+  runs: number;
+  last_run_at?: string | null;
+  created_at: string;
+  updated_at: string;
+};
+
+/** core.RuleAction of the API. */
+export type RuleAction = {
+  type: "apply_tag" | "move_to_notebook" | "call_webhook" | "send_email";
+  tag?: string;
+  notebook_id?: number;
+  url?: string;
+  to?: string[];
+};
+
+/** core.RuleInput of the API. */
+export type RuleInput = {
+  name: string;
+  /** Enabled defaults to true. */
+  enabled?: boolean | null;
+  when: RuleTrigger;
+  then: RuleAction[];
+};
+
+/** core.RuleTrigger of the API. */
+export type RuleTrigger = {
+  event: "note_created" | "reminder_due" | "note_shared";
+  /** Tag, when set, limits the rule to notes with the tag or one nested under it. */
+  tag?: string;
+};
+
+/** core.SavedView of the API. */
+export type SavedView = {
+  id: number;
+  owner_id: string;
+  name: string;
+  query?: string;
+  sort?: "created" | "-created" | "updated" | "-updated" | "title" | "-title";
+  fields?: string[];
+  shared_with?: string[];
+  created_at: string;
+  updated_at?: string | null;
+};
+
+/** core.SavedViewInput of the API. */
+export type SavedViewInput = {
+  name: string;
+  query?: string;
+  sort?: "created" | "-created" | "updated" | "-updated" | "title" | "-title";
+  fields?: string[];
+  shared_with?: string[];
+};
+
+/** core.Share of the API. */
+export type Share = {
+  grantee: string;
+  role: "viewer" | "editor";
+};
+
+/** core.TagCount of the API. */
+export type TagCount = {
+  tag: string;
+  count: number;
+};
+
+/** core.TagNode of the API. */
+export type TagNode = {
+  name: string;
+  path: string;
+  /** Count is the number of notes with exactly this tag, Total also includes notes tagged with any descendant. */
+  count: number;
+  total: number;
+  children?: TagNode[];
+};
+
+/** events.Event of the API. */
+export type Event = {
+  version: number;
+  type: string;
+  note_id: number;
+  /** Note is the note after a note.* event; presence.* events leave it out. */
+  note?: Note;
+  /** UserID has the note open in presence.* events, and is Typing in it. */
+  user_id?: string;
+  typing?: boolean;
+  at: string;
+};
+
+/** events.Viewer of the API. */
+export type Viewer = {
+  user_id: string;
+  typing: boolean;
+  since: string;
+};
+
+/** handlers.ActivityDay of the API. */
+export type ActivityDay = {
+  date: string;
+  created: number;
+  updated: number;
+};
+
+/** handlers.BlockStorage of the API. */
+export type BlockStorage = {
+  type: string;
+  count: number;
+  bytes: number;
+};
+
+/** handlers.Board of the API. */
+export type Board = {
+  property: string;
+  columns: BoardColumn[];
+};
+
+/** handlers.BoardColumn of the API. */
+export type BoardColumn = {
+  /** Value is the property value or tag of the column; cards without one are in a last column with an empty value. */
+  value: string;
+  cards: Note[];
+};
+
+/** handlers.BulkMoveRequest of the API. */
+export type BulkMoveRequest = {
+  ids: number[];
+  notebook_id: number;
+};
+
+/** handlers.BulkTagRequest of the API. */
+export type BulkTagRequest = {
+  q?: string;
+  notebook_id?: number;
+  /** From and To bound the creation time; To is exclusive. */
+  from?: string | null;
+  to?: string | null;
+};
+
+/** handlers.CDCRecord of the API. */
+export type CDCRecord = {
+  seq: number;
+  op: string;
+  note_id?: number;
+  note?: Note | null;
+  at: string;
+};
+
+/** handlers.ChangeDiff of the API. */
+export type ChangeDiff = {
+  change_request: ChangeRequest;
+  fields: FieldChange[];
+  /** Content is the line diff of the content, empty when the request leaves it alone. */
+  content: DiffLine[];
+};
+
+/** handlers.ClipRequest of the API. */
+export type ClipRequest = {
+  url: string;
+  /** HTML is the page as the browser rendered it. When set the server does not fetch URL, so pages behind a login can be clipped too. */
+  html?: string;
+  title?: string;
+  tags?: string[];
+  notebook_id?: number;
+};
+
+/** handlers.CommentThread of the API. */
+export type CommentThread = {
+  id: number;
+  note_id: number;
+  author_id: string;
+  body: string;
+  reply_to?: number;
+  /** Resolved marks a thread as settled; ResolvedBy did so at ResolvedAt. */
+  resolved: boolean;
+  resolved_by?: string;
+  resolved_at?: string | null;
+  created_at: string;
+  updated_at?: string | null;
+  replies: Comment[];
+};
+
+/** handlers.CreateCommentRequest of the API. */
+export type CreateCommentRequest = {
+  body: string;
+  /** ReplyTo answers a comment in its thread; zero starts a thread. */
+  reply_to?: number;
+};
+
+/** handlers.Dashboard of the API. */
+export type Dashboard = {
+  pinned: Note[];
+  upcoming_reminders: Note[];
+  recent_activity: Note[];
+  tag_cloud: TagCount[];
+};
+
+/** handlers.EchoPrincipal of the API. */
+export type EchoPrincipal = {
+  user_id: string;
+  admin: boolean;
+  anonymous: boolean;
+};
+
+/** handlers.EchoRateLimit of the API. */
+export type EchoRateLimit = {
+  limit: string;
+  remaining: string;
+  reset: string;
+};
+
+/** handlers.EchoResponse of the API. */
+export type EchoResponse = {
+  method: string;
+  url: string;
+  route: string;
+  request_id: string;
+  remote_addr: string;
+  proto: string;
+  host: string;
+  query: Record<string, string[]>;
+  headers: Record<string, string[]>;
+  principal: EchoPrincipal;
+  /** RateLimit is the caller's limit state, when rate limits are on. */
+  rate_limit?: EchoRateLimit | null;
+};
+
+/** handlers.EmailNoteRequest of the API. */
+export type EmailNoteRequest = {
+  to: string[];
+  /** Message is written above the note. */
+  message?: string;
+};
+
+/** handlers.EmailNoteResponse of the API. */
+export type EmailNoteResponse = {
+  sent: string[];
+};
+
+/** handlers.EmojiList of the API. */
+export type EmojiList = {
+  builtin: BuiltinEmoji[];
+  custom: CustomEmoji[];
+};
+
+/** handlers.ErrorCode of the API. */
+export type ErrorCode = {
+  code: string;
+  status: number;
+  description: string;
+};
+
+/** handlers.ErrorResponse of the API. */
+export type ErrorResponse = {
+  error: string;
+  /** Code is one of those GET /errors lists. */
+  code: string;
+  /** Fields maps invalid fields to what is wrong with them, for validation_failed. */
+  fields?: Record<string, string>;
+  /** ConflictingID is the note whose title a duplicate_title request would repeat. */
+  conflicting_id?: number;
+  /** Lock is the edit lock someone else holds, for note_locked. */
+  lock?: NoteLock | null;
+};
+
+/** handlers.FavoriteResponse of the API. */
+export type FavoriteResponse = {
+  favorite: boolean;
+};
+
+/** handlers.FieldChange of the API. */
+export type FieldChange = {
+  field: string;
+  old: unknown;
+  new: unknown;
+};
+
+/** handlers.InboundResult of the API. */
+export type InboundResult = {
+  note_id: number;
+  public_id: string;
+  title: string;
+};
+
+/** handlers.InstanceStats of the API. */
+export type InstanceStats = {
+  /** Users counts users who own at least one note. */
+  users: number;
+  notes: number;
+  bytes: number;
+  notebooks: number;
+  top_users: UserStorage[];
+  /** Requests and Errors are absent when metrics are disabled. */
+  requests_per_day?: DayCount[];
+  top_errors?: StatusCount[];
+};
+
+/** handlers.JobResponse of the API. */
+export type JobResponse = {
+  id: number;
+  kind: string;
+  state: "queued" | "running" | "done" | "failed";
+  total: number;
+  done: number;
+  errors: string[];
+  error_count: number;
+  created_at: string;
+  started_at?: string | null;
+  finished_at?: string | null;
+  /** ResultURL is where the file the job produced can be downloaded: /jobs/{id}/result, or a signed link that needs no token. */
+  result_url?: string;
+};
+
+/** handlers.MarkReadRequest of the API. */
+export type MarkReadRequest = {
+  /** IDs lists the notifications to mark read; empty marks all. */
+  ids?: number[];
+};
+
+/** handlers.MarkReadResponse of the API. */
+export type MarkReadResponse = {
+  marked: number;
+};
+
+/** handlers.MergeTagsRequest of the API. */
+export type MergeTagsRequest = {
+  source: string;
+  target: string;
+};
+
+/** handlers.MoveCardRequest of the API. */
+export type MoveCardRequest = {
+  column: string;
+  /** BeforeID places the card above another card of the column in the same notebook; without it the card keeps its position. */
+  before_id?: number;
+};
+
+/** handlers.MoveNoteRequest of the API. */
+export type MoveNoteRequest = {
+  notebook_id: number;
+};
+
+/** handlers.NoteCount of the API. */
+export type NoteCount = {
+  count: number;
+};
+
+/** handlers.NoteTransitions of the API. */
+export type NoteTransitions = {
+  state: string;
+  next: string[];
+};
+
+/** handlers.NotebookStorage of the API. */
+export type NotebookStorage = {
+  /** NotebookID is 0 for notes outside notebooks. */
+  notebook_id: number;
+  name?: string;
+  count: number;
+  bytes: number;
+};
+
+/** handlers.PresenceRequest of the API. */
+export type PresenceRequest = {
+  /** Typing tells whether the caller is typing in the note. */
+  typing: boolean;
+};
+
+/** handlers.PublicLinkResponse of the API. */
+export type PublicLinkResponse = {
+  url: string;
+  token: string;
+};
+
+/** handlers.PutEmojiRequest of the API. */
+export type PutEmojiRequest = {
+  url: string;
+};
+
+/** handlers.QueryValidation of the API. */
+export type QueryValidation = {
+  valid: boolean;
+  language?: string;
+  query?: string;
+  error?: ParseError | null;
+};
+
+/** handlers.ReactionRequest of the API. */
+export type ReactionRequest = {
+  emoji: string;
+};
+
+/** handlers.ReactionsResponse of the API. */
+export type ReactionsResponse = {
+  /** Reactions counts the users behind each emoji. */
+  reactions: Record<string, number>;
+  /** Mine lists the caller's own reactions. */
+  mine: string[];
+};
+
+/** handlers.RejectChangeRequest of the API. */
+export type RejectChangeRequest = {
+  reason?: string;
+};
+
+/** handlers.RenameTagRequest of the API. */
+export type RenameTagRequest = {
+  name: string;
+};
+
+/** handlers.ReorderRequest of the API. */
+export type ReorderRequest = {
+  notebook_id: number;
+  ids: number[];
+};
+
+/** handlers.ReviewCard of the API. */
+export type ReviewCard = {
+  note: Note;
+  /** Review is nil for notes never reviewed. */
+  review?: ReviewState | null;
+};
+
+/** handlers.ReviewDoneRequest of the API. */
+export type ReviewDoneRequest = {
+  grade?: "again" | "good" | "easy";
+};
+
+/** handlers.SearchResults of the API. */
+export type SearchResults = {
+  total: number;
+  notes: Note[];
+  facets: Facets;
+};
+
+/** handlers.StartUploadRequest of the API. */
+export type StartUploadRequest = {
+  name: string;
+  content_type: string;
+  size: number;
+  /** SHA256 is the hex digest of the whole file, checked when the last chunk arrives. */
+  sha256: string;
+};
+
+/** handlers.StorageStats of the API. */
+export type StorageStats = {
+  total: Usage;
+  notebooks: NotebookStorage[];
+  tags: TagStorage[];
+  blocks: BlockStorage[];
+};
+
+/** handlers.SuccessResponse of the API. */
+export type SuccessResponse = {
+  message: string;
+};
+
+/** handlers.TagChangeResponse of the API. */
+export type TagChangeResponse = {
+  tag: string;
+  notes_updated: number;
+};
+
+/** handlers.TagStorage of the API. */
+export type TagStorage = {
+  tag: string;
+  count: number;
+  bytes: number;
+};
+
+/** handlers.TransitionRequest of the API. */
+export type TransitionRequest = {
+  to: string;
+};
+
+/** handlers.TypeaheadHit of the API. */
+export type TypeaheadHit = {
+  id: number;
+  title: string;
+};
+
+/** handlers.UndoResponse of the API. */
+export type UndoResponse = {
+  operation: "delete" | "move" | "copy" | "rename_tag" | "apply_tag" | "remove_tag";
+  notes: Note[];
+};
+
+/** handlers.UpdateCommentRequest of the API. */
+export type UpdateCommentRequest = {
+  /** Body edits the comment; only its author can. */
+  body?: string | null;
+  /** Resolved resolves or reopens a thread; the author of the thread and editors of the note can. */
+  resolved?: boolean | null;
+};
+
+/** handlers.UserStorage of the API. */
+export type UserStorage = {
+  user_id: string;
+  count: number;
+  bytes: number;
+};
+
+/** handlers.VaultImportResponse of the API. */
+export type VaultImportResponse = {
+  notebooks_created: number;
+  notes_created: number;
+  skipped: string[];
+};
+
+/** handlers.WatchResponse of the API. */
+export type WatchResponse = {
+  watching: boolean;
+};
+
+/** handlers.ZapierAppendRequest of the API. */
+export type ZapierAppendRequest = {
+  note_id: number;
+  content: string;
+};
+
+/** handlers.ZapierCreateRequest of the API. */
+export type ZapierCreateRequest = {
+  title: string;
+  content: string;
+  tags: string;
+  notebook_id: number;
+};
+
+/** handlers.ZapierMe of the API. */
+export type ZapierMe = {
+  user_id: string;
+};
+
+/** handlers.ZapierNote of the API. */
+export type ZapierNote = {
+  id: string;
+  note_id: number;
+  title: string;
+  content: string;
+  tags: string;
+  notebook_id: number;
+  pinned: boolean;
+  created_at: string;
+  updated_at: string;
+};
+
+/** jobs.Job of the API. */
+export type JobsJob = {
+  id: number;
+  kind: string;
+  state: "queued" | "running" | "done" | "failed";
+  total: number;
+  done: number;
+  errors: string[];
+  error_count: number;
+  created_at: string;
+  started_at?: string | null;
+  finished_at?: string | null;
+};
+
+/** metrics.DayCount of the API. */
+export type DayCount = {
+  date: string;
+  requests: number;
+};
+
+/** metrics.StatusCount of the API. */
+export type StatusCount = {
+  status: number;
+  requests: number;
+};
+
+/** notify.Notification of the API. */
+export type Notification = {
+  id: number;
+  type: string;
+  note_id: number;
+  job_id?: number;
+  title: string;
+  actor: string;
+  at: string;
+  read: boolean;
+  /** ChangeID is the change request of change.* notifications. */
+  change_id?: number;
+};
+
+/** preview.Preview of the API. */
+export type Preview = {
+  url: string;
+  state: "pending" | "ready" | "failed";
+  title?: string;
+  description?: string;
+  image?: string;
+  site_name?: string;
+  favicon?: string;
+  fetched_at?: string | null;
+};
+
+/** render.BuiltinEmoji of the API. */
+export type BuiltinEmoji = {
+  name: string;
+  emoji: string;
+};
+
+/** render.CustomEmoji of the API. */
+export type CustomEmoji = {
+  name: string;
+  url: string;
+  author: string;
+  saved_at: string;
+};
+
+/** render.Template of the API. */
+export type Template = {
+  name: string;
+  header?: string;
+  footer?: string;
+  meta?: "top" | "bottom" | "none";
+  css?: string;
+  html?: string;
+  author: string;
+  saved_at: string;
+};
+
+/** repo.Usage of the API. */
+export type Usage = {
+  count: number;
+  bytes: number;
+};
+
+/** search.Facet of the API. */
+export type Facet = {
+  value: string;
+  label?: string;
+  count: number;
+};
+
+/** search.Facets of the API. */
+export type Facets = {
+  tags: Facet[];
+  /** Notebooks are by notebook ID; notes outside notebooks are not counted. */
+  notebooks: Facet[];
+  /** Created is by how long ago the note was created, in the buckets today, week, month, year and older, each excluding the ones before. */
+  created: Facet[];
+  /** Authors are by owner ID. */
+  authors: Facet[];
+};
+
+/** search.Job of the API. */
+export type SearchJob = {
+  id: number;
+  state: "running" | "done";
+  schema_version: number;
+  total: number;
+  done: number;
+  started_at: string;
+  finished_at?: string | null;
+};
+
+/** search.ParseError of the API. */
+export type ParseError = {
+  pos: number;
+  message: string;
+};
+
+export class NotesApiClient {
+  constructor(private readonly options: ClientOptions) {}
+
+  private async call<T>(method: string, path: string, call: Call): Promise<T> {
+    const url = new URL(this.options.baseUrl.replace(/\/$/, "") + path);
+    for (const [key, value] of Object.entries(call.query ?? {})) {
+      if (value !== undefined && value !== "") url.searchParams.set(key, String(value));
+    }
+    const headers: Record<string, string> = {};
+    for (const [key, value] of Object.entries(call.headers ?? {})) {
+      if (value !== undefined && value !== "") headers[key] = String(value);
+    }
+    let body: BodyInit | undefined;
+    if (call.contentType) {
+      headers["Content-Type"] = call.contentType;
+      body = call.body as BodyInit;
+    } else if (call.body !== undefined) {
+      headers["Content-Type"] = "application/json";
+      body = JSON.stringify(call.body);
+    }
+    if (this.options.token) headers["Authorization"] = "Bearer " + this.options.token;
+
+    const res = await (this.options.fetch ?? fetch)(url, { method, headers, body });
+    if (!res.ok) {
+      const text = await res.text();
+      let error: { code?: string; error?: string } = {};
+      try {
+        error = JSON.parse(text);
+      } catch {
+        // Not a JSON error body.
+      }
+      throw new ApiError(res.status, error.code ?? "", error.error ?? "", text);
+    }
+    if (res.status === 204 || call.response === "none") return undefined as T;
+    if (call.response === "text") return (await res.text()) as T;
+    if (call.response === "blob") return (await res.blob()) as T;
+    return (await res.json()) as T;
+  }
+
+  /** POST /admin/attachments/{id}/release: Выпустить вложение из карантина */
+  releaseAttachment(id: number): Promise<Attachment> {
+    return this.call("POST", `/api/v1/admin/attachments/${encodeURIComponent(String(id))}/release`, { response: "json" });
+  }
+
+  /** GET /admin/audit: Журнал аудита */
+  listAudit(params?: {
+    /** Записи после этого ID */
+    after?: number;
+    /** Сколько записей (по умолчанию 100, не больше 1000) */
+    limit?: number;
+  }): Promise<Entry[]> {
+    return this.call("GET", `/api/v1/admin/audit`, { query: { "after": params?.after, "limit": params?.limit }, response: "json" });
+  }
+
+  /** GET /admin/cdc: Поток изменений для репликации (CDC) */
+  streamCDC(params?: {
+    /** Номер последнего полученного изменения */
+    since?: number;
+  }): Promise<CDCRecord> {
+    return this.call("GET", `/api/v1/admin/cdc`, { query: { "since": params?.since }, response: "json" });
+  }
+
+  /** GET /admin/print-templates: Шаблоны печати */
+  listPrintTemplates(): Promise<Template[]> {
+    return this.call("GET", `/api/v1/admin/print-templates`, { response: "json" });
+  }
+
+  /** DELETE /admin/print-templates/{name}: Удалить шаблон печати */
+  deletePrintTemplate(name: string): Promise<void> {
+    return this.call("DELETE", `/api/v1/admin/print-templates/${encodeURIComponent(String(name))}`, { response: "none" });
+  }
+
+  /** PUT /admin/print-templates/{name}: Сохранить шаблон печати */
+  putPrintTemplate(name: string, body: Template): Promise<Template> {
+    return this.call("PUT", `/api/v1/admin/print-templates/${encodeURIComponent(String(name))}`, { body, response: "json" });
+  }
+
+  /** GET /admin/search/reindex: Ход перестроения индекса */
+  reindexStatus(): Promise<SearchJob> {
+    return this.call("GET", `/api/v1/admin/search/reindex`, { response: "json" });
+  }
+
+  /** POST /admin/search/reindex: Перестроить поисковый индекс */
+  reindexSearch(): Promise<SearchJob> {
+    return this.call("POST", `/api/v1/admin/search/reindex`, { response: "json" });
+  }
+
+  /** GET /admin/stats: Статистика инстанса */
+  instanceStats(params?: {
+    /** Сколько дней запросов, включая сегодня (по умолчанию 14, не больше 90) */
+    days?: number;
+  }): Promise<InstanceStats> {
+    return this.call("GET", `/api/v1/admin/stats`, { query: { "days": params?.days }, response: "json" });
+  }
+
+  /** GET /boards/{property}: Канбан-доска */
+  getBoard(property: string, params?: {
+    /** Порядок колонок через запятую; пустые колонки тоже выводятся */
+    columns?: string;
+    /** Только заметки блокнота */
+    notebookId?: number;
+  }): Promise<Board> {
+    return this.call("GET", `/api/v1/boards/${encodeURIComponent(String(property))}`, { query: { "columns": params?.columns, "notebook_id": params?.notebookId }, response: "json" });
+  }
+
+  /** PATCH /boards/{property}/cards/{id}: Переместить карточку */
+  moveCard(property: string, id: string, body: MoveCardRequest): Promise<Note> {
+    return this.call("PATCH", `/api/v1/boards/${encodeURIComponent(String(property))}/cards/${encodeURIComponent(String(id))}`, { body, response: "json" });
+  }
+
+  /** GET /change-requests: Запросы на изменение */
+  listChangeRequests(params?: {
+    /** Только с этим статусом */
+    status?: string;
+    /** Только запросы к заметкам блокнота */
+    notebookId?: number;
+  }): Promise<ChangeRequest[]> {
+    return this.call("GET", `/api/v1/change-requests`, { query: { "status": params?.status, "notebook_id": params?.notebookId }, response: "json" });
+  }
+
+  /** GET /change-requests/{id}: Получить запрос на изменение */
+  getChangeRequest(id: number): Promise<ChangeRequest> {
+    return this.call("GET", `/api/v1/change-requests/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** POST /change-requests/{id}/approve: Одобрить запрос на изменение */
+  approveChangeRequest(id: number): Promise<ChangeRequest> {
+    return this.call("POST", `/api/v1/change-requests/${encodeURIComponent(String(id))}/approve`, { response: "json" });
+  }
+
+  /** GET /change-requests/{id}/diff: Что изменит запрос */
+  getChangeDiff(id: number): Promise<ChangeDiff> {
+    return this.call("GET", `/api/v1/change-requests/${encodeURIComponent(String(id))}/diff`, { response: "json" });
+  }
+
+  /** POST /change-requests/{id}/reject: Отклонить запрос на изменение */
+  rejectChangeRequest(id: number, body: RejectChangeRequest): Promise<ChangeRequest> {
+    return this.call("POST", `/api/v1/change-requests/${encodeURIComponent(String(id))}/reject`, { body, response: "json" });
+  }
+
+  /** POST /clip: Сохранить веб-страницу как заметку */
+  clipPage(body: ClipRequest): Promise<Note> {
+    return this.call("POST", `/api/v1/clip`, { body, response: "json" });
+  }
+
+  /** GET /collections: Список коллекций */
+  listCollections(): Promise<Collection[]> {
+    return this.call("GET", `/api/v1/collections`, { response: "json" });
+  }
+
+  /** POST /collections: Создать коллекцию */
+  createCollection(body: CollectionCreate): Promise<Collection> {
+    return this.call("POST", `/api/v1/collections`, { body, response: "json" });
+  }
+
+  /** DELETE /collections/{id}: Удалить коллекцию */
+  deleteCollection(id: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/collections/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** GET /collections/{id}: Получить коллекцию */
+  getCollection(id: number): Promise<Collection> {
+    return this.call("GET", `/api/v1/collections/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** PATCH /collections/{id}: Изменить коллекцию */
+  patchCollection(id: number, body: CollectionUpdate): Promise<Collection> {
+    return this.call("PATCH", `/api/v1/collections/${encodeURIComponent(String(id))}`, { body, response: "json" });
+  }
+
+  /** GET /collections/{id}/notes: Заметки коллекции */
+  listCollectionNotes(id: number): Promise<Note[]> {
+    return this.call("GET", `/api/v1/collections/${encodeURIComponent(String(id))}/notes`, { response: "json" });
+  }
+
+  /** GET /dashboard: Дашборд */
+  getDashboard(): Promise<Dashboard> {
+    return this.call("GET", `/api/v1/dashboard`, { response: "json" });
+  }
+
+  /** GET /debug/echo: Запрос глазами сервера */
+  debugEcho(): Promise<EchoResponse> {
+    return this.call("GET", `/api/v1/debug/echo`, { response: "json" });
+  }
+
+  /** GET /emoji: Эмодзи */
+  listEmoji(): Promise<EmojiList> {
+    return this.call("GET", `/api/v1/emoji`, { response: "json" });
+  }
+
+  /** DELETE /emoji/{name}: Удалить своё эмодзи */
+  deleteEmoji(name: string): Promise<void> {
+    return this.call("DELETE", `/api/v1/emoji/${encodeURIComponent(String(name))}`, { response: "none" });
+  }
+
+  /** PUT /emoji/{name}: Добавить своё эмодзи */
+  putEmoji(name: string, body: PutEmojiRequest): Promise<CustomEmoji> {
+    return this.call("PUT", `/api/v1/emoji/${encodeURIComponent(String(name))}`, { body, response: "json" });
+  }
+
+  /** GET /errors: Коды ошибок */
+  listErrorCodes(): Promise<ErrorCode[]> {
+    return this.call("GET", `/api/v1/errors`, { response: "json" });
+  }
+
+  /** GET /events: Поток изменений заметок (SSE) */
+  streamEvents(): Promise<Event> {
+    return this.call("GET", `/api/v1/events`, { response: "json" });
+  }
+
+  /** POST /export: Экспорт в фоне */
+  createExportJob(params?: {
+    /** Формат файла */
+    format?: string;
+  }): Promise<JobResponse> {
+    return this.call("POST", `/api/v1/export`, { query: { "format": params?.format }, response: "json" });
+  }
+
+  /** GET /export/vault: Экспорт в формате Obsidian @deprecated */
+  exportVault(): Promise<Blob> {
+    return this.call("GET", `/api/v1/export/vault`, { response: "blob" });
+  }
+
+  /** POST /import/jobs: Импорт в фоне */
+  createImportJob(body: Blob | ArrayBuffer | Uint8Array, params?: {
+    /** Формат файла */
+    format?: string;
+    /** Родительский блокнот для импорта */
+    notebookId?: number;
+  }): Promise<JobResponse> {
+    return this.call("POST", `/api/v1/import/jobs`, { body, contentType: "application/octet-stream", query: { "format": params?.format, "notebook_id": params?.notebookId }, response: "json" });
+  }
+
+  /** POST /import/vault: Импорт из формата Obsidian */
+  importVault(body: Blob | ArrayBuffer | Uint8Array, params?: {
+    /** Родительский блокнот для импорта */
+    notebookId?: number;
+    /** Только разобрать архив: ответ 200 с тем, что было бы создано (или Prefer: dry-run) */
+    dryRun?: boolean;
+  }): Promise<VaultImportResponse> {
+    return this.call("POST", `/api/v1/import/vault`, { body, contentType: "application/zip", query: { "notebook_id": params?.notebookId, "dry_run": params?.dryRun }, response: "json" });
+  }
+
+  /** GET /inbound-hooks: Входящие вебхуки пользователя */
+  listInboundHooks(): Promise<InboundHook[]> {
+    return this.call("GET", `/api/v1/inbound-hooks`, { response: "json" });
+  }
+
+  /** POST /inbound-hooks: Создать входящий вебхук */
+  createInboundHook(body: InboundHookInput): Promise<InboundHook> {
+    return this.call("POST", `/api/v1/inbound-hooks`, { body, response: "json" });
+  }
+
+  /** DELETE /inbound-hooks/{id}: Удалить входящий вебхук */
+  deleteInboundHook(id: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/inbound-hooks/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** POST /inbound/{token}: Создать заметку входящим вебхуком */
+  receiveInbound(token: string, body: string | Blob | ArrayBuffer | Uint8Array, contentType: string): Promise<InboundResult> {
+    return this.call("POST", `/api/v1/inbound/${encodeURIComponent(String(token))}`, { body, contentType, response: "json" });
+  }
+
+  /** GET /jobs/{id}: Состояние фоновой задачи */
+  getJob(id: number): Promise<JobResponse> {
+    return this.call("GET", `/api/v1/jobs/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** GET /jobs/{id}/result: Результат фоновой задачи */
+  getJobResult(id: number): Promise<Blob> {
+    return this.call("GET", `/api/v1/jobs/${encodeURIComponent(String(id))}/result`, { response: "blob" });
+  }
+
+  /** GET /journal: Лента дневника */
+  listJournal(params?: {
+    /** С даты включительно (YYYY-MM-DD) */
+    from?: string;
+    /** По дату включительно (YYYY-MM-DD) */
+    to?: string;
+  }): Promise<Note[]> {
+    return this.call("GET", `/api/v1/journal`, { query: { "from": params?.from, "to": params?.to }, response: "json" });
+  }
+
+  /** GET /journal/{date}: Запись дневника за день */
+  getJournal(date: string): Promise<Note> {
+    return this.call("GET", `/api/v1/journal/${encodeURIComponent(String(date))}`, { response: "json" });
+  }
+
+  /** POST /journal/{date}: Получить или создать запись дневника */
+  createJournal(date: string): Promise<Note> {
+    return this.call("POST", `/api/v1/journal/${encodeURIComponent(String(date))}`, { response: "json" });
+  }
+
+  /** GET /lifecycle: Жизненный цикл заметок */
+  getLifecycle(): Promise<Lifecycle> {
+    return this.call("GET", `/api/v1/lifecycle`, { response: "json" });
+  }
+
+  /** GET /me/preferences: Мои настройки */
+  getPreferences(): Promise<Preferences> {
+    return this.call("GET", `/api/v1/me/preferences`, { response: "json" });
+  }
+
+  /** PATCH /me/preferences: Изменить мои настройки */
+  patchPreferences(body: PreferencesUpdate): Promise<Preferences> {
+    return this.call("PATCH", `/api/v1/me/preferences`, { body, response: "json" });
+  }
+
+  /** GET /note-templates: Шаблоны заметок пользователя */
+  listNoteTemplates(): Promise<NoteTemplate[]> {
+    return this.call("GET", `/api/v1/note-templates`, { response: "json" });
+  }
+
+  /** POST /note-templates: Создать шаблон заметки */
+  createNoteTemplate(body: NoteTemplateInput): Promise<NoteTemplate> {
+    return this.call("POST", `/api/v1/note-templates`, { body, response: "json" });
+  }
+
+  /** DELETE /note-templates/{id}: Удалить шаблон заметки */
+  deleteNoteTemplate(id: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/note-templates/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** GET /note-templates/{id}: Шаблон заметки */
+  getNoteTemplate(id: number): Promise<NoteTemplate> {
+    return this.call("GET", `/api/v1/note-templates/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** PUT /note-templates/{id}: Заменить шаблон заметки */
+  putNoteTemplate(id: number, body: NoteTemplateInput): Promise<NoteTemplate> {
+    return this.call("PUT", `/api/v1/note-templates/${encodeURIComponent(String(id))}`, { body, response: "json" });
+  }
+
+  /** POST /note-templates/{id}/notes: Создать заметку по шаблону */
+  createNoteFromTemplate(id: number): Promise<Note> {
+    return this.call("POST", `/api/v1/note-templates/${encodeURIComponent(String(id))}/notes`, { response: "json" });
+  }
+
+  /** GET /notebooks: Список блокнотов */
+  listNotebooks(): Promise<Notebook[]> {
+    return this.call("GET", `/api/v1/notebooks`, { response: "json" });
+  }
+
+  /** POST /notebooks: Создать блокнот */
+  createNotebook(body: NotebookCreate): Promise<Notebook> {
+    return this.call("POST", `/api/v1/notebooks`, { body, response: "json" });
+  }
+
+  /** DELETE /notebooks/{id}: Удалить блокнот */
+  deleteNotebook(id: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/notebooks/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** GET /notebooks/{id}: Получить блокнот */
+  getNotebook(id: number): Promise<Notebook> {
+    return this.call("GET", `/api/v1/notebooks/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** PATCH /notebooks/{id}: Переименовать или переместить блокнот */
+  patchNotebook(id: number, body: NotebookUpdate): Promise<Notebook> {
+    return this.call("PATCH", `/api/v1/notebooks/${encodeURIComponent(String(id))}`, { body, response: "json" });
+  }
+
+  /** GET /notebooks/{id}/settings: Настройки блокнота */
+  getNotebookSettings(id: number): Promise<NotebookSettings> {
+    return this.call("GET", `/api/v1/notebooks/${encodeURIComponent(String(id))}/settings`, { response: "json" });
+  }
+
+  /** PUT /notebooks/{id}/settings: Заменить настройки блокнота */
+  putNotebookSettings(id: number, body: NotebookSettings): Promise<NotebookSettings> {
+    return this.call("PUT", `/api/v1/notebooks/${encodeURIComponent(String(id))}/settings`, { body, response: "json" });
+  }
+
+  /** PUT /notebooks/{id}/shares: Открыть доступ к блокноту */
+  shareNotebook(id: number, body: Share): Promise<Notebook> {
+    return this.call("PUT", `/api/v1/notebooks/${encodeURIComponent(String(id))}/shares`, { body, response: "json" });
+  }
+
+  /** DELETE /notebooks/{id}/shares/{grantee}: Закрыть доступ к блокноту */
+  unshareNotebook(id: number, grantee: string): Promise<Notebook> {
+    return this.call("DELETE", `/api/v1/notebooks/${encodeURIComponent(String(id))}/shares/${encodeURIComponent(String(grantee))}`, { response: "json" });
+  }
+
+  /** GET /notes: Список заметок */
+  listNotes(params?: {
+    /** Номер страницы */
+    page?: number;
+    /** Размер страницы; по умолчанию page_size из настроек, без него — все */
+    limit?: number;
+    /** Тип заметки */
+    type?: string;
+    /** Тег, включая вложенные (project → project/alpha) */
+    tag?: string;
+    /** Состояния жизненного цикла через запятую, например draft,active */
+    state?: string;
+    /** Только заметки блокнота, в порядке position */
+    notebookId?: number;
+    /** Поиск на языке запросов (см. GET /search/validate); результаты по релевантности */
+    q?: string;
+    /** Фильтр по свойству, например prop.status=done; числа, даты и булевы значения сравниваются по типу */
+    prop?: Record<string, string>;
+    /** Только заметки с моей реакцией */
+    reacted?: boolean;
+    /** Только заметки с этой реакцией (вместе с reacted — с моей) */
+    reaction?: string;
+    /** Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек */
+    dateFormat?: string;
+    /** Дата из Last-Modified; 304, если изменений не было */
+    ifModifiedSince?: string;
+  }): Promise<Note[]> {
+    return this.call("GET", `/api/v1/notes`, { query: { "page": params?.page, "limit": params?.limit, "type": params?.type, "tag": params?.tag, "state": params?.state, "notebook_id": params?.notebookId, "q": params?.q, ...prefixed("prop.", params?.prop), "reacted": params?.reacted, "reaction": params?.reaction, "date_format": params?.dateFormat }, headers: { "If-Modified-Since": params?.ifModifiedSince }, response: "json" });
+  }
+
+  /** POST /notes: Создать заметку */
+  createNote(body: NoteCreate, params?: {
+    /** Только проверить запрос: ответ 200 с тем, что получилось бы, без сохранения (или Prefer: dry-run) */
+    dryRun?: boolean;
+    /** Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек */
+    dateFormat?: string;
+  }): Promise<Note> {
+    return this.call("POST", `/api/v1/notes`, { body, query: { "dry_run": params?.dryRun, "date_format": params?.dateFormat }, response: "json" });
+  }
+
+  /** POST /notes/copy: Скопировать несколько заметок */
+  bulkCopyNotes(body: BulkMoveRequest, params?: {
+    /** Только проверить: ответ 200 с заметками, какими они стали бы (или Prefer: dry-run) */
+    dryRun?: boolean;
+  }): Promise<Note[]> {
+    return this.call("POST", `/api/v1/notes/copy`, { body, query: { "dry_run": params?.dryRun }, response: "json" });
+  }
+
+  /** GET /notes/count: Количество заметок */
+  countNotes(params?: {
+    /** Тип заметки */
+    type?: string;
+    /** Тег, включая вложенные (project → project/alpha) */
+    tag?: string;
+    /** Состояния жизненного цикла через запятую, например draft,active */
+    state?: string;
+    /** Только заметки блокнота */
+    notebookId?: number;
+    /** Поиск на языке запросов (см. GET /search/validate) */
+    q?: string;
+    /** Фильтр по свойству, например prop.status=done */
+    prop?: Record<string, string>;
+    /** Только заметки с моей реакцией */
+    reacted?: boolean;
+    /** Только заметки с этой реакцией (вместе с reacted — с моей) */
+    reaction?: string;
+  }): Promise<NoteCount> {
+    return this.call("GET", `/api/v1/notes/count`, { query: { "type": params?.type, "tag": params?.tag, "state": params?.state, "notebook_id": params?.notebookId, "q": params?.q, ...prefixed("prop.", params?.prop), "reacted": params?.reacted, "reaction": params?.reaction }, response: "json" });
+  }
+
+  /** GET /notes/favorites: Избранные заметки */
+  listFavorites(params?: {
+    /** Номер страницы */
+    page?: number;
+    /** Размер страницы; по умолчанию page_size из настроек, без него — все */
+    limit?: number;
+  }): Promise<Note[]> {
+    return this.call("GET", `/api/v1/notes/favorites`, { query: { "page": params?.page, "limit": params?.limit }, response: "json" });
+  }
+
+  /** POST /notes/move: Переместить несколько заметок */
+  bulkMoveNotes(body: BulkMoveRequest, params?: {
+    /** Только проверить: ответ 200 с заметками, какими они стали бы (или Prefer: dry-run) */
+    dryRun?: boolean;
+  }): Promise<Note[]> {
+    return this.call("POST", `/api/v1/notes/move`, { body, query: { "dry_run": params?.dryRun }, response: "json" });
+  }
+
+  /** GET /notes/nearby: Заметки рядом с точкой */
+  nearbyNotes(params?: {
+    /** Широта */
+    lat?: number;
+    /** Долгота */
+    lon?: number;
+    /** Радиус в метрах (по умолчанию 1000) */
+    radius?: number;
+  }): Promise<NearbyNote[]> {
+    return this.call("GET", `/api/v1/notes/nearby`, { query: { "lat": params?.lat, "lon": params?.lon, "radius": params?.radius }, response: "json" });
+  }
+
+  /** GET /notes/random: Случайная заметка */
+  randomNote(params?: {
+    /** Тег, включая вложенные */
+    tag?: string;
+    /** Только заметки блокнота */
+    notebookId?: number;
+    /** Тип заметки */
+    type?: string;
+  }): Promise<Note> {
+    return this.call("GET", `/api/v1/notes/random`, { query: { "tag": params?.tag, "notebook_id": params?.notebookId, "type": params?.type }, response: "json" });
+  }
+
+  /** GET /notes/recent: Недавно открытые заметки */
+  listRecentNotes(params?: {
+    /** Номер страницы */
+    page?: number;
+    /** Размер страницы; по умолчанию page_size из настроек, без него — все */
+    limit?: number;
+  }): Promise<Note[]> {
+    return this.call("GET", `/api/v1/notes/recent`, { query: { "page": params?.page, "limit": params?.limit }, response: "json" });
+  }
+
+  /** PATCH /notes/reorder: Изменить порядок заметок в блокноте */
+  reorderNotes(body: ReorderRequest): Promise<Note[]> {
+    return this.call("PATCH", `/api/v1/notes/reorder`, { body, response: "json" });
+  }
+
+  /** GET /notes/slug/{slug}: Получить заметку по slug */
+  getNoteBySlug(slug: string): Promise<Note> {
+    return this.call("GET", `/api/v1/notes/slug/${encodeURIComponent(String(slug))}`, { response: "json" });
+  }
+
+  /** DELETE /notes/{id}: Удалить заметку */
+  deleteNote(id: string): Promise<SuccessResponse | undefined> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** GET /notes/{id}: Получить заметку */
+  getNote(id: string, params?: {
+    /** Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек */
+    dateFormat?: string;
+  }): Promise<Note> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}`, { query: { "date_format": params?.dateFormat }, response: "json" });
+  }
+
+  /** HEAD /notes/{id}: Проверить, есть ли заметка */
+  headNote(id: string): Promise<void> {
+    return this.call("HEAD", `/api/v1/notes/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** PATCH /notes/{id}: Обновить заметку (частично) */
+  patchNote(id: string, body: NoteUpdate, params?: {
+    /** Только проверить запрос: ответ 200 с тем, что получилось бы, без сохранения (или Prefer: dry-run) */
+    dryRun?: boolean;
+    /** Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек */
+    dateFormat?: string;
+  }): Promise<Note> {
+    return this.call("PATCH", `/api/v1/notes/${encodeURIComponent(String(id))}`, { body, query: { "dry_run": params?.dryRun, "date_format": params?.dateFormat }, response: "json" });
+  }
+
+  /** GET /notes/{id}/attachments: Вложения заметки */
+  listAttachments(id: string): Promise<Attachment[]> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/attachments`, { response: "json" });
+  }
+
+  /** DELETE /notes/{id}/attachments/{aid}: Удалить вложение */
+  deleteAttachment(id: string, aid: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/attachments/${encodeURIComponent(String(aid))}`, { response: "none" });
+  }
+
+  /** GET /notes/{id}/attachments/{aid}: Скачать вложение */
+  getAttachment(id: string, aid: number): Promise<Blob> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/attachments/${encodeURIComponent(String(aid))}`, { response: "blob" });
+  }
+
+  /** GET /notes/{id}/comments: Обсуждения заметки */
+  listComments(id: string, params?: {
+    /** Только решённые (true) или нерешённые (false) ветки */
+    resolved?: boolean;
+  }): Promise<CommentThread[]> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/comments`, { query: { "resolved": params?.resolved }, response: "json" });
+  }
+
+  /** POST /notes/{id}/comments: Прокомментировать заметку */
+  createComment(id: string, body: CreateCommentRequest): Promise<Comment> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/comments`, { body, response: "json" });
+  }
+
+  /** DELETE /notes/{id}/comments/{cid}: Удалить комментарий */
+  deleteComment(id: string, cid: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/comments/${encodeURIComponent(String(cid))}`, { response: "none" });
+  }
+
+  /** PATCH /notes/{id}/comments/{cid}: Изменить комментарий или решить ветку */
+  updateComment(id: string, cid: number, body: UpdateCommentRequest): Promise<Comment> {
+    return this.call("PATCH", `/api/v1/notes/${encodeURIComponent(String(id))}/comments/${encodeURIComponent(String(cid))}`, { body, response: "json" });
+  }
+
+  /** POST /notes/{id}/copy: Скопировать заметку в блокнот */
+  copyNote(id: string, body: MoveNoteRequest, params?: {
+    /** Только проверить: ответ 200 с заметкой, какой она стала бы (или Prefer: dry-run) */
+    dryRun?: boolean;
+  }): Promise<Note> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/copy`, { body, query: { "dry_run": params?.dryRun }, response: "json" });
+  }
+
+  /** POST /notes/{id}/email: Отправить заметку по почте */
+  emailNote(id: string, body: EmailNoteRequest): Promise<EmailNoteResponse> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/email`, { body, response: "json" });
+  }
+
+  /** DELETE /notes/{id}/favorite: Убрать заметку из избранного */
+  unfavoriteNote(id: string): Promise<FavoriteResponse> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/favorite`, { response: "json" });
+  }
+
+  /** POST /notes/{id}/favorite: Добавить заметку в избранное */
+  favoriteNote(id: string): Promise<FavoriteResponse> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/favorite`, { response: "json" });
+  }
+
+  /** GET /notes/{id}/highlight: HTML с подсветкой синтаксиса для сниппета */
+  getNoteHighlighted(id: string): Promise<string> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/highlight`, { response: "text" });
+  }
+
+  /** GET /notes/{id}/link-previews: Превью ссылок заметки */
+  getLinkPreviews(id: string): Promise<Preview[]> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/link-previews`, { response: "json" });
+  }
+
+  /** DELETE /notes/{id}/lock: Снять блокировку */
+  unlockNote(id: string): Promise<void> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/lock`, { response: "none" });
+  }
+
+  /** GET /notes/{id}/lock: Кто заблокировал заметку */
+  getNoteLock(id: string): Promise<NoteLock> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/lock`, { response: "json" });
+  }
+
+  /** POST /notes/{id}/lock: Заблокировать заметку для правки */
+  lockNote(id: string): Promise<NoteLock> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/lock`, { response: "json" });
+  }
+
+  /** GET /notes/{id}/markdown: Экспорт заметки в Markdown */
+  getNoteMarkdown(id: string): Promise<string> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/markdown`, { response: "text" });
+  }
+
+  /** POST /notes/{id}/move: Переместить заметку в другой блокнот */
+  moveNote(id: string, body: MoveNoteRequest, params?: {
+    /** Только проверить: ответ 200 с заметкой, какой она стала бы (или Prefer: dry-run) */
+    dryRun?: boolean;
+  }): Promise<Note> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/move`, { body, query: { "dry_run": params?.dryRun }, response: "json" });
+  }
+
+  /** DELETE /notes/{id}/presence: Отметить, что заметка закрыта */
+  leavePresence(id: string): Promise<Viewer[]> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/presence`, { response: "json" });
+  }
+
+  /** GET /notes/{id}/presence: Кто открыл заметку */
+  getPresence(id: string): Promise<Viewer[]> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/presence`, { response: "json" });
+  }
+
+  /** POST /notes/{id}/presence: Отметить, что заметка открыта */
+  updatePresence(id: string, body: PresenceRequest): Promise<Viewer[]> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/presence`, { body, response: "json" });
+  }
+
+  /** GET /notes/{id}/print: Заметка для печати */
+  getNotePrint(id: string, params?: {
+    /** Формат */
+    format?: string;
+    /** Имя шаблона */
+    template?: string;
+  }): Promise<string> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/print`, { query: { "format": params?.format, "template": params?.template }, response: "text" });
+  }
+
+  /** DELETE /notes/{id}/public-link: Отозвать публичную ссылку */
+  deletePublicLink(id: string): Promise<void> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/public-link`, { response: "none" });
+  }
+
+  /** GET /notes/{id}/public-link: Публичная ссылка на заметку */
+  getPublicLink(id: string): Promise<PublicLinkResponse> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/public-link`, { response: "json" });
+  }
+
+  /** POST /notes/{id}/public-link: Опубликовать заметку */
+  createPublicLink(id: string): Promise<PublicLinkResponse> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/public-link`, { response: "json" });
+  }
+
+  /** GET /notes/{id}/public-link/qr.png: QR-код публичной ссылки */
+  getPublicLinkQR(id: string, params?: {
+    /** Пикселей на модуль (1-32) */
+    scale?: number;
+  }): Promise<Blob> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/public-link/qr.png`, { query: { "scale": params?.scale }, response: "blob" });
+  }
+
+  /** DELETE /notes/{id}/reactions: Снять реакцию */
+  removeReaction(id: string, params?: {
+    /** Реакция */
+    emoji?: string;
+  }): Promise<ReactionsResponse> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/reactions`, { query: { "emoji": params?.emoji }, response: "json" });
+  }
+
+  /** POST /notes/{id}/reactions: Поставить реакцию */
+  addReaction(id: string, body: ReactionRequest): Promise<ReactionsResponse> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/reactions`, { body, response: "json" });
+  }
+
+  /** GET /notes/{id}/transitions: Состояние заметки */
+  getNoteTransitions(id: string): Promise<NoteTransitions> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/transitions`, { response: "json" });
+  }
+
+  /** POST /notes/{id}/transitions: Перевести заметку в другое состояние */
+  transitionNote(id: string, body: TransitionRequest): Promise<Note> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/transitions`, { body, response: "json" });
+  }
+
+  /** POST /notes/{id}/uploads: Начать загрузку вложения */
+  startUpload(id: string, body: StartUploadRequest): Promise<Upload> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/uploads`, { body, response: "json" });
+  }
+
+  /** GET /notes/{id}/views: Кто просматривал заметку */
+  getNoteViews(id: string): Promise<NoteView[]> {
+    return this.call("GET", `/api/v1/notes/${encodeURIComponent(String(id))}/views`, { response: "json" });
+  }
+
+  /** DELETE /notes/{id}/watch: Перестать следить за заметкой */
+  unwatchNote(id: string): Promise<WatchResponse> {
+    return this.call("DELETE", `/api/v1/notes/${encodeURIComponent(String(id))}/watch`, { response: "json" });
+  }
+
+  /** POST /notes/{id}/watch: Следить за заметкой */
+  watchNote(id: string): Promise<WatchResponse> {
+    return this.call("POST", `/api/v1/notes/${encodeURIComponent(String(id))}/watch`, { response: "json" });
+  }
+
+  /** GET /notifications: Мои уведомления */
+  listNotifications(params?: {
+    /** Только непрочитанные */
+    unread?: boolean;
+  }): Promise<Notification[]> {
+    return this.call("GET", `/api/v1/notifications`, { query: { "unread": params?.unread }, response: "json" });
+  }
+
+  /** POST /notifications/read: Отметить уведомления прочитанными */
+  markNotificationsRead(body: MarkReadRequest): Promise<MarkReadResponse> {
+    return this.call("POST", `/api/v1/notifications/read`, { body, response: "json" });
+  }
+
+  /** GET /review/next: Следующая заметка на повторение */
+  nextReview(params?: {
+    /** Только заметки с тегом, включая вложенные */
+    tag?: string;
+  }): Promise<ReviewCard | undefined> {
+    return this.call("GET", `/api/v1/review/next`, { query: { "tag": params?.tag }, response: "json" });
+  }
+
+  /** POST /review/{id}/done: Отметить повторение заметки */
+  reviewDone(id: string, body: ReviewDoneRequest): Promise<ReviewState> {
+    return this.call("POST", `/api/v1/review/${encodeURIComponent(String(id))}/done`, { body, response: "json" });
+  }
+
+  /** GET /rules: Правила пользователя */
+  listRules(): Promise<Rule[]> {
+    return this.call("GET", `/api/v1/rules`, { response: "json" });
+  }
+
+  /** POST /rules: Создать правило */
+  createRule(body: RuleInput): Promise<Rule> {
+    return this.call("POST", `/api/v1/rules`, { body, response: "json" });
+  }
+
+  /** DELETE /rules/{id}: Удалить правило */
+  deleteRule(id: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/rules/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** GET /rules/{id}: Получить правило */
+  getRule(id: number): Promise<Rule> {
+    return this.call("GET", `/api/v1/rules/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** PUT /rules/{id}: Заменить правило */
+  putRule(id: number, body: RuleInput): Promise<Rule> {
+    return this.call("PUT", `/api/v1/rules/${encodeURIComponent(String(id))}`, { body, response: "json" });
+  }
+
+  /** GET /search: Поиск по всем заметкам */
+  searchNotes(params?: {
+    /** Запрос, например tag:work AND created:>2024-01-01 AND \ */
+    q?: string;
+    /** Номер страницы */
+    page?: number;
+    /** Размер страницы; по умолчанию page_size из настроек, без него — все */
+    limit?: number;
+    /** Тип заметки */
+    type?: string;
+    /** Тег, включая вложенные (project → project/alpha) */
+    tag?: string;
+    /** Состояния жизненного цикла через запятую */
+    state?: string;
+    /** Только заметки блокнота */
+    notebookId?: number;
+    /** Фильтр по свойству, например prop.status=done */
+    prop?: Record<string, string>;
+  }): Promise<SearchResults> {
+    return this.call("GET", `/api/v1/search`, { query: { "q": params?.q, "page": params?.page, "limit": params?.limit, "type": params?.type, "tag": params?.tag, "state": params?.state, "notebook_id": params?.notebookId, ...prefixed("prop.", params?.prop) }, response: "json" });
+  }
+
+  /** GET /search/typeahead: Подсказки при вводе */
+  typeahead(params?: {
+    /** Начало заголовка */
+    q?: string;
+    /** Сколько подсказок вернуть (по умолчанию 8, не больше 20) */
+    limit?: number;
+  }): Promise<TypeaheadHit[]> {
+    return this.call("GET", `/api/v1/search/typeahead`, { query: { "q": params?.q, "limit": params?.limit }, response: "json" });
+  }
+
+  /** GET /search/validate: Проверить поисковый запрос */
+  validateSearchQuery(params?: {
+    /** Запрос */
+    q?: string;
+  }): Promise<QueryValidation> {
+    return this.call("GET", `/api/v1/search/validate`, { query: { "q": params?.q }, response: "json" });
+  }
+
+  /** GET /stats/activity: Активность по дням */
+  activityStats(params?: {
+    /** Сколько дней, включая сегодня (по умолчанию 30, не больше 366) */
+    days?: number;
+    /** Часовой пояс IANA */
+    tz?: string;
+  }): Promise<ActivityDay[]> {
+    return this.call("GET", `/api/v1/stats/activity`, { query: { "days": params?.days, "tz": params?.tz }, response: "json" });
+  }
+
+  /** GET /stats/storage: Занятое место */
+  storageStats(): Promise<StorageStats> {
+    return this.call("GET", `/api/v1/stats/storage`, { response: "json" });
+  }
+
+  /** POST /sync/run: Запустить синхронизацию */
+  runSync(): Promise<Status> {
+    return this.call("POST", `/api/v1/sync/run`, { response: "json" });
+  }
+
+  /** GET /sync/status: Состояние синхронизации */
+  syncStatus(): Promise<Status> {
+    return this.call("GET", `/api/v1/sync/status`, { response: "json" });
+  }
+
+  /** GET /tags: Список тегов */
+  listTags(): Promise<TagCount[]> {
+    return this.call("GET", `/api/v1/tags`, { response: "json" });
+  }
+
+  /** POST /tags/merge: Слить теги */
+  mergeTags(body: MergeTagsRequest): Promise<TagChangeResponse> {
+    return this.call("POST", `/api/v1/tags/merge`, { body, response: "json" });
+  }
+
+  /** GET /tags/tree: Дерево тегов */
+  tagTree(): Promise<TagNode[]> {
+    return this.call("GET", `/api/v1/tags/tree`, { response: "json" });
+  }
+
+  /** PATCH /tags/{name}: Переименовать тег */
+  renameTag(name: string, body: RenameTagRequest): Promise<TagChangeResponse> {
+    return this.call("PATCH", `/api/v1/tags/${encodeURIComponent(String(name))}`, { body, response: "json" });
+  }
+
+  /** POST /tags/{name}/apply: Добавить тег заметкам по фильтру */
+  applyTag(name: string, body: BulkTagRequest): Promise<JobResponse> {
+    return this.call("POST", `/api/v1/tags/${encodeURIComponent(String(name))}/apply`, { body, response: "json" });
+  }
+
+  /** POST /tags/{name}/remove: Снять тег с заметок по фильтру */
+  removeTag(name: string, body: BulkTagRequest): Promise<JobResponse> {
+    return this.call("POST", `/api/v1/tags/${encodeURIComponent(String(name))}/remove`, { body, response: "json" });
+  }
+
+  /** POST /undo/{token}: Отменить операцию */
+  undoOperation(token: string): Promise<UndoResponse> {
+    return this.call("POST", `/api/v1/undo/${encodeURIComponent(String(token))}`, { response: "json" });
+  }
+
+  /** DELETE /uploads/{id}: Отменить загрузку */
+  cancelUpload(id: string): Promise<void> {
+    return this.call("DELETE", `/api/v1/uploads/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** GET /uploads/{id}: Состояние загрузки */
+  getUpload(id: string): Promise<Upload> {
+    return this.call("GET", `/api/v1/uploads/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** PATCH /uploads/{id}: Отправить кусок файла */
+  writeUpload(id: string, body: Blob | ArrayBuffer | Uint8Array, params?: {
+    /** Смещение куска */
+    uploadOffset?: number;
+  }): Promise<Attachment | undefined> {
+    return this.call("PATCH", `/api/v1/uploads/${encodeURIComponent(String(id))}`, { body, contentType: "application/octet-stream", headers: { "Upload-Offset": params?.uploadOffset }, response: "json" });
+  }
+
+  /** GET /views: Представления пользователя */
+  listSavedViews(): Promise<SavedView[]> {
+    return this.call("GET", `/api/v1/views`, { response: "json" });
+  }
+
+  /** POST /views: Сохранить представление */
+  createSavedView(body: SavedViewInput): Promise<SavedView> {
+    return this.call("POST", `/api/v1/views`, { body, response: "json" });
+  }
+
+  /** DELETE /views/{id}: Удалить представление */
+  deleteSavedView(id: number): Promise<void> {
+    return this.call("DELETE", `/api/v1/views/${encodeURIComponent(String(id))}`, { response: "none" });
+  }
+
+  /** GET /views/{id}: Представление */
+  getSavedView(id: number): Promise<SavedView> {
+    return this.call("GET", `/api/v1/views/${encodeURIComponent(String(id))}`, { response: "json" });
+  }
+
+  /** PUT /views/{id}: Заменить представление */
+  putSavedView(id: number, body: SavedViewInput): Promise<SavedView> {
+    return this.call("PUT", `/api/v1/views/${encodeURIComponent(String(id))}`, { body, response: "json" });
+  }
+
+  /** GET /views/{id}/notes: Заметки представления */
+  savedViewNotes(id: number, params?: {
+    /** Номер страницы */
+    page?: number;
+    /** Размер страницы; по умолчанию page_size из настроек, без него — все */
+    limit?: number;
+  }): Promise<Note[]> {
+    return this.call("GET", `/api/v1/views/${encodeURIComponent(String(id))}/notes`, { query: { "page": params?.page, "limit": params?.limit }, response: "json" });
+  }
+
+  /** POST /zapier/actions/append_to_note: Действие: дописать текст в заметку */
+  zapierAppendToNote(body: ZapierAppendRequest): Promise<ZapierNote> {
+    return this.call("POST", `/api/v1/zapier/actions/append_to_note`, { body, response: "json" });
+  }
+
+  /** POST /zapier/actions/create_note: Действие: создать заметку */
+  zapierCreateNote(body: ZapierCreateRequest): Promise<ZapierNote> {
+    return this.call("POST", `/api/v1/zapier/actions/create_note`, { body, response: "json" });
+  }
+
+  /** GET /zapier/me: Проверка ключа для Zapier/IFTTT */
+  zapierMe(): Promise<ZapierMe> {
+    return this.call("GET", `/api/v1/zapier/me`, { response: "json" });
+  }
+
+  /** GET /zapier/triggers/new_note: Триггер: новая заметка */
+  zapierNewNotes(params?: {
+    /** Только из блокнота */
+    notebookId?: number;
+  }): Promise<ZapierNote[]> {
+    return this.call("GET", `/api/v1/zapier/triggers/new_note`, { query: { "notebook_id": params?.notebookId }, response: "json" });
+  }
+
+  /** GET /zapier/triggers/new_tagged_note: Триггер: заметка получила тег */
+  zapierNewTaggedNotes(params?: {
+    /** Тег (включая вложенные) */
+    tag?: string;
+    /** Только из блокнота */
+    notebookId?: number;
+  }): Promise<ZapierNote[]> {
+    return this.call("GET", `/api/v1/zapier/triggers/new_tagged_note`, { query: { "tag": params?.tag, "notebook_id": params?.notebookId }, response: "json" });
+  }
+}
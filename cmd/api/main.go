@@ -2,27 +2,392 @@
 //
 // @title           Notes API
 // @version         1.0
-// @description     Учебный REST API для заметок (CRUD).
+// @description     Учебный REST API для заметок (CRUD). С ?envelope=true JSON-ответы приходят в обёртке {data, meta, errors}: статус, пагинация и заголовки — в meta, для клиентов, которые не читают заголовки.
 // @contact.name    Backend Course
 // @contact.email   example@university.ru
 // @BasePath        /api/v1
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"flag"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 
+	"example.com/notes-api/internal/attach"
+	"example.com/notes-api/internal/audit"
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/blob"
+	"example.com/notes-api/internal/cache"
+	"example.com/notes-api/internal/cloudsync"
+	"example.com/notes-api/internal/config"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/digest"
+	"example.com/notes-api/internal/events"
+	"example.com/notes-api/internal/faults"
+	"example.com/notes-api/internal/health"
 	httpx "example.com/notes-api/internal/http"
 	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/mailer"
+	"example.com/notes-api/internal/metrics"
+	"example.com/notes-api/internal/nats"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/ocr"
+	"example.com/notes-api/internal/pdf"
+	"example.com/notes-api/internal/preview"
+	"example.com/notes-api/internal/ratelimit"
+	"example.com/notes-api/internal/record"
+	"example.com/notes-api/internal/redis"
+	"example.com/notes-api/internal/render"
 	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/sandbox"
+	"example.com/notes-api/internal/search"
+	"example.com/notes-api/internal/transcribe"
+	"example.com/notes-api/internal/undo"
 )
 
 func main() {
-	repo := repo.NewNoteRepoMem()
-	h := &handlers.Handler{Repo: repo}
-	r := httpx.NewRouter(h)
+	cfg := config.Load()
+	flag.BoolVar(&cfg.Sandbox, "sandbox", cfg.Sandbox, "serve deterministic demo data that resets on restart, without rate limits")
+	flag.Parse()
+	if cfg.Sandbox {
+		cfg.SandboxOverrides()
+	}
+
+	policy := handlers.V1Policy
+	if cfg.LegacyDelete {
+		policy.DeleteStatus = http.StatusOK
+	}
+
+	journal := handlers.DefaultJournalTemplate
+	if cfg.JournalTitleTemplate != "" {
+		journal.Title = cfg.JournalTitleTemplate
+	}
+	if cfg.JournalContentTemplate != "" {
+		journal.Content = cfg.JournalContentTemplate
+	}
+
+	tokens, err := auth.ParseTokens(cfg.AuthTokens)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	notes := repo.NewNoteRepoMem()
+	h := &handlers.Handler{
+		Repo:            notes,
+		Notebooks:       repo.NewNotebookRepoMem(),
+		Collections:     repo.NewCollectionRepoMem(),
+		Policy:          policy,
+		JournalTemplate: journal,
+		BaseURL:         cfg.BaseURL,
+	}
+	notes.UniqueTitles = func(notebookID int64) bool {
+		return cfg.UniqueTitles || h.Notebooks.UniqueTitles(notebookID)
+	}
+	if notes.Lifecycle, err = core.ParseLifecycle(cfg.Lifecycle); err != nil {
+		log.Fatalf("NOTES_LIFECYCLE: %v", err)
+	}
+
+	if cfg.PDFFont != "" {
+		if h.PDFFont, err = pdf.LoadFont(cfg.PDFFont); err != nil {
+			log.Fatalf("NOTES_PDF_FONT: %v", err)
+		}
+	}
+
+	var redisClient *redis.Client
+	if cfg.RedisAddr != "" {
+		redisClient = redis.New(cfg.RedisAddr, cfg.RedisPassword)
+	}
+
+	var natsClient *nats.Client
+	if cfg.NATSURL != "" {
+		natsClient = nats.New(cfg.NATSURL)
+	}
+
+	var broker events.Broker
+	switch cfg.EventsBroker {
+	case "memory":
+		broker = events.NewMemoryBroker()
+	case "redis":
+		if redisClient == nil {
+			log.Fatal("NOTES_EVENTS_BROKER=redis requires NOTES_REDIS_ADDR")
+		}
+		broker = events.RedisBroker{Client: redisClient, Channel: "notes:events"}
+	case "nats":
+		if natsClient == nil {
+			log.Fatal("NOTES_EVENTS_BROKER=nats requires NOTES_NATS_URL")
+		}
+		broker = events.NATSBroker{Client: natsClient, Subject: "notes.internal.events"}
+	default:
+		log.Fatalf("unknown events broker %q", cfg.EventsBroker)
+	}
+	h.Events = events.NewHub(broker)
+	h.Events.Run(context.Background())
+	h.Presence = events.NewPresence()
+	h.Presence.Follow(context.Background(), h.Events)
+	notes.OnChange(func(c repo.Change) { h.Events.Publish(events.FromChange(c)) })
+
+	h.Search = search.NewService()
+	var ok bool
+	if h.Search.Analyzer, ok = search.AnalyzerByName(cfg.SearchLanguage); !ok {
+		log.Fatalf("NOTES_SEARCH_LANGUAGE: unknown analyzer %q", cfg.SearchLanguage)
+	}
+	notes.OnChange(h.Search.Apply)
+	h.Titles = search.NewTitles()
+	notes.OnChange(h.Titles.Apply)
+
+	h.Reads = &cache.Group{}
+	h.ListCache = cache.NewLists(cfg.ListCacheTTL)
+	notes.OnChange(func(c repo.Change) { h.ListCache.Invalidate(c.Note) })
+	h.Notebooks.OnChange(h.ListCache.Flush)
+
+	h.LastModified = repo.NewLastModified()
+	notes.OnChange(h.LastModified.Apply)
+	h.Notebooks.OnChange(h.LastModified.Touch)
+
+	h.CDC = repo.NewChangeLog(10000)
+	notes.OnChange(h.CDC.Append)
+
+	h.Views = repo.NewViewLog()
+	notes.OnChange(h.Views.Apply)
+
+	h.Storage = repo.NewStorageUsage()
+	notes.OnChange(h.Storage.Apply)
+
+	h.Jobs = jobs.NewQueue(2)
+	h.Jobs.OnFinish = h.NotifyJob
+	h.Jobs.Run(context.Background())
+
+	h.Blobs = blob.NewMem()
+	if cfg.BlobDir != "" {
+		h.Blobs = blob.Dir{Root: cfg.BlobDir}
+	}
+	h.Downloads.Key = []byte(cfg.DownloadSecret)
+	if cfg.DownloadSecret == "" {
+		h.Downloads.Key = make([]byte, 32)
+		rand.Read(h.Downloads.Key)
+	}
+	h.ExportTTL = cfg.ExportTTL
+
+	h.Audit = audit.NewLog(10000)
+	h.Attachments = attach.NewStore(h.Blobs)
+	h.Attachments.Audit = h.Audit
+	scanners := attach.Chain{attach.Sniffer{}}
+	if cfg.ClamAVAddr != "" {
+		scanners = append(scanners, attach.ClamAV{Addr: cfg.ClamAVAddr})
+	}
+	h.Attachments.Scanner = scanners
+	h.Attachments.OnReady = h.RecognizeAttachment
+	if cfg.OCRURL != "" {
+		h.OCR = ocr.Tesseract{URL: cfg.OCRURL, Languages: strings.Split(cfg.OCRLanguages, ",")}
+	}
+	if cfg.TranscriptionURL != "" {
+		h.Transcriber = transcribe.Whisper{URL: cfg.TranscriptionURL, APIKey: cfg.TranscriptionKey, Model: cfg.TranscriptionModel}
+	}
+
+	if cfg.LinkPreviews {
+		h.Previews = preview.NewService()
+		h.Previews.Run(context.Background(), 4)
+		notes.OnChange(h.Previews.Apply)
+	}
+	h.PrintTemplates = render.NewTemplates()
+	h.Emoji = render.NewEmoji()
+	h.NoteTemplates = repo.NewTemplateRepoMem()
+	h.StartNoteTemplates(context.Background(), time.Minute)
+	h.SavedViews = repo.NewSavedViewRepoMem()
+	h.Reviews = repo.NewReviewRepoMem()
+	h.InboundHooks = repo.NewInboundHookRepoMem()
+	h.Rules = repo.NewRuleRepoMem()
+	h.ChangeRequests = repo.NewChangeRequestRepoMem()
+	h.Comments = repo.NewCommentRepoMem()
+	notes.OnChange(h.Comments.Apply)
+	h.Favorites = repo.NewFavoriteRepoMem()
+	h.Recent = repo.NewRecentRepoMem()
+	notes.OnChange(h.Recent.Apply)
+	notes.OnChange(h.Favorites.Apply)
+	notes.OnChange(h.RunRules)
+	h.StartReminders(context.Background(), time.Minute)
+	h.StartRetention(context.Background(), time.Hour)
+	h.DebugEchoOpen = cfg.DebugEcho
+	if cfg.UndoWindow > 0 {
+		h.Undo = undo.NewBuffer(cfg.UndoWindow)
+	}
+	if cfg.LockTTL > 0 {
+		h.Locks = repo.NewLockRepoMem()
+		h.Locks.TTL = cfg.LockTTL
+		notes.Locks = h.Locks
+	}
+	if cfg.Clipping {
+		h.Clipper = &preview.Fetcher{}
+	}
+	notes.OnChange(h.Attachments.Apply)
+
+	h.Notifications = notify.NewInbox()
+	h.Watches = notify.NewWatches()
+	h.Preferences = repo.NewPreferenceRepoMem()
+
+	var sink events.Sink
+	switch cfg.EventsSink {
+	case "":
+	case "nats":
+		if natsClient == nil {
+			log.Fatal("NOTES_EVENTS_SINK=nats requires NOTES_NATS_URL")
+		}
+		sink = events.NATSSink{Client: natsClient, Prefix: cfg.EventsTopic}
+	case "kafka":
+		if cfg.KafkaRESTURL == "" {
+			log.Fatal("NOTES_EVENTS_SINK=kafka requires NOTES_KAFKA_REST_URL")
+		}
+		sink = events.KafkaRESTSink{URL: strings.TrimRight(cfg.KafkaRESTURL, "/"), Topic: cfg.EventsTopic}
+	default:
+		log.Fatalf("unknown events sink %q", cfg.EventsSink)
+	}
+	if sink != nil {
+		forwarder := events.NewForwarder(sink)
+		forwarder.Run(context.Background())
+		notes.OnChange(func(c repo.Change) { forwarder.Publish(events.FromChange(c)) })
+	}
+
+	if cfg.SyncDir != "" {
+		principal := auth.Anonymous
+		if cfg.SyncUser != auth.Anonymous.UserID {
+			principal = core.Principal{UserID: cfg.SyncUser}
+		}
+		h.Sync = &cloudsync.Syncer{
+			Notes:     h.Repo,
+			Notebooks: h.Notebooks,
+			Remote:    cloudsync.DirRemote{Root: cfg.SyncDir},
+			Principal: principal,
+		}
+		h.Sync.Start(context.Background(), cfg.SyncInterval)
+	}
+
+	var mail mailer.Mailer
+	if cfg.SMTPAddr != "" {
+		mail = mailer.SMTP{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.MailFrom,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}
+	}
+
+	recipients, err := digest.ParseRecipients(cfg.DigestRecipients)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(recipients) > 0 && mail == nil {
+		log.Fatal("NOTES_DIGEST_RECIPIENTS requires NOTES_SMTP_ADDR")
+	}
+	if mail != nil {
+		h.Mailer = mail
+		sender := &digest.Sender{Notes: h.Repo, Mailer: mail, Recipients: recipients, Preferences: h.Preferences}
+		sender.Start(context.Background(), 15*time.Minute)
+	}
+
+	monitor := health.NewMonitor(cfg.HealthInterval)
+	var backends []string
+	if redisClient != nil {
+		monitor.Add("redis", redisClient.Ping)
+		backends = append(backends, "redis")
+	}
+	if natsClient != nil {
+		monitor.Add("nats", natsClient.Ping)
+		backends = append(backends, "nats")
+	}
+	monitor.Start(context.Background())
+
+	opts := httpx.Options{
+		Tokens:  tokens,
+		Metrics: metrics.New(cfg.SLOLatency, cfg.SLOObjective),
+		Ready:   monitor,
+	}
+	h.Metrics = opts.Metrics
+
+	for _, name := range backends {
+		opts.Metrics.GaugeFunc(`backend_up{backend="`+name+`"}`, "Whether the last health check of a backend passed.",
+			func() float64 {
+				if monitor.Up(name) {
+					return 1
+				}
+				return 0
+			})
+	}
+	if redisClient != nil {
+		opts.Metrics.CounterFunc("redis_pool_acquires_total", "Connections taken from the Redis pool.",
+			func() float64 { return float64(redisClient.Stats().Acquires) })
+		opts.Metrics.CounterFunc("redis_pool_acquire_seconds_total", "Time spent taking connections from the Redis pool, including dials.",
+			func() float64 { return redisClient.Stats().AcquireWait.Seconds() })
+		opts.Metrics.CounterFunc("redis_pool_dials_total", "New Redis connections dialed.",
+			func() float64 { return float64(redisClient.Stats().Dials) })
+		opts.Metrics.GaugeFunc("redis_pool_idle_connections", "Idle connections in the Redis pool.",
+			func() float64 { return float64(redisClient.Stats().Idle) })
+	}
+
+	opts.Metrics.CounterFunc("notes_list_cache_hits_total", "Note lists served from the cache.",
+		func() float64 { return float64(h.ListCache.Stats().Hits) })
+	opts.Metrics.CounterFunc("notes_list_cache_misses_total", "Note lists computed from the repository.",
+		func() float64 { return float64(h.ListCache.Stats().Misses) })
+	opts.Metrics.CounterFunc("notes_list_cache_coalesced_total", "Note list requests that waited for an identical request in flight.",
+		func() float64 { return float64(h.ListCache.Stats().Coalesced) })
+	opts.Metrics.CounterFunc("notes_read_coalesced_total", "Note reads that waited for an identical read in flight.",
+		func() float64 { return float64(h.Reads.Coalesced()) })
+	opts.Metrics.GaugeFunc("notes_list_cache_entries", "Note lists currently cached.",
+		func() float64 { return float64(h.ListCache.Stats().Entries) })
+
+	if opts.RateLimits.Free, err = ratelimit.ParseLimit(cfg.RateLimitFree); err != nil {
+		log.Fatal(err)
+	}
+	if opts.RateLimits.Admin, err = ratelimit.ParseLimit(cfg.RateLimitAdmin); err != nil {
+		log.Fatal(err)
+	}
+	var limiter ratelimit.Limiter = ratelimit.NewMemory()
+	if redisClient != nil {
+		limiter = ratelimit.NewRedis(redisClient)
+	}
+	if !opts.RateLimits.Free.Unlimited() || !opts.RateLimits.Admin.Unlimited() {
+		opts.RateLimiter = limiter
+	}
+	if h.EmailLimit, err = ratelimit.ParseLimit(cfg.EmailLimit); err != nil {
+		log.Fatal(err)
+	}
+	h.EmailLimiter = limiter
+
+	rules, err := faults.Parse(cfg.Faults)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(rules) > 0 {
+		log.Printf("WARNING: injecting faults into requests (NOTES_FAULTS=%q)", cfg.Faults)
+		opts.Faults = faults.NewInjector(rules)
+	}
+
+	if cfg.RecordFile != "" {
+		if opts.Recorder, err = record.Open(cfg.RecordFile); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("recording requests to %s", cfg.RecordFile)
+	}
+
+	if cfg.Sandbox {
+		users := tokens.Users()
+		if len(users) == 0 {
+			users = []string{auth.Anonymous.UserID}
+		}
+		err := sandbox.Fill(sandbox.Repos{Notes: notes, Notebooks: h.Notebooks, Collections: h.Collections}, users, 1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("sandbox: serving demo data for %s; changes are lost on restart", strings.Join(users, ", "))
+	}
+
+	r := httpx.NewRouter(h, opts)
 
 	r.Get("/docs/*", httpSwagger.WrapHandler)
 	r.Get("/docs/doc.json", func(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +395,6 @@ func main() {
 		http.ServeFile(w, r, "./docs/swagger.json")
 	})
 
-	log.Println("Server started at :8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	log.Println("Server started at " + cfg.Addr)
+	log.Fatal(http.ListenAndServe(cfg.Addr, r))
 }
@@ -0,0 +1,306 @@
+// Command notes-bench load-tests a running Notes API: it seeds notes, then
+// runs a mix of reads, writes and searches from concurrent workers and
+// reports throughput and latency percentiles per operation.
+//
+//	notes-bench -url http://localhost:8080 -token secret -c 32 -d 30s -mix read=70,write=20,search=10
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ops are the operations a mix is made of, in report order.
+var ops = []string{"read", "list", "write", "search"}
+
+var words = []string{"молоко", "отчёт", "встреча", "идея", "проект", "отпуск", "книга", "код"}
+
+func main() {
+	var (
+		baseURL     = flag.String("url", "http://localhost:8080", "API address")
+		token       = flag.String("token", os.Getenv("NOTES_BENCH_TOKEN"), "bearer token")
+		concurrency = flag.Int("c", 16, "concurrent workers")
+		duration    = flag.Duration("d", 10*time.Second, "how long to run")
+		seed        = flag.Int("seed", 200, "notes to create before the run")
+		mixSpec     = flag.String("mix", "read=60,list=10,write=20,search=10", "operation weights")
+	)
+	flag.Parse()
+
+	mix, err := parseMix(*mixSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c := &client{
+		base:  strings.TrimRight(*baseURL, "/") + "/api/v1",
+		token: *token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+
+	ids := make([]int64, 0, *seed)
+	for i := 0; i < *seed; i++ {
+		id, err := c.create(rand.New(rand.NewSource(int64(i))))
+		if err != nil {
+			log.Fatalf("seed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 && (mix["read"] > 0 || mix["write"] > 0) {
+		log.Fatal("reads and writes need seeded notes: set -seed above 0")
+	}
+
+	results := make(chan sample, 1024)
+	stats := make(map[string]*opStats)
+	done := make(chan struct{})
+	go func() {
+		for s := range results {
+			st := stats[s.op]
+			if st == nil {
+				st = &opStats{}
+				stats[s.op] = st
+			}
+			st.add(s)
+		}
+		close(done)
+	}()
+
+	deadline := time.Now().Add(*duration)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(w)))
+			for time.Now().Before(deadline) {
+				op := mix.pick(rnd)
+				t := time.Now()
+				err := c.run(op, rnd, ids)
+				results <- sample{op: op, latency: time.Since(t), err: err}
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+	<-done
+
+	report(os.Stdout, stats, elapsed)
+}
+
+// mix maps operations to their relative weights.
+type mix map[string]int
+
+// parseMix reads "op=weight,..." specs such as "read=70,write=30".
+func parseMix(spec string) (mix, error) {
+	m := make(mix)
+	for _, part := range strings.Split(spec, ",") {
+		op, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("mix %q: want op=weight", part)
+		}
+		if !known(op) {
+			return nil, fmt.Errorf("mix %q: unknown operation, want one of %s", part, strings.Join(ops, ", "))
+		}
+		n, err := strconv.Atoi(weight)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("mix %q: weight must be a non-negative integer", part)
+		}
+		m[op] = n
+	}
+	if m.total() == 0 {
+		return nil, fmt.Errorf("mix %q: no operation has a weight", spec)
+	}
+	return m, nil
+}
+
+func known(op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (m mix) total() int {
+	total := 0
+	for _, w := range m {
+		total += w
+	}
+	return total
+}
+
+func (m mix) pick(rnd *rand.Rand) string {
+	n := rnd.Intn(m.total())
+	for _, op := range ops {
+		if n < m[op] {
+			return op
+		}
+		n -= m[op]
+	}
+	panic("unreachable")
+}
+
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func (c *client) run(op string, rnd *rand.Rand, ids []int64) error {
+	switch op {
+	case "read":
+		return c.do(http.MethodGet, "/notes/"+strconv.FormatInt(ids[rnd.Intn(len(ids))], 10), nil, nil)
+	case "list":
+		return c.do(http.MethodGet, "/notes?limit=50", nil, nil)
+	case "write":
+		if rnd.Intn(2) == 0 {
+			_, err := c.create(rnd)
+			return err
+		}
+		body := map[string]string{"content": text(rnd)}
+		return c.do(http.MethodPatch, "/notes/"+strconv.FormatInt(ids[rnd.Intn(len(ids))], 10), body, nil)
+	default:
+		return c.do(http.MethodGet, "/notes?limit=50&q="+url.QueryEscape(words[rnd.Intn(len(words))]), nil, nil)
+	}
+}
+
+func (c *client) create(rnd *rand.Rand) (int64, error) {
+	var created struct{ ID int64 }
+	body := map[string]interface{}{
+		"title":   "bench " + words[rnd.Intn(len(words))],
+		"content": text(rnd),
+		"tags":    []string{"bench"},
+	}
+	if err := c.do(http.MethodPost, "/notes", body, &created); err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+func (c *client) do(method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.base+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+func text(rnd *rand.Rand) string {
+	parts := make([]string, 5+rnd.Intn(20))
+	for i := range parts {
+		parts[i] = words[rnd.Intn(len(words))]
+	}
+	return strings.Join(parts, " ")
+}
+
+type sample struct {
+	op      string
+	latency time.Duration
+	err     error
+}
+
+type opStats struct {
+	latencies []time.Duration
+	errors    int
+	lastErr   error
+}
+
+func (s *opStats) add(smp sample) {
+	if smp.err != nil {
+		s.errors++
+		s.lastErr = smp.err
+		return
+	}
+	s.latencies = append(s.latencies, smp.latency)
+}
+
+// percentile returns the p-th percentile (0-100) of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p / 100)
+	return sorted[i]
+}
+
+func report(w io.Writer, stats map[string]*opStats, elapsed time.Duration) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tok\terrors\treq/s\tp50\tp90\tp99\tmax\t")
+
+	var total, errors int
+	for _, op := range ops {
+		s := stats[op]
+		if s == nil {
+			continue
+		}
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		ok := len(s.latencies)
+		total += ok + s.errors
+		errors += s.errors
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n", op, ok, s.errors,
+			float64(ok)/elapsed.Seconds(),
+			round(percentile(s.latencies, 50)), round(percentile(s.latencies, 90)),
+			round(percentile(s.latencies, 99)), round(percentile(s.latencies, 100)))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d requests in %s, %.1f req/s, %d errors\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds(), errors)
+	for _, op := range ops {
+		if s := stats[op]; s != nil && s.lastErr != nil {
+			fmt.Fprintf(w, "last %s error: %v\n", op, s.lastErr)
+		}
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Millisecond {
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,150 @@
+// Command notes-replay re-sends requests recorded with NOTES_RECORD_FILE to
+// another instance and reports every response whose status differs from
+// the recorded one. Recordings hold user IDs, not credentials, so tokens
+// are given per user:
+//
+//	notes-replay -url http://localhost:8081 -tokens alice=secret1,bob=secret2 requests.jsonl
+//
+// Numeric IDs in recorded URLs only line up when the target starts from
+// the same state the recording did, typically both empty.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/record"
+)
+
+func main() {
+	var (
+		baseURL   = flag.String("url", "http://localhost:8080", "instance to replay against")
+		tokenSpec = flag.String("tokens", "", "user=token pairs, comma-separated, for authenticated requests")
+		timing    = flag.Bool("timing", false, "keep the recorded gaps between requests")
+		stop      = flag.Bool("stop", false, "stop at the first status mismatch")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: notes-replay [flags] recording.jsonl\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	tokens, err := parseTokens(*tokenSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		// Redirects are part of what is compared.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	base := strings.TrimRight(*baseURL, "/")
+
+	var sent, skipped, mismatched int
+	var last time.Time
+	err = record.Read(f, func(e record.Entry) error {
+		if e.BodyOmitted {
+			skipped++
+			return nil
+		}
+		if *timing && !last.IsZero() && e.Time.After(last) {
+			time.Sleep(e.Time.Sub(last))
+		}
+		last = e.Time
+
+		req, err := http.NewRequest(e.Method, base+e.URL, bytes.NewReader(e.Body))
+		if err != nil {
+			return err
+		}
+		req.Header = e.Header.Clone()
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		if e.User != "" {
+			token, ok := tokens[e.User]
+			if !ok {
+				return fmt.Errorf("no token for user %q: add it to -tokens", e.User)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		sent++
+		status, err := send(client, req)
+		if err != nil {
+			// Dropped connections are a valid outcome to compare.
+			log.Printf("%s %s: %v", e.Method, e.URL, err)
+		}
+		if status != e.Status {
+			mismatched++
+			fmt.Printf("%s %s (%s): recorded %d, got %d\n", e.Method, e.URL, userOf(e), e.Status, status)
+			if *stop {
+				return errMismatch
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errMismatch {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d requests replayed, %d mismatched, %d skipped (body over %d bytes)\n", sent, mismatched, skipped, record.MaxBody)
+	if mismatched > 0 {
+		os.Exit(1)
+	}
+}
+
+var errMismatch = fmt.Errorf("status mismatch")
+
+// send returns the response status, or 0 when there was no response.
+func send(client *http.Client, req *http.Request) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Streaming endpoints such as /events never end; the status is enough.
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		io.Copy(io.Discard, resp.Body)
+	}
+	return resp.StatusCode, nil
+}
+
+func parseTokens(spec string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		user, token, ok := strings.Cut(pair, "=")
+		if !ok || user == "" || token == "" {
+			return nil, fmt.Errorf("invalid token pair %q: want user=token", pair)
+		}
+		tokens[user] = token
+	}
+	return tokens, nil
+}
+
+func userOf(e record.Entry) string {
+	if e.User == "" {
+		return "anonymous"
+	}
+	return e.User
+}
@@ -0,0 +1,124 @@
+// Package apispec is the registry of the API: its routes and the types
+// they take and return. The registry is read from the swag annotations of
+// the handlers and the Go types they name (Load), kept in registry.json
+// (Default), and turned into an OpenAPI 3.1 document and typed Go and
+// TypeScript clients. "make clients" regenerates all of them.
+package apispec
+
+// Registry is every documented route of the API with the types they use.
+type Registry struct {
+	Info   Info    `json:"info"`
+	Routes []Route `json:"routes"`
+	// Types are the named types routes use, such as "core.Note", by name.
+	Types map[string]Schema `json:"types"`
+}
+
+// Info is the general information of cmd/api/main.go.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	BasePath    string `json:"base_path"`
+}
+
+// Route is a handler with its @Router annotation. Path is relative to
+// Info.BasePath; Accept and Produce are MIME types.
+type Route struct {
+	Method      string     `json:"method"`
+	Path        string     `json:"path"`
+	Handler     string     `json:"handler"`
+	Summary     string     `json:"summary,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Accept      []string   `json:"accept,omitempty"`
+	Produce     []string   `json:"produce,omitempty"`
+	Deprecated  bool       `json:"deprecated,omitempty"`
+	Params      []Param    `json:"params,omitempty"`
+	Responses   []Response `json:"responses"`
+}
+
+// Param is a path, query, header or body parameter. Body parameters have
+// a Schema; the others a scalar Type: string, integer, number or boolean.
+type Param struct {
+	Name        string   `json:"name"`
+	In          string   `json:"in"`
+	Type        string   `json:"type,omitempty"`
+	Schema      *Schema  `json:"schema,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Default     string   `json:"default,omitempty"`
+}
+
+// Response is a documented answer of a route. Responses without a body
+// have no Schema.
+type Response struct {
+	Status      int      `json:"status"`
+	Description string   `json:"description,omitempty"`
+	Schema      *Schema  `json:"schema,omitempty"`
+	Headers     []Header `json:"headers,omitempty"`
+}
+
+type Header struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema describes a JSON value: a reference to a named type in
+// Registry.Types, or a value of Type. The empty Schema is any value.
+type Schema struct {
+	Ref      string `json:"ref,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Nullable bool   `json:"nullable,omitempty"`
+	// Items are the elements of arrays.
+	Items *Schema `json:"items,omitempty"`
+	// Properties are the fields of objects with known fields, in order;
+	// Values the values of maps.
+	Properties []Property `json:"properties,omitempty"`
+	Values     *Schema    `json:"values,omitempty"`
+	Enum       []string   `json:"enum,omitempty"`
+}
+
+type Property struct {
+	Name        string `json:"name"`
+	Schema      Schema `json:"schema"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+	Example     string `json:"example,omitempty"`
+}
+
+// Success returns the first 2xx response of r, if any.
+func (r Route) Success() (Response, bool) {
+	for _, resp := range r.Responses {
+		if resp.Status >= 200 && resp.Status < 300 {
+			return resp, true
+		}
+	}
+	return Response{}, false
+}
+
+// Body returns the body parameter of r, if any.
+func (r Route) Body() (Param, bool) {
+	for _, p := range r.Params {
+		if p.In == "body" {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// RawBody reports whether r takes a body other than JSON without
+// describing it, such as the chunks of an upload.
+func (r Route) RawBody() bool {
+	if _, ok := r.Body(); ok {
+		return false
+	}
+	for _, t := range r.Accept {
+		if t != "application/json" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGeneratedUpToDate fails when the handler annotations changed without
+// "make clients".
+func TestGeneratedUpToDate(t *testing.T) {
+	root := filepath.Join("..", "..")
+	reg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Generate(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range files {
+		got, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run make clients", path)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	reg, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := reg.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	operations := 0
+	for _, methods := range doc.Paths {
+		operations += len(methods)
+	}
+	if operations != len(reg.Routes) {
+		t.Errorf("%d operations for %d routes", operations, len(reg.Routes))
+	}
+	get := doc.Paths["/notes/{id}"]["get"]
+	if get["operationId"] != "GetNote" {
+		t.Errorf("GET /notes/{id} = %v", get)
+	}
+	ok := get["responses"].(map[string]any)["200"].(map[string]any)
+	schema := ok["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	if schema["$ref"] != "#/components/schemas/core.Note" {
+		t.Errorf("GET /notes/{id} 200 schema = %v", schema)
+	}
+}
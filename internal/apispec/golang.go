@@ -0,0 +1,341 @@
+package apispec
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Generated is the header of generated files.
+const Generated = "// Code generated by apigen. DO NOT EDIT."
+
+// initialisms are written in capitals in Go names.
+var initialisms = map[string]bool{
+	"id": true, "ids": true, "url": true, "uri": true, "uuid": true, "api": true,
+	"http": true, "json": true, "html": true, "sha": true, "qr": true, "ip": true,
+}
+
+// goReserved are names of the hand-written part of the Go client that
+// generated types must not take.
+var goReserved = map[string]bool{
+	"Client": true, "NewClient": true, "Error": true, "Credentials": true,
+	"BearerToken": true, "RetryPolicy": true, "DefaultRetry": true,
+}
+
+// GoClient returns the typed Go client of the registry for package pkg.
+// It calls Client.do, which the package defines by hand.
+func (r *Registry) GoClient(pkg string) ([]byte, error) {
+	g := &goGen{reg: r, names: goTypeNames(r.Types)}
+
+	var body bytes.Buffer
+	for _, name := range sortedKeys(r.Types) {
+		s := r.Types[name]
+		fmt.Fprintf(&body, "// %s is %s of the API.\n", g.names[name], name)
+		fmt.Fprintf(&body, "type %s %s\n\n", g.names[name], g.goType(s, false))
+	}
+	for _, route := range r.Routes {
+		g.method(&body, route)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\nimport (\n", Generated, pkg)
+	code := body.String()
+	for _, imp := range []struct{ path, use string }{
+		{"context", "context.Context"},
+		{"iter", "iter.Seq2"},
+		{"net/url", "url.PathEscape("},
+		{"strconv", "strconv.Format"},
+		{"time", "time.Time"},
+	} {
+		if strings.Contains(code, imp.use) {
+			fmt.Fprintf(&out, "\t%q\n", imp.path)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(code)
+	return format.Source(out.Bytes())
+}
+
+// goTypeNames names the registry types in Go by their name without the
+// package, or with it for names two packages declare.
+func goTypeNames(types map[string]Schema) map[string]string {
+	count := make(map[string]int)
+	for name := range types {
+		_, short, _ := strings.Cut(name, ".")
+		count[short]++
+	}
+	names := make(map[string]string, len(types))
+	for name := range types {
+		pkg, short, _ := strings.Cut(name, ".")
+		if count[short] > 1 || goReserved[short] {
+			short = exported(pkg) + short
+		}
+		names[name] = short
+	}
+	return names
+}
+
+type goGen struct {
+	reg   *Registry
+	names map[string]string
+}
+
+// goType returns the Go type of s; nullable values are pointers except
+// where nil says it already.
+func (g *goGen) goType(s Schema, nullable bool) string {
+	var t string
+	switch {
+	case s.Ref != "":
+		t = g.names[s.Ref]
+	case s.Type == "string" && s.Format == "date-time":
+		t = "time.Time"
+	case s.Type == "string" && (s.Format == "byte" || s.Format == "binary"):
+		return "[]byte"
+	case s.Type == "string":
+		t = "string"
+	case s.Type == "integer" && s.Format == "int64":
+		t = "int64"
+	case s.Type == "integer":
+		t = "int"
+	case s.Type == "number":
+		t = "float64"
+	case s.Type == "boolean":
+		t = "bool"
+	case s.Type == "array":
+		return "[]" + g.goType(*s.Items, false)
+	case s.Type == "object" && s.Values != nil:
+		return "map[string]" + g.goType(*s.Values, false)
+	case s.Type == "object" && len(s.Properties) > 0:
+		var b strings.Builder
+		b.WriteString("struct {\n")
+		for _, p := range s.Properties {
+			if p.Description != "" {
+				for _, line := range strings.Split(p.Description, "\n") {
+					fmt.Fprintf(&b, "// %s\n", line)
+				}
+			}
+			tag := p.Name
+			if !p.Required {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&b, "%s %s `json:%q`\n", exported(p.Name), g.goType(p.Schema, true), tag)
+		}
+		b.WriteString("}")
+		t = b.String()
+	case s.Type == "object":
+		return "map[string]any"
+	default:
+		return "any"
+	}
+	if nullable && s.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+// method writes the client method of a route, named after its handler:
+// the path parameters are arguments, followed by the body, if any, and
+// the query and header parameters in a <Handler>Params.
+func (g *goGen) method(b *bytes.Buffer, route Route) {
+	var args, argNames, options []string
+	var params []Param
+	path := fmt.Sprintf("%q", g.reg.Info.BasePath+route.Path)
+	for _, p := range route.Params {
+		switch p.In {
+		case "path":
+			arg := goArg(p.Name)
+			typ, value := "string", arg
+			if p.Type == "integer" {
+				typ, value = "int64", "strconv.FormatInt("+arg+", 10)"
+			}
+			args = append(args, arg+" "+typ)
+			argNames = append(argNames, arg)
+			path = strings.Replace(path, "{"+p.Name+"}", `" + url.PathEscape(`+value+`) + "`, 1)
+		case "query", "header":
+			params = append(params, p)
+		}
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, `"" + `), ` + ""`)
+
+	request := []string{fmt.Sprintf("method: %q", route.Method), "path: " + path}
+	if body, ok := route.Body(); ok {
+		args = append(args, "body "+g.goType(*body.Schema, true))
+		argNames = append(argNames, "body")
+		request = append(request, "body: body")
+	} else if route.RawBody() {
+		args = append(args, "body []byte")
+		argNames = append(argNames, "body")
+		if len(route.Accept) == 1 {
+			request = append(request, "body: body", fmt.Sprintf("contentType: %q", route.Accept[0]))
+		} else {
+			args = append(args, "contentType string")
+			argNames = append(argNames, "contentType")
+			request = append(request, "body: body", "contentType: contentType")
+		}
+	}
+	if len(params) > 0 {
+		name := route.Handler + "Params"
+		g.params(b, name, route, params)
+		args = append(args, "params *"+name)
+		options = append(options, "params.apply(&req)")
+	}
+
+	out := ""
+	if success, ok := route.Success(); ok && success.Schema != nil {
+		out = g.goType(*success.Schema, false)
+		if success.Schema.Ref != "" {
+			out = "*" + out
+		}
+	}
+
+	fmt.Fprintf(b, "// %s calls %s %s.", route.Handler, route.Method, route.Path)
+	if route.Summary != "" {
+		fmt.Fprintf(b, " %s.", strings.TrimSuffix(route.Summary, "."))
+	}
+	b.WriteString("\n")
+	if route.Deprecated {
+		b.WriteString("//\n// Deprecated: the route is deprecated.\n")
+	}
+	results := "error"
+	if out != "" {
+		results = "(" + out + ", error)"
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) %s {\n", route.Handler, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), results)
+	fmt.Fprintf(b, "req := request{%s}\n", strings.Join(request, ", "))
+	for _, o := range options {
+		b.WriteString(o + "\n")
+	}
+	if out == "" {
+		b.WriteString("return c.do(ctx, req, nil)\n}\n\n")
+		return
+	}
+	fmt.Fprintf(b, "var out %s\nerr := c.do(ctx, req, &out)\nreturn out, err\n}\n\n", out)
+
+	if item, ok := strings.CutPrefix(out, "[]"); ok && paged(params) {
+		name := route.Handler + "Params"
+		fmt.Fprintf(b, "// %sAll iterates over every item of %s, fetching page after\n// page from params.Page on.\n", route.Handler, route.Handler)
+		fmt.Fprintf(b, "func (c *Client) %sAll(%s) iter.Seq2[%s, error] {\n", route.Handler, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), item)
+		fmt.Fprintf(b, "var p %s\nif params != nil {\np = *params\n}\n", name)
+		call := strings.Join(append(append([]string{"ctx"}, argNames...), "&p"), ", ")
+		fmt.Fprintf(b, "return paginate(&p.Page, &p.Limit, func() (%s, error) {\nreturn c.%s(%s)\n})\n}\n\n", out, route.Handler, call)
+	}
+}
+
+// paged reports whether params page a list with page and limit.
+func paged(params []Param) bool {
+	found := 0
+	for _, p := range params {
+		if p.In == "query" && p.Type == "integer" && (p.Name == "page" || p.Name == "limit") {
+			found++
+		}
+	}
+	return found == 2
+}
+
+// params writes the struct of the query and header parameters of a route.
+// Zero fields are not sent. Parameters such as prop.{name} are maps.
+func (g *goGen) params(b *bytes.Buffer, name string, route Route, params []Param) {
+	fmt.Fprintf(b, "// %s are the optional parameters of %s.\n", name, route.Handler)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, p := range params {
+		if p.Description != "" {
+			fmt.Fprintf(b, "// %s\n", p.Description)
+		}
+		if prefix, ok := paramPrefix(p.Name); ok {
+			fmt.Fprintf(b, "%s map[string]string\n", exported(prefix))
+			continue
+		}
+		fmt.Fprintf(b, "%s %s\n", exported(p.Name), paramType(p.Type))
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "func (p *%s) apply(req *request) {\nif p == nil {\nreturn\n}\n", name)
+	for _, p := range params {
+		field := "p." + exported(p.Name)
+		set := "req.setQuery"
+		if p.In == "header" {
+			set = "req.setHeader"
+		}
+		if prefix, ok := paramPrefix(p.Name); ok {
+			field = "p." + exported(prefix)
+			fmt.Fprintf(b, "for k, v := range %s {\n%s(%q+k, v)\n}\n", field, set, prefix+".")
+			continue
+		}
+		switch paramType(p.Type) {
+		case "int64":
+			fmt.Fprintf(b, "if %s != 0 {\n%s(%q, strconv.FormatInt(%s, 10))\n}\n", field, set, p.Name, field)
+		case "float64":
+			fmt.Fprintf(b, "if %s != 0 {\n%s(%q, strconv.FormatFloat(%s, 'f', -1, 64))\n}\n", field, set, p.Name, field)
+		case "bool":
+			fmt.Fprintf(b, "if %s {\n%s(%q, \"true\")\n}\n", field, set, p.Name)
+		default:
+			fmt.Fprintf(b, "if %s != \"\" {\n%s(%q, %s)\n}\n", field, set, p.Name, field)
+		}
+	}
+	b.WriteString("}\n\n")
+}
+
+// paramPrefix returns "prop" for parameters such as prop.{name}.
+func paramPrefix(name string) (string, bool) {
+	prefix, ok := strings.CutSuffix(name, ".{name}")
+	return prefix, ok
+}
+
+func paramType(typ string) string {
+	switch typ {
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "string"
+}
+
+// exported returns a JSON or parameter name as an exported Go name:
+// notebook_id is NotebookID and Upload-Offset UploadOffset.
+func exported(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "X" + s
+	}
+	return s
+}
+
+// goArg returns a path parameter name as a Go argument: notebook_id is
+// notebookID.
+func goArg(name string) string {
+	s := exported(name)
+	for i, r := range s {
+		if !unicode.IsUpper(r) {
+			if i > 1 {
+				i--
+			}
+			return strings.ToLower(s[:i]) + s[i:]
+		}
+	}
+	return strings.ToLower(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,452 @@
+package apispec
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	infoLine     = regexp.MustCompile(`^@(title|version|description|BasePath)\s+(.*)$`)
+	textLine     = regexp.MustCompile(`^@(Summary|Description|Tags|Accept|Produce)\s+(.*)$`)
+	routeLine    = regexp.MustCompile(`^@Router\s+(\S+)\s+\[(\w+)\]`)
+	paramLine    = regexp.MustCompile(`^@Param\s+(\S+)\s+(\w+)\s+(\S+)\s+(true|false)\s+"([^"]*)"\s*(.*)$`)
+	responseLine = regexp.MustCompile(`^@(?:Success|Failure)\s+(\d+)\s*(?:\{(\w+)\}\s+(\S+))?\s*(?:"([^"]*)")?`)
+	headerLine   = regexp.MustCompile(`^@Header\s+(\d+)\s+\{(\w+)\}\s+(\S+)\s*(?:"([^"]*)")?`)
+	enumsAttr    = regexp.MustCompile(`Enums\(([^)]*)\)`)
+	defaultAttr  = regexp.MustCompile(`default\(([^)]*)\)`)
+)
+
+// mimeTypes expands the short names swag takes for @Accept and @Produce.
+var mimeTypes = map[string]string{
+	"json":                  "application/json",
+	"xml":                   "application/xml",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"png":                   "image/png",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+}
+
+// scalarTypes maps the parameter and header types of annotations to JSON
+// types.
+var scalarTypes = map[string]string{
+	"int": "integer", "integer": "integer", "number": "number",
+	"bool": "boolean", "boolean": "boolean", "string": "string",
+}
+
+// Load reads the registry from the module at root: the general
+// information of cmd/api/main.go, the routes annotated in the handlers
+// package and the types of the internal packages they name.
+func Load(root string) (*Registry, error) {
+	l := &loader{decls: make(map[string]*ast.TypeSpec), types: make(map[string]Schema)}
+	reg := &Registry{Types: l.types}
+
+	fset := token.NewFileSet()
+	main, err := parser.ParseFile(fset, filepath.Join(root, "cmd", "api", "main.go"), nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range main.Comments {
+		for _, c := range group.List {
+			if m := infoLine.FindStringSubmatch(annotation(c)); m != nil {
+				switch m[1] {
+				case "title":
+					reg.Info.Title = m[2]
+				case "version":
+					reg.Info.Version = m[2]
+				case "description":
+					reg.Info.Description = m[2]
+				case "BasePath":
+					reg.Info.BasePath = m[2]
+				}
+			}
+		}
+	}
+
+	var handlers []*ast.File
+	err = filepath.WalkDir(filepath.Join(root, "internal"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if d.Name() == "testdata" {
+			return filepath.SkipDir
+		}
+		pkgs, err := parser.ParseDir(fset, path, func(fi fs.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		for name, pkg := range pkgs {
+			for _, f := range sortedFiles(pkg) {
+				l.collect(name, f)
+				if name == "handlers" {
+					handlers = append(handlers, f)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range handlers {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			if r, ok := l.route(fn); ok {
+				reg.Routes = append(reg.Routes, r)
+			}
+		}
+	}
+	sort.Slice(reg.Routes, func(i, j int) bool {
+		a, b := reg.Routes[i], reg.Routes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return reg, nil
+}
+
+func sortedFiles(pkg *ast.Package) []*ast.File {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]*ast.File, len(names))
+	for i, name := range names {
+		files[i] = pkg.Files[name]
+	}
+	return files
+}
+
+func annotation(c *ast.Comment) string {
+	return strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+}
+
+type loader struct {
+	// decls are the type declarations of the internal packages, by
+	// qualified name such as "core.Note".
+	decls map[string]*ast.TypeSpec
+	types map[string]Schema
+}
+
+func (l *loader) collect(pkg string, f *ast.File) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			l.decls[pkg+"."+ts.Name.Name] = ts
+		}
+	}
+}
+
+// route reads the annotations of a handler.
+func (l *loader) route(fn *ast.FuncDecl) (Route, bool) {
+	r := Route{Handler: fn.Name.Name}
+	headers := make(map[int][]Header)
+	var description []string
+	for _, c := range fn.Doc.List {
+		line := annotation(c)
+		if m := routeLine.FindStringSubmatch(line); m != nil {
+			r.Path, r.Method = m[1], strings.ToUpper(m[2])
+		} else if m := textLine.FindStringSubmatch(line); m != nil {
+			switch m[1] {
+			case "Summary":
+				r.Summary = m[2]
+			case "Description":
+				description = append(description, m[2])
+			case "Tags":
+				r.Tags = splitList(m[2])
+			case "Accept":
+				r.Accept = mimeList(m[2])
+			case "Produce":
+				r.Produce = mimeList(m[2])
+			}
+		} else if line == "@Deprecated" {
+			r.Deprecated = true
+		} else if m := paramLine.FindStringSubmatch(line); m != nil {
+			p := Param{Name: m[1], In: m[2], Required: m[4] == "true", Description: m[5]}
+			if p.In == "body" {
+				s := l.annotated("object", m[3])
+				p.Schema = &s
+			} else {
+				p.Type = scalarTypes[m[3]]
+				if p.Type == "" {
+					p.Type = "string"
+				}
+			}
+			if e := enumsAttr.FindStringSubmatch(m[6]); e != nil {
+				p.Enum = splitList(e[1])
+			}
+			if d := defaultAttr.FindStringSubmatch(m[6]); d != nil {
+				p.Default = d[1]
+			}
+			r.Params = append(r.Params, p)
+		} else if m := responseLine.FindStringSubmatch(line); m != nil {
+			status, _ := strconv.Atoi(m[1])
+			if r.response(status) != nil {
+				continue
+			}
+			resp := Response{Status: status, Description: m[4]}
+			if m[2] != "" {
+				s := l.annotated(m[2], m[3])
+				resp.Schema = &s
+			}
+			r.Responses = append(r.Responses, resp)
+		} else if m := headerLine.FindStringSubmatch(line); m != nil {
+			status, _ := strconv.Atoi(m[1])
+			typ := scalarTypes[m[2]]
+			if typ == "" {
+				typ = "string"
+			}
+			headers[status] = append(headers[status], Header{Name: m[3], Type: typ, Description: m[4]})
+		}
+	}
+	if r.Path == "" {
+		return Route{}, false
+	}
+	r.Description = strings.Join(description, "\n")
+	for status, hs := range headers {
+		if resp := r.response(status); resp != nil {
+			resp.Headers = hs
+		}
+	}
+	sort.Slice(r.Responses, func(i, j int) bool { return r.Responses[i].Status < r.Responses[j].Status })
+	return r, true
+}
+
+func (r *Route) response(status int) *Response {
+	for i := range r.Responses {
+		if r.Responses[i].Status == status {
+			return &r.Responses[i]
+		}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		list = append(list, item)
+	}
+	return list
+}
+
+func mimeList(s string) []string {
+	list := splitList(s)
+	for i, m := range list {
+		if full, ok := mimeTypes[m]; ok {
+			list[i] = full
+		}
+	}
+	return list
+}
+
+// annotated returns the schema of a {kind} type of a @Param or @Success
+// annotation. Unqualified types are in the handlers package.
+func (l *loader) annotated(kind, typ string) Schema {
+	switch kind {
+	case "array":
+		items := l.annotated("object", typ)
+		return Schema{Type: "array", Items: &items}
+	case "string":
+		return Schema{Type: "string"}
+	case "file":
+		return Schema{Type: "string", Format: "binary"}
+	}
+	switch {
+	case typ == "object" || strings.HasPrefix(typ, "map[string]interface"):
+		return Schema{Type: "object"}
+	case strings.HasPrefix(typ, "map[string]"):
+		values := l.annotated("object", strings.TrimPrefix(typ, "map[string]"))
+		return Schema{Type: "object", Values: &values}
+	case scalarTypes[typ] != "":
+		return Schema{Type: scalarTypes[typ]}
+	case !strings.Contains(typ, "."):
+		typ = "handlers." + typ
+	}
+	return l.named(typ)
+}
+
+// named returns the schema of a declared type: a reference for structs,
+// which are added to the types, and the schema of the underlying type
+// otherwise. Types it does not know are any value.
+func (l *loader) named(name string) Schema {
+	spec, ok := l.decls[name]
+	if !ok {
+		return Schema{}
+	}
+	pkg, _, _ := strings.Cut(name, ".")
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return l.expr(pkg, spec.Type)
+	}
+	if _, done := l.types[name]; !done {
+		// A placeholder ends the recursion of self-referencing types.
+		l.types[name] = Schema{Type: "object"}
+		l.types[name] = l.object(pkg, st)
+	}
+	return Schema{Ref: name}
+}
+
+func (l *loader) expr(pkg string, e ast.Expr) Schema {
+	switch t := e.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return Schema{Type: "string"}
+		case "bool":
+			return Schema{Type: "boolean"}
+		case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "byte", "rune":
+			return Schema{Type: "integer"}
+		case "int64", "uint64":
+			return Schema{Type: "integer", Format: "int64"}
+		case "float32", "float64":
+			return Schema{Type: "number"}
+		case "any", "error":
+			return Schema{}
+		}
+		return l.named(pkg + "." + t.Name)
+	case *ast.SelectorExpr:
+		x, _ := t.X.(*ast.Ident)
+		if x == nil {
+			return Schema{}
+		}
+		switch x.Name + "." + t.Sel.Name {
+		case "time.Time":
+			return Schema{Type: "string", Format: "date-time"}
+		case "time.Duration":
+			return Schema{Type: "integer", Format: "int64"}
+		case "json.RawMessage":
+			return Schema{}
+		}
+		return l.named(x.Name + "." + t.Sel.Name)
+	case *ast.StarExpr:
+		s := l.expr(pkg, t.X)
+		s.Nullable = true
+		return s
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return Schema{Type: "string", Format: "byte"}
+		}
+		items := l.expr(pkg, t.Elt)
+		return Schema{Type: "array", Items: &items}
+	case *ast.MapType:
+		values := l.expr(pkg, t.Value)
+		return Schema{Type: "object", Values: &values}
+	case *ast.StructType:
+		return l.object(pkg, t)
+	}
+	return Schema{}
+}
+
+// object returns the schema of a struct as encoding/json writes it:
+// embedded structs without a name are flattened into it, and fields it
+// declares itself win over theirs.
+func (l *loader) object(pkg string, st *ast.StructType) Schema {
+	s := Schema{Type: "object"}
+	own := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		for _, ident := range field.Names {
+			name, _ := jsonName(field, ident.Name)
+			own[name] = true
+		}
+	}
+
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			name, _ := jsonName(field, "")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				if embedded := l.embedded(pkg, field.Type); embedded != nil {
+					for _, p := range embedded.Properties {
+						if !own[p.Name] {
+							s.Properties = append(s.Properties, p)
+						}
+					}
+					continue
+				}
+			}
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name, omitempty := jsonName(field, ident.Name)
+			if name == "-" {
+				continue
+			}
+			p := Property{Name: name, Schema: l.expr(pkg, field.Type), Required: !omitempty}
+			tag := fieldTag(field)
+			if tag.Get("swaggertype") == "object" {
+				p.Schema = Schema{Type: "object"}
+			}
+			if enums := tag.Get("enums"); enums != "" {
+				p.Schema.Enum = splitList(enums)
+			}
+			p.Example = tag.Get("example")
+			p.Description = strings.TrimSpace(field.Doc.Text())
+			s.Properties = append(s.Properties, p)
+		}
+	}
+	return s
+}
+
+// embedded returns the object schema of an embedded struct field.
+func (l *loader) embedded(pkg string, e ast.Expr) *Schema {
+	if star, ok := e.(*ast.StarExpr); ok {
+		e = star.X
+	}
+	name := ""
+	switch t := e.(type) {
+	case *ast.Ident:
+		name = pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			name = x.Name + "." + t.Sel.Name
+		}
+	}
+	if ref := l.named(name); ref.Ref != "" {
+		s := l.types[ref.Ref]
+		return &s
+	}
+	return nil
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	s, _ := strconv.Unquote(field.Tag.Value)
+	return reflect.StructTag(s)
+}
+
+// jsonName returns the name encoding/json gives a field, "-" for skipped
+// ones, and whether it is omitempty or omitzero.
+func jsonName(field *ast.Field, goName string) (string, bool) {
+	name, opts, _ := strings.Cut(fieldTag(field).Get("json"), ",")
+	if name == "" {
+		name = goName
+	}
+	opts = "," + opts + ","
+	return name, strings.Contains(opts, ",omitempty,") || strings.Contains(opts, ",omitzero,")
+}
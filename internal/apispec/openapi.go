@@ -0,0 +1,215 @@
+package apispec
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI returns the registry as an OpenAPI 3.1 document.
+func (r *Registry) OpenAPI() ([]byte, error) {
+	paths := make(map[string]map[string]any)
+	for _, route := range r.Routes {
+		if paths[route.Path] == nil {
+			paths[route.Path] = make(map[string]any)
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = operation(route)
+	}
+
+	schemas := make(map[string]any, len(r.Types))
+	for name, s := range r.Types {
+		schemas[name] = jsonSchema(s)
+	}
+
+	info := map[string]any{"title": r.Info.Title, "version": r.Info.Version}
+	if r.Info.Description != "" {
+		info["description"] = r.Info.Description
+	}
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info":    info,
+		"servers": []any{map[string]any{"url": r.Info.BasePath}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{map[string]any{"bearer": []string{}}},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func operation(route Route) map[string]any {
+	op := map[string]any{"operationId": route.Handler}
+	if route.Summary != "" {
+		op["summary"] = route.Summary
+	}
+	if route.Description != "" {
+		op["description"] = route.Description
+	}
+	if len(route.Tags) > 0 {
+		op["tags"] = route.Tags
+	}
+	if route.Deprecated {
+		op["deprecated"] = true
+	}
+
+	var params []any
+	form := Schema{Type: "object"}
+	for _, p := range route.Params {
+		switch p.In {
+		case "body":
+			op["requestBody"] = map[string]any{
+				"required": p.Required,
+				"content":  content(orDefault(route.Accept, "application/json"), *p.Schema, p.Description),
+			}
+		case "formData":
+			s := Schema{Type: p.Type}
+			if p.Type == "" || p.Type == "string" && p.Name == "file" {
+				s = Schema{Type: "string", Format: "binary"}
+			}
+			form.Properties = append(form.Properties, Property{Name: p.Name, Schema: s, Required: p.Required, Description: p.Description})
+		default:
+			s := Schema{Type: p.Type, Enum: p.Enum}
+			param := map[string]any{"name": p.Name, "in": p.In, "schema": jsonSchema(s)}
+			if p.Required || p.In == "path" {
+				param["required"] = true
+			}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			if p.Default != "" {
+				param["schema"].(map[string]any)["default"] = value(p.Type, p.Default)
+			}
+			params = append(params, param)
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if len(form.Properties) > 0 {
+		op["requestBody"] = map[string]any{"content": content([]string{"multipart/form-data"}, form, "")}
+	}
+
+	responses := make(map[string]any, len(route.Responses))
+	for _, resp := range route.Responses {
+		description := resp.Description
+		if description == "" {
+			description = http.StatusText(resp.Status)
+		}
+		out := map[string]any{"description": description}
+		if resp.Schema != nil {
+			types := []string{"application/json"}
+			if resp.Status < 300 {
+				types = orDefault(route.Produce, "application/json")
+			}
+			out["content"] = content(types, *resp.Schema, "")
+		}
+		if len(resp.Headers) > 0 {
+			headers := make(map[string]any, len(resp.Headers))
+			for _, h := range resp.Headers {
+				header := map[string]any{"schema": map[string]any{"type": h.Type}}
+				if h.Description != "" {
+					header["description"] = h.Description
+				}
+				headers[h.Name] = header
+			}
+			out["headers"] = headers
+		}
+		responses[strconv.Itoa(resp.Status)] = out
+	}
+	op["responses"] = responses
+	return op
+}
+
+func content(types []string, s Schema, description string) map[string]any {
+	schema := jsonSchema(s)
+	if description != "" {
+		schema["description"] = description
+	}
+	c := make(map[string]any, len(types))
+	for _, t := range types {
+		c[t] = map[string]any{"schema": schema}
+	}
+	return c
+}
+
+func orDefault(types []string, def string) []string {
+	if len(types) == 0 {
+		return []string{def}
+	}
+	return types
+}
+
+// jsonSchema returns s as a JSON Schema of OpenAPI 3.1, in which nullable
+// values are typed null as well.
+func jsonSchema(s Schema) map[string]any {
+	out := make(map[string]any)
+	if s.Ref != "" {
+		ref := map[string]any{"$ref": "#/components/schemas/" + s.Ref}
+		if !s.Nullable {
+			return ref
+		}
+		out["anyOf"] = []any{ref, map[string]any{"type": "null"}}
+		return out
+	}
+	switch {
+	case s.Type != "" && s.Nullable:
+		out["type"] = []string{s.Type, "null"}
+	case s.Type != "":
+		out["type"] = s.Type
+	}
+	if s.Format != "" {
+		out["format"] = s.Format
+	}
+	if len(s.Enum) > 0 {
+		enum := make([]any, len(s.Enum))
+		for i, e := range s.Enum {
+			enum[i] = value(s.Type, e)
+		}
+		out["enum"] = enum
+	}
+	if s.Items != nil {
+		out["items"] = jsonSchema(*s.Items)
+	}
+	if s.Values != nil {
+		out["additionalProperties"] = jsonSchema(*s.Values)
+	}
+	if len(s.Properties) > 0 {
+		props := make(map[string]any, len(s.Properties))
+		var required []string
+		for _, p := range s.Properties {
+			schema := jsonSchema(p.Schema)
+			if p.Description != "" {
+				schema["description"] = p.Description
+			}
+			if p.Example != "" {
+				schema["examples"] = []any{value(p.Schema.Type, p.Example)}
+			}
+			props[p.Name] = schema
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		out["properties"] = props
+		if len(required) > 0 {
+			out["required"] = required
+		}
+	}
+	return out
+}
+
+// value returns the annotation text s as a JSON value of typ, or as a
+// string when it is not one.
+func value(typ, s string) any {
+	if typ == "integer" || typ == "number" || typ == "boolean" {
+		var v any
+		if json.Unmarshal([]byte(s), &v) == nil {
+			return v
+		}
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package apispec
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sync"
+)
+
+// registryJSON is the registry as "make clients" last wrote it.
+//
+//go:embed registry.json
+var registryJSON []byte
+
+var defaultRegistry = sync.OnceValues(func() (*Registry, error) {
+	var reg Registry
+	err := json.Unmarshal(registryJSON, &reg)
+	return &reg, err
+})
+
+// Default returns the registry built into the binary, which the server
+// serves as its OpenAPI document.
+func Default() (*Registry, error) {
+	return defaultRegistry()
+}
+
+// Generate returns what apigen writes for reg, by path from the module
+// root.
+func Generate(reg *Registry) (map[string][]byte, error) {
+	registry, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	goClient, err := reg.GoClient("client")
+	if err != nil {
+		return nil, err
+	}
+	tsClient, err := reg.TypeScriptClient()
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		"internal/apispec/registry.json":  append(registry, '\n'),
+		"pkg/client/api_gen.go":           goClient,
+		"clients/typescript/notes-api.ts": tsClient,
+	}, nil
+}
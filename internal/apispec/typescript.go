@@ -0,0 +1,270 @@
+package apispec
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var tsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsRuntime is the hand-written part of the TypeScript client that the
+// generated methods call.
+const tsRuntime = `export class ApiError extends Error {
+  constructor(
+    public readonly status: number,
+    public readonly code: string,
+    message: string,
+    public readonly body: unknown,
+  ) {
+    super(message || "HTTP " + status);
+  }
+}
+
+export interface ClientOptions {
+  /** The server root, without /api/v1. */
+  baseUrl: string;
+  /** Sent as a bearer token when set. */
+  token?: string;
+  fetch?: typeof fetch;
+}
+
+type Scalar = string | number | boolean | undefined;
+
+interface Call {
+  query?: Record<string, Scalar>;
+  headers?: Record<string, Scalar>;
+  body?: unknown;
+  contentType?: string;
+  /** How to read a successful response. */
+  response: "json" | "text" | "blob" | "none";
+}
+
+function prefixed(prefix: string, values?: Record<string, string>): Record<string, string> {
+  const out: Record<string, string> = {};
+  for (const [key, value] of Object.entries(values ?? {})) {
+    out[prefix + key] = value;
+  }
+  return out;
+}
+`
+
+// TypeScriptClient returns the typed TypeScript client of the registry: an
+// interface per type and a NotesApiClient with a method per route.
+func (r *Registry) TypeScriptClient() ([]byte, error) {
+	g := &tsGen{reg: r, names: goTypeNames(r.Types)}
+	var b bytes.Buffer
+	b.WriteString(Generated + "\n\n")
+	b.WriteString(tsRuntime)
+
+	for _, name := range sortedKeys(r.Types) {
+		fmt.Fprintf(&b, "\n/** %s of the API. */\nexport type %s = %s;\n", name, g.names[name], g.tsType(r.Types[name], ""))
+	}
+
+	b.WriteString(`
+export class NotesApiClient {
+  constructor(private readonly options: ClientOptions) {}
+
+  private async call<T>(method: string, path: string, call: Call): Promise<T> {
+    const url = new URL(this.options.baseUrl.replace(/\/$/, "") + path);
+    for (const [key, value] of Object.entries(call.query ?? {})) {
+      if (value !== undefined && value !== "") url.searchParams.set(key, String(value));
+    }
+    const headers: Record<string, string> = {};
+    for (const [key, value] of Object.entries(call.headers ?? {})) {
+      if (value !== undefined && value !== "") headers[key] = String(value);
+    }
+    let body: BodyInit | undefined;
+    if (call.contentType) {
+      headers["Content-Type"] = call.contentType;
+      body = call.body as BodyInit;
+    } else if (call.body !== undefined) {
+      headers["Content-Type"] = "application/json";
+      body = JSON.stringify(call.body);
+    }
+    if (this.options.token) headers["Authorization"] = "Bearer " + this.options.token;
+
+    const res = await (this.options.fetch ?? fetch)(url, { method, headers, body });
+    if (!res.ok) {
+      const text = await res.text();
+      let error: { code?: string; error?: string } = {};
+      try {
+        error = JSON.parse(text);
+      } catch {
+        // Not a JSON error body.
+      }
+      throw new ApiError(res.status, error.code ?? "", error.error ?? "", text);
+    }
+    if (res.status === 204 || call.response === "none") return undefined as T;
+    if (call.response === "text") return (await res.text()) as T;
+    if (call.response === "blob") return (await res.blob()) as T;
+    return (await res.json()) as T;
+  }
+`)
+	for _, route := range r.Routes {
+		g.method(&b, route)
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+type tsGen struct {
+	reg   *Registry
+	names map[string]string
+}
+
+func (g *tsGen) tsType(s Schema, indent string) string {
+	var t string
+	switch {
+	case s.Ref != "":
+		t = g.names[s.Ref]
+	case len(s.Enum) > 0:
+		values := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			values[i] = strconv.Quote(e)
+			if s.Type != "string" {
+				values[i] = e
+			}
+		}
+		t = strings.Join(values, " | ")
+	case s.Type == "string":
+		t = "string"
+	case s.Type == "integer" || s.Type == "number":
+		t = "number"
+	case s.Type == "boolean":
+		t = "boolean"
+	case s.Type == "array":
+		t = g.tsType(*s.Items, indent)
+		if strings.ContainsAny(t, " |") {
+			t = "(" + t + ")"
+		}
+		t += "[]"
+	case s.Type == "object" && s.Values != nil:
+		t = "Record<string, " + g.tsType(*s.Values, indent) + ">"
+	case s.Type == "object" && len(s.Properties) > 0:
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, p := range s.Properties {
+			if p.Description != "" {
+				fmt.Fprintf(&b, "%s  /** %s */\n", indent, strings.ReplaceAll(p.Description, "\n", " "))
+			}
+			name := p.Name
+			if !tsIdent.MatchString(name) {
+				name = strconv.Quote(name)
+			}
+			if !p.Required {
+				name += "?"
+			}
+			fmt.Fprintf(&b, "%s  %s: %s;\n", indent, name, g.tsType(p.Schema, indent+"  "))
+		}
+		b.WriteString(indent + "}")
+		t = b.String()
+	case s.Type == "object":
+		t = "Record<string, unknown>"
+	default:
+		t = "unknown"
+	}
+	if s.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+// method writes the client method of a route, named after its handler
+// like the Go client's.
+func (g *tsGen) method(b *bytes.Buffer, route Route) {
+	var args, query, headers, call []string
+	var params []string
+	path := g.reg.Info.BasePath + route.Path
+	for _, p := range route.Params {
+		name := tsName(p.Name)
+		typ := "string"
+		if p.Type == "integer" || p.Type == "number" {
+			typ = "number"
+		} else if p.Type == "boolean" {
+			typ = "boolean"
+		}
+		switch p.In {
+		case "path":
+			args = append(args, name+": "+typ)
+			path = strings.Replace(path, "{"+p.Name+"}", "${encodeURIComponent(String("+name+"))}", 1)
+		case "query", "header":
+			if prefix, ok := paramPrefix(p.Name); ok {
+				name = tsName(prefix)
+				params = append(params, fmt.Sprintf("    /** %s */\n    %s?: Record<string, string>;\n", p.Description, name))
+				query = append(query, fmt.Sprintf("...prefixed(%q, params?.%s)", prefix+".", name))
+				continue
+			}
+			params = append(params, fmt.Sprintf("    /** %s */\n    %s?: %s;\n", p.Description, name, typ))
+			entry := fmt.Sprintf("%q: params?.%s", p.Name, name)
+			if p.In == "header" {
+				headers = append(headers, entry)
+			} else {
+				query = append(query, entry)
+			}
+		}
+	}
+	if body, ok := route.Body(); ok {
+		args = append(args, "body: "+g.tsType(*body.Schema, "  "))
+		call = append(call, "body")
+	} else if route.RawBody() && len(route.Accept) == 1 {
+		args = append(args, "body: Blob | ArrayBuffer | Uint8Array")
+		call = append(call, "body", fmt.Sprintf("contentType: %q", route.Accept[0]))
+	} else if route.RawBody() {
+		args = append(args, "body: string | Blob | ArrayBuffer | Uint8Array", "contentType: string")
+		call = append(call, "body", "contentType")
+	}
+	if len(params) > 0 {
+		args = append(args, "params?: {\n"+strings.Join(params, "")+"  }")
+	}
+	if len(query) > 0 {
+		call = append(call, "query: { "+strings.Join(query, ", ")+" }")
+	}
+	if len(headers) > 0 {
+		call = append(call, "headers: { "+strings.Join(headers, ", ")+" }")
+	}
+
+	out, response := "void", "none"
+	if success, ok := route.Success(); ok && success.Schema != nil {
+		switch {
+		case success.Schema.Format == "binary":
+			out, response = "Blob", "blob"
+		case success.Schema.Type == "string" && len(route.Produce) > 0 && route.Produce[0] != "application/json":
+			out, response = "string", "text"
+		default:
+			out, response = g.tsType(*success.Schema, "  "), "json"
+		}
+		if route.response(http.StatusNoContent) != nil {
+			out += " | undefined"
+		}
+	}
+	call = append(call, fmt.Sprintf("response: %q", response))
+
+	fmt.Fprintf(b, "\n  /** %s %s", route.Method, route.Path)
+	if route.Summary != "" {
+		fmt.Fprintf(b, ": %s", route.Summary)
+	}
+	if route.Deprecated {
+		b.WriteString(" @deprecated")
+	}
+	b.WriteString(" */\n")
+	fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", tsName(route.Handler), strings.Join(args, ", "), out)
+	fmt.Fprintf(b, "    return this.call(%q, `%s`, { %s });\n  }\n", route.Method, path, strings.Join(call, ", "))
+}
+
+// tsName returns a name in lowerCamelCase: notebook_id is notebookId and
+// ListNotes listNotes.
+func tsName(name string) string {
+	var b strings.Builder
+	for i, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(part[:1]) + part[1:])
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+// Package attach stores note attachments: their metadata in memory and
+// their content in a blob store. Large files arrive through resumable
+// uploads that are written in chunks and checked against a SHA-256 digest
+// before they become attachments.
+package attach
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"example.com/notes-api/internal/audit"
+	"example.com/notes-api/internal/blob"
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+var ErrNotFound = errors.New("attachment not found")
+
+// RejectedError is returned by Add for files the Scanner rejected.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "attachment rejected: " + e.Reason
+}
+
+// Store keeps the attachments of every note.
+type Store struct {
+	Clock clock.Clock
+	Blobs blob.Store
+	// Scanner, when set, checks every file before it is stored. A scan
+	// that fails quarantines the file.
+	Scanner Scanner
+	// Audit, when set, records rejected, quarantined and released files.
+	Audit *audit.Log
+	// OnReady, when set, is called with every attachment that can be
+	// downloaded: when it is added clean or released from quarantine.
+	OnReady func(core.Attachment)
+
+	mu          sync.Mutex
+	next        int64
+	attachments map[int64]*core.Attachment
+	uploads     map[string]*Upload
+}
+
+func NewStore(blobs blob.Store) *Store {
+	return &Store{
+		Clock:       clock.System{},
+		Blobs:       blobs,
+		attachments: make(map[int64]*core.Attachment),
+		uploads:     make(map[string]*Upload),
+	}
+}
+
+// Add stores data as a new attachment described by a, filling in its ID,
+// size, digest, scan status and creation time. Files the Scanner rejects
+// are not stored and give a *RejectedError.
+func (s *Store) Add(a core.Attachment, data []byte) (core.Attachment, error) {
+	sum := sha256.Sum256(data)
+	a.Size = int64(len(data))
+	a.SHA256 = hex.EncodeToString(sum[:])
+	if a.ContentType == "" {
+		a.ContentType = http.DetectContentType(data)
+	}
+
+	a.Status = Clean
+	if s.Scanner != nil {
+		f, err := s.Scanner.Scan(a.Name, a.ContentType, data)
+		if err != nil {
+			f = Finding{Quarantine, "scan failed: " + err.Error()}
+		}
+		target := "note:" + strconv.FormatInt(a.NoteID, 10)
+		switch f.Verdict {
+		case Reject:
+			s.audit(a.OwnerID, "attachment.rejected", target, a.Name+": "+f.Reason)
+			return core.Attachment{}, &RejectedError{Reason: f.Reason}
+		case Quarantine:
+			s.audit(a.OwnerID, "attachment.quarantined", target, a.Name+": "+f.Reason)
+			a.Status, a.ScanReason = Quarantine, f.Reason
+		}
+	}
+
+	s.mu.Lock()
+	s.next++
+	a.ID = s.next
+	s.mu.Unlock()
+
+	if err := s.Blobs.Put(key(a.ID), data); err != nil {
+		return core.Attachment{}, err
+	}
+
+	s.mu.Lock()
+	a.CreatedAt = s.Clock.Now()
+	s.attachments[a.ID] = &a
+	s.mu.Unlock()
+
+	if a.Status == Clean && s.OnReady != nil {
+		s.OnReady(a)
+	}
+	return a, nil
+}
+
+// List returns the attachments of a note, oldest first.
+func (s *Store) List(noteID int64) []core.Attachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]core.Attachment, 0)
+	for _, a := range s.attachments {
+		if a.NoteID == noteID {
+			list = append(list, *a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (s *Store) Get(id int64) (core.Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attachments[id]
+	if !ok {
+		return core.Attachment{}, ErrNotFound
+	}
+	return *a, nil
+}
+
+// Open returns the content of an attachment.
+func (s *Store) Open(id int64) ([]byte, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return s.Blobs.Get(key(id))
+}
+
+// Release clears the quarantine of an attachment.
+func (s *Store) Release(id int64, actor string) (core.Attachment, error) {
+	s.mu.Lock()
+	a, ok := s.attachments[id]
+	var released core.Attachment
+	if ok {
+		a.Status, a.ScanReason = Clean, ""
+		released = *a
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return core.Attachment{}, ErrNotFound
+	}
+	s.audit(actor, "attachment.released", "attachment:"+strconv.FormatInt(id, 10), released.Name)
+	if s.OnReady != nil {
+		s.OnReady(released)
+	}
+	return released, nil
+}
+
+// SetText records the text recognized in an attachment.
+func (s *Store) SetText(id int64, text string) (core.Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attachments[id]
+	if !ok {
+		return core.Attachment{}, ErrNotFound
+	}
+	a.Text = text
+	return *a, nil
+}
+
+func (s *Store) Delete(id int64) error {
+	s.mu.Lock()
+	_, ok := s.attachments[id]
+	delete(s.attachments, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	return s.Blobs.Delete(key(id))
+}
+
+// Apply deletes the attachments and unfinished uploads of deleted notes.
+// Register it with NoteRepoMem.OnChange.
+func (s *Store) Apply(c repo.Change) {
+	if c.Op != repo.ChangeDeleted {
+		return
+	}
+	for _, a := range s.List(c.Note.ID) {
+		s.Delete(a.ID)
+	}
+
+	s.mu.Lock()
+	var uploads []string
+	for id, u := range s.uploads {
+		if u.NoteID == c.Note.ID {
+			uploads = append(uploads, id)
+		}
+	}
+	s.mu.Unlock()
+	for _, id := range uploads {
+		s.CancelUpload(id)
+	}
+}
+
+func (s *Store) audit(actor, action, target, detail string) {
+	if s.Audit != nil {
+		s.Audit.Record(actor, action, target, detail)
+	}
+}
+
+func key(id int64) string {
+	return "attachments/" + strconv.FormatInt(id, 10)
+}
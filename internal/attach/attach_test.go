@@ -0,0 +1,55 @@
+package attach
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/blob"
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+func TestUploadExpires(t *testing.T) {
+	blobs := blob.NewMem()
+	s := NewStore(blobs)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s.Clock = fake
+
+	sum := sha256.Sum256([]byte("hello"))
+	u, err := s.StartUpload(1, "alice", "a.txt", "text/plain", 5, hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.WriteChunk(u.ID, 0, []byte("he")); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(UploadTTL)
+	if _, _, err := s.WriteChunk(u.ID, 2, []byte("llo")); err != ErrUploadNotFound {
+		t.Errorf("WriteChunk after expiry = %v", err)
+	}
+	if _, err := blobs.Get("uploads/" + u.ID + "/0"); err != blob.ErrNotFound {
+		t.Errorf("chunk kept after expiry: %v", err)
+	}
+
+	if _, err := s.StartUpload(1, "alice", "a.txt", "", core.MaxAttachmentSize+1, hex.EncodeToString(sum[:])); err != ErrTooLarge {
+		t.Errorf("oversized StartUpload = %v", err)
+	}
+	if _, err := s.StartUpload(1, "alice", "a.txt", "", 5, "abc"); err != ErrInvalidChecksum {
+		t.Errorf("StartUpload with bad digest = %v", err)
+	}
+}
+
+func TestDeletedNote(t *testing.T) {
+	s := NewStore(blob.NewMem())
+	a, err := s.Add(core.Attachment{NoteID: 1, Name: "a.txt"}, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Apply(repo.Change{Op: repo.ChangeDeleted, Note: core.Note{ID: 1}})
+	if _, err := s.Open(a.ID); err != ErrNotFound {
+		t.Errorf("Open after note deletion = %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package attach
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Verdicts a Scanner can reach, from least to most severe.
+const (
+	Clean      = "clean"
+	Quarantine = "quarantined"
+	Reject     = "rejected"
+)
+
+var severity = map[string]int{Clean: 0, Quarantine: 1, Reject: 2}
+
+// Finding is what a Scanner concluded about a file.
+type Finding struct {
+	Verdict string
+	Reason  string
+}
+
+// Scanner inspects a file before it is stored. Quarantined files are kept
+// but only admins can download them; rejected files are not stored.
+type Scanner interface {
+	Scan(name, contentType string, data []byte) (Finding, error)
+}
+
+// Chain runs every scanner and keeps the most severe finding.
+type Chain []Scanner
+
+func (c Chain) Scan(name, contentType string, data []byte) (Finding, error) {
+	worst := Finding{Verdict: Clean}
+	for _, s := range c {
+		f, err := s.Scan(name, contentType, data)
+		if err != nil {
+			return Finding{}, err
+		}
+		if severity[f.Verdict] > severity[worst.Verdict] {
+			worst = f
+		}
+	}
+	return worst, nil
+}
+
+// executables are the extensions rejected outright.
+var executables = map[string]bool{
+	".exe": true, ".dll": true, ".com": true, ".scr": true, ".msi": true,
+	".bat": true, ".cmd": true, ".ps1": true, ".vbs": true, ".js": true,
+	".jar": true, ".sh": true, ".apk": true,
+}
+
+// generic are sniffed types too broad to contradict a declared type:
+// documents such as .docx are zip files and .svg images are XML.
+var generic = map[string]bool{
+	"application/octet-stream": true,
+	"text/plain":               true,
+	"text/xml":                 true,
+	"application/zip":          true,
+}
+
+// Sniffer checks a file's name and declared type against its content. It
+// rejects executables and quarantines files whose content does not match
+// their extension, such as an HTML page renamed to photo.jpg.
+type Sniffer struct{}
+
+func (Sniffer) Scan(name, contentType string, data []byte) (Finding, error) {
+	ext := strings.ToLower(path.Ext(name))
+	if executables[ext] {
+		return Finding{Reject, "executable file type " + ext}, nil
+	}
+	if bytes.HasPrefix(data, []byte("MZ")) || bytes.HasPrefix(data, []byte("\x7fELF")) {
+		return Finding{Reject, "executable content"}, nil
+	}
+
+	sniffed := baseType(http.DetectContentType(data))
+	if generic[sniffed] {
+		return Finding{Verdict: Clean}, nil
+	}
+	expected := []string{baseType(contentType), baseType(mime.TypeByExtension(ext))}
+	for _, t := range expected {
+		if t != "" && t != "application/octet-stream" && !media(t, sniffed) && t != sniffed {
+			return Finding{Quarantine, fmt.Sprintf("content is %s, not %s", sniffed, t)}, nil
+		}
+	}
+	return Finding{Verdict: Clean}, nil
+}
+
+// media reports whether both types are audio or video. Containers such as
+// MP4 and Ogg hold either, so sniffing cannot tell an .m4a voice memo from
+// a video.
+func media(a, b string) bool {
+	isMedia := func(t string) bool {
+		return strings.HasPrefix(t, "audio/") || strings.HasPrefix(t, "video/") || t == "application/ogg"
+	}
+	return isMedia(a) && isMedia(b)
+}
+
+func baseType(t string) string {
+	if base, _, err := mime.ParseMediaType(t); err == nil {
+		return base
+	}
+	return ""
+}
+
+// ClamAV scans files with a clamd daemon listening on Addr (host:port).
+type ClamAV struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+var errClamAV = errors.New("clamav")
+
+// clamChunk is the size of the chunks streamed to clamd, which rejects
+// chunks over its StreamMaxLength.
+const clamChunk = 64 << 10
+
+func (c ClamAV) Scan(name, contentType string, data []byte) (Finding, error) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	conn, err := net.DialTimeout("tcp", c.Addr, timeout)
+	if err != nil {
+		return Finding{}, fmt.Errorf("%w: %v", errClamAV, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("zINSTREAM\x00")
+	for len(data) > 0 {
+		n := min(len(data), clamChunk)
+		binary.Write(w, binary.BigEndian, uint32(n))
+		w.Write(data[:n])
+		data = data[n:]
+	}
+	binary.Write(w, binary.BigEndian, uint32(0))
+	if err := w.Flush(); err != nil {
+		return Finding{}, fmt.Errorf("%w: %v", errClamAV, err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return Finding{}, fmt.Errorf("%w: %v", errClamAV, err)
+	}
+	reply = strings.TrimPrefix(strings.TrimSuffix(reply, "\x00"), "stream: ")
+	switch {
+	case reply == "OK":
+		return Finding{Verdict: Clean}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Finding{Reject, "malware: " + strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return Finding{}, fmt.Errorf("%w: %s", errClamAV, reply)
+	}
+}
@@ -0,0 +1,89 @@
+package attach
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestSniffer(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	for _, tc := range []struct {
+		name, contentType string
+		data              []byte
+		verdict           string
+	}{
+		{"notes.txt", "text/plain", []byte("hello"), Clean},
+		{"diagram.png", "image/png", png, Clean},
+		{"diagram.png", "", png, Clean},
+		{"report.docx", "", []byte("PK\x03\x04"), Clean},
+		{"memo.m4a", "audio/mp4", []byte("\x00\x00\x00\x18ftypM4A \x00\x00\x00\x00"), Clean},
+		{"photo.jpg", "", png, Quarantine},
+		{"diagram.png", "application/pdf", png, Quarantine},
+		{"install.sh", "", []byte("echo hi"), Reject},
+		{"photo.jpg", "", []byte("MZ\x90\x00"), Reject},
+	} {
+		f, err := Sniffer{}.Scan(tc.name, tc.contentType, tc.data)
+		if err != nil || f.Verdict != tc.verdict {
+			t.Errorf("Scan(%q, %q) = %+v, %v; want %s", tc.name, tc.contentType, f, err, tc.verdict)
+		}
+	}
+}
+
+// fakeClamd answers INSTREAM commands like clamd, flagging streams that
+// contain the EICAR marker.
+func fakeClamd(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			if cmd, _ := r.ReadString(0); cmd != "zINSTREAM\x00" {
+				conn.Write([]byte("UNKNOWN COMMAND\x00"))
+				conn.Close()
+				continue
+			}
+			var data []byte
+			for {
+				var n uint32
+				if binary.Read(r, binary.BigEndian, &n) != nil || n == 0 {
+					break
+				}
+				chunk := make([]byte, n)
+				io.ReadFull(r, chunk)
+				data = append(data, chunk...)
+			}
+			if strings.Contains(string(data), "EICAR") {
+				conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			} else {
+				conn.Write([]byte("stream: OK\x00"))
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestClamAV(t *testing.T) {
+	c := ClamAV{Addr: fakeClamd(t)}
+	if f, err := c.Scan("a.txt", "", []byte(strings.Repeat("x", 3*clamChunk))); err != nil || f.Verdict != Clean {
+		t.Errorf("clean file = %+v, %v", f, err)
+	}
+	f, err := c.Scan("a.txt", "", []byte("X5O!P%@AP-EICAR-STANDARD-ANTIVIRUS-TEST-FILE"))
+	if err != nil || f.Verdict != Reject || f.Reason != "malware: Eicar-Test-Signature" {
+		t.Errorf("EICAR = %+v, %v", f, err)
+	}
+	if _, err := (ClamAV{Addr: "127.0.0.1:1"}).Scan("a.txt", "", nil); err == nil {
+		t.Error("no error without a daemon")
+	}
+}
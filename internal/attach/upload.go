@@ -0,0 +1,200 @@
+package attach
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+const (
+	// MaxChunkSize bounds the data sent in one request.
+	MaxChunkSize = 16 << 20
+	// UploadTTL is how long an unfinished upload can be resumed after it
+	// was started.
+	UploadTTL = 24 * time.Hour
+)
+
+var (
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrOffsetMismatch   = errors.New("upload offset does not match")
+	ErrChecksumMismatch = errors.New("uploaded data does not match sha256")
+	ErrTooLarge         = errors.New("attachment is too large")
+	ErrInvalidChecksum  = errors.New("sha256 must be 64 hex digits")
+)
+
+// Upload is an attachment being uploaded in chunks. Offset is the number of
+// bytes received so far; a client that lost its connection asks for it and
+// goes on from there.
+type Upload struct {
+	ID          string    `json:"id" example:"3f2a9c1e5b7d4a60"`
+	NoteID      int64     `json:"note_id" example:"1"`
+	Name        string    `json:"name" example:"whiteboard.jpg"`
+	ContentType string    `json:"content_type" example:"image/jpeg"`
+	Size        int64     `json:"size" example:"1048576"`
+	SHA256      string    `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Offset      int64     `json:"offset" example:"524288"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	OwnerID     string    `json:"-"`
+
+	chunks []string
+}
+
+// StartUpload begins an upload of size bytes whose content must hash to
+// sum.
+func (s *Store) StartUpload(noteID int64, ownerID, name, contentType string, size int64, sum string) (Upload, error) {
+	if err := core.ValidateAttachmentName(name); err != nil {
+		return Upload{}, err
+	}
+	if size < 0 || size > core.MaxAttachmentSize {
+		return Upload{}, ErrTooLarge
+	}
+	sum = strings.ToLower(sum)
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+		return Upload{}, ErrInvalidChecksum
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+	s.expire(now)
+	u := &Upload{
+		ID:          hex.EncodeToString(id),
+		NoteID:      noteID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      sum,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(UploadTTL),
+		OwnerID:     ownerID,
+	}
+	s.uploads[u.ID] = u
+	return u.snapshot(), nil
+}
+
+// Upload returns the upload with id.
+func (s *Store) Upload(id string) (Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(s.Clock.Now())
+	u, ok := s.uploads[id]
+	if !ok {
+		return Upload{}, ErrUploadNotFound
+	}
+	return u.snapshot(), nil
+}
+
+// WriteChunk appends data to an upload at offset, which must be the upload's
+// current offset. When the last byte arrives the content is checked against
+// the digest given at the start and stored as an attachment, which is
+// returned; otherwise the attachment is nil. A digest mismatch discards the
+// upload.
+func (s *Store) WriteChunk(id string, offset int64, data []byte) (Upload, *core.Attachment, error) {
+	s.mu.Lock()
+	s.expire(s.Clock.Now())
+	u, ok := s.uploads[id]
+	if !ok {
+		s.mu.Unlock()
+		return Upload{}, nil, ErrUploadNotFound
+	}
+	if offset != u.Offset {
+		snap := u.snapshot()
+		s.mu.Unlock()
+		return snap, nil, ErrOffsetMismatch
+	}
+	if len(data) > MaxChunkSize || u.Offset+int64(len(data)) > u.Size {
+		snap := u.snapshot()
+		s.mu.Unlock()
+		return snap, nil, ErrTooLarge
+	}
+	if len(data) > 0 {
+		key := "uploads/" + u.ID + "/" + strconv.FormatInt(offset, 10)
+		if err := s.Blobs.Put(key, data); err != nil {
+			s.mu.Unlock()
+			return Upload{}, nil, err
+		}
+		u.chunks = append(u.chunks, key)
+		u.Offset += int64(len(data))
+	}
+	if u.Offset < u.Size {
+		snap := u.snapshot()
+		s.mu.Unlock()
+		return snap, nil, nil
+	}
+	delete(s.uploads, id)
+	s.mu.Unlock()
+
+	defer s.discard(u)
+	var buf bytes.Buffer
+	for _, key := range u.chunks {
+		chunk, err := s.Blobs.Get(key)
+		if err != nil {
+			return Upload{}, nil, err
+		}
+		buf.Write(chunk)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	if hex.EncodeToString(sum[:]) != u.SHA256 {
+		return u.snapshot(), nil, ErrChecksumMismatch
+	}
+	a, err := s.Add(core.Attachment{
+		NoteID:      u.NoteID,
+		Name:        u.Name,
+		ContentType: u.ContentType,
+		OwnerID:     u.OwnerID,
+	}, buf.Bytes())
+	if err != nil {
+		return Upload{}, nil, err
+	}
+	return u.snapshot(), &a, nil
+}
+
+// CancelUpload discards an upload and the chunks it received.
+func (s *Store) CancelUpload(id string) error {
+	s.mu.Lock()
+	u, ok := s.uploads[id]
+	delete(s.uploads, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrUploadNotFound
+	}
+	s.discard(u)
+	return nil
+}
+
+// expire drops uploads that can no longer be resumed. The caller holds
+// s.mu.
+func (s *Store) expire(now time.Time) {
+	for id, u := range s.uploads {
+		if !now.Before(u.ExpiresAt) {
+			delete(s.uploads, id)
+			s.discard(u)
+		}
+	}
+}
+
+func (s *Store) discard(u *Upload) {
+	for _, key := range u.chunks {
+		s.Blobs.Delete(key)
+	}
+}
+
+func (u *Upload) snapshot() Upload {
+	snap := *u
+	snap.chunks = nil
+	return snap
+}
@@ -0,0 +1,65 @@
+// Package audit keeps a record of security-relevant events, such as
+// rejected uploads, for admins to review.
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+)
+
+// Entry is one audited event.
+type Entry struct {
+	ID     int64     `json:"id" example:"1"`
+	At     time.Time `json:"at"`
+	Actor  string    `json:"actor" example:"alice"`
+	Action string    `json:"action" example:"attachment.rejected"`
+	// Target names what the action was about, e.g. note:1.
+	Target string `json:"target" example:"note:1"`
+	Detail string `json:"detail,omitempty" example:"invoice.pdf: executable content"`
+}
+
+// Log keeps the most recent entries in memory.
+type Log struct {
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	next    int64
+	entries []Entry
+	limit   int
+}
+
+// NewLog returns a log that keeps the last limit entries.
+func NewLog(limit int) *Log {
+	return &Log{Clock: clock.System{}, limit: limit}
+}
+
+// Record appends an entry, filling in its ID and time.
+func (l *Log) Record(actor, action, target, detail string) Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.next++
+	e := Entry{ID: l.next, At: l.Clock.Now(), Actor: actor, Action: action, Target: target, Detail: detail}
+	l.entries = append(l.entries, e)
+	if len(l.entries) > l.limit {
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.limit:]...)
+	}
+	return e
+}
+
+// List returns entries after the ID after, oldest first, at most limit of
+// them.
+func (l *Log) List(after int64, limit int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]Entry, 0)
+	for _, e := range l.entries {
+		if e.ID > after && len(list) < limit {
+			list = append(list, e)
+		}
+	}
+	return list
+}
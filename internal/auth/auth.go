@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Anonymous is the principal used when no tokens are configured: the API
+// then behaves as a single-user instance with full access.
+var Anonymous = core.Principal{UserID: "anonymous", Admin: true}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p core.Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+func FromContext(ctx context.Context) core.Principal {
+	if p, ok := ctx.Value(ctxKey{}).(core.Principal); ok {
+		return p
+	}
+	return Anonymous
+}
+
+// Tokens maps bearer tokens to principals.
+type Tokens map[string]core.Principal
+
+// Users returns the distinct user IDs the tokens authenticate, sorted.
+func (t Tokens) Users() []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, p := range t {
+		if !seen[p.UserID] {
+			seen[p.UserID] = true
+			users = append(users, p.UserID)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// ParseTokens reads a comma-separated list of token:user[:team|team][:admin]
+// entries, e.g. "s3cret:alice:devs|ops,t0ken:bob,r00t:root::admin".
+func ParseTokens(spec string) (Tokens, error) {
+	tokens := make(Tokens)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid token entry %q", entry)
+		}
+
+		p := core.Principal{UserID: parts[1]}
+		if len(parts) > 2 && parts[2] != "" {
+			p.Teams = strings.Split(parts[2], "|")
+		}
+		if len(parts) > 3 {
+			if parts[3] != "admin" {
+				return nil, fmt.Errorf("invalid token entry %q", entry)
+			}
+			p.Admin = true
+		}
+
+		tokens[parts[0]] = p
+	}
+	return tokens, nil
+}
@@ -0,0 +1,164 @@
+// Package blob stores generated files, such as export archives, and signs
+// URLs that let anyone holding them download a file until they expire.
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps files by key. Keys are slash-separated paths without dot
+// segments.
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// Mem keeps files in memory; they are lost on restart.
+type Mem struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func NewMem() *Mem {
+	return &Mem{files: make(map[string][]byte)}
+}
+
+func (m *Mem) Put(key string, data []byte) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Mem) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.files[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *Mem) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, key)
+	return nil
+}
+
+// Dir keeps files under Root, one file per key.
+type Dir struct {
+	Root string
+}
+
+func (d Dir) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(d.Root, filepath.FromSlash(key)), nil
+}
+
+func (d Dir) Put(key string, data []byte) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// Write under a temporary name so readers never see half a file.
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (d Dir) Get(key string) ([]byte, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (d Dir) Delete(key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// Signer signs download URLs with a secret key, so that they can be
+// checked without storing them.
+type Signer struct {
+	Key []byte
+}
+
+// Sign returns the query string that grants access to key until expires.
+func (s Signer) Sign(key string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return "expires=" + exp + "&sig=" + s.mac(key, exp)
+}
+
+// Verify reports whether sig grants access to key until the expires
+// timestamp, and whether that time has passed at now.
+func (s Signer) Verify(key, expires, sig string, now time.Time) (valid, expired bool) {
+	if !hmac.Equal([]byte(sig), []byte(s.mac(key, expires))) {
+		return false, false
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return false, false
+	}
+	return true, now.Unix() >= exp
+}
+
+func (s Signer) mac(key, expires string) string {
+	m := hmac.New(sha256.New, s.Key)
+	m.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(m.Sum(nil))
+}
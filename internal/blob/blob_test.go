@@ -0,0 +1,55 @@
+package blob
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	for name, s := range map[string]Store{"mem": NewMem(), "dir": Dir{Root: t.TempDir()}} {
+		if err := s.Put("exports/abc/vault.zip", []byte("PK")); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if data, err := s.Get("exports/abc/vault.zip"); err != nil || string(data) != "PK" {
+			t.Errorf("%s: Get = %q, %v", name, data, err)
+		}
+		for _, key := range []string{"", "/etc/passwd", "a/../../b", "a//b", `a\\b`} {
+			if err := s.Put(key, nil); err != ErrInvalidKey {
+				t.Errorf("%s: Put(%q) = %v", name, key, err)
+			}
+		}
+		if err := s.Delete("exports/abc/vault.zip"); err != nil {
+			t.Errorf("%s: Delete: %v", name, err)
+		}
+		if _, err := s.Get("exports/abc/vault.zip"); err != ErrNotFound {
+			t.Errorf("%s: Get after Delete = %v", name, err)
+		}
+	}
+}
+
+func TestSigner(t *testing.T) {
+	s := Signer{Key: []byte("secret")}
+	now := time.Unix(1700000000, 0)
+	q, err := url.ParseQuery(s.Sign("exports/a.zip", now.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, sig := q.Get("expires"), q.Get("sig")
+
+	if valid, expired := s.Verify("exports/a.zip", exp, sig, now); !valid || expired {
+		t.Errorf("fresh link: valid=%v expired=%v", valid, expired)
+	}
+	if _, expired := s.Verify("exports/a.zip", exp, sig, now.Add(time.Hour)); !expired {
+		t.Error("link outlived its expiry")
+	}
+	if valid, _ := s.Verify("exports/b.zip", exp, sig, now); valid {
+		t.Error("signature accepted for another key")
+	}
+	if valid, _ := s.Verify("exports/a.zip", "1800000000", sig, now); valid {
+		t.Error("signature accepted for another expiry")
+	}
+	if valid, _ := (Signer{Key: []byte("other")}).Verify("exports/a.zip", exp, sig, now); valid {
+		t.Error("signature accepted under another key")
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type call struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// Group coalesces concurrent calls with the same key: while one is in
+// flight, later callers wait for its result instead of repeating it.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+
+	coalesced atomic.Uint64
+}
+
+// Do runs fn once for all concurrent callers with key. shared reports
+// whether the result came from another caller's call.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		g.coalesced.Add(1)
+		<-c.done
+		return c.val, c.err, true
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// Coalesced counts the calls answered by another caller's call.
+func (g *Group) Coalesced() uint64 {
+	return g.coalesced.Load()
+}
@@ -0,0 +1,128 @@
+// Package cache keeps recent note list results in memory so that hot
+// queries do not rescan every note.
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+// AllNotebooks is the scope of lists that are not limited to one notebook.
+const AllNotebooks = -1
+
+type entry struct {
+	notes   []core.Note
+	scope   int64
+	ids     map[int64]bool
+	expires time.Time
+}
+
+// Lists caches note lists by key for up to TTL. A change to a note drops
+// only the lists it can affect: those containing it, those scoped to its
+// notebook and the unscoped ones. Concurrent misses on one key share a
+// single load.
+type Lists struct {
+	TTL   time.Duration
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// gen counts invalidations; loads that raced one are not stored.
+	gen   uint64
+	loads Group
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewLists(ttl time.Duration) *Lists {
+	return &Lists{TTL: ttl, Clock: clock.System{}, entries: make(map[string]*entry)}
+}
+
+// Get returns the list cached under key, calling load on a miss. scope is
+// the notebook the list is limited to, or AllNotebooks. The returned slice
+// is the caller's to modify.
+func (c *Lists) Get(key string, scope int64, load func() ([]core.Note, error)) ([]core.Note, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && c.Clock.Now().Before(e.expires) {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return clone(e.notes), nil
+	}
+	c.mu.Unlock()
+
+	v, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		c.mu.Lock()
+		gen := c.gen
+		c.mu.Unlock()
+
+		c.misses.Add(1)
+		notes, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if gen == c.gen {
+			ids := make(map[int64]bool, len(notes))
+			for _, n := range notes {
+				ids[n.ID] = true
+			}
+			c.entries[key] = &entry{notes: notes, scope: scope, ids: ids, expires: c.Clock.Now().Add(c.TTL)}
+		}
+		return notes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clone(v.([]core.Note)), nil
+}
+
+// Invalidate drops the lists a change to n can affect.
+func (c *Lists) Invalidate(n core.Note) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for key, e := range c.entries {
+		if e.scope == AllNotebooks || e.scope == n.NotebookID || e.ids[n.ID] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Flush drops every list, for changes such as notebook shares that can
+// affect any of them.
+func (c *Lists) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.entries = make(map[string]*entry)
+}
+
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Coalesced uint64
+	Entries   int
+}
+
+func (c *Lists) Stats() Stats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Coalesced: c.loads.Coalesced(), Entries: entries}
+}
+
+// clone copies a cached list, keeping empty lists non-nil so that they
+// encode as [] rather than null.
+func clone(notes []core.Note) []core.Note {
+	return append(make([]core.Note, 0, len(notes)), notes...)
+}
@@ -0,0 +1,374 @@
+// Package clip extracts the readable article from a web page, leaving out
+// navigation, ads and other clutter, and converts it to Markdown.
+package clip
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrNoContent = errors.New("no readable content found")
+
+// Article is the readable part of a page.
+type Article struct {
+	Title string
+	// Content is the article as Markdown, with links made absolute.
+	Content string
+}
+
+// Extract finds the article in an HTML page fetched from base, following
+// the approach of Mozilla's Readability: paragraphs score the blocks that
+// contain them by length and commas, class names hint at content or
+// clutter, and link-heavy blocks are discounted. The best scoring block
+// becomes the article.
+func Extract(r io.Reader, base *url.URL) (Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Article{}, err
+	}
+
+	title := metaTitle(doc)
+	prune(doc)
+	best := bestCandidate(doc)
+	if best == nil {
+		return Article{}, ErrNoContent
+	}
+
+	w := &writer{base: base}
+	w.node(best)
+	content := strings.TrimSpace(blankLines.ReplaceAllString(w.b.String(), "\n\n"))
+	if content == "" {
+		return Article{}, ErrNoContent
+	}
+	if title == "" {
+		title = firstHeading(best)
+	}
+	return Article{Title: title, Content: content}, nil
+}
+
+// clutter are elements that never hold the article.
+var clutter = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Svg: true, atom.Select: true,
+	atom.Input: true, atom.Textarea: true, atom.Template: true,
+}
+
+var (
+	positive   = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	negative   = regexp.MustCompile(`(?i)comment|sidebar|footer|foot|nav|menu|masthead|banner|promo|related|share|social|sponsor|advert|\bads?\b|widget|popup|cookie|subscribe`)
+	blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+	spaces     = regexp.MustCompile(`\s+`)
+)
+
+// prune removes clutter, and blocks whose class or id mark them as
+// clutter unless they also look like content.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || c.Type == html.ElementNode && (clutter[c.DataAtom] || unlikely(c)) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func unlikely(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	hints := attr(n, "class") + " " + attr(n, "id")
+	return negative.MatchString(hints) && !positive.MatchString(hints)
+}
+
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Td || n.DataAtom == atom.Blockquote) {
+			text := strings.TrimSpace(textOf(n))
+			if len([]rune(text)) >= 25 {
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len([]rune(text)))/100, 3)
+				if p := n.Parent; p != nil {
+					scores[p] += score
+					if g := p.Parent; g != nil {
+						scores[g] += score / 2
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		hints := attr(n, "class") + " " + attr(n, "id")
+		if positive.MatchString(hints) {
+			score += 25
+		}
+		if negative.MatchString(hints) {
+			score -= 25
+		}
+		if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+			score += 10
+		}
+		score *= 1 - linkDensity(n)
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return findBody(doc)
+	}
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len([]rune(textOf(n)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			links += len([]rune(textOf(n)))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(links) / float64(total)
+}
+
+func findBody(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Body {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if b := findBody(c); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+// metaTitle returns the Open Graph title, or the document title.
+func metaTitle(doc *html.Node) string {
+	var og, title string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Meta:
+				if attr(n, "property") == "og:title" && og == "" {
+					og = strings.TrimSpace(attr(n, "content"))
+				}
+			case atom.Title:
+				if title == "" {
+					title = collapse(textOf(n))
+				}
+			case atom.Body:
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if og != "" {
+		return og
+	}
+	return title
+}
+
+func firstHeading(n *html.Node) string {
+	if n.Type == html.ElementNode && (n.DataAtom == atom.H1 || n.DataAtom == atom.H2) {
+		return collapse(textOf(n))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if h := firstHeading(c); h != "" {
+			return h
+		}
+	}
+	return ""
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
+
+func collapse(s string) string {
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// writer converts an HTML subtree to Markdown.
+type writer struct {
+	base      *url.URL
+	b         strings.Builder
+	lineStart bool
+	pre       bool
+	list      []atom.Atom
+}
+
+func (w *writer) write(s string) {
+	w.b.WriteString(s)
+	w.lineStart = strings.HasSuffix(s, "\n")
+}
+
+func (w *writer) newline() {
+	if !w.lineStart && w.b.Len() > 0 {
+		w.write("\n")
+	}
+}
+
+func (w *writer) block(f func()) {
+	w.newline()
+	w.write("\n")
+	f()
+	w.newline()
+	w.write("\n")
+}
+
+func (w *writer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *writer) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre {
+			w.write(n.Data)
+			return
+		}
+		text := spaces.ReplaceAllString(n.Data, " ")
+		if w.lineStart || w.b.Len() == 0 {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text != "" {
+			w.write(text)
+		}
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block(func() {
+			w.write(strings.Repeat("#", int(n.Data[1]-'0')) + " " + collapse(textOf(n)))
+		})
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Figure, atom.Table, atom.Tr:
+		w.block(func() { w.children(n) })
+	case atom.Br:
+		w.write("\n")
+	case atom.Hr:
+		w.block(func() { w.write("---") })
+	case atom.Pre:
+		w.block(func() {
+			w.write("```\n")
+			w.pre = true
+			w.children(n)
+			w.pre = false
+			w.newline()
+			w.write("```")
+		})
+	case atom.Code:
+		if w.pre {
+			w.children(n)
+		} else {
+			w.write("`" + textOf(n) + "`")
+		}
+	case atom.Blockquote:
+		inner := &writer{base: w.base}
+		inner.children(n)
+		text := strings.TrimSpace(blankLines.ReplaceAllString(inner.b.String(), "\n\n"))
+		w.block(func() { w.write("> " + strings.ReplaceAll(text, "\n", "\n> ")) })
+	case atom.Ul, atom.Ol:
+		w.list = append(w.list, n.DataAtom)
+		w.block(func() { w.children(n) })
+		w.list = w.list[:len(w.list)-1]
+	case atom.Li:
+		w.newline()
+		marker := "- "
+		if len(w.list) > 0 && w.list[len(w.list)-1] == atom.Ol {
+			marker = "1. "
+		}
+		w.write(strings.Repeat("  ", max(len(w.list)-1, 0)) + marker)
+		w.children(n)
+		w.newline()
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "*")
+	case atom.A:
+		href := w.resolve(attr(n, "href"))
+		text := collapse(textOf(n))
+		if href == "" || text == "" {
+			w.children(n)
+		} else {
+			w.write("[" + text + "](" + href + ")")
+		}
+	case atom.Img:
+		if src := w.resolve(attr(n, "src")); src != "" {
+			w.write("![" + collapse(attr(n, "alt")) + "](" + src + ")")
+		}
+	default:
+		w.children(n)
+	}
+}
+
+func (w *writer) wrap(n *html.Node, mark string) {
+	text := collapse(textOf(n))
+	if text != "" {
+		w.write(mark + text + mark)
+	}
+}
+
+// resolve makes ref absolute, keeping only http(s) links.
+func (w *writer) resolve(ref string) string {
+	if ref == "" || w.base == nil {
+		return ref
+	}
+	u, err := w.base.Parse(strings.TrimSpace(ref))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,50 @@
+package clip
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	page := `<html><head><title>Site | Story</title><meta property="og:title" content="Story"></head><body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<div id="main-content">
+  <h2>The story</h2>
+  <p>It began, as these things do, with a <strong>small</strong> change to a <em>large</em> system.</p>
+  <pre><code>go test ./...</code></pre>
+  <ul><li>One</li><li>Two <a href="https://go.dev/">links</a></li></ul>
+  <blockquote><p>Quoted, at length, from somewhere else entirely.</p></blockquote>
+  <img src="img/a.png" alt="A figure">
+</div>
+<div class="comments"><p>First! This comment is long enough to count, surely.</p></div>
+<footer><p>Copyright, all rights reserved, forever and ever.</p></footer>
+<script>track()</script>
+</body></html>`
+	base, _ := url.Parse("https://example.com/posts/1")
+
+	a, err := Extract(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Title != "Story" {
+		t.Errorf("title = %q", a.Title)
+	}
+	want := "## The story\n\n" +
+		"It began, as these things do, with a **small** change to a *large* system.\n\n" +
+		"```\ngo test ./...\n```\n\n" +
+		"- One\n- Two [links](https://go.dev/)\n\n" +
+		"> Quoted, at length, from somewhere else entirely.\n\n" +
+		"![A figure](https://example.com/posts/img/a.png)"
+	if a.Content != want {
+		t.Errorf("content =\n%s\nwant\n%s", a.Content, want)
+	}
+}
+
+func TestExtractEmpty(t *testing.T) {
+	_, err := Extract(strings.NewReader(`<html><body><script>x()</script></body></html>`), nil)
+	if !errors.Is(err, ErrNoContent) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Package clock lets time-dependent code run against a controllable time
+// source in tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time, always in UTC.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the real clock.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Fake is a Clock that only moves when told to. It is safe for concurrent
+// use.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now.UTC()}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now.UTC()
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (f *Fake) Advance(d time.Duration) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	return f.now
+}
@@ -0,0 +1,435 @@
+// Package cloudsync keeps notes in two-way sync with a folder of Markdown
+// files in the vault layout.
+package cloudsync
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/vault"
+)
+
+type Conflict struct {
+	Path   string    `json:"path" example:"Work/Plan.md"`
+	NoteID int64     `json:"note_id" example:"1"`
+	CopyID int64     `json:"copy_id" example:"7"`
+	At     time.Time `json:"at"`
+}
+
+// maxConflicts bounds how many recent conflicts Status reports.
+const maxConflicts = 50
+
+// Status describes the last run. Conflicts accumulate across runs, newest
+// last, so they stay visible after the run that found them.
+type Status struct {
+	Remote    string     `json:"remote" example:"dir:/home/user/Dropbox/Notes"`
+	Running   bool       `json:"running"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	Pushed    int        `json:"pushed"`
+	Pulled    int        `json:"pulled"`
+	Imported  int        `json:"imported"`
+	Deleted   int        `json:"deleted"`
+	// Locked counts notes left for a later run because someone else
+	// has locked them for editing.
+	Locked    int        `json:"locked"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// synced records what a note and its file looked like after the last run,
+// so that each side's changes can be told apart.
+type synced struct {
+	path   string
+	local  string
+	remote string
+}
+
+// Syncer syncs the notes owned by Principal, or every note for an admin.
+// When both sides of a note changed since the last run, the note keeps its
+// own version and the remote one is imported as a separate conflict copy.
+type Syncer struct {
+	Notes     core.NoteRepository
+	Notebooks *repo.NotebookRepoMem
+	Remote    Remote
+	Principal core.Principal
+
+	run    sync.Mutex
+	mu     sync.Mutex
+	state  map[int64]synced
+	status Status
+}
+
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.status
+	st.Remote = s.Remote.String()
+	st.Conflicts = append([]Conflict{}, st.Conflicts...)
+	return st
+}
+
+// Start runs the sync every interval until ctx is cancelled.
+func (s *Syncer) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Run(ctx); err != nil {
+				log.Printf("sync with %s: %v", s.Remote, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run performs one sync. Runs never overlap.
+func (s *Syncer) Run(ctx context.Context) error {
+	s.run.Lock()
+	defer s.run.Unlock()
+
+	s.mu.Lock()
+	s.status.Running = true
+	if s.state == nil {
+		s.state = make(map[int64]synced)
+	}
+	s.mu.Unlock()
+
+	r := &syncRun{Syncer: s, ctx: ctx, now: time.Now()}
+	err := r.sync()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running = false
+	s.status.LastRun = &r.now
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	s.status.Pushed, s.status.Pulled = r.pushed, r.pulled
+	s.status.Imported, s.status.Deleted = r.imported, r.deleted
+	s.status.Locked = r.locked
+	s.status.Conflicts = append(s.status.Conflicts, r.conflicts...)
+	if extra := len(s.status.Conflicts) - maxConflicts; extra > 0 {
+		s.status.Conflicts = s.status.Conflicts[extra:]
+	}
+	return err
+}
+
+type syncRun struct {
+	*Syncer
+	ctx context.Context
+	now time.Time
+
+	pushed, pulled, imported, deleted, locked int
+	conflicts                                 []Conflict
+}
+
+func (r *syncRun) sync() error {
+	remote, err := r.Remote.List(r.ctx)
+	if err != nil {
+		return err
+	}
+	notes, err := r.owned()
+	if err != nil {
+		return err
+	}
+
+	var namer vault.Namer
+	claimed := make(map[string]bool)
+	present := make(map[int64]bool)
+
+	for _, n := range notes {
+		present[n.ID] = true
+		p := namer.Path(vault.Entry{Folder: r.folder(n.NotebookID), Note: n})
+		claimed[p] = true
+
+		if err := r.syncNote(n, p, remote, claimed); err != nil {
+			return err
+		}
+	}
+
+	for id, st := range r.state {
+		if present[id] {
+			continue
+		}
+		// Deleted here: drop the file too, unless it was edited remotely in
+		// the meantime, in which case it comes back as a new note.
+		delete(r.state, id)
+		if hash, ok := remote[st.path]; ok && hash == st.remote && !claimed[st.path] {
+			if err := r.Remote.Remove(r.ctx, st.path); err != nil {
+				return err
+			}
+			delete(remote, st.path)
+			r.deleted++
+		}
+	}
+
+	paths := make([]string, 0, len(remote))
+	for p := range remote {
+		if !claimed[p] {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		data, err := r.Remote.Read(r.ctx, p)
+		if err != nil {
+			return err
+		}
+		n, err := r.create(p, data)
+		if err != nil {
+			return err
+		}
+		r.state[n.ID] = synced{path: p, local: localHash(*n), remote: Hash(data)}
+		r.imported++
+	}
+
+	return nil
+}
+
+func (r *syncRun) syncNote(n core.Note, p string, remote map[string]string, claimed map[string]bool) error {
+	st, known := r.state[n.ID]
+	if !known {
+		hash, exists := remote[p]
+		if !exists || hash == localHash(n) {
+			return r.push(n, p, "")
+		}
+		// Both sides have a note at the same place and neither has synced
+		// before: keep both.
+		return r.conflict(n, p, p)
+	}
+
+	claimed[st.path] = true
+	hash, exists := remote[st.path]
+	localChanged := localHash(n) != st.local
+	remoteChanged := exists && hash != st.remote
+
+	switch {
+	case !exists && !localChanged:
+		if err := r.delete(n.ID); err != nil {
+			return r.skipLocked(err)
+		}
+		delete(r.state, n.ID)
+		r.deleted++
+		return nil
+	case !exists:
+		return r.push(n, p, "")
+	case localChanged && remoteChanged:
+		return r.conflict(n, st.path, p)
+	case remoteChanged:
+		return r.pull(n, st.path, hash)
+	case localChanged || st.path != p:
+		return r.push(n, p, st.path)
+	}
+	return nil
+}
+
+// lockedWriter is implemented by repositories that refuse writes to notes
+// someone else has locked in the same step as the write, such as
+// repo.NoteRepoMem.
+type lockedWriter interface {
+	UpdateAs(userID string, id int64, updates map[string]interface{}) error
+	DeleteAs(userID string, id int64) error
+}
+
+// update writes updates to a note on behalf of Principal.
+func (r *syncRun) update(id int64, updates map[string]interface{}) error {
+	if writer, ok := r.Notes.(lockedWriter); ok {
+		return writer.UpdateAs(r.Principal.UserID, id, updates)
+	}
+	return r.Notes.UpdatePartial(id, updates)
+}
+
+// delete deletes a note on behalf of Principal.
+func (r *syncRun) delete(id int64) error {
+	if writer, ok := r.Notes.(lockedWriter); ok {
+		return writer.DeleteAs(r.Principal.UserID, id)
+	}
+	return r.Notes.Delete(id)
+}
+
+// skipLocked counts a write refused by an edit lock and lets the run go
+// on; the note keeps its sync state, so a later run retries it.
+func (r *syncRun) skipLocked(err error) error {
+	var locked *repo.LockedError
+	if errors.As(err, &locked) {
+		r.locked++
+		return nil
+	}
+	return err
+}
+
+// push writes the note to p, removing its previous file at old if it moved.
+func (r *syncRun) push(n core.Note, p, old string) error {
+	data := []byte(vault.Render(n))
+	if err := r.Remote.Write(r.ctx, p, data); err != nil {
+		return err
+	}
+	if old != "" && old != p {
+		if err := r.Remote.Remove(r.ctx, old); err != nil {
+			return err
+		}
+	}
+
+	r.state[n.ID] = synced{path: p, local: localHash(n), remote: Hash(data)}
+	r.pushed++
+	return nil
+}
+
+func (r *syncRun) pull(n core.Note, p, hash string) error {
+	data, err := r.Remote.Read(r.ctx, p)
+	if err != nil {
+		return err
+	}
+
+	parsed := vault.Parse(string(data))
+	updates := map[string]interface{}{
+		"content": parsed.Content,
+		"blocks":  []core.Block{},
+		"tags":    parsed.Tags,
+		"pinned":  parsed.Pinned,
+	}
+	if parsed.Language != "" {
+		updates["language"] = parsed.Language
+	}
+	if err := r.update(n.ID, updates); err != nil {
+		return r.skipLocked(err)
+	}
+
+	updated, err := r.Notes.GetByID(n.ID)
+	if err != nil {
+		return err
+	}
+	r.state[n.ID] = synced{path: p, local: localHash(*updated), remote: hash}
+	r.pulled++
+	return nil
+}
+
+// conflict imports the remote file at remotePath as a copy of the note and
+// then pushes the note's own version to p.
+func (r *syncRun) conflict(n core.Note, remotePath, p string) error {
+	data, err := r.Remote.Read(r.ctx, remotePath)
+	if err != nil {
+		return err
+	}
+	cp, err := r.create(remotePath, data)
+	if err != nil {
+		return err
+	}
+	if err := r.update(cp.ID, map[string]interface{}{
+		"title": n.Title + " (conflict " + r.now.Format("2006-01-02") + ")",
+	}); err != nil {
+		return err
+	}
+	r.conflicts = append(r.conflicts, Conflict{Path: remotePath, NoteID: n.ID, CopyID: cp.ID, At: r.now})
+
+	// The copy is pushed on the next run, under its own name.
+	if err := r.push(n, p, ""); err != nil {
+		return err
+	}
+	if remotePath != p {
+		return r.Remote.Remove(r.ctx, remotePath)
+	}
+	return nil
+}
+
+// create imports a remote file as a new note, creating the notebooks of
+// its folder as needed.
+func (r *syncRun) create(p string, data []byte) (*core.Note, error) {
+	dir, file := path.Split(p)
+
+	notebookID, err := r.notebook(strings.Split(strings.Trim(dir, "/"), "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	n := vault.Parse(string(data))
+	n.Title = strings.TrimSuffix(file, path.Ext(file))
+	n.OwnerID = r.Principal.UserID
+	n.NotebookID = notebookID
+
+	id, err := r.Notes.Create(n)
+	if err != nil {
+		return nil, err
+	}
+	return r.Notes.GetByID(id)
+}
+
+// notebook finds the notebook at the folder path, matching names the way
+// they are written to the remote, and creates the missing ones.
+func (r *syncRun) notebook(folder []string) (int64, error) {
+	notebooks, err := r.Notebooks.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var parent int64
+	for _, name := range folder {
+		if name == "" {
+			continue
+		}
+
+		found := int64(0)
+		for _, nb := range notebooks {
+			if nb.ParentID == parent && strings.EqualFold(vault.Sanitize(nb.Name), name) && r.owns(nb.OwnerID) {
+				found = nb.ID
+				break
+			}
+		}
+		if found == 0 {
+			found, err = r.Notebooks.Create(core.Notebook{Name: name, ParentID: parent, OwnerID: r.Principal.UserID})
+			if err != nil {
+				return 0, err
+			}
+		}
+		parent = found
+	}
+
+	return parent, nil
+}
+
+func (r *syncRun) owned() ([]core.Note, error) {
+	all, err := r.Notes.List()
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]core.Note, 0, len(all))
+	for _, n := range all {
+		if r.owns(n.OwnerID) {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+func (r *syncRun) owns(ownerID string) bool {
+	return r.Principal.Admin || ownerID == r.Principal.UserID
+}
+
+func (r *syncRun) folder(notebookID int64) []string {
+	var names []string
+	for _, ref := range r.Notebooks.Path(notebookID) {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+func localHash(n core.Note) string {
+	return Hash([]byte(vault.Render(n)))
+}
@@ -0,0 +1,59 @@
+package cloudsync
+
+import (
+	"context"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+func TestLockedNotesWait(t *testing.T) {
+	ctx := context.Background()
+	notes := repo.NewNoteRepoMem()
+	notes.Locks = repo.NewLockRepoMem()
+	remote := DirRemote{Root: t.TempDir()}
+	s := &Syncer{Notes: notes, Notebooks: repo.NewNotebookRepoMem(), Remote: remote, Principal: core.Principal{UserID: "alice"}}
+
+	id, err := notes.Create(core.Note{OwnerID: "alice", Title: "План", Content: "было"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Run(ctx); err != nil || s.Status().Pushed != 1 {
+		t.Fatalf("first run: %v, %+v", err, s.Status())
+	}
+
+	// A remote edit to a note bob is editing waits for his lock.
+	if err := remote.Write(ctx, "План.md", []byte("стало\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notes.Locks.Acquire(id, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Run(ctx); err != nil || s.Status().Locked != 1 || s.Status().Pulled != 0 {
+		t.Fatalf("locked pull: %v, %+v", err, s.Status())
+	}
+	if n, _ := notes.GetByID(id); n.Content != "было" {
+		t.Errorf("content under lock = %q", n.Content)
+	}
+	if err := notes.Locks.Release(id, "bob", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Run(ctx); err != nil || s.Status().Pulled != 1 {
+		t.Fatalf("pull after unlock: %v, %+v", err, s.Status())
+	}
+
+	// So does a remote delete.
+	if err := remote.Remove(ctx, "План.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notes.Locks.Acquire(id, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Run(ctx); err != nil || s.Status().Locked != 1 || s.Status().Deleted != 0 {
+		t.Fatalf("locked delete: %v, %+v", err, s.Status())
+	}
+	if _, err := notes.GetByID(id); err != nil {
+		t.Errorf("note deleted under lock: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package cloudsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Remote is the storage notes are synced with. Paths are slash-separated
+// and relative to the root of the remote; only Markdown files are listed.
+type Remote interface {
+	// List returns the content hash of every file, keyed by path.
+	List(ctx context.Context) (map[string]string, error)
+	Read(ctx context.Context, name string) ([]byte, error)
+	Write(ctx context.Context, name string, data []byte) error
+	Remove(ctx context.Context, name string) error
+	String() string
+}
+
+// DirRemote syncs with a local directory. Pointing it at the folder kept
+// in sync by the Dropbox or Google Drive desktop client syncs notes with
+// that service.
+type DirRemote struct {
+	Root string
+}
+
+func (d DirRemote) String() string {
+	return "dir:" + d.Root
+}
+
+func (d DirRemote) List(ctx context.Context) (map[string]string, error) {
+	files := make(map[string]string)
+
+	err := filepath.WalkDir(d.Root, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(e.Name(), ".") && p != d.Root {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d.Root, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = Hash(data)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (d DirRemote) Read(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(d.path(name))
+}
+
+// Write replaces the file atomically so a desktop client never uploads a
+// half-written note.
+func (d DirRemote) Write(ctx context.Context, name string, data []byte) error {
+	p := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".sync-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (d DirRemote) Remove(ctx context.Context, name string) error {
+	err := os.Remove(d.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (d DirRemote) path(name string) string {
+	return filepath.Join(d.Root, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	Addr string
+	// BaseURL is the address clients reach the server at, used in public
+	// links. Empty derives it from each request.
+	BaseURL string
+	// LegacyDelete keeps the pre-204 DeleteNote response (200 with a message)
+	// for clients that still parse it.
+	LegacyDelete bool
+	// UniqueTitles forbids repeated note titles in every notebook, not
+	// only in those that turn the constraint on.
+	UniqueTitles bool
+	// Lifecycle is the state machine of notes in core.ParseLifecycle
+	// format; empty uses draft, active, done and archived.
+	Lifecycle string
+
+	JournalTitleTemplate   string
+	JournalContentTemplate string
+
+	// AuthTokens lists bearer tokens in auth.ParseTokens format. Empty
+	// disables authentication.
+	AuthTokens string
+
+	// SyncDir enables two-way sync of notes with a folder, typically one
+	// kept in sync by the Dropbox or Google Drive desktop client.
+	SyncDir      string
+	SyncInterval time.Duration
+	// SyncUser is the user whose notes are synced; "anonymous" syncs all.
+	SyncUser string
+
+	// SLOLatency and SLOObjective define the error budget reported at
+	// /metrics: the share of requests that must succeed within SLOLatency.
+	SLOLatency   time.Duration
+	SLOObjective float64
+
+	// RateLimitFree and RateLimitAdmin are ratelimit.ParseLimit specs for
+	// regular users and admins; empty means unlimited. With RedisAddr set,
+	// limits are shared by every replica using that Redis.
+	RateLimitFree  string
+	RateLimitAdmin string
+	RedisAddr      string
+	RedisPassword  string
+
+	// ListCacheTTL is how long note lists stay cached at most; writes
+	// invalidate them earlier.
+	ListCacheTTL time.Duration
+
+	// HealthInterval is how often backends such as Redis are pinged for
+	// /readyz.
+	HealthInterval time.Duration
+
+	// EventsBroker carries note events between replicas: "memory" for a
+	// single replica, "redis" to use RedisAddr or "nats" to use NATSURL.
+	EventsBroker string
+
+	// EventsSink also publishes note events for other services: "nats"
+	// publishes on <EventsTopic>.<type> at NATSURL, "kafka" produces to
+	// EventsTopic through the REST proxy at KafkaRESTURL. Empty disables it.
+	EventsSink   string
+	EventsTopic  string
+	NATSURL      string
+	KafkaRESTURL string
+
+	// SMTPAddr is the mail relay (host:port) used to send email; empty
+	// disables email.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	// EmailLimit is the ratelimit.ParseLimit spec for the notes each user
+	// may send by email.
+	EmailLimit string
+
+	// DigestRecipients opts users into the weekly digest email, in
+	// digest.ParseRecipients format, on top of those who opted in through
+	// their preferences. It requires SMTPAddr.
+	DigestRecipients string
+
+	// Faults injects failures into matching requests, in faults.Parse
+	// format, so that client teams can test their retries. Never set it in
+	// production.
+	Faults string
+
+	// RecordFile, when set, is where every request is appended for replay
+	// with notes-replay. Credentials are left out, and secrets in JSON
+	// bodies redacted, but note contents are kept: protect the file.
+	RecordFile string
+
+	// PDFFont is a TrueType font file for PDF print views, e.g.
+	// DejaVuSans.ttf; it must cover the scripts notes are written in.
+	// Empty disables PDF output.
+	PDFFont string
+
+	// BlobDir is where export files are stored; empty keeps them in
+	// memory. DownloadSecret signs their download URLs, which expire after
+	// ExportTTL; empty uses a random secret, so URLs die with the process.
+	BlobDir        string
+	DownloadSecret string
+	ExportTTL      time.Duration
+
+	// ClamAVAddr is a clamd daemon (host:port) that scans attachments for
+	// malware; empty leaves only the checks of file types against content.
+	ClamAVAddr string
+
+	// OCRURL is a tesseract-server that recognizes text in image
+	// attachments, in the OCRLanguages (comma-separated Tesseract codes
+	// such as eng,rus). Empty disables OCR.
+	OCRURL       string
+	OCRLanguages string
+
+	// TranscriptionURL is an OpenAI-compatible API, such as
+	// https://api.openai.com, that transcribes audio attachments with
+	// TranscriptionModel. Empty disables transcription.
+	TranscriptionURL   string
+	TranscriptionKey   string
+	TranscriptionModel string
+
+	// SearchLanguage is the analyzer of searches by users who chose none
+	// in their preferences: simple, english or russian.
+	SearchLanguage string
+
+	// LinkPreviews fetches the pages notes link to, for link cards. Only
+	// public addresses are fetched.
+	LinkPreviews bool
+	// Clipping enables POST /clip, which saves the readable part of a web
+	// page as a note. Like previews, it only fetches public addresses.
+	Clipping bool
+
+	// UndoWindow is how long destructive operations can be undone; zero
+	// disables undo.
+	UndoWindow time.Duration
+
+	// LockTTL is how long an edit lock of a note lasts without a
+	// heartbeat; zero disables locking.
+	LockTTL time.Duration
+
+	// DebugEcho opens GET /debug/echo to every caller rather than admins
+	// only. The sandbox turns it on.
+	DebugEcho bool
+
+	// Sandbox serves deterministic demo data with rate limits off and
+	// nothing written outside the process; see Config.SandboxOverrides.
+	Sandbox bool
+}
+
+func Load() Config {
+	return Config{
+		Addr:         getEnv("NOTES_ADDR", ":8080"),
+		BaseURL:      getEnv("NOTES_BASE_URL", ""),
+		LegacyDelete: getEnvBool("NOTES_LEGACY_DELETE", false),
+		UniqueTitles: getEnvBool("NOTES_UNIQUE_TITLES", false),
+		Lifecycle:    getEnv("NOTES_LIFECYCLE", ""),
+
+		JournalTitleTemplate:   getEnv("NOTES_JOURNAL_TITLE_TEMPLATE", ""),
+		JournalContentTemplate: getEnv("NOTES_JOURNAL_CONTENT_TEMPLATE", ""),
+
+		AuthTokens: getEnv("NOTES_AUTH_TOKENS", ""),
+
+		SyncDir:      getEnv("NOTES_SYNC_DIR", ""),
+		SyncInterval: getEnvDuration("NOTES_SYNC_INTERVAL", 5*time.Minute),
+		SyncUser:     getEnv("NOTES_SYNC_USER", "anonymous"),
+
+		SLOLatency:   getEnvDuration("NOTES_SLO_LATENCY", 300*time.Millisecond),
+		SLOObjective: getEnvFloat("NOTES_SLO_OBJECTIVE", 0.999),
+
+		RateLimitFree:  getEnv("NOTES_RATE_LIMIT_FREE", ""),
+		RateLimitAdmin: getEnv("NOTES_RATE_LIMIT_ADMIN", ""),
+		RedisAddr:      getEnv("NOTES_REDIS_ADDR", ""),
+		RedisPassword:  getEnv("NOTES_REDIS_PASSWORD", ""),
+
+		ListCacheTTL: getEnvDuration("NOTES_LIST_CACHE_TTL", 30*time.Second),
+
+		HealthInterval: getEnvDuration("NOTES_HEALTH_INTERVAL", 10*time.Second),
+
+		EventsBroker: getEnv("NOTES_EVENTS_BROKER", "memory"),
+
+		EventsSink:   getEnv("NOTES_EVENTS_SINK", ""),
+		EventsTopic:  getEnv("NOTES_EVENTS_TOPIC", "notes"),
+		NATSURL:      getEnv("NOTES_NATS_URL", ""),
+		KafkaRESTURL: getEnv("NOTES_KAFKA_REST_URL", ""),
+
+		SMTPAddr:     getEnv("NOTES_SMTP_ADDR", ""),
+		SMTPUsername: getEnv("NOTES_SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("NOTES_SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("NOTES_MAIL_FROM", "notes@localhost"),
+		EmailLimit:   getEnv("NOTES_EMAIL_LIMIT", "20/h:5"),
+
+		DigestRecipients: getEnv("NOTES_DIGEST_RECIPIENTS", ""),
+
+		Faults: getEnv("NOTES_FAULTS", ""),
+
+		RecordFile: getEnv("NOTES_RECORD_FILE", ""),
+
+		PDFFont: getEnv("NOTES_PDF_FONT", ""),
+
+		BlobDir:        getEnv("NOTES_BLOB_DIR", ""),
+		DownloadSecret: getEnv("NOTES_DOWNLOAD_SECRET", ""),
+		ExportTTL:      getEnvDuration("NOTES_EXPORT_TTL", 24*time.Hour),
+
+		ClamAVAddr: getEnv("NOTES_CLAMAV_ADDR", ""),
+
+		OCRURL:       getEnv("NOTES_OCR_URL", ""),
+		OCRLanguages: getEnv("NOTES_OCR_LANGUAGES", "eng"),
+
+		TranscriptionURL:   getEnv("NOTES_TRANSCRIPTION_URL", ""),
+		TranscriptionKey:   getEnv("NOTES_TRANSCRIPTION_KEY", ""),
+		TranscriptionModel: getEnv("NOTES_TRANSCRIPTION_MODEL", "whisper-1"),
+
+		SearchLanguage: getEnv("NOTES_SEARCH_LANGUAGE", "russian"),
+
+		LinkPreviews: getEnvBool("NOTES_LINK_PREVIEWS", true),
+		Clipping:     getEnvBool("NOTES_CLIPPING", true),
+
+		UndoWindow: getEnvDuration("NOTES_UNDO_WINDOW", 30*time.Second),
+
+		LockTTL: getEnvDuration("NOTES_LOCK_TTL", 2*time.Minute),
+
+		DebugEcho: getEnvBool("NOTES_DEBUG_ECHO", false),
+
+		Sandbox: getEnvBool("NOTES_SANDBOX", false),
+	}
+}
+
+// SandboxOverrides turns off everything that would let sandbox mutations
+// outlive the process or reach other systems, and disables rate limits.
+// It opens the debug echo endpoint to the client developers it serves.
+func (c *Config) SandboxOverrides() {
+	c.RateLimitFree, c.RateLimitAdmin = "", ""
+	c.EventsBroker = "memory"
+	c.EventsSink = ""
+	c.SyncDir = ""
+	c.BlobDir = ""
+	c.SMTPAddr = ""
+	c.DigestRecipients = ""
+	c.OCRURL = ""
+	c.TranscriptionURL = ""
+	c.LinkPreviews = false
+	c.Clipping = false
+	c.DebugEcho = true
+}
+
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
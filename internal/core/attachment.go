@@ -0,0 +1,40 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxAttachmentSize bounds a single attachment.
+const MaxAttachmentSize = 256 << 20
+
+var ErrInvalidAttachmentName = errors.New("attachment name must be 1-255 bytes of UTF-8 without slashes or control characters")
+
+// Attachment is a file stored with a note.
+type Attachment struct {
+	ID          int64     `json:"id" example:"1"`
+	NoteID      int64     `json:"note_id" example:"1"`
+	Name        string    `json:"name" example:"whiteboard.jpg"`
+	ContentType string    `json:"content_type" example:"image/jpeg"`
+	Size        int64     `json:"size" example:"1048576"`
+	SHA256      string    `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	OwnerID     string    `json:"owner_id" example:"alice"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Status is quarantined when a scan found the file suspicious; only
+	// admins can download it then, and ScanReason says why.
+	Status     string `json:"status" example:"clean" enums:"clean,quarantined"`
+	ScanReason string `json:"scan_reason,omitempty" example:"content is image/png, not image/jpeg"`
+	// Text is the text recognized in the file: found by OCR in images or
+	// transcribed from audio. It is searchable as part of the note.
+	Text string `json:"text,omitempty" example:"Q3 roadmap: ship sync"`
+}
+
+func ValidateAttachmentName(name string) error {
+	if name == "" || len(name) > 255 || !utf8.ValidString(name) || name == "." || name == ".." ||
+		strings.ContainsAny(name, `/\`) || strings.ContainsFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return ErrInvalidAttachmentName
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type BlockType string
+
+const (
+	BlockParagraph BlockType = "paragraph"
+	BlockHeading   BlockType = "heading"
+	BlockCode      BlockType = "code"
+	BlockChecklist BlockType = "checklist"
+	BlockImage     BlockType = "image"
+)
+
+// Block is one element of structured note content. Which fields are
+// meaningful depends on Type.
+type Block struct {
+	Type     BlockType       `json:"type" example:"paragraph"`
+	Text     string          `json:"text,omitempty" example:"Текст абзаца"`
+	Level    int             `json:"level,omitempty"`
+	Language string          `json:"language,omitempty"`
+	Items    []ChecklistItem `json:"items,omitempty"`
+	URL      string          `json:"url,omitempty"`
+	Alt      string          `json:"alt,omitempty"`
+}
+
+type ChecklistItem struct {
+	Text    string `json:"text"`
+	Checked bool   `json:"checked"`
+}
+
+func ValidateBlocks(blocks []Block) error {
+	for i, b := range blocks {
+		if err := b.validate(); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (b Block) validate() error {
+	switch b.Type {
+	case BlockParagraph:
+		if strings.TrimSpace(b.Text) == "" {
+			return fmt.Errorf("paragraph text is required")
+		}
+	case BlockHeading:
+		if b.Level < 1 || b.Level > 6 {
+			return fmt.Errorf("heading level must be between 1 and 6")
+		}
+		if strings.TrimSpace(b.Text) == "" {
+			return fmt.Errorf("heading text is required")
+		}
+	case BlockCode:
+	case BlockChecklist:
+		if len(b.Items) == 0 {
+			return fmt.Errorf("checklist must have at least one item")
+		}
+		for _, item := range b.Items {
+			if strings.TrimSpace(item.Text) == "" {
+				return fmt.Errorf("checklist item text is required")
+			}
+		}
+	case BlockImage:
+		u, err := url.Parse(b.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("image url must be an absolute http(s) URL")
+		}
+	default:
+		return fmt.Errorf("unknown block type %q", b.Type)
+	}
+	return nil
+}
+
+// PlainText projects blocks to plain text, the form used for search and
+// stored in Note.Content.
+func PlainText(blocks []Block) string {
+	lines := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		switch b.Type {
+		case BlockChecklist:
+			for _, item := range b.Items {
+				lines = append(lines, item.Text)
+			}
+		case BlockImage:
+			if b.Alt != "" {
+				lines = append(lines, b.Alt)
+			}
+		default:
+			lines = append(lines, b.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func Markdown(blocks []Block) string {
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		switch b.Type {
+		case BlockHeading:
+			parts = append(parts, strings.Repeat("#", b.Level)+" "+b.Text)
+		case BlockCode:
+			parts = append(parts, "```"+b.Language+"\n"+b.Text+"\n```")
+		case BlockChecklist:
+			items := make([]string, 0, len(b.Items))
+			for _, item := range b.Items {
+				mark := " "
+				if item.Checked {
+					mark = "x"
+				}
+				items = append(items, "- ["+mark+"] "+item.Text)
+			}
+			parts = append(parts, strings.Join(items, "\n"))
+		case BlockImage:
+			parts = append(parts, "!["+b.Alt+"]("+b.URL+")")
+		default:
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// NoteMarkdown renders a whole note, falling back to the flat content
+// for notes without blocks.
+func NoteMarkdown(n Note) string {
+	body := n.Content
+	if len(n.Blocks) > 0 {
+		body = Markdown(n.Blocks)
+	}
+	return "# " + n.Title + "\n\n" + body + "\n"
+}
@@ -0,0 +1,28 @@
+package core
+
+import "time"
+
+// Change request statuses.
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+// ChangeRequest is an edit of a note in a notebook that requires approval,
+// held until the owner of the notebook approves or rejects it.
+type ChangeRequest struct {
+	ID         int64  `json:"id" example:"1"`
+	NoteID     int64  `json:"note_id" example:"12"`
+	NotebookID int64  `json:"notebook_id" example:"3"`
+	AuthorID   string `json:"author_id" example:"bob"`
+	Status     string `json:"status" example:"pending" enums:"pending,approved,rejected"`
+	// Changes are the fields the edit sets, named as in PATCH /notes/{id}.
+	Changes   map[string]interface{} `json:"changes" swaggertype:"object"`
+	CreatedAt time.Time              `json:"created_at"`
+	// DecidedBy approved or rejected the request at DecidedAt, giving
+	// Reason for a rejection.
+	DecidedBy string     `json:"decided_by,omitempty" example:"alice"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	Reason    string     `json:"reason,omitempty" example:"Дата уже согласована"`
+}
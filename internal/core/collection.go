@@ -0,0 +1,94 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	PropertyString = "string"
+	PropertyNumber = "number"
+	PropertyBool   = "bool"
+	PropertyDate   = "date"
+)
+
+type PropertyDef struct {
+	Name     string `json:"name" example:"status"`
+	Type     string `json:"type" example:"string" enums:"string,number,bool,date"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// Collection is a set of notes whose properties follow a schema, like a
+// Notion database. Properties outside the schema are allowed.
+type Collection struct {
+	ID        int64
+	OwnerID   string
+	Name      string
+	Schema    []PropertyDef
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type CollectionCreate struct {
+	Name   string        `json:"name" example:"Задачи"`
+	Schema []PropertyDef `json:"schema"`
+}
+
+type CollectionUpdate struct {
+	Name   *string        `json:"name,omitempty" example:"Баги"`
+	Schema *[]PropertyDef `json:"schema,omitempty"`
+}
+
+func ValidateSchema(schema []PropertyDef) error {
+	if len(schema) > maxProperties {
+		return fmt.Errorf("at most %d properties are allowed", maxProperties)
+	}
+	seen := make(map[string]bool, len(schema))
+	for _, def := range schema {
+		if err := ValidateProperties(map[string]interface{}{def.Name: ""}, false); err != nil {
+			return err
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("property %q is defined twice", def.Name)
+		}
+		seen[def.Name] = true
+		switch def.Type {
+		case PropertyString, PropertyNumber, PropertyBool, PropertyDate:
+		default:
+			return fmt.Errorf("property %q has unknown type %q", def.Name, def.Type)
+		}
+	}
+	return nil
+}
+
+// Check validates properties against the schema: required ones must be
+// present and every defined one must have its type.
+func (c Collection) Check(props map[string]interface{}) error {
+	for _, def := range c.Schema {
+		v, ok := props[def.Name]
+		if !ok {
+			if def.Required {
+				return fmt.Errorf("property %q is required in collection %q", def.Name, c.Name)
+			}
+			continue
+		}
+
+		valid := false
+		switch def.Type {
+		case PropertyString:
+			_, valid = v.(string)
+		case PropertyNumber:
+			_, valid = v.(float64)
+		case PropertyBool:
+			_, valid = v.(bool)
+		case PropertyDate:
+			if s, ok := v.(string); ok {
+				_, valid = parseDate(s)
+			}
+		}
+		if !valid {
+			return fmt.Errorf("property %q must be a %s", def.Name, def.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package core
+
+import "time"
+
+// Comment is a remark on a note. Comments without ReplyTo start threads;
+// replies name the first comment of their thread. Threads, not replies,
+// are resolved.
+type Comment struct {
+	ID       int64  `json:"id" example:"7"`
+	NoteID   int64  `json:"note_id" example:"12"`
+	AuthorID string `json:"author_id" example:"bob"`
+	Body     string `json:"body" example:"Может, перенести на четверг?"`
+	ReplyTo  int64  `json:"reply_to,omitempty" example:"0"`
+	// Resolved marks a thread as settled; ResolvedBy did so at ResolvedAt.
+	Resolved   bool       `json:"resolved"`
+	ResolvedBy string     `json:"resolved_by,omitempty" example:"alice"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
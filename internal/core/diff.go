@@ -0,0 +1,70 @@
+package core
+
+import "strings"
+
+// maxDiffCells bounds the table LineDiff fills; texts with more line
+// pairs are shown as replaced whole.
+const maxDiffCells = 4 << 20
+
+// DiffLine is a line of a line diff: Op is "=" for a line both texts have,
+// "-" for one only the old text has and "+" for one only the new has.
+type DiffLine struct {
+	Op   string `json:"op" example:"+" enums:"=,-,+"`
+	Text string `json:"text" example:"купить хлеб"`
+}
+
+// LineDiff returns the lines of old and new in order, marked with how
+// they differ, along a longest common subsequence.
+func LineDiff(old, new string) []DiffLine {
+	a, b := splitLines(old), splitLines(new)
+	var diff []DiffLine
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			diff = append(diff, DiffLine{Op: "-", Text: line})
+		}
+		for _, line := range b {
+			diff = append(diff, DiffLine{Op: "+", Text: line})
+		}
+		return diff
+	}
+
+	// common[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: "=", Text: a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			diff = append(diff, DiffLine{Op: "-", Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Kinds of domain errors. Packages define their own errors of a kind with
+// NotFound, Conflict, Forbidden or Invalid, so that callers can tell what
+// went wrong with errors.Is without knowing every package's errors.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrConflict  = errors.New("conflict")
+	ErrForbidden = errors.New("forbidden")
+)
+
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string { return e.message }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// NotFound returns an error of kind ErrNotFound with message.
+func NotFound(message string) error { return &kindError{ErrNotFound, message} }
+
+// Conflict returns an error of kind ErrConflict with message.
+func Conflict(message string) error { return &kindError{ErrConflict, message} }
+
+// Forbidden returns an error of kind ErrForbidden with message.
+func Forbidden(message string) error { return &kindError{ErrForbidden, message} }
+
+// ErrValidation reports invalid input. Fields maps the JSON name of each
+// invalid field to what is wrong with it.
+type ErrValidation struct {
+	Fields map[string]string
+}
+
+// Invalid returns a validation error of a single field.
+func Invalid(field, message string) *ErrValidation {
+	return &ErrValidation{Fields: map[string]string{field: message}}
+}
+
+func (e *ErrValidation) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for f := range e.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = e.Fields[f]
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func FuzzMarkdown(f *testing.F) {
+	f.Add(`[{"type":"heading","level":2,"text":"План"},{"type":"paragraph","text":"купить молоко"}]`)
+	f.Add(`[{"type":"checklist","items":[{"text":"a","checked":true},{"text":"b"}]}]`)
+	f.Add(`[{"type":"code","language":"go","text":"x := 1"},{"type":"image","url":"https://example.com/a.png","alt":"a"}]`)
+	f.Add(`[{"type":"heading","level":-1,"text":"x"}]`)
+
+	f.Fuzz(func(t *testing.T, data string) {
+		var blocks []Block
+		if json.Unmarshal([]byte(data), &blocks) != nil || ValidateBlocks(blocks) != nil {
+			return
+		}
+		md := NoteMarkdown(Note{Title: "t", Blocks: blocks})
+		if len(blocks) > 0 && !strings.HasPrefix(md, "# t\n\n") {
+			t.Fatalf("markdown %q does not start with the title", md)
+		}
+		PlainText(blocks)
+	})
+}
+
+func FuzzValidateProperties(f *testing.F) {
+	f.Add(`{"status":"done","due":"2025-01-06","estimate":3,"done":true}`, false)
+	f.Add(`{"status":null,"tags":["a","b"]}`, true)
+
+	f.Fuzz(func(t *testing.T, data string, patch bool) {
+		var props map[string]interface{}
+		if json.Unmarshal([]byte(data), &props) != nil {
+			return
+		}
+		if ValidateProperties(props, patch) != nil {
+			return
+		}
+		merged := MergeProperties(nil, props)
+		for key := range merged {
+			PropertyMatches(Note{Properties: merged}, key, "x")
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"errors"
+	"math"
+)
+
+const earthRadiusMeters = 6371000.0
+
+var ErrInvalidLocation = errors.New("latitude must be within [-90, 90] and longitude within [-180, 180], both set together")
+
+func ValidateLocation(lat, lon *float64) error {
+	if (lat == nil) != (lon == nil) {
+		return ErrInvalidLocation
+	}
+	if lat == nil {
+		return nil
+	}
+	if math.IsNaN(*lat) || math.IsNaN(*lon) || *lat < -90 || *lat > 90 || *lon < -180 || *lon > 180 {
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+// Haversine returns the great-circle distance between two points in meters.
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
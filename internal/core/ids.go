@@ -0,0 +1,59 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+)
+
+// NewPublicID returns a UUIDv7 for use in URLs instead of the sequential
+// ID, which leaks how many notes exist and is easy to guess. The leading
+// timestamp keeps IDs roughly ordered by creation.
+func NewPublicID() string {
+	return PublicID(time.Now(), rand.Reader)
+}
+
+// PublicID returns the UUIDv7 for time t with random bits read from
+// random, so that seeded sources give reproducible IDs.
+func PublicID(t time.Time, random io.Reader) string {
+	var b [16]byte
+	io.ReadFull(random, b[6:])
+	binary.BigEndian.PutUint64(b[:8], uint64(t.UnixMilli())<<16|uint64(binary.BigEndian.Uint16(b[6:8])))
+	b[6] = 0x70 | b[6]&0x0f
+	b[8] = 0x80 | b[8]&0x3f
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// IsPublicID reports whether s has the form of a UUID.
+func IsPublicID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package core
+
+import "time"
+
+// InboundHook lets an external system, such as a monitoring tool or a
+// form, create notes of its owner by posting any payload to
+// /inbound/{token}. Title, Content and Tags are text/template sources
+// that map the payload onto the note; the token in the URL is the only
+// credential.
+type InboundHook struct {
+	ID         int64  `json:"id" example:"1"`
+	OwnerID    string `json:"owner_id" example:"alice"`
+	Name       string `json:"name" example:"Grafana alerts"`
+	Token      string `json:"token" example:"Jx3mV0pQ9lKc2bWzT8rY1nAe"`
+	NotebookID int64  `json:"notebook_id,omitempty" example:"0"`
+	// Title defaults to the hook name and Content to the payload as sent.
+	Title      string     `json:"title,omitempty" example:"{{.Body.title}}"`
+	Content    string     `json:"content,omitempty" example:"{{.Body.message}}"`
+	Tags       []string   `json:"tags,omitempty" example:"alert,{{.Body.state}}"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// InboundHookInput creates an inbound hook.
+type InboundHookInput struct {
+	Name       string   `json:"name" example:"Grafana alerts"`
+	NotebookID int64    `json:"notebook_id,omitempty" example:"0"`
+	Title      string   `json:"title,omitempty" example:"{{.Body.title}}"`
+	Content    string   `json:"content,omitempty" example:"{{.Body.message}}"`
+	Tags       []string `json:"tags,omitempty" example:"alert,{{.Body.state}}"`
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lifecycle is the state machine notes move through. New notes start in
+// the first state; a note moves only along Transitions.
+type Lifecycle struct {
+	States []string `json:"states" example:"draft,active,done,archived"`
+	// Transitions maps each state to the states a note in it may move to.
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// DefaultLifecycle takes notes from draft through active and done to
+// archived, with a way back from each step but the first.
+var DefaultLifecycle = Lifecycle{
+	States: []string{"draft", "active", "done", "archived"},
+	Transitions: map[string][]string{
+		"draft":    {"active"},
+		"active":   {"done", "draft"},
+		"done":     {"archived", "active"},
+		"archived": {"active"},
+	},
+}
+
+// ParseLifecycle reads a lifecycle written as transitions separated by
+// semicolons, each a state, ">" and the states it leads to separated by
+// commas: "draft>active; active>done,draft; done>active". States are in
+// the order they first appear in, so the first one named is the state of
+// new notes. An empty spec is DefaultLifecycle.
+func ParseLifecycle(spec string) (Lifecycle, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultLifecycle, nil
+	}
+	l := Lifecycle{Transitions: map[string][]string{}}
+	add := func(state string) (string, error) {
+		state = strings.ToLower(strings.TrimSpace(state))
+		if state == "" || strings.ContainsAny(state, " \t,;>") {
+			return "", fmt.Errorf("invalid state %q", state)
+		}
+		if !l.Has(state) {
+			l.States = append(l.States, state)
+		}
+		return state, nil
+	}
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		from, targets, ok := strings.Cut(entry, ">")
+		if !ok {
+			return Lifecycle{}, fmt.Errorf("transition %q has no >", strings.TrimSpace(entry))
+		}
+		from, err := add(from)
+		if err != nil {
+			return Lifecycle{}, err
+		}
+		for _, to := range strings.Split(targets, ",") {
+			if to, err = add(to); err != nil {
+				return Lifecycle{}, err
+			}
+			if !l.Allows(from, to) && to != from {
+				l.Transitions[from] = append(l.Transitions[from], to)
+			}
+		}
+	}
+	return l, nil
+}
+
+// OrDefault returns l, or DefaultLifecycle when l has no states.
+func (l Lifecycle) OrDefault() Lifecycle {
+	if len(l.States) == 0 {
+		return DefaultLifecycle
+	}
+	return l
+}
+
+// Initial returns the state of new notes.
+func (l Lifecycle) Initial() string {
+	return l.States[0]
+}
+
+func (l Lifecycle) Has(state string) bool {
+	for _, s := range l.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether a note in state from may move to state to.
+func (l Lifecycle) Allows(from, to string) bool {
+	for _, s := range l.Transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package core
+
+import "time"
+
+// NoteLock is a claim of a user on editing a note alone until ExpiresAt.
+// The holder extends it by acquiring it again.
+type NoteLock struct {
+	NoteID     int64     `json:"note_id" example:"12"`
+	HolderID   string    `json:"holder_id" example:"bob"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
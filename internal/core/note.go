@@ -1,21 +1,166 @@
 package core
 
-import "time"
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	NoteTypeNote    = "note"
+	NoteTypeSnippet = "snippet"
+)
 
 type Note struct {
-	ID        int64
-	Title     string
-	Content   string
-	CreatedAt time.Time
-	UpdatedAt *time.Time
+	ID int64
+	// PublicID is a UUIDv7 that routes accept in place of ID.
+	PublicID   string
+	Slug       string
+	OwnerID    string
+	NotebookID int64
+	// CollectionID is the collection whose schema the note follows.
+	CollectionID int64         `json:",omitempty"`
+	Path         []NotebookRef `json:",omitempty"`
+	Position     int64
+	Type         string
+	Title        string
+	Content      string
+	Blocks       []Block  `json:",omitempty"`
+	Language     string   `json:",omitempty"`
+	Latitude     *float64 `json:",omitempty"`
+	Longitude    *float64 `json:",omitempty"`
+	JournalDate  string   `json:",omitempty"`
+	Tags         []string `json:",omitempty"`
+	// SourceURL is the page a clipped note was taken from.
+	SourceURL string `json:",omitempty"`
+	Pinned    bool
+	State     string     `json:",omitempty"`
+	RemindAt  *time.Time `json:",omitempty"`
+	// Color is a #rrggbb color for showing the note.
+	Color string `json:",omitempty"`
+	// ExpiresAt is when the retention policy of the notebook the note was
+	// created in deletes it.
+	ExpiresAt *time.Time `json:",omitempty"`
+	// Properties are typed key-value fields; see ValidateProperties.
+	Properties map[string]interface{} `json:",omitempty"`
+	// Reactions counts the users behind each emoji in ReactedBy, which is
+	// kept out of responses.
+	Reactions map[string]int      `json:",omitempty"`
+	ReactedBy map[string][]string `json:"-"`
+	// ShareToken, when set, lets anyone read the note through its public
+	// link. It is only handed out by the public-link endpoints.
+	ShareToken string `json:"-"`
+	CreatedAt  time.Time
+	UpdatedAt  *time.Time
+	// Display has the dates formatted for the reader, when asked for with
+	// date_format or Accept-Language.
+	Display *DisplayDates `json:",omitempty"`
+}
+
+// DisplayDates are the timestamps of a note formatted for display.
+type DisplayDates struct {
+	Created  string
+	Updated  string `json:",omitempty"`
+	RemindAt string `json:",omitempty"`
 }
 
 type NoteCreate struct {
-	Title   string `json:"title" example:"Новая заметка"`
-	Content string `json:"content" example:"Текст заметки"`
+	NotebookID   int64                  `json:"notebook_id,omitempty" example:"1"`
+	CollectionID int64                  `json:"collection_id,omitempty" example:"1"`
+	Type         string                 `json:"type,omitempty" example:"note" enums:"note,snippet"`
+	Title        string                 `json:"title" example:"Новая заметка"`
+	Content      string                 `json:"content" example:"Текст заметки"`
+	Blocks       []Block                `json:"blocks,omitempty"`
+	Language     string                 `json:"language,omitempty" example:"go"`
+	Latitude     *float64               `json:"latitude,omitempty" example:"55.7558"`
+	Longitude    *float64               `json:"longitude,omitempty" example:"37.6173"`
+	Tags         []string               `json:"tags,omitempty" example:"work,ideas"`
+	Pinned       bool                   `json:"pinned,omitempty"`
+	RemindAt     *time.Time             `json:"remind_at,omitempty"`
+	Color        string                 `json:"color,omitempty" example:"#3366ff"`
+	Properties   map[string]interface{} `json:"properties,omitempty" swaggertype:"object"`
+}
+
+func (c NoteCreate) Note() Note {
+	return Note{
+		NotebookID:   c.NotebookID,
+		CollectionID: c.CollectionID,
+		Type:         c.Type,
+		Title:        c.Title,
+		Content:      c.Content,
+		Blocks:       c.Blocks,
+		Language:     c.Language,
+		Latitude:     c.Latitude,
+		Longitude:    c.Longitude,
+		Tags:         NormalizeTags(c.Tags),
+		Pinned:       c.Pinned,
+		RemindAt:     UTC(c.RemindAt),
+		Color:        strings.ToLower(strings.TrimSpace(c.Color)),
+		Properties:   c.Properties,
+	}
 }
 
 type NoteUpdate struct {
-	Title   *string `json:"title,omitempty" example:"Обновлено"`
-	Content *string `json:"content,omitempty" example:"Новый текст"`
+	Title     *string    `json:"title,omitempty" example:"Обновлено"`
+	Content   *string    `json:"content,omitempty" example:"Новый текст"`
+	Blocks    *[]Block   `json:"blocks,omitempty"`
+	Language  *string    `json:"language,omitempty" example:"python"`
+	Latitude  *float64   `json:"latitude,omitempty" example:"59.9343"`
+	Longitude *float64   `json:"longitude,omitempty" example:"30.3351"`
+	Tags      *[]string  `json:"tags,omitempty"`
+	Pinned    *bool      `json:"pinned,omitempty"`
+	RemindAt  *time.Time `json:"remind_at,omitempty"`
+	// Color is a #rrggbb color; "" removes it.
+	Color *string `json:"color,omitempty" example:"#3366ff"`
+	// CollectionID moves the note into a collection; 0 removes it.
+	CollectionID *int64 `json:"collection_id,omitempty" example:"1"`
+	// Properties are merged into the note's; null removes a property.
+	Properties map[string]interface{} `json:"properties,omitempty" swaggertype:"object"`
+}
+
+var ErrInvalidColor = errors.New("color must be #rrggbb")
+
+// ValidateColor accepts "" and colors written as #rrggbb.
+func ValidateColor(c string) error {
+	if c == "" {
+		return nil
+	}
+	if len(c) != 7 || c[0] != '#' {
+		return ErrInvalidColor
+	}
+	for _, r := range c[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return ErrInvalidColor
+		}
+	}
+	return nil
+}
+
+// UTC returns t in UTC, so that timestamps are stored and returned the
+// same way whatever offset clients send them with.
+func UTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+type NearbyNote struct {
+	Note
+	DistanceMeters float64
+}
+
+func ValidNoteType(t string) bool {
+	return t == NoteTypeNote || t == NoteTypeSnippet
+}
+
+func SortByPosition(notes []Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].Position != notes[j].Position {
+			return notes[i].Position < notes[j].Position
+		}
+		return notes[i].ID < notes[j].ID
+	})
 }
@@ -0,0 +1,126 @@
+package core
+
+import "time"
+
+type Role string
+
+const (
+	RoleNone   Role = ""
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+var roleRank = map[Role]int{RoleNone: 0, RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+func (r Role) Valid() bool {
+	return r == RoleViewer || r == RoleEditor
+}
+
+// Allows reports whether r grants at least min.
+func (r Role) Allows(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+func MaxRole(a, b Role) Role {
+	if roleRank[b] > roleRank[a] {
+		return b
+	}
+	return a
+}
+
+// TeamPrefix marks share grantees that name a team rather than a user.
+const TeamPrefix = "team:"
+
+type Principal struct {
+	UserID string
+	Teams  []string
+	Admin  bool
+}
+
+// Matches reports whether a share grantee ("alice" or "team:devs")
+// refers to the principal.
+func (p Principal) Matches(grantee string) bool {
+	if grantee == p.UserID {
+		return true
+	}
+	for _, t := range p.Teams {
+		if grantee == TeamPrefix+t {
+			return true
+		}
+	}
+	return false
+}
+
+type Share struct {
+	Grantee string `json:"grantee" example:"team:devs"`
+	Role    Role   `json:"role" example:"viewer" enums:"viewer,editor"`
+}
+
+type Notebook struct {
+	ID        int64
+	ParentID  int64
+	Name      string
+	OwnerID   string
+	Shares    []Share       `json:",omitempty"`
+	Path      []NotebookRef `json:",omitempty"`
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	// UniqueTitles forbids two notes of the notebook to share a title.
+	UniqueTitles bool `json:",omitempty"`
+	// RequireApproval turns edits of its notes by anyone but the owner
+	// into change requests the owner approves or rejects.
+	RequireApproval bool `json:",omitempty"`
+	// Settings are served by their own sub-resource, not with the notebook.
+	Settings *NotebookSettings `json:"-"`
+}
+
+// NotebookSettings are the defaults given to notes created in a notebook.
+type NotebookSettings struct {
+	// Tags are added to those of every new note.
+	Tags []string `json:"tags,omitempty" example:"work"`
+	// Color is given to new notes that do not pick one.
+	Color string `json:"color,omitempty" example:"#3366ff"`
+	// TemplateID is a note template whose title, content and tags fill in
+	// new notes that leave their title or content empty.
+	TemplateID int64 `json:"template_id,omitempty" example:"1"`
+	// RetentionDays, when set, deletes new notes that many days after
+	// they are created.
+	RetentionDays int `json:"retention_days,omitempty" example:"30"`
+}
+
+// MaxRetentionDays bounds NotebookSettings.RetentionDays.
+const MaxRetentionDays = 36500
+
+// IsZero reports whether s sets no default.
+func (s NotebookSettings) IsZero() bool {
+	return len(s.Tags) == 0 && s.Color == "" && s.TemplateID == 0 && s.RetentionDays == 0
+}
+
+type NotebookRef struct {
+	ID   int64
+	Name string
+}
+
+type NotebookCreate struct {
+	Name         string `json:"name" example:"Работа"`
+	ParentID     int64  `json:"parent_id,omitempty" example:"1"`
+	UniqueTitles bool   `json:"unique_titles,omitempty"`
+}
+
+type NotebookUpdate struct {
+	Name     *string `json:"name,omitempty" example:"Проекты"`
+	ParentID *int64  `json:"parent_id,omitempty" example:"0"`
+	// UniqueTitles turns the unique title constraint on or off.
+	UniqueTitles *bool `json:"unique_titles,omitempty"`
+	// RequireApproval turns approval mode on or off; only the owner can.
+	RequireApproval *bool `json:"require_approval,omitempty"`
+}
+
+// NoteView tells when a user other than the owner viewed a note.
+type NoteView struct {
+	UserID        string    `json:"user_id" example:"alice"`
+	Views         int       `json:"views"`
+	FirstViewedAt time.Time `json:"first_viewed_at"`
+	LastViewedAt  time.Time `json:"last_viewed_at"`
+}
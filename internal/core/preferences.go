@@ -0,0 +1,134 @@
+package core
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxPageSize bounds the page size a user can ask for.
+const MaxPageSize = 500
+
+// Preferences are per-user settings. The zero value of each field means
+// "not set": features fall back to their own defaults.
+type Preferences struct {
+	// DefaultNotebookID receives notes created without a notebook.
+	DefaultNotebookID int64  `json:"default_notebook_id" example:"1"`
+	Locale            string `json:"locale" example:"ru-RU"`
+	// Timezone is an IANA zone name used for digests and reminders.
+	Timezone string `json:"timezone" example:"Europe/Moscow"`
+	// PageSize is the note list limit used when a request gives none.
+	PageSize int `json:"page_size" example:"50"`
+	// Email receives the weekly digest.
+	Email         string                  `json:"email" example:"alice@example.com"`
+	Notifications NotificationPreferences `json:"notifications"`
+	Privacy       PrivacyPreferences      `json:"privacy"`
+	// SearchLanguage is the analyzer of the user's searches; empty uses
+	// the one of the instance.
+	SearchLanguage string `json:"search_language" example:"russian" enums:"simple,english,russian"`
+}
+
+type NotificationPreferences struct {
+	// Watched delivers notifications about watched notes.
+	Watched bool `json:"watched"`
+	// Digest opts into the weekly digest email.
+	Digest bool `json:"digest"`
+}
+
+type PrivacyPreferences struct {
+	// RecentNotes keeps the notes the user viewed for GET /notes/recent.
+	RecentNotes bool `json:"recent_notes"`
+}
+
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Notifications: NotificationPreferences{Watched: true},
+		Privacy:       PrivacyPreferences{RecentNotes: true},
+	}
+}
+
+// Location returns the time zone of the preferences, UTC when unset.
+func (p Preferences) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+type PreferencesUpdate struct {
+	DefaultNotebookID *int64                         `json:"default_notebook_id,omitempty" example:"1"`
+	Locale            *string                        `json:"locale,omitempty" example:"ru-RU"`
+	Timezone          *string                        `json:"timezone,omitempty" example:"Europe/Moscow"`
+	PageSize          *int                           `json:"page_size,omitempty" example:"50"`
+	Email             *string                        `json:"email,omitempty" example:"alice@example.com"`
+	Notifications     *NotificationPreferencesUpdate `json:"notifications,omitempty"`
+	Privacy           *PrivacyPreferencesUpdate      `json:"privacy,omitempty"`
+	SearchLanguage    *string                        `json:"search_language,omitempty" example:"russian"`
+}
+
+type NotificationPreferencesUpdate struct {
+	Watched *bool `json:"watched,omitempty"`
+	Digest  *bool `json:"digest,omitempty"`
+}
+
+type PrivacyPreferencesUpdate struct {
+	RecentNotes *bool `json:"recent_notes,omitempty"`
+}
+
+// Apply returns p with the update applied, or an error naming the first
+// invalid field. Notebook existence and search languages are left to the
+// caller.
+func (u PreferencesUpdate) Apply(p Preferences) (Preferences, error) {
+	if u.DefaultNotebookID != nil {
+		if *u.DefaultNotebookID < 0 {
+			return p, Invalid("default_notebook_id", "default_notebook_id must not be negative")
+		}
+		p.DefaultNotebookID = *u.DefaultNotebookID
+	}
+	if u.Locale != nil {
+		p.Locale = strings.TrimSpace(*u.Locale)
+	}
+	if u.Timezone != nil {
+		tz := strings.TrimSpace(*u.Timezone)
+		if tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil {
+				return p, Invalid("timezone", "unknown timezone "+tz)
+			}
+		}
+		p.Timezone = tz
+	}
+	if u.PageSize != nil {
+		if *u.PageSize < 0 || *u.PageSize > MaxPageSize {
+			return p, Invalid("page_size", "page_size must be between 0 and 500")
+		}
+		p.PageSize = *u.PageSize
+	}
+	if u.Email != nil {
+		email := strings.TrimSpace(*u.Email)
+		if email != "" && !strings.Contains(email, "@") {
+			return p, Invalid("email", "invalid email")
+		}
+		p.Email = email
+	}
+	if n := u.Notifications; n != nil {
+		if n.Watched != nil {
+			p.Notifications.Watched = *n.Watched
+		}
+		if n.Digest != nil {
+			p.Notifications.Digest = *n.Digest
+		}
+	}
+	if u.SearchLanguage != nil {
+		p.SearchLanguage = strings.ToLower(strings.TrimSpace(*u.SearchLanguage))
+	}
+	if u.Privacy != nil && u.Privacy.RecentNotes != nil {
+		p.Privacy.RecentNotes = *u.Privacy.RecentNotes
+	}
+	if p.Notifications.Digest && p.Email == "" {
+		return p, Invalid("notifications.digest", "the digest needs an email")
+	}
+	return p, nil
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxProperties     = 50
+	maxPropertyKeyLen = 64
+)
+
+// ValidateProperties checks a properties map: keys are up to 64 letters,
+// digits, '_' or '-', and values are strings, numbers or booleans. Dates
+// are strings in YYYY-MM-DD or RFC 3339 form. In a patch, null values
+// remove keys.
+func ValidateProperties(props map[string]interface{}, patch bool) error {
+	if len(props) > maxProperties {
+		return fmt.Errorf("at most %d properties are allowed", maxProperties)
+	}
+	for key, value := range props {
+		if key == "" || len(key) > maxPropertyKeyLen || strings.IndexFunc(key, func(r rune) bool {
+			return !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		}) >= 0 {
+			return fmt.Errorf("invalid property name %q", key)
+		}
+		switch value.(type) {
+		case string, float64, bool:
+		case nil:
+			if !patch {
+				return fmt.Errorf("property %q has no value", key)
+			}
+		default:
+			return fmt.Errorf("property %q must be a string, number, boolean or date", key)
+		}
+	}
+	return nil
+}
+
+// MergeProperties applies a patch to props without modifying either:
+// null values remove keys, others replace them.
+func MergeProperties(props, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(props)+len(patch))
+	for k, v := range props {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// PropertyMatches reports whether the note's property key equals value,
+// a query string compared according to the property's type: numerically,
+// as a boolean, as a date, or as text ignoring case.
+func PropertyMatches(n Note, key, value string) bool {
+	switch v := n.Properties[key].(type) {
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		return err == nil && f == v
+	case bool:
+		b, err := strconv.ParseBool(value)
+		return err == nil && b == v
+	case string:
+		if a, ok := parseDate(v); ok {
+			if b, ok := parseDate(value); ok {
+				return a.Equal(b)
+			}
+		}
+		return strings.EqualFold(v, value)
+	}
+	return false
+}
+
+func parseDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
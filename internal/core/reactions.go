@@ -0,0 +1,79 @@
+package core
+
+import "sort"
+
+// ReactionEmoji is the set of reactions a note accepts.
+var ReactionEmoji = []string{"👍", "❤️", "🎉", "😄", "👀", "🚀"}
+
+func ValidReaction(emoji string) bool {
+	for _, e := range ReactionEmoji {
+		if e == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactedBy reports whether userID left any reaction on the note, or the
+// given one when emoji is not empty.
+func ReactedBy(n Note, userID, emoji string) bool {
+	for e, users := range n.ReactedBy {
+		if emoji != "" && e != emoji {
+			continue
+		}
+		for _, u := range users {
+			if u == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReactionsBy returns the reactions userID left on the note, in
+// ReactionEmoji order.
+func ReactionsBy(n Note, userID string) []string {
+	mine := make([]string, 0)
+	for _, e := range ReactionEmoji {
+		if ReactedBy(n, userID, e) {
+			mine = append(mine, e)
+		}
+	}
+	return mine
+}
+
+// WithReaction returns the reaction maps of the note after userID adds
+// (or, with add false, removes) emoji. The maps are copied, since earlier
+// copies of the note share them; nil maps are returned once no reaction
+// is left.
+func WithReaction(n Note, userID, emoji string, add bool) (map[string][]string, map[string]int) {
+	reactedBy := make(map[string][]string, len(n.ReactedBy)+1)
+	for e, users := range n.ReactedBy {
+		reactedBy[e] = users
+	}
+
+	users := make([]string, 0, len(reactedBy[emoji])+1)
+	for _, u := range reactedBy[emoji] {
+		if u != userID {
+			users = append(users, u)
+		}
+	}
+	if add {
+		users = append(users, userID)
+		sort.Strings(users)
+	}
+	if len(users) == 0 {
+		delete(reactedBy, emoji)
+	} else {
+		reactedBy[emoji] = users
+	}
+
+	if len(reactedBy) == 0 {
+		return nil, nil
+	}
+	counts := make(map[string]int, len(reactedBy))
+	for e, users := range reactedBy {
+		counts[e] = len(users)
+	}
+	return reactedBy, counts
+}
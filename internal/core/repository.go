@@ -0,0 +1,17 @@
+package core
+
+// NoteRepository stores notes. It is all the handlers need to serve
+// notes; backends may implement more, such as moving notes between
+// notebooks, to enable more features. Backends pass the conformance
+// suite in repo/repotest; repo.NoteRepoMem is the in-memory one.
+type NoteRepository interface {
+	// Create stores n under a new ID and returns the ID.
+	Create(n Note) (int64, error)
+	// GetByID returns a copy of the note, or an error of kind ErrNotFound.
+	GetByID(id int64) (*Note, error)
+	// List returns every note, ordered by ID.
+	List() ([]Note, error)
+	// UpdatePartial sets the fields named by the keys of updates.
+	UpdatePartial(id int64, updates map[string]interface{}) error
+	Delete(id int64) error
+}
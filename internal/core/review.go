@@ -0,0 +1,43 @@
+package core
+
+import "time"
+
+// Review grades: how well a user remembered a note when reviewing it.
+const (
+	ReviewAgain = "again"
+	ReviewGood  = "good"
+	ReviewEasy  = "easy"
+)
+
+// ValidReviewGrade reports whether g is a review grade.
+func ValidReviewGrade(g string) bool {
+	return g == ReviewAgain || g == ReviewGood || g == ReviewEasy
+}
+
+// ReviewState is where a note stands in the spaced-repetition review of a
+// user. Notes never reviewed have none and are due at once.
+type ReviewState struct {
+	NoteID         int64      `json:"note_id" example:"1"`
+	IntervalDays   int        `json:"interval_days" example:"4"`
+	Reviews        int        `json:"reviews" example:"3"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at,omitempty"`
+	DueAt          time.Time  `json:"due_at"`
+}
+
+// Reviewed returns the state after a review at now with grade: again
+// starts over at a day, good doubles the interval and easy triples it,
+// each at least a day (easy, four).
+func (s ReviewState) Reviewed(grade string, now time.Time) ReviewState {
+	switch grade {
+	case ReviewAgain:
+		s.IntervalDays = 1
+	case ReviewEasy:
+		s.IntervalDays = max(s.IntervalDays*3, 4)
+	default:
+		s.IntervalDays = max(s.IntervalDays*2, 1)
+	}
+	s.Reviews++
+	s.LastReviewedAt = &now
+	s.DueAt = now.AddDate(0, 0, s.IntervalDays)
+	return s
+}
@@ -0,0 +1,77 @@
+package core
+
+import "time"
+
+// Events that trigger rules.
+const (
+	// TriggerNoteCreated fires when a note is created, with any of the
+	// tag of the trigger when it sets one.
+	TriggerNoteCreated = "note_created"
+	// TriggerReminderDue fires when the reminder of a note comes due.
+	TriggerReminderDue = "reminder_due"
+	// TriggerNoteShared fires when a note gets a public link.
+	TriggerNoteShared = "note_shared"
+)
+
+// What rules do.
+const (
+	ActionApplyTag       = "apply_tag"
+	ActionMoveToNotebook = "move_to_notebook"
+	ActionCallWebhook    = "call_webhook"
+	ActionSendEmail      = "send_email"
+)
+
+// MaxRuleActions bounds the actions of one rule.
+const MaxRuleActions = 10
+
+// Rule runs its actions on the notes of its owner that an event happens
+// to: when When matches, then Then.
+type Rule struct {
+	ID      int64        `json:"id" example:"1"`
+	OwnerID string       `json:"owner_id" example:"alice"`
+	Name    string       `json:"name" example:"Счета в бухгалтерию"`
+	Enabled bool         `json:"enabled" example:"true"`
+	When    RuleTrigger  `json:"when"`
+	Then    []RuleAction `json:"then"`
+	// OwnerTeams are the owner's teams when the rule was saved, for
+	// checking the owner's access to notebooks when it runs.
+	OwnerTeams []string `json:"-"`
+	// Runs counts the events the rule ran on, LastRunAt is the latest.
+	Runs      int        `json:"runs" example:"3"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// RuleTrigger is the event a rule waits for.
+type RuleTrigger struct {
+	Event string `json:"event" example:"note_created" enums:"note_created,reminder_due,note_shared"`
+	// Tag, when set, limits the rule to notes with the tag or one nested
+	// under it.
+	Tag string `json:"tag,omitempty" example:"invoice"`
+}
+
+// Matches reports whether the trigger applies to n when its event happens.
+func (t RuleTrigger) Matches(n Note) bool {
+	return t.Tag == "" || HasTag(n, t.Tag)
+}
+
+// RuleAction is one thing a rule does. Which fields it uses depends on
+// Type: Tag for apply_tag, NotebookID for move_to_notebook, URL for
+// call_webhook and To for send_email.
+type RuleAction struct {
+	Type       string   `json:"type" example:"apply_tag" enums:"apply_tag,move_to_notebook,call_webhook,send_email"`
+	Tag        string   `json:"tag,omitempty" example:"finance"`
+	NotebookID int64    `json:"notebook_id,omitempty" example:"2"`
+	URL        string   `json:"url,omitempty" example:"https://hooks.example.com/notes"`
+	To         []string `json:"to,omitempty" example:"accounting@example.com"`
+}
+
+// RuleInput creates or replaces a rule.
+type RuleInput struct {
+	Name string `json:"name" example:"Счета в бухгалтерию"`
+	// Enabled defaults to true.
+	Enabled *bool        `json:"enabled,omitempty" example:"true"`
+	When    RuleTrigger  `json:"when"`
+	Then    []RuleAction `json:"then"`
+}
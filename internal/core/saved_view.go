@@ -0,0 +1,42 @@
+package core
+
+import "time"
+
+// SavedView is a named filter of notes. Query holds the filters of
+// GET /notes as a URL query; Sort and Fields order and trim the notes it
+// returns. Users and teams (TeamPrefix) in SharedWith can run the view,
+// which shows each of them the matching notes they can read, but only the
+// owner can change it.
+type SavedView struct {
+	ID         int64      `json:"id" example:"1"`
+	OwnerID    string     `json:"owner_id" example:"alice"`
+	Name       string     `json:"name" example:"Open bugs this sprint"`
+	Query      string     `json:"query,omitempty" example:"tag=bug&prop.status=open"`
+	Sort       string     `json:"sort,omitempty" example:"-updated" enums:"created,-created,updated,-updated,title,-title"`
+	Fields     []string   `json:"fields,omitempty" example:"ID,Title,Tags"`
+	SharedWith []string   `json:"shared_with,omitempty" example:"bob,team:devs"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// SavedViewInput creates or replaces a saved view.
+type SavedViewInput struct {
+	Name       string   `json:"name" example:"Open bugs this sprint"`
+	Query      string   `json:"query,omitempty" example:"tag=bug&prop.status=open"`
+	Sort       string   `json:"sort,omitempty" example:"-updated" enums:"created,-created,updated,-updated,title,-title"`
+	Fields     []string `json:"fields,omitempty" example:"ID,Title,Tags"`
+	SharedWith []string `json:"shared_with,omitempty" example:"bob,team:devs"`
+}
+
+// Visible reports whether p can run v.
+func (v SavedView) Visible(p Principal) bool {
+	if v.OwnerID == p.UserID {
+		return true
+	}
+	for _, grantee := range v.SharedWith {
+		if p.Matches(grantee) {
+			return true
+		}
+	}
+	return false
+}
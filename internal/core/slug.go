@@ -0,0 +1,54 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxSlugLen keeps slugs short enough to read in a URL.
+const maxSlugLen = 80
+
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ы': "y", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Slugify turns a title into a URL-friendly slug: lowercase ASCII letters
+// and digits separated by single hyphens, with Cyrillic transliterated, so
+// "Планы на 2025!" becomes "plany-na-2025".
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		var s string
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			s = string(r)
+		case cyrillic[r] != "":
+			s = cyrillic[r]
+		case r == 'ъ' || r == 'ь':
+			// Hard and soft signs have no sound of their own.
+			continue
+		default:
+			hyphen = b.Len() > 0
+			continue
+		}
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+		b.WriteString(s)
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLen {
+		slug = strings.TrimRight(slug[:maxSlugLen], "-")
+	}
+	if slug == "" {
+		return "note"
+	}
+	return slug
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagSeparator splits namespaced tags such as project/alpha.
+const TagSeparator = "/"
+
+type TagNode struct {
+	Name string `json:"name" example:"alpha"`
+	Path string `json:"path" example:"project/alpha"`
+	// Count is the number of notes with exactly this tag, Total also
+	// includes notes tagged with any descendant.
+	Count    int       `json:"count"`
+	Total    int       `json:"total"`
+	Children []TagNode `json:"children,omitempty"`
+}
+
+// NormalizeTag lowercases the tag and trims spaces and empty segments,
+// so " Project//Alpha/ " becomes "project/alpha".
+func NormalizeTag(tag string) string {
+	parts := strings.Split(strings.ToLower(tag), TagSeparator)
+	segments := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			segments = append(segments, p)
+		}
+	}
+	return strings.Join(segments, TagSeparator)
+}
+
+// TagMatches reports whether tag is query itself or one of its descendants.
+func TagMatches(tag, query string) bool {
+	return tag == query || strings.HasPrefix(tag, query+TagSeparator)
+}
+
+func HasTag(n Note, query string) bool {
+	for _, t := range n.Tags {
+		if TagMatches(t, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagTree arranges the tags of notes into a namespace hierarchy. A note
+// is counted once in Total of each ancestor, however many descendant tags
+// it has.
+func TagTree(notes []Note) []TagNode {
+	type treeNode struct {
+		count    int
+		notes    map[int64]bool
+		children map[string]*treeNode
+	}
+	newNode := func() *treeNode {
+		return &treeNode{notes: make(map[int64]bool), children: make(map[string]*treeNode)}
+	}
+
+	root := newNode()
+	for _, n := range notes {
+		for _, tag := range n.Tags {
+			node := root
+			for _, seg := range strings.Split(tag, TagSeparator) {
+				child, ok := node.children[seg]
+				if !ok {
+					child = newNode()
+					node.children[seg] = child
+				}
+				child.notes[n.ID] = true
+				node = child
+			}
+			node.count++
+		}
+	}
+
+	var build func(node *treeNode, prefix string) []TagNode
+	build = func(node *treeNode, prefix string) []TagNode {
+		out := make([]TagNode, 0, len(node.children))
+		for name, child := range node.children {
+			path := name
+			if prefix != "" {
+				path = prefix + TagSeparator + name
+			}
+			out = append(out, TagNode{
+				Name:     name,
+				Path:     path,
+				Count:    child.count,
+				Total:    len(child.notes),
+				Children: build(child, path),
+			})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+		return out
+	}
+
+	return build(root, "")
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty ones and
+// duplicates while keeping the first-seen order.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// CountTags returns how many notes carry each tag, most used first.
+func CountTags(notes []Note) []TagCount {
+	counts := make(map[string]int)
+	for _, n := range notes {
+		for _, t := range n.Tags {
+			counts[t]++
+		}
+	}
+
+	out := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		out = append(out, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out
+}
@@ -0,0 +1,38 @@
+package core
+
+import "time"
+
+// NoteTemplate is a blueprint for notes. Title and Content are
+// text/templates that receive .Date (YYYY-MM-DD) and .Weekday, as journal
+// templates do. With a Schedule, a cron expression read in Timezone, the
+// server creates a note from the template every time the schedule
+// matches.
+type NoteTemplate struct {
+	ID         int64    `json:"id" example:"1"`
+	OwnerID    string   `json:"owner_id" example:"alice"`
+	Name       string   `json:"name" example:"Планёрка"`
+	Title      string   `json:"title" example:"Weekly standup {{.Date}}"`
+	Content    string   `json:"content" example:"## Done\n\n## Next\n"`
+	Tags       []string `json:"tags,omitempty" example:"standup"`
+	NotebookID int64    `json:"notebook_id,omitempty" example:"1"`
+	Schedule   string   `json:"schedule,omitempty" example:"0 9 * * MON"`
+	Timezone   string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	// NextRunAt is when the schedule next creates a note.
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	// LastNoteID is the note the schedule created last.
+	LastNoteID int64      `json:"last_note_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// NoteTemplateInput creates or replaces a note template.
+type NoteTemplateInput struct {
+	Name       string   `json:"name" example:"Планёрка"`
+	Title      string   `json:"title" example:"Weekly standup {{.Date}}"`
+	Content    string   `json:"content" example:"## Done\n\n## Next\n"`
+	Tags       []string `json:"tags,omitempty" example:"standup"`
+	NotebookID int64    `json:"notebook_id,omitempty" example:"1"`
+	Schedule   string   `json:"schedule,omitempty" example:"0 9 * * MON"`
+	Timezone   string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+}
@@ -0,0 +1,50 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 2025-01-06 is a Monday.
+	from := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 9 * * MON", from, time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon", from.Add(-time.Second), from},
+		{"*/15 * * * *", from, time.Date(2025, 1, 6, 9, 15, 0, 0, time.UTC)},
+		{"30 8-10 * * 1-5", from, time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", from, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@daily", from, time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * FRI", from, time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", from.Add(-6 * time.Hour).In(moscow), time.Date(2025, 1, 6, 9, 0, 0, 0, moscow)},
+		{"0 0 30 2 *", from, time.Time{}},
+	} {
+		s, err := Parse(tc.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.spec, err)
+			continue
+		}
+		if got := s.Next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%q after %v = %v, want %v", tc.spec, tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * FOO *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
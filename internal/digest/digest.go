@@ -0,0 +1,229 @@
+// Package digest emails users a weekly summary of their notes.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/mailer"
+	"example.com/notes-api/internal/repo"
+)
+
+// Digests go out on SendDay at SendHour in each recipient's time zone and
+// cover the week before.
+const (
+	SendDay  = time.Monday
+	SendHour = 9
+)
+
+type Recipient struct {
+	UserID   string
+	Email    string
+	Location *time.Location
+}
+
+// ParseRecipients reads a comma-separated list of user:email[:zone]
+// entries, e.g. "alice:alice@example.com:Europe/Moscow,bob:bob@example.com".
+// The zone defaults to UTC.
+func ParseRecipients(spec string) ([]Recipient, error) {
+	var recipients []Recipient
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || !strings.Contains(parts[1], "@") {
+			return nil, fmt.Errorf("invalid digest recipient %q", entry)
+		}
+		r := Recipient{UserID: parts[0], Email: parts[1], Location: time.UTC}
+		if len(parts) == 3 && parts[2] != "" {
+			loc, err := time.LoadLocation(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("digest recipient %q: %w", parts[0], err)
+			}
+			r.Location = loc
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// Digest summarizes a user's notes over [From, To).
+type Digest struct {
+	From, To  time.Time
+	Created   []core.Note
+	Edited    []core.Note
+	Reminders []core.Note
+}
+
+// Build collects the notes of userID created or edited in [from, to) and
+// those with reminders due in the week after to.
+func Build(notes []core.Note, userID string, from, to time.Time) Digest {
+	d := Digest{From: from, To: to}
+	for _, n := range notes {
+		if n.OwnerID != userID {
+			continue
+		}
+		switch {
+		case within(n.CreatedAt, from, to):
+			d.Created = append(d.Created, n)
+		case n.UpdatedAt != nil && within(*n.UpdatedAt, from, to):
+			d.Edited = append(d.Edited, n)
+		}
+		if n.RemindAt != nil && within(*n.RemindAt, to, to.AddDate(0, 0, 7)) {
+			d.Reminders = append(d.Reminders, n)
+		}
+	}
+	sort.Slice(d.Reminders, func(i, j int) bool { return d.Reminders[i].RemindAt.Before(*d.Reminders[j].RemindAt) })
+	return d
+}
+
+func within(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+func (d Digest) Empty() bool {
+	return len(d.Created) == 0 && len(d.Edited) == 0 && len(d.Reminders) == 0
+}
+
+// Message renders the digest with times in loc.
+func (d Digest) Message(loc *time.Location) mailer.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your notes from %s to %s.\n",
+		d.From.In(loc).Format("2 Jan"), d.To.In(loc).AddDate(0, 0, -1).Format("2 Jan 2006"))
+
+	section := func(title string, notes []core.Note, line func(core.Note) string) {
+		if len(notes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (%d)\n", title, len(notes))
+		for _, n := range notes {
+			fmt.Fprintf(&b, "  - %s\n", line(n))
+		}
+	}
+	title := func(n core.Note) string { return n.Title }
+	section("New notes", d.Created, title)
+	section("Edited notes", d.Edited, title)
+	section("Reminders this week", d.Reminders, func(n core.Note) string {
+		return n.RemindAt.In(loc).Format("Mon 2 Jan 15:04") + "  " + n.Title
+	})
+
+	return mailer.Message{Subject: "Your weekly notes digest", Body: b.String()}
+}
+
+// LastSlot returns the latest digest time at or before now in loc.
+func LastSlot(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	days := (int(local.Weekday()) - int(SendDay) + 7) % 7
+	slot := time.Date(local.Year(), local.Month(), local.Day()-days, SendHour, 0, 0, 0, loc)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -7)
+	}
+	return slot
+}
+
+// Sender mails digests to Recipients and to the users who opted in through
+// Preferences, whose time zone setting also applies to Recipients. The
+// first slot seen for a recipient after startup is skipped, so restarts do
+// not send the same digest twice.
+type Sender struct {
+	Notes       core.NoteRepository
+	Mailer      mailer.Mailer
+	Recipients  []Recipient
+	Preferences *repo.PreferenceRepoMem
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// Start checks for due digests every interval until ctx is cancelled.
+func (s *Sender) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.Run(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run sends the digests due at now. A digest that fails to send is
+// retried on the next run.
+func (s *Sender) Run(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sent == nil {
+		s.sent = make(map[string]time.Time)
+	}
+
+	var notes []core.Note
+	for _, r := range s.recipients() {
+		slot := LastSlot(now, r.Location)
+		last, seen := s.sent[r.UserID]
+		if !seen {
+			s.sent[r.UserID] = slot
+			continue
+		}
+		if !slot.After(last) {
+			continue
+		}
+
+		if notes == nil {
+			all, err := s.Notes.List()
+			if err != nil {
+				log.Printf("digest: %v", err)
+				return
+			}
+			notes = all
+		}
+
+		d := Build(notes, r.UserID, slot.AddDate(0, 0, -7), slot)
+		if !d.Empty() {
+			m := d.Message(r.Location)
+			m.To = []string{r.Email}
+			if err := s.Mailer.Send(ctx, m); err != nil {
+				log.Printf("digest for %s: %v", r.UserID, err)
+				continue
+			}
+		}
+		s.sent[r.UserID] = slot
+	}
+}
+
+func (s *Sender) recipients() []Recipient {
+	if s.Preferences == nil {
+		return s.Recipients
+	}
+
+	recipients := make([]Recipient, 0, len(s.Recipients))
+	listed := make(map[string]bool, len(s.Recipients))
+	for _, r := range s.Recipients {
+		if prefs := s.Preferences.Get(r.UserID); prefs.Timezone != "" {
+			r.Location = prefs.Location()
+		}
+		recipients = append(recipients, r)
+		listed[r.UserID] = true
+	}
+	for _, userID := range s.Preferences.Users() {
+		prefs := s.Preferences.Get(userID)
+		if listed[userID] || !prefs.Notifications.Digest || prefs.Email == "" {
+			continue
+		}
+		recipients = append(recipients, Recipient{UserID: userID, Email: prefs.Email, Location: prefs.Location()})
+	}
+	return recipients
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"example.com/notes-api/internal/nats"
+	"example.com/notes-api/internal/redis"
+)
+
+// Broker carries encoded events between replicas. Subscribe blocks,
+// calling fn for each message, until ctx is done or the connection fails.
+type Broker interface {
+	Publish(ctx context.Context, payload []byte) error
+	Subscribe(ctx context.Context, fn func([]byte)) error
+	String() string
+}
+
+// MemoryBroker keeps events within the process, for a single replica.
+type MemoryBroker struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]func([]byte)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[int]func([]byte))}
+}
+
+func (b *MemoryBroker) String() string {
+	return "memory"
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, fn := range b.subs {
+		fn(payload)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, fn func([]byte)) error {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subs[id] = fn
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.subs, id)
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+// RedisBroker relays events through a Redis pub/sub channel.
+type RedisBroker struct {
+	Client  *redis.Client
+	Channel string
+}
+
+func (b RedisBroker) String() string {
+	return b.Client.String() + "/" + b.Channel
+}
+
+func (b RedisBroker) Publish(ctx context.Context, payload []byte) error {
+	_, err := b.Client.Do(ctx, "PUBLISH", b.Channel, string(payload))
+	return err
+}
+
+func (b RedisBroker) Subscribe(ctx context.Context, fn func([]byte)) error {
+	return b.Client.Subscribe(ctx, b.Channel, fn)
+}
+
+// NATSBroker relays events through a NATS subject.
+type NATSBroker struct {
+	Client  *nats.Client
+	Subject string
+}
+
+func (b NATSBroker) String() string {
+	return b.Client.String() + "/" + b.Subject
+}
+
+func (b NATSBroker) Publish(ctx context.Context, payload []byte) error {
+	return b.Client.Publish(ctx, b.Subject, payload)
+}
+
+func (b NATSBroker) Subscribe(ctx context.Context, fn func([]byte)) error {
+	return b.Client.Subscribe(ctx, b.Subject, fn)
+}
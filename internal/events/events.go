@@ -0,0 +1,160 @@
+// Package events fans note changes out to real-time clients. Changes go
+// through a Broker so that clients connected to any replica see changes
+// made on every other one.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+const (
+	NoteCreated = "note.created"
+	NoteUpdated = "note.updated"
+	NoteDeleted = "note.deleted"
+)
+
+// SchemaVersion is the version of the Event encoding. It changes only
+// when a field is removed or changes meaning; consumers should ignore
+// fields they do not know.
+const SchemaVersion = 1
+
+type Event struct {
+	Version int    `json:"version" example:"1"`
+	Type    string `json:"type" example:"note.updated"`
+	NoteID  int64  `json:"note_id" example:"1"`
+	// Note is the note after a note.* event; presence.* events leave it
+	// out.
+	Note core.Note `json:"note,omitzero"`
+	// UserID has the note open in presence.* events, and is Typing in
+	// it.
+	UserID string    `json:"user_id,omitempty" example:"bob"`
+	Typing bool      `json:"typing,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+func FromChange(c repo.Change) Event {
+	return Event{Version: SchemaVersion, Type: "note." + c.Op, NoteID: c.Note.ID, Note: c.Note, At: c.At}
+}
+
+// Hub delivers events published on any replica to the subscribers of this
+// one. Slow subscribers miss events rather than holding up the others.
+type Hub struct {
+	broker Broker
+	out    chan []byte
+
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func NewHub(b Broker) *Hub {
+	return &Hub{
+		broker: b,
+		out:    make(chan []byte, 1024),
+		subs:   make(map[chan Event]struct{}),
+	}
+}
+
+// Run publishes queued events and relays broker messages to subscribers
+// until ctx is done, resubscribing when the broker connection drops.
+func (h *Hub) Run(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case payload := <-h.out:
+				if err := h.broker.Publish(ctx, payload); err != nil {
+					log.Printf("events: publish to %s: %v", h.broker, err)
+				}
+			}
+		}
+	}()
+
+	go func() {
+		backoff := time.Second
+		for {
+			started := time.Now()
+			err := h.broker.Subscribe(ctx, h.deliver)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("events: subscription to %s: %v", h.broker, err)
+
+			if time.Since(started) > time.Minute {
+				backoff = time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+		}
+	}()
+}
+
+// Publish queues an event without blocking; it is dropped when the queue
+// is full.
+func (h *Hub) Publish(e Event) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("events: encode %s: %v", e.Type, err)
+		return
+	}
+
+	select {
+	case h.out <- payload:
+	default:
+		log.Printf("events: queue full, dropping %s for note %d", e.Type, e.NoteID)
+	}
+}
+
+// Subscribe returns a channel of events and a function that ends the
+// subscription.
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// Subscribers is the number of subscriptions that have not ended.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+func (h *Hub) deliver(payload []byte) {
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		log.Printf("events: decode: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan string, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Subscribe(ctx, func(payload []byte) { got <- string(payload) })
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); subscriptions(b) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscribe did not register")
+		}
+	}
+	b.Publish(context.Background(), []byte("hello"))
+	if payload := <-got; payload != "hello" {
+		t.Errorf("payload = %q", payload)
+	}
+
+	// A subscriber that went away is not called again.
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Subscribe = %v after cancel", err)
+	}
+	b.Publish(context.Background(), []byte("late"))
+	select {
+	case payload := <-got:
+		t.Errorf("%q delivered after the subscriber left", payload)
+	default:
+	}
+	if n := subscriptions(b); n != 0 {
+		t.Errorf("%d subscriptions left", n)
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := NewMemoryBroker()
+	h := NewHub(b)
+	h.Run(ctx)
+	stalled, unsubscribeStalled := h.Subscribe()
+	defer unsubscribeStalled()
+	live, unsubscribe := h.Subscribe()
+
+	// The hub subscribes to the broker in the background.
+	for deadline := time.Now().Add(5 * time.Second); subscriptions(b) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("hub did not subscribe to the broker")
+		}
+	}
+
+	// A subscriber that stops reading misses events once its buffer is
+	// full, and does not hold up the others.
+	const events = 100
+	for i := int64(1); i <= events; i++ {
+		h.Publish(Event{Type: NoteUpdated, NoteID: i})
+		e := receive(live, 5*time.Second)
+		if e == nil || e.NoteID != i {
+			t.Fatalf("event %d: got %+v", i, e)
+		}
+	}
+	if n := len(stalled); n != cap(stalled) {
+		t.Errorf("stalled subscriber holds %d events, want %d", n, cap(stalled))
+	}
+	if e := <-stalled; e.NoteID != 1 {
+		t.Errorf("stalled subscriber kept %+v first, want the oldest event", e)
+	}
+
+	if n := h.Subscribers(); n != 2 {
+		t.Errorf("Subscribers = %d, want 2", n)
+	}
+	unsubscribe()
+	h.Publish(Event{Type: NoteDeleted, NoteID: events})
+	if e := receive(live, 50*time.Millisecond); e != nil {
+		t.Errorf("%+v delivered after unsubscribing", e)
+	}
+	if n := h.Subscribers(); n != 1 {
+		t.Errorf("Subscribers = %d after unsubscribing, want 1", n)
+	}
+}
+
+func receive(ch <-chan Event, timeout time.Duration) *Event {
+	select {
+	case e := <-ch:
+		return &e
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+func subscriptions(b *MemoryBroker) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
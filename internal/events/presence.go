@@ -0,0 +1,150 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+)
+
+// Presence events tell who has a note open: PresenceUpdated when a client
+// opens the note, starts or stops typing, or sends a heartbeat, and
+// PresenceLeft when it closes the note.
+const (
+	PresenceUpdated = "presence.updated"
+	PresenceLeft    = "presence.left"
+)
+
+// DefaultPresenceTTL is how long a user stays present without a
+// heartbeat.
+const DefaultPresenceTTL = time.Minute
+
+// IsPresence reports whether an event is about presence rather than a
+// change to the note.
+func IsPresence(e Event) bool {
+	return strings.HasPrefix(e.Type, "presence.")
+}
+
+// Viewer is a user who has a note open.
+type Viewer struct {
+	UserID string    `json:"user_id" example:"bob"`
+	Typing bool      `json:"typing"`
+	Since  time.Time `json:"since"`
+}
+
+type presence struct {
+	Viewer
+	at   time.Time
+	left bool
+}
+
+// Presence tracks who has each note open from the presence events of
+// every replica. Users who send no heartbeat for TTL are gone.
+type Presence struct {
+	Clock clock.Clock
+	// TTL is how long a user stays present without a heartbeat; zero uses
+	// DefaultPresenceTTL.
+	TTL time.Duration
+
+	mu    sync.Mutex
+	notes map[int64]map[string]*presence
+}
+
+func NewPresence() *Presence {
+	return &Presence{Clock: clock.System{}, notes: make(map[int64]map[string]*presence)}
+}
+
+// Event returns the presence event of userID on a note, stamped now.
+func (p *Presence) Event(noteID int64, userID string, typing, left bool) Event {
+	e := Event{Version: SchemaVersion, Type: PresenceUpdated, NoteID: noteID, UserID: userID, Typing: typing, At: p.Clock.Now()}
+	if left {
+		e.Type, e.Typing = PresenceLeft, false
+	}
+	return e
+}
+
+// Apply records a presence event and ignores any other. Events older
+// than the last one of the same user on the note are stale and ignored;
+// of two at the same time, leaving wins.
+func (p *Presence) Apply(e Event) {
+	if !IsPresence(e) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	users := p.notes[e.NoteID]
+	if users == nil {
+		users = make(map[string]*presence)
+		p.notes[e.NoteID] = users
+	}
+	cur := users[e.UserID]
+	if cur != nil && (e.At.Before(cur.at) || e.At.Equal(cur.at) && e.Type != PresenceLeft) {
+		return
+	}
+	if e.Type == PresenceLeft {
+		users[e.UserID] = &presence{Viewer: Viewer{UserID: e.UserID}, at: e.At, left: true}
+		return
+	}
+	since := e.At
+	if cur != nil && !cur.left && !p.expired(cur, p.Clock.Now()) {
+		since = cur.Since
+	}
+	users[e.UserID] = &presence{Viewer: Viewer{UserID: e.UserID, Typing: e.Typing, Since: since}, at: e.At}
+}
+
+// Viewers returns who has a note open, by the time they opened it.
+func (p *Presence) Viewers(noteID int64) []Viewer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.Clock.Now()
+	viewers := []Viewer{}
+	for id, cur := range p.notes[noteID] {
+		if p.expired(cur, now) {
+			delete(p.notes[noteID], id)
+			continue
+		}
+		if !cur.left {
+			viewers = append(viewers, cur.Viewer)
+		}
+	}
+	if len(p.notes[noteID]) == 0 {
+		delete(p.notes, noteID)
+	}
+	sort.Slice(viewers, func(i, j int) bool {
+		if !viewers[i].Since.Equal(viewers[j].Since) {
+			return viewers[i].Since.Before(viewers[j].Since)
+		}
+		return viewers[i].UserID < viewers[j].UserID
+	})
+	return viewers
+}
+
+// Follow applies the presence events hub delivers until ctx is done.
+func (p *Presence) Follow(ctx context.Context, hub *Hub) {
+	events, unsubscribe := hub.Subscribe()
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-events:
+				p.Apply(e)
+			}
+		}
+	}()
+}
+
+func (p *Presence) expired(cur *presence, now time.Time) bool {
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = DefaultPresenceTTL
+	}
+	return !now.Before(cur.at.Add(ttl))
+}
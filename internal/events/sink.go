@@ -0,0 +1,144 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/nats"
+)
+
+// Sink delivers lifecycle events to other services, such as search
+// indexers or analytics.
+type Sink interface {
+	Send(ctx context.Context, e Event, payload []byte) error
+	String() string
+}
+
+// NATSSink publishes each event on Prefix.<type>, for example
+// notes.note.created, so consumers can subscribe to the types they need.
+type NATSSink struct {
+	Client *nats.Client
+	Prefix string
+}
+
+func (s NATSSink) String() string {
+	return s.Client.String() + "/" + s.Prefix
+}
+
+func (s NATSSink) Send(ctx context.Context, e Event, payload []byte) error {
+	return s.Client.Publish(ctx, s.Prefix+"."+e.Type, payload)
+}
+
+// KafkaRESTSink produces events to a Kafka topic through a Confluent REST
+// Proxy. Records are keyed by note ID, so the events of one note stay in
+// order within their partition.
+type KafkaRESTSink struct {
+	URL    string
+	Topic  string
+	Client *http.Client
+}
+
+func (s KafkaRESTSink) String() string {
+	return s.URL + "/topics/" + s.Topic
+}
+
+func (s KafkaRESTSink) Send(ctx context.Context, e Event, payload []byte) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{
+			"key":   strconv.FormatInt(e.NoteID, 10),
+			"value": json.RawMessage(payload),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/topics/"+url.PathEscape(s.Topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy: %s", resp.Status)
+	}
+	return nil
+}
+
+// Forwarder sends events to a Sink in order, retrying failed deliveries
+// with backoff. Events still failing after the last attempt are logged and
+// dropped; the queue never blocks the repository.
+type Forwarder struct {
+	sink     Sink
+	queue    chan Event
+	attempts int
+}
+
+func NewForwarder(sink Sink) *Forwarder {
+	return &Forwarder{sink: sink, queue: make(chan Event, 1024), attempts: 5}
+}
+
+func (f *Forwarder) Publish(e Event) {
+	select {
+	case f.queue <- e:
+	default:
+		log.Printf("events: %s queue full, dropping %s for note %d", f.sink, e.Type, e.NoteID)
+	}
+}
+
+func (f *Forwarder) Run(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-f.queue:
+				f.send(ctx, e)
+			}
+		}
+	}()
+}
+
+func (f *Forwarder) send(ctx context.Context, e Event) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("events: encode %s: %v", e.Type, err)
+		return
+	}
+
+	backoff := 100 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := f.sink.Send(ctx, e, payload)
+		if err == nil {
+			return
+		}
+		if attempt == f.attempts {
+			log.Printf("events: giving up on %s for note %d to %s: %v", e.Type, e.NoteID, f.sink, err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,135 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/events"
+	"example.com/notes-api/internal/nats"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/testutil"
+)
+
+// flakySink fails its first sends, as many as failures, then records
+// payloads.
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	payloads chan []byte
+}
+
+func (s *flakySink) String() string { return "flaky" }
+
+func (s *flakySink) Send(ctx context.Context, e events.Event, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.payloads <- payload
+	return nil
+}
+
+func TestForwarderEnvelope(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := &flakySink{failures: 1, payloads: make(chan []byte, 1)}
+	f := events.NewForwarder(sink)
+	f.Run(ctx)
+
+	at := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	f.Publish(events.FromChange(repo.Change{Op: "updated", Note: core.Note{ID: 7, Title: "План"}, At: at}))
+
+	var payload []byte
+	select {
+	case payload = <-sink.payloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered after a failed attempt")
+	}
+	// Consumers rely on these fields; removing one or changing what it
+	// means needs a new SchemaVersion.
+	var envelope struct {
+		Version int       `json:"version"`
+		Type    string    `json:"type"`
+		NoteID  int64     `json:"note_id"`
+		Note    core.Note `json:"note"`
+		At      time.Time `json:"at"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Version != 1 || envelope.Type != events.NoteUpdated ||
+		envelope.NoteID != 7 || envelope.Note.Title != "План" || !envelope.At.Equal(at) {
+		t.Errorf("envelope = %s", payload)
+	}
+}
+
+func TestKafkaRESTSink(t *testing.T) {
+	var got struct {
+		path, contentType string
+		body              []byte
+	}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.contentType = r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		got.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := events.KafkaRESTSink{URL: srv.URL, Topic: "notes/events"}
+	e := events.Event{Version: events.SchemaVersion, Type: events.NoteDeleted, NoteID: 42}
+	payload, _ := json.Marshal(e)
+	if err := sink.Send(context.Background(), e, payload); err != nil {
+		t.Fatal(err)
+	}
+	if got.path != "/topics/notes%2Fevents" || got.contentType != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("request = %s, %s", got.path, got.contentType)
+	}
+	var body struct {
+		Records []struct {
+			Key   string       `json:"key"`
+			Value events.Event `json:"value"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(got.body, &body); err != nil {
+		t.Fatal(err)
+	}
+	// Records are keyed by note, so one note's events stay in order.
+	if len(body.Records) != 1 || body.Records[0].Key != "42" || body.Records[0].Value.Type != events.NoteDeleted || body.Records[0].Value.Version != 1 {
+		t.Errorf("body = %s", got.body)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Send(context.Background(), e, payload); err == nil {
+		t.Error("Send succeeded on 503")
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	pub := make(chan string, 1)
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+		c.Send("INFO {}")
+		c.Line() // CONNECT
+		if c.Expect("PING") {
+			c.Send("PONG")
+			pub <- c.Line()
+		}
+	})
+	sink := events.NATSSink{Client: nats.New("nats://" + peer.Addr), Prefix: "notes"}
+	if err := sink.Send(context.Background(), events.Event{Type: events.NoteCreated}, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-pub; line != "PUB notes.note.created 2" {
+		t.Errorf("published %q", line)
+	}
+}
@@ -0,0 +1,157 @@
+// Package faults injects latency, server errors and dropped connections
+// into matching requests, so that clients can exercise their retry logic
+// against a real server. It is meant for development and staging only.
+package faults
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Kind string
+
+const (
+	Latency Kind = "latency"
+	Error   Kind = "error"
+	Drop    Kind = "drop"
+)
+
+// Fault is applied to Percent percent of the requests its rule matches.
+// Delay is used by latency faults and Status by error faults.
+type Fault struct {
+	Kind    Kind
+	Percent float64
+	Delay   time.Duration
+	Status  int
+}
+
+// Rule applies its faults to requests whose path matches Pattern: exactly,
+// or by prefix when Pattern ends in "*". An empty Method matches any.
+type Rule struct {
+	Method  string
+	Pattern string
+	Faults  []Fault
+}
+
+func (r Rule) matches(req *http.Request) bool {
+	if r.Method != "" && r.Method != req.Method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Pattern, "*"); ok {
+		return strings.HasPrefix(req.URL.Path, prefix)
+	}
+	return req.URL.Path == r.Pattern
+}
+
+// Parse reads rules separated by ";", each a pattern, optionally preceded
+// by a method, followed by "=" and comma-separated faults:
+//
+//	GET /api/v1/notes*=latency:300ms@20%,error:503@5%; /api/v1/*=drop@1%
+//
+// An empty spec has no rules.
+func Parse(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target, list, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("fault rule %q: want [METHOD] PATTERN=FAULT,...", part)
+		}
+
+		var rule Rule
+		fields := strings.Fields(target)
+		switch len(fields) {
+		case 1:
+			rule.Pattern = fields[0]
+		case 2:
+			rule.Method, rule.Pattern = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, fmt.Errorf("fault rule %q: want [METHOD] PATTERN=FAULT,...", part)
+		}
+		if !strings.HasPrefix(rule.Pattern, "/") {
+			return nil, fmt.Errorf("fault rule %q: pattern must start with /", part)
+		}
+
+		for _, f := range strings.Split(list, ",") {
+			fault, err := parseFault(strings.TrimSpace(f))
+			if err != nil {
+				return nil, fmt.Errorf("fault rule %q: %w", part, err)
+			}
+			rule.Faults = append(rule.Faults, fault)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseFault reads "latency:300ms@20%", "error:503@5%" or "drop@1%".
+func parseFault(s string) (Fault, error) {
+	spec, percent, ok := strings.Cut(s, "@")
+	if !ok {
+		return Fault{}, fmt.Errorf("fault %q: missing @percent", s)
+	}
+	p, err := strconv.ParseFloat(strings.TrimSuffix(percent, "%"), 64)
+	if err != nil || p < 0 || p > 100 {
+		return Fault{}, fmt.Errorf("fault %q: percent must be between 0 and 100", s)
+	}
+
+	kind, arg, _ := strings.Cut(spec, ":")
+	f := Fault{Kind: Kind(kind), Percent: p}
+	switch f.Kind {
+	case Latency:
+		if f.Delay, err = time.ParseDuration(arg); err != nil || f.Delay <= 0 {
+			return Fault{}, fmt.Errorf("fault %q: invalid delay", s)
+		}
+	case Error:
+		if f.Status, err = strconv.Atoi(arg); err != nil || f.Status < 500 || f.Status > 599 {
+			return Fault{}, fmt.Errorf("fault %q: status must be a 5xx code", s)
+		}
+	case Drop:
+		if arg != "" {
+			return Fault{}, fmt.Errorf("fault %q: drop takes no argument", s)
+		}
+	default:
+		return Fault{}, fmt.Errorf("fault %q: unknown kind, want latency, error or drop", s)
+	}
+	return f, nil
+}
+
+// Injector picks the faults to apply to a request.
+type Injector struct {
+	Rules []Rule
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func NewInjector(rules []Rule) *Injector {
+	return &Injector{Rules: rules, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Pick rolls every fault of every rule the request matches and returns
+// those that hit, in rule order.
+func (in *Injector) Pick(r *http.Request) []Fault {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var hit []Fault
+	for _, rule := range in.Rules {
+		if !rule.matches(r) {
+			continue
+		}
+		for _, f := range rule.Faults {
+			if in.rand.Float64()*100 < f.Percent {
+				hit = append(hit, f)
+			}
+		}
+	}
+	return hit
+}
@@ -0,0 +1,50 @@
+package faults
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := Parse("GET /api/v1/notes*=latency:300ms@20%,error:503@5%; /health=drop@100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Rule{
+		{Method: "GET", Pattern: "/api/v1/notes*", Faults: []Fault{
+			{Kind: Latency, Percent: 20, Delay: 300 * time.Millisecond},
+			{Kind: Error, Percent: 5, Status: 503},
+		}},
+		{Pattern: "/health", Faults: []Fault{{Kind: Drop, Percent: 100}}},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Fatalf("Parse = %+v, want %+v", rules, want)
+	}
+
+	for _, spec := range []string{"/a", "a=drop@1%", "/a=drop", "/a=error:404@1%", "/a=latency:x@1%", "/a=drop@101%", "/a=boom@1%"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	in := NewInjector([]Rule{
+		{Method: "GET", Pattern: "/api/v1/notes*", Faults: []Fault{{Kind: Error, Percent: 100, Status: 503}}},
+		{Pattern: "/health", Faults: []Fault{{Kind: Drop, Percent: 0}}},
+	})
+	for path, want := range map[string]int{
+		"/api/v1/notes/1":   1,
+		"/api/v1/notebooks": 0,
+		"/health":           0,
+	} {
+		if got := len(in.Pick(httptest.NewRequest("GET", path, nil))); got != want {
+			t.Errorf("GET %s: %d faults, want %d", path, got, want)
+		}
+	}
+	if got := in.Pick(httptest.NewRequest("POST", "/api/v1/notes", nil)); len(got) != 0 {
+		t.Errorf("POST matched a GET rule: %+v", got)
+	}
+}
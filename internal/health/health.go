@@ -0,0 +1,151 @@
+// Package health pings the backends the server depends on in the
+// background and reports whether it is ready to serve.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check reports whether a backend is reachable.
+type Check func(ctx context.Context) error
+
+type CheckStatus struct {
+	Name      string     `json:"name" example:"redis"`
+	OK        bool       `json:"ok"`
+	Error     string     `json:"error,omitempty"`
+	LatencyMS float64    `json:"latency_ms" example:"0.35"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	// Failures counts consecutive failed checks.
+	Failures int `json:"failures"`
+}
+
+type Report struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckStatus `json:"checks"`
+}
+
+// Monitor runs its checks every Interval. A check that has not completed
+// yet counts as failing, so a fresh replica is not ready until every
+// backend has answered once.
+type Monitor struct {
+	Interval time.Duration
+	Timeout  time.Duration
+
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+	status map[string]*CheckStatus
+}
+
+func NewMonitor(interval time.Duration) *Monitor {
+	return &Monitor{
+		Interval: interval,
+		Timeout:  2 * time.Second,
+		checks:   make(map[string]Check),
+		status:   make(map[string]*CheckStatus),
+	}
+}
+
+func (m *Monitor) Add(name string, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.names = append(m.names, name)
+	m.checks[name] = check
+	m.status[name] = &CheckStatus{Name: name}
+}
+
+func (m *Monitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.Interval)
+		defer ticker.Stop()
+
+		for {
+			m.RunChecks(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunChecks runs every check once, concurrently.
+func (m *Monitor) RunChecks(ctx context.Context) {
+	m.mu.Lock()
+	checks := make(map[string]Check, len(m.checks))
+	for name, check := range m.checks {
+		checks[name] = check
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, m.Timeout)
+			defer cancel()
+			start := time.Now()
+			err := check(cctx)
+			now := time.Now()
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			s := m.status[name]
+			s.OK = err == nil
+			s.Error = ""
+			s.LatencyMS = float64(now.Sub(start).Microseconds()) / 1000
+			s.CheckedAt = &now
+			if err != nil {
+				s.Error = err.Error()
+				s.Failures++
+			} else {
+				s.Failures = 0
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (m *Monitor) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := Report{Ready: true, Checks: make([]CheckStatus, 0, len(m.names))}
+	for _, name := range m.names {
+		s := *m.status[name]
+		r.Checks = append(r.Checks, s)
+		r.Ready = r.Ready && s.OK
+	}
+	return r
+}
+
+// Up reports whether the named check last succeeded, for metrics.
+func (m *Monitor) Up(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.status[name]
+	return ok && s.OK
+}
+
+// ServeHTTP answers readiness probes: 200 when every check passes,
+// otherwise 503 so that load balancers stop routing to this replica.
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := m.Report()
+	code := http.StatusOK
+	if !report.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,39 @@
+package highlight
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var spans = regexp.MustCompile(`<span class="[kscn]">|</span>`)
+
+func FuzzHTML(f *testing.F) {
+	f.Add("func main() { s := \"x\" // done\n}", "go")
+	f.Add("def f(x):\n    return 'a' # c", "python")
+	f.Add("/* open comment", "js")
+	f.Add("\"unterminated <b>", "go")
+	f.Add("<script>", "")
+
+	f.Fuzz(func(t *testing.T, code, lang string) {
+		if !utf8.ValidString(code) {
+			// tokenize works on runes and replaces invalid bytes.
+			return
+		}
+		out := HTML(code, lang)
+		prefix := `<pre class="highlight"><code>`
+		if lang != "" {
+			prefix = `<pre class="highlight"><code class="language-` + html.EscapeString(strings.ToLower(lang)) + `">`
+		}
+		const suffix = "</code></pre>"
+		if len(out) < len(prefix)+len(suffix) || out[:len(prefix)] != prefix || out[len(out)-len(suffix):] != suffix {
+			t.Fatalf("unexpected wrapper in %q", out)
+		}
+		body := spans.ReplaceAllString(out[len(prefix):len(out)-len(suffix)], "")
+		if got := html.UnescapeString(body); got != code {
+			t.Fatalf("HTML(%q) renders %q", code, got)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package highlight
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+type language struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	ignoreCase   bool
+}
+
+var languages = map[string]language{
+	"go": {
+		keywords:     words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	},
+	"python": {
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"javascript": {
+		keywords:     words("async await break case catch class const continue default delete do else export extends finally for function if import in instanceof let new of return switch this throw try typeof var void while yield null undefined true false"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	},
+	"sql": {
+		keywords:     words("select from where and or not insert into values update set delete create table index drop alter join left right inner outer on group by order having limit offset as null is in like distinct returning"),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+		ignoreCase:   true,
+	},
+	"bash": {
+		keywords:     words("if then else elif fi for while do done case esac function in return export local"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"json": {
+		keywords: words("true false null"),
+		quotes:   "\"",
+	},
+}
+
+var aliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"js":     "javascript",
+	"ts":     "javascript",
+	"sh":     "bash",
+	"shell":  "bash",
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+func lookup(name string) (language, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	lang, ok := languages[name]
+	return lang, ok
+}
+
+// Supported reports whether HTML will contain token markup for the language.
+func Supported(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+// HTML renders code as an escaped <pre><code> block. Keywords, strings,
+// comments and numbers of supported languages are wrapped in spans with
+// the classes k, s, c and n; other languages are only escaped.
+func HTML(code, lang string) string {
+	var b strings.Builder
+	b.WriteString(`<pre class="highlight"><code`)
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(strings.ToLower(lang)) + `"`)
+	}
+	b.WriteString(">")
+
+	l, ok := lookup(lang)
+	if !ok {
+		b.WriteString(html.EscapeString(code))
+	} else {
+		tokenize(&b, code, l)
+	}
+
+	b.WriteString("</code></pre>")
+	return b.String()
+}
+
+func tokenize(b *strings.Builder, code string, l language) {
+	src := []rune(code)
+	for i := 0; i < len(src); {
+		rest := string(src[i:])
+
+		if end := l.comment(rest); end > 0 {
+			n := len([]rune(rest[:end]))
+			span(b, "c", string(src[i:i+n]))
+			i += n
+			continue
+		}
+
+		switch r := src[i]; {
+		case strings.ContainsRune(l.quotes, r):
+			j := i + 1
+			for j < len(src) && src[j] != r {
+				if src[j] == '\\' && r != '`' {
+					j++
+				}
+				j++
+			}
+			if j < len(src) {
+				j++
+			}
+			if j > len(src) {
+				j = len(src)
+			}
+			span(b, "s", string(src[i:j]))
+			i = j
+
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(src) && (unicode.IsDigit(src[j]) || unicode.IsLetter(src[j]) || src[j] == '.' || src[j] == '_') {
+				j++
+			}
+			span(b, "n", string(src[i:j]))
+			i = j
+
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(src[j]) || unicode.IsDigit(src[j]) || src[j] == '_') {
+				j++
+			}
+			word := string(src[i:j])
+			if l.keywords[word] || l.ignoreCase && l.keywords[strings.ToLower(word)] {
+				span(b, "k", word)
+			} else {
+				b.WriteString(html.EscapeString(word))
+			}
+			i = j
+
+		default:
+			b.WriteString(html.EscapeString(string(r)))
+			i++
+		}
+	}
+}
+
+// comment returns the byte length of a comment starting at s, or 0.
+func (l language) comment(s string) int {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			if end := strings.IndexByte(s, '\n'); end >= 0 {
+				return end
+			}
+			return len(s)
+		}
+	}
+	if open, closing := l.blockComment[0], l.blockComment[1]; open != "" && strings.HasPrefix(s, open) {
+		if end := strings.Index(s[len(open):], closing); end >= 0 {
+			return len(open) + end + len(closing)
+		}
+		return len(s)
+	}
+	return 0
+}
+
+func span(b *strings.Builder, class, text string) {
+	b.WriteString(`<span class="` + class + `">`)
+	b.WriteString(html.EscapeString(text))
+	b.WriteString("</span>")
+}
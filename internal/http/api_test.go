@@ -0,0 +1,2533 @@
+package httpx_test
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/attach"
+	"example.com/notes-api/internal/audit"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/events"
+	httpx "example.com/notes-api/internal/http"
+	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/preview"
+	"example.com/notes-api/internal/qr"
+	"example.com/notes-api/internal/ratelimit"
+	"example.com/notes-api/internal/record"
+	"example.com/notes-api/internal/render"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/search"
+	"example.com/notes-api/internal/testutil"
+	"example.com/notes-api/pkg/client"
+)
+
+func createNote(t *testing.T, c *testutil.Client, body string) core.Note {
+	t.Helper()
+	var n core.Note
+	c.Post("/api/v1/notes", body).Expect(http.StatusCreated).JSON(&n)
+	return n
+}
+
+func TestHealth(t *testing.T) {
+	s := testutil.New(t)
+	s.As("").Get("/health").Expect(http.StatusOK)
+	s.As("").Get("/api/v1/notes").Expect(http.StatusUnauthorized)
+}
+
+func TestAuthChallenge(t *testing.T) {
+	s := testutil.New(t)
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:wrong"))
+	for _, tc := range []struct {
+		path, authorization string
+		want                []string
+	}{
+		{"/api/v1/notes", "", []string{"Bearer"}},
+		{"/api/v1/notes", "Bearer wrong", []string{"Bearer"}},
+		{"/api/v1/notes", basic, []string{"Bearer", `Basic realm="notes"`}},
+		{"/api/v1/zapier/me", "", []string{"Bearer", `Basic realm="notes"`}},
+		{"/dav/", "", []string{"Bearer", `Basic realm="notes"`}},
+	} {
+		c := s.As("")
+		if tc.authorization != "" {
+			c = c.WithHeader("Authorization", tc.authorization)
+		}
+		res := c.Get(tc.path).Expect(http.StatusUnauthorized)
+		if got := res.Header.Values("WWW-Authenticate"); !slices.Equal(got, tc.want) {
+			t.Errorf("GET %s with %q: WWW-Authenticate = %q, want %q", tc.path, tc.authorization, got, tc.want)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	n := createNote(t, alice, `{"title":"Планы на неделю","content":"купить молоко","tags":["Home"]}`)
+	alice.Get("/api/v1/notes/1").Expect(http.StatusOK).Golden("note")
+	alice.Get("/api/v1/notes/" + n.PublicID).Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/slug/" + n.Slug).Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/1/markdown").Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/1/highlight").Expect(http.StatusBadRequest)
+	alice.Post("/api/v1/notes", `{"title":" "}`).Expect(http.StatusBadRequest)
+	alice.Post("/api/v1/notes", `{`).Expect(http.StatusBadRequest)
+
+	snippet := createNote(t, alice, `{"title":"hello","type":"snippet","language":"go","content":"package main"}`)
+	alice.Get("/api/v1/notes/2/highlight").Expect(http.StatusOK)
+
+	s.Clock.Advance(time.Hour)
+	var patched core.Note
+	alice.Patch("/api/v1/notes/1", `{"title":"Планы","pinned":true}`).Expect(http.StatusOK).JSON(&patched)
+	if patched.Slug != "plany" || patched.UpdatedAt == nil || !patched.UpdatedAt.Equal(testutil.Epoch.Add(time.Hour)) {
+		t.Errorf("patched note = %+v", patched)
+	}
+	alice.Get("/api/v1/notes/slug/" + n.Slug).Expect(http.StatusMovedPermanently)
+	alice.Patch("/api/v1/notes/1", `{}`).Expect(http.StatusBadRequest)
+
+	var notes []core.Note
+	alice.Get("/api/v1/notes?tag=home").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].ID != n.ID {
+		t.Errorf("tag filter = %+v", notes)
+	}
+	alice.Get("/api/v1/notes?q=молоко").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 {
+		t.Errorf("search = %+v", notes)
+	}
+	resp := alice.Get("/api/v1/notes?limit=1&page=2").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].ID != snippet.ID || resp.Header.Get("X-Total-Count") != "2" {
+		t.Errorf("page 2 = %+v, total %s", notes, resp.Header.Get("X-Total-Count"))
+	}
+
+	createNote(t, alice, `{"title":"Кафе","content":"x","latitude":55.7558,"longitude":37.6173}`)
+	alice.Get("/api/v1/notes/nearby?lat=55.7558&lon=37.6173").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 {
+		t.Errorf("nearby = %+v", notes)
+	}
+
+	s.As(testutil.Bob).Get("/api/v1/notes/1").Expect(http.StatusNotFound)
+	s.As(testutil.Bob).Delete("/api/v1/notes/1").Expect(http.StatusNotFound)
+	alice.Delete("/api/v1/notes/1").Expect(http.StatusNoContent)
+	alice.Get("/api/v1/notes/1").Expect(http.StatusNotFound)
+}
+
+func TestLegacyDelete(t *testing.T) {
+	s := testutil.New(t)
+	s.Handler.Policy = handlers.VersionPolicy{DeleteStatus: http.StatusOK}
+	createNote(t, s.As(testutil.Alice), `{"title":"a","content":""}`)
+	s.As(testutil.Alice).Delete("/api/v1/notes/1").Expect(http.StatusOK)
+}
+
+func TestNotebooks(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	alice.Post("/api/v1/notebooks", `{"name":"Работа"}`).Expect(http.StatusCreated)
+	alice.Post("/api/v1/notebooks", `{"name":"Проекты","parent_id":1}`).Expect(http.StatusCreated)
+	alice.Get("/api/v1/notebooks/2").Expect(http.StatusOK).Golden("notebook")
+	alice.Patch("/api/v1/notebooks/1", `{"parent_id":2}`).Expect(http.StatusBadRequest)
+	alice.Patch("/api/v1/notebooks/2", `{"name":"Проекты 2025"}`).Expect(http.StatusOK)
+
+	createNote(t, alice, `{"title":"a","content":"","notebook_id":1}`)
+	createNote(t, alice, `{"title":"b","content":"","notebook_id":1}`)
+	createNote(t, alice, `{"title":"c","content":""}`)
+
+	bob.Get("/api/v1/notebooks/1").Expect(http.StatusNotFound)
+	alice.Put("/api/v1/notebooks/1/shares", `{"grantee":"team:devs","role":"viewer"}`).Expect(http.StatusOK)
+	bob.Get("/api/v1/notes/1").Expect(http.StatusOK)
+	bob.Patch("/api/v1/notes/1", `{"content":"x"}`).Expect(http.StatusForbidden)
+
+	var notes []core.Note
+	alice.Patch("/api/v1/notes/reorder", `{"notebook_id":1,"ids":[2,1]}`).Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 2 || notes[0].ID != 2 {
+		t.Errorf("reorder = %+v", notes)
+	}
+	alice.Post("/api/v1/notes/3/move", `{"notebook_id":2}`).Expect(http.StatusOK)
+	alice.Post("/api/v1/notes/3/copy", `{"notebook_id":1}`).Expect(http.StatusCreated)
+	alice.Post("/api/v1/notes/move", `{"ids":[1,2],"notebook_id":2}`).Expect(http.StatusOK)
+	alice.Post("/api/v1/notes/copy", `{"ids":[1],"notebook_id":0}`).Expect(http.StatusCreated)
+	bob.Post("/api/v1/notes/move", `{"ids":[1],"notebook_id":0}`).Expect(http.StatusForbidden)
+
+	alice.Get("/api/v1/notebooks").Expect(http.StatusOK)
+	alice.Delete("/api/v1/notebooks/1/shares/team:devs").Expect(http.StatusOK)
+	bob.Get("/api/v1/notes/1").Expect(http.StatusNotFound)
+	alice.Delete("/api/v1/notebooks/1").Expect(http.StatusConflict)
+	alice.Delete("/api/v1/notebooks/2").Expect(http.StatusConflict)
+	alice.Post("/api/v1/notebooks", `{"name":"Пусто"}`).Expect(http.StatusCreated)
+	alice.Delete("/api/v1/notebooks/3").Expect(http.StatusNoContent)
+}
+
+func TestCollectionsAndBoards(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	alice.Post("/api/v1/collections", `{"name":"Задачи","schema":[{"name":"status","type":"string","required":true}]}`).
+		Expect(http.StatusCreated)
+	alice.Get("/api/v1/collections").Expect(http.StatusOK)
+	alice.Get("/api/v1/collections/1").Expect(http.StatusOK)
+	alice.Patch("/api/v1/collections/1", `{"name":"Баги"}`).Expect(http.StatusOK)
+	s.As(testutil.Bob).Get("/api/v1/collections/1").Expect(http.StatusNotFound)
+
+	alice.Post("/api/v1/notes", `{"title":"x","content":"","collection_id":1}`).Expect(http.StatusBadRequest)
+	createNote(t, alice, `{"title":"a","content":"","collection_id":1,"properties":{"status":"todo"}}`)
+	createNote(t, alice, `{"title":"b","content":"","collection_id":1,"properties":{"status":"done"}}`)
+	alice.Get("/api/v1/collections/1/notes?prop.status=done").Expect(http.StatusOK)
+
+	var board handlers.Board
+	alice.Get("/api/v1/boards/status?columns=todo,doing,done").Expect(http.StatusOK).JSON(&board)
+	if len(board.Columns) != 3 || len(board.Columns[0].Cards) != 1 || len(board.Columns[1].Cards) != 0 {
+		t.Errorf("board = %+v", board)
+	}
+	alice.Patch("/api/v1/boards/status/cards/1", `{"column":"doing"}`).Expect(http.StatusOK)
+	alice.Get("/api/v1/boards/status?columns=todo,doing,done").Expect(http.StatusOK).JSON(&board)
+	if len(board.Columns[1].Cards) != 1 {
+		t.Errorf("board after move = %+v", board)
+	}
+	alice.Delete("/api/v1/collections/1").Expect(http.StatusConflict)
+	alice.Post("/api/v1/collections", `{"name":"Пусто","schema":[]}`).Expect(http.StatusCreated)
+	alice.Delete("/api/v1/collections/2").Expect(http.StatusNoContent)
+}
+
+func TestTagsAndJournal(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	createNote(t, alice, `{"title":"a","content":"","tags":["project/alpha","todo"]}`)
+	createNote(t, alice, `{"title":"b","content":"","tags":["tasks"]}`)
+	alice.Get("/api/v1/tags").Expect(http.StatusOK).Golden("tags")
+	alice.Get("/api/v1/tags/tree").Expect(http.StatusOK)
+	alice.Patch("/api/v1/tags/project", `{"name":"work"}`).Expect(http.StatusOK)
+	alice.Post("/api/v1/tags/merge", `{"source":"todo","target":"tasks"}`).Expect(http.StatusOK)
+	alice.Patch("/api/v1/tags/missing", `{"name":"x"}`).Expect(http.StatusNotFound)
+
+	alice.Post("/api/v1/journal/2025-01-06", nil).Expect(http.StatusCreated)
+	alice.Post("/api/v1/journal/2025-01-06", nil).Expect(http.StatusOK)
+	alice.Get("/api/v1/journal/2025-01-06").Expect(http.StatusOK)
+	alice.Get("/api/v1/journal/2025-13-01").Expect(http.StatusBadRequest)
+	alice.Get("/api/v1/journal?from=2025-01-01&to=2025-01-31").Expect(http.StatusOK)
+}
+
+func TestReactionsViewsAndWatches(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	alice.Post("/api/v1/notebooks", `{"name":"Команда"}`).Expect(http.StatusCreated)
+	alice.Put("/api/v1/notebooks/1/shares", `{"grantee":"bob","role":"viewer"}`).Expect(http.StatusOK)
+	createNote(t, alice, `{"title":"a","content":"","notebook_id":1}`)
+
+	var reactions handlers.ReactionsResponse
+	bob.Post("/api/v1/notes/1/reactions", `{"emoji":"👍"}`).Expect(http.StatusOK).JSON(&reactions)
+	if reactions.Reactions["👍"] != 1 || len(reactions.Mine) != 1 {
+		t.Errorf("reactions = %+v", reactions)
+	}
+	bob.Post("/api/v1/notes/1/reactions", `{"emoji":"🍕"}`).Expect(http.StatusBadRequest)
+	var notes []core.Note
+	bob.Get("/api/v1/notes?reacted=true").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 {
+		t.Errorf("reacted = %+v", notes)
+	}
+	bob.Delete("/api/v1/notes/1/reactions?emoji=%F0%9F%91%8D").Expect(http.StatusOK)
+
+	alice.Get("/api/v1/notes/1/views").Expect(http.StatusOK).Golden("views_empty")
+	bob.Get("/api/v1/notes/1").Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/1/views").Expect(http.StatusOK).Golden("views")
+	bob.Get("/api/v1/notes/1/views").Expect(http.StatusForbidden)
+
+	bob.Post("/api/v1/notes/1/watch", nil).Expect(http.StatusOK)
+	alice.Patch("/api/v1/notes/1", `{"content":"new"}`).Expect(http.StatusOK)
+	var inbox []notify.Notification
+	bob.Get("/api/v1/notifications?unread=true").Expect(http.StatusOK).JSON(&inbox)
+	if len(inbox) != 1 || inbox[0].Type != notify.NoteUpdated || inbox[0].Actor != "alice" {
+		t.Errorf("notifications = %+v", inbox)
+	}
+	bob.Post("/api/v1/notifications/read", nil).Expect(http.StatusOK)
+	bob.Delete("/api/v1/notes/1/watch").Expect(http.StatusOK)
+	s.As(testutil.Carol).Post("/api/v1/notes/1/watch", nil).Expect(http.StatusNotFound)
+}
+
+func TestPublicLinks(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	content := "Описание <script>alert(1)</script>\n\n- [x] готово\n- [ ] [ссылка](javascript:alert(1))\n\n![фото](https://example.com/a.png)"
+	createNote(t, alice, `{"title":"Поездка","content":`+strconv.Quote(content)+`,"tags":["travel"]}`)
+	alice.Get("/api/v1/notes/1/public-link").Expect(http.StatusNotFound)
+	bob.Post("/api/v1/notes/1/public-link", nil).Expect(http.StatusNotFound)
+
+	var link handlers.PublicLinkResponse
+	alice.Post("/api/v1/notes/1/public-link", nil).Expect(http.StatusCreated).JSON(&link)
+	if link.URL != "http://example.com/s/"+link.Token {
+		t.Errorf("link = %+v", link)
+	}
+	alice.Post("/api/v1/notes/1/public-link", nil).Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/1/public-link").Expect(http.StatusOK)
+
+	img, err := png.Decode(bytes.NewReader(alice.Get("/api/v1/notes/1/public-link/qr.png?scale=2").Expect(http.StatusOK).Body))
+	if err != nil {
+		t.Fatalf("QR code: %v", err)
+	}
+	code, _ := qr.Encode([]byte(link.URL))
+	if b := img.Bounds(); b.Dx() != (code.Size+2*qr.QuietZone)*2 {
+		t.Errorf("QR code is %v", b)
+	}
+	alice.Get("/api/v1/notes/1/public-link/qr.png?scale=0").Expect(http.StatusBadRequest)
+	bob.Get("/api/v1/notes/1/public-link/qr.png").Expect(http.StatusNotFound)
+
+	page := string(s.As("").Get("/s/" + link.Token).Expect(http.StatusOK).Body)
+	for _, want := range []string{
+		`<meta property="og:title" content="Поездка">`,
+		`<meta property="og:image" content="https://example.com/a.png">`,
+		`&lt;script&gt;`,
+		`<input type="checkbox" disabled checked> готово`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("shared page lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<script>") || strings.Contains(page, `href="javascript`) {
+		t.Errorf("shared page is not escaped:\n%s", page)
+	}
+
+	alice.Delete("/api/v1/notes/1/public-link").Expect(http.StatusNoContent)
+	s.As("").Get("/s/" + link.Token).Expect(http.StatusNotFound)
+	alice.Get("/api/v1/notes/1/public-link/qr.png").Expect(http.StatusNotFound)
+}
+
+func TestPrint(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	createNote(t, alice, `{"title":"Список","blocks":[{"type":"checklist","items":[{"text":"молоко","checked":true},{"text":"хлеб"}]},{"type":"image","url":"https://example.com/a.png"}]}`)
+	page := string(alice.Get("/api/v1/notes/1/print").Expect(http.StatusOK).Body)
+	for _, want := range []string{
+		`<input type="checkbox" disabled checked> молоко`,
+		`<input type="checkbox" disabled> хлеб`,
+		`<li>https://example.com/a.png</li>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("print view lacks %q:\n%s", want, page)
+		}
+	}
+	alice.Get("/api/v1/notes/1/print?format=pdf").Expect(http.StatusNotImplemented)
+	alice.Get("/api/v1/notes/1/print?format=docx").Expect(http.StatusBadRequest)
+	s.As(testutil.Bob).Get("/api/v1/notes/1/print").Expect(http.StatusNotFound)
+}
+
+func TestPrintTemplates(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	admin := s.As(testutil.Admin)
+	createNote(t, alice, `{"title":"Отчёт","content":"Текст","tags":["work"]}`)
+
+	alice.Put("/api/v1/admin/print-templates/acme", `{}`).Expect(http.StatusForbidden)
+	admin.Put("/api/v1/admin/print-templates/acme", `{"header":"{{.Title","meta":"bottom"}`).Expect(http.StatusBadRequest)
+	admin.Put("/api/v1/admin/print-templates/ACME", `{}`).Expect(http.StatusBadRequest)
+	admin.Put("/api/v1/admin/print-templates/acme", `{"header":"ACME · {{.Title}}","footer":"Confidential","meta":"none","css":"h1 { color: #c00; }"}`).
+		Expect(http.StatusOK)
+	admin.Put("/api/v1/admin/print-templates/bare", `{"html":"<h1>{{.Title}}</h1><p>{{.Header}}</p>{{.Body}}","header":"#{{index .Tags 0}}"}`).
+		Expect(http.StatusOK)
+
+	page := string(alice.Get("/api/v1/notes/1/print?template=acme").Expect(http.StatusOK).Body)
+	for _, want := range []string{`<header class="brand">ACME · Отчёт</header>`, `<footer class="brand">Confidential</footer>`, `h1 { color: #c00; }`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("print view lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, `class="meta"`) {
+		t.Errorf("meta not hidden:\n%s", page)
+	}
+	page = string(alice.Get("/api/v1/notes/1/print?template=bare").Expect(http.StatusOK).Body)
+	if !strings.HasPrefix(page, "<h1>Отчёт</h1><p>#work</p>") {
+		t.Errorf("custom layout = %s", page)
+	}
+
+	var list []render.Template
+	admin.Get("/api/v1/admin/print-templates").Expect(http.StatusOK).JSON(&list)
+	if len(list) != 2 || list[0].Name != "acme" || list[0].Author != "root" {
+		t.Errorf("templates = %+v", list)
+	}
+	admin.Delete("/api/v1/admin/print-templates/acme").Expect(http.StatusNoContent)
+	admin.Delete("/api/v1/admin/print-templates/acme").Expect(http.StatusNotFound)
+	alice.Get("/api/v1/notes/1/print?template=acme").Expect(http.StatusBadRequest)
+}
+
+func TestStorageStats(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	alice.Post("/api/v1/notebooks", `{"name":"Работа"}`).Expect(http.StatusCreated)
+	createNote(t, alice, `{"title":"План","content":"пункты","notebook_id":1,"tags":["work"]}`)
+	createNote(t, alice, `{"title":"Фото","blocks":[{"type":"image","url":"https://example.com/a.png"},{"type":"paragraph","text":"подпись"}],"tags":["work","travel"]}`)
+	createNote(t, alice, `{"title":"Удалить"}`)
+	createNote(t, s.As(testutil.Bob), `{"title":"Чужая","content":"не считается"}`)
+	alice.Post("/api/v1/notes/2/move", `{"notebook_id":1}`).Expect(http.StatusOK)
+	alice.Patch("/api/v1/notes/1", `{"content":"пункты и ещё"}`).Expect(http.StatusOK)
+	alice.Delete("/api/v1/notes/3").Expect(http.StatusNoContent)
+
+	alice.Get("/api/v1/stats/storage").Expect(http.StatusOK).Golden("storage")
+}
+
+func TestPreferencesAndStats(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	alice.Get("/api/v1/me/preferences").Expect(http.StatusOK).Golden("preferences_default")
+	alice.Patch("/api/v1/me/preferences", `{"timezone":"Nowhere/City"}`).Expect(http.StatusBadRequest)
+	alice.Patch("/api/v1/me/preferences", `{"timezone":"Asia/Tokyo","page_size":10}`).Expect(http.StatusOK)
+
+	// 23:30 UTC is already the next day in Tokyo.
+	s.Clock.Set(time.Date(2025, 1, 6, 23, 30, 0, 0, time.UTC))
+	createNote(t, alice, `{"title":"late","content":""}`)
+	alice.Get("/api/v1/stats/activity?days=2").Expect(http.StatusOK).Golden("activity_tokyo")
+	alice.Get("/api/v1/stats/activity?days=2&tz=UTC").Expect(http.StatusOK).Golden("activity_utc")
+	alice.Get("/api/v1/stats/activity?tz=Nowhere/City").Expect(http.StatusBadRequest)
+	alice.Get("/api/v1/dashboard").Expect(http.StatusOK)
+}
+
+func TestZapier(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	alice.Get("/api/v1/zapier/me").Expect(http.StatusOK)
+	alice.Post("/api/v1/zapier/actions/create_note", `{"title":"z","content":"a","tags":"inbox, zapier"}`).
+		Expect(http.StatusCreated)
+	alice.Post("/api/v1/zapier/actions/append_to_note", `{"note_id":1,"content":"b"}`).Expect(http.StatusOK)
+	alice.Get("/api/v1/zapier/triggers/new_note").Expect(http.StatusOK)
+	alice.Get("/api/v1/zapier/triggers/new_tagged_note?tag=inbox").Expect(http.StatusOK)
+}
+
+func TestVault(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	createNote(t, alice, `{"title":"a","content":"hello","tags":["x"]}`)
+	zip := alice.Get("/api/v1/export/vault").Expect(http.StatusOK).Body
+	other := testutil.New(t).As(testutil.Alice)
+	other.Post("/api/v1/import/vault", zip).Expect(http.StatusCreated)
+	var notes []core.Note
+	other.Get("/api/v1/notes").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].Content != "hello" {
+		t.Errorf("imported = %+v", notes)
+	}
+}
+
+func TestImportJobs(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	csv := "title,content,tags,notebook\nПлан,пункты,work,Работа\n,,,\nИдеи,,,\n"
+	var job handlers.JobResponse
+	alice.Post("/api/v1/import/jobs?format=csv", csv).Expect(http.StatusAccepted).JSON(&job)
+
+	// The job runs in the background; poll it like a client would.
+	for deadline := time.Now().Add(5 * time.Second); job.State != jobs.StateDone; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) || job.State == jobs.StateFailed {
+			t.Fatalf("job = %+v", job)
+		}
+		alice.Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)).Expect(http.StatusOK).JSON(&job)
+	}
+	if job.Total != 3 || job.Done != 3 || job.ErrorCount != 1 || job.ResultURL == "" {
+		t.Errorf("job = %+v", job)
+	}
+	var report handlers.ImportReport
+	alice.Get(job.ResultURL).Expect(http.StatusOK).JSON(&report)
+	if report.NotebooksCreated != 1 || len(report.NoteIDs) != 2 || len(report.Skipped) != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	s.As(testutil.Bob).Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)).Expect(http.StatusNotFound)
+
+	alice.Post("/api/v1/import/jobs?format=enex", "<html/>").Expect(http.StatusAccepted).JSON(&job)
+	for job.State != jobs.StateFailed {
+		if job.State == jobs.StateDone {
+			t.Fatalf("invalid ENEX imported: %+v", job)
+		}
+		time.Sleep(time.Millisecond)
+		alice.Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)).Expect(http.StatusOK).JSON(&job)
+	}
+	alice.Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10) + "/result").Expect(http.StatusNotFound)
+	alice.Post("/api/v1/import/jobs?format=pdf", "x").Expect(http.StatusBadRequest)
+	alice.Get("/api/v1/jobs/99").Expect(http.StatusNotFound)
+}
+
+func TestExportJobs(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	createNote(t, alice, `{"title":"a","content":"hello"}`)
+
+	var job handlers.JobResponse
+	alice.Post("/api/v1/export", nil).Expect(http.StatusAccepted).JSON(&job)
+	path := "/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)
+	for deadline := time.Now().Add(5 * time.Second); job.State != jobs.StateDone; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) || job.State == jobs.StateFailed {
+			t.Fatalf("job = %+v", job)
+		}
+		alice.Get(path).Expect(http.StatusOK).JSON(&job)
+	}
+
+	link := strings.TrimPrefix(job.ResultURL, "http://example.com")
+	if !strings.HasPrefix(link, handlers.DownloadPath+"exports/") {
+		t.Fatalf("result_url = %q", job.ResultURL)
+	}
+	if loc := alice.Get(path + "/result").Expect(http.StatusSeeOther).Header.Get("Location"); loc != job.ResultURL {
+		t.Errorf("result redirects to %q", loc)
+	}
+	if zip := s.As("").Get(link).Expect(http.StatusOK).Body; !bytes.HasPrefix(zip, []byte("PK")) {
+		t.Errorf("download is not a zip: %q", zip)
+	}
+	s.As("").Get(link + "0").Expect(http.StatusNotFound)
+
+	var inbox []notify.Notification
+	alice.Get("/api/v1/notifications").Expect(http.StatusOK).JSON(&inbox)
+	if len(inbox) != 1 || inbox[0].Type != notify.JobDone || inbox[0].JobID != job.ID {
+		t.Errorf("notifications = %+v", inbox)
+	}
+
+	s.Clock.Advance(handlers.DefaultExportTTL)
+	s.As("").Get(link).Expect(http.StatusGone)
+	alice.Post("/api/v1/export?format=pdf", nil).Expect(http.StatusBadRequest)
+}
+
+// failingNotes is a note repository whose listing fails.
+type failingNotes struct{ core.NoteRepository }
+
+func (failingNotes) List() ([]core.Note, error) { return nil, errors.New("storage unavailable") }
+
+func TestNoteRepositoryErrors(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	n := createNote(t, alice, `{"title": "a", "content": ""}`)
+
+	s.Handler.Repo = failingNotes{s.Handler.Repo}
+	alice.Get("/api/v1/notes").Expect(http.StatusInternalServerError)
+	alice.Get("/api/v1/notes/" + strconv.FormatInt(n.ID, 10)).Expect(http.StatusOK)
+}
+
+// crudNotes hides every capability of a note repository beyond
+// core.NoteRepository.
+type crudNotes struct{ core.NoteRepository }
+
+func TestCRUDNoteRepository(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	s.Handler.Repo = crudNotes{s.Handler.Repo}
+
+	n := createNote(t, alice, `{"title": "a", "content": ""}`)
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+	alice.Get(path).Expect(http.StatusOK)
+	alice.Patch(path, `{"title": "b"}`).Expect(http.StatusOK)
+	var notes []core.Note
+	alice.Get("/api/v1/notes").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].Title != "b" {
+		t.Errorf("notes = %+v", notes)
+	}
+	alice.Get("/api/v1/notes/" + n.PublicID).Expect(http.StatusNotFound)
+	alice.Get(path + "/transitions").Expect(http.StatusOK)
+
+	for _, res := range []*testutil.Response{
+		alice.Patch(path+"?dry_run=true", `{"title": "c"}`),
+		alice.Post("/api/v1/notes?dry_run=true", `{"title": "c", "content": ""}`),
+		alice.Post(path+"/move", `{"notebook_id": 0}`),
+		alice.Post(path+"/public-link", nil),
+	} {
+		var e handlers.ErrorResponse
+		if res.Expect(http.StatusNotFound).JSON(&e); e.Code != "feature_disabled" {
+			t.Errorf("code = %q, want feature_disabled", e.Code)
+		}
+	}
+
+	// Deletes cannot be undone without Restore, so DeleteNote leaves out
+	// the optional Undo-Token header.
+	res := alice.Delete(path).Expect(http.StatusNoContent)
+	if token := res.Header.Get("Undo-Token"); token != "" {
+		t.Errorf("delete offered undo %q without Restore", token)
+	}
+	alice.Get(path).Expect(http.StatusNotFound)
+}
+
+func TestSiteExport(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name": "Работа"}`).Expect(http.StatusCreated).JSON(&nb)
+	n := createNote(t, alice, `{"title": "План", "content": "**важно**", "tags": ["work"], "notebook_id": `+strconv.FormatInt(nb.ID, 10)+`}`)
+	createNote(t, s.As(testutil.Bob), `{"title": "Чужая", "content": ""}`)
+
+	var job handlers.JobResponse
+	alice.Post("/api/v1/export?format=site", nil).Expect(http.StatusAccepted).JSON(&job)
+	for deadline := time.Now().Add(5 * time.Second); job.State != jobs.StateDone; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) || job.State == jobs.StateFailed {
+			t.Fatalf("job = %+v", job)
+		}
+		alice.Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)).Expect(http.StatusOK).JSON(&job)
+	}
+	body := s.As("").Get(strings.TrimPrefix(job.ResultURL, "http://example.com")).Expect(http.StatusOK).Body
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	page := "notes/" + strconv.FormatInt(n.ID, 10) + "-plan.html"
+	for _, name := range []string{"index.html", "search.html", "search-index.js", "style.css", "tags/work.html", page} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s in %v", name, reflect.ValueOf(files).MapKeys())
+		}
+	}
+	if !strings.Contains(files[page], "<strong>важно</strong>") || !strings.Contains(files[page], `href="../style.css"`) {
+		t.Errorf("%s = %s", page, files[page])
+	}
+	if !strings.Contains(files["index.html"], `href="`+page+`"`) || strings.Contains(files["search-index.js"], "Чужая") {
+		t.Errorf("index.html = %s\nsearch-index.js = %s", files["index.html"], files["search-index.js"])
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	s := testutil.New(t)
+	createNote(t, s.As(testutil.Alice), `{"title":"a","content":""}`)
+
+	s.As(testutil.Alice).Get("/api/v1/admin/search/reindex").Expect(http.StatusForbidden)
+	var job search.Job
+	s.As(testutil.Admin).Post("/api/v1/admin/search/reindex", nil).Expect(http.StatusAccepted).JSON(&job)
+	if !job.StartedAt.Equal(testutil.Epoch) {
+		t.Errorf("reindex started at %v, want the test clock's %v", job.StartedAt, testutil.Epoch)
+	}
+	s.As(testutil.Admin).Get("/api/v1/admin/search/reindex").Expect(http.StatusOK)
+
+	s.As(testutil.Alice).Get("/api/v1/admin/stats").Expect(http.StatusForbidden)
+	s.As(testutil.Admin).Get("/api/v1/admin/stats?days=91").Expect(http.StatusBadRequest)
+	s.Clock.Advance(24 * time.Hour)
+	s.As(testutil.Admin).Get("/api/v1/admin/stats?days=2").Expect(http.StatusOK).Golden("admin_stats")
+
+	s.As(testutil.Alice).Get("/api/v1/sync/status").Expect(http.StatusNotFound)
+	s.As(testutil.Alice).Post("/api/v1/sync/run", nil).Expect(http.StatusNotFound)
+}
+
+func TestStreams(t *testing.T) {
+	s := testutil.New(t)
+	createNote(t, s.As(testutil.Alice), `{"title":"a","content":""}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cdc := s.As(testutil.Admin).DoContext(ctx, http.MethodGet, "/api/v1/admin/cdc", nil).Expect(http.StatusOK)
+	if !strings.Contains(string(cdc.Body), `"op":"snapshot"`) {
+		t.Errorf("cdc = %s", cdc.Body)
+	}
+	s.As(testutil.Admin).Get("/api/v1/admin/cdc?since=x").Expect(http.StatusBadRequest)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.As(testutil.Alice).DoContext(ctx, http.MethodGet, "/api/v1/events", nil).Expect(http.StatusOK)
+
+	// HEAD answers with the headers of a stream without running it.
+	for c, path := range map[*testutil.Client]string{s.As(testutil.Alice): "/api/v1/events", s.As(testutil.Admin): "/api/v1/admin/cdc"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp := c.DoContext(ctx, http.MethodHead, path, nil).Expect(http.StatusOK)
+		if ctx.Err() != nil || len(resp.Body) != 0 || resp.Header.Get("Content-Length") != "" {
+			t.Errorf("HEAD %s = %v %q, ctx %v", path, resp.Header, resp.Body, ctx.Err())
+		}
+		cancel()
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	s := testutil.New(t)
+	srv := httptest.NewServer(s.Router)
+	defer srv.Close()
+	// Presence follows the hub too.
+	idle := s.Handler.Events.Subscribers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testutil.Alice)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+	if line, err := stream.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	if n := s.Handler.Events.Subscribers(); n != idle+1 {
+		t.Fatalf("Subscribers = %d while streaming, want %d", n, idle+1)
+	}
+
+	// Notes alice cannot read are left out.
+	createNote(t, s.As(testutil.Carol), `{"title": "carol's", "content": ""}`)
+	mine := createNote(t, s.As(testutil.Alice), `{"title": "mine", "content": ""}`)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream: %v after %q", err, lines)
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+	var e events.Event
+	if lines[0] != "event: note.created" || json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &e) != nil || e.NoteID != mine.ID {
+		t.Errorf("stream = %q, want alice's note %d", lines, mine.ID)
+	}
+
+	// A client that disconnects ends its subscription.
+	cancel()
+	for deadline := time.Now().Add(5 * time.Second); s.Handler.Events.Subscribers() != idle; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Subscribers = %d after disconnecting, want %d", s.Handler.Events.Subscribers(), idle)
+		}
+	}
+}
+
+func TestRecordedURLsHideCredentials(t *testing.T) {
+	s := testutil.New(t)
+	file := filepath.Join(t.TempDir(), "requests.jsonl")
+	rec, err := record.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := httpx.NewRouter(s.Handler, httpx.Options{Recorder: rec})
+	for _, req := range []struct{ method, target string }{
+		{http.MethodGet, "/s/secret-token"},
+		{http.MethodPost, "/api/v1/inbound/secret-token"},
+		{http.MethodGet, handlers.DownloadPath + "exports/a.zip?expires=1736154000&sig=secret-sig"},
+		{http.MethodGet, "/api/v1/notes?tag=work"},
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.target, nil))
+	}
+	rec.Close()
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var urls []string
+	if err := record.Read(f, func(e record.Entry) error {
+		urls = append(urls, e.URL)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/s/REDACTED",
+		"/api/v1/inbound/REDACTED",
+		handlers.DownloadPath + "exports/a.zip?expires=REDACTED&sig=REDACTED",
+		"/api/v1/notes?tag=work",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("recorded URLs = %q, want %q", urls, want)
+	}
+}
+
+func TestRecordedBodiesHideSecrets(t *testing.T) {
+	s := testutil.New(t)
+	file := filepath.Join(t.TempDir(), "requests.jsonl")
+	rec, err := record.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := httpx.NewRouter(s.Handler, httpx.Options{Recorder: rec})
+	bodies := []string{
+		`{"name":"r","when":{"event":"note_created"},"then":[{"type":"call_webhook","url":"https://hooks.example.com/T0/s3cret"},{"type":"apply_tag","tag":"url"}]}`,
+		`{"title":"token","content":"","meta":{"password":"hunter2","api_key":"k3y","count":12345678901234567890}}`,
+		`{"title":"a","content":"https://example.com"}`,
+		`plain text`,
+	}
+	for _, body := range bodies {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testutil.Alice)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	rec.Close()
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var recorded []string
+	if err := record.Read(f, func(e record.Entry) error {
+		recorded = append(recorded, string(e.Body))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"name":"r","then":[{"type":"call_webhook","url":"REDACTED"},{"tag":"url","type":"apply_tag"}],"when":{"event":"note_created"}}`,
+		`{"content":"","meta":{"api_key":"REDACTED","count":12345678901234567890,"password":"REDACTED"},"title":"token"}`,
+		// Bodies without secrets, or that are not JSON, are kept as sent.
+		bodies[2],
+		bodies[3],
+	}
+	if !reflect.DeepEqual(recorded, want) {
+		t.Errorf("recorded bodies = %q, want %q", recorded, want)
+	}
+}
+
+func TestWebDAV(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	alice.Do("PROPFIND", "/dav/", nil).Expect(http.StatusMultiStatus)
+	alice.Do(http.MethodPut, "/dav/hello.md", "# Hello\n\ntext").Expect(http.StatusCreated)
+	alice.Get("/dav/hello.md").Expect(http.StatusOK)
+}
+
+func TestResumableUpload(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	n := createNote(t, alice, `{"title":"a","content":"b"}`)
+	notePath := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+
+	data := []byte(strings.Repeat("0123456789", 100))
+	sum := sha256.Sum256(data)
+	start := map[string]any{"name": "digits.txt", "content_type": "text/plain", "size": len(data), "sha256": hex.EncodeToString(sum[:])}
+
+	var u attach.Upload
+	resp := alice.Post(notePath+"/uploads", start).Expect(http.StatusCreated).JSON(&u)
+	path := resp.Header.Get("Location")
+	s.As(testutil.Bob).Post(notePath+"/uploads", start).Expect(http.StatusNotFound)
+	s.As(testutil.Bob).Get(path).Expect(http.StatusNotFound)
+
+	alice.WithHeader("Upload-Offset", "0").Patch(path, data[:600]).Expect(http.StatusNoContent)
+	// A retried chunk the server already has is refused with the offset
+	// to resume from.
+	if off := alice.WithHeader("Upload-Offset", "0").Patch(path, data[:600]).Expect(http.StatusConflict).Header.Get("Upload-Offset"); off != "600" {
+		t.Errorf("Upload-Offset = %q", off)
+	}
+	alice.Get(path).Expect(http.StatusOK).JSON(&u)
+	if u.Offset != 600 {
+		t.Errorf("offset = %d", u.Offset)
+	}
+
+	var a core.Attachment
+	alice.WithHeader("Upload-Offset", "600").Patch(path, data[600:]).Expect(http.StatusCreated).JSON(&a)
+	if a.Size != int64(len(data)) || a.SHA256 != u.SHA256 {
+		t.Errorf("attachment = %+v", a)
+	}
+	alice.Get(path).Expect(http.StatusNotFound)
+
+	var list []core.Attachment
+	alice.Get(notePath + "/attachments").Expect(http.StatusOK).JSON(&list)
+	if len(list) != 1 {
+		t.Fatalf("attachments = %+v", list)
+	}
+	file := notePath + "/attachments/" + strconv.FormatInt(a.ID, 10)
+	if got := alice.Get(file).Expect(http.StatusOK).Body; !bytes.Equal(got, data) {
+		t.Errorf("downloaded %d bytes", len(got))
+	}
+
+	// A corrupted upload is discarded instead of becoming an attachment.
+	resp = alice.Post(notePath+"/uploads", start).Expect(http.StatusCreated)
+	path = resp.Header.Get("Location")
+	corrupt := append([]byte(nil), data...)
+	corrupt[10] = 'x'
+	alice.WithHeader("Upload-Offset", "0").Patch(path, corrupt).Expect(http.StatusUnprocessableEntity)
+	alice.Get(path).Expect(http.StatusNotFound)
+
+	alice.Delete(file).Expect(http.StatusNoContent)
+	alice.Get(file).Expect(http.StatusNotFound)
+}
+
+// upload sends data as an attachment of the note at notePath in a single
+// chunk and returns the response to it.
+func upload(t *testing.T, c *testutil.Client, notePath, name string, data []byte) *testutil.Response {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	start := map[string]any{"name": name, "size": len(data), "sha256": hex.EncodeToString(sum[:])}
+	path := c.Post(notePath+"/uploads", start).Expect(http.StatusCreated).Header.Get("Location")
+	return c.WithHeader("Upload-Offset", "0").Patch(path, data)
+}
+
+func TestAttachmentScanning(t *testing.T) {
+	s := testutil.New(t)
+	alice, admin := s.As(testutil.Alice), s.As(testutil.Admin)
+	notePath := "/api/v1/notes/" + strconv.FormatInt(createNote(t, alice, `{"title":"a","content":"b"}`).ID, 10)
+
+	upload(t, alice, notePath, "setup.exe", []byte("MZ\x90\x00")).Expect(http.StatusUnprocessableEntity)
+	upload(t, alice, notePath, "notes.txt", []byte("\x7fELF\x02\x01")).Expect(http.StatusUnprocessableEntity)
+
+	var a core.Attachment
+	upload(t, alice, notePath, "photo.jpg", []byte("<html><script>alert(1)</script></html>")).Expect(http.StatusCreated).JSON(&a)
+	if a.Status != attach.Quarantine {
+		t.Errorf("attachment = %+v", a)
+	}
+	file := notePath + "/attachments/" + strconv.FormatInt(a.ID, 10)
+	alice.Get(file).Expect(http.StatusForbidden)
+
+	var entries []audit.Entry
+	alice.Get("/api/v1/admin/audit").Expect(http.StatusForbidden)
+	admin.Get("/api/v1/admin/audit").Expect(http.StatusOK).JSON(&entries)
+	if len(entries) != 3 || entries[0].Action != "attachment.rejected" || entries[0].Actor != "alice" || entries[2].Action != "attachment.quarantined" {
+		t.Errorf("audit = %+v", entries)
+	}
+
+	admin.Post("/api/v1/admin/attachments/"+strconv.FormatInt(a.ID, 10)+"/release", nil).Expect(http.StatusOK)
+	alice.Get(file).Expect(http.StatusOK)
+	admin.Get("/api/v1/admin/audit?after=3").Expect(http.StatusOK).JSON(&entries)
+	if len(entries) != 1 || entries[0].Action != "attachment.released" {
+		t.Errorf("audit after release = %+v", entries)
+	}
+}
+
+type recognizer func(name string, data []byte) (string, error)
+
+func (f recognizer) Recognize(_ context.Context, name string, image []byte) (string, error) {
+	return f(name, image)
+}
+
+func (f recognizer) Transcribe(_ context.Context, name string, audio []byte) (string, error) {
+	return f(name, audio)
+}
+
+func TestAttachmentRecognition(t *testing.T) {
+	s := testutil.New(t)
+	s.Handler.OCR = recognizer(func(name string, image []byte) (string, error) {
+		return "Q3 roadmap on the whiteboard", nil
+	})
+	s.Handler.Transcriber = recognizer(func(name string, audio []byte) (string, error) {
+		return "remember the standup moved to ten", nil
+	})
+	alice := s.As(testutil.Alice)
+	n := createNote(t, alice, `{"title":"Meeting","content":"photos"}`)
+	notePath := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+
+	var a core.Attachment
+	upload(t, alice, notePath, "board.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")).Expect(http.StatusCreated).JSON(&a)
+	upload(t, alice, notePath, "notes.txt", []byte("plain text is not recognized")).Expect(http.StatusCreated)
+	upload(t, alice, notePath, "memo.ogg", []byte("OggS\x00\x02\x00\x00")).Expect(http.StatusCreated)
+
+	var notes []core.Note
+	for _, q := range []string{"whiteboard", "standup"} {
+		notes = nil
+		for deadline := time.Now().Add(5 * time.Second); len(notes) == 0; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("recognized text %q never became searchable", q)
+			}
+			alice.Get("/api/v1/notes?q=" + q).Expect(http.StatusOK).JSON(&notes)
+		}
+		if notes[0].ID != n.ID {
+			t.Errorf("search %q = %+v", q, notes)
+		}
+	}
+	var list []core.Attachment
+	alice.Get(notePath + "/attachments").Expect(http.StatusOK).JSON(&list)
+	if len(list) != 3 || list[0].Text != "Q3 roadmap on the whiteboard" || list[1].Text != "" || list[2].Text != "remember the standup moved to ten" {
+		t.Errorf("attachments = %+v", list)
+	}
+
+	alice.Delete(notePath + "/attachments/" + strconv.FormatInt(a.ID, 10)).Expect(http.StatusNoContent)
+	alice.Get("/api/v1/notes?q=whiteboard").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 0 {
+		t.Errorf("search after delete = %+v", notes)
+	}
+}
+
+func TestLinkPreviews(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	n := createNote(t, alice, `{"title":"Links","content":"Read https://example.com/guide, then https://down.invalid/x"}`)
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "/link-previews"
+
+	var previews []preview.Preview
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		alice.Get(path).Expect(http.StatusOK).JSON(&previews)
+		if len(previews) == 2 && previews[0].State != preview.StatePending && previews[1].State != preview.StatePending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("previews = %+v", previews)
+		}
+	}
+	if p := previews[0]; p.URL != "https://example.com/guide" || p.State != preview.StateReady || p.Title != "/guide" ||
+		p.SiteName != "example.com" || p.Favicon != "https://example.com/favicon.ico" {
+		t.Errorf("preview = %+v", p)
+	}
+	if previews[1].State != preview.StateFailed {
+		t.Errorf("unreachable page = %+v", previews[1])
+	}
+	s.As(testutil.Bob).Get(path).Expect(http.StatusNotFound)
+}
+
+func TestClip(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	var n core.Note
+	alice.Post("/api/v1/clip", `{"url":"https://example.com/story","tags":["Reading"]}`).
+		Expect(http.StatusCreated).JSON(&n)
+	if n.Title != "/story" || n.SourceURL != "https://example.com/story" ||
+		n.Content != "This page was served by example.com at /story, for tests." || len(n.Tags) != 1 || n.Tags[0] != "reading" {
+		t.Errorf("clipped note = %+v", n)
+	}
+
+	page := `<html><body><div class="sidebar"><p>Subscribe to our newsletter, it is great, really.</p></div>` +
+		`<div class="post"><h1>Notes</h1><p>Clipped from the browser, with a <a href="/more">relative link</a>, as sent.</p></div></body></html>`
+	body, _ := json.Marshal(map[string]string{"url": "https://example.com/private", "html": page})
+	alice.Post("/api/v1/clip", string(body)).Expect(http.StatusCreated).JSON(&n)
+	if n.Title != "Notes" || n.Content != "# Notes\n\nClipped from the browser, with a [relative link](https://example.com/more), as sent." {
+		t.Errorf("clipped html = %q %q", n.Title, n.Content)
+	}
+
+	alice.Post("/api/v1/clip", `{"url":"file:///etc/passwd"}`).Expect(http.StatusBadRequest)
+	alice.Post("/api/v1/clip", `{"url":"https://down.invalid/"}`).Expect(http.StatusBadGateway)
+}
+
+func TestEmailNote(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	n := createNote(t, alice, `{"title":"Plan","content":"Ship it"}`)
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "/email"
+
+	var res handlers.EmailNoteResponse
+	alice.Post(path, `{"to":["Ann <ann@example.com>","ANN@example.com","bo@example.com"],"message":"FYI"}`).
+		Expect(http.StatusOK).JSON(&res)
+	if len(res.Sent) != 2 || res.Sent[0] != "ann@example.com" || res.Sent[1] != "bo@example.com" {
+		t.Errorf("sent = %v", res.Sent)
+	}
+	msgs := s.Outbox.Messages()
+	if len(msgs) != 1 || msgs[0].Subject != "Plan" || !strings.HasPrefix(msgs[0].Body, "FYI\n") || !strings.Contains(msgs[0].Body, "Ship it") {
+		t.Errorf("messages = %+v", msgs)
+	}
+
+	var entries []audit.Entry
+	s.As(testutil.Admin).Get("/api/v1/admin/audit").Expect(http.StatusOK).JSON(&entries)
+	if len(entries) != 1 || entries[0].Action != "note.emailed" || entries[0].Actor != "alice" || entries[0].Detail != "ann@example.com, bo@example.com" {
+		t.Errorf("audit = %+v", entries)
+	}
+
+	alice.Post(path, `{"to":["not an address"]}`).Expect(http.StatusBadRequest)
+	alice.Post(path, `{"to":[]}`).Expect(http.StatusBadRequest)
+	s.As(testutil.Bob).Post(path, `{"to":["bo@example.com"]}`).Expect(http.StatusNotFound)
+
+	for i := 0; i < 4; i++ {
+		alice.Post(path, `{"to":["bo@example.com"]}`).Expect(http.StatusOK)
+	}
+	resp := alice.Post(path, `{"to":["bo@example.com"]}`).Expect(http.StatusTooManyRequests)
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("missing Retry-After")
+	}
+}
+
+func TestDisplayDates(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	n := createNote(t, alice, `{"title":"Dates"}`)
+	if n.Display != nil {
+		t.Errorf("display dates without asking: %+v", n.Display)
+	}
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+
+	alice.Patch("/api/v1/me/preferences", `{"locale":"ru-RU","timezone":"Europe/Moscow"}`).Expect(http.StatusOK)
+	var got core.Note
+	alice.Get(path + "?date_format=short").Expect(http.StatusOK).JSON(&got)
+	if got.Display == nil || got.Display.Created != testutil.Epoch.In(time.FixedZone("MSK", 3*60*60)).Format("02.01.2006") {
+		t.Errorf("display = %+v", got.Display)
+	}
+
+	var notes []core.Note
+	alice.WithHeader("Accept-Language", "en-US,en;q=0.9").Get("/api/v1/notes").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].Display == nil || !strings.HasPrefix(notes[0].Display.Created, testutil.Epoch.Format("Jan 2, 2006")) {
+		t.Errorf("notes = %+v", notes)
+	}
+	notes = nil
+	alice.Get("/api/v1/notes").Expect(http.StatusOK).JSON(&notes)
+	if notes[0].Display != nil {
+		t.Errorf("cached list kept display dates: %+v", notes[0].Display)
+	}
+	alice.Get(path + "?date_format=full").Expect(http.StatusBadRequest)
+}
+
+func TestUndo(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	alice.Post("/api/v1/notebooks", `{"name":"Архив"}`).Expect(http.StatusCreated)
+	n := createNote(t, alice, `{"title":"Keep me","tags":["draft"]}`)
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+
+	token := alice.Delete(path).Expect(http.StatusNoContent).Header.Get("Undo-Token")
+	if token == "" {
+		t.Fatal("delete gave no Undo-Token")
+	}
+	s.As(testutil.Bob).Post("/api/v1/undo/"+token, "").Expect(http.StatusNotFound)
+	var res handlers.UndoResponse
+	alice.Post("/api/v1/undo/"+token, "").Expect(http.StatusOK).JSON(&res)
+	if res.Operation != "delete" || len(res.Notes) != 1 || res.Notes[0].ID != n.ID || res.Notes[0].PublicID != n.PublicID {
+		t.Errorf("undo = %+v", res)
+	}
+	alice.Get(path).Expect(http.StatusOK)
+	alice.Post("/api/v1/undo/"+token, "").Expect(http.StatusNotFound)
+
+	token = alice.Post("/api/v1/notes/move", `{"ids":[`+strconv.FormatInt(n.ID, 10)+`],"notebook_id":1}`).
+		Expect(http.StatusOK).Header.Get("Undo-Token")
+	alice.Post("/api/v1/undo/"+token, "").Expect(http.StatusOK).JSON(&res)
+	if res.Operation != "move" || len(res.Notes) != 1 || res.Notes[0].NotebookID != 0 {
+		t.Errorf("undo move = %+v", res)
+	}
+
+	token = alice.Patch("/api/v1/tags/draft", `{"name":"final"}`).Expect(http.StatusOK).Header.Get("Undo-Token")
+	alice.Post("/api/v1/undo/"+token, "").Expect(http.StatusOK).JSON(&res)
+	if len(res.Notes) != 1 || len(res.Notes[0].Tags) != 1 || res.Notes[0].Tags[0] != "draft" {
+		t.Errorf("undo rename = %+v", res)
+	}
+
+	token = alice.Delete(path).Expect(http.StatusNoContent).Header.Get("Undo-Token")
+	s.Clock.Advance(31 * time.Second)
+	alice.Post("/api/v1/undo/"+token, "").Expect(http.StatusNotFound)
+	alice.Get(path).Expect(http.StatusNotFound)
+}
+
+func TestDryRun(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+	alice.Post("/api/v1/notebooks", `{"name":"Работа"}`).Expect(http.StatusCreated)
+	n := createNote(t, alice, `{"title":"Draft","content":"v1"}`)
+	id := strconv.FormatInt(n.ID, 10)
+
+	var preview core.Note
+	resp := alice.Post("/api/v1/notes?dry_run=true", `{"title":"Ghost","notebook_id":1}`).Expect(http.StatusOK)
+	resp.JSON(&preview)
+	if resp.Header.Get("Preference-Applied") != "dry-run" || preview.ID != 0 || preview.Title != "Ghost" || len(preview.Path) != 1 {
+		t.Errorf("create preview = %+v", preview)
+	}
+	alice.Post("/api/v1/notes?dry_run=true", `{"title":""}`).Expect(http.StatusBadRequest)
+
+	alice.WithHeader("Prefer", "handling=dry-run").Patch("/api/v1/notes/"+id, `{"title":"Final","content":"v2"}`).
+		Expect(http.StatusOK).JSON(&preview)
+	if preview.Title != "Final" || preview.Content != "v2" || preview.Slug != "final" {
+		t.Errorf("patch preview = %+v", preview)
+	}
+
+	var moved []core.Note
+	alice.Post("/api/v1/notes/move?dry_run=1", `{"ids":[`+id+`],"notebook_id":1}`).Expect(http.StatusOK).JSON(&moved)
+	if len(moved) != 1 || moved[0].NotebookID != 1 {
+		t.Errorf("move preview = %+v", moved)
+	}
+	alice.Post("/api/v1/notes/copy?dry_run=true", `{"ids":[`+id+`]}`).Expect(http.StatusOK)
+
+	zip := alice.Get("/api/v1/export/vault").Expect(http.StatusOK).Body
+	var imported handlers.VaultImportResponse
+	alice.Post("/api/v1/import/vault?dry_run=true", zip).Expect(http.StatusOK).JSON(&imported)
+	if imported.NotesCreated != 1 || imported.NotebooksCreated != 1 {
+		t.Errorf("import preview = %+v", imported)
+	}
+
+	var notes []core.Note
+	alice.Get("/api/v1/notes").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].Title != "Draft" || notes[0].NotebookID != 0 {
+		t.Errorf("dry runs changed notes: %+v", notes)
+	}
+}
+
+func TestDebugEcho(t *testing.T) {
+	s := testutil.New(t)
+	s.As(testutil.Alice).Get("/api/v1/debug/echo").Expect(http.StatusForbidden)
+
+	var echo handlers.EchoResponse
+	s.As(testutil.Admin).WithHeader("X-Client", "tui/1.2").Get("/api/v1/debug/echo?x=1").Expect(http.StatusOK).JSON(&echo)
+	if echo.Method != "GET" || echo.URL != "/api/v1/debug/echo?x=1" || echo.Route != "/api/v1/debug/echo" ||
+		echo.RequestID == "" || echo.Principal.UserID != "root" || !echo.Principal.Admin || echo.Query["x"][0] != "1" {
+		t.Errorf("echo = %+v", echo)
+	}
+	if got := echo.Headers["Authorization"]; len(got) != 1 || got[0] != "Bearer [redacted]" {
+		t.Errorf("authorization = %v", got)
+	}
+	if got := echo.Headers["X-Client"]; len(got) != 1 || got[0] != "tui/1.2" {
+		t.Errorf("headers = %v", echo.Headers)
+	}
+
+	s.Handler.DebugEchoOpen = true
+	s.As(testutil.Alice).Get("/api/v1/debug/echo").Expect(http.StatusOK)
+}
+
+func TestDeprecatedRoute(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	resp := c.Get("/api/v1/export/vault").Expect(http.StatusOK)
+	if got := resp.Header.Get("Deprecation"); got != "@1792108800" {
+		t.Errorf("Deprecation = %q", got)
+	}
+	if got := resp.Header.Get("Sunset"); got != "Fri, 16 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := resp.Header.Get("Link"); got != `</api/v1/export?format=vault>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+	if got := c.Get("/api/v1/notes").Expect(http.StatusOK).Header.Get("Deprecation"); got != "" {
+		t.Errorf("Deprecation on a current route = %q", got)
+	}
+
+	metrics := s.As("").Get("/metrics").Expect(http.StatusOK).Body
+	if want := `http_deprecated_requests_total{route="/api/v1/export/vault",method="GET"} 1`; !strings.Contains(string(metrics), want) {
+		t.Errorf("metrics lack %s", want)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	var codes []handlers.ErrorCode
+	c.Get("/api/v1/errors").Expect(http.StatusOK).JSON(&codes)
+	known := map[string]int{}
+	for _, code := range codes {
+		known[code.Code] = code.Status
+	}
+	if len(codes) == 0 || known["note_not_found"] != http.StatusNotFound || known["unauthorized"] != http.StatusUnauthorized {
+		t.Errorf("codes = %+v", codes)
+	}
+
+	for _, tc := range []struct {
+		resp *testutil.Response
+		code string
+	}{
+		{c.Get("/api/v1/notes/999"), "note_not_found"},
+		{c.Post("/api/v1/notes", "{"), "invalid_json"},
+		{s.As("").Get("/api/v1/notes"), "unauthorized"},
+		{c.Get("/api/v1/collections/999"), "collection_not_found"},
+	} {
+		var e handlers.ErrorResponse
+		tc.resp.JSON(&e)
+		if e.Code != tc.code {
+			t.Errorf("code = %q, want %q", e.Code, tc.code)
+		} else if _, ok := known[e.Code]; !ok {
+			t.Errorf("code %q is not listed", e.Code)
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	var e handlers.ErrorResponse
+	c.Patch("/api/v1/me/preferences", `{"page_size": 1000}`).Expect(http.StatusBadRequest).JSON(&e)
+	if e.Code != "validation_failed" || e.Fields["page_size"] == "" {
+		t.Errorf("preferences error = %+v", e)
+	}
+
+	var nb core.Notebook
+	c.Post("/api/v1/notebooks", `{"name": "Work"}`).Expect(http.StatusCreated).JSON(&nb)
+	e = handlers.ErrorResponse{}
+	id := strconv.FormatInt(nb.ID, 10)
+	c.Patch("/api/v1/notebooks/"+id, `{"parent_id": `+id+`}`).Expect(http.StatusBadRequest).JSON(&e)
+	if e.Code != "validation_failed" || e.Fields["parent_id"] == "" {
+		t.Errorf("notebook error = %+v", e)
+	}
+}
+
+func TestUniqueTitles(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	var nb core.Notebook
+	c.Post("/api/v1/notebooks", `{"name": "Meetings", "unique_titles": true}`).Expect(http.StatusCreated).JSON(&nb)
+	if !nb.UniqueTitles {
+		t.Fatalf("notebook = %+v", nb)
+	}
+	id := strconv.FormatInt(nb.ID, 10)
+	first := createNote(t, c, `{"title": "Standup", "content": "x", "notebook_id": `+id+`}`)
+
+	var e handlers.ErrorResponse
+	c.Post("/api/v1/notes", `{"title": "standup", "content": "y", "notebook_id": `+id+`}`).Expect(http.StatusConflict).JSON(&e)
+	if e.Code != "duplicate_title" || e.ConflictingID != first.ID {
+		t.Errorf("create error = %+v", e)
+	}
+	c.Post("/api/v1/notes?dry_run=true", `{"title": "standup", "content": "y", "notebook_id": `+id+`}`).Expect(http.StatusConflict)
+
+	second := createNote(t, c, `{"title": "Other", "content": "x", "notebook_id": `+id+`}`)
+	e = handlers.ErrorResponse{}
+	c.Patch("/api/v1/notes/"+strconv.FormatInt(second.ID, 10)+"?dry_run=true", `{"title": "Standup"}`).Expect(http.StatusConflict).JSON(&e)
+	if e.Code != "duplicate_title" || e.ConflictingID != first.ID {
+		t.Errorf("dry-run patch error = %+v", e)
+	}
+
+	// Undoing a delete does not bring back a title taken since.
+	token := c.Delete("/api/v1/notes/" + strconv.FormatInt(second.ID, 10)).Expect(http.StatusNoContent).Header.Get("Undo-Token")
+	createNote(t, c, `{"title": "Other", "content": "y", "notebook_id": `+id+`}`)
+	c.Post("/api/v1/undo/"+token, nil).Expect(http.StatusConflict)
+
+	other := createNote(t, c, `{"title": "Standup", "content": "z"}`)
+	e = handlers.ErrorResponse{}
+	c.Post("/api/v1/notes/"+strconv.FormatInt(other.ID, 10)+"/move", `{"notebook_id": `+id+`}`).Expect(http.StatusConflict).JSON(&e)
+	if e.ConflictingID != first.ID {
+		t.Errorf("move error = %+v", e)
+	}
+
+	// Neither does undoing a move out of the notebook.
+	token = c.Post("/api/v1/notes/"+strconv.FormatInt(first.ID, 10)+"/move", `{"notebook_id": 0}`).Expect(http.StatusOK).Header.Get("Undo-Token")
+	taken := createNote(t, c, `{"title": "Standup", "content": "new", "notebook_id": `+id+`}`)
+	e = handlers.ErrorResponse{}
+	c.Post("/api/v1/undo/"+token, nil).Expect(http.StatusConflict).JSON(&e)
+	if e.Code != "duplicate_title" || e.ConflictingID != taken.ID {
+		t.Errorf("undo move error = %+v", e)
+	}
+
+	c.Patch("/api/v1/notebooks/"+id, `{"unique_titles": false}`).Expect(http.StatusOK)
+	createNote(t, c, `{"title": "Standup", "content": "y", "notebook_id": `+id+`}`)
+}
+
+func TestNoteTemplates(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	var e handlers.ErrorResponse
+	c.Post("/api/v1/note-templates", `{"name": "Standup", "title": "{{.Date", "schedule": "0 9 * *"}`).Expect(http.StatusBadRequest).JSON(&e)
+	if e.Fields["title"] == "" || e.Fields["schedule"] == "" {
+		t.Errorf("validation error = %+v", e)
+	}
+
+	// The fake clock starts on Monday 2025-01-06 at 09:00 UTC.
+	var tmpl core.NoteTemplate
+	c.Post("/api/v1/note-templates", `{"name": "Standup", "title": "Weekly standup {{.Date}}", "tags": ["standup"], "schedule": "0 9 * * MON"}`).
+		Expect(http.StatusCreated).JSON(&tmpl)
+	if tmpl.NextRunAt == nil || !tmpl.NextRunAt.Equal(testutil.Epoch.AddDate(0, 0, 7)) {
+		t.Fatalf("next run = %v", tmpl.NextRunAt)
+	}
+	path := "/api/v1/note-templates/" + strconv.FormatInt(tmpl.ID, 10)
+	s.As(testutil.Bob).Get(path).Expect(http.StatusNotFound)
+
+	var manual core.Note
+	c.Post(path+"/notes", "").Expect(http.StatusCreated).JSON(&manual)
+	if manual.Title != "Weekly standup 2025-01-06" || len(manual.Tags) != 1 {
+		t.Errorf("manual note = %+v", manual)
+	}
+
+	s.Clock.Advance(7*24*time.Hour + time.Minute)
+	s.Handler.RunNoteTemplates(s.Clock.Now())
+	for deadline := time.Now().Add(5 * time.Second); tmpl.LastNoteID == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the scheduled note was not created")
+		}
+		c.Get(path).Expect(http.StatusOK).JSON(&tmpl)
+	}
+	var scheduled core.Note
+	c.Get("/api/v1/notes/" + strconv.FormatInt(tmpl.LastNoteID, 10)).Expect(http.StatusOK).JSON(&scheduled)
+	if scheduled.Title != "Weekly standup 2025-01-13" {
+		t.Errorf("scheduled note = %q", scheduled.Title)
+	}
+	if !tmpl.NextRunAt.Equal(testutil.Epoch.AddDate(0, 0, 14)) {
+		t.Errorf("next run after the first = %v", tmpl.NextRunAt)
+	}
+
+	c.Delete(path).Expect(http.StatusNoContent)
+	c.Get(path).Expect(http.StatusNotFound)
+}
+
+func TestNotebookSettings(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	var nb core.Notebook
+	c.Post("/api/v1/notebooks", `{"name": "Inbox"}`).Expect(http.StatusCreated).JSON(&nb)
+	path := "/api/v1/notebooks/" + strconv.FormatInt(nb.ID, 10) + "/settings"
+
+	var e handlers.ErrorResponse
+	c.Put(path, `{"color": "blue", "template_id": 99, "retention_days": -1}`).Expect(http.StatusBadRequest).JSON(&e)
+	if len(e.Fields) != 3 {
+		t.Errorf("validation error = %+v", e)
+	}
+
+	var tmpl core.NoteTemplate
+	c.Post("/api/v1/note-templates", `{"name": "Daily", "title": "Inbox {{.Date}}", "content": "- ", "tags": ["daily"]}`).
+		Expect(http.StatusCreated).JSON(&tmpl)
+	s.As(testutil.Bob).Put(path, `{}`).Expect(http.StatusNotFound)
+	c.Put(path, `{"tags": ["Inbox"], "color": "#3366FF", "template_id": `+strconv.FormatInt(tmpl.ID, 10)+`, "retention_days": 7}`).
+		Expect(http.StatusOK)
+	var settings core.NotebookSettings
+	c.Get(path).Expect(http.StatusOK).JSON(&settings)
+	if settings.Color != "#3366ff" || settings.RetentionDays != 7 || len(settings.Tags) != 1 {
+		t.Errorf("settings = %+v", settings)
+	}
+
+	id := strconv.FormatInt(nb.ID, 10)
+	n := createNote(t, c, `{"title": "", "content": "", "tags": ["mine"], "notebook_id": `+id+`}`)
+	if n.Title != "Inbox 2025-01-06" || n.Content != "- " || n.Color != "#3366ff" || len(n.Tags) != 3 {
+		t.Errorf("note = %+v", n)
+	}
+	if n.ExpiresAt == nil || !n.ExpiresAt.Equal(testutil.Epoch.AddDate(0, 0, 7)) {
+		t.Errorf("expires at = %v", n.ExpiresAt)
+	}
+	own := createNote(t, c, `{"title": "Kept", "content": "x", "color": "#000000", "notebook_id": `+id+`}`)
+	if own.Title != "Kept" || own.Content != "x" || own.Color != "#000000" {
+		t.Errorf("note with its own fields = %+v", own)
+	}
+	outside := createNote(t, c, `{"title": "Elsewhere", "content": "x"}`)
+
+	s.Clock.Advance(7 * 24 * time.Hour)
+	s.Handler.ExpireNotes(s.Clock.Now())
+	c.Get("/api/v1/notes/" + strconv.FormatInt(n.ID, 10)).Expect(http.StatusNotFound)
+	c.Get("/api/v1/notes/" + strconv.FormatInt(outside.ID, 10)).Expect(http.StatusOK)
+
+	c.Put(path, `{}`).Expect(http.StatusOK)
+	if plain := createNote(t, c, `{"title": "Plain", "content": "x", "notebook_id": `+id+`}`); plain.Color != "" || plain.ExpiresAt != nil {
+		t.Errorf("note after clearing settings = %+v", plain)
+	}
+}
+
+func TestBulkTags(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	var nb core.Notebook
+	c.Post("/api/v1/notebooks", `{"name": "Reports"}`).Expect(http.StatusCreated).JSON(&nb)
+	id := strconv.FormatInt(nb.ID, 10)
+	q1 := createNote(t, c, `{"title": "Quarterly report", "content": "q1", "notebook_id": `+id+`}`)
+	s.Clock.Advance(24 * time.Hour)
+	q2 := createNote(t, c, `{"title": "Quarterly report", "content": "q2", "tags": ["archive"], "notebook_id": `+id+`}`)
+	other := createNote(t, c, `{"title": "Quarterly report", "content": "elsewhere"}`)
+
+	run := func(path, body string) handlers.BulkTagReport {
+		t.Helper()
+		var job handlers.JobResponse
+		c.Post(path, body).Expect(http.StatusAccepted).JSON(&job)
+		for deadline := time.Now().Add(5 * time.Second); job.State != jobs.StateDone; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) || job.State == jobs.StateFailed {
+				t.Fatalf("job = %+v", job)
+			}
+			c.Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)).Expect(http.StatusOK).JSON(&job)
+		}
+		var report handlers.BulkTagReport
+		c.Get(job.ResultURL).Expect(http.StatusOK).JSON(&report)
+		return report
+	}
+	tags := func(n core.Note) []string {
+		var got core.Note
+		c.Get("/api/v1/notes/" + strconv.FormatInt(n.ID, 10)).Expect(http.StatusOK).JSON(&got)
+		return got.Tags
+	}
+
+	if r := run("/api/v1/tags/Archive/apply", `{"q": "quarterly", "notebook_id": `+id+`}`); r.Tag != "archive" || r.NotesUpdated != 1 {
+		t.Errorf("apply = %+v", r)
+	}
+	if len(tags(q1)) != 1 || len(tags(other)) != 0 {
+		t.Errorf("tags after apply: %v, %v", tags(q1), tags(other))
+	}
+
+	from := testutil.Epoch.Add(time.Hour).Format(time.RFC3339)
+	removed := run("/api/v1/tags/archive/remove", `{"notebook_id": `+id+`, "from": "`+from+`"}`)
+	if removed.NotesUpdated != 1 {
+		t.Errorf("remove = %+v", removed)
+	}
+	if len(tags(q1)) != 1 || len(tags(q2)) != 0 {
+		t.Errorf("tags after remove: %v, %v", tags(q1), tags(q2))
+	}
+
+	// The report carries the undo token of the job; undoing reverts only
+	// the tag, keeping edits made since.
+	c.Patch("/api/v1/notes/"+strconv.FormatInt(q2.ID, 10), `{"tags": ["q2"]}`).Expect(http.StatusOK)
+	var undone handlers.UndoResponse
+	c.Post("/api/v1/undo/"+removed.UndoToken, nil).Expect(http.StatusOK).JSON(&undone)
+	if undone.Operation != "remove_tag" || strings.Join(tags(q2), ",") != "q2,archive" {
+		t.Errorf("undo remove = %+v, tags %v", undone, tags(q2))
+	}
+	applied := run("/api/v1/tags/urgent/apply", `{"notebook_id": `+id+`}`)
+	c.Post("/api/v1/undo/"+applied.UndoToken, nil).Expect(http.StatusOK).JSON(&undone)
+	if undone.Operation != "apply_tag" || len(undone.Notes) != 2 || len(tags(q1)) != 1 {
+		t.Errorf("undo apply = %+v, tags %v", undone, tags(q1))
+	}
+
+	var e handlers.ErrorResponse
+	s.As(testutil.Bob).Post("/api/v1/tags/x/apply", `{"notebook_id": `+id+`}`).Expect(http.StatusBadRequest).JSON(&e)
+	if e.Fields["notebook_id"] == "" {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestSavedViews(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var e handlers.ErrorResponse
+	alice.Post("/api/v1/views", `{"name": " ", "query": "page=2", "sort": "size", "fields": ["Nope"]}`).Expect(http.StatusBadRequest).JSON(&e)
+	if len(e.Fields) != 4 {
+		t.Errorf("validation error = %+v", e)
+	}
+
+	createNote(t, alice, `{"title": "b bug", "content": "x", "tags": ["bug"]}`)
+	s.Clock.Advance(time.Minute)
+	createNote(t, alice, `{"title": "a bug", "content": "x", "tags": ["bug"]}`)
+	createNote(t, alice, `{"title": "feature", "content": "x", "tags": ["feature"]}`)
+
+	var v core.SavedView
+	alice.Post("/api/v1/views", `{"name": "Open bugs", "query": "tag=bug", "sort": "-created", "fields": ["title", "Tags"], "shared_with": ["bob"]}`).
+		Expect(http.StatusCreated).JSON(&v)
+	path := "/api/v1/views/" + strconv.FormatInt(v.ID, 10)
+
+	var got []map[string]any
+	alice.Get(path + "/notes").Expect(http.StatusOK).JSON(&got)
+	if len(got) != 2 || got[0]["Title"] != "a bug" || len(got[0]) != 2 {
+		t.Errorf("alice's view notes = %v", got)
+	}
+
+	// Bob can run the shared view, but sees only the notes he can read.
+	bob.Get(path).Expect(http.StatusOK)
+	got = nil
+	bob.Get(path + "/notes").Expect(http.StatusOK).JSON(&got)
+	if len(got) != 0 {
+		t.Errorf("bob's view notes = %v", got)
+	}
+	bob.Put(path, `{"name": "Mine"}`).Expect(http.StatusForbidden)
+	bob.Delete(path).Expect(http.StatusForbidden)
+	s.As(testutil.Admin).Get(path).Expect(http.StatusNotFound)
+
+	var views []core.SavedView
+	bob.Get("/api/v1/views").Expect(http.StatusOK).JSON(&views)
+	if len(views) != 1 {
+		t.Errorf("bob's views = %+v", views)
+	}
+
+	alice.Put(path, `{"name": "Open bugs"}`).Expect(http.StatusOK)
+	bob.Get(path).Expect(http.StatusNotFound)
+	alice.Delete(path).Expect(http.StatusNoContent)
+	alice.Get(path).Expect(http.StatusNotFound)
+}
+
+func TestCountAndHeadNotes(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	n := createNote(t, c, `{"title": "Plan", "content": "x", "tags": ["work"]}`)
+	createNote(t, c, `{"title": "Ideas", "content": "y"}`)
+
+	var count handlers.NoteCount
+	c.Get("/api/v1/notes/count?tag=work").Expect(http.StatusOK).JSON(&count)
+	if count.Count != 1 {
+		t.Errorf("count = %d", count.Count)
+	}
+	c.Get("/api/v1/notes/count").Expect(http.StatusOK).JSON(&count)
+	if count.Count != 2 {
+		t.Errorf("count without filters = %d", count.Count)
+	}
+
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+	resp := c.Do(http.MethodHead, path, nil).Expect(http.StatusOK)
+	if len(resp.Body) != 0 || resp.Header.Get("Last-Modified") == "" {
+		t.Errorf("HEAD = %v %q", resp.Header, resp.Body)
+	}
+	s.As(testutil.Bob).Do(http.MethodHead, path, nil).Expect(http.StatusNotFound)
+	c.Do(http.MethodHead, "/api/v1/notes/999", nil).Expect(http.StatusNotFound)
+}
+
+func TestRandomNoteAndReview(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	first := createNote(t, c, `{"title": "Old idea", "content": "x", "tags": ["idea"]}`)
+	s.Clock.Advance(time.Hour)
+	second := createNote(t, c, `{"title": "Newer", "content": "y"}`)
+
+	var random core.Note
+	c.Get("/api/v1/notes/random?tag=idea").Expect(http.StatusOK).JSON(&random)
+	if random.ID != first.ID {
+		t.Errorf("random note = %d", random.ID)
+	}
+	c.Get("/api/v1/notes/random?tag=none").Expect(http.StatusNotFound)
+
+	next := func() int64 {
+		t.Helper()
+		var card handlers.ReviewCard
+		resp := c.Get("/api/v1/review/next")
+		if resp.Code == http.StatusNoContent {
+			return 0
+		}
+		resp.Expect(http.StatusOK).JSON(&card)
+		return card.Note.ID
+	}
+	if id := next(); id != first.ID {
+		t.Fatalf("first review = %d", id)
+	}
+	var state core.ReviewState
+	c.Post("/api/v1/review/"+strconv.FormatInt(first.ID, 10)+"/done", "").Expect(http.StatusOK).JSON(&state)
+	if state.IntervalDays != 1 || state.Reviews != 1 {
+		t.Errorf("state = %+v", state)
+	}
+	if id := next(); id != second.ID {
+		t.Fatalf("second review = %d", id)
+	}
+	c.Post("/api/v1/review/"+strconv.FormatInt(second.ID, 10)+"/done", `{"grade": "easy"}`).Expect(http.StatusOK).JSON(&state)
+	if state.IntervalDays != 4 {
+		t.Errorf("easy state = %+v", state)
+	}
+	if id := next(); id != 0 {
+		t.Errorf("review with nothing due = %d", id)
+	}
+
+	s.Clock.Advance(24 * time.Hour)
+	if id := next(); id != first.ID {
+		t.Errorf("review a day later = %d", id)
+	}
+	c.Post("/api/v1/review/"+strconv.FormatInt(first.ID, 10)+"/done", `{"grade": "meh"}`).Expect(http.StatusBadRequest)
+	s.As(testutil.Bob).Post("/api/v1/review/"+strconv.FormatInt(first.ID, 10)+"/done", "").Expect(http.StatusNotFound)
+}
+
+func TestEnvelope(t *testing.T) {
+	s := testutil.New(t)
+	c := s.As(testutil.Alice)
+
+	createNote(t, c, `{"title": "One", "content": "x"}`)
+	n := createNote(t, c, `{"title": "Two", "content": "y"}`)
+
+	var list struct {
+		Data []core.Note
+		Meta httpx.EnvelopeMeta
+	}
+	c.Get("/api/v1/notes?envelope=true&limit=1&page=2").Expect(http.StatusOK).JSON(&list)
+	if len(list.Data) != 1 || list.Meta.Status != http.StatusOK || list.Meta.TotalCount == nil || *list.Meta.TotalCount != 2 ||
+		list.Meta.Page != 2 || list.Meta.Limit != 1 {
+		t.Errorf("list envelope = %+v", list)
+	}
+
+	var env httpx.Envelope
+	c.Get("/api/v1/notes/999?envelope=true").Expect(http.StatusNotFound).JSON(&env)
+	var e handlers.ErrorResponse
+	if len(env.Errors) != 1 || string(env.Data) != "null" || json.Unmarshal(env.Errors[0], &e) != nil || e.Code != "note_not_found" {
+		t.Errorf("error envelope = %+v", env)
+	}
+
+	env = httpx.Envelope{}
+	c.Delete("/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "?envelope=true").Expect(http.StatusOK).JSON(&env)
+	if env.Meta.Status != http.StatusNoContent || env.Meta.Headers["Undo-Token"] == "" {
+		t.Errorf("delete envelope = %+v", env)
+	}
+
+	md := c.Get("/api/v1/notes/1/markdown?envelope=true").Expect(http.StatusOK)
+	if !strings.HasPrefix(md.Header.Get("Content-Type"), "text/markdown") {
+		t.Errorf("markdown was enveloped: %s", md.Body)
+	}
+}
+
+func TestOpenAPIAndGoClient(t *testing.T) {
+	s := testutil.New(t)
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+	}
+	s.As("").Get("/openapi.json").Expect(http.StatusOK).JSON(&doc)
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	srv := httptest.NewServer(s.Router)
+	defer srv.Close()
+	c := client.NewClient(srv.URL, client.BearerToken(testutil.Alice))
+	ctx := context.Background()
+
+	n, err := c.CreateNote(ctx, client.NoteCreate{Title: "Из клиента", Content: "текст", Tags: []string{"sdk"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.GetNote(ctx, strconv.FormatInt(n.ID, 10), nil)
+	if err != nil || got.Title != "Из клиента" {
+		t.Fatalf("GetNote = %+v, %v", got, err)
+	}
+	list, err := c.ListNotes(ctx, &client.ListNotesParams{Tag: "sdk"})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListNotes = %+v, %v", list, err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := c.CreateNote(ctx, client.NoteCreate{Title: "Ещё " + strconv.Itoa(i), Tags: []string{"sdk"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var titles []string
+	for note, err := range c.ListNotesAll(ctx, &client.ListNotesParams{Tag: "sdk", Limit: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, note.Title)
+	}
+	if len(titles) != 5 {
+		t.Errorf("ListNotesAll = %q", titles)
+	}
+
+	markdown, err := c.GetNoteMarkdown(ctx, n.PublicID)
+	if err != nil || !strings.Contains(markdown, "текст") {
+		t.Fatalf("GetNoteMarkdown = %q, %v", markdown, err)
+	}
+
+	_, err = c.GetNote(ctx, "999", nil)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound || apiErr.Code != "note_not_found" {
+		t.Errorf("GetNote of a missing note = %v", err)
+	}
+}
+
+func TestInboundHooks(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	alice.Post("/api/v1/inbound-hooks", `{"name":"Alerts","title":"{{.Body.title"}`).Expect(http.StatusBadRequest)
+	var hook core.InboundHook
+	alice.Post("/api/v1/inbound-hooks", `{
+		"name": "Alerts",
+		"title": "[{{.Body.state}}] {{.Body.title}}",
+		"content": "{{.Body.message}} ({{.Body.value}}){{.Body.missing}}",
+		"tags": ["alert", "{{.Body.state}}"]
+	}`).Expect(http.StatusCreated).JSON(&hook)
+	if hook.Token == "" {
+		t.Fatalf("hook = %+v", hook)
+	}
+
+	// The token is the credential: no API token is needed.
+	anonymous := s.As("").WithHeader("Content-Type", "application/json")
+	var res handlers.InboundResult
+	anonymous.Post("/api/v1/inbound/"+hook.Token, `{"state":"alerting","title":"CPU","message":"db-1 is hot","value":97.5}`).
+		Expect(http.StatusCreated).JSON(&res)
+	var n core.Note
+	alice.Get("/api/v1/notes/" + strconv.FormatInt(res.NoteID, 10)).Expect(http.StatusOK).JSON(&n)
+	if n.OwnerID != "alice" || n.Title != "[alerting] CPU" || n.Content != "db-1 is hot (97.5)" || strings.Join(n.Tags, ",") != "alert,alerting" {
+		t.Errorf("note from JSON = %+v", n)
+	}
+
+	// Forms map onto fields; text becomes the content as it is.
+	form := s.As("").WithHeader("Content-Type", "application/x-www-form-urlencoded")
+	form.Post("/api/v1/inbound/"+hook.Token, "state=ok&title=Form&message=sent").Expect(http.StatusCreated).JSON(&res)
+	if res.Title != "[ok] Form" {
+		t.Errorf("note from form = %+v", res)
+	}
+	var plain core.InboundHook
+	alice.Post("/api/v1/inbound-hooks", `{"name":"Log"}`).Expect(http.StatusCreated).JSON(&plain)
+	s.As("").WithHeader("Content-Type", "text/plain").Post("/api/v1/inbound/"+plain.Token, "disk full").
+		Expect(http.StatusCreated).JSON(&res)
+	alice.Get("/api/v1/notes/" + strconv.FormatInt(res.NoteID, 10)).Expect(http.StatusOK).JSON(&n)
+	if n.Title != "Log" || n.Content != "disk full" {
+		t.Errorf("note from text = %+v", n)
+	}
+
+	anonymous.Post("/api/v1/inbound/"+hook.Token, `{"state":`).Expect(http.StatusBadRequest)
+	var hooks []core.InboundHook
+	alice.Get("/api/v1/inbound-hooks").Expect(http.StatusOK).JSON(&hooks)
+	if len(hooks) != 2 || hooks[0].LastUsedAt == nil {
+		t.Errorf("hooks = %+v", hooks)
+	}
+	s.As(testutil.Bob).Delete("/api/v1/inbound-hooks/1").Expect(http.StatusNotFound)
+	alice.Delete("/api/v1/inbound-hooks/1").Expect(http.StatusNoContent)
+	anonymous.Post("/api/v1/inbound/"+hook.Token, `{}`).Expect(http.StatusNotFound)
+}
+
+func TestRules(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	events := make(chan handlers.RuleEvent, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e handlers.RuleEvent
+		json.NewDecoder(r.Body).Decode(&e)
+		events <- e
+	}))
+	defer hook.Close()
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Финансы"}`).Expect(http.StatusCreated).JSON(&nb)
+	alice.Post("/api/v1/rules", `{"name":"x","when":{"event":"note_deleted"},"then":[{"type":"apply_tag"}]}`).
+		Expect(http.StatusBadRequest)
+	s.As(testutil.Bob).Post("/api/v1/rules", `{"name":"x","when":{"event":"note_created"},"then":[{"type":"move_to_notebook","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}]}`).
+		Expect(http.StatusBadRequest)
+
+	var rule core.Rule
+	alice.Post("/api/v1/rules", `{
+		"name": "Счета",
+		"when": {"event": "note_created", "tag": "Invoice"},
+		"then": [
+			{"type": "apply_tag", "tag": "finance"},
+			{"type": "move_to_notebook", "notebook_id": `+strconv.FormatInt(nb.ID, 10)+`},
+			{"type": "call_webhook", "url": "`+hook.URL+`"},
+			{"type": "send_email", "to": ["Bookkeeping <books@example.com>"]}
+		]
+	}`).Expect(http.StatusCreated).JSON(&rule)
+	if !rule.Enabled || rule.When.Tag != "invoice" || rule.Then[3].To[0] != "books@example.com" {
+		t.Fatalf("rule = %+v", rule)
+	}
+
+	createNote(t, alice, `{"title":"Обед","content":""}`)
+	n := createNote(t, alice, `{"title":"Счёт №5","content":"1000 ₽","tags":["invoice/march"]}`)
+	path := "/api/v1/rules/" + strconv.FormatInt(rule.ID, 10)
+	for deadline := time.Now().Add(5 * time.Second); rule.Runs == 0 || len(s.Outbox.Messages()) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("rule did not run: %+v", rule)
+		}
+		alice.Get(path).Expect(http.StatusOK).JSON(&rule)
+	}
+	alice.Get("/api/v1/notes/" + strconv.FormatInt(n.ID, 10)).Expect(http.StatusOK).JSON(&n)
+	if n.NotebookID != nb.ID || strings.Join(n.Tags, ",") != "invoice/march,finance" {
+		t.Errorf("note after rule = %+v", n)
+	}
+	if e := <-events; e.Event != core.TriggerNoteCreated || e.RuleID != rule.ID || e.Note.ID != n.ID {
+		t.Errorf("webhook event = %+v", e)
+	}
+	if msg := s.Outbox.Messages()[0]; msg.Subject != "Счёт №5" || msg.To[0] != "books@example.com" {
+		t.Errorf("email = %+v", msg)
+	}
+	var entries []audit.Entry
+	s.As(testutil.Admin).Get("/api/v1/admin/audit").Expect(http.StatusOK).JSON(&entries)
+	if !slices.ContainsFunc(entries, func(e audit.Entry) bool {
+		return e.Actor == "alice" && e.Action == "note.emailed" && e.Target == "note:"+strconv.FormatInt(n.ID, 10)
+	}) {
+		t.Errorf("audit has no rule email: %+v", entries)
+	}
+	if rule.Runs != 1 {
+		t.Errorf("runs = %d, want only the tagged note", rule.Runs)
+	}
+
+	// Reminders fire once, when they come due after the previous check.
+	var due core.Rule
+	alice.Post("/api/v1/rules", `{"name":"Напомнить","when":{"event":"reminder_due"},"then":[{"type":"call_webhook","url":"`+hook.URL+`"}]}`).
+		Expect(http.StatusCreated).JSON(&due)
+	remindAt := testutil.Epoch.Add(time.Hour).Format(time.RFC3339)
+	reminded := createNote(t, alice, `{"title":"Позвонить","content":"","remind_at":"`+remindAt+`"}`)
+	s.Handler.CheckReminders(s.Clock.Now())
+	s.Clock.Advance(2 * time.Hour)
+	s.Handler.CheckReminders(s.Clock.Now())
+	s.Handler.CheckReminders(s.Clock.Now())
+	if e := <-events; e.Event != core.TriggerReminderDue || e.Note.ID != reminded.ID {
+		t.Errorf("reminder event = %+v", e)
+	}
+
+	s.As(testutil.Bob).Get(path).Expect(http.StatusNotFound)
+	alice.Put(path, `{"name":"Счета","enabled":false,"when":{"event":"note_shared"},"then":[{"type":"apply_tag","tag":"shared"}]}`).
+		Expect(http.StatusOK).JSON(&rule)
+	if rule.Enabled || rule.Runs != 1 {
+		t.Errorf("replaced rule = %+v", rule)
+	}
+	alice.Delete(path).Expect(http.StatusNoContent)
+
+	var list []core.Rule
+	alice.Get("/api/v1/rules").Expect(http.StatusOK).JSON(&list)
+	if len(list) != 1 || list[0].ID != due.ID {
+		t.Errorf("rules = %+v", list)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected webhook event %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Rule emails count towards the owner's email limit; an email over it
+	// fails its action, which the owner is notified of.
+	bob := s.As(testutil.Bob)
+	s.Handler.EmailLimit = ratelimit.Limit{Rate: 1, Period: time.Hour, Burst: 1}
+	bob.Post("/api/v1/rules", `{"name":"Почта","when":{"event":"note_created"},"then":[{"type":"send_email","to":["books@example.com"]}]}`).
+		Expect(http.StatusCreated)
+	sent := len(s.Outbox.Messages())
+	createNote(t, bob, `{"title":"Первая","content":""}`)
+	createNote(t, bob, `{"title":"Вторая","content":""}`)
+	var inbox []notify.Notification
+	for deadline := time.Now().Add(5 * time.Second); len(inbox) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no notification of the failed rule email")
+		}
+		bob.Get("/api/v1/notifications").Expect(http.StatusOK).JSON(&inbox)
+	}
+	var job handlers.JobResponse
+	bob.Get("/api/v1/jobs/" + strconv.FormatInt(inbox[0].JobID, 10)).Expect(http.StatusOK).JSON(&job)
+	if got := len(s.Outbox.Messages()) - sent; got != 1 || job.ErrorCount != 1 {
+		t.Errorf("sent %d emails with a limit of 1, job = %+v", got, job)
+	}
+
+	// Moves into a notebook stop once the owner of the rule loses edit
+	// access to it.
+	carol := s.As(testutil.Carol)
+	nbPath := "/api/v1/notebooks/" + strconv.FormatInt(nb.ID, 10)
+	alice.Put(nbPath+"/shares", `{"grantee":"carol","role":"editor"}`).Expect(http.StatusOK)
+	carol.Post("/api/v1/rules", `{"name":"В финансы","when":{"event":"note_created"},"then":[{"type":"move_to_notebook","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}]}`).
+		Expect(http.StatusCreated)
+	alice.Delete(nbPath + "/shares/carol").Expect(http.StatusOK)
+	moved := createNote(t, carol, `{"title":"Расходы","content":""}`)
+	inbox = nil
+	for deadline := time.Now().Add(5 * time.Second); len(inbox) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no notification of the failed move")
+		}
+		carol.Get("/api/v1/notifications").Expect(http.StatusOK).JSON(&inbox)
+	}
+	carol.Get("/api/v1/notes/" + strconv.FormatInt(moved.ID, 10)).Expect(http.StatusOK).JSON(&moved)
+	if moved.NotebookID != 0 {
+		t.Errorf("rule moved the note into notebook %d after access was revoked", moved.NotebookID)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	var lifecycle core.Lifecycle
+	alice.Get("/api/v1/lifecycle").Expect(http.StatusOK).JSON(&lifecycle)
+	if strings.Join(lifecycle.States, ",") != "draft,active,done,archived" {
+		t.Fatalf("lifecycle = %+v", lifecycle)
+	}
+
+	n := createNote(t, alice, `{"title":"План","content":""}`)
+	createNote(t, alice, `{"title":"Черновик","content":""}`)
+	if n.State != "draft" {
+		t.Fatalf("new note state = %q", n.State)
+	}
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "/transitions"
+	alice.Post(path, `{"to":"done"}`).Expect(http.StatusConflict)
+	alice.Post(path, `{"to":"published"}`).Expect(http.StatusBadRequest)
+	s.As(testutil.Bob).Post(path, `{"to":"active"}`).Expect(http.StatusNotFound)
+	alice.Post(path, `{"to":"Active"}`).Expect(http.StatusOK).JSON(&n)
+	alice.Post(path, `{"to":"done"}`).Expect(http.StatusOK).JSON(&n)
+	if n.State != "done" || n.UpdatedAt == nil {
+		t.Errorf("note = %+v", n)
+	}
+
+	var next handlers.NoteTransitions
+	alice.Get(path).Expect(http.StatusOK).JSON(&next)
+	if next.State != "done" || strings.Join(next.Next, ",") != "archived,active" {
+		t.Errorf("transitions = %+v", next)
+	}
+
+	var notes []core.Note
+	alice.Get("/api/v1/notes?state=done,archived").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].ID != n.ID {
+		t.Errorf("done notes = %+v", notes)
+	}
+	var count handlers.NoteCount
+	alice.Get("/api/v1/notes/count?state=draft").Expect(http.StatusOK).JSON(&count)
+	if count.Count != 1 {
+		t.Errorf("draft count = %d", count.Count)
+	}
+	alice.Get("/api/v1/notes?state=nope").Expect(http.StatusBadRequest)
+}
+
+func TestChangeRequests(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Вики"}`).Expect(http.StatusCreated).JSON(&nb)
+	nbPath := "/api/v1/notebooks/" + strconv.FormatInt(nb.ID, 10)
+	alice.Put(nbPath+"/shares", `{"grantee":"bob","role":"editor"}`).Expect(http.StatusOK)
+	n := createNote(t, alice, `{"title":"Регламент","content":"шаг 1\nшаг 2\nшаг 3","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}`)
+	notePath := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+
+	bob.Patch(nbPath, `{"require_approval":true}`).Expect(http.StatusForbidden)
+	alice.Patch(nbPath, `{"require_approval":true}`).Expect(http.StatusOK).JSON(&nb)
+	if !nb.RequireApproval {
+		t.Fatalf("notebook = %+v", nb)
+	}
+
+	// Members propose; the note stays as it is until the owner decides.
+	var cr core.ChangeRequest
+	bob.Patch(notePath, `{"title":"Регламент v2","content":"шаг 1\nшаг 2б\nшаг 3"}`).Expect(http.StatusAccepted).JSON(&cr)
+	if cr.Status != core.ChangePending || cr.AuthorID != "bob" || cr.NotebookID != nb.ID {
+		t.Fatalf("change request = %+v", cr)
+	}
+	bob.Delete(notePath).Expect(http.StatusForbidden)
+	alice.Get(notePath).Expect(http.StatusOK).JSON(&n)
+	if n.Title != "Регламент" {
+		t.Errorf("note changed before approval: %+v", n)
+	}
+
+	crPath := "/api/v1/change-requests/" + strconv.FormatInt(cr.ID, 10)
+	var diff handlers.ChangeDiff
+	alice.Get(crPath + "/diff").Expect(http.StatusOK).JSON(&diff)
+	var ops []string
+	for _, line := range diff.Content {
+		ops = append(ops, line.Op+line.Text)
+	}
+	if len(diff.Fields) != 1 || diff.Fields[0].Old != "Регламент" || diff.Fields[0].New != "Регламент v2" ||
+		strings.Join(ops, "|") != "=шаг 1|-шаг 2|+шаг 2б|=шаг 3" {
+		t.Errorf("diff = %+v", diff)
+	}
+
+	s.As(testutil.Carol).Get(crPath).Expect(http.StatusNotFound)
+	bob.Post(crPath+"/approve", "").Expect(http.StatusForbidden)
+	alice.Post(crPath+"/approve", "").Expect(http.StatusOK).JSON(&cr)
+	alice.Post(crPath+"/approve", "").Expect(http.StatusConflict)
+	alice.Get(notePath).Expect(http.StatusOK).JSON(&n)
+	if cr.Status != core.ChangeApproved || cr.DecidedBy != "alice" || n.Title != "Регламент v2" || n.Content != "шаг 1\nшаг 2б\nшаг 3" {
+		t.Errorf("after approval: request %+v, note %+v", cr, n)
+	}
+
+	bob.Patch(notePath, `{"pinned":true}`).Expect(http.StatusAccepted).JSON(&cr)
+	alice.Post("/api/v1/change-requests/"+strconv.FormatInt(cr.ID, 10)+"/reject", `{"reason":"не нужно"}`).
+		Expect(http.StatusOK).JSON(&cr)
+	if cr.Status != core.ChangeRejected || cr.Reason != "не нужно" {
+		t.Errorf("rejected request = %+v", cr)
+	}
+
+	var pending []core.ChangeRequest
+	alice.Get("/api/v1/change-requests?status=pending").Expect(http.StatusOK).JSON(&pending)
+	if len(pending) != 0 {
+		t.Errorf("pending = %+v", pending)
+	}
+	var inbox []notify.Notification
+	bob.Get("/api/v1/notifications").Expect(http.StatusOK).JSON(&inbox)
+	if len(inbox) != 2 || inbox[0].Type != notify.ChangeRejected || inbox[1].Type != notify.ChangeApproved {
+		t.Errorf("bob's notifications = %+v", inbox)
+	}
+
+	// The owner edits directly.
+	alice.Patch(notePath, `{"pinned":true}`).Expect(http.StatusOK)
+}
+
+func TestNoteLocks(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Команда"}`).Expect(http.StatusCreated).JSON(&nb)
+	alice.Put("/api/v1/notebooks/"+strconv.FormatInt(nb.ID, 10)+"/shares", `{"grantee":"bob","role":"editor"}`).Expect(http.StatusOK)
+	n := createNote(t, alice, `{"title":"Протокол","content":"","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}`)
+	notePath := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10)
+	lockPath := notePath + "/lock"
+
+	alice.Get(lockPath).Expect(http.StatusNotFound)
+	var lock core.NoteLock
+	bob.Post(lockPath, "").Expect(http.StatusOK).JSON(&lock)
+	if lock.HolderID != "bob" || !lock.ExpiresAt.Equal(testutil.Epoch.Add(repo.DefaultLockTTL)) {
+		t.Fatalf("lock = %+v", lock)
+	}
+
+	// Everyone but the holder is locked out of writing.
+	var e handlers.ErrorResponse
+	alice.Patch(notePath, `{"title":"Протокол встречи"}`).Expect(http.StatusLocked).JSON(&e)
+	if e.Code != "note_locked" || e.Lock == nil || e.Lock.HolderID != "bob" {
+		t.Errorf("locked response = %+v", e)
+	}
+	alice.Delete(notePath).Expect(http.StatusLocked)
+	alice.Post(notePath+"/move", `{"notebook_id":0}`).Expect(http.StatusLocked)
+	alice.Post("/api/v1/notes/move", `{"ids":[`+strconv.FormatInt(n.ID, 10)+`],"notebook_id":0}`).Expect(http.StatusLocked)
+	davPath := "/dav/Команда/Протокол.md"
+	alice.Do(http.MethodPut, davPath, "перезаписано").Expect(http.StatusLocked)
+	alice.WithHeader("Destination", "/dav/Протокол.md").Do("MOVE", davPath, nil).Expect(http.StatusLocked)
+	alice.Do(http.MethodDelete, davPath, nil).Expect(http.StatusLocked)
+	alice.Post(lockPath, "").Expect(http.StatusLocked)
+	bob.Patch(notePath, `{"title":"Протокол встречи","tags":["встречи"]}`).Expect(http.StatusOK)
+
+	// Writes that reach the note through tags and rules yield as well.
+	alice.Patch("/api/v1/tags/встречи", `{"name":"собрания"}`).Expect(http.StatusLocked)
+	var job handlers.JobResponse
+	alice.Post("/api/v1/tags/итоги/apply", `{"notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}`).Expect(http.StatusAccepted).JSON(&job)
+	for deadline := time.Now().Add(5 * time.Second); job.State != jobs.StateDone; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) || job.State == jobs.StateFailed {
+			t.Fatalf("bulk tag job = %+v", job)
+		}
+		alice.Get("/api/v1/jobs/" + strconv.FormatInt(job.ID, 10)).Expect(http.StatusOK).JSON(&job)
+	}
+	if job.ErrorCount != 1 {
+		t.Errorf("bulk tag job = %+v, want the locked note as its error", job)
+	}
+	alice.Post("/api/v1/rules", `{"name":"Опубликовано","when":{"event":"note_shared"},"then":[{"type":"apply_tag","tag":"опубликовано"}]}`).
+		Expect(http.StatusCreated)
+	alice.Post(notePath+"/public-link", "").Expect(http.StatusCreated)
+	failed := func() bool {
+		var inbox []notify.Notification
+		alice.Get("/api/v1/notifications").Expect(http.StatusOK).JSON(&inbox)
+		return slices.ContainsFunc(inbox, func(n notify.Notification) bool { return n.Title == "rule" })
+	}
+	for deadline := time.Now().Add(5 * time.Second); !failed(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no notification of the failed rule")
+		}
+	}
+	var locked core.Note
+	alice.Get(notePath).Expect(http.StatusOK).JSON(&locked)
+	if strings.Join(locked.Tags, ",") != "встречи" {
+		t.Errorf("tags of the locked note = %v", locked.Tags)
+	}
+
+	// A heartbeat extends the lock; without one it expires.
+	s.Clock.Advance(time.Minute)
+	bob.Post(lockPath, "").Expect(http.StatusOK).JSON(&lock)
+	if !lock.AcquiredAt.Equal(testutil.Epoch) || !lock.ExpiresAt.Equal(s.Clock.Now().Add(repo.DefaultLockTTL)) {
+		t.Errorf("extended lock = %+v", lock)
+	}
+	s.Clock.Advance(repo.DefaultLockTTL)
+	alice.Patch(notePath, `{"pinned":true}`).Expect(http.StatusOK)
+
+	// The owner of the note breaks other people's locks; editors cannot.
+	alice.Post(lockPath, "").Expect(http.StatusOK)
+	bob.Delete(lockPath).Expect(http.StatusLocked)
+	alice.Delete(lockPath).Expect(http.StatusNoContent)
+	bob.Post(lockPath, "").Expect(http.StatusOK)
+	alice.Delete(lockPath).Expect(http.StatusNoContent)
+	alice.Get(lockPath).Expect(http.StatusNotFound)
+}
+
+func TestPresence(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Общий"}`).Expect(http.StatusCreated).JSON(&nb)
+	alice.Put("/api/v1/notebooks/"+strconv.FormatInt(nb.ID, 10)+"/shares", `{"grantee":"bob","role":"viewer"}`).Expect(http.StatusOK)
+	n := createNote(t, alice, `{"title":"Повестка","content":"","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}`)
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "/presence"
+
+	// Alice's event stream sees Bob open the note.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	stream := make(chan *testutil.Response)
+	go func() { stream <- alice.DoContext(ctx, http.MethodGet, "/api/v1/events", nil) }()
+	time.Sleep(50 * time.Millisecond)
+
+	var viewers []events.Viewer
+	bob.Post(path, "").Expect(http.StatusOK)
+	s.Clock.Advance(time.Second)
+	alice.Post(path, `{"typing":true}`).Expect(http.StatusOK).JSON(&viewers)
+	if len(viewers) != 2 || viewers[0].UserID != "bob" || viewers[0].Typing || viewers[1].UserID != "alice" || !viewers[1].Typing {
+		t.Errorf("viewers = %+v", viewers)
+	}
+	if body := string((<-stream).Expect(http.StatusOK).Body); !strings.Contains(body, `"type":"presence.updated","note_id":`+strconv.FormatInt(n.ID, 10)+`,"user_id":"bob"`) {
+		t.Errorf("events = %s", body)
+	}
+
+	s.As(testutil.Carol).Get(path).Expect(http.StatusNotFound)
+	bob.Delete(path).Expect(http.StatusOK)
+	bob.Get(path).Expect(http.StatusOK).JSON(&viewers)
+	if len(viewers) != 1 || viewers[0].UserID != "alice" {
+		t.Errorf("after bob left: %+v", viewers)
+	}
+
+	// Without a heartbeat, users drop out.
+	s.Clock.Advance(events.DefaultPresenceTTL)
+	bob.Get(path).Expect(http.StatusOK).JSON(&viewers)
+	if len(viewers) != 0 {
+		t.Errorf("after the TTL: %+v", viewers)
+	}
+}
+
+func TestCommentThreads(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Ревью"}`).Expect(http.StatusCreated).JSON(&nb)
+	alice.Put("/api/v1/notebooks/"+strconv.FormatInt(nb.ID, 10)+"/shares", `{"grantee":"bob","role":"viewer"}`).Expect(http.StatusOK)
+	n := createNote(t, alice, `{"title":"Дизайн API","content":"","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}`)
+	path := "/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "/comments"
+	alice.Post("/api/v1/notes/"+strconv.FormatInt(n.ID, 10)+"/watch", "").Expect(http.StatusOK)
+
+	var first, second, reply, nested core.Comment
+	bob.Post(path, `{"body":"Почему PATCH, а не PUT?"}`).Expect(http.StatusCreated).JSON(&first)
+	bob.Post(path, `{"body":"Опечатка во втором разделе"}`).Expect(http.StatusCreated).JSON(&second)
+	alice.Post(path, `{"body":"Частичное обновление","reply_to":`+strconv.FormatInt(first.ID, 10)+`}`).Expect(http.StatusCreated).JSON(&reply)
+	bob.Post(path, `{"body":"Понял","reply_to":`+strconv.FormatInt(reply.ID, 10)+`}`).Expect(http.StatusCreated).JSON(&nested)
+	if nested.ReplyTo != first.ID {
+		t.Errorf("reply to a reply = %+v", nested)
+	}
+	bob.Post(path, `{"body":" "}`).Expect(http.StatusBadRequest)
+	bob.Post(path, `{"body":"?","reply_to":999}`).Expect(http.StatusBadRequest)
+	s.As(testutil.Carol).Post(path, `{"body":"!"}`).Expect(http.StatusNotFound)
+
+	firstPath := path + "/" + strconv.FormatInt(first.ID, 10)
+	alice.Patch(firstPath, `{"body":"Правка"}`).Expect(http.StatusForbidden)
+	bob.Patch(path+"/"+strconv.FormatInt(reply.ID, 10), `{"resolved":true}`).Expect(http.StatusForbidden)
+	alice.Patch(path+"/"+strconv.FormatInt(reply.ID, 10), `{"resolved":true}`).Expect(http.StatusBadRequest)
+	alice.Patch(firstPath, `{"resolved":true}`).Expect(http.StatusOK).JSON(&first)
+	if !first.Resolved || first.ResolvedBy != "alice" || first.ResolvedAt == nil {
+		t.Errorf("resolved thread = %+v", first)
+	}
+
+	var threads []handlers.CommentThread
+	bob.Get(path).Expect(http.StatusOK).JSON(&threads)
+	if len(threads) != 2 || threads[0].ID != first.ID || len(threads[0].Replies) != 2 || len(threads[1].Replies) != 0 {
+		t.Fatalf("threads = %+v", threads)
+	}
+	bob.Get(path + "?resolved=false").Expect(http.StatusOK).JSON(&threads)
+	if len(threads) != 1 || threads[0].ID != second.ID {
+		t.Errorf("unresolved threads = %+v", threads)
+	}
+	bob.Get(path + "?resolved=maybe").Expect(http.StatusBadRequest)
+
+	var inbox []notify.Notification
+	alice.Get("/api/v1/notifications").Expect(http.StatusOK).JSON(&inbox)
+	if len(inbox) != 3 || inbox[0].Type != notify.CommentAdded {
+		t.Errorf("alice's notifications = %+v", inbox)
+	}
+
+	// Deleting the first comment of a thread deletes its replies.
+	alice.Delete(firstPath).Expect(http.StatusNoContent)
+	bob.Delete(path + "/" + strconv.FormatInt(nested.ID, 10)).Expect(http.StatusNotFound)
+	bob.Get(path).Expect(http.StatusOK).JSON(&threads)
+	if len(threads) != 1 {
+		t.Errorf("after delete: %+v", threads)
+	}
+}
+
+func TestEmoji(t *testing.T) {
+	s := testutil.New(t)
+	alice, admin := s.As(testutil.Alice), s.As(testutil.Admin)
+
+	alice.Put("/api/v1/emoji/parrot", `{"url":"https://cdn.example.com/parrot.gif"}`).Expect(http.StatusForbidden)
+	admin.Put("/api/v1/emoji/tada", `{"url":"https://cdn.example.com/tada.gif"}`).Expect(http.StatusBadRequest)
+	admin.Put("/api/v1/emoji/parrot", `{"url":"javascript:alert(1)"}`).Expect(http.StatusBadRequest)
+	admin.Put("/api/v1/emoji/Parrot!", `{"url":"https://cdn.example.com/parrot.gif"}`).Expect(http.StatusBadRequest)
+	admin.Put("/api/v1/emoji/parrot", `{"url":"https://cdn.example.com/parrot.gif"}`).Expect(http.StatusOK)
+
+	var list handlers.EmojiList
+	alice.Get("/api/v1/emoji").Expect(http.StatusOK).JSON(&list)
+	if len(list.Builtin) == 0 || len(list.Custom) != 1 || list.Custom[0].Name != "parrot" || list.Custom[0].Author != "root" {
+		t.Fatalf("emoji = %+v", list)
+	}
+
+	n := createNote(t, alice, `{"title":"Релиз","content":"Готово :tada: :parrot: :nope:\n\n`+"`:tada:`"+` [ссылка :tada:](https://example.com/:tada:/)"}`)
+	page := string(alice.Get("/api/v1/notes/" + strconv.FormatInt(n.ID, 10) + "/print").Expect(http.StatusOK).Body)
+	for _, want := range []string{
+		"Готово 🎉 " + `<img class="emoji" src="https://cdn.example.com/parrot.gif" alt=":parrot:" title=":parrot:">` + " :nope:",
+		"<code>:tada:</code>",
+		`<a href="https://example.com/:tada:/" rel="nofollow noopener">ссылка 🎉</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("print view lacks %s:\n%s", want, page)
+		}
+	}
+
+	alice.Post("/api/v1/notes/"+strconv.FormatInt(n.ID, 10)+"/email", `{"to":["ann@example.com"]}`).Expect(http.StatusOK)
+	if msgs := s.Outbox.Messages(); len(msgs) != 1 || !strings.Contains(msgs[0].Body, "Готово 🎉 :parrot:") {
+		t.Errorf("messages = %+v", msgs)
+	}
+
+	admin.Delete("/api/v1/emoji/parrot").Expect(http.StatusNoContent)
+	admin.Delete("/api/v1/emoji/parrot").Expect(http.StatusNotFound)
+}
+
+func TestFavorites(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Команда"}`).Expect(http.StatusCreated).JSON(&nb)
+	nbPath := "/api/v1/notebooks/" + strconv.FormatInt(nb.ID, 10)
+	alice.Put(nbPath+"/shares", `{"grantee":"bob","role":"viewer"}`).Expect(http.StatusOK)
+	shared := createNote(t, alice, `{"title":"Общая","content":"","notebook_id":`+strconv.FormatInt(nb.ID, 10)+`}`)
+	own := createNote(t, bob, `{"title":"Своя","content":""}`)
+	private := createNote(t, alice, `{"title":"Личная","content":""}`)
+
+	bob.Post("/api/v1/notes/"+strconv.FormatInt(private.ID, 10)+"/favorite", "").Expect(http.StatusNotFound)
+	var fav handlers.FavoriteResponse
+	bob.Post("/api/v1/notes/"+strconv.FormatInt(shared.ID, 10)+"/favorite", "").Expect(http.StatusOK).JSON(&fav)
+	if !fav.Favorite {
+		t.Errorf("favorite = %+v", fav)
+	}
+	s.Clock.Advance(time.Minute)
+	bob.Post("/api/v1/notes/"+strconv.FormatInt(own.ID, 10)+"/favorite", "").Expect(http.StatusOK)
+
+	var notes []core.Note
+	bob.Get("/api/v1/notes/favorites").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 2 || notes[0].ID != own.ID || notes[1].ID != shared.ID || notes[1].Pinned {
+		t.Fatalf("bob's favorites = %+v", notes)
+	}
+	alice.Get("/api/v1/notes/favorites").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 0 {
+		t.Errorf("alice's favorites = %+v", notes)
+	}
+
+	// Favorites the user can no longer read are left out.
+	alice.Delete(nbPath + "/shares/bob").Expect(http.StatusOK)
+	bob.Get("/api/v1/notes/favorites").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].ID != own.ID {
+		t.Errorf("after unsharing: %+v", notes)
+	}
+
+	bob.Delete("/api/v1/notes/" + strconv.FormatInt(own.ID, 10) + "/favorite").Expect(http.StatusOK).JSON(&fav)
+	if fav.Favorite {
+		t.Errorf("unfavorite = %+v", fav)
+	}
+	bob.Get("/api/v1/notes/favorites").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 0 {
+		t.Errorf("after unfavorite: %+v", notes)
+	}
+}
+
+func TestRecentNotes(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	first := createNote(t, alice, `{"title":"Первая","content":""}`)
+	second := createNote(t, alice, `{"title":"Вторая","content":""}`)
+	firstPath := "/api/v1/notes/" + strconv.FormatInt(first.ID, 10)
+	secondPath := "/api/v1/notes/" + strconv.FormatInt(second.ID, 10)
+
+	alice.Get(firstPath).Expect(http.StatusOK)
+	s.Clock.Advance(time.Second)
+	alice.Get(secondPath).Expect(http.StatusOK)
+	s.Clock.Advance(time.Second)
+	// Views less than a minute apart count once.
+	alice.Get(firstPath).Expect(http.StatusOK)
+
+	var notes []core.Note
+	alice.Get("/api/v1/notes/recent").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 2 || notes[0].ID != second.ID || notes[1].ID != first.ID {
+		t.Fatalf("recent = %+v", notes)
+	}
+
+	s.Clock.Advance(repo.DefaultRecentDebounce)
+	alice.Get(firstPath + "/markdown").Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/recent?limit=1").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 || notes[0].ID != first.ID {
+		t.Errorf("recent after debounce = %+v", notes)
+	}
+
+	alice.Delete(secondPath).Expect(http.StatusNoContent)
+	alice.Get("/api/v1/notes/recent").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 1 {
+		t.Errorf("recent after delete = %+v", notes)
+	}
+
+	// Turning tracking off forgets the history and stops recording.
+	var prefs core.Preferences
+	alice.Get("/api/v1/me/preferences").Expect(http.StatusOK).JSON(&prefs)
+	if !prefs.Privacy.RecentNotes {
+		t.Errorf("recent_notes is off by default")
+	}
+	alice.Patch("/api/v1/me/preferences", `{"privacy":{"recent_notes":false}}`).Expect(http.StatusOK)
+	alice.Get(firstPath).Expect(http.StatusOK)
+	alice.Get("/api/v1/notes/recent").Expect(http.StatusOK).JSON(&notes)
+	if len(notes) != 0 {
+		t.Errorf("recent with tracking off = %+v", notes)
+	}
+}
+
+func TestSearchFacets(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	var nb core.Notebook
+	alice.Post("/api/v1/notebooks", `{"name":"Работа"}`).Expect(http.StatusCreated).JSON(&nb)
+	alice.Put("/api/v1/notebooks/"+strconv.FormatInt(nb.ID, 10)+"/shares", `{"grantee":"bob","role":"editor"}`).Expect(http.StatusOK)
+	nbID := strconv.FormatInt(nb.ID, 10)
+
+	createNote(t, alice, `{"title":"План релиза","content":"","tags":["work","release"],"notebook_id":`+nbID+`}`)
+	s.Clock.Advance(3 * 24 * time.Hour)
+	createNote(t, bob, `{"title":"Релиз: план отката","content":"","tags":["work"],"notebook_id":`+nbID+`}`)
+	s.Clock.Advance(24 * time.Hour)
+	createNote(t, bob, `{"title":"Личный план","content":"","tags":["home"]}`)
+	createNote(t, alice, `{"title":"Чужой план","content":""}`)
+
+	var res handlers.SearchResults
+	bob.Get("/api/v1/search?q=план&limit=1").Expect(http.StatusOK).JSON(&res)
+	if res.Total != 3 || len(res.Notes) != 1 {
+		t.Fatalf("results = %+v", res)
+	}
+	want := search.Facets{
+		Tags:      []search.Facet{{Value: "work", Count: 2}, {Value: "home", Count: 1}, {Value: "release", Count: 1}},
+		Notebooks: []search.Facet{{Value: nbID, Label: "Работа", Count: 2}},
+		Created:   []search.Facet{{Value: search.CreatedToday, Count: 1}, {Value: search.CreatedWeek, Count: 2}},
+		Authors:   []search.Facet{{Value: "bob", Count: 2}, {Value: "alice", Count: 1}},
+	}
+	if !reflect.DeepEqual(res.Facets, want) {
+		t.Errorf("facets = %+v", res.Facets)
+	}
+
+	// Filters narrow the facets along with the results.
+	bob.Get("/api/v1/search?q=план&tag=release").Expect(http.StatusOK).JSON(&res)
+	if res.Total != 1 || len(res.Facets.Authors) != 1 || res.Facets.Authors[0].Value != "alice" {
+		t.Errorf("filtered results = %+v", res)
+	}
+
+	bob.Get("/api/v1/search").Expect(http.StatusBadRequest)
+}
+
+func TestSearchQueryLanguage(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	old := createNote(t, alice, `{"title":"Отчёт за год","content":"Итоги и точная фраза","tags":["work"]}`)
+	s.Clock.Advance(48 * time.Hour)
+	recent := createNote(t, alice, `{"title":"Отчёт за неделю","content":"Фраза не точная","tags":["work","weekly"]}`)
+	home := createNote(t, alice, `{"title":"Список покупок","content":"молоко","tags":["home"]}`)
+
+	find := func(q string) []int64 {
+		t.Helper()
+		var res handlers.SearchResults
+		alice.Get("/api/v1/search?q=" + url.QueryEscape(q)).Expect(http.StatusOK).JSON(&res)
+		ids := make([]int64, len(res.Notes))
+		for i, n := range res.Notes {
+			ids[i] = n.ID
+		}
+		return ids
+	}
+	for q, want := range map[string][]int64{
+		`tag:work AND created:>2025-01-06`:    {recent.ID},
+		`tag:work created:<=2025-01-06`:       {old.ID},
+		`"точная фраза"`:                      {old.ID},
+		`отчёт -tag:weekly`:                   {old.ID},
+		`молоко OR (tag:work AND NOT неделю)`: {old.ID, home.ID},
+		`title:"за неделю" OR type:snippet`:   {recent.ID},
+	} {
+		got := find(q)
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", q, got, want)
+		}
+	}
+
+	var v handlers.QueryValidation
+	alice.Get("/api/v1/search/validate?q=" + url.QueryEscape(`tag:work created:>2024-01-01 (a OR "b c")`)).Expect(http.StatusOK).JSON(&v)
+	if !v.Valid || v.Query != `tag:work AND created:>2024-01-01 AND (a OR "b c")` {
+		t.Errorf("validation = %+v", v)
+	}
+	for q, pos := range map[string]int{
+		`заметки tag:`:        8,
+		`(a OR b`:             7,
+		`created:>вчера`:      0,
+		`a AND`:               5,
+		`tag:work "без конца`: 9,
+	} {
+		alice.Get("/api/v1/search/validate?q=" + url.QueryEscape(q)).Expect(http.StatusOK).JSON(&v)
+		if v.Valid || v.Error == nil || v.Error.Pos != pos {
+			t.Errorf("%s: validation = %+v, want an error at %d", q, v.Error, pos)
+		}
+	}
+
+	var e handlers.ErrorResponse
+	alice.Get("/api/v1/notes?q=" + url.QueryEscape("notebook:x")).Expect(http.StatusBadRequest).JSON(&e)
+	if e.Error != "Invalid q at 0: notebook: needs a notebook ID" {
+		t.Errorf("error = %+v", e)
+	}
+
+	// Words that only look like syntax stay plain words.
+	plain := createNote(t, alice, `{"title":"Re: standup","content":"В 10:30, см. https://example.com/page, -mail"}`)
+	for _, q := range []string{`10:30`, `https://example.com/page`, `"re: standup"`, `-mail`, `foo:bar`} {
+		var notes []core.Note
+		alice.Get("/api/v1/notes?q=" + url.QueryEscape(q)).Expect(http.StatusOK).JSON(&notes)
+		if want := q != `foo:bar`; want != (len(notes) == 1 && notes[0].ID == plain.ID) {
+			t.Errorf("%s: got %+v", q, notes)
+		}
+	}
+}
+
+func TestSearchAnalyzers(t *testing.T) {
+	s := testutil.New(t)
+	alice := s.As(testutil.Alice)
+
+	ru := createNote(t, alice, `{"title":"Заметка о встрече","content":"Обсудили отчёт"}`)
+	en := createNote(t, alice, `{"title":"Meeting notes","content":"We tested the release"}`)
+
+	find := func(q string) []int64 {
+		t.Helper()
+		var notes []core.Note
+		alice.Get("/api/v1/notes?q=" + url.QueryEscape(q)).Expect(http.StatusOK).JSON(&notes)
+		var ids []int64
+		for _, n := range notes {
+			ids = append(ids, n.ID)
+		}
+		return ids
+	}
+
+	// The instance analyzes searches as Russian unless users choose.
+	for q, want := range map[string][]int64{
+		"заметки":         {ru.ID},
+		"встречи отчеты":  {ru.ID},
+		"Отчет":           {ru.ID},
+		"notes":           {en.ID},
+		"note":            nil,
+		`"отчёт" И notes`: nil,
+	} {
+		if got := find(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("russian %s: got %v, want %v", q, got, want)
+		}
+	}
+	alice.Get("/api/v1/notes?q=" + url.QueryEscape("и не")).Expect(http.StatusBadRequest)
+
+	alice.Patch("/api/v1/me/preferences", `{"search_language":"klingon"}`).Expect(http.StatusBadRequest)
+	alice.Patch("/api/v1/me/preferences", `{"search_language":"English"}`).Expect(http.StatusOK)
+	for q, want := range map[string][]int64{
+		"note test":  {en.ID},
+		"заметки":    nil,
+		"the notes":  {en.ID},
+		"title:note": {en.ID},
+	} {
+		if got := find(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("english %s: got %v, want %v", q, got, want)
+		}
+	}
+
+	var v handlers.QueryValidation
+	alice.Get("/api/v1/search/validate?q=notes").Expect(http.StatusOK).JSON(&v)
+	if v.Language != "english" {
+		t.Errorf("validation = %+v", v)
+	}
+
+	alice.Patch("/api/v1/me/preferences", `{"search_language":"simple"}`).Expect(http.StatusOK)
+	if got := find("заметки"); len(got) != 0 {
+		t.Errorf("simple заметки: got %v", got)
+	}
+	if got := find("заметка"); len(got) != 1 {
+		t.Errorf("simple заметка: got %v", got)
+	}
+}
+
+func TestTypeahead(t *testing.T) {
+	s := testutil.New(t)
+	alice, bob := s.As(testutil.Alice), s.As(testutil.Bob)
+
+	plan := createNote(t, alice, `{"title":"План релиза","content":""}`)
+	weekly := createNote(t, alice, `{"title":"Еженедельный план работ","content":""}`)
+	release := createNote(t, alice, `{"title":"Релизный план","content":""}`)
+	createNote(t, bob, `{"title":"План Боба","content":""}`)
+
+	hits := func(c *testutil.Client, q string) []handlers.TypeaheadHit {
+		t.Helper()
+		var hits []handlers.TypeaheadHit
+		c.Get("/api/v1/search/typeahead?q=" + url.QueryEscape(q)).Expect(http.StatusOK).JSON(&hits)
+		return hits
+	}
+
+	got := hits(alice, "пла")
+	if len(got) != 3 || got[0].ID != plan.ID || got[1].ID != release.ID || got[2].ID != weekly.ID || got[0].Title != "План релиза" {
+		t.Fatalf("пла: %+v", got)
+	}
+	if got := hits(alice, "план рел"); len(got) != 2 || got[0].ID != plan.ID || got[1].ID != release.ID {
+		t.Errorf("план рел: %+v", got)
+	}
+	if got := hits(bob, "пла"); len(got) != 1 || got[0].Title != "План Боба" {
+		t.Errorf("bob: %+v", got)
+	}
+	var limited []handlers.TypeaheadHit
+	alice.Get("/api/v1/search/typeahead?q=%D0%BF&limit=1").Expect(http.StatusOK).JSON(&limited)
+	if len(limited) != 1 || limited[0].ID != plan.ID {
+		t.Errorf("limit=1: %+v", limited)
+	}
+	alice.Get("/api/v1/search/typeahead?q=x&limit=100").Expect(http.StatusBadRequest)
+
+	// New titles show up once the index is rebuilt, at most once per
+	// debounce.
+	createNote(t, alice, `{"title":"Планёрка","content":""}`)
+	if got := hits(alice, "планер"); len(got) != 0 {
+		t.Errorf("before the debounce: %+v", got)
+	}
+	s.Clock.Advance(search.DefaultTitleDebounce)
+	if got := hits(alice, "планер"); len(got) != 1 || got[0].Title != "Планёрка" {
+		t.Errorf("after the debounce: %+v", got)
+	}
+}
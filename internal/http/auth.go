@@ -0,0 +1,65 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/http/handlers"
+)
+
+// authenticate admits requests carrying one of tokens. Others are asked
+// for a bearer token; Basic is offered too when the request tried Basic
+// credentials or its path starts with one of basicPaths, for clients that
+// only prompt for Basic.
+func authenticate(tokens auth.Tokens, basicPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(tokens) == 0 {
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), auth.Anonymous)))
+				return
+			}
+
+			token, ok := credentials(r)
+			p, found := tokens[token]
+			if !ok || !found {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				if offersBasic(r, basicPaths) {
+					w.Header().Add("WWW-Authenticate", `Basic realm="notes"`)
+				}
+				respondError(w, handlers.CodeUnauthorized, "Unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// credentials returns the token the request authenticates with.
+func credentials(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		// No-code tools such as Zapier send the token as an API key.
+		token = r.Header.Get("X-API-Key")
+		ok = token != ""
+	}
+	if !ok {
+		// File managers mounting WebDAV only speak Basic auth, so the
+		// token is also accepted as the password.
+		_, token, ok = r.BasicAuth()
+	}
+	return token, ok
+}
+
+func offersBasic(r *http.Request, basicPaths []string) bool {
+	if _, _, ok := r.BasicAuth(); ok {
+		return true
+	}
+	for _, prefix := range basicPaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
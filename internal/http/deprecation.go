@@ -0,0 +1,51 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+// Deprecation describes a route clients should move off, in the headers
+// of RFC 9745 and RFC 8594.
+type Deprecation struct {
+	// Since is when the route was deprecated; zero only says that it is.
+	Since time.Time
+	// Sunset is when the route may be removed, if that is decided.
+	Sunset time.Time
+	// Successor is the path of the route that replaces this one.
+	Successor string
+	// Docs links to notes on moving off the route.
+	Docs string
+}
+
+// deprecated marks the routes it is used With: a deprecated route keeps
+// working, but its responses carry the headers of d and its calls are
+// counted in reg, when set, so that it is known when it can go.
+func deprecated(reg *metrics.Registry, d Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d.Since.IsZero() {
+				w.Header().Set("Deprecation", "true")
+			} else {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+			}
+			if !d.Sunset.IsZero() {
+				w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Successor != "" {
+				w.Header().Add("Link", "<"+d.Successor+`>; rel="successor-version"`)
+			}
+			if d.Docs != "" {
+				w.Header().Add("Link", "<"+d.Docs+`>; rel="deprecation"; type="text/html"`)
+			}
+			if reg != nil {
+				reg.Deprecated(chi.RouteContext(r.Context()).RoutePattern(), r.Method)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
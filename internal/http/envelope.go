@@ -0,0 +1,152 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Envelope wraps a JSON response for clients that cannot read status
+// codes or headers, such as some low-code tools. Data is the response,
+// or null for errors, whose bodies go in Errors instead.
+type Envelope struct {
+	Data   json.RawMessage   `json:"data"`
+	Meta   EnvelopeMeta      `json:"meta"`
+	Errors []json.RawMessage `json:"errors"`
+}
+
+type EnvelopeMeta struct {
+	// Status is the status the response would have had without the
+	// envelope; 204 is answered 200 so that the envelope has a body.
+	Status     int  `json:"status" example:"200"`
+	TotalCount *int `json:"total_count,omitempty" example:"42"`
+	Page       int  `json:"page,omitempty" example:"1"`
+	Limit      int  `json:"limit,omitempty" example:"20"`
+	// Headers are the response headers that carry information, such as
+	// Location or Undo-Token.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// envelopeSkipped are the headers left out of EnvelopeMeta.Headers: they
+// describe the transfer rather than the response, or are in the meta
+// already.
+var envelopeSkipped = map[string]bool{
+	"Content-Type":            true,
+	"Content-Length":          true,
+	"Content-Disposition":     true,
+	"Cache-Control":           true,
+	"Content-Security-Policy": true,
+	"Referrer-Policy":         true,
+	"X-Accel-Buffering":       true,
+	"X-Total-Count":           true,
+}
+
+// envelope wraps JSON responses in an Envelope for requests with
+// ?envelope=true. Other responses, such as files, event streams and 304s,
+// are passed through.
+func envelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if on, _ := strconv.ParseBool(r.URL.Query().Get("envelope")); !on {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish(r)
+	})
+}
+
+type envelopeWriter struct {
+	http.ResponseWriter
+	status      int
+	passthrough bool
+	buf         bytes.Buffer
+}
+
+func (w *envelopeWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		if w.passthrough {
+			w.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	w.status = code
+	ct := w.Header().Get("Content-Type")
+	if code == http.StatusNotModified || code < http.StatusOK || ct != "" && !strings.HasPrefix(ct, "application/json") {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *envelopeWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.passthrough {
+		f.Flush()
+	}
+}
+
+// finish writes the buffered response in an envelope, or as it is when it
+// turns out not to be JSON.
+func (w *envelopeWriter) finish(r *http.Request) {
+	if w.passthrough {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	body := bytes.TrimSpace(w.buf.Bytes())
+	if len(body) > 0 && !json.Valid(body) {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.buf.Bytes())
+		return
+	}
+
+	env := Envelope{Data: json.RawMessage("null"), Meta: EnvelopeMeta{Status: w.status}, Errors: []json.RawMessage{}}
+	switch {
+	case w.status >= http.StatusBadRequest && len(body) > 0:
+		env.Errors = append(env.Errors, body)
+	case len(body) > 0:
+		env.Data = body
+	}
+
+	header := w.Header()
+	if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		env.Meta.TotalCount = &total
+		env.Meta.Page = 1
+		if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+			env.Meta.Page = page
+		}
+		env.Meta.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	}
+	for key, values := range header {
+		if !envelopeSkipped[key] {
+			if env.Meta.Headers == nil {
+				env.Meta.Headers = make(map[string]string)
+			}
+			env.Meta.Headers[key] = strings.Join(values, ", ")
+		}
+	}
+
+	status := w.status
+	if status == http.StatusNoContent {
+		status = http.StatusOK
+	}
+	header.Del("Content-Length")
+	header.Set("Content-Type", "application/json")
+	w.ResponseWriter.WriteHeader(status)
+	encoder := json.NewEncoder(w.ResponseWriter)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(env)
+}
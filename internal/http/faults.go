@@ -0,0 +1,66 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/faults"
+	"example.com/notes-api/internal/http/handlers"
+)
+
+// injectFaults applies the faults the injector picks: every latency hit
+// delays the request, then the first error or drop hit replaces the
+// response. Responses carry X-Fault-Injected so that injected failures
+// can be told apart from real ones.
+func injectFaults(in *faults.Injector) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hit := in.Pick(r)
+			if len(hit) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var delay time.Duration
+			var kinds []string
+			var final *faults.Fault
+			for i, f := range hit {
+				switch f.Kind {
+				case faults.Latency:
+					delay += f.Delay
+				default:
+					if final != nil {
+						continue
+					}
+					final = &hit[i]
+				}
+				kinds = append(kinds, string(f.Kind))
+			}
+			w.Header().Set("X-Fault-Injected", strings.Join(kinds, ", "))
+
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			switch {
+			case final == nil:
+				next.ServeHTTP(w, r)
+			case final.Kind == faults.Error:
+				// The fault keeps its status even when no code has it.
+				c := handlers.CodeForStatus(final.Status)
+				c.Status = final.Status
+				respondError(w, c, "Injected fault")
+			default:
+				// net/http closes the connection without writing a response.
+				panic(http.ErrAbortHandler)
+			}
+		})
+	}
+}
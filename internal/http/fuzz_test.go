@@ -0,0 +1,76 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"example.com/notes-api/internal/testutil"
+)
+
+// The fuzz targets only require that malformed input never brings the
+// server down or past what the handlers document: the router recovers
+// panics as 500s, and the harness reports undocumented responses.
+
+func FuzzCreateNote(f *testing.F) {
+	f.Add(`{"title":"a","content":"b","tags":["x"],"pinned":true}`)
+	f.Add(`{"title":"a","type":"snippet","language":"go","latitude":55.7,"longitude":37.6}`)
+	f.Add(`{"title":"a","blocks":[{"type":"heading","level":7,"text":"h"}]}`)
+	f.Add(`{"title":"a","properties":{"due":"2025-13-01","n":1e400}}`)
+	f.Add(`{"title":"a","remind_at":"not a time"}`)
+	f.Add(`{"title":`)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		s := testutil.New(t)
+		res := s.As(testutil.Alice).Post("/api/v1/notes", body)
+		if res.Code >= http.StatusInternalServerError {
+			t.Fatalf("POST %q: %d %s", body, res.Code, res.Body)
+		}
+	})
+}
+
+func FuzzPatchNote(f *testing.F) {
+	f.Add(`{"title":"b"}`)
+	f.Add(`{"title":" "}`)
+	f.Add(`{"blocks":[]}`)
+	f.Add(`{"latitude":91}`)
+	f.Add(`{"properties":{"status":null}}`)
+	f.Add(`{"collection_id":-1}`)
+	f.Add(`[]`)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		s := testutil.New(t)
+		alice := s.As(testutil.Alice)
+		alice.Post("/api/v1/notes", `{"title":"a","content":"b"}`).Expect(http.StatusCreated)
+		res := alice.Patch("/api/v1/notes/1", body)
+		if res.Code >= http.StatusInternalServerError {
+			t.Fatalf("PATCH %q: %d %s", body, res.Code, res.Body)
+		}
+	})
+}
+
+func FuzzListNotes(f *testing.F) {
+	f.Add("q", "молоко")
+	f.Add("tag", "Work/Projects")
+	f.Add("page", "-1")
+	f.Add("limit", "99999999999999999999")
+	f.Add("notebook_id", "abc")
+	f.Add("prop.due", "2025-01-06")
+	f.Add("reaction", "👍")
+	f.Add("type", "snippet")
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		s := testutil.New(t)
+		alice := s.As(testutil.Alice)
+		alice.Post("/api/v1/notes", `{"title":"a","content":"молоко","tags":["work"],"properties":{"due":"2025-01-06"}}`).Expect(http.StatusCreated)
+		alice.Post("/api/v1/notes/1/reactions", `{"emoji":"👍"}`).Expect(http.StatusOK)
+
+		query := url.Values{key: {value}}.Encode()
+		for _, path := range []string{"/api/v1/notes?", "/api/v1/stats/activity?", "/api/v1/notes/nearby?lat=0&lon=0&"} {
+			res := alice.Get(path + query)
+			if res.Code >= http.StatusInternalServerError {
+				t.Fatalf("GET %s%s: %d %s", path, query, res.Code, res.Body)
+			}
+		}
+	})
+}
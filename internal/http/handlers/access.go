@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// noteRole resolves what the principal may do with a note: owners and
+// admins own it, everyone else inherits their role on its notebook. In
+// notebooks that require approval, only the owners of the notebook edit
+// directly; the others propose changes.
+func (h *Handler) noteRole(p core.Principal, n core.Note) core.Role {
+	if h.proposes(p, n) {
+		return core.RoleViewer
+	}
+	return h.grantedRole(p, n)
+}
+
+// grantedRole is the role of p on n before approval mode is considered.
+func (h *Handler) grantedRole(p core.Principal, n core.Note) core.Role {
+	if p.Admin || n.OwnerID == p.UserID {
+		return core.RoleOwner
+	}
+	if n.NotebookID != 0 {
+		return h.Notebooks.Role(p, n.NotebookID)
+	}
+	return core.RoleNone
+}
+
+// proposes reports whether the edits p makes to n become change requests:
+// p could edit n, but its notebook requires approval and p does not own
+// the notebook.
+func (h *Handler) proposes(p core.Principal, n core.Note) bool {
+	if h.ChangeRequests == nil || n.NotebookID == 0 || !h.Notebooks.RequiresApproval(n.NotebookID) ||
+		h.Notebooks.Role(p, n.NotebookID).Allows(core.RoleOwner) {
+		return false
+	}
+	return h.grantedRole(p, n).Allows(core.RoleEditor)
+}
+
+func (h *Handler) canRead(r *http.Request, n core.Note) bool {
+	return h.noteRole(auth.FromContext(r.Context()), n).Allows(core.RoleViewer)
+}
+
+func (h *Handler) canWrite(r *http.Request, n core.Note) bool {
+	return h.noteRole(auth.FromContext(r.Context()), n).Allows(core.RoleEditor)
+}
+
+// requireAdmin rejects callers who are not admins.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !auth.FromContext(r.Context()).Admin {
+		respondError(w, CodeForbidden, "Forbidden")
+		return false
+	}
+	return true
+}
+
+// readable filters notes down to those visible to the caller.
+func (h *Handler) readable(r *http.Request, notes []core.Note) []core.Note {
+	p := auth.FromContext(r.Context())
+	visible := make([]core.Note, 0, len(notes))
+	for _, n := range notes {
+		if h.noteRole(p, n).Allows(core.RoleViewer) {
+			visible = append(visible, n)
+		}
+	}
+	return visible
+}
+
+// withPaths fills the notebook breadcrumbs of notes in place.
+func (h *Handler) withPaths(notes ...*core.Note) {
+	for _, n := range notes {
+		if n.NotebookID != 0 {
+			n.Path = h.Notebooks.Path(n.NotebookID)
+		}
+	}
+}
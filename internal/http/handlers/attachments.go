@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"example.com/notes-api/internal/attach"
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/ocr"
+	"example.com/notes-api/internal/transcribe"
+	"github.com/go-chi/chi/v5"
+)
+
+type StartUploadRequest struct {
+	Name        string `json:"name" example:"whiteboard.jpg"`
+	ContentType string `json:"content_type" example:"image/jpeg"`
+	Size        int64  `json:"size" example:"1048576"`
+	// SHA256 is the hex digest of the whole file, checked when the last
+	// chunk arrives.
+	SHA256 string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// ListAttachments godoc
+// @Summary      Вложения заметки
+// @Tags         attachments
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {array}   core.Attachment
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/attachments [get]
+func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadAttachmentNote(w, r, false)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Attachments.List(note.ID))
+}
+
+// GetAttachment godoc
+// @Summary      Скачать вложение
+// @Description  Вложения в карантине (status quarantined) может скачать только админ
+// @Tags         attachments
+// @Produce      application/octet-stream
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Param        aid  path      int     true  "ID вложения"
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string  "Вложение в карантине"
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/attachments/{aid} [get]
+func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadAttachmentNote(w, r, false)
+	if !ok {
+		return
+	}
+	a, ok := h.loadAttachment(w, r, note)
+	if !ok {
+		return
+	}
+	if a.Status == attach.Quarantine && !auth.FromContext(r.Context()).Admin {
+		respondError(w, CodeForbidden, "Attachment is quarantined: "+a.ScanReason)
+		return
+	}
+	data, err := h.Attachments.Open(a.ID)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to read attachment")
+		return
+	}
+
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("ETag", `"`+a.SHA256+`"`)
+	w.Write(data)
+}
+
+// DeleteAttachment godoc
+// @Summary      Удалить вложение
+// @Tags         attachments
+// @Param        id   path  string  true  "ID или публичный UUID"
+// @Param        aid  path  int     true  "ID вложения"
+// @Success      204  "Вложение удалено"
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /notes/{id}/attachments/{aid} [delete]
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadAttachmentNote(w, r, true)
+	if !ok {
+		return
+	}
+	a, ok := h.loadAttachment(w, r, note)
+	if !ok {
+		return
+	}
+	if err := h.Attachments.Delete(a.ID); err != nil {
+		respondError(w, CodeInternal, "Failed to delete attachment")
+		return
+	}
+	if a.Text != "" {
+		h.indexAttachmentText(a, "")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartUpload godoc
+// @Summary      Начать загрузку вложения
+// @Description  Создаёт сессию загрузки. Файл отправляется кусками через PATCH /uploads/{id}; если соединение оборвалось, GET /uploads/{id} скажет, с какого смещения продолжать. Сессия живёт 24 часа
+// @Tags         attachments
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string              true  "ID или публичный UUID"
+// @Param        input  body      StartUploadRequest  true  "Имя, размер и SHA-256 файла"
+// @Success      201    {object}  attach.Upload
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      413    {object}  map[string]string
+// @Failure      423    {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /notes/{id}/uploads [post]
+func (h *Handler) StartUpload(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadAttachmentNote(w, r, true)
+	if !ok {
+		return
+	}
+
+	var req StartUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	ownerID := auth.FromContext(r.Context()).UserID
+	u, err := h.Attachments.StartUpload(note.ID, ownerID, req.Name, req.ContentType, req.Size, req.SHA256)
+	if err != nil {
+		switch err {
+		case attach.ErrTooLarge:
+			respondError(w, CodeTooLarge, "Attachment is too large")
+		default:
+			respondError(w, CodeInvalidRequest, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/uploads/"+u.ID)
+	w.Header().Set("Upload-Offset", "0")
+	respondWithJSON(w, http.StatusCreated, u)
+}
+
+// GetUpload godoc
+// @Summary      Состояние загрузки
+// @Description  Offset и заголовок Upload-Offset — сколько байт уже получено. HEAD отдаёт только заголовок
+// @Tags         attachments
+// @Produce      json
+// @Param        id   path      string  true  "ID загрузки"
+// @Success      200  {object}  attach.Upload
+// @Failure      404  {object}  map[string]string
+// @Router       /uploads/{id} [get]
+func (h *Handler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	respondWithJSON(w, http.StatusOK, u)
+}
+
+// WriteUpload godoc
+// @Summary      Отправить кусок файла
+// @Description  Тело — байты файла начиная со смещения из заголовка Upload-Offset, не больше 16 МиБ за раз. Если смещение не совпадает с полученным, ответ 409 с текущим смещением. Пока файл не получен целиком, ответ 204 с новым Upload-Offset. Последний кусок проверяет SHA-256: при совпадении файл проверяется сканером и вложение создаётся, иначе загрузка отменяется с 422. Подозрительный файл попадает в карантин, опасный (например, исполняемый) отклоняется с 422
+// @Tags         attachments
+// @Accept       application/octet-stream
+// @Produce      json
+// @Param        id             path      string  true  "ID загрузки"
+// @Param        Upload-Offset  header    int     true  "Смещение куска"
+// @Success      201            {object}  core.Attachment
+// @Success      204            "Кусок принят"
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      409            {object}  map[string]string
+// @Failure      413            {object}  map[string]string
+// @Failure      422            {object}  map[string]string
+// @Router       /uploads/{id} [patch]
+func (h *Handler) WriteUpload(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		respondError(w, CodeInvalidRequest, "Upload-Offset header is required")
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, attach.MaxChunkSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, CodeTooLarge, "Chunk is too large")
+		} else {
+			respondError(w, CodeInvalidRequest, "Failed to read chunk")
+		}
+		return
+	}
+
+	u, a, err := h.Attachments.WriteChunk(u.ID, offset, data)
+	var rejected *attach.RejectedError
+	if errors.As(err, &rejected) {
+		respondError(w, CodeUnprocessable, "Attachment rejected: "+rejected.Reason)
+		return
+	}
+	switch err {
+	case nil:
+	case attach.ErrUploadNotFound:
+		respondError(w, CodeNotFound, "Upload not found")
+		return
+	case attach.ErrOffsetMismatch:
+		w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		respondError(w, CodeConflict, "Upload-Offset must be "+strconv.FormatInt(u.Offset, 10))
+		return
+	case attach.ErrTooLarge:
+		respondError(w, CodeTooLarge, "Chunk goes past the declared size")
+		return
+	case attach.ErrChecksumMismatch:
+		respondError(w, CodeUnprocessable, "Uploaded data does not match sha256; start the upload again")
+		return
+	default:
+		respondError(w, CodeInternal, "Failed to store chunk")
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	if a == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/notes/"+strconv.FormatInt(a.NoteID, 10)+"/attachments/"+strconv.FormatInt(a.ID, 10))
+	respondWithJSON(w, http.StatusCreated, a)
+}
+
+// CancelUpload godoc
+// @Summary      Отменить загрузку
+// @Tags         attachments
+// @Param        id   path  string  true  "ID загрузки"
+// @Success      204  "Загрузка отменена"
+// @Failure      404  {object}  map[string]string
+// @Router       /uploads/{id} [delete]
+func (h *Handler) CancelUpload(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	h.Attachments.CancelUpload(u.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReleaseAttachment godoc
+// @Summary      Выпустить вложение из карантина
+// @Description  Для админов. Действие записывается в журнал аудита
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "ID вложения"
+// @Success      200  {object}  core.Attachment
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/attachments/{id}/release [post]
+func (h *Handler) ReleaseAttachment(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if h.Attachments == nil {
+		respondError(w, CodeFeatureDisabled, "Attachments are not enabled")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid attachment ID")
+		return
+	}
+	a, err := h.Attachments.Release(id, auth.FromContext(r.Context()).UserID)
+	if err != nil {
+		respondError(w, CodeNotFound, "Attachment not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, a)
+}
+
+// RecognizeAttachment queues OCR of image attachments and transcription
+// of audio attachments, and indexes the text found with their note. Set it
+// as Attachments.OnReady.
+func (h *Handler) RecognizeAttachment(a core.Attachment) {
+	contentType, _, _ := mime.ParseMediaType(a.ContentType)
+	var kind string
+	var recognize func(ctx context.Context, name string, data []byte) (string, error)
+	switch {
+	case h.OCR != nil && ocr.Images(contentType):
+		kind, recognize = "ocr", h.OCR.Recognize
+	case h.Transcriber != nil && transcribe.Audio(contentType):
+		kind, recognize = "transcription", h.Transcriber.Transcribe
+	default:
+		return
+	}
+	if h.Jobs == nil {
+		return
+	}
+
+	_, err := h.Jobs.Submit(kind, a.OwnerID, func(ctx context.Context, p *jobs.Progress) (*jobs.Result, error) {
+		p.SetTotal(1)
+		data, err := h.Attachments.Open(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		text, err := recognize(ctx, a.Name, data)
+		if err != nil {
+			return nil, err
+		}
+		// The attachment may have been deleted while it was recognized.
+		if _, err := h.Attachments.SetText(a.ID, text); err != nil {
+			return nil, err
+		}
+		h.indexAttachmentText(a, text)
+		p.Step()
+		return nil, nil
+	})
+	if err != nil {
+		log.Printf("%s: attachment %d: %v", kind, a.ID, err)
+	}
+}
+
+// indexAttachmentText makes text found in a searchable with its note.
+// Cached lists and list validators change with it, since searches may
+// now match differently.
+func (h *Handler) indexAttachmentText(a core.Attachment, text string) {
+	if h.Search == nil {
+		return
+	}
+	h.Search.SetAttachmentText(a.NoteID, a.ID, text)
+	if h.ListCache != nil {
+		h.ListCache.Flush()
+	}
+	if h.LastModified != nil {
+		h.LastModified.Touch()
+	}
+}
+
+// loadAttachmentNote loads the note named by the {id} URL parameter for
+// its attachments, requiring edit rights when write is set.
+func (h *Handler) loadAttachmentNote(w http.ResponseWriter, r *http.Request, write bool) (*core.Note, bool) {
+	if h.Attachments == nil {
+		respondError(w, CodeFeatureDisabled, "Attachments are not enabled")
+		return nil, false
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return nil, false
+	}
+	if write && !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return nil, false
+	}
+	if write && !h.unlocked(w, r, *note) {
+		return nil, false
+	}
+	return note, true
+}
+
+// loadAttachment fetches the attachment named by the {aid} URL parameter,
+// which must belong to note.
+func (h *Handler) loadAttachment(w http.ResponseWriter, r *http.Request, note *core.Note) (core.Attachment, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "aid"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid attachment ID")
+		return core.Attachment{}, false
+	}
+	a, err := h.Attachments.Get(id)
+	if err != nil || a.NoteID != note.ID {
+		respondError(w, CodeNotFound, "Attachment not found")
+		return core.Attachment{}, false
+	}
+	return a, true
+}
+
+// loadUpload fetches the upload named by the {id} URL parameter. Only the
+// user who started an upload can see it.
+func (h *Handler) loadUpload(w http.ResponseWriter, r *http.Request) (attach.Upload, bool) {
+	if h.Attachments == nil {
+		respondError(w, CodeFeatureDisabled, "Attachments are not enabled")
+		return attach.Upload{}, false
+	}
+	u, err := h.Attachments.Upload(chi.URLParam(r, "id"))
+	if err != nil || u.OwnerID != auth.FromContext(r.Context()).UserID {
+		respondError(w, CodeNotFound, "Upload not found")
+		return attach.Upload{}, false
+	}
+	return u, true
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// ListAudit godoc
+// @Summary      Журнал аудита
+// @Description  Для админов: события безопасности, например отклонённые и помещённые в карантин вложения, от старых к новым. Чтобы читать дальше, передайте after — ID последней полученной записи
+// @Tags         admin
+// @Produce      json
+// @Param        after  query     int  false  "Записи после этого ID"
+// @Param        limit  query     int  false  "Сколько записей (по умолчанию 100, не больше 1000)"
+// @Success      200    {array}   audit.Entry
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /admin/audit [get]
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if h.Audit == nil {
+		respondError(w, CodeFeatureDisabled, "Audit log is not enabled")
+		return
+	}
+
+	q := r.URL.Query()
+	var after int64
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			respondError(w, CodeInvalidRequest, "Invalid after")
+			return
+		}
+		after = n
+	}
+	limit := defaultAuditLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			respondError(w, CodeInvalidRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+	respondWithJSON(w, http.StatusOK, h.Audit.List(after, limit))
+}
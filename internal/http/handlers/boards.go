@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"github.com/go-chi/chi/v5"
+)
+
+// boardTagPrefix marks boards grouped by a tag namespace: on the board
+// tag:status, a note tagged status/doing is in the doing column.
+const boardTagPrefix = "tag:"
+
+type BoardColumn struct {
+	// Value is the property value or tag of the column; cards without one
+	// are in a last column with an empty value.
+	Value string      `json:"value" example:"doing"`
+	Cards []core.Note `json:"cards"`
+}
+
+type Board struct {
+	Property string        `json:"property" example:"status"`
+	Columns  []BoardColumn `json:"columns"`
+}
+
+type MoveCardRequest struct {
+	Column string `json:"column" example:"done"`
+	// BeforeID places the card above another card of the column in the same
+	// notebook; without it the card keeps its position.
+	BeforeID int64 `json:"before_id,omitempty" example:"7"`
+}
+
+// GetBoard godoc
+// @Summary      Канбан-доска
+// @Description  Группирует заметки в колонки по значению свойства (status) или по тегам пространства (tag:status → status/todo). Карточки в колонке упорядочены по position
+// @Tags         boards
+// @Produce      json
+// @Param        property  path   string  true   "Свойство или tag:<пространство>"  example(status)
+// @Param        columns   query  string  false  "Порядок колонок через запятую; пустые колонки тоже выводятся"  example(todo,doing,done)
+// @Param        notebook_id  query  int  false  "Только заметки блокнота"
+// @Success      200  {object}  Board
+// @Failure      400  {object}  map[string]string
+// @Router       /boards/{property} [get]
+func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	property := chi.URLParam(r, "property")
+
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+	notes, err := h.listNotes(r, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+
+	var order []string
+	columns := make(map[string][]core.Note)
+	if v := r.URL.Query().Get("columns"); v != "" {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" && columns[c] == nil {
+				order = append(order, c)
+				columns[c] = []core.Note{}
+			}
+		}
+	}
+	listed := len(order)
+
+	for _, n := range notes {
+		h.withPaths(&n)
+		c := boardColumn(n, property)
+		if _, ok := columns[c]; !ok {
+			order = append(order, c)
+		}
+		columns[c] = append(columns[c], n)
+	}
+
+	// Unlisted columns follow the listed ones alphabetically, and cards
+	// without a value come last.
+	extra := order[listed:]
+	sort.Slice(extra, func(i, j int) bool {
+		if extra[i] == "" || extra[j] == "" {
+			return extra[j] == ""
+		}
+		return extra[i] < extra[j]
+	})
+
+	board := Board{Property: property, Columns: make([]BoardColumn, 0, len(order))}
+	for _, c := range order {
+		core.SortByPosition(columns[c])
+		board.Columns = append(board.Columns, BoardColumn{Value: c, Cards: columns[c]})
+	}
+
+	respondWithJSON(w, http.StatusOK, board)
+}
+
+// MoveCard godoc
+// @Summary      Переместить карточку
+// @Description  Меняет значение свойства (или тег) заметки на колонку и при before_id ставит карточку перед указанной
+// @Tags         boards
+// @Accept       json
+// @Produce      json
+// @Param        property  path  string           true  "Свойство или tag:<пространство>"
+// @Param        id        path  string           true  "ID или публичный UUID"
+// @Param        input     body  MoveCardRequest  true  "Колонка и позиция"
+// @Success      200  {object}  core.Note
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /boards/{property}/cards/{id} [patch]
+func (h *Handler) MoveCard(w http.ResponseWriter, r *http.Request) {
+	property := chi.URLParam(r, "property")
+
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if !h.unlocked(w, r, *note) {
+		return
+	}
+
+	var req MoveCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	req.Column = strings.TrimSpace(req.Column)
+
+	updates := make(map[string]interface{})
+	if prefix, ok := strings.CutPrefix(property, boardTagPrefix); ok {
+		prefix = core.NormalizeTag(prefix)
+		if prefix == "" {
+			respondError(w, CodeInvalidRequest, "Invalid tag namespace")
+			return
+		}
+		tags := make([]string, 0, len(note.Tags)+1)
+		for _, t := range note.Tags {
+			if !strings.HasPrefix(t, prefix+core.TagSeparator) {
+				tags = append(tags, t)
+			}
+		}
+		if req.Column != "" {
+			tags = append(tags, prefix+core.TagSeparator+req.Column)
+		}
+		updates["tags"] = core.NormalizeTags(tags)
+	} else {
+		patch := map[string]interface{}{property: columnValue(note.Properties[property], req.Column)}
+		if err := core.ValidateProperties(patch, true); err != nil {
+			respondError(w, CodeInvalidRequest, err.Error())
+			return
+		}
+		if note.CollectionID != 0 && !h.checkCollection(w, r, note.CollectionID, core.MergeProperties(note.Properties, patch)) {
+			return
+		}
+		updates["properties"] = patch
+	}
+
+	if err := h.updateNote(auth.FromContext(r.Context()).UserID, note.ID, updates); err != nil {
+		respondErr(w, err, "Failed to move card")
+		return
+	}
+
+	if req.BeforeID != 0 && !h.placeCard(w, r, note, property, req) {
+		return
+	}
+
+	moved, err := h.Repo.GetByID(note.ID)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve moved card")
+		return
+	}
+	h.withPaths(moved)
+	respondWithJSON(w, http.StatusOK, moved)
+}
+
+// placeCard reorders the cards of the target column in the card's
+// notebook so that the card sits right above BeforeID.
+func (h *Handler) placeCard(w http.ResponseWriter, r *http.Request, card *core.Note, property string, req MoveCardRequest) bool {
+	before, err := h.Repo.GetByID(req.BeforeID)
+	if err != nil || !h.canRead(r, *before) || before.NotebookID != card.NotebookID || boardColumn(*before, property) != req.Column {
+		respondError(w, CodeInvalidRequest, "before_id must be a card of the column in the same notebook")
+		return false
+	}
+
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to move card")
+		return false
+	}
+	column := make([]core.Note, 0)
+	for _, n := range h.readable(r, notes) {
+		if n.NotebookID == card.NotebookID && n.ID != card.ID && boardColumn(n, property) == req.Column {
+			column = append(column, n)
+		}
+	}
+	core.SortByPosition(column)
+
+	ids := make([]int64, 0, len(column)+1)
+	for _, n := range column {
+		if n.ID == before.ID {
+			ids = append(ids, card.ID)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	if !h.canReorder(r, card.NotebookID, ids) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return false
+	}
+	reorderer, ok := noteStore[noteReorderer](h, w, "Moving cards")
+	if !ok {
+		return false
+	}
+	if _, err := reorderer.Reorder(card.NotebookID, ids); err != nil {
+		respondError(w, CodeInternal, "Failed to move card")
+		return false
+	}
+	return true
+}
+
+// boardColumn returns the column of a note on the board of property.
+func boardColumn(n core.Note, property string) string {
+	if prefix, ok := strings.CutPrefix(property, boardTagPrefix); ok {
+		prefix = core.NormalizeTag(prefix) + core.TagSeparator
+		for _, t := range n.Tags {
+			if rest, ok := strings.CutPrefix(t, prefix); ok {
+				return rest
+			}
+		}
+		return ""
+	}
+
+	switch v := n.Properties[property].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// columnValue converts a column back to a property value of the same type
+// as the current one, or nil for the empty column.
+func columnValue(current interface{}, column string) interface{} {
+	if column == "" {
+		return nil
+	}
+	switch current.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(column, 64); err == nil {
+			return f
+		}
+	case bool:
+		if b, err := strconv.ParseBool(column); err == nil {
+			return b
+		}
+	}
+	return column
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// CDCRecord is one line of the change stream. Op is "snapshot" for the
+// notes that exist when a stream starts without since, "heartbeat" for
+// keep-alives carrying the last sequence number, or a repo change op.
+type CDCRecord struct {
+	Seq    int64      `json:"seq" example:"42"`
+	Op     string     `json:"op" example:"updated"`
+	NoteID int64      `json:"note_id,omitempty" example:"1"`
+	Note   *core.Note `json:"note,omitempty"`
+	At     time.Time  `json:"at"`
+}
+
+// StreamCDC godoc
+// @Summary      Поток изменений для репликации (CDC)
+// @Description  NDJSON: снимок всех заметок, затем все изменения по порядку с номерами seq. С параметром since поток возобновляется после указанного номера без снимка
+// @Tags         admin
+// @Produce      application/x-ndjson
+// @Param        since  query    int  false  "Номер последнего полученного изменения"
+// @Success      200    {object} CDCRecord
+// @Failure      400    {object} map[string]string
+// @Failure      403    {object} map[string]string
+// @Failure      404    {object} map[string]string
+// @Failure      410    {object} map[string]string
+// @Router       /admin/cdc [get]
+func (h *Handler) StreamCDC(w http.ResponseWriter, r *http.Request) {
+	if h.CDC == nil {
+		respondError(w, CodeFeatureDisabled, "Change stream is not enabled")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, CodeInternal, "Streaming unsupported")
+		return
+	}
+
+	var snapshot []core.Note
+	var seq int64
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if seq, err = strconv.ParseInt(v, 10, 64); err != nil || seq < 0 {
+			respondError(w, CodeInvalidRequest, "Invalid since")
+			return
+		}
+		if _, _, ok := h.CDC.Since(seq); !ok {
+			respondError(w, CodeGone, "Changes since "+v+" are no longer retained; restart without since")
+			return
+		}
+	} else {
+		snapshotter, ok := noteStore[noteSnapshotter](h, w, "Change stream")
+		if !ok {
+			return
+		}
+		snapshot, seq = snapshotter.Snapshot()
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	now := time.Now()
+	for i := range snapshot {
+		enc.Encode(CDCRecord{Seq: seq, Op: "snapshot", NoteID: snapshot[i].ID, Note: &snapshot[i], At: now})
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		changes, wait, ok := h.CDC.Since(seq)
+		if !ok {
+			// The consumer fell too far behind; it has to resume or resync.
+			return
+		}
+		for _, c := range changes {
+			if err := enc.Encode(CDCRecord{Seq: c.Seq, Op: c.Op, NoteID: c.Note.ID, Note: &c.Note, At: c.At}); err != nil {
+				return
+			}
+			seq = c.Seq
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			enc.Encode(CDCRecord{Seq: seq, Op: "heartbeat", At: time.Now()})
+			flusher.Flush()
+		case <-wait:
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// changeFields maps the fields of a change request, as named in
+// PATCH /notes/{id}, to those of core.Note. Content and blocks are shown
+// as a line diff instead.
+var changeFields = map[string]func(n core.Note) interface{}{
+	"title":         func(n core.Note) interface{} { return n.Title },
+	"language":      func(n core.Note) interface{} { return n.Language },
+	"latitude":      func(n core.Note) interface{} { return n.Latitude },
+	"longitude":     func(n core.Note) interface{} { return n.Longitude },
+	"tags":          func(n core.Note) interface{} { return n.Tags },
+	"pinned":        func(n core.Note) interface{} { return n.Pinned },
+	"remind_at":     func(n core.Note) interface{} { return n.RemindAt },
+	"color":         func(n core.Note) interface{} { return n.Color },
+	"properties":    func(n core.Note) interface{} { return n.Properties },
+	"collection_id": func(n core.Note) interface{} { return n.CollectionID },
+}
+
+type RejectChangeRequest struct {
+	Reason string `json:"reason,omitempty" example:"Дата уже согласована"`
+}
+
+// ChangeDiff shows what approving a change request would do to the note as
+// it is now.
+type ChangeDiff struct {
+	ChangeRequest core.ChangeRequest `json:"change_request"`
+	Fields        []FieldChange      `json:"fields"`
+	// Content is the line diff of the content, empty when the request
+	// leaves it alone.
+	Content []core.DiffLine `json:"content"`
+}
+
+// FieldChange is a field of the note before and after a change request.
+type FieldChange struct {
+	Field string      `json:"field" example:"title"`
+	Old   interface{} `json:"old" example:"Планы"`
+	New   interface{} `json:"new" example:"Планы на неделю"`
+}
+
+// requestChange answers an edit of note by a member of a notebook that
+// requires approval: the edit is kept as a change request for the owner.
+func (h *Handler) requestChange(w http.ResponseWriter, r *http.Request, note core.Note, updates map[string]interface{}) {
+	author := auth.FromContext(r.Context()).UserID
+	cr := h.ChangeRequests.Create(core.ChangeRequest{
+		NoteID:     note.ID,
+		NotebookID: note.NotebookID,
+		AuthorID:   author,
+		Changes:    updates,
+	})
+	if nb, err := h.Notebooks.GetByID(note.NotebookID); err == nil && h.Notifications != nil {
+		h.Notifications.Add(nb.OwnerID, notify.Notification{
+			Type:     notify.ChangeRequested,
+			NoteID:   note.ID,
+			ChangeID: cr.ID,
+			Title:    note.Title,
+			Actor:    author,
+			At:       cr.CreatedAt,
+		})
+	}
+	w.Header().Set("Location", "/api/v1/change-requests/"+strconv.FormatInt(cr.ID, 10))
+	respondWithJSON(w, http.StatusAccepted, cr)
+}
+
+// ListChangeRequests godoc
+// @Summary      Запросы на изменение
+// @Description  Запросы, которые пользователь создал или может одобрить как владелец блокнота, сначала новые
+// @Tags         change-requests
+// @Produce      json
+// @Param        status       query  string  false  "Только с этим статусом" Enums(pending, approved, rejected)
+// @Param        notebook_id  query  int     false  "Только запросы к заметкам блокнота"
+// @Success      200  {array}   core.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /change-requests [get]
+func (h *Handler) ListChangeRequests(w http.ResponseWriter, r *http.Request) {
+	if !h.changeRequestsEnabled(w) {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", core.ChangePending, core.ChangeApproved, core.ChangeRejected:
+	default:
+		respondError(w, CodeInvalidRequest, "Invalid status")
+		return
+	}
+	var notebookID int64
+	if v := r.URL.Query().Get("notebook_id"); v != "" {
+		var err error
+		if notebookID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid notebook ID")
+			return
+		}
+	}
+
+	p := auth.FromContext(r.Context())
+	list := h.ChangeRequests.List(func(cr core.ChangeRequest) bool {
+		return (status == "" || cr.Status == status) &&
+			(notebookID == 0 || cr.NotebookID == notebookID) &&
+			(cr.AuthorID == p.UserID || h.decides(p, cr))
+	})
+	if list == nil {
+		list = []core.ChangeRequest{}
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetChangeRequest godoc
+// @Summary      Получить запрос на изменение
+// @Tags         change-requests
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /change-requests/{id} [get]
+func (h *Handler) GetChangeRequest(w http.ResponseWriter, r *http.Request) {
+	cr, ok := h.loadChangeRequest(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, cr)
+}
+
+// GetChangeDiff godoc
+// @Summary      Что изменит запрос
+// @Description  Сравнивает заметку, какая она сейчас, с той, какой она станет после одобрения: изменённые поля до и после и построчный diff текста
+// @Tags         change-requests
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  ChangeDiff
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /change-requests/{id}/diff [get]
+func (h *Handler) GetChangeDiff(w http.ResponseWriter, r *http.Request) {
+	cr, ok := h.loadChangeRequest(w, r)
+	if !ok {
+		return
+	}
+	note, err := h.Repo.GetByID(cr.NoteID)
+	if err != nil {
+		respondErr(w, err, "Failed to get note")
+		return
+	}
+	previewer, ok := noteStore[notePreviewer](h, w, "Change request diffs")
+	if !ok {
+		return
+	}
+	after, err := previewer.PreviewUpdate(cr.NoteID, cr.Changes)
+	if err != nil {
+		respondErr(w, err, "Failed to get note")
+		return
+	}
+
+	diff := ChangeDiff{ChangeRequest: *cr, Fields: []FieldChange{}, Content: []core.DiffLine{}}
+	fields := make([]string, 0, len(cr.Changes))
+	for field := range cr.Changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if get, ok := changeFields[field]; ok {
+			diff.Fields = append(diff.Fields, FieldChange{Field: field, Old: get(*note), New: get(*after)})
+		}
+	}
+	if note.Content != after.Content {
+		diff.Content = core.LineDiff(note.Content, after.Content)
+	}
+	respondWithJSON(w, http.StatusOK, diff)
+}
+
+// ApproveChangeRequest godoc
+// @Summary      Одобрить запрос на изменение
+// @Description  Только владелец блокнота. Изменения применяются к заметке, какая она сейчас
+// @Tags         change-requests
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string  "Запрос уже рассмотрен или заголовок повторяется (duplicate_title)"
+// @Failure      423  {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /change-requests/{id}/approve [post]
+func (h *Handler) ApproveChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChange(w, r, core.ChangeApproved, "")
+}
+
+// RejectChangeRequest godoc
+// @Summary      Отклонить запрос на изменение
+// @Description  Только владелец блокнота; reason увидит автор запроса
+// @Tags         change-requests
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                  true   "ID"
+// @Param        input  body      RejectChangeRequest  false  "Причина"
+// @Success      200    {object}  core.ChangeRequest
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "Запрос уже рассмотрен"
+// @Router       /change-requests/{id}/reject [post]
+func (h *Handler) RejectChangeRequest(w http.ResponseWriter, r *http.Request) {
+	var req RejectChangeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, CodeInvalidJSON, "Invalid JSON")
+			return
+		}
+	}
+	h.decideChange(w, r, core.ChangeRejected, strings.TrimSpace(req.Reason))
+}
+
+func (h *Handler) decideChange(w http.ResponseWriter, r *http.Request, status, reason string) {
+	cr, ok := h.loadChangeRequest(w, r)
+	if !ok {
+		return
+	}
+	p := auth.FromContext(r.Context())
+	if !h.decides(p, *cr) {
+		respondError(w, CodeForbidden, "Only the owner of the notebook can decide")
+		return
+	}
+
+	var apply func(core.ChangeRequest) error
+	if status == core.ChangeApproved {
+		apply = func(cr core.ChangeRequest) error {
+			return h.updateNote(p.UserID, cr.NoteID, cr.Changes)
+		}
+	}
+	decided, err := h.ChangeRequests.Decide(cr.ID, status, p.UserID, reason, apply)
+	if err != nil {
+		respondErr(w, err, "Failed to decide change request")
+		return
+	}
+
+	typ := notify.ChangeRejected
+	if status == core.ChangeApproved {
+		typ = notify.ChangeApproved
+	}
+	if note, err := h.Repo.GetByID(decided.NoteID); err == nil {
+		if status == core.ChangeApproved {
+			h.notifyWatchers(r, *note, notify.NoteUpdated)
+		}
+		if h.Notifications != nil && decided.AuthorID != p.UserID {
+			h.Notifications.Add(decided.AuthorID, notify.Notification{
+				Type:     typ,
+				NoteID:   note.ID,
+				ChangeID: decided.ID,
+				Title:    note.Title,
+				Actor:    p.UserID,
+				At:       *decided.DecidedAt,
+			})
+		}
+	}
+	respondWithJSON(w, http.StatusOK, decided)
+}
+
+// decides reports whether p approves and rejects cr: the owners of its
+// notebook do.
+func (h *Handler) decides(p core.Principal, cr core.ChangeRequest) bool {
+	return h.Notebooks.Role(p, cr.NotebookID).Allows(core.RoleOwner)
+}
+
+func (h *Handler) changeRequestsEnabled(w http.ResponseWriter) bool {
+	if h.ChangeRequests == nil {
+		respondError(w, CodeFeatureDisabled, "Change requests are not enabled")
+		return false
+	}
+	return true
+}
+
+// loadChangeRequest returns the change request of the id URL parameter,
+// if the caller wrote it or decides it.
+func (h *Handler) loadChangeRequest(w http.ResponseWriter, r *http.Request) (*core.ChangeRequest, bool) {
+	if !h.changeRequestsEnabled(w) {
+		return nil, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid change request ID")
+		return nil, false
+	}
+	p := auth.FromContext(r.Context())
+	cr, err := h.ChangeRequests.GetByID(id)
+	if err == nil && cr.AuthorID != p.UserID && !h.decides(p, *cr) {
+		err = repo.ErrChangeRequestNotFound
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to get change request")
+		return nil, false
+	}
+	return cr, true
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/clip"
+	"example.com/notes-api/internal/core"
+)
+
+// maxClipSize bounds the page read when clipping, and the HTML a client
+// may send instead.
+const maxClipSize = 5 << 20
+
+type ClipRequest struct {
+	URL string `json:"url" example:"https://go.dev/blog/go1.22"`
+	// HTML is the page as the browser rendered it. When set the server
+	// does not fetch URL, so pages behind a login can be clipped too.
+	HTML       string   `json:"html,omitempty"`
+	Title      string   `json:"title,omitempty" example:"Go 1.22 is released"`
+	Tags       []string `json:"tags,omitempty" example:"go,reading"`
+	NotebookID int64    `json:"notebook_id,omitempty" example:"1"`
+}
+
+// ClipPage godoc
+// @Summary      Сохранить веб-страницу как заметку
+// @Description  Бэкенд для расширения браузера. Сервер загружает страницу по url (только публичные адреса) или берёт переданный html, выделяет основной текст без меню, рекламы и комментариев и сохраняет его в Markdown. Адрес страницы сохраняется в SourceURL. Заголовок по умолчанию берётся со страницы
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        input  body      ClipRequest  true  "Страница"
+// @Success      201    {object}  core.Note
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      422    {object}  map[string]string
+// @Failure      502    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Router       /clip [post]
+func (h *Handler) ClipPage(w http.ResponseWriter, r *http.Request) {
+	if h.Clipper == nil {
+		respondError(w, CodeFeatureDisabled, "Clipping is not enabled")
+		return
+	}
+
+	var req ClipRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxClipSize+64<<10)).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	source, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") || source.Host == "" {
+		respondError(w, CodeInvalidRequest, "url must be an http(s) URL")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	notebookID := req.NotebookID
+	if notebookID == 0 {
+		notebookID = h.defaultNotebook(r)
+	}
+	if notebookID != 0 {
+		if _, err := h.Notebooks.GetByID(notebookID); err != nil {
+			respondError(w, CodeInvalidRequest, "Notebook not found")
+			return
+		}
+		if !h.Notebooks.Role(p, notebookID).Allows(core.RoleEditor) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return
+		}
+	}
+
+	page, base := []byte(req.HTML), source
+	if req.HTML == "" {
+		page, base, err = h.Clipper.Read(r.Context(), source.String(), maxClipSize)
+		if err != nil {
+			respondError(w, CodeUpstreamFailed, "Failed to fetch page")
+			return
+		}
+	}
+	article, err := clip.Extract(bytes.NewReader(page), base)
+	if errors.Is(err, clip.ErrNoContent) {
+		respondError(w, CodeUnprocessable, "No readable content found")
+		return
+	}
+	if err != nil {
+		respondError(w, CodeUnprocessable, "Failed to parse page")
+		return
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = article.Title
+	}
+	if title == "" {
+		title = source.Host
+	}
+
+	n := core.Note{
+		OwnerID:    p.UserID,
+		NotebookID: notebookID,
+		Type:       core.NoteTypeNote,
+		Title:      title,
+		Content:    article.Content,
+		Tags:       core.NormalizeTags(req.Tags),
+		SourceURL:  source.String(),
+	}
+	if err := h.applyNotebookDefaults(&n); err != nil {
+		respondErr(w, err, "Failed to apply notebook settings")
+		return
+	}
+	id, err := h.Repo.Create(n)
+	if err != nil {
+		respondErr(w, err, "Failed to create note")
+		return
+	}
+
+	note, err := h.Repo.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve created note")
+		return
+	}
+	h.withPaths(note)
+	respondWithJSON(w, http.StatusCreated, note)
+}
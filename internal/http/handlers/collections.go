@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateCollection godoc
+// @Summary      Создать коллекцию
+// @Description  Коллекция задаёт схему свойств (типы и обязательность), которой должны соответствовать её заметки
+// @Tags         collections
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.CollectionCreate  true  "Название и схема"
+// @Success      201    {object}  core.Collection
+// @Failure      400    {object}  map[string]string
+// @Router       /collections [post]
+func (h *Handler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	var input core.CollectionCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		respondError(w, CodeInvalidRequest, "Name is required")
+		return
+	}
+	if err := core.ValidateSchema(input.Schema); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	id, err := h.Collections.Create(core.Collection{Name: name, OwnerID: p.UserID, Schema: input.Schema})
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to create collection")
+		return
+	}
+
+	h.respondCollection(w, http.StatusCreated, id)
+}
+
+// ListCollections godoc
+// @Summary      Список коллекций
+// @Tags         collections
+// @Produce      json
+// @Success      200  {array}   core.Collection
+// @Failure      500  {object}  map[string]string
+// @Router       /collections [get]
+func (h *Handler) ListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := h.Collections.GetAll()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get collections")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	visible := make([]core.Collection, 0, len(collections))
+	for _, c := range collections {
+		if p.Admin || c.OwnerID == p.UserID {
+			visible = append(visible, c)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, visible)
+}
+
+// GetCollection godoc
+// @Summary      Получить коллекцию
+// @Tags         collections
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.Collection
+// @Failure      404  {object}  map[string]string
+// @Router       /collections/{id} [get]
+func (h *Handler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCollection(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+// PatchCollection godoc
+// @Summary      Изменить коллекцию
+// @Description  Новая схема проверяется при следующем изменении заметок; существующие заметки не перепроверяются
+// @Tags         collections
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                    true  "ID"
+// @Param        input  body      core.CollectionUpdate  true  "Поля для обновления"
+// @Success      200    {object}  core.Collection
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /collections/{id} [patch]
+func (h *Handler) PatchCollection(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCollection(w, r)
+	if !ok {
+		return
+	}
+
+	var update core.CollectionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if update.Name == nil && update.Schema == nil {
+		respondError(w, CodeInvalidRequest, "No fields to update")
+		return
+	}
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			respondError(w, CodeInvalidRequest, "Name cannot be empty")
+			return
+		}
+		update.Name = &name
+	}
+	if update.Schema != nil {
+		if err := core.ValidateSchema(*update.Schema); err != nil {
+			respondError(w, CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.Collections.Update(c.ID, update.Name, update.Schema); err != nil {
+		respondErr(w, err, "Failed to update collection")
+		return
+	}
+
+	h.respondCollection(w, http.StatusOK, c.ID)
+}
+
+// DeleteCollection godoc
+// @Summary      Удалить коллекцию
+// @Description  Удалить можно только коллекцию без заметок
+// @Tags         collections
+// @Param        id   path  int  true  "ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /collections/{id} [delete]
+func (h *Handler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCollection(w, r)
+	if !ok {
+		return
+	}
+
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to delete collection")
+		return
+	}
+	for _, n := range notes {
+		if n.CollectionID == c.ID {
+			respondError(w, CodeConflict, "Collection is not empty")
+			return
+		}
+	}
+
+	if err := h.Collections.Delete(c.ID); err != nil {
+		respondErr(w, err, "Failed to delete collection")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListCollectionNotes godoc
+// @Summary      Заметки коллекции
+// @Description  Представление коллекции: её заметки с теми же фильтрами, что и у списка заметок, например prop.status=done
+// @Tags         collections
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {array}   core.Note
+// @Failure      404  {object}  map[string]string
+// @Router       /collections/{id}/notes [get]
+func (h *Handler) ListCollectionNotes(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCollection(w, r)
+	if !ok {
+		return
+	}
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+
+	notes, err := h.listNotes(r, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+
+	filtered := notes[:0]
+	for _, n := range notes {
+		if n.CollectionID == c.ID {
+			h.withPaths(&n)
+			filtered = append(filtered, n)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, filtered)
+}
+
+// checkCollection verifies the caller may put notes into the collection
+// and that props satisfy its schema.
+func (h *Handler) checkCollection(w http.ResponseWriter, r *http.Request, id int64, props map[string]interface{}) bool {
+	c, err := h.Collections.GetByID(id)
+	p := auth.FromContext(r.Context())
+	if err != nil || !(p.Admin || c.OwnerID == p.UserID) {
+		respondError(w, CodeInvalidRequest, "Collection not found")
+		return false
+	}
+	if err := c.Check(props); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// loadCollection fetches the collection named by the {id} URL parameter.
+// Collections of other users are reported as missing.
+func (h *Handler) loadCollection(w http.ResponseWriter, r *http.Request) (*core.Collection, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid collection ID")
+		return nil, false
+	}
+
+	c, err := h.Collections.GetByID(id)
+	if err != nil {
+		respondErr(w, err, "Failed to get collection")
+		return nil, false
+	}
+
+	p := auth.FromContext(r.Context())
+	if !p.Admin && c.OwnerID != p.UserID {
+		respondError(w, CodeCollectionNotFound, "Collection not found")
+		return nil, false
+	}
+
+	return c, true
+}
+
+func (h *Handler) respondCollection(w http.ResponseWriter, code int, id int64) {
+	c, err := h.Collections.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve collection")
+		return
+	}
+	respondWithJSON(w, code, c)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+type CreateCommentRequest struct {
+	Body string `json:"body" example:"Может, перенести на четверг?"`
+	// ReplyTo answers a comment in its thread; zero starts a thread.
+	ReplyTo int64 `json:"reply_to,omitempty" example:"0"`
+}
+
+type UpdateCommentRequest struct {
+	// Body edits the comment; only its author can.
+	Body *string `json:"body,omitempty"`
+	// Resolved resolves or reopens a thread; the author of the thread and
+	// editors of the note can.
+	Resolved *bool `json:"resolved,omitempty"`
+}
+
+// CommentThread is the first comment of a thread with its replies, oldest
+// first.
+type CommentThread struct {
+	core.Comment
+	Replies []core.Comment `json:"replies"`
+}
+
+// ListComments godoc
+// @Summary      Обсуждения заметки
+// @Description  Ветки комментариев с ответами, сначала старые
+// @Tags         comments
+// @Produce      json
+// @Param        id        path      string  true   "ID или публичный UUID"
+// @Param        resolved  query     bool    false  "Только решённые (true) или нерешённые (false) ветки"
+// @Success      200       {array}   CommentThread
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /notes/{id}/comments [get]
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadCommentNote(w, r)
+	if !ok {
+		return
+	}
+	var resolved *bool
+	if v := r.URL.Query().Get("resolved"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid resolved")
+			return
+		}
+		resolved = &b
+	}
+
+	threads := []CommentThread{}
+	index := make(map[int64]int)
+	for _, c := range h.Comments.ListByNote(note.ID) {
+		if c.ReplyTo == 0 {
+			index[c.ID] = len(threads)
+			threads = append(threads, CommentThread{Comment: c, Replies: []core.Comment{}})
+		} else if i, ok := index[c.ReplyTo]; ok {
+			threads[i].Replies = append(threads[i].Replies, c)
+		}
+	}
+	if resolved != nil {
+		kept := threads[:0]
+		for _, t := range threads {
+			if t.Resolved == *resolved {
+				kept = append(kept, t)
+			}
+		}
+		threads = kept
+	}
+	respondWithJSON(w, http.StatusOK, threads)
+}
+
+// CreateComment godoc
+// @Summary      Прокомментировать заметку
+// @Description  Комментировать может любой, кто видит заметку. reply_to отвечает в ветке комментария; ответ на ответ попадает в ту же ветку
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string                true  "ID или публичный UUID"
+// @Param        input  body      CreateCommentRequest  true  "Комментарий"
+// @Success      201    {object}  core.Comment
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notes/{id}/comments [post]
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadCommentNote(w, r)
+	if !ok {
+		return
+	}
+	var req CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		respondErr(w, core.Invalid("body", "body is required"), "Failed to add comment")
+		return
+	}
+
+	c, err := h.Comments.Create(core.Comment{
+		NoteID:   note.ID,
+		AuthorID: auth.FromContext(r.Context()).UserID,
+		Body:     body,
+		ReplyTo:  req.ReplyTo,
+	})
+	if err != nil {
+		respondErr(w, err, "Failed to add comment")
+		return
+	}
+	h.notifyWatchers(r, *note, notify.CommentAdded)
+	respondWithJSON(w, http.StatusCreated, c)
+}
+
+// UpdateComment godoc
+// @Summary      Изменить комментарий или решить ветку
+// @Description  Текст меняет только автор. resolved ставится на первый комментарий ветки; решить или переоткрыть ветку может её автор и редакторы заметки
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string                true  "ID или публичный UUID"
+// @Param        cid    path      int                   true  "ID комментария"
+// @Param        input  body      UpdateCommentRequest  true  "Поля для обновления"
+// @Success      200    {object}  core.Comment
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notes/{id}/comments/{cid} [patch]
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadCommentNote(w, r)
+	if !ok {
+		return
+	}
+	c, ok := h.loadComment(w, r, note)
+	if !ok {
+		return
+	}
+	var req UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	if req.Body == nil && req.Resolved == nil {
+		respondError(w, CodeInvalidRequest, "No fields to update")
+		return
+	}
+
+	userID := auth.FromContext(r.Context()).UserID
+	if req.Body != nil && c.AuthorID != userID {
+		respondError(w, CodeForbidden, "Only the author can edit a comment")
+		return
+	}
+	if req.Resolved != nil && c.AuthorID != userID && !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Only the author of the thread and editors can resolve it")
+		return
+	}
+	var body string
+	if req.Body != nil {
+		if body = strings.TrimSpace(*req.Body); body == "" {
+			respondErr(w, core.Invalid("body", "body is required"), "Failed to update comment")
+			return
+		}
+	}
+
+	var err error
+	updated := *c
+	if req.Resolved != nil {
+		if updated, err = h.Comments.Resolve(c.ID, *req.Resolved, userID); err != nil {
+			respondErr(w, err, "Failed to update comment")
+			return
+		}
+	}
+	if req.Body != nil {
+		if updated, err = h.Comments.Edit(c.ID, body); err != nil {
+			respondErr(w, err, "Failed to update comment")
+			return
+		}
+	}
+	respondWithJSON(w, http.StatusOK, updated)
+}
+
+// DeleteComment godoc
+// @Summary      Удалить комментарий
+// @Description  Удаляет автор комментария или владелец заметки. Вместе с первым комментарием ветки удаляются ответы
+// @Tags         comments
+// @Param        id   path  string  true  "ID или публичный UUID"
+// @Param        cid  path  int     true  "ID комментария"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/comments/{cid} [delete]
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadCommentNote(w, r)
+	if !ok {
+		return
+	}
+	c, ok := h.loadComment(w, r, note)
+	if !ok {
+		return
+	}
+	p := auth.FromContext(r.Context())
+	if c.AuthorID != p.UserID && !h.noteRole(p, *note).Allows(core.RoleOwner) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if err := h.Comments.Delete(c.ID); err != nil {
+		respondErr(w, err, "Failed to delete comment")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) loadCommentNote(w http.ResponseWriter, r *http.Request) (*core.Note, bool) {
+	if h.Comments == nil {
+		respondError(w, CodeFeatureDisabled, "Comments are not enabled")
+		return nil, false
+	}
+	return h.loadNote(w, r)
+}
+
+// loadComment fetches the comment named by the {cid} URL parameter, which
+// must belong to note.
+func (h *Handler) loadComment(w http.ResponseWriter, r *http.Request, note *core.Note) (*core.Comment, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "cid"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid comment ID")
+		return nil, false
+	}
+	c, err := h.Comments.GetByID(id)
+	if err == nil && c.NoteID != note.ID {
+		err = repo.ErrCommentNotFound
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to get comment")
+		return nil, false
+	}
+	return c, true
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+)
+
+// notModified sets Last-Modified and answers 304 when the client's copy is
+// current. HTTP dates have whole seconds, so while the current second is
+// the one of the last change, no Last-Modified is sent: a later change in
+// the same second would otherwise carry the same date.
+func notModified(w http.ResponseWriter, r *http.Request, modified time.Time) bool {
+	modified = modified.UTC().Truncate(time.Second)
+	if !time.Now().Truncate(time.Second).After(modified) {
+		return false
+	}
+
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
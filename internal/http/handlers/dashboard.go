@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+const dashboardWidgetLimit = 10
+
+type Dashboard struct {
+	Pinned    []core.Note     `json:"pinned"`
+	Reminders []core.Note     `json:"upcoming_reminders"`
+	Recent    []core.Note     `json:"recent_activity"`
+	TagCloud  []core.TagCount `json:"tag_cloud"`
+}
+
+// GetDashboard godoc
+// @Summary      Дашборд
+// @Description  Закреплённые заметки, ближайшие напоминания, последние изменения и облако тегов одним запросом
+// @Tags         dashboard
+// @Produce      json
+// @Success      200  {object}  Dashboard
+// @Failure      500  {object}  map[string]string
+// @Router       /dashboard [get]
+func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to build dashboard")
+		return
+	}
+	notes = h.readable(r, notes)
+
+	now := h.now()
+	d := Dashboard{
+		Pinned:    []core.Note{},
+		Reminders: []core.Note{},
+		TagCloud:  core.CountTags(notes),
+	}
+
+	for _, n := range notes {
+		if n.Pinned {
+			d.Pinned = append(d.Pinned, n)
+		}
+		if n.RemindAt != nil && n.RemindAt.After(now) {
+			d.Reminders = append(d.Reminders, n)
+		}
+	}
+	core.SortByPosition(d.Pinned)
+	sort.Slice(d.Reminders, func(i, j int) bool {
+		return d.Reminders[i].RemindAt.Before(*d.Reminders[j].RemindAt)
+	})
+
+	d.Recent = append([]core.Note{}, notes...)
+	sort.Slice(d.Recent, func(i, j int) bool {
+		return lastActivity(d.Recent[i]).After(lastActivity(d.Recent[j]))
+	})
+
+	d.Reminders = limitNotes(d.Reminders, dashboardWidgetLimit)
+	d.Recent = limitNotes(d.Recent, dashboardWidgetLimit)
+
+	respondWithJSON(w, http.StatusOK, d)
+}
+
+func lastActivity(n core.Note) time.Time {
+	if n.UpdatedAt != nil {
+		return *n.UpdatedAt
+	}
+	return n.CreatedAt
+}
+
+func limitNotes(notes []core.Note, limit int) []core.Note {
+	if len(notes) > limit {
+		return notes[:limit]
+	}
+	return notes
+}
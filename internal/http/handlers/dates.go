@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/locale"
+)
+
+// dateFormatter returns how the caller wants dates displayed: in the
+// style of ?date_format=, the language of Accept-Language or else their
+// locale preference, and their time zone. It returns nil when the request
+// asks for neither, so that plain API clients get machine timestamps only.
+func (h *Handler) dateFormatter(w http.ResponseWriter, r *http.Request) (*locale.Formatter, bool) {
+	style := r.URL.Query().Get("date_format")
+	accept := r.Header.Get("Accept-Language")
+	if style == "" && accept == "" {
+		return nil, true
+	}
+
+	prefs := h.preferences(auth.FromContext(r.Context()).UserID)
+	f, err := locale.NewFormatter(locale.Match(accept, prefs.Locale), style, prefs.Location())
+	if err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return nil, false
+	}
+	return &f, true
+}
+
+// withDates sets the display dates of notes in place; a nil f clears them.
+func withDates(f *locale.Formatter, notes ...*core.Note) {
+	for _, n := range notes {
+		if f == nil {
+			n.Display = nil
+			continue
+		}
+		d := &core.DisplayDates{Created: f.Format(n.CreatedAt)}
+		if n.UpdatedAt != nil {
+			d.Updated = f.Format(*n.UpdatedAt)
+		}
+		if n.RemindAt != nil {
+			d.RemindAt = f.Format(*n.RemindAt)
+		}
+		n.Display = d
+	}
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/vault"
+	"golang.org/x/net/webdav"
+)
+
+// WebDAV serves the notes visible to the caller as a WebDAV tree mounted at
+// prefix: notebooks are folders and notes are Markdown files in the vault
+// format, named after their title.
+func (h *Handler) WebDAV(prefix string) http.Handler {
+	locks := webdav.NewMemLS()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs := &davFS{h: h, p: auth.FromContext(r.Context())}
+		// The file system refuses writes to notes locked by someone else,
+		// but webdav reports that as 403 or 404; answer 423 as the REST
+		// API does.
+		switch r.Method {
+		case http.MethodPut, http.MethodDelete, "MOVE", "PROPPATCH":
+			e, err := fs.resolve(strings.TrimPrefix(r.URL.Path, prefix))
+			if err == nil && e.note != nil && h.Locks != nil {
+				if err := h.Locks.Check(e.note.ID, fs.p.UserID); err != nil {
+					respondErr(w, err, "Failed to check lock")
+					return
+				}
+			}
+		}
+		dav := &webdav.Handler{
+			Prefix:     prefix,
+			FileSystem: fs,
+			LockSystem: locks,
+		}
+		dav.ServeHTTP(w, r)
+	})
+}
+
+type davFS struct {
+	h *Handler
+	p core.Principal
+}
+
+// davEntry is a node of the tree. The root has neither a notebook nor a note.
+type davEntry struct {
+	name     string
+	notebook *core.Notebook
+	note     *core.Note
+}
+
+func (e davEntry) isDir() bool { return e.note == nil }
+
+func (e davEntry) notebookID() int64 {
+	if e.notebook == nil {
+		return 0
+	}
+	return e.notebook.ID
+}
+
+func (fs *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	parent, base, err := fs.resolveParent(name)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.child(parent, base); err == nil {
+		return os.ErrExist
+	}
+	if !fs.canCreateIn(parent) {
+		return os.ErrPermission
+	}
+
+	_, err = fs.h.Notebooks.Create(core.Notebook{Name: base, ParentID: parent.notebookID(), OwnerID: fs.p.UserID})
+	return err
+}
+
+func (fs *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	write := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	e, err := fs.resolve(name)
+	if err == nil {
+		if e.isDir() {
+			if write {
+				return nil, os.ErrPermission
+			}
+			return &davDir{fs: fs, entry: e}, nil
+		}
+		if !write {
+			return newDavFile(e), nil
+		}
+		if !fs.editable(*e.note) {
+			return nil, os.ErrPermission
+		}
+		return &davWriter{fs: fs, entry: e}, nil
+	}
+	if err != os.ErrNotExist || flag&os.O_CREATE == 0 {
+		return nil, err
+	}
+
+	parent, base, err := fs.resolveParent(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(path.Ext(base), ".md") || !fs.canCreateIn(parent) {
+		return nil, os.ErrPermission
+	}
+	return &davWriter{fs: fs, entry: davEntry{name: base}, parent: parent}, nil
+}
+
+// RemoveAll deletes a note, or a notebook that is already empty: like the
+// REST API, it never deletes notes as a side effect of removing a folder.
+func (fs *davFS) RemoveAll(ctx context.Context, name string) error {
+	e, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.note != nil:
+		if !fs.editable(*e.note) {
+			return os.ErrPermission
+		}
+		return fs.h.deleteNote(fs.p.UserID, e.note.ID)
+	case e.notebook != nil:
+		if !fs.h.Notebooks.Role(fs.p, e.notebook.ID).Allows(core.RoleOwner) {
+			return os.ErrPermission
+		}
+		children, err := fs.list(e)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return os.ErrPermission
+		}
+		return fs.h.Notebooks.Delete(e.notebook.ID)
+	}
+	return os.ErrPermission
+}
+
+func (fs *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	e, err := fs.resolve(oldName)
+	if err != nil {
+		return err
+	}
+	parent, base, err := fs.resolveParent(newName)
+	if err != nil {
+		return err
+	}
+	if !fs.canCreateIn(parent) {
+		return os.ErrPermission
+	}
+
+	switch {
+	case e.note != nil:
+		if !fs.editable(*e.note) || !strings.EqualFold(path.Ext(base), ".md") {
+			return os.ErrPermission
+		}
+		if title := strings.TrimSuffix(base, path.Ext(base)); title != e.note.Title {
+			if err := fs.h.updateNote(fs.p.UserID, e.note.ID, map[string]interface{}{"title": title}); err != nil {
+				return err
+			}
+		}
+		if parent.notebookID() != e.note.NotebookID {
+			mover, ok := fs.h.Repo.(noteMover)
+			if !ok {
+				return os.ErrPermission
+			}
+			_, err := mover.Move(fs.p.UserID, []int64{e.note.ID}, parent.notebookID())
+			return err
+		}
+		return nil
+	case e.notebook != nil:
+		if !fs.h.Notebooks.Role(fs.p, e.notebook.ID).Allows(core.RoleOwner) {
+			return os.ErrPermission
+		}
+		parentID := parent.notebookID()
+		err := fs.h.Notebooks.Update(e.notebook.ID, &base, &parentID)
+		if errors.Is(err, repo.ErrNotebookCycle) {
+			return os.ErrPermission
+		}
+		return err
+	}
+	return os.ErrPermission
+}
+
+func (fs *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	e, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.info(e), nil
+}
+
+// editable reports whether the caller may change n: they can edit it and
+// nobody else holds its edit lock.
+func (fs *davFS) editable(n core.Note) bool {
+	if !fs.h.noteRole(fs.p, n).Allows(core.RoleEditor) {
+		return false
+	}
+	return fs.h.Locks == nil || fs.h.Locks.Check(n.ID, fs.p.UserID) == nil
+}
+
+func (fs *davFS) canCreateIn(dir davEntry) bool {
+	if dir.notebook == nil {
+		return true
+	}
+	return fs.h.Notebooks.Role(fs.p, dir.notebook.ID).Allows(core.RoleEditor)
+}
+
+func (fs *davFS) resolve(name string) (davEntry, error) {
+	e := davEntry{name: "/"}
+	for _, part := range strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if !e.isDir() {
+			return davEntry{}, os.ErrNotExist
+		}
+		child, err := fs.child(e, part)
+		if err != nil {
+			return davEntry{}, err
+		}
+		e = child
+	}
+	return e, nil
+}
+
+func (fs *davFS) resolveParent(name string) (davEntry, string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" {
+		return davEntry{}, "", os.ErrPermission
+	}
+	dir, base := path.Split(clean)
+	parent, err := fs.resolve(dir)
+	if err != nil {
+		return davEntry{}, "", err
+	}
+	if !parent.isDir() {
+		return davEntry{}, "", os.ErrNotExist
+	}
+	return parent, base, nil
+}
+
+func (fs *davFS) child(dir davEntry, name string) (davEntry, error) {
+	children, err := fs.list(dir)
+	if err != nil {
+		return davEntry{}, err
+	}
+	for _, c := range children {
+		if strings.EqualFold(c.name, name) {
+			return c, nil
+		}
+	}
+	return davEntry{}, os.ErrNotExist
+}
+
+// list returns the folders and files of a directory. Notebooks and notes
+// whose parent the caller cannot see are listed at the root, so shared
+// content is always reachable.
+func (fs *davFS) list(dir davEntry) ([]davEntry, error) {
+	notebooks, err := fs.h.Notebooks.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := fs.h.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[int64]bool)
+	for _, nb := range notebooks {
+		visible[nb.ID] = fs.h.Notebooks.Role(fs.p, nb.ID).Allows(core.RoleViewer)
+	}
+	in := func(parentID int64) bool {
+		if dir.notebook == nil {
+			return parentID == 0 || !visible[parentID]
+		}
+		return parentID == dir.notebook.ID
+	}
+
+	used := make(map[string]bool)
+	unique := func(name, ext string) string {
+		candidate := name + ext
+		for i := 1; used[strings.ToLower(candidate)]; i++ {
+			candidate = name + " " + strconv.Itoa(i) + ext
+		}
+		used[strings.ToLower(candidate)] = true
+		return candidate
+	}
+
+	var entries []davEntry
+	for i := range notebooks {
+		nb := &notebooks[i]
+		if visible[nb.ID] && in(nb.ParentID) {
+			entries = append(entries, davEntry{name: unique(vault.Sanitize(nb.Name), ""), notebook: nb})
+		}
+	}
+	for i := range notes {
+		n := &notes[i]
+		if fs.h.noteRole(fs.p, *n).Allows(core.RoleViewer) && in(n.NotebookID) {
+			entries = append(entries, davEntry{name: unique(vault.Sanitize(n.Title), ".md"), note: n})
+		}
+	}
+
+	return entries, nil
+}
+
+func (fs *davFS) info(e davEntry) davInfo {
+	switch {
+	case e.note != nil:
+		return davInfo{name: e.name, size: int64(len(vault.Render(*e.note))), mod: lastActivity(*e.note)}
+	case e.notebook != nil:
+		mod := e.notebook.CreatedAt
+		if e.notebook.UpdatedAt != nil {
+			mod = *e.notebook.UpdatedAt
+		}
+		return davInfo{name: e.name, mod: mod, dir: true}
+	}
+	return davInfo{name: e.name, mod: time.Now(), dir: true}
+}
+
+type davInfo struct {
+	name string
+	size int64
+	mod  time.Time
+	dir  bool
+}
+
+func (i davInfo) Name() string       { return i.name }
+func (i davInfo) Size() int64        { return i.size }
+func (i davInfo) ModTime() time.Time { return i.mod }
+func (i davInfo) IsDir() bool        { return i.dir }
+func (i davInfo) Sys() interface{}   { return nil }
+
+func (i davInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+var errDavIsDir = errors.New("is a directory")
+
+type davFile struct {
+	*bytes.Reader
+	info davInfo
+}
+
+func newDavFile(e davEntry) *davFile {
+	data := vault.Render(*e.note)
+	return &davFile{
+		Reader: bytes.NewReader([]byte(data)),
+		info:   davInfo{name: e.name, size: int64(len(data)), mod: lastActivity(*e.note)},
+	}
+}
+
+func (f *davFile) Close() error                             { return nil }
+func (f *davFile) Readdir(count int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (f *davFile) Stat() (os.FileInfo, error)               { return f.info, nil }
+func (f *davFile) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+
+type davDir struct {
+	fs    *davFS
+	entry davEntry
+	read  bool
+}
+
+func (d *davDir) Close() error                                 { return nil }
+func (d *davDir) Read(p []byte) (int, error)                   { return 0, errDavIsDir }
+func (d *davDir) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d *davDir) Stat() (os.FileInfo, error)                   { return d.fs.info(d.entry), nil }
+func (d *davDir) Write(p []byte) (int, error)                  { return 0, errDavIsDir }
+
+func (d *davDir) Readdir(count int) ([]os.FileInfo, error) {
+	if d.read {
+		if count > 0 {
+			return nil, io.EOF
+		}
+		return nil, nil
+	}
+	d.read = true
+
+	children, err := d.fs.list(d.entry)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(children))
+	for _, c := range children {
+		infos = append(infos, d.fs.info(c))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+// davWriter buffers an uploaded file and stores it as a note on Close. The
+// file name is the title; the body and front matter replace the content,
+// tags and pinning of an existing note.
+type davWriter struct {
+	fs     *davFS
+	entry  davEntry
+	parent davEntry
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (f *davWriter) Read(p []byte) (int, error)                   { return 0, os.ErrPermission }
+func (f *davWriter) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (f *davWriter) Readdir(count int) ([]os.FileInfo, error)     { return nil, os.ErrInvalid }
+func (f *davWriter) Write(p []byte) (int, error)                  { return f.buf.Write(p) }
+
+func (f *davWriter) Stat() (os.FileInfo, error) {
+	return davInfo{name: f.entry.name, size: int64(f.buf.Len()), mod: time.Now()}, nil
+}
+
+func (f *davWriter) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	parsed := vault.Parse(f.buf.String())
+
+	if f.entry.note == nil {
+		parsed.Title = strings.TrimSuffix(f.entry.name, path.Ext(f.entry.name))
+		parsed.OwnerID = f.fs.p.UserID
+		parsed.NotebookID = f.parent.notebookID()
+		_, err := f.fs.h.Repo.Create(parsed)
+		return err
+	}
+
+	// Someone may have locked the note while the file was uploading.
+	if !f.fs.editable(*f.entry.note) {
+		return os.ErrPermission
+	}
+	updates := map[string]interface{}{
+		"tags":   parsed.Tags,
+		"pinned": parsed.Pinned,
+	}
+	if parsed.Content != strings.TrimRight(noteBody(*f.entry.note), "\n") {
+		updates["content"] = parsed.Content
+		updates["blocks"] = []core.Block{}
+	}
+	if f.entry.note.Type == core.NoteTypeSnippet && parsed.Language != "" {
+		updates["language"] = parsed.Language
+	}
+	return f.fs.h.updateNote(f.fs.p.UserID, f.entry.note.ID, updates)
+}
+
+func noteBody(n core.Note) string {
+	if len(n.Blocks) > 0 {
+		return core.Markdown(n.Blocks)
+	}
+	return n.Content
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// redactedHeaders carry credentials and are echoed without their value.
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+}
+
+type EchoResponse struct {
+	Method     string              `json:"method" example:"GET"`
+	URL        string              `json:"url" example:"/api/v1/debug/echo?x=1"`
+	Route      string              `json:"route" example:"/api/v1/debug/echo"`
+	RequestID  string              `json:"request_id" example:"host/abc-000001"`
+	RemoteAddr string              `json:"remote_addr" example:"203.0.113.7:52114"`
+	Proto      string              `json:"proto" example:"HTTP/1.1"`
+	Host       string              `json:"host" example:"notes.example.com"`
+	Query      map[string][]string `json:"query"`
+	Headers    map[string][]string `json:"headers"`
+	Principal  EchoPrincipal       `json:"principal"`
+	// RateLimit is the caller's limit state, when rate limits are on.
+	RateLimit *EchoRateLimit `json:"rate_limit,omitempty"`
+}
+
+type EchoPrincipal struct {
+	UserID    string `json:"user_id" example:"alice"`
+	Admin     bool   `json:"admin"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+type EchoRateLimit struct {
+	Limit     string `json:"limit" example:"60"`
+	Remaining string `json:"remaining" example:"59"`
+	Reset     string `json:"reset" example:"1"`
+}
+
+// DebugEcho godoc
+// @Summary      Запрос глазами сервера
+// @Description  Для отладки интеграций: метод, адрес, найденный маршрут, ID запроса, заголовки (значения с учётными данными скрыты), пользователь и состояние лимита запросов. Доступно админам, а всем — только при NOTES_DEBUG_ECHO=true
+// @Tags         debug
+// @Produce      json
+// @Success      200  {object}  EchoResponse
+// @Failure      403  {object}  map[string]string
+// @Router       /debug/echo [get]
+func (h *Handler) DebugEcho(w http.ResponseWriter, r *http.Request) {
+	p := auth.FromContext(r.Context())
+	if !h.DebugEchoOpen && !requireAdmin(w, r) {
+		return
+	}
+
+	headers := make(map[string][]string, len(r.Header))
+	for name, values := range r.Header {
+		if redactedHeaders[name] {
+			values = []string{redact(values[0])}
+		}
+		headers[name] = values
+	}
+
+	resp := EchoResponse{
+		Method:     r.Method,
+		URL:        r.URL.RequestURI(),
+		Route:      chi.RouteContext(r.Context()).RoutePattern(),
+		RequestID:  middleware.GetReqID(r.Context()),
+		RemoteAddr: r.RemoteAddr,
+		Proto:      r.Proto,
+		Host:       r.Host,
+		Query:      r.URL.Query(),
+		Headers:    headers,
+		Principal:  EchoPrincipal{UserID: p.UserID, Admin: p.Admin, Anonymous: p.UserID == auth.Anonymous.UserID},
+	}
+	// The rate limit middleware has already answered with the caller's
+	// state in the response headers.
+	if limit := w.Header().Get("RateLimit-Limit"); limit != "" {
+		resp.RateLimit = &EchoRateLimit{
+			Limit:     limit,
+			Remaining: w.Header().Get("RateLimit-Remaining"),
+			Reset:     w.Header().Get("RateLimit-Reset"),
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// redact keeps the scheme of a credential, such as "Bearer", and hides
+// the rest.
+func redact(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " [redacted]"
+	}
+	return "[redacted]"
+}
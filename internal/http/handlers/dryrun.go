@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// dryRun reports whether the request asks to be validated without being
+// carried out, with ?dry_run=true or a Prefer header carrying dry-run or
+// handling=dry-run.
+func dryRun(r *http.Request) bool {
+	if dry, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dry {
+		return true
+	}
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.FieldsFunc(header, func(c rune) bool { return c == ',' || c == ';' }) {
+			pref = strings.ToLower(strings.TrimSpace(pref))
+			if pref == "dry-run" || pref == "handling=dry-run" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// respondDryRun answers a dry run with what the request would have
+// produced. The status is always 200, as nothing was created.
+func respondDryRun(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Preference-Applied", "dry-run")
+	respondWithJSON(w, http.StatusOK, payload)
+}
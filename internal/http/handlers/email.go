@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/mailer"
+	"example.com/notes-api/internal/render"
+)
+
+// maxEmailRecipients bounds the addresses of one note email.
+const maxEmailRecipients = 10
+
+type EmailNoteRequest struct {
+	To []string `json:"to" example:"friend@example.com"`
+	// Message is written above the note.
+	Message string `json:"message,omitempty" example:"Посмотри, пожалуйста"`
+}
+
+type EmailNoteResponse struct {
+	Sent []string `json:"sent" example:"friend@example.com"`
+}
+
+// EmailNote godoc
+// @Summary      Отправить заметку по почте
+// @Description  Отправляет заметку в Markdown на указанные адреса (не больше 10), так что получателям не нужен аккаунт. Число писем одного пользователя ограничено; при превышении ответ 429 с Retry-After. Каждая отправка попадает в журнал аудита
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string            true  "ID или публичный UUID"
+// @Param        input  body      EmailNoteRequest  true  "Получатели"
+// @Success      200    {object}  EmailNoteResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      429    {object}  map[string]string
+// @Failure      502    {object}  map[string]string
+// @Router       /notes/{id}/email [post]
+func (h *Handler) EmailNote(w http.ResponseWriter, r *http.Request) {
+	if h.Mailer == nil {
+		respondError(w, CodeFeatureDisabled, "Email is not enabled")
+		return
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	var req EmailNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	to, ok := emailRecipients(w, req.To)
+	if !ok {
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	if retry, ok := h.allowEmail(r.Context(), p.UserID); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+		respondError(w, CodeRateLimited, "Too many emails")
+		return
+	}
+
+	body := render.Shortcodes(core.NoteMarkdown(*note))
+	if msg := strings.TrimSpace(req.Message); msg != "" {
+		body = msg + "\n\n---\n\n" + body
+	}
+	body += "\n\n--\nShared by " + p.UserID + "\n"
+	if err := h.mailNote(r.Context(), p.UserID, *note, to, body); err != nil {
+		log.Printf("email note %d: %v", note.ID, err)
+		respondError(w, CodeUpstreamFailed, "Failed to send email")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, EmailNoteResponse{Sent: to})
+}
+
+// allowEmail counts an email towards the limit of userID. When it is
+// over the limit it returns false and how long to wait; limiter errors
+// let the email through.
+func (h *Handler) allowEmail(ctx context.Context, userID string) (time.Duration, bool) {
+	if h.EmailLimiter == nil {
+		return 0, true
+	}
+	res, err := h.EmailLimiter.Allow(ctx, "email:user:"+userID, h.EmailLimit)
+	if err != nil {
+		log.Printf("email rate limit: %v", err)
+		return 0, true
+	}
+	return res.RetryAfter, res.Allowed
+}
+
+// mailNote sends n with body to the addresses on behalf of userID and
+// records it in the audit log.
+func (h *Handler) mailNote(ctx context.Context, userID string, n core.Note, to []string, body string) error {
+	if err := h.Mailer.Send(ctx, mailer.Message{To: to, Subject: n.Title, Body: body}); err != nil {
+		return err
+	}
+	if h.Audit != nil {
+		h.Audit.Record(userID, "note.emailed", "note:"+strconv.FormatInt(n.ID, 10), strings.Join(to, ", "))
+	}
+	return nil
+}
+
+// emailRecipients validates and deduplicates addresses, keeping only the
+// address part of entries such as "Ann <ann@example.com>".
+func emailRecipients(w http.ResponseWriter, entries []string) ([]string, bool) {
+	seen := make(map[string]bool)
+	to := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		addr, err := mail.ParseAddress(strings.TrimSpace(entry))
+		if err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid email address: "+entry)
+			return nil, false
+		}
+		if key := strings.ToLower(addr.Address); !seen[key] {
+			seen[key] = true
+			to = append(to, addr.Address)
+		}
+	}
+	if len(to) == 0 {
+		respondError(w, CodeInvalidRequest, "At least one recipient is required")
+		return nil, false
+	}
+	if len(to) > maxEmailRecipients {
+		respondError(w, CodeInvalidRequest, "Too many recipients")
+		return nil, false
+	}
+	return to, true
+}
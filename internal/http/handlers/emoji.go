@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/render"
+	"github.com/go-chi/chi/v5"
+)
+
+// EmojiList is every shortcode notes can use.
+type EmojiList struct {
+	Builtin []render.BuiltinEmoji `json:"builtin"`
+	Custom  []render.CustomEmoji  `json:"custom"`
+}
+
+type PutEmojiRequest struct {
+	URL string `json:"url" example:"https://cdn.example.com/emoji/partyparrot.gif"`
+}
+
+// ListEmoji godoc
+// @Summary      Эмодзи
+// @Description  Шорткоды вида :tada:, которые в HTML заметки (печать, публичная ссылка) становятся эмодзи, а в письмах — символами. Свои эмодзи показываются картинками
+// @Tags         emoji
+// @Produce      json
+// @Success      200  {object}  EmojiList
+// @Router       /emoji [get]
+func (h *Handler) ListEmoji(w http.ResponseWriter, r *http.Request) {
+	list := EmojiList{Builtin: render.Builtin(), Custom: []render.CustomEmoji{}}
+	if h.Emoji != nil {
+		list.Custom = h.Emoji.List()
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// PutEmoji godoc
+// @Summary      Добавить своё эмодзи
+// @Description  Для админов: картинка по адресу url показывается вместо :name: во всех заметках. Имена встроенных эмодзи заняты
+// @Tags         emoji
+// @Accept       json
+// @Produce      json
+// @Param        name   path      string           true  "Имя: строчные буквы, цифры, _, + и -"
+// @Param        input  body      PutEmojiRequest  true  "Картинка"
+// @Success      200    {object}  render.CustomEmoji
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /emoji/{name} [put]
+func (h *Handler) PutEmoji(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !h.emojiEnabled(w) {
+		return
+	}
+	var req PutEmojiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	saved, err := h.Emoji.Put(render.CustomEmoji{
+		Name:    chi.URLParam(r, "name"),
+		URL:     req.URL,
+		Author:  auth.FromContext(r.Context()).UserID,
+		SavedAt: h.now(),
+	})
+	if err != nil {
+		respondError(w, CodeInvalidRequest, sentence(err.Error()))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, saved)
+}
+
+// DeleteEmoji godoc
+// @Summary      Удалить своё эмодзи
+// @Tags         emoji
+// @Param        name  path  string  true  "Имя"
+// @Success      204  "Эмодзи удалено"
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /emoji/{name} [delete]
+func (h *Handler) DeleteEmoji(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !h.emojiEnabled(w) {
+		return
+	}
+	if err := h.Emoji.Delete(chi.URLParam(r, "name")); err != nil {
+		respondErr(w, err, "Failed to delete emoji")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) emojiEnabled(w http.ResponseWriter) bool {
+	if h.Emoji == nil {
+		respondError(w, CodeFeatureDisabled, "Custom emoji are not enabled")
+		return false
+	}
+	return true
+}
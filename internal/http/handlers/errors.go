@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// ErrorCode is a kind of error the API answers with. Code is stable, so
+// clients can branch on it rather than on the message, which may change.
+type ErrorCode struct {
+	Code        string `json:"code" example:"note_not_found"`
+	Status      int    `json:"status" example:"404"`
+	Description string `json:"description" example:"The note does not exist or is not visible to the caller"`
+}
+
+var errorCodes = map[string]ErrorCode{}
+
+// defineError registers an error code; GET /errors lists every one.
+func defineError(code string, status int, description string) ErrorCode {
+	if _, ok := errorCodes[code]; ok {
+		panic("handlers: error code " + code + " defined twice")
+	}
+	c := ErrorCode{Code: code, Status: status, Description: description}
+	errorCodes[code] = c
+	return c
+}
+
+var (
+	CodeInvalidJSON        = defineError("invalid_json", http.StatusBadRequest, "The request body is not JSON of the expected shape")
+	CodeValidationFailed   = defineError("validation_failed", http.StatusBadRequest, "Fields of the request are invalid; fields maps each to what is wrong with it")
+	CodeInvalidRequest     = defineError("invalid_request", http.StatusBadRequest, "A parameter, header or field is missing or invalid; the message says which")
+	CodeUnauthorized       = defineError("unauthorized", http.StatusUnauthorized, "The bearer token is missing, unknown or revoked")
+	CodeForbidden          = defineError("forbidden", http.StatusForbidden, "The caller may not do this to the resource, or the route is for admins")
+	CodeNotFound           = defineError("not_found", http.StatusNotFound, "The resource does not exist or is not visible to the caller")
+	CodeNoteNotFound       = defineError("note_not_found", http.StatusNotFound, "The note does not exist or is not visible to the caller")
+	CodeNotebookNotFound   = defineError("notebook_not_found", http.StatusNotFound, "The notebook does not exist or is not shared with the caller")
+	CodeCollectionNotFound = defineError("collection_not_found", http.StatusNotFound, "The collection does not exist or belongs to someone else")
+	CodeFeatureDisabled    = defineError("feature_disabled", http.StatusNotFound, "The feature is turned off on this instance")
+	CodeMethodNotAllowed   = defineError("method_not_allowed", http.StatusMethodNotAllowed, "The route does not take this method; Allow lists the ones it does")
+	CodeConflict           = defineError("conflict", http.StatusConflict, "The request conflicts with the current state of the resource")
+	CodeDuplicateTitle     = defineError("duplicate_title", http.StatusConflict, "The notebook requires unique titles and conflicting_id already has this one")
+	CodeInvalidTransition  = defineError("invalid_transition", http.StatusConflict, "The lifecycle does not let the note move from its state to the one requested")
+	CodeNoteLocked         = defineError("note_locked", http.StatusLocked, "Someone else holds the edit lock of the note; lock tells who and until when")
+	CodeGone               = defineError("gone", http.StatusGone, "The resource existed but has expired")
+	CodeTooLarge           = defineError("too_large", http.StatusRequestEntityTooLarge, "The body, file or archive is over the size limit")
+	CodeUnprocessable      = defineError("unprocessable", http.StatusUnprocessableEntity, "The request is well-formed but its content cannot be used")
+	CodeRateLimited        = defineError("rate_limited", http.StatusTooManyRequests, "The caller is over a rate limit; Retry-After says when to try again")
+	CodeInternal           = defineError("internal", http.StatusInternalServerError, "The server failed; retrying may help")
+	CodeNotImplemented     = defineError("not_implemented", http.StatusNotImplemented, "The server is built or configured without what the request needs")
+	CodeUpstreamFailed     = defineError("upstream_failed", http.StatusBadGateway, "A server the API depends on, such as a fetched web page, failed")
+	CodeUnavailable        = defineError("unavailable", http.StatusServiceUnavailable, "The server is too busy for the request; retrying later may help")
+)
+
+// ErrorCodes returns every error code, by status and then code.
+func ErrorCodes() []ErrorCode {
+	list := make([]ErrorCode, 0, len(errorCodes))
+	for _, c := range errorCodes {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Status != list[j].Status {
+			return list[i].Status < list[j].Status
+		}
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
+
+// CodeForStatus returns the generic code of status, for errors that do
+// not come from a handler, such as injected faults. It falls back to
+// CodeInternal.
+func CodeForStatus(status int) ErrorCode {
+	for _, c := range []ErrorCode{
+		CodeInvalidRequest, CodeUnauthorized, CodeForbidden, CodeNotFound, CodeMethodNotAllowed,
+		CodeConflict, CodeGone, CodeTooLarge, CodeUnprocessable, CodeRateLimited,
+		CodeNotImplemented, CodeUpstreamFailed, CodeUnavailable,
+	} {
+		if c.Status == status {
+			return c
+		}
+	}
+	return CodeInternal
+}
+
+// ListErrorCodes godoc
+// @Summary      Коды ошибок
+// @Description  Все коды, которые API может вернуть в поле code ответа с ошибкой, со статусом HTTP и описанием. Клиентам стоит ветвиться по коду: текст ошибки может меняться
+// @Tags         errors
+// @Produce      json
+// @Success      200  {array}  ErrorCode
+// @Router       /errors [get]
+func (h *Handler) ListErrorCodes(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, ErrorCodes())
+}
+
+// respondError answers with c and a message for people.
+func respondError(w http.ResponseWriter, c ErrorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(c.Status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: c.Code})
+}
+
+// errorKinds maps domain errors to codes, most specific first.
+var errorKinds = []struct {
+	err  error
+	code ErrorCode
+}{
+	{repo.ErrNoteNotFound, CodeNoteNotFound},
+	{repo.ErrNotebookNotFound, CodeNotebookNotFound},
+	{repo.ErrCollectionNotFound, CodeCollectionNotFound},
+	{repo.ErrInvalidTransition, CodeInvalidTransition},
+	{core.ErrNotFound, CodeNotFound},
+	{core.ErrConflict, CodeConflict},
+	{core.ErrForbidden, CodeForbidden},
+}
+
+// respondErr answers with the code the kind of err maps to, and err as
+// the message. Errors of no kind are server failures: they are logged
+// and answered with a 500 and message.
+func respondErr(w http.ResponseWriter, err error, message string) {
+	var invalid *core.ErrValidation
+	if errors.As(err, &invalid) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(CodeValidationFailed.Status)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error:  sentence(invalid.Error()),
+			Code:   CodeValidationFailed.Code,
+			Fields: invalid.Fields,
+		})
+		return
+	}
+	var duplicate *repo.DuplicateTitleError
+	if errors.As(err, &duplicate) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(CodeDuplicateTitle.Status)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error:         sentence(duplicate.Error()),
+			Code:          CodeDuplicateTitle.Code,
+			ConflictingID: duplicate.NoteID,
+		})
+		return
+	}
+	var locked *repo.LockedError
+	if errors.As(err, &locked) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(CodeNoteLocked.Status)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error: sentence(locked.Error()),
+			Code:  CodeNoteLocked.Code,
+			Lock:  &locked.Lock,
+		})
+		return
+	}
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			respondError(w, k.code, sentence(err.Error()))
+			return
+		}
+	}
+	log.Printf("%s: %v", message, err)
+	respondError(w, CodeInternal, message)
+}
+
+// sentence capitalizes an error message, as messages to clients are.
+func sentence(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
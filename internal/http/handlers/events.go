@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"example.com/notes-api/internal/events"
+)
+
+// eventsHeartbeat keeps idle streams from being closed by proxies.
+const eventsHeartbeat = 25 * time.Second
+
+// StreamEvents godoc
+// @Summary      Поток изменений заметок (SSE)
+// @Description  Server-Sent Events: note.created, note.updated, note.deleted и presence.updated, presence.left (кто открыл заметку и печатает ли) для заметок, доступных пользователю, с любой реплики
+// @Tags         events
+// @Produce      text/event-stream
+// @Success      200  {object}  events.Event
+// @Failure      404  {object}  map[string]string
+// @Router       /events [get]
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if h.Events == nil {
+		respondError(w, CodeFeatureDisabled, "Events are not enabled")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, CodeInternal, "Streaming unsupported")
+		return
+	}
+
+	stream, unsubscribe := h.Events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case e := <-stream:
+			if events.IsPresence(e) {
+				if note, err := h.Repo.GetByID(e.NoteID); err != nil || !h.canRead(r, *note) {
+					continue
+				}
+			} else {
+				if !h.canRead(r, e.Note) {
+					continue
+				}
+				h.withPaths(&e.Note)
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+type FavoriteResponse struct {
+	Favorite bool `json:"favorite"`
+}
+
+// FavoriteNote godoc
+// @Summary      Добавить заметку в избранное
+// @Description  Избранное у каждого пользователя своё и не зависит от закрепления (pinned): можно добавить и чужую заметку, если она доступна
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  FavoriteResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/favorite [post]
+func (h *Handler) FavoriteNote(w http.ResponseWriter, r *http.Request) {
+	h.setFavorite(w, r, true)
+}
+
+// UnfavoriteNote godoc
+// @Summary      Убрать заметку из избранного
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  FavoriteResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/favorite [delete]
+func (h *Handler) UnfavoriteNote(w http.ResponseWriter, r *http.Request) {
+	h.setFavorite(w, r, false)
+}
+
+// ListFavorites godoc
+// @Summary      Избранные заметки
+// @Description  Заметки, которые пользователь добавил в избранное и всё ещё может читать, сначала добавленные последними
+// @Tags         notes
+// @Produce      json
+// @Param        page   query  int  false  "Номер страницы"
+// @Param        limit  query  int  false  "Размер страницы; по умолчанию page_size из настроек, без него — все"
+// @Success      200    {array}    core.Note
+// @Header       200    {integer}  X-Total-Count  "Общее количество"
+// @Failure      400    {object}   map[string]string
+// @Failure      404    {object}   map[string]string
+// @Router       /notes/favorites [get]
+func (h *Handler) ListFavorites(w http.ResponseWriter, r *http.Request) {
+	if !h.favoritesEnabled(w) {
+		return
+	}
+	p := auth.FromContext(r.Context())
+	notes := []core.Note{}
+	for _, id := range h.Favorites.List(p.UserID) {
+		if n, err := h.Repo.GetByID(id); err == nil && h.noteRole(p, *n).Allows(core.RoleViewer) {
+			notes = append(notes, *n)
+		}
+	}
+	notes, ok := h.paginate(w, r, notes)
+	if !ok {
+		return
+	}
+	for i := range notes {
+		h.withPaths(&notes[i])
+	}
+	respondWithJSON(w, http.StatusOK, notes)
+}
+
+func (h *Handler) setFavorite(w http.ResponseWriter, r *http.Request, favorite bool) {
+	if !h.favoritesEnabled(w) {
+		return
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	userID := auth.FromContext(r.Context()).UserID
+	if favorite {
+		h.Favorites.Add(userID, note.ID, h.now())
+	} else {
+		h.Favorites.Remove(userID, note.ID)
+	}
+	respondWithJSON(w, http.StatusOK, FavoriteResponse{Favorite: favorite})
+}
+
+func (h *Handler) favoritesEnabled(w http.ResponseWriter) bool {
+	if h.Favorites == nil {
+		respondError(w, CodeFeatureDisabled, "Favorites are not enabled")
+		return false
+	}
+	return true
+}
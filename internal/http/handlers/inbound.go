@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxInboundSize bounds inbound payloads; alerts and form posts are far
+// smaller.
+const maxInboundSize = 1 << 20
+
+// defaultInboundContent is the content template of hooks that set none:
+// the payload as it was sent.
+const defaultInboundContent = "{{.Text}}"
+
+// inboundFuncs are the functions inbound templates can call besides the
+// text/template builtins.
+var inboundFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// inboundData is what inbound templates receive.
+type inboundData struct {
+	// Body is the payload: decoded JSON, form fields by name (a list when
+	// repeated) or the text.
+	Body        any
+	Text        string
+	ContentType string
+	// Query are the parameters of the hook URL, so that one hook can
+	// serve senders that add their own.
+	Query map[string]string
+	Hook  string
+	Now   time.Time
+}
+
+type InboundResult struct {
+	NoteID   int64  `json:"note_id" example:"12"`
+	PublicID string `json:"public_id" example:"01927c7e-8f5a-7b3e-9c1d-2f4a6b8c0d1e"`
+	Title    string `json:"title" example:"CPU > 90% on db-1"`
+}
+
+// CreateInboundHook godoc
+// @Summary      Создать входящий вебхук
+// @Description  Возвращает токен для POST /inbound/{token}. title, content и tags — шаблоны text/template: .Body — тело запроса (JSON, поля формы или текст), .Text — тело как есть, .Query — параметры URL, .Hook — имя вебхука, .Now — время; функция json выводит значение в JSON. Пустой заголовок заменяется именем вебхука, пустой шаблон content — телом запроса
+// @Tags         inbound
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.InboundHookInput  true  "Вебхук"
+// @Success      201    {object}  core.InboundHook
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /inbound-hooks [post]
+func (h *Handler) CreateInboundHook(w http.ResponseWriter, r *http.Request) {
+	if !h.inboundEnabled(w) {
+		return
+	}
+	var in core.InboundHookInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	hook := core.InboundHook{
+		OwnerID:    auth.FromContext(r.Context()).UserID,
+		Name:       strings.TrimSpace(in.Name),
+		NotebookID: in.NotebookID,
+		Title:      in.Title,
+		Content:    in.Content,
+	}
+	invalid := &core.ErrValidation{Fields: map[string]string{}}
+	if hook.Name == "" {
+		invalid.Fields["name"] = "name is required"
+	}
+	if _, err := parseInbound(hook.Title); err != nil {
+		invalid.Fields["title"] = err.Error()
+	}
+	if _, err := parseInbound(hook.Content); err != nil {
+		invalid.Fields["content"] = err.Error()
+	}
+	for _, tag := range in.Tags {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		if _, err := parseInbound(tag); err != nil {
+			invalid.Fields["tags"] = err.Error()
+		}
+		hook.Tags = append(hook.Tags, tag)
+	}
+	if len(invalid.Fields) > 0 {
+		respondErr(w, invalid, "")
+		return
+	}
+	if hook.NotebookID != 0 {
+		if _, err := h.Notebooks.GetByID(hook.NotebookID); err != nil {
+			respondError(w, CodeInvalidRequest, "Notebook not found")
+			return
+		}
+		if !h.Notebooks.Role(auth.FromContext(r.Context()), hook.NotebookID).Allows(core.RoleEditor) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return
+		}
+	}
+
+	hook = h.InboundHooks.Create(hook)
+	w.Header().Set("Location", "/api/v1/inbound-hooks/"+strconv.FormatInt(hook.ID, 10))
+	respondWithJSON(w, http.StatusCreated, hook)
+}
+
+// ListInboundHooks godoc
+// @Summary      Входящие вебхуки пользователя
+// @Tags         inbound
+// @Produce      json
+// @Success      200  {array}   core.InboundHook
+// @Failure      404  {object}  map[string]string
+// @Router       /inbound-hooks [get]
+func (h *Handler) ListInboundHooks(w http.ResponseWriter, r *http.Request) {
+	if !h.inboundEnabled(w) {
+		return
+	}
+	list := h.InboundHooks.ListFor(auth.FromContext(r.Context()).UserID)
+	if list == nil {
+		list = []core.InboundHook{}
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// DeleteInboundHook godoc
+// @Summary      Удалить входящий вебхук
+// @Description  Токен вебхука перестаёт действовать
+// @Tags         inbound
+// @Param        id   path  int  true  "ID"
+// @Success      204  "Вебхук удалён"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /inbound-hooks/{id} [delete]
+func (h *Handler) DeleteInboundHook(w http.ResponseWriter, r *http.Request) {
+	if !h.inboundEnabled(w) {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid hook ID")
+		return
+	}
+	hook, err := h.InboundHooks.GetByID(id)
+	if err == nil && hook.OwnerID != auth.FromContext(r.Context()).UserID {
+		err = repo.ErrInboundHookNotFound
+	}
+	if err == nil {
+		err = h.InboundHooks.Delete(id)
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to delete hook")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReceiveInbound godoc
+// @Summary      Создать заметку входящим вебхуком
+// @Description  Не требует токена API: токен в URL выдаёт POST /inbound-hooks. Тело — JSON, форма (urlencoded или multipart) или текст, не больше 1 МиБ; заметка владельца вебхука создаётся по его шаблонам
+// @Tags         inbound
+// @Accept       json,x-www-form-urlencoded,mpfd,plain
+// @Produce      json
+// @Param        token  path      string  true  "Токен вебхука"
+// @Success      201    {object}  InboundResult
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      413    {object}  map[string]string
+// @Failure      422    {object}  map[string]string
+// @Router       /inbound/{token} [post]
+func (h *Handler) ReceiveInbound(w http.ResponseWriter, r *http.Request) {
+	if !h.inboundEnabled(w) {
+		return
+	}
+	hook, err := h.InboundHooks.ByToken(chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		respondErr(w, err, "Failed to get hook")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboundSize))
+	if err != nil {
+		respondError(w, CodeTooLarge, "Payload is too large")
+		return
+	}
+	in := inboundData{Text: string(data), Query: map[string]string{}, Hook: hook.Name, Now: h.now()}
+	for key := range r.URL.Query() {
+		in.Query[key] = r.URL.Query().Get(key)
+	}
+	in.ContentType, _, _ = mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if in.Body, err = inboundBody(r, in.ContentType, data); err != nil {
+		respondError(w, CodeInvalidRequest, "Payload does not match its Content-Type")
+		return
+	}
+
+	n := core.Note{OwnerID: hook.OwnerID, NotebookID: hook.NotebookID, Type: core.NoteTypeNote}
+	content := hook.Content
+	if content == "" {
+		content = defaultInboundContent
+	}
+	if n.Title, err = execInbound(hook.Title, in); err == nil {
+		n.Content, err = execInbound(content, in)
+	}
+	var tags []string
+	for i := 0; err == nil && i < len(hook.Tags); i++ {
+		var tag string
+		if tag, err = execInbound(hook.Tags[i], in); err == nil {
+			tags = append(tags, strings.Split(tag, ",")...)
+		}
+	}
+	if err != nil {
+		respondError(w, CodeUnprocessable, "Hook template failed: "+err.Error())
+		return
+	}
+	n.Title = strings.TrimSpace(strings.ReplaceAll(n.Title, "\n", " "))
+	if n.Title == "" {
+		n.Title = hook.Name
+	}
+	n.Tags = core.NormalizeTags(tags)
+
+	if n.NotebookID != 0 {
+		if _, err := h.Notebooks.GetByID(n.NotebookID); err != nil {
+			respondError(w, CodeUnprocessable, "The notebook of the hook no longer exists")
+			return
+		}
+	}
+	if err := h.applyNotebookDefaults(&n); err != nil {
+		respondErr(w, err, "Failed to apply notebook settings")
+		return
+	}
+	id, err := h.Repo.Create(n)
+	if err != nil {
+		respondErr(w, err, "Failed to create note")
+		return
+	}
+	note, err := h.Repo.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve created note")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, InboundResult{NoteID: note.ID, PublicID: note.PublicID, Title: note.Title})
+}
+
+func (h *Handler) inboundEnabled(w http.ResponseWriter) bool {
+	if h.InboundHooks == nil {
+		respondError(w, CodeFeatureDisabled, "Inbound hooks are not enabled")
+		return false
+	}
+	return true
+}
+
+// inboundBody decodes a payload by its media type: JSON, a form, or text
+// for anything else.
+func inboundBody(r *http.Request, mediaType string, data []byte) (any, error) {
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var body any
+		d := json.NewDecoder(bytes.NewReader(data))
+		// Numbers stay as they were sent instead of turning into floats.
+		d.UseNumber()
+		if err := d.Decode(&body); err != nil {
+			return nil, err
+		}
+		return body, nil
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return formFields(values), nil
+	case mediaType == "multipart/form-data":
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if err := r.ParseMultipartForm(maxInboundSize); err != nil {
+			return nil, err
+		}
+		return formFields(r.MultipartForm.Value), nil
+	}
+	return string(data), nil
+}
+
+func formFields(values map[string][]string) map[string]any {
+	fields := make(map[string]any, len(values))
+	for key, v := range values {
+		if len(v) == 1 {
+			fields[key] = v[0]
+		} else {
+			fields[key] = v
+		}
+	}
+	return fields
+}
+
+func parseInbound(src string) (*template.Template, error) {
+	return template.New("inbound").Funcs(inboundFuncs).Parse(src)
+}
+
+func execInbound(src string, data inboundData) (string, error) {
+	tmpl, err := parseInbound(src)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		// Template errors name the template and position, not the hook.
+		var execErr template.ExecError
+		if errors.As(err, &execErr) {
+			return "", execErr.Err
+		}
+		return "", err
+	}
+	// Fields the payload lacks print as "<no value>"; a note is better
+	// off without them.
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/blob"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/importer"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/vault"
+	"github.com/go-chi/chi/v5"
+)
+
+// DownloadPath is where export files are served, outside the API: the
+// signed URL is the credential.
+const DownloadPath = "/downloads/"
+
+// DefaultExportTTL is how long export files stay downloadable when
+// Handler.ExportTTL is zero.
+const DefaultExportTTL = 24 * time.Hour
+
+// importFormats reads an uploaded file into a vault, by format name.
+var importFormats = map[string]func(data []byte) (*vault.Vault, error){
+	"vault": func(data []byte) (*vault.Vault, error) { return vault.Read(bytes.NewReader(data), int64(len(data))) },
+	"enex":  func(data []byte) (*vault.Vault, error) { return importer.ENEX(bytes.NewReader(data)) },
+	"csv":   func(data []byte) (*vault.Vault, error) { return importer.CSV(bytes.NewReader(data)) },
+}
+
+// exportFormats builds an export file of what p can read, by format name.
+var exportFormats = map[string]func(h *Handler, p core.Principal) (*jobs.Result, error){
+	"vault": func(h *Handler, p core.Principal) (*jobs.Result, error) {
+		data, err := h.vaultArchive(p)
+		return &jobs.Result{Name: "vault.zip", ContentType: "application/zip", Data: data}, err
+	},
+	"site": func(h *Handler, p core.Principal) (*jobs.Result, error) {
+		data, err := h.siteArchive(p)
+		return &jobs.Result{Name: "site.zip", ContentType: "application/zip", Data: data}, err
+	},
+	"json": func(h *Handler, p core.Principal) (*jobs.Result, error) {
+		notes, err := h.Repo.List()
+		if err != nil {
+			return nil, err
+		}
+		readable := make([]core.Note, 0, len(notes))
+		for _, n := range notes {
+			if h.noteRole(p, n).Allows(core.RoleViewer) {
+				h.withPaths(&n)
+				readable = append(readable, n)
+			}
+		}
+		data, err := json.MarshalIndent(readable, "", "  ")
+		return &jobs.Result{Name: "notes.json", ContentType: "application/json", Data: data}, err
+	},
+}
+
+type JobResponse struct {
+	jobs.Job
+	// ResultURL is where the file the job produced can be downloaded:
+	// /jobs/{id}/result, or a signed link that needs no token.
+	ResultURL string `json:"result_url,omitempty" example:"/api/v1/jobs/1/result"`
+}
+
+// ImportReport is the result file of an import job.
+type ImportReport struct {
+	VaultImportResponse
+	NoteIDs []int64  `json:"note_ids"`
+	Errors  []string `json:"errors"`
+}
+
+// CreateImportJob godoc
+// @Summary      Импорт в фоне
+// @Description  Принимает файл и сразу возвращает задачу; ход импорта — в GET /jobs/{id}, отчёт — по result_url. Форматы: vault (ZIP в формате Obsidian), enex (экспорт Evernote), csv (колонки title, content, tags, notebook)
+// @Tags         jobs
+// @Accept       application/octet-stream
+// @Produce      json
+// @Param        format       query     string  true   "Формат файла"  Enums(vault, enex, csv)
+// @Param        notebook_id  query     int     false  "Родительский блокнот для импорта"
+// @Success      202          {object}  JobResponse
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string  "Фоновые задачи выключены"
+// @Failure      413          {object}  map[string]string
+// @Failure      503          {object}  map[string]string  "Очередь задач переполнена"
+// @Router       /import/jobs [post]
+func (h *Handler) CreateImportJob(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		respondError(w, CodeFeatureDisabled, "Background jobs are not enabled")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	parse, ok := importFormats[format]
+	if !ok {
+		respondError(w, CodeInvalidRequest, "format must be vault, enex or csv")
+		return
+	}
+	parentID, ok := h.importParent(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxVaultSize))
+	if err != nil {
+		respondError(w, CodeTooLarge, "File is too large")
+		return
+	}
+
+	ownerID := auth.FromContext(r.Context()).UserID
+	job, err := h.Jobs.Submit("import", ownerID, func(ctx context.Context, p *jobs.Progress) (*jobs.Result, error) {
+		v, err := parse(data)
+		if err != nil {
+			return nil, err
+		}
+		p.SetTotal(len(v.Folders) + len(v.Entries))
+		for _, name := range v.Skipped {
+			p.Error("skipped " + name)
+		}
+
+		resp, ids, err := h.importVault(ownerID, parentID, v, p.Step)
+		report := ImportReport{VaultImportResponse: resp, NoteIDs: ids, Errors: []string{}}
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+		body, _ := json.MarshalIndent(report, "", "  ")
+		return &jobs.Result{Name: "import-report.json", ContentType: "application/json", Data: body}, err
+	})
+	if err != nil {
+		respondError(w, CodeUnavailable, "Too many jobs queued")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/jobs/"+strconv.FormatInt(job.ID, 10))
+	respondWithJSON(w, http.StatusAccepted, h.jobResponse(job))
+}
+
+// CreateExportJob godoc
+// @Summary      Экспорт в фоне
+// @Description  Собирает архив всех доступных заметок в фоне: vault — в формате Obsidian, json — массив заметок, site — статический сайт (index.html со списками по блокнотам и тегам, страница на каждую заметку, поиск по встроенному индексу), который можно выложить на любой хостинг статики. Когда задача закончится, придёт уведомление, а result_url задачи станет подписанной ссылкой на файл, которая работает без токена до истечения срока
+// @Tags         jobs
+// @Produce      json
+// @Param        format  query     string  false  "Формат файла"  Enums(vault, json, site)  default(vault)
+// @Success      202     {object}  JobResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string  "Фоновые задачи выключены"
+// @Failure      503     {object}  map[string]string  "Очередь задач переполнена"
+// @Router       /export [post]
+func (h *Handler) CreateExportJob(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil || h.Blobs == nil {
+		respondError(w, CodeFeatureDisabled, "Background exports are not enabled")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "vault"
+	}
+	build, ok := exportFormats[format]
+	if !ok {
+		respondError(w, CodeInvalidRequest, "format must be vault, json or site")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	base := h.baseURL(r)
+	job, err := h.Jobs.Submit("export", p.UserID, func(ctx context.Context, progress *jobs.Progress) (*jobs.Result, error) {
+		progress.SetTotal(1)
+		result, err := build(h, p)
+		if err != nil {
+			return nil, err
+		}
+
+		token := make([]byte, 16)
+		rand.Read(token)
+		key := "exports/" + hex.EncodeToString(token) + "/" + result.Name
+		if err := h.Blobs.Put(key, result.Data); err != nil {
+			return nil, err
+		}
+		ttl := h.exportTTL()
+		time.AfterFunc(ttl, func() {
+			if err := h.Blobs.Delete(key); err != nil {
+				log.Printf("delete expired export %s: %v", key, err)
+			}
+		})
+		progress.Step()
+
+		result.URL = base + DownloadPath + key + "?" + h.Downloads.Sign(key, h.now().Add(ttl))
+		result.Data = nil
+		return result, nil
+	})
+	if err != nil {
+		respondError(w, CodeUnavailable, "Too many jobs queued")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/jobs/"+strconv.FormatInt(job.ID, 10))
+	respondWithJSON(w, http.StatusAccepted, h.jobResponse(job))
+}
+
+// Download serves a stored export file at DownloadPath{key} to anyone
+// holding a signed URL for it. It is not part of the API.
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	q := r.URL.Query()
+	valid, expired := h.Downloads.Verify(key, q.Get("expires"), q.Get("sig"), h.now())
+	if !valid || h.Blobs == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if expired {
+		http.Error(w, "Download link has expired", http.StatusGone)
+		return
+	}
+	data, err := h.Blobs.Get(key)
+	if err == blob.ErrNotFound {
+		http.Error(w, "Download link has expired", http.StatusGone)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+path.Base(key)+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Write(data)
+}
+
+func (h *Handler) exportTTL() time.Duration {
+	if h.ExportTTL > 0 {
+		return h.ExportTTL
+	}
+	return DefaultExportTTL
+}
+
+// NotifyJob tells the owner of a job that it finished. Register it with
+// jobs.Queue.OnFinish.
+func (h *Handler) NotifyJob(job jobs.Job) {
+	if h.Notifications == nil {
+		return
+	}
+	// Rules run on every matching event; only their failures are news.
+	if job.Kind == ruleJobKind && job.State != jobs.StateFailed && job.ErrorCount == 0 {
+		return
+	}
+	typ := notify.JobDone
+	if job.State == jobs.StateFailed {
+		typ = notify.JobFailed
+	}
+	at := h.now()
+	if job.FinishedAt != nil {
+		at = *job.FinishedAt
+	}
+	h.Notifications.Add(job.OwnerID, notify.Notification{Type: typ, JobID: job.ID, Title: job.Kind, Actor: job.OwnerID, At: at})
+}
+
+// GetJob godoc
+// @Summary      Состояние фоновой задачи
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path      int  true  "ID задачи"
+// @Success      200  {object}  JobResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /jobs/{id} [get]
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.loadJob(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.jobResponse(job))
+}
+
+// GetJobResult godoc
+// @Summary      Результат фоновой задачи
+// @Description  Файл, который задача создала, например отчёт об импорте. Для экспорта — перенаправление на подписанную ссылку
+// @Tags         jobs
+// @Produce      application/octet-stream
+// @Param        id   path      int  true  "ID задачи"
+// @Success      200  {file}    binary
+// @Success      303  "Перенаправление на файл"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string  "Задачи нет или она ещё не закончилась"
+// @Router       /jobs/{id}/result [get]
+func (h *Handler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.loadJob(w, r)
+	if !ok {
+		return
+	}
+	result := h.Jobs.Result(job.ID)
+	if result == nil {
+		respondError(w, CodeNotFound, "Job has no result yet")
+		return
+	}
+	if result.URL != "" {
+		http.Redirect(w, r, result.URL, http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", result.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+result.Name+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(result.Data)))
+	w.Write(result.Data)
+}
+
+// loadJob fetches the job named by the {id} URL parameter. Jobs of other
+// users are reported as missing, except to admins.
+func (h *Handler) loadJob(w http.ResponseWriter, r *http.Request) (jobs.Job, bool) {
+	if h.Jobs == nil {
+		respondError(w, CodeFeatureDisabled, "Background jobs are not enabled")
+		return jobs.Job{}, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid job ID")
+		return jobs.Job{}, false
+	}
+	job, ok := h.Jobs.Get(id)
+	p := auth.FromContext(r.Context())
+	if !ok || !(p.Admin || job.OwnerID == p.UserID) {
+		respondError(w, CodeNotFound, "Job not found")
+		return jobs.Job{}, false
+	}
+	return job, true
+}
+
+func (h *Handler) jobResponse(job jobs.Job) JobResponse {
+	resp := JobResponse{Job: job}
+	if job.HasResult {
+		resp.ResultURL = "/api/v1/jobs/" + strconv.FormatInt(job.ID, 10) + "/result"
+		if result := h.Jobs.Result(job.ID); result != nil && result.URL != "" {
+			resp.ResultURL = result.URL
+		}
+	}
+	return resp
+}
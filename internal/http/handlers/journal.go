@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"text/template"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"github.com/go-chi/chi/v5"
+)
+
+const journalDateLayout = "2006-01-02"
+
+// JournalTemplate holds text/template sources for new journal notes.
+// Templates receive .Date (YYYY-MM-DD) and .Weekday.
+type JournalTemplate struct {
+	Title   string
+	Content string
+}
+
+var DefaultJournalTemplate = JournalTemplate{
+	Title:   "Дневник {{.Date}}",
+	Content: "",
+}
+
+type journalData struct {
+	Date    string
+	Weekday string
+}
+
+func (t JournalTemplate) render(day time.Time) (title, content string, err error) {
+	if t.Title == "" {
+		t.Title = DefaultJournalTemplate.Title
+	}
+
+	data := journalData{Date: day.Format(journalDateLayout), Weekday: day.Weekday().String()}
+
+	if title, err = execTemplate(t.Title, data); err != nil {
+		return "", "", err
+	}
+	if content, err = execTemplate(t.Content, data); err != nil {
+		return "", "", err
+	}
+	return title, content, nil
+}
+
+func execTemplate(src string, data journalData) (string, error) {
+	tmpl, err := template.New("journal").Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseJournalDate(r *http.Request) (time.Time, bool) {
+	day, err := time.Parse(journalDateLayout, chi.URLParam(r, "date"))
+	return day, err == nil
+}
+
+// GetJournal godoc
+// @Summary      Запись дневника за день
+// @Tags         journal
+// @Produce      json
+// @Param        date  path      string  true  "Дата (YYYY-MM-DD)"
+// @Success      200   {object}  core.Note
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /journal/{date} [get]
+func (h *Handler) GetJournal(w http.ResponseWriter, r *http.Request) {
+	day, ok := parseJournalDate(r)
+	if !ok {
+		respondError(w, CodeInvalidRequest, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	journal, ok := noteStore[journalStore](h, w, "Journal")
+	if !ok {
+		return
+	}
+	note, err := journal.GetJournal(auth.FromContext(r.Context()).UserID, day.Format(journalDateLayout))
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get journal")
+		return
+	}
+	if note == nil {
+		respondError(w, CodeNoteNotFound, "Journal note not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, note)
+}
+
+// CreateJournal godoc
+// @Summary      Получить или создать запись дневника
+// @Description  Возвращает запись за день, создавая её из шаблона, если её ещё нет
+// @Tags         journal
+// @Produce      json
+// @Param        date  path      string  true  "Дата (YYYY-MM-DD)"
+// @Success      200   {object}  core.Note  "Запись уже существовала"
+// @Success      201   {object}  core.Note  "Запись создана"
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /journal/{date} [post]
+func (h *Handler) CreateJournal(w http.ResponseWriter, r *http.Request) {
+	day, ok := parseJournalDate(r)
+	if !ok {
+		respondError(w, CodeInvalidRequest, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	title, content, err := h.JournalTemplate.render(day)
+	if err != nil {
+		respondError(w, CodeInternal, "Invalid journal template")
+		return
+	}
+
+	journal, ok := noteStore[journalStore](h, w, "Journal")
+	if !ok {
+		return
+	}
+	note, created, err := journal.GetOrCreateJournal(core.Note{
+		OwnerID:     auth.FromContext(r.Context()).UserID,
+		Type:        core.NoteTypeNote,
+		Title:       title,
+		Content:     content,
+		JournalDate: day.Format(journalDateLayout),
+	})
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to create journal")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, note)
+}
+
+// ListJournal godoc
+// @Summary      Лента дневника
+// @Description  Потоково отдаёт записи дневника в формате NDJSON, новые первыми
+// @Tags         journal
+// @Produce      application/x-ndjson
+// @Param        from  query     string  false  "С даты включительно (YYYY-MM-DD)"
+// @Param        to    query     string  false  "По дату включительно (YYYY-MM-DD)"
+// @Success      200   {array}   core.Note
+// @Failure      400   {object}  map[string]string
+// @Router       /journal [get]
+func (h *Handler) ListJournal(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	for _, v := range []string{from, to} {
+		if _, err := time.Parse(journalDateLayout, v); v != "" && err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	journal, ok := noteStore[journalStore](h, w, "Journal")
+	if !ok {
+		return
+	}
+	notes, err := journal.ListJournal(auth.FromContext(r.Context()).UserID, from, to)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get journal")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for _, note := range notes {
+		if err := encoder.Encode(note); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
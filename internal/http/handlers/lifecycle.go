@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+type TransitionRequest struct {
+	To string `json:"to" example:"done"`
+}
+
+// NoteTransitions is where a note is in the lifecycle and where it can go.
+type NoteTransitions struct {
+	State string   `json:"state" example:"active"`
+	Next  []string `json:"next" example:"done,draft"`
+}
+
+// GetLifecycle godoc
+// @Summary      Жизненный цикл заметок
+// @Description  Состояния заметок и разрешённые переходы между ними. Новые заметки получают первое состояние
+// @Tags         notes
+// @Produce      json
+// @Success      200  {object}  core.Lifecycle
+// @Router       /lifecycle [get]
+func (h *Handler) GetLifecycle(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.lifecycle())
+}
+
+// GetNoteTransitions godoc
+// @Summary      Состояние заметки
+// @Description  Текущее состояние заметки и состояния, в которые её можно перевести
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  NoteTransitions
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/transitions [get]
+func (h *Handler) GetNoteTransitions(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	state := h.stateOf(*note)
+	next := h.lifecycle().Transitions[state]
+	if next == nil {
+		next = []string{}
+	}
+	respondWithJSON(w, http.StatusOK, NoteTransitions{State: state, Next: next})
+}
+
+// TransitionNote godoc
+// @Summary      Перевести заметку в другое состояние
+// @Description  Переход должен быть разрешён жизненным циклом (GET /lifecycle); перевод в текущее состояние ничего не меняет
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string             true  "ID или публичный UUID"
+// @Param        input  body      TransitionRequest  true  "Новое состояние"
+// @Success      200    {object}  core.Note
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "Переход не разрешён (invalid_transition)"
+// @Failure      423    {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /notes/{id}/transitions [post]
+func (h *Handler) TransitionNote(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if !h.unlocked(w, r, *note) {
+		return
+	}
+	var req TransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	lifecycler, ok := noteStore[noteLifecycler](h, w, "Lifecycle")
+	if !ok {
+		return
+	}
+	note, err := lifecycler.Transition(auth.FromContext(r.Context()).UserID, note.ID, strings.ToLower(strings.TrimSpace(req.To)))
+	if err != nil {
+		respondErr(w, err, "Failed to change state")
+		return
+	}
+	h.withPaths(note)
+	respondWithJSON(w, http.StatusOK, note)
+}
+
+// lifecycle returns the lifecycle of the note repository, or
+// core.DefaultLifecycle when it has none.
+func (h *Handler) lifecycle() core.Lifecycle {
+	if lifecycler, ok := h.Repo.(noteLifecycler); ok {
+		return lifecycler.EffectiveLifecycle()
+	}
+	return core.DefaultLifecycle
+}
+
+// stateOf returns the lifecycle state of n. Notes in a state the
+// lifecycle no longer has are in its first one, as Transition treats them.
+func (h *Handler) stateOf(n core.Note) string {
+	lifecycle := h.lifecycle()
+	if !lifecycle.Has(n.State) {
+		return lifecycle.Initial()
+	}
+	return n.State
+}
+
+// stateFilter returns the states of the state parameter of note lists,
+// separated by commas, or false when one is not in the lifecycle.
+func (h *Handler) stateFilter(r *http.Request) (map[string]bool, bool) {
+	param := r.URL.Query().Get("state")
+	if param == "" {
+		return nil, true
+	}
+	lifecycle := h.lifecycle()
+	states := make(map[string]bool)
+	for _, state := range strings.Split(param, ",") {
+		state = strings.ToLower(strings.TrimSpace(state))
+		if !lifecycle.Has(state) {
+			return nil, false
+		}
+		states[state] = true
+	}
+	return states, true
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// LockNote godoc
+// @Summary      Заблокировать заметку для правки
+// @Description  Пока блокировка действует, остальные получают 423 (note_locked) на изменение, перевод состояния и удаление заметки. Блокировка истекает сама; повторный запрос владельца блокировки продлевает её (heartbeat)
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  core.NoteLock
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  ErrorResponse  "Заметку заблокировал другой пользователь"
+// @Router       /notes/{id}/lock [post]
+func (h *Handler) LockNote(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadLockNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	lock, err := h.Locks.Acquire(note.ID, auth.FromContext(r.Context()).UserID)
+	if err != nil {
+		respondErr(w, err, "Failed to lock note")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, lock)
+}
+
+// GetNoteLock godoc
+// @Summary      Кто заблокировал заметку
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  core.NoteLock
+// @Failure      404  {object}  map[string]string  "Заметка не заблокирована"
+// @Router       /notes/{id}/lock [get]
+func (h *Handler) GetNoteLock(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadLockNote(w, r)
+	if !ok {
+		return
+	}
+	lock, err := h.Locks.Get(note.ID)
+	if err != nil {
+		respondErr(w, err, "Failed to get lock")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, lock)
+}
+
+// UnlockNote godoc
+// @Summary      Снять блокировку
+// @Description  Снимает свою блокировку; владелец заметки снимает и чужую
+// @Tags         notes
+// @Param        id   path  string  true  "ID или публичный UUID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]string  "Заметка не заблокирована"
+// @Failure      423  {object}  ErrorResponse  "Заметку заблокировал другой пользователь"
+// @Router       /notes/{id}/lock [delete]
+func (h *Handler) UnlockNote(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadLockNote(w, r)
+	if !ok {
+		return
+	}
+	p := auth.FromContext(r.Context())
+	force := h.noteRole(p, *note).Allows(core.RoleOwner)
+	if err := h.Locks.Release(note.ID, p.UserID, force); err != nil {
+		respondErr(w, err, "Failed to unlock note")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) loadLockNote(w http.ResponseWriter, r *http.Request) (*core.Note, bool) {
+	if h.Locks == nil {
+		respondError(w, CodeFeatureDisabled, "Note locking is not enabled")
+		return nil, false
+	}
+	return h.loadNote(w, r)
+}
+
+// unlocked answers 423 when someone other than the caller holds the edit
+// lock of n.
+func (h *Handler) unlocked(w http.ResponseWriter, r *http.Request, n core.Note) bool {
+	if h.Locks == nil {
+		return true
+	}
+	if err := h.Locks.Check(n.ID, auth.FromContext(r.Context()).UserID); err != nil {
+		respondErr(w, err, "Failed to check lock")
+		return false
+	}
+	return true
+}
+
+// updateNote writes updates to a note on behalf of userID. Someone else
+// holding the edit lock of the note makes it a *repo.LockedError; an
+// empty userID, for writes nobody made by hand, yields to every lock.
+func (h *Handler) updateNote(userID string, id int64, updates map[string]interface{}) error {
+	if writer, ok := h.Repo.(noteWriter); ok {
+		return writer.UpdateAs(userID, id, updates)
+	}
+	if err := h.checkLock(id, userID); err != nil {
+		return err
+	}
+	return h.Repo.UpdatePartial(id, updates)
+}
+
+// deleteNote deletes a note on behalf of userID, refusing locked notes as
+// updateNote does.
+func (h *Handler) deleteNote(userID string, id int64) error {
+	if writer, ok := h.Repo.(noteWriter); ok {
+		return writer.DeleteAs(userID, id)
+	}
+	if err := h.checkLock(id, userID); err != nil {
+		return err
+	}
+	return h.Repo.Delete(id)
+}
+
+// checkLock is the lock check of repositories that cannot make it part
+// of the write.
+func (h *Handler) checkLock(id int64, userID string) error {
+	if h.Locks == nil {
+		return nil
+	}
+	return h.Locks.Check(id, userID)
+}
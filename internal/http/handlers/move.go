@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+type MoveNoteRequest struct {
+	NotebookID int64 `json:"notebook_id" example:"2"`
+}
+
+type BulkMoveRequest struct {
+	IDs        []int64 `json:"ids"`
+	NotebookID int64   `json:"notebook_id" example:"2"`
+}
+
+// MoveNote godoc
+// @Summary      Переместить заметку в другой блокнот
+// @Description  notebook_id=0 убирает заметку из блокнота
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string           true  "ID или публичный UUID"
+// @Param        input  body      MoveNoteRequest  true  "Целевой блокнот"
+// @Param        dry_run  query  bool  false  "Только проверить: ответ 200 с заметкой, какой она стала бы (или Prefer: dry-run)"
+// @Success      200    {object}  core.Note
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Failure      423    {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /notes/{id}/move [post]
+func (h *Handler) MoveNote(w http.ResponseWriter, r *http.Request) {
+	h.transferNote(w, r, false)
+}
+
+// CopyNote godoc
+// @Summary      Скопировать заметку в блокнот
+// @Description  Копия принадлежит текущему пользователю
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string           true  "ID или публичный UUID"
+// @Param        input  body      MoveNoteRequest  true  "Целевой блокнот"
+// @Param        dry_run  query  bool  false  "Только проверить: ответ 200 с заметкой, какой она стала бы (или Prefer: dry-run)"
+// @Success      201    {object}  core.Note
+// @Success      200    {object}  core.Note  "dry_run: копия не создана"
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Router       /notes/{id}/copy [post]
+func (h *Handler) CopyNote(w http.ResponseWriter, r *http.Request) {
+	h.transferNote(w, r, true)
+}
+
+// BulkMoveNotes godoc
+// @Summary      Переместить несколько заметок
+// @Description  Перемещаются либо все заметки, либо ни одной. Заголовок Undo-Token позволяет отменить перемещение через POST /undo/{token}
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        input  body      BulkMoveRequest  true  "Заметки и целевой блокнот"
+// @Param        dry_run  query  bool  false  "Только проверить: ответ 200 с заметками, какими они стали бы (или Prefer: dry-run)"
+// @Success      200    {array}   core.Note
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Failure      423    {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /notes/move [post]
+func (h *Handler) BulkMoveNotes(w http.ResponseWriter, r *http.Request) {
+	h.transferNotes(w, r, false)
+}
+
+// BulkCopyNotes godoc
+// @Summary      Скопировать несколько заметок
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        input  body      BulkMoveRequest  true  "Заметки и целевой блокнот"
+// @Param        dry_run  query  bool  false  "Только проверить: ответ 200 с заметками, какими они стали бы (или Prefer: dry-run)"
+// @Success      201    {array}   core.Note
+// @Success      200    {array}   core.Note  "dry_run: копии не созданы"
+// @Header       201    {string}  Undo-Token  "Токен для POST /undo/{token}"
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Router       /notes/copy [post]
+func (h *Handler) BulkCopyNotes(w http.ResponseWriter, r *http.Request) {
+	h.transferNotes(w, r, true)
+}
+
+func (h *Handler) transferNote(w http.ResponseWriter, r *http.Request, duplicate bool) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	var req MoveNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	if !duplicate && !h.unlocked(w, r, *note) {
+		return
+	}
+
+	notes, ok := h.transfer(w, r, []core.Note{*note}, req.NotebookID, duplicate)
+	if !ok {
+		return
+	}
+
+	if dryRun(r) {
+		respondDryRun(w, notes[0])
+		return
+	}
+	status := http.StatusOK
+	if duplicate {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, notes[0])
+}
+
+func (h *Handler) transferNotes(w http.ResponseWriter, r *http.Request, duplicate bool) {
+	var req BulkMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		respondError(w, CodeInvalidRequest, "No IDs given")
+		return
+	}
+
+	sources := make([]core.Note, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		note, err := h.Repo.GetByID(id)
+		if err != nil || !h.canRead(r, *note) {
+			respondError(w, CodeNoteNotFound, "Note not found")
+			return
+		}
+		if !duplicate && !h.unlocked(w, r, *note) {
+			return
+		}
+		sources = append(sources, *note)
+	}
+
+	notes, ok := h.transfer(w, r, sources, req.NotebookID, duplicate)
+	if !ok {
+		return
+	}
+
+	if dryRun(r) {
+		respondDryRun(w, notes)
+		return
+	}
+	status := http.StatusOK
+	if duplicate {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, notes)
+}
+
+// transfer checks permissions for moving or copying sources into the
+// notebook and performs the operation. Moving needs edit rights on every
+// note, copying only read rights; both need edit rights on the target.
+func (h *Handler) transfer(w http.ResponseWriter, r *http.Request, sources []core.Note, notebookID int64, duplicate bool) ([]core.Note, bool) {
+	mover, ok := noteStore[noteMover](h, w, "Moving notes")
+	if !ok {
+		return nil, false
+	}
+	p := auth.FromContext(r.Context())
+
+	if notebookID != 0 {
+		if _, err := h.Notebooks.GetByID(notebookID); err != nil {
+			respondError(w, CodeInvalidRequest, "Notebook not found")
+			return nil, false
+		}
+		if !h.Notebooks.Role(p, notebookID).Allows(core.RoleEditor) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return nil, false
+		}
+	}
+
+	ids := make([]int64, 0, len(sources))
+	for _, n := range sources {
+		if !duplicate && !h.canWrite(r, n) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return nil, false
+		}
+		ids = append(ids, n.ID)
+	}
+
+	if dryRun(r) {
+		preview := make([]core.Note, len(sources))
+		for i, n := range sources {
+			if n.NotebookID != notebookID {
+				n.NotebookID, n.Position = notebookID, 0
+			}
+			if duplicate {
+				n.ID, n.PublicID, n.OwnerID, n.JournalDate = 0, "", p.UserID, ""
+			}
+			h.withPaths(&n)
+			preview[i] = n
+		}
+		return preview, true
+	}
+
+	var notes []core.Note
+	var err error
+	if duplicate {
+		notes, err = mover.Copy(ids, notebookID, p.UserID)
+	} else {
+		notes, err = mover.Move(p.UserID, ids, notebookID)
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to transfer notes")
+		return nil, false
+	}
+
+	if duplicate {
+		copies := notes
+		h.offerUndo(w, r, "copy", func() ([]core.Note, error) {
+			for _, n := range copies {
+				if err := h.deleteNote(p.UserID, n.ID); err != nil && err != repo.ErrNoteNotFound {
+					return nil, err
+				}
+			}
+			return []core.Note{}, nil
+		})
+	} else {
+		h.offerUndo(w, r, "move", func() ([]core.Note, error) { return mover.MoveBack(sources) })
+	}
+
+	for i := range notes {
+		h.withPaths(&notes[i])
+	}
+	return notes, true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/core"
+)
+
+// The handlers serve notes from any core.NoteRepository. The features
+// below need more of it; they check Handler.Repo for the interface they
+// need and answer feature_disabled when it lacks it. repo.NoteRepoMem
+// implements all of them.
+
+// noteWriter writes notes on behalf of a user, refusing notes someone
+// else has locked in the same step as the write.
+type noteWriter interface {
+	UpdateAs(userID string, id int64, updates map[string]interface{}) error
+	DeleteAs(userID string, id int64) error
+}
+
+// noteResolver finds notes by public ID and by slug.
+type noteResolver interface {
+	Resolve(publicID string) (int64, error)
+	ResolveSlug(slug string) (int64, error)
+}
+
+// notePreviewer checks writes without storing them, for dry runs and
+// change requests.
+type notePreviewer interface {
+	// PreviewUpdate returns the note as UpdatePartial would leave it,
+	// without storing it.
+	PreviewUpdate(id int64, updates map[string]interface{}) (*core.Note, error)
+	// CheckTitle returns the error Create would return for a note titled
+	// title in notebookID because of a unique-title notebook.
+	CheckTitle(notebookID int64, title string) error
+}
+
+// noteRestorer brings deleted notes back, for undo.
+type noteRestorer interface {
+	// Restore stores n again under its own ID after a delete.
+	Restore(n core.Note) error
+}
+
+// noteSnapshotter lists notes consistently with the change stream.
+type noteSnapshotter interface {
+	// Snapshot returns every note and the sequence number of the last
+	// change they include.
+	Snapshot() ([]core.Note, int64)
+}
+
+// journalStore keeps one journal note per user and day.
+type journalStore interface {
+	GetJournal(ownerID, date string) (*core.Note, error)
+	GetOrCreateJournal(n core.Note) (*core.Note, bool, error)
+	ListJournal(ownerID, from, to string) ([]core.Note, error)
+}
+
+// noteMover moves and copies notes between notebooks.
+type noteMover interface {
+	Move(userID string, ids []int64, notebookID int64) ([]core.Note, error)
+	MoveBack(snapshots []core.Note) ([]core.Note, error)
+	Copy(ids []int64, notebookID int64, ownerID string) ([]core.Note, error)
+}
+
+// noteReorderer orders the notes of a notebook.
+type noteReorderer interface {
+	Reorder(notebookID int64, ids []int64) ([]core.Note, error)
+}
+
+// noteSharer hands out public links to notes.
+type noteSharer interface {
+	Share(id int64) (*core.Note, error)
+	Unshare(id int64) error
+	ResolveShare(token string) (int64, error)
+}
+
+// noteLifecycler moves notes through the states of a lifecycle.
+type noteLifecycler interface {
+	EffectiveLifecycle() core.Lifecycle
+	Transition(userID string, id int64, to string) (*core.Note, error)
+}
+
+// tagChanger changes the tags of a note in one step, so that edits made
+// since the caller read it are kept.
+type tagChanger interface {
+	ChangeTags(userID string, id int64, change func(tags []string) []string) (bool, error)
+}
+
+// nearbyFinder finds notes by location.
+type nearbyFinder interface {
+	Nearby(lat, lon, radius float64) ([]core.NearbyNote, error)
+}
+
+// noteReactor records emoji reactions to notes.
+type noteReactor interface {
+	React(id int64, userID, emoji string, add bool) (*core.Note, error)
+}
+
+// tagRenamer renames tags across notes.
+type tagRenamer interface {
+	RenameTag(userID, from, to string, match func(core.Note) bool) (int, error)
+}
+
+// noteStore returns h.Repo as T. When the repository does not implement
+// T it answers feature_disabled, naming feature, and returns false.
+func noteStore[T any](h *Handler, w http.ResponseWriter, feature string) (T, bool) {
+	store, ok := h.Repo.(T)
+	if !ok {
+		respondError(w, CodeFeatureDisabled, feature+" is not supported by the note store")
+	}
+	return store, ok
+}
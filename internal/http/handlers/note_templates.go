@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/cron"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateNoteTemplate godoc
+// @Summary      Создать шаблон заметки
+// @Description  Заголовок и текст — шаблоны text/template с .Date (YYYY-MM-DD) и .Weekday, как у дневника. С расписанием schedule (cron из пяти полей, например "0 9 * * MON", или @daily) сервер сам создаёт заметку по шаблону в блокноте notebook_id; время читается в timezone, по умолчанию в часовом поясе из настроек
+// @Tags         note-templates
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.NoteTemplateInput  true  "Шаблон"
+// @Success      201    {object}  core.NoteTemplate
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /note-templates [post]
+func (h *Handler) CreateNoteTemplate(w http.ResponseWriter, r *http.Request) {
+	if !h.noteTemplatesEnabled(w) {
+		return
+	}
+	t, ok := h.decodeNoteTemplate(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.NoteTemplates.Create(t)
+	if err != nil {
+		respondErr(w, err, "Failed to create template")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, t)
+}
+
+// ListNoteTemplates godoc
+// @Summary      Шаблоны заметок пользователя
+// @Tags         note-templates
+// @Produce      json
+// @Success      200  {array}   core.NoteTemplate
+// @Failure      404  {object}  map[string]string
+// @Router       /note-templates [get]
+func (h *Handler) ListNoteTemplates(w http.ResponseWriter, r *http.Request) {
+	if !h.noteTemplatesEnabled(w) {
+		return
+	}
+	list := h.NoteTemplates.ListByOwner(auth.FromContext(r.Context()).UserID)
+	if list == nil {
+		list = []core.NoteTemplate{}
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetNoteTemplate godoc
+// @Summary      Шаблон заметки
+// @Tags         note-templates
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.NoteTemplate
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /note-templates/{id} [get]
+func (h *Handler) GetNoteTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.loadNoteTemplate(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, t)
+}
+
+// PutNoteTemplate godoc
+// @Summary      Заменить шаблон заметки
+// @Description  Следующий запуск считается заново от текущего момента
+// @Tags         note-templates
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                     true  "ID"
+// @Param        input  body      core.NoteTemplateInput  true  "Шаблон"
+// @Success      200    {object}  core.NoteTemplate
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /note-templates/{id} [put]
+func (h *Handler) PutNoteTemplate(w http.ResponseWriter, r *http.Request) {
+	old, ok := h.loadNoteTemplate(w, r)
+	if !ok {
+		return
+	}
+	t, ok := h.decodeNoteTemplate(w, r)
+	if !ok {
+		return
+	}
+	t.ID = old.ID
+	t, err := h.NoteTemplates.Replace(t)
+	if err != nil {
+		respondErr(w, err, "Failed to update template")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, t)
+}
+
+// DeleteNoteTemplate godoc
+// @Summary      Удалить шаблон заметки
+// @Description  Созданные по шаблону заметки остаются
+// @Tags         note-templates
+// @Param        id   path  int  true  "ID"
+// @Success      204  "Шаблон удалён"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /note-templates/{id} [delete]
+func (h *Handler) DeleteNoteTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.loadNoteTemplate(w, r)
+	if !ok {
+		return
+	}
+	if err := h.NoteTemplates.Delete(t.ID); err != nil {
+		respondErr(w, err, "Failed to delete template")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateNoteFromTemplate godoc
+// @Summary      Создать заметку по шаблону
+// @Description  Подставляет сегодняшнюю дату в часовом поясе шаблона, не дожидаясь расписания
+// @Tags         note-templates
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      201  {object}  core.Note
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Router       /note-templates/{id}/notes [post]
+func (h *Handler) CreateNoteFromTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.loadNoteTemplate(w, r)
+	if !ok {
+		return
+	}
+	id, err := h.createFromTemplate(*t, h.now().In(h.templateLocation(*t)))
+	if err != nil {
+		respondErr(w, err, "Failed to create note")
+		return
+	}
+	note, err := h.Repo.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve created note")
+		return
+	}
+	h.withPaths(note)
+	respondWithJSON(w, http.StatusCreated, note)
+}
+
+func (h *Handler) noteTemplatesEnabled(w http.ResponseWriter) bool {
+	if h.NoteTemplates == nil {
+		respondError(w, CodeFeatureDisabled, "Note templates are not enabled")
+		return false
+	}
+	return true
+}
+
+// loadNoteTemplate returns the template of the URL, which only its owner
+// can see.
+func (h *Handler) loadNoteTemplate(w http.ResponseWriter, r *http.Request) (*core.NoteTemplate, bool) {
+	if !h.noteTemplatesEnabled(w) {
+		return nil, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid template ID")
+		return nil, false
+	}
+	t, err := h.NoteTemplates.GetByID(id)
+	if err == nil && t.OwnerID != auth.FromContext(r.Context()).UserID {
+		err = repo.ErrTemplateNotFound
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to get template")
+		return nil, false
+	}
+	return t, true
+}
+
+// decodeNoteTemplate reads and validates a core.NoteTemplateInput, and
+// schedules the first run of the template.
+func (h *Handler) decodeNoteTemplate(w http.ResponseWriter, r *http.Request) (core.NoteTemplate, bool) {
+	var in core.NoteTemplateInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return core.NoteTemplate{}, false
+	}
+
+	t := core.NoteTemplate{
+		OwnerID:    auth.FromContext(r.Context()).UserID,
+		Name:       strings.TrimSpace(in.Name),
+		Title:      in.Title,
+		Content:    in.Content,
+		Tags:       core.NormalizeTags(in.Tags),
+		NotebookID: in.NotebookID,
+		Schedule:   strings.TrimSpace(in.Schedule),
+		Timezone:   strings.TrimSpace(in.Timezone),
+	}
+	invalid := &core.ErrValidation{Fields: map[string]string{}}
+	if t.Name == "" {
+		invalid.Fields["name"] = "name is required"
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		invalid.Fields["title"] = "title is required"
+	} else if _, err := template.New("title").Parse(t.Title); err != nil {
+		invalid.Fields["title"] = err.Error()
+	}
+	if _, err := template.New("content").Parse(t.Content); err != nil {
+		invalid.Fields["content"] = err.Error()
+	}
+	if t.NotebookID != 0 && !h.Notebooks.Role(auth.FromContext(r.Context()), t.NotebookID).Allows(core.RoleEditor) {
+		invalid.Fields["notebook_id"] = "notebook not found"
+	}
+	if t.Timezone != "" {
+		if _, err := time.LoadLocation(t.Timezone); err != nil {
+			invalid.Fields["timezone"] = "unknown timezone " + t.Timezone
+		}
+	}
+	if t.Schedule != "" {
+		if _, err := cron.Parse(t.Schedule); err != nil {
+			invalid.Fields["schedule"] = err.Error()
+		} else if t.NextRunAt = h.nextTemplateRun(t, h.now()); t.NextRunAt == nil {
+			invalid.Fields["schedule"] = "schedule never matches"
+		}
+	}
+	if len(invalid.Fields) > 0 {
+		respondErr(w, invalid, "")
+		return core.NoteTemplate{}, false
+	}
+	return t, true
+}
+
+// templateLocation is the time zone of t: its own, else its owner's.
+func (h *Handler) templateLocation(t core.NoteTemplate) *time.Location {
+	tz := t.Timezone
+	if tz == "" {
+		tz = h.preferences(t.OwnerID).Timezone
+	}
+	if loc, err := time.LoadLocation(tz); err == nil && tz != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// nextTemplateRun returns the first time after after that the schedule of
+// t matches, or nil for unscheduled templates.
+func (h *Handler) nextTemplateRun(t core.NoteTemplate, after time.Time) *time.Time {
+	s, err := cron.Parse(t.Schedule)
+	if t.Schedule == "" || err != nil {
+		return nil
+	}
+	next := s.Next(after.In(h.templateLocation(t)))
+	if next.IsZero() {
+		return nil
+	}
+	next = next.UTC()
+	return &next
+}
+
+// createFromTemplate creates the note of t for day, a time in the time
+// zone of t.
+func (h *Handler) createFromTemplate(t core.NoteTemplate, day time.Time) (int64, error) {
+	title, content, err := JournalTemplate{Title: t.Title, Content: t.Content}.render(day)
+	if err != nil {
+		return 0, core.Invalid("title", err.Error())
+	}
+	n := core.Note{
+		OwnerID:    t.OwnerID,
+		NotebookID: t.NotebookID,
+		Type:       core.NoteTypeNote,
+		Title:      title,
+		Content:    content,
+		Tags:       append([]string(nil), t.Tags...),
+	}
+	if err := h.applyNotebookDefaults(&n); err != nil {
+		return 0, err
+	}
+	return h.Repo.Create(n)
+}
+
+// RunNoteTemplates creates the notes of the schedules due at now, each in
+// a background job when jobs are enabled, so that the owner is notified.
+func (h *Handler) RunNoteTemplates(now time.Time) {
+	if h.NoteTemplates == nil {
+		return
+	}
+	for _, t := range h.NoteTemplates.Due(now, h.nextTemplateRun) {
+		run := func() error {
+			id, err := h.createFromTemplate(t, t.NextRunAt.In(h.templateLocation(t)))
+			if err != nil {
+				return err
+			}
+			h.NoteTemplates.Ran(t.ID, id)
+			return nil
+		}
+		if h.Jobs == nil {
+			if err := run(); err != nil {
+				log.Printf("note template %d: %v", t.ID, err)
+			}
+			continue
+		}
+		_, err := h.Jobs.Submit("note_template", t.OwnerID, func(ctx context.Context, p *jobs.Progress) (*jobs.Result, error) {
+			p.SetTotal(1)
+			if err := run(); err != nil {
+				return nil, err
+			}
+			p.Step()
+			return nil, nil
+		})
+		if err != nil {
+			log.Printf("note template %d: %v", t.ID, err)
+		}
+	}
+}
+
+// StartNoteTemplates runs the due schedules every interval until ctx is
+// cancelled.
+func (h *Handler) StartNoteTemplates(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			h.RunNoteTemplates(h.now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
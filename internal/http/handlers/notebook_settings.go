@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// GetNotebookSettings godoc
+// @Summary      Настройки блокнота
+// @Description  Значения по умолчанию для новых заметок блокнота
+// @Tags         notebooks
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.NotebookSettings
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notebooks/{id}/settings [get]
+func (h *Handler) GetNotebookSettings(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleViewer)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Notebooks.Settings(nb.ID))
+}
+
+// PutNotebookSettings godoc
+// @Summary      Заменить настройки блокнота
+// @Description  Новые заметки блокнота получают теги tags в дополнение к своим, цвет color (#rrggbb), если не выбрали свой, заголовок и текст шаблона template_id (свой шаблон заметки), если оставили их пустыми, и удаляются через retention_days дней после создания. Уже созданные заметки не меняются. Пустой объект убирает настройки
+// @Tags         notebooks
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                    true  "ID"
+// @Param        input  body      core.NotebookSettings  true  "Настройки"
+// @Success      200    {object}  core.NotebookSettings
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notebooks/{id}/settings [put]
+func (h *Handler) PutNotebookSettings(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var s core.NotebookSettings
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	s.Tags = core.NormalizeTags(s.Tags)
+	s.Color = strings.ToLower(strings.TrimSpace(s.Color))
+
+	invalid := &core.ErrValidation{Fields: map[string]string{}}
+	if err := core.ValidateColor(s.Color); err != nil {
+		invalid.Fields["color"] = err.Error()
+	}
+	if s.TemplateID != 0 {
+		if h.NoteTemplates == nil {
+			invalid.Fields["template_id"] = "note templates are not enabled"
+		} else if t, err := h.NoteTemplates.GetByID(s.TemplateID); err != nil || t.OwnerID != auth.FromContext(r.Context()).UserID {
+			invalid.Fields["template_id"] = "template not found"
+		}
+	}
+	if s.RetentionDays < 0 || s.RetentionDays > core.MaxRetentionDays {
+		invalid.Fields["retention_days"] = "retention_days must be within 0-36500"
+	}
+	if len(invalid.Fields) > 0 {
+		respondErr(w, invalid, "")
+		return
+	}
+
+	if err := h.Notebooks.SetSettings(nb.ID, s); err != nil {
+		respondErr(w, err, "Failed to update notebook settings")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Notebooks.Settings(nb.ID))
+}
+
+// applyNotebookDefaults gives n, a note about to be created, the settings
+// of its notebook.
+func (h *Handler) applyNotebookDefaults(n *core.Note) error {
+	if n.NotebookID == 0 {
+		return nil
+	}
+	s := h.Notebooks.Settings(n.NotebookID)
+
+	tags := append(append([]string(nil), n.Tags...), s.Tags...)
+	if s.TemplateID != 0 && h.NoteTemplates != nil &&
+		(strings.TrimSpace(n.Title) == "" || (n.Content == "" && len(n.Blocks) == 0)) {
+		// The template may have been deleted since; the note is then
+		// created without it.
+		if t, err := h.NoteTemplates.GetByID(s.TemplateID); err == nil {
+			title, content, err := JournalTemplate{Title: t.Title, Content: t.Content}.render(h.now().In(h.templateLocation(*t)))
+			if err != nil {
+				return core.Invalid("title", err.Error())
+			}
+			if strings.TrimSpace(n.Title) == "" {
+				n.Title = title
+			}
+			if n.Content == "" && len(n.Blocks) == 0 {
+				n.Content = content
+			}
+			tags = append(tags, t.Tags...)
+		}
+	}
+	n.Tags = core.NormalizeTags(tags)
+
+	if n.Color == "" {
+		n.Color = s.Color
+	}
+	if s.RetentionDays > 0 {
+		expires := h.now().AddDate(0, 0, s.RetentionDays).UTC()
+		n.ExpiresAt = &expires
+	}
+	return nil
+}
+
+// ExpireNotes deletes the notes whose retention ended by now.
+func (h *Handler) ExpireNotes(now time.Time) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		log.Printf("expire notes: %v", err)
+		return
+	}
+	for _, n := range notes {
+		if n.ExpiresAt == nil || n.ExpiresAt.After(now) {
+			continue
+		}
+		// Notes locked for editing expire once the lock is released.
+		if err := h.deleteNote("", n.ID); err != nil {
+			log.Printf("expire note %d: %v", n.ID, err)
+		}
+	}
+}
+
+// StartRetention deletes expired notes every interval until ctx is
+// cancelled.
+func (h *Handler) StartRetention(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			h.ExpireNotes(h.now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateNotebook godoc
+// @Summary      Создать блокнот
+// @Description  Блокнот можно вложить в другой, если у пользователя есть права на редактирование родителя
+// @Tags         notebooks
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.NotebookCreate  true  "Данные блокнота"
+// @Success      201    {object}  core.Notebook
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Router       /notebooks [post]
+func (h *Handler) CreateNotebook(w http.ResponseWriter, r *http.Request) {
+	var input core.NotebookCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		respondError(w, CodeInvalidRequest, "Name is required")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	if input.ParentID != 0 && !h.Notebooks.Role(p, input.ParentID).Allows(core.RoleEditor) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+
+	id, err := h.Notebooks.Create(core.Notebook{Name: name, ParentID: input.ParentID, OwnerID: p.UserID, UniqueTitles: input.UniqueTitles})
+	if err != nil {
+		respondErr(w, err, "Failed to create notebook")
+		return
+	}
+
+	nb, err := h.Notebooks.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve created notebook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, nb)
+}
+
+// ListNotebooks godoc
+// @Summary      Список блокнотов
+// @Description  Блокноты, к которым у пользователя есть доступ (свои, общие и унаследованные)
+// @Tags         notebooks
+// @Produce      json
+// @Success      200  {array}   core.Notebook
+// @Failure      500  {object}  map[string]string
+// @Router       /notebooks [get]
+func (h *Handler) ListNotebooks(w http.ResponseWriter, r *http.Request) {
+	notebooks, err := h.Notebooks.GetAll()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notebooks")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	visible := make([]core.Notebook, 0, len(notebooks))
+	for _, nb := range notebooks {
+		if h.Notebooks.Role(p, nb.ID).Allows(core.RoleViewer) {
+			visible = append(visible, nb)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, visible)
+}
+
+// GetNotebook godoc
+// @Summary      Получить блокнот
+// @Tags         notebooks
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.Notebook
+// @Failure      404  {object}  map[string]string
+// @Router       /notebooks/{id} [get]
+func (h *Handler) GetNotebook(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleViewer)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, nb)
+}
+
+// PatchNotebook godoc
+// @Summary      Переименовать или переместить блокнот
+// @Description  Переименование доступно редакторам, перемещение — только владельцу. unique_titles запрещает заметкам блокнота повторять заголовки; уже повторяющиеся заголовки остаются, проверяются только новые заметки и переименования. require_approval (только владелец) превращает правки заметок блокнота всеми, кроме владельца, в запросы на изменение
+// @Tags         notebooks
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                  true  "ID"
+// @Param        input  body      core.NotebookUpdate  true  "Поля для обновления"
+// @Success      200    {object}  core.Notebook
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notebooks/{id} [patch]
+func (h *Handler) PatchNotebook(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var update core.NotebookUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if update.Name == nil && update.ParentID == nil && update.UniqueTitles == nil && update.RequireApproval == nil {
+		respondError(w, CodeInvalidRequest, "No fields to update")
+		return
+	}
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			respondError(w, CodeInvalidRequest, "Name cannot be empty")
+			return
+		}
+		update.Name = &name
+	}
+
+	p := auth.FromContext(r.Context())
+	if update.ParentID != nil {
+		if !h.Notebooks.Role(p, nb.ID).Allows(core.RoleOwner) {
+			respondError(w, CodeForbidden, "Only the owner can move a notebook")
+			return
+		}
+		if *update.ParentID != 0 && !h.Notebooks.Role(p, *update.ParentID).Allows(core.RoleEditor) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return
+		}
+	}
+	if update.RequireApproval != nil {
+		if h.ChangeRequests == nil {
+			respondError(w, CodeInvalidRequest, "Change requests are not enabled")
+			return
+		}
+		if !h.Notebooks.Role(p, nb.ID).Allows(core.RoleOwner) {
+			respondError(w, CodeForbidden, "Only the owner can change approval mode")
+			return
+		}
+	}
+
+	if update.Name != nil || update.ParentID != nil {
+		if err := h.Notebooks.Update(nb.ID, update.Name, update.ParentID); err != nil {
+			respondErr(w, err, "Failed to update notebook")
+			return
+		}
+	}
+	if update.UniqueTitles != nil {
+		if err := h.Notebooks.SetUniqueTitles(nb.ID, *update.UniqueTitles); err != nil {
+			respondErr(w, err, "Failed to update notebook")
+			return
+		}
+	}
+	if update.RequireApproval != nil {
+		if err := h.Notebooks.SetRequireApproval(nb.ID, *update.RequireApproval); err != nil {
+			respondErr(w, err, "Failed to update notebook")
+			return
+		}
+	}
+
+	updated, err := h.Notebooks.GetByID(nb.ID)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve updated notebook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, updated)
+}
+
+// DeleteNotebook godoc
+// @Summary      Удалить блокнот
+// @Description  Удалить можно только пустой блокнот без заметок и вложенных блокнотов
+// @Tags         notebooks
+// @Param        id   path  int  true  "ID"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /notebooks/{id} [delete]
+func (h *Handler) DeleteNotebook(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleOwner)
+	if !ok {
+		return
+	}
+
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to delete notebook")
+		return
+	}
+	for _, n := range notes {
+		if n.NotebookID == nb.ID {
+			respondError(w, CodeConflict, "Notebook is not empty")
+			return
+		}
+	}
+
+	if err := h.Notebooks.Delete(nb.ID); err != nil {
+		respondErr(w, err, "Failed to delete notebook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareNotebook godoc
+// @Summary      Открыть доступ к блокноту
+// @Description  Выдаёт пользователю ("alice") или команде ("team:devs") роль viewer или editor; доступ наследуется вложенными блокнотами
+// @Tags         notebooks
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int         true  "ID"
+// @Param        input  body      core.Share  true  "Кому и какая роль"
+// @Success      200    {object}  core.Notebook
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notebooks/{id}/shares [put]
+func (h *Handler) ShareNotebook(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var share core.Share
+	if err := json.NewDecoder(r.Body).Decode(&share); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	share.Grantee = strings.TrimSpace(share.Grantee)
+	if share.Grantee == "" || share.Grantee == core.TeamPrefix || !share.Role.Valid() {
+		respondError(w, CodeInvalidRequest, "Share needs a grantee and a viewer or editor role")
+		return
+	}
+
+	if err := h.Notebooks.SetShare(nb.ID, share); err != nil {
+		respondError(w, CodeInternal, "Failed to share notebook")
+		return
+	}
+
+	h.respondNotebook(w, nb.ID)
+}
+
+// UnshareNotebook godoc
+// @Summary      Закрыть доступ к блокноту
+// @Tags         notebooks
+// @Produce      json
+// @Param        id       path      int     true  "ID"
+// @Param        grantee  path      string  true  "Пользователь или team:<команда>"
+// @Success      200      {object}  core.Notebook
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Router       /notebooks/{id}/shares/{grantee} [delete]
+func (h *Handler) UnshareNotebook(w http.ResponseWriter, r *http.Request) {
+	nb, ok := h.loadNotebook(w, r, core.RoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := h.Notebooks.RemoveShare(nb.ID, chi.URLParam(r, "grantee")); err != nil {
+		respondError(w, CodeInternal, "Failed to unshare notebook")
+		return
+	}
+
+	h.respondNotebook(w, nb.ID)
+}
+
+func (h *Handler) respondNotebook(w http.ResponseWriter, id int64) {
+	nb, err := h.Notebooks.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve notebook")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, nb)
+}
+
+// loadNotebook fetches the notebook named by the {id} URL parameter and
+// checks the caller holds at least min on it. Notebooks the caller cannot
+// see are reported as missing.
+func (h *Handler) loadNotebook(w http.ResponseWriter, r *http.Request, min core.Role) (*core.Notebook, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid notebook ID")
+		return nil, false
+	}
+
+	nb, err := h.Notebooks.GetByID(id)
+	if err != nil {
+		respondErr(w, err, "Failed to get notebook")
+		return nil, false
+	}
+
+	role := h.Notebooks.Role(auth.FromContext(r.Context()), id)
+	if !role.Allows(core.RoleViewer) {
+		respondError(w, CodeNotebookNotFound, "Notebook not found")
+		return nil, false
+	}
+	if !role.Allows(min) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return nil, false
+	}
+
+	return nb, true
+}
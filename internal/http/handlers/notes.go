@@ -5,18 +5,163 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-
+	"time"
+
+	"example.com/notes-api/internal/attach"
+	"example.com/notes-api/internal/audit"
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/blob"
+	"example.com/notes-api/internal/cache"
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/cloudsync"
 	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/events"
+	"example.com/notes-api/internal/highlight"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/mailer"
+	"example.com/notes-api/internal/metrics"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/ocr"
+	"example.com/notes-api/internal/pdf"
+	"example.com/notes-api/internal/preview"
+	"example.com/notes-api/internal/ratelimit"
+	"example.com/notes-api/internal/render"
 	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/search"
+	"example.com/notes-api/internal/transcribe"
+	"example.com/notes-api/internal/undo"
 	"github.com/go-chi/chi/v5"
 )
 
 type Handler struct {
-	Repo *repo.NoteRepoMem
+	Repo            core.NoteRepository
+	Notebooks       *repo.NotebookRepoMem
+	Policy          VersionPolicy
+	JournalTemplate JournalTemplate
+	// Sync is nil unless a sync folder is configured.
+	Sync *cloudsync.Syncer
+	// Events streams note changes to clients; nil disables GET /events.
+	Events *events.Hub
+	// Presence tracks who has notes open, through Events; nil disables
+	// it.
+	Presence *events.Presence
+	// CDC retains changes for GET /admin/cdc; nil disables it.
+	CDC *repo.ChangeLog
+	// Search serves the q filter of ListNotes; nil disables full-text search.
+	Search *search.Service
+	// Titles serves GET /search/typeahead; nil disables it.
+	Titles *search.Titles
+	// ListCache caches ListNotes results; nil disables caching.
+	ListCache *cache.Lists
+	// LastModified enables conditional ListNotes requests.
+	LastModified *repo.LastModified
+	// Reads coalesces identical concurrent note reads; nil disables it.
+	Reads       *cache.Group
+	Collections *repo.CollectionRepoMem
+	// Views records when shared notes are viewed; nil disables it.
+	Views *repo.ViewLog
+	// Storage counts what each user's notes take up; nil disables
+	// GET /stats/storage.
+	Storage *repo.StorageUsage
+	// Metrics supplies request counts to GET /admin/stats; nil leaves
+	// them out.
+	Metrics *metrics.Registry
+	// Notifications and Watches enable watching notes; nil disables it.
+	Notifications *notify.Inbox
+	Watches       *notify.Watches
+	// Preferences stores per-user settings; nil leaves everyone on the
+	// defaults.
+	Preferences *repo.PreferenceRepoMem
+	// Clock tells handlers the time; nil uses the system clock.
+	Clock clock.Clock
+	// BaseURL is the external address of the server, such as
+	// https://notes.example.com, used in links the API hands out. Empty
+	// derives it from each request.
+	BaseURL string
+	// PDFFont enables PDF print views; nil answers them with 501.
+	PDFFont *pdf.Font
+	// Jobs runs imports and exports in the background; nil disables
+	// /import/jobs, /export and /jobs.
+	Jobs *jobs.Queue
+	// Blobs stores export files, downloaded through URLs signed with
+	// Downloads that expire after ExportTTL (DefaultExportTTL when zero).
+	// nil disables /export.
+	Blobs     blob.Store
+	Downloads blob.Signer
+	ExportTTL time.Duration
+	// Attachments stores files uploaded to notes; nil disables
+	// attachments and uploads.
+	Attachments *attach.Store
+	// Audit records security events for GET /admin/audit; nil disables it.
+	Audit *audit.Log
+	// OCR recognizes text in image attachments so that notes can be found
+	// by it; nil disables OCR.
+	OCR ocr.Recognizer
+	// Transcriber turns audio attachments into searchable text; nil
+	// disables transcription.
+	Transcriber transcribe.Transcriber
+	// Previews fetches the pages notes link to; nil disables
+	// GET /notes/{id}/link-previews.
+	Previews *preview.Service
+	// Clipper fetches pages for POST /clip; nil disables clipping.
+	Clipper *preview.Fetcher
+	// Mailer sends notes for POST /notes/{id}/email; nil disables it.
+	// EmailLimiter, when set, holds each user to EmailLimit emails.
+	Mailer       mailer.Mailer
+	EmailLimiter ratelimit.Limiter
+	EmailLimit   ratelimit.Limit
+	// PrintTemplates are the layouts admins registered for the print view
+	// and PDF export; nil disables them.
+	PrintTemplates *render.Templates
+	// Undo keeps destructive operations revertible through POST
+	// /undo/{token} for a short while; nil disables undo.
+	Undo *undo.Buffer
+	// DebugEchoOpen lets every caller use GET /debug/echo, not only
+	// admins; for development setups.
+	DebugEchoOpen bool
+	// NoteTemplates stores note templates and their schedules; nil
+	// disables them.
+	NoteTemplates *repo.TemplateRepoMem
+	// SavedViews stores named note filters; nil disables them.
+	SavedViews *repo.SavedViewRepoMem
+	// Reviews keeps the spaced-repetition review of notes; nil disables
+	// it.
+	Reviews *repo.ReviewRepoMem
+	// InboundHooks maps the tokens of inbound webhooks to the notes they
+	// create; nil disables them.
+	InboundHooks *repo.InboundHookRepoMem
+	// Rules automates notes on events; nil, or nil Jobs, disables them.
+	// RuleClient posts their webhooks; nil only reaches public addresses.
+	Rules      *repo.RuleRepoMem
+	RuleClient *http.Client
+	// ChangeRequests holds the edits of notebooks in approval mode; nil
+	// disables approval mode.
+	ChangeRequests *repo.ChangeRequestRepoMem
+	// Locks holds the edit locks of notes; nil disables locking.
+	Locks *repo.LockRepoMem
+	// Comments holds the comment threads of notes; nil disables them.
+	Comments *repo.CommentRepoMem
+	// Recent keeps the notes each user viewed last; nil disables it.
+	Recent *repo.RecentRepoMem
+	// Favorites keeps each user's favorite notes; nil disables them.
+	Favorites *repo.FavoriteRepoMem
+	// Emoji are the custom emoji admins added to the built-in shortcodes;
+	// nil leaves only the built-in ones.
+	Emoji *render.Emoji
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Code is one of those GET /errors lists.
+	Code string `json:"code" example:"note_not_found"`
+	// Fields maps invalid fields to what is wrong with them, for
+	// validation_failed.
+	Fields map[string]string `json:"fields,omitempty"`
+	// ConflictingID is the note whose title a duplicate_title request
+	// would repeat.
+	ConflictingID int64 `json:"conflicting_id,omitempty"`
+	// Lock is the edit lock someone else holds, for note_locked.
+	Lock *core.NoteLock `json:"lock,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -24,132 +169,590 @@ type SuccessResponse struct {
 }
 
 type UpdateNoteRequest struct {
-	Title   *string `json:"title"`
-	Content *string `json:"content"`
+	Title        *string                `json:"title"`
+	Content      *string                `json:"content"`
+	Blocks       *[]core.Block          `json:"blocks"`
+	Language     *string                `json:"language"`
+	Latitude     *float64               `json:"latitude"`
+	Longitude    *float64               `json:"longitude"`
+	Tags         *[]string              `json:"tags"`
+	Pinned       *bool                  `json:"pinned"`
+	RemindAt     *time.Time             `json:"remind_at"`
+	Color        *string                `json:"color"`
+	Properties   map[string]interface{} `json:"properties"`
+	CollectionID *int64                 `json:"collection_id"`
 }
 
 // CreateNote godoc
 // @Summary      Создать заметку
+// @Description  Заметка получает настройки блокнота (GET /notebooks/{id}/settings): его теги, цвет и срок хранения, а пустые заголовок или текст берутся из его шаблона
 // @Tags         notes
 // @Accept       json
 // @Produce      json
-// @Param        input  body     core.Note  true  "Данные новой заметки"
+// @Param        input  body     core.NoteCreate  true  "Данные новой заметки"
+// @Param        dry_run  query  bool  false  "Только проверить запрос: ответ 200 с тем, что получилось бы, без сохранения (или Prefer: dry-run)"
+// @Param        date_format  query  string  false  "Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек" Enums(short, medium, long)
 // @Success      201    {object} core.Note
+// @Success      200    {object} core.Note  "dry_run: заметка не создана"
 // @Failure      400    {object} map[string]string
+// @Failure      403    {object} map[string]string
+// @Failure      404    {object} ErrorResponse  "dry_run: хранилище заметок не умеет проверять записи (feature_disabled)"
 // @Failure      500    {object} map[string]string
+// @Failure      409    {object} map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
 // @Router       /notes [post]
 func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
-	var n core.Note
+	dates, ok := h.dateFormatter(w, r)
+	if !ok {
+		return
+	}
+
+	var input core.NoteCreate
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	n := input.Note()
+	n.OwnerID = auth.FromContext(r.Context()).UserID
+
+	if n.Type == "" {
+		n.Type = core.NoteTypeNote
+	}
+	if !core.ValidNoteType(n.Type) {
+		respondError(w, CodeInvalidRequest, "Unknown note type")
+		return
+	}
+	if n.Type != core.NoteTypeSnippet {
+		n.Language = ""
+	}
+
+	if err := core.ValidateLocation(n.Latitude, n.Longitude); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err := core.ValidateColor(n.Color); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if len(n.Blocks) > 0 {
+		if err := core.ValidateBlocks(n.Blocks); err != nil {
+			respondError(w, CodeInvalidRequest, err.Error())
+			return
+		}
+		n.Content = core.PlainText(n.Blocks)
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid JSON")
+	if err := core.ValidateProperties(n.Properties, false); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+	if len(n.Properties) == 0 {
+		n.Properties = nil
+	}
+	if n.CollectionID != 0 && !h.checkCollection(w, r, n.CollectionID, n.Properties) {
+		return
+	}
+
+	if n.NotebookID == 0 {
+		n.NotebookID = h.defaultNotebook(r)
+	}
+	if n.NotebookID != 0 {
+		if _, err := h.Notebooks.GetByID(n.NotebookID); err != nil {
+			respondError(w, CodeInvalidRequest, "Notebook not found")
+			return
+		}
+		if !h.Notebooks.Role(auth.FromContext(r.Context()), n.NotebookID).Allows(core.RoleEditor) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return
+		}
+	}
+	if err := h.applyNotebookDefaults(&n); err != nil {
+		respondErr(w, err, "Failed to apply notebook settings")
 		return
 	}
 
 	if strings.TrimSpace(n.Title) == "" {
-		respondWithError(w, http.StatusBadRequest, "Title is required")
+		respondError(w, CodeInvalidRequest, "Title is required")
+		return
+	}
+
+	if dryRun(r) {
+		previewer, ok := noteStore[notePreviewer](h, w, "Dry run")
+		if !ok {
+			return
+		}
+		if err := previewer.CheckTitle(n.NotebookID, n.Title); err != nil {
+			respondErr(w, err, "Failed to create note")
+			return
+		}
+		n.Slug = core.Slugify(n.Title)
+		n.CreatedAt = h.now()
+		h.withPaths(&n)
+		withDates(dates, &n)
+		respondDryRun(w, n)
 		return
 	}
 
 	id, err := h.Repo.Create(n)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create note")
+		respondErr(w, err, "Failed to create note")
 		return
 	}
 
 	createdNote, err := h.Repo.GetByID(id)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve created note")
+		respondError(w, CodeInternal, "Failed to retrieve created note")
 		return
 	}
 
+	h.withPaths(createdNote)
+	withDates(dates, createdNote)
 	respondWithJSON(w, http.StatusCreated, createdNote)
 }
 
 // GetNote godoc
 // @Summary      Получить заметку
 // @Tags         notes
-// @Param        id   path   int  true  "ID"
+// @Param        id   path   string  true  "ID или публичный UUID"
+// @Param        date_format  query  string  false  "Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек" Enums(short, medium, long)
 // @Success      200  {object}  core.Note
+// @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Router       /notes/{id} [get]
 func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid note ID")
+	note, ok := h.loadNote(w, r)
+	if !ok {
 		return
 	}
-
-	note, err := h.Repo.GetByID(id)
-	if err != nil {
-		if err == repo.ErrNoteNotFound {
-			respondWithError(w, http.StatusNotFound, "Note not found")
-		} else {
-			respondWithError(w, http.StatusInternalServerError, "Failed to get note")
-		}
+	dates, ok := h.dateFormatter(w, r)
+	if !ok {
 		return
 	}
 
+	h.recordView(r, *note)
+	h.withPaths(note)
+	withDates(dates, note)
 	respondWithJSON(w, http.StatusOK, note)
 }
 
+// HeadNote godoc
+// @Summary      Проверить, есть ли заметка
+// @Description  Отвечает как GET /notes/{id}, но без тела и не отмечая просмотр
+// @Tags         notes
+// @Param        id   path  string  true  "ID или публичный UUID"
+// @Success      200  "Заметка есть и доступна"
+// @Header       200  {string}  Last-Modified  "Когда заметка менялась в последний раз"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id} [head]
+func (h *Handler) HeadNote(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Last-Modified", lastActivity(*note).UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetNoteMarkdown godoc
+// @Summary      Экспорт заметки в Markdown
+// @Tags         notes
+// @Produce      plain
+// @Param        id   path   string  true  "ID или публичный UUID"
+// @Success      200  {string}  string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/markdown [get]
+func (h *Handler) GetNoteMarkdown(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	h.recordView(r, *note)
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(core.NoteMarkdown(*note)))
+}
+
+// GetNoteHighlighted godoc
+// @Summary      HTML с подсветкой синтаксиса для сниппета
+// @Tags         notes
+// @Produce      html
+// @Param        id   path   string  true  "ID или публичный UUID"
+// @Success      200  {string}  string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/highlight [get]
+func (h *Handler) GetNoteHighlighted(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	if note.Type != core.NoteTypeSnippet {
+		respondError(w, CodeInvalidRequest, "Note is not a snippet")
+		return
+	}
+
+	h.recordView(r, *note)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(highlight.HTML(note.Content, note.Language)))
+}
+
 // ListNotes godoc
 // @Summary      Список заметок
 // @Description  Возвращает список заметок с пагинацией и фильтром по заголовку
 // @Tags         notes
 // @Param        page   query  int     false  "Номер страницы"
-// @Param        limit  query  int     false  "Размер страницы"
-// @Param        q      query  string  false  "Поиск по title"
+// @Param        limit  query  int     false  "Размер страницы; по умолчанию page_size из настроек, без него — все"
+// @Param        type   query  string  false  "Тип заметки" Enums(note, snippet)
+// @Param        tag    query  string  false  "Тег, включая вложенные (project → project/alpha)"
+// @Param        state  query  string  false  "Состояния жизненного цикла через запятую, например draft,active"
+// @Param        notebook_id  query  int  false  "Только заметки блокнота, в порядке position"
+// @Param        q      query  string  false  "Поиск на языке запросов (см. GET /search/validate); результаты по релевантности"
+// @Param        prop.{name}  query  string  false  "Фильтр по свойству, например prop.status=done; числа, даты и булевы значения сравниваются по типу"
+// @Param        reacted  query  bool    false  "Только заметки с моей реакцией"
+// @Param        reaction query  string  false  "Только заметки с этой реакцией (вместе с reacted — с моей)"
+// @Param        date_format  query  string  false  "Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек" Enums(short, medium, long)
+// @Param        If-Modified-Since  header  string  false  "Дата из Last-Modified; 304, если изменений не было"
 // @Success      200    {array}  core.Note
+// @Success      304    "Заметки не менялись"
 // @Header       200    {integer}  X-Total-Count  "Общее количество"
+// @Header       200    {string}   Last-Modified  "Когда в последний раз менялись доступные заметки"
+// @Failure      400    {object}  map[string]string
 // @Failure      500    {object}  map[string]string
 // @Router       /notes [get]
 func (h *Handler) ListNotes(w http.ResponseWriter, r *http.Request) {
-	notes, err := h.Repo.GetAll()
+	query := r.URL.Query()
+
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+	dates, ok := h.dateFormatter(w, r)
+	if !ok {
+		return
+	}
+
+	if h.LastModified != nil && notModified(w, r, h.LastModified.For(auth.FromContext(r.Context()))) {
+		return
+	}
+
+	load := func() ([]core.Note, error) { return h.listNotes(r, scope) }
+	var notes []core.Note
+	var err error
+	if h.ListCache != nil {
+		p := auth.FromContext(r.Context())
+		key := p.UserID + "|" + strconv.FormatBool(p.Admin) + "|" + query.Encode()
+		if query.Get("q") != "" {
+			// Searches depend on the analyzer and time zone of the user.
+			prefs := h.preferences(p.UserID)
+			key += "|" + prefs.SearchLanguage + "|" + prefs.Timezone
+		}
+		notes, err = h.ListCache.Get(key, scope, load)
+	} else {
+		notes, err = load()
+	}
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to get notes")
+		respondError(w, CodeInternal, "Failed to get notes")
 		return
 	}
 
-	if notes == nil {
-		notes = []core.Note{}
+	notes, ok = h.paginate(w, r, notes)
+	if !ok {
+		return
+	}
+	for i := range notes {
+		h.withPaths(&notes[i])
+		withDates(dates, &notes[i])
 	}
 
 	respondWithJSON(w, http.StatusOK, notes)
 }
 
+// NoteCount is the answer of GET /notes/count.
+type NoteCount struct {
+	Count int `json:"count" example:"42"`
+}
+
+// CountNotes godoc
+// @Summary      Количество заметок
+// @Description  Сколько заметок вернул бы GET /notes с теми же фильтрами, без самих заметок
+// @Tags         notes
+// @Produce      json
+// @Param        type   query  string  false  "Тип заметки" Enums(note, snippet)
+// @Param        tag    query  string  false  "Тег, включая вложенные (project → project/alpha)"
+// @Param        state  query  string  false  "Состояния жизненного цикла через запятую, например draft,active"
+// @Param        notebook_id  query  int  false  "Только заметки блокнота"
+// @Param        q      query  string  false  "Поиск на языке запросов (см. GET /search/validate)"
+// @Param        prop.{name}  query  string  false  "Фильтр по свойству, например prop.status=done"
+// @Param        reacted  query  bool    false  "Только заметки с моей реакцией"
+// @Param        reaction query  string  false  "Только заметки с этой реакцией (вместе с reacted — с моей)"
+// @Success      200    {object}  NoteCount
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /notes/count [get]
+func (h *Handler) CountNotes(w http.ResponseWriter, r *http.Request) {
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+	notes, err := h.listNotes(r, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to count notes")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, NoteCount{Count: len(notes)})
+}
+
+// listScope validates the ListNotes query and returns its notebook_id
+// filter, or cache.AllNotebooks.
+func (h *Handler) listScope(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	query := r.URL.Query()
+
+	scope := int64(cache.AllNotebooks)
+	if v := query.Get("notebook_id"); v != "" {
+		notebookID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid notebook ID")
+			return 0, false
+		}
+		scope = notebookID
+	}
+	if q := query.Get("q"); q != "" {
+		if h.Search == nil {
+			respondError(w, CodeInvalidRequest, "Search is not enabled")
+			return 0, false
+		}
+		if _, err := h.parseQuery(auth.FromContext(r.Context()).UserID, q); err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid q "+err.Error())
+			return 0, false
+		}
+	}
+	if _, ok := h.stateFilter(r); !ok {
+		respondError(w, CodeInvalidRequest, "Unknown state")
+		return 0, false
+	}
+	return scope, true
+}
+
+// listNotes applies the ListNotes filters; scope is the notebook_id
+// filter or cache.AllNotebooks.
+func (h *Handler) listNotes(r *http.Request, scope int64) ([]core.Note, error) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	notes = h.readable(r, notes)
+
+	if noteType := r.URL.Query().Get("type"); noteType != "" {
+		filtered := notes[:0]
+		for _, n := range notes {
+			if n.Type == noteType {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+	}
+
+	if tag := core.NormalizeTag(r.URL.Query().Get("tag")); tag != "" {
+		filtered := notes[:0]
+		for _, n := range notes {
+			if core.HasTag(n, tag) {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+	}
+
+	if states, _ := h.stateFilter(r); states != nil {
+		filtered := notes[:0]
+		for _, n := range notes {
+			if states[h.stateOf(n)] {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+	}
+
+	if scope != cache.AllNotebooks {
+		filtered := notes[:0]
+		for _, n := range notes {
+			if n.NotebookID == scope {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+		core.SortByPosition(notes)
+	}
+
+	for param, values := range r.URL.Query() {
+		key, ok := strings.CutPrefix(param, "prop.")
+		if !ok {
+			continue
+		}
+		filtered := notes[:0]
+		for _, n := range notes {
+			if core.PropertyMatches(n, key, values[0]) {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+	}
+
+	reaction := r.URL.Query().Get("reaction")
+	if reacted, _ := strconv.ParseBool(r.URL.Query().Get("reacted")); reacted || reaction != "" {
+		userID := auth.FromContext(r.Context()).UserID
+		filtered := notes[:0]
+		for _, n := range notes {
+			if reacted && core.ReactedBy(n, userID, reaction) || !reacted && n.Reactions[reaction] > 0 {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+	}
+
+	if q := r.URL.Query().Get("q"); q != "" {
+		notes = h.searchNotes(auth.FromContext(r.Context()).UserID, notes, q)
+	}
+
+	return notes, nil
+}
+
+// NearbyNotes godoc
+// @Summary      Заметки рядом с точкой
+// @Description  Возвращает заметки с геометкой в радиусе от точки, ближайшие первыми
+// @Tags         notes
+// @Param        lat     query  number  true   "Широта"
+// @Param        lon     query  number  true   "Долгота"
+// @Param        radius  query  number  false  "Радиус в метрах (по умолчанию 1000)"
+// @Success      200     {array}   core.NearbyNote
+// @Failure      400     {object}  map[string]string
+// @Router       /notes/nearby [get]
+func (h *Handler) NearbyNotes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	lat, errLat := strconv.ParseFloat(q.Get("lat"), 64)
+	lon, errLon := strconv.ParseFloat(q.Get("lon"), 64)
+	if errLat != nil || errLon != nil || core.ValidateLocation(&lat, &lon) != nil {
+		respondError(w, CodeInvalidRequest, "Invalid lat/lon")
+		return
+	}
+
+	radius := 1000.0
+	if v := q.Get("radius"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 {
+			respondError(w, CodeInvalidRequest, "Invalid radius")
+			return
+		}
+		radius = parsed
+	}
+
+	finder, ok := noteStore[nearbyFinder](h, w, "Nearby search")
+	if !ok {
+		return
+	}
+	notes, err := finder.Nearby(lat, lon, radius)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+
+	visible := make([]core.NearbyNote, 0, len(notes))
+	for _, n := range notes {
+		if h.canRead(r, n.Note) {
+			visible = append(visible, n)
+		}
+	}
+	notes = visible
+
+	respondWithJSON(w, http.StatusOK, notes)
+}
+
 // PatchNote godoc
 // @Summary      Обновить заметку (частично)
 // @Tags         notes
 // @Accept       json
-// @Param        id     path   int        true  "ID"
-// @Param        input  body   core.Note true  "Поля для обновления"
+// @Param        id     path   string     true  "ID или публичный UUID"
+// @Param        input  body   core.NoteUpdate  true  "Поля для обновления"
+// @Param        dry_run  query  bool  false  "Только проверить запрос: ответ 200 с тем, что получилось бы, без сохранения (или Prefer: dry-run)"
+// @Param        date_format  query  string  false  "Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек" Enums(short, medium, long)
 // @Success      200    {object}  core.Note
+// @Success      202    {object}  core.ChangeRequest  "Блокнот требует одобрения правок: создан запрос на изменение"
 // @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
 // @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Failure      423    {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
 // @Router       /notes/{id} [patch]
 func (h *Handler) PatchNote(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid note ID")
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	propose := h.proposes(auth.FromContext(r.Context()), *note)
+	if !propose && !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if !propose && !h.unlocked(w, r, *note) {
+		return
+	}
+	dates, ok := h.dateFormatter(w, r)
+	if !ok {
 		return
 	}
+	id := note.ID
 
 	var update UpdateNoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid JSON")
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
 		return
 	}
 
-	if update.Title == nil && update.Content == nil {
-		respondWithError(w, http.StatusBadRequest, "No fields to update")
+	if update.Title != nil && strings.TrimSpace(*update.Title) == "" {
+		respondError(w, CodeInvalidRequest, "Title cannot be empty")
 		return
 	}
 
-	if update.Title != nil && strings.TrimSpace(*update.Title) == "" {
-		respondWithError(w, http.StatusBadRequest, "Title cannot be empty")
+	if err := core.ValidateLocation(update.Latitude, update.Longitude); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if update.Blocks != nil {
+		if err := core.ValidateBlocks(*update.Blocks); err != nil {
+			respondError(w, CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	if update.Color != nil {
+		color := strings.ToLower(strings.TrimSpace(*update.Color))
+		if err := core.ValidateColor(color); err != nil {
+			respondError(w, CodeInvalidRequest, err.Error())
+			return
+		}
+		update.Color = &color
+	}
+
+	if err := core.ValidateProperties(update.Properties, true); err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	collectionID := note.CollectionID
+	if update.CollectionID != nil {
+		collectionID = *update.CollectionID
+	}
+	if collectionID != 0 && (update.CollectionID != nil || len(update.Properties) > 0) &&
+		!h.checkCollection(w, r, collectionID, core.MergeProperties(note.Properties, update.Properties)) {
 		return
 	}
 
@@ -160,60 +763,183 @@ func (h *Handler) PatchNote(w http.ResponseWriter, r *http.Request) {
 	if update.Content != nil {
 		updates["content"] = *update.Content
 	}
+	if update.Latitude != nil {
+		updates["latitude"] = *update.Latitude
+		updates["longitude"] = *update.Longitude
+	}
+	if update.Language != nil {
+		updates["language"] = strings.TrimSpace(*update.Language)
+	}
+	if update.Blocks != nil {
+		updates["blocks"] = *update.Blocks
+		if len(*update.Blocks) > 0 {
+			updates["content"] = core.PlainText(*update.Blocks)
+		}
+	}
+	if update.Tags != nil {
+		updates["tags"] = core.NormalizeTags(*update.Tags)
+	}
+	if update.Pinned != nil {
+		updates["pinned"] = *update.Pinned
+	}
+	if update.RemindAt != nil {
+		updates["remind_at"] = update.RemindAt.UTC()
+	}
+	if update.Color != nil {
+		updates["color"] = *update.Color
+	}
+	if len(update.Properties) > 0 {
+		updates["properties"] = update.Properties
+	}
+	if update.CollectionID != nil {
+		updates["collection_id"] = *update.CollectionID
+	}
 
-	err = h.Repo.UpdatePartial(id, updates)
-	if err != nil {
-		if err == repo.ErrNoteNotFound {
-			respondWithError(w, http.StatusNotFound, "Note not found")
-		} else {
-			respondWithError(w, http.StatusInternalServerError, "Failed to update note")
+	if len(updates) == 0 {
+		respondError(w, CodeInvalidRequest, "No fields to update")
+		return
+	}
+
+	if dryRun(r) {
+		previewer, ok := noteStore[notePreviewer](h, w, "Dry run")
+		if !ok {
+			return
 		}
+		preview, err := previewer.PreviewUpdate(id, updates)
+		if err != nil {
+			respondErr(w, err, "Failed to update note")
+			return
+		}
+		h.withPaths(preview)
+		withDates(dates, preview)
+		respondDryRun(w, preview)
+		return
+	}
+
+	if propose {
+		h.requestChange(w, r, *note, updates)
+		return
+	}
+
+	if err := h.updateNote(auth.FromContext(r.Context()).UserID, id, updates); err != nil {
+		respondErr(w, err, "Failed to update note")
 		return
 	}
 
 	updatedNote, err := h.Repo.GetByID(id)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve updated note")
+		respondError(w, CodeInternal, "Failed to retrieve updated note")
 		return
 	}
 
+	h.notifyWatchers(r, *updatedNote, notify.NoteUpdated)
+	h.withPaths(updatedNote)
+	withDates(dates, updatedNote)
 	respondWithJSON(w, http.StatusOK, updatedNote)
 }
 
 // DeleteNote godoc
 // @Summary      Удалить заметку
+// @Description  Для старых клиентов NOTES_LEGACY_DELETE=true возвращает 200 с сообщением вместо 204
 // @Tags         notes
-// @Param        id  path  int  true  "ID"
+// @Param        id  path  string  true  "ID или публичный UUID"
 // @Success      204  "No Content"
+// @Success      200  {object}  SuccessResponse  "Только с NOTES_LEGACY_DELETE=true"
+// @Header       204  {string}  Undo-Token  "Необязательный: токен для POST /undo/{token}, если хранилище заметок умеет их восстанавливать"
+// @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
 // @Router       /notes/{id} [delete]
 func (h *Handler) DeleteNote(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid note ID")
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if !h.unlocked(w, r, *note) {
+		return
+	}
+	id := note.ID
+
+	if err := h.deleteNote(auth.FromContext(r.Context()).UserID, id); err != nil {
+		respondErr(w, err, "Failed to delete note")
+		return
+	}
+	h.notifyWatchers(r, *note, notify.NoteDeleted)
+	deleted := *note
+	if restorer, ok := h.Repo.(noteRestorer); ok {
+		h.offerUndo(w, r, "delete", func() ([]core.Note, error) { return h.restoreNote(restorer, deleted) })
+	}
+
+	status := h.Policy.deleteStatus()
+	if status == http.StatusOK {
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Note deleted successfully",
+		})
 		return
 	}
 
-	err = h.Repo.Delete(id)
+	w.WriteHeader(status)
+}
+
+// loadNote fetches the note named by the {id} URL parameter, either its ID
+// or its public ID. Notes the caller cannot read are reported as missing.
+func (h *Handler) loadNote(w http.ResponseWriter, r *http.Request) (*core.Note, bool) {
+	param := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(param, 10, 64)
 	if err != nil {
-		if err == repo.ErrNoteNotFound {
-			respondWithError(w, http.StatusNotFound, "Note not found")
-		} else {
-			respondWithError(w, http.StatusInternalServerError, "Failed to delete note")
+		if !core.IsPublicID(param) {
+			respondError(w, CodeInvalidRequest, "Invalid note ID")
+			return nil, false
+		}
+		err = repo.ErrNoteNotFound
+		if resolver, ok := h.Repo.(noteResolver); ok {
+			id, err = resolver.Resolve(param)
 		}
-		return
 	}
 
-	respondWithJSON(w, http.StatusOK, SuccessResponse{
-		Message: "Note deleted successfully",
+	var note *core.Note
+	if err == nil {
+		note, err = h.getNote(id)
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to get note")
+		return nil, false
+	}
+
+	if !h.canRead(r, *note) {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return nil, false
+	}
+
+	return note, true
+}
+
+// getNote reads a note, sharing the repository call with concurrent
+// requests for the same note when Reads is set.
+func (h *Handler) getNote(id int64) (*core.Note, error) {
+	if h.Reads == nil {
+		return h.Repo.GetByID(id)
+	}
+
+	v, err, _ := h.Reads.Do("note:"+strconv.FormatInt(id, 10), func() (interface{}, error) {
+		return h.Repo.GetByID(id)
 	})
+	if err != nil {
+		return nil, err
+	}
+	note := *v.(*core.Note)
+	return &note, nil
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now().UTC()
+	}
+	return h.Clock.Now()
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -0,0 +1,21 @@
+package handlers
+
+import "net/http"
+
+// VersionPolicy describes the response conventions of an API version.
+type VersionPolicy struct {
+	// DeleteStatus is returned by DeleteNote on success. http.StatusOK also
+	// writes a SuccessResponse body; anything else is sent without a body.
+	DeleteStatus int
+}
+
+var V1Policy = VersionPolicy{
+	DeleteStatus: http.StatusNoContent,
+}
+
+func (p VersionPolicy) deleteStatus() int {
+	if p.DeleteStatus == 0 {
+		return http.StatusNoContent
+	}
+	return p.DeleteStatus
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/search"
+)
+
+// GetPreferences godoc
+// @Summary      Мои настройки
+// @Tags         me
+// @Produce      json
+// @Success      200  {object}  core.Preferences
+// @Router       /me/preferences [get]
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.preferences(auth.FromContext(r.Context()).UserID))
+}
+
+// PatchPreferences godoc
+// @Summary      Изменить мои настройки
+// @Description  Меняет только переданные поля. Пустая строка или 0 сбрасывают настройку. Выключение privacy.recent_notes стирает список недавно открытых заметок
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.PreferencesUpdate  true  "Настройки"
+// @Success      200    {object}  core.Preferences
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /me/preferences [patch]
+func (h *Handler) PatchPreferences(w http.ResponseWriter, r *http.Request) {
+	if h.Preferences == nil {
+		respondError(w, CodeFeatureDisabled, "Preferences are not enabled")
+		return
+	}
+
+	var update core.PreferencesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	if id := update.DefaultNotebookID; id != nil && *id != 0 {
+		if _, err := h.Notebooks.GetByID(*id); err != nil || !h.Notebooks.Role(p, *id).Allows(core.RoleEditor) {
+			respondError(w, CodeInvalidRequest, "Notebook not found")
+			return
+		}
+	}
+
+	if lang := update.SearchLanguage; lang != nil && *lang != "" {
+		if _, ok := search.AnalyzerByName(strings.ToLower(strings.TrimSpace(*lang))); !ok {
+			respondError(w, CodeInvalidRequest, "Unknown search language")
+			return
+		}
+	}
+
+	prefs, err := h.Preferences.Update(p.UserID, update)
+	if err != nil {
+		respondErr(w, err, "Failed to update preferences")
+		return
+	}
+	if h.Recent != nil && !prefs.Privacy.RecentNotes {
+		h.Recent.Clear(p.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+// preferences returns the preferences of userID, or the defaults when
+// preferences are disabled.
+func (h *Handler) preferences(userID string) core.Preferences {
+	if h.Preferences == nil {
+		return core.DefaultPreferences()
+	}
+	return h.Preferences.Get(userID)
+}
+
+// defaultNotebook returns the caller's default notebook for new notes, or
+// 0 once it is gone or no longer writable.
+func (h *Handler) defaultNotebook(r *http.Request) int64 {
+	p := auth.FromContext(r.Context())
+	id := h.preferences(p.UserID).DefaultNotebookID
+	if id == 0 {
+		return 0
+	}
+	if _, err := h.Notebooks.GetByID(id); err != nil || !h.Notebooks.Role(p, id).Allows(core.RoleEditor) {
+		return 0
+	}
+	return id
+}
+
+// paginate applies the page and limit query parameters, falling back to
+// the caller's preferred page size, and reports the unpaged count in
+// X-Total-Count.
+func (h *Handler) paginate(w http.ResponseWriter, r *http.Request, notes []core.Note) ([]core.Note, bool) {
+	query := r.URL.Query()
+
+	limit := h.preferences(auth.FromContext(r.Context()).UserID).PageSize
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > core.MaxPageSize {
+			respondError(w, CodeInvalidRequest, "Invalid limit")
+			return nil, false
+		}
+		limit = n
+	}
+	page := 1
+	if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, CodeInvalidRequest, "Invalid page")
+			return nil, false
+		}
+		page = n
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(notes)))
+	if limit == 0 {
+		return notes, true
+	}
+	start := (page - 1) * limit
+	if start >= len(notes) {
+		return []core.Note{}, true
+	}
+	return notes[start:min(start+limit, len(notes))], true
+}
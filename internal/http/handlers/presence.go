@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+)
+
+type PresenceRequest struct {
+	// Typing tells whether the caller is typing in the note.
+	Typing bool `json:"typing"`
+}
+
+// UpdatePresence godoc
+// @Summary      Отметить, что заметка открыта
+// @Description  Открывшие заметку видны в GET /notes/{id}/presence и в потоке GET /events (presence.updated) всем, кто видит заметку. Запрос нужно повторять раньше, чем через минуту (heartbeat), и при смене typing
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string           true   "ID или публичный UUID"
+// @Param        input  body      PresenceRequest  false  "Печатает ли пользователь"
+// @Success      200    {array}   events.Viewer
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notes/{id}/presence [post]
+func (h *Handler) UpdatePresence(w http.ResponseWriter, r *http.Request) {
+	var req PresenceRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, CodeInvalidJSON, "Invalid JSON")
+			return
+		}
+	}
+	h.setPresence(w, r, req.Typing, false)
+}
+
+// LeavePresence godoc
+// @Summary      Отметить, что заметка закрыта
+// @Tags         events
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {array}   events.Viewer
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/presence [delete]
+func (h *Handler) LeavePresence(w http.ResponseWriter, r *http.Request) {
+	h.setPresence(w, r, false, true)
+}
+
+// GetPresence godoc
+// @Summary      Кто открыл заметку
+// @Description  Для клиентов без GET /events: пользователи, открывшие заметку на любой реплике, и печатают ли они, в порядке открытия
+// @Tags         events
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {array}   events.Viewer
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/presence [get]
+func (h *Handler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	if !h.presenceEnabled(w) {
+		return
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Presence.Viewers(note.ID))
+}
+
+// setPresence records the presence of the caller on the note here at once
+// and publishes it for the other replicas and event streams.
+func (h *Handler) setPresence(w http.ResponseWriter, r *http.Request, typing, left bool) {
+	if !h.presenceEnabled(w) {
+		return
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	e := h.Presence.Event(note.ID, auth.FromContext(r.Context()).UserID, typing, left)
+	h.Presence.Apply(e)
+	h.Events.Publish(e)
+	respondWithJSON(w, http.StatusOK, h.Presence.Viewers(note.ID))
+}
+
+func (h *Handler) presenceEnabled(w http.ResponseWriter) bool {
+	if h.Presence == nil || h.Events == nil {
+		respondError(w, CodeFeatureDisabled, "Presence is not enabled")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/preview"
+)
+
+// GetLinkPreviews godoc
+// @Summary      Превью ссылок заметки
+// @Description  Заголовок, описание, картинка и иконка страниц, на которые ссылается заметка, в порядке появления ссылок (не больше 20). Страницы загружаются в фоне после сохранения заметки; пока превью не готово, у него state pending и только url. Загружаются только публичные адреса
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {array}   preview.Preview
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/link-previews [get]
+func (h *Handler) GetLinkPreviews(w http.ResponseWriter, r *http.Request) {
+	if h.Previews == nil {
+		respondError(w, CodeFeatureDisabled, "Link previews are not enabled")
+		return
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Previews.For(preview.Links(*note)))
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+
+	"example.com/notes-api/internal/render"
+)
+
+// GetNotePrint godoc
+// @Summary      Заметка для печати
+// @Description  HTML, свёрстанный для печати, с состоянием чек-листов и списком изображений. format=pdf отдаёт готовый PDF, если на сервере задан шрифт (NOTES_PDF_FONT). template выбирает шаблон, зарегистрированный админом: шапку, подвал, место метаданных и оформление
+// @Tags         notes
+// @Produce      html
+// @Produce      application/pdf
+// @Param        id        path   string  true   "ID или публичный UUID"
+// @Param        format    query  string  false  "Формат" Enums(html, pdf)
+// @Param        template  query  string  false  "Имя шаблона"
+// @Success      200  {string}  string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      501  {object}  map[string]string  "PDF не настроен"
+// @Router       /notes/{id}/print [get]
+func (h *Handler) GetNotePrint(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "html" && format != "pdf" {
+		respondError(w, CodeInvalidRequest, "Unknown format")
+		return
+	}
+	if format == "pdf" && h.PDFFont == nil {
+		respondError(w, CodeNotImplemented, "PDF rendering is not enabled")
+		return
+	}
+
+	var tmpl *render.Template
+	if name := r.URL.Query().Get("template"); name != "" {
+		var err error
+		if h.PrintTemplates != nil {
+			tmpl, err = h.PrintTemplates.Get(name)
+		}
+		if h.PrintTemplates == nil || err != nil {
+			respondError(w, CodeInvalidRequest, "Unknown template")
+			return
+		}
+	}
+
+	h.recordView(r, *note)
+	h.withPaths(note)
+
+	// Render fully before writing, so that a failure is still a 500.
+	var buf bytes.Buffer
+	var err error
+	if format == "pdf" {
+		err = render.PDF(&buf, *note, h.PDFFont, tmpl)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="`+note.Slug+`.pdf"`)
+	} else {
+		err = render.PrintWith(&buf, render.NotePage(*note, "", h.Emoji.URLs()), tmpl)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if err != nil {
+		log.Printf("print note %d: %v", note.ID, err)
+		w.Header().Del("Content-Disposition")
+		respondError(w, CodeInternal, "Failed to render note")
+		return
+	}
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji" example:"👍"`
+}
+
+type ReactionsResponse struct {
+	// Reactions counts the users behind each emoji.
+	Reactions map[string]int `json:"reactions"`
+	// Mine lists the caller's own reactions.
+	Mine []string `json:"mine"`
+}
+
+// AddReaction godoc
+// @Summary      Поставить реакцию
+// @Description  Реакцию может оставить любой, кто видит заметку. Повторная реакция ничего не меняет
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string           true  "ID или публичный UUID"
+// @Param        input  body      ReactionRequest  true  "Одна из 👍 ❤️ 🎉 😄 👀 🚀"
+// @Success      200    {object}  ReactionsResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notes/{id}/reactions [post]
+func (h *Handler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	var req ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	h.react(w, r, req.Emoji, true)
+}
+
+// RemoveReaction godoc
+// @Summary      Снять реакцию
+// @Tags         notes
+// @Produce      json
+// @Param        id     path      string  true  "ID или публичный UUID"
+// @Param        emoji  query     string  true  "Реакция"
+// @Success      200    {object}  ReactionsResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notes/{id}/reactions [delete]
+func (h *Handler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, r.URL.Query().Get("emoji"), false)
+}
+
+func (h *Handler) react(w http.ResponseWriter, r *http.Request, emoji string, add bool) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	if !core.ValidReaction(emoji) {
+		respondError(w, CodeInvalidRequest, "Unsupported reaction")
+		return
+	}
+
+	reactor, ok := noteStore[noteReactor](h, w, "Reactions")
+	if !ok {
+		return
+	}
+	userID := auth.FromContext(r.Context()).UserID
+	note, err := reactor.React(note.ID, userID, emoji, add)
+	if err != nil {
+		respondErr(w, err, "Failed to update reactions")
+		return
+	}
+
+	reactions := note.Reactions
+	if reactions == nil {
+		reactions = map[string]int{}
+	}
+	respondWithJSON(w, http.StatusOK, ReactionsResponse{
+		Reactions: reactions,
+		Mine:      core.ReactionsBy(*note, userID),
+	})
+}
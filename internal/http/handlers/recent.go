@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// ListRecentNotes godoc
+// @Summary      Недавно открытые заметки
+// @Description  Заметки, которые пользователь открывал, сначала последние. Повторные просмотры в течение минуты не учитываются. Не ведётся, если в настройках выключено privacy.recent_notes
+// @Tags         notes
+// @Produce      json
+// @Param        page   query  int  false  "Номер страницы"
+// @Param        limit  query  int  false  "Размер страницы; по умолчанию page_size из настроек, без него — все"
+// @Success      200    {array}    core.Note
+// @Header       200    {integer}  X-Total-Count  "Общее количество"
+// @Failure      400    {object}   map[string]string
+// @Failure      404    {object}   map[string]string
+// @Router       /notes/recent [get]
+func (h *Handler) ListRecentNotes(w http.ResponseWriter, r *http.Request) {
+	if h.Recent == nil {
+		respondError(w, CodeFeatureDisabled, "Recently viewed notes are not enabled")
+		return
+	}
+	p := auth.FromContext(r.Context())
+	notes := []core.Note{}
+	for _, id := range h.Recent.List(p.UserID) {
+		if n, err := h.Repo.GetByID(id); err == nil && h.noteRole(p, *n).Allows(core.RoleViewer) {
+			notes = append(notes, *n)
+		}
+	}
+	notes, ok := h.paginate(w, r, notes)
+	if !ok {
+		return
+	}
+	for i := range notes {
+		h.withPaths(&notes[i])
+	}
+	respondWithJSON(w, http.StatusOK, notes)
+}
+
+// recordRecent adds a note to the caller's recently viewed notes, unless
+// they turned that off.
+func (h *Handler) recordRecent(r *http.Request, n core.Note) {
+	if h.Recent == nil {
+		return
+	}
+	userID := auth.FromContext(r.Context()).UserID
+	if !h.preferences(userID).Privacy.RecentNotes {
+		return
+	}
+	h.Recent.Record(userID, n.ID, h.now())
+}
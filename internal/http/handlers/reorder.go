@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+type ReorderRequest struct {
+	NotebookID int64   `json:"notebook_id" example:"1"`
+	IDs        []int64 `json:"ids"`
+}
+
+// ReorderNotes godoc
+// @Summary      Изменить порядок заметок в блокноте
+// @Description  Принимает упорядоченный список ID заметок блокнота и сохраняет этот порядок
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        input  body      ReorderRequest  true  "Блокнот и новый порядок"
+// @Success      200    {array}   core.Note
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notes/reorder [patch]
+func (h *Handler) ReorderNotes(w http.ResponseWriter, r *http.Request) {
+	var req ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		respondError(w, CodeInvalidRequest, "No IDs to reorder")
+		return
+	}
+
+	if !h.canReorder(r, req.NotebookID, req.IDs) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+
+	reorderer, ok := noteStore[noteReorderer](h, w, "Reordering")
+	if !ok {
+		return
+	}
+	notes, err := reorderer.Reorder(req.NotebookID, req.IDs)
+	if err != nil {
+		respondErr(w, err, "Failed to reorder notes")
+		return
+	}
+
+	notes = h.readable(r, notes)
+	for i := range notes {
+		h.withPaths(&notes[i])
+	}
+
+	respondWithJSON(w, http.StatusOK, notes)
+}
+
+// canReorder requires edit rights on the notebook, or on every listed note
+// when they are not filed in a notebook.
+func (h *Handler) canReorder(r *http.Request, notebookID int64, ids []int64) bool {
+	if notebookID != 0 {
+		return h.Notebooks.Role(auth.FromContext(r.Context()), notebookID).Allows(core.RoleEditor)
+	}
+	for _, id := range ids {
+		note, err := h.Repo.GetByID(id)
+		if err != nil {
+			continue
+		}
+		if !h.canWrite(r, *note) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// ReviewCard is a note due for review with where it stands in the review.
+type ReviewCard struct {
+	Note core.Note `json:"note"`
+	// Review is nil for notes never reviewed.
+	Review *core.ReviewState `json:"review,omitempty"`
+}
+
+type ReviewDoneRequest struct {
+	Grade string `json:"grade,omitempty" example:"good" enums:"again,good,easy"`
+}
+
+// RandomNote godoc
+// @Summary      Случайная заметка
+// @Description  Случайная из заметок, которые вернул бы GET /notes с теми же фильтрами
+// @Tags         review
+// @Produce      json
+// @Param        tag          query     string  false  "Тег, включая вложенные"
+// @Param        notebook_id  query     int     false  "Только заметки блокнота"
+// @Param        type         query     string  false  "Тип заметки" Enums(note, snippet)
+// @Success      200          {object}  core.Note
+// @Failure      400          {object}  map[string]string
+// @Failure      404          {object}  map[string]string  "Подходящих заметок нет"
+// @Failure      500          {object}  map[string]string
+// @Router       /notes/random [get]
+func (h *Handler) RandomNote(w http.ResponseWriter, r *http.Request) {
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+	notes, err := h.listNotes(r, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+	if len(notes) == 0 {
+		respondError(w, CodeNoteNotFound, "No notes match")
+		return
+	}
+
+	note := notes[rand.Intn(len(notes))]
+	h.withPaths(&note)
+	respondWithJSON(w, http.StatusOK, note)
+}
+
+// NextReview godoc
+// @Summary      Следующая заметка на повторение
+// @Description  Своя заметка, срок повторения которой наступил, — сначала просроченные дольше всех. Заметки, которые ещё не повторялись, ждут повторения с момента создания
+// @Tags         review
+// @Produce      json
+// @Param        tag  query     string  false  "Только заметки с тегом, включая вложенные"
+// @Success      200  {object}  ReviewCard
+// @Success      204  "Повторять нечего"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /review/next [get]
+func (h *Handler) NextReview(w http.ResponseWriter, r *http.Request) {
+	if !h.reviewsEnabled(w) {
+		return
+	}
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+	notes, err := h.listNotes(r, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+
+	userID := auth.FromContext(r.Context()).UserID
+	states := h.Reviews.For(userID)
+	now := h.now()
+	var due []ReviewCard
+	for _, n := range notes {
+		if n.OwnerID != userID {
+			continue
+		}
+		card := ReviewCard{Note: n}
+		if s, ok := states[n.ID]; ok {
+			if s.DueAt.After(now) {
+				continue
+			}
+			card.Review = &s
+		}
+		due = append(due, card)
+	}
+	if len(due) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		a, b := dueAt(due[i]), dueAt(due[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return due[i].Note.ID < due[j].Note.ID
+	})
+	card := due[0]
+	h.withPaths(&card.Note)
+	respondWithJSON(w, http.StatusOK, card)
+}
+
+// ReviewDone godoc
+// @Summary      Отметить повторение заметки
+// @Description  again — вспомнить не удалось, повторить завтра; good (по умолчанию) удваивает интервал; easy утраивает его
+// @Tags         review
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string             true   "ID или публичный UUID заметки"
+// @Param        input  body      ReviewDoneRequest  false  "Оценка"
+// @Success      200    {object}  core.ReviewState
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /review/{id}/done [post]
+func (h *Handler) ReviewDone(w http.ResponseWriter, r *http.Request) {
+	if !h.reviewsEnabled(w) {
+		return
+	}
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReviewDoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	if req.Grade == "" {
+		req.Grade = core.ReviewGood
+	}
+	if !core.ValidReviewGrade(req.Grade) {
+		respondErr(w, core.Invalid("grade", "grade must be again, good or easy"), "")
+		return
+	}
+
+	s := h.Reviews.Record(auth.FromContext(r.Context()).UserID, note.ID, req.Grade, h.now())
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+func (h *Handler) reviewsEnabled(w http.ResponseWriter) bool {
+	if h.Reviews == nil {
+		respondError(w, CodeFeatureDisabled, "Review is not enabled")
+		return false
+	}
+	return true
+}
+
+// dueAt is when c became due: never reviewed notes are due from their
+// creation.
+func dueAt(c ReviewCard) time.Time {
+	if c.Review == nil {
+		return c.Note.CreatedAt
+	}
+	return c.Review.DueAt
+}
@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/preview"
+	"example.com/notes-api/internal/render"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// ruleJobKind is the job kind rules run as.
+const ruleJobKind = "rule"
+
+// ruleClient posts the webhooks of rules when Handler.RuleClient is nil.
+// Like link previews, it only reaches public addresses.
+var ruleClient = preview.SafeClient(10 * time.Second)
+
+// RuleEvent is the JSON body call_webhook actions post.
+type RuleEvent struct {
+	Event  string    `json:"event" example:"note_created"`
+	RuleID int64     `json:"rule_id" example:"1"`
+	Rule   string    `json:"rule" example:"Счета в бухгалтерию"`
+	Note   core.Note `json:"note"`
+	At     time.Time `json:"at"`
+}
+
+// CreateRule godoc
+// @Summary      Создать правило
+// @Description  Правило выполняет действия then над заметками владельца, когда происходит событие when: note_created — заметка создана, reminder_due — наступило напоминание, note_shared — заметка опубликована; tag ограничивает правило заметками с тегом. Действия: apply_tag (tag), move_to_notebook (notebook_id; не выполняется, если владелец правила потерял право на запись в блокнот), call_webhook (url, получает RuleEvent), send_email (to; письма считаются в лимит писем владельца, сверх лимита действие не выполняется; письма попадают в журнал аудита). Правила выполняются фоновыми задачами kind=rule; о неудачных приходит уведомление. Действия над заметкой, которую заблокировал для правки другой пользователь, не выполняются
+// @Tags         rules
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.RuleInput  true  "Правило"
+// @Success      201    {object}  core.Rule
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /rules [post]
+func (h *Handler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	if !h.rulesEnabled(w) {
+		return
+	}
+	rule, ok := h.decodeRule(w, r)
+	if !ok {
+		return
+	}
+	p := auth.FromContext(r.Context())
+	rule.OwnerID, rule.OwnerTeams = p.UserID, p.Teams
+	rule = h.Rules.Create(rule)
+	w.Header().Set("Location", "/api/v1/rules/"+strconv.FormatInt(rule.ID, 10))
+	respondWithJSON(w, http.StatusCreated, rule)
+}
+
+// ListRules godoc
+// @Summary      Правила пользователя
+// @Tags         rules
+// @Produce      json
+// @Success      200  {array}   core.Rule
+// @Failure      404  {object}  map[string]string
+// @Router       /rules [get]
+func (h *Handler) ListRules(w http.ResponseWriter, r *http.Request) {
+	if !h.rulesEnabled(w) {
+		return
+	}
+	list := h.Rules.ListFor(auth.FromContext(r.Context()).UserID)
+	if list == nil {
+		list = []core.Rule{}
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetRule godoc
+// @Summary      Получить правило
+// @Tags         rules
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.Rule
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /rules/{id} [get]
+func (h *Handler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, rule)
+}
+
+// PutRule godoc
+// @Summary      Заменить правило
+// @Description  Число запусков и время последнего сохраняются
+// @Tags         rules
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int             true  "ID"
+// @Param        input  body      core.RuleInput  true  "Правило"
+// @Success      200    {object}  core.Rule
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /rules/{id} [put]
+func (h *Handler) PutRule(w http.ResponseWriter, r *http.Request) {
+	old, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	rule, ok := h.decodeRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ID = old.ID
+	rule.OwnerTeams = auth.FromContext(r.Context()).Teams
+	rule, err := h.Rules.Replace(rule)
+	if err != nil {
+		respondErr(w, err, "Failed to update rule")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, rule)
+}
+
+// DeleteRule godoc
+// @Summary      Удалить правило
+// @Tags         rules
+// @Param        id   path  int  true  "ID"
+// @Success      204  "Правило удалено"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /rules/{id} [delete]
+func (h *Handler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Rules.Delete(rule.ID); err != nil {
+		respondErr(w, err, "Failed to delete rule")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) rulesEnabled(w http.ResponseWriter) bool {
+	if h.Rules == nil || h.Jobs == nil {
+		respondError(w, CodeFeatureDisabled, "Rules are not enabled")
+		return false
+	}
+	return true
+}
+
+// loadRule returns the rule of the id URL parameter; the rules of others
+// are not found.
+func (h *Handler) loadRule(w http.ResponseWriter, r *http.Request) (*core.Rule, bool) {
+	if !h.rulesEnabled(w) {
+		return nil, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid rule ID")
+		return nil, false
+	}
+	rule, err := h.Rules.GetByID(id)
+	if err == nil && rule.OwnerID != auth.FromContext(r.Context()).UserID {
+		err = repo.ErrRuleNotFound
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to get rule")
+		return nil, false
+	}
+	return rule, true
+}
+
+// decodeRule reads and validates a core.RuleInput.
+func (h *Handler) decodeRule(w http.ResponseWriter, r *http.Request) (core.Rule, bool) {
+	var in core.RuleInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return core.Rule{}, false
+	}
+	rule := core.Rule{
+		Name:    strings.TrimSpace(in.Name),
+		Enabled: in.Enabled == nil || *in.Enabled,
+		When:    core.RuleTrigger{Event: in.When.Event, Tag: core.NormalizeTag(in.When.Tag)},
+	}
+
+	invalid := &core.ErrValidation{Fields: map[string]string{}}
+	if rule.Name == "" {
+		invalid.Fields["name"] = "name is required"
+	}
+	switch rule.When.Event {
+	case core.TriggerNoteCreated, core.TriggerReminderDue, core.TriggerNoteShared:
+	default:
+		invalid.Fields["when.event"] = "event must be note_created, reminder_due or note_shared"
+	}
+	if len(in.Then) == 0 {
+		invalid.Fields["then"] = "at least one action is required"
+	} else if len(in.Then) > core.MaxRuleActions {
+		invalid.Fields["then"] = fmt.Sprintf("at most %d actions are allowed", core.MaxRuleActions)
+	}
+	p := auth.FromContext(r.Context())
+	for i, a := range in.Then {
+		a, problem := h.checkRuleAction(p, a)
+		if problem != "" {
+			invalid.Fields[fmt.Sprintf("then[%d]", i)] = problem
+		}
+		rule.Then = append(rule.Then, a)
+	}
+	if len(invalid.Fields) > 0 {
+		respondErr(w, invalid, "")
+		return core.Rule{}, false
+	}
+	return rule, true
+}
+
+// checkRuleAction normalizes a, keeping only the fields of its type, and
+// returns what is wrong with it for p to save it.
+func (h *Handler) checkRuleAction(p core.Principal, a core.RuleAction) (core.RuleAction, string) {
+	switch a.Type {
+	case core.ActionApplyTag:
+		a = core.RuleAction{Type: a.Type, Tag: core.NormalizeTag(a.Tag)}
+		if a.Tag == "" {
+			return a, "tag is required"
+		}
+	case core.ActionMoveToNotebook:
+		a = core.RuleAction{Type: a.Type, NotebookID: a.NotebookID}
+		if _, err := h.Notebooks.GetByID(a.NotebookID); err != nil || !h.Notebooks.Role(p, a.NotebookID).Allows(core.RoleEditor) {
+			return a, "notebook not found"
+		}
+	case core.ActionCallWebhook:
+		a = core.RuleAction{Type: a.Type, URL: strings.TrimSpace(a.URL)}
+		if u, err := url.Parse(a.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return a, "url must be an http or https URL"
+		}
+	case core.ActionSendEmail:
+		to := a.To
+		a = core.RuleAction{Type: a.Type}
+		if h.Mailer == nil {
+			return a, "email is not enabled"
+		}
+		seen := make(map[string]bool)
+		for _, entry := range to {
+			addr, err := mail.ParseAddress(strings.TrimSpace(entry))
+			if err != nil {
+				return a, "invalid email address: " + entry
+			}
+			if key := strings.ToLower(addr.Address); !seen[key] {
+				seen[key] = true
+				a.To = append(a.To, addr.Address)
+			}
+		}
+		if len(a.To) == 0 {
+			return a, "at least one recipient is required"
+		}
+		if len(a.To) > maxEmailRecipients {
+			return a, "too many recipients"
+		}
+	default:
+		return a, "type must be apply_tag, move_to_notebook, call_webhook or send_email"
+	}
+	return a, ""
+}
+
+// RunRules fires the note_created rules of created notes. It is a
+// NoteRepoMem.OnChange listener: the rules run as jobs, after the
+// repository is unlocked.
+func (h *Handler) RunRules(c repo.Change) {
+	if c.Op == repo.ChangeCreated {
+		h.fireRules(core.TriggerNoteCreated, c.Note)
+	}
+}
+
+// CheckReminders fires the reminder_due rules of the notes whose reminder
+// came due since the previous check.
+func (h *Handler) CheckReminders(now time.Time) {
+	if h.Rules == nil {
+		return
+	}
+	since := h.Rules.AdvanceReminders(now)
+	if !now.After(since) {
+		return
+	}
+	notes, err := h.Repo.List()
+	if err != nil {
+		log.Printf("check reminders: %v", err)
+		return
+	}
+	for _, n := range notes {
+		if n.RemindAt != nil && n.RemindAt.After(since) && !n.RemindAt.After(now) {
+			h.fireRules(core.TriggerReminderDue, n)
+		}
+	}
+}
+
+// StartReminders checks for due reminders every interval until ctx is
+// cancelled.
+func (h *Handler) StartReminders(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			h.CheckReminders(h.now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// fireRules submits a job for every rule that event on n matches.
+func (h *Handler) fireRules(event string, n core.Note) {
+	if h.Rules == nil || h.Jobs == nil {
+		return
+	}
+	for _, rule := range h.Rules.Matching(event, n) {
+		_, err := h.Jobs.Submit(ruleJobKind, rule.OwnerID, func(ctx context.Context, p *jobs.Progress) (*jobs.Result, error) {
+			return nil, h.runRule(ctx, p, rule, event, n.ID)
+		})
+		if err != nil {
+			log.Printf("rule %d on note %d: %v", rule.ID, n.ID, err)
+		}
+	}
+}
+
+// runRule does the actions of rule on the note noteID in order. A failed
+// action is reported and does not stop the others.
+func (h *Handler) runRule(ctx context.Context, p *jobs.Progress, rule core.Rule, event string, noteID int64) error {
+	p.SetTotal(len(rule.Then))
+	for i, a := range rule.Then {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Each action sees what the ones before it did.
+		note, err := h.Repo.GetByID(noteID)
+		if err != nil {
+			return err
+		}
+		if err := h.ruleAction(ctx, rule, event, *note, a); err != nil {
+			p.Error(fmt.Sprintf("then[%d] %s: %v", i, a.Type, err))
+		}
+		p.Step()
+	}
+	h.Rules.RecordRun(rule.ID, h.now())
+	return nil
+}
+
+func (h *Handler) ruleAction(ctx context.Context, rule core.Rule, event string, n core.Note, a core.RuleAction) error {
+	switch a.Type {
+	case core.ActionApplyTag:
+		if slices.Contains(n.Tags, a.Tag) {
+			return nil
+		}
+		return h.updateNote(rule.OwnerID, n.ID, map[string]interface{}{"tags": core.NormalizeTags(append(n.Tags, a.Tag))})
+	case core.ActionMoveToNotebook:
+		if n.NotebookID == a.NotebookID {
+			return nil
+		}
+		// The owner may have lost access to the notebook since saving the
+		// rule.
+		owner := core.Principal{UserID: rule.OwnerID, Teams: rule.OwnerTeams}
+		if !h.Notebooks.Role(owner, a.NotebookID).Allows(core.RoleEditor) {
+			return errors.New("no edit access to the notebook")
+		}
+		mover, ok := h.Repo.(noteMover)
+		if !ok {
+			return errors.New("moving notes is not supported by the note store")
+		}
+		_, err := mover.Move(rule.OwnerID, []int64{n.ID}, a.NotebookID)
+		return err
+	case core.ActionCallWebhook:
+		return h.callRuleWebhook(ctx, a.URL, RuleEvent{Event: event, RuleID: rule.ID, Rule: rule.Name, Note: n, At: h.now()})
+	case core.ActionSendEmail:
+		if h.Mailer == nil {
+			return errors.New("email is not enabled")
+		}
+		// Rule emails count towards the owner's limit and go to the audit
+		// log like the ones they send themselves.
+		if _, ok := h.allowEmail(ctx, rule.OwnerID); !ok {
+			return errors.New("too many emails")
+		}
+		body := render.Shortcodes(core.NoteMarkdown(n)) + "\n\n--\nSent by the rule " + strconv.Quote(rule.Name) + " of " + rule.OwnerID + "\n"
+		return h.mailNote(ctx, rule.OwnerID, n, a.To, body)
+	}
+	return fmt.Errorf("unknown action %q", a.Type)
+}
+
+func (h *Handler) callRuleWebhook(ctx context.Context, target string, event RuleEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := h.RuleClient
+	if client == nil {
+		client = ruleClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
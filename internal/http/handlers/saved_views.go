@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// viewFilters are the GET /notes parameters a saved view can hold, besides
+// prop.{name}.
+var viewFilters = map[string]bool{"q": true, "tag": true, "state": true, "type": true, "notebook_id": true, "reacted": true, "reaction": true}
+
+// viewSorts orders notes by sort key; "-" before a key reverses it.
+var viewSorts = map[string]func(a, b core.Note) bool{
+	"created": func(a, b core.Note) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"updated": func(a, b core.Note) bool { return lastActivity(a).Before(lastActivity(b)) },
+	"title":   func(a, b core.Note) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) },
+}
+
+// noteFields are the JSON names of core.Note, which view fields pick from.
+var noteFields = func() []string {
+	var names []string
+	t := reflect.TypeOf(core.Note{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		names = append(names, name)
+	}
+	return names
+}()
+
+// CreateSavedView godoc
+// @Summary      Сохранить представление
+// @Description  Сохраняет фильтр заметок под именем. query — параметры GET /notes (q, tag, state, type, notebook_id, reacted, reaction, prop.{name}), sort — порядок (created, updated, title; с "-" — обратный), fields — поля заметок в ответе. Пользователи и команды из shared_with могут открывать представление, но не менять его
+// @Tags         views
+// @Accept       json
+// @Produce      json
+// @Param        input  body      core.SavedViewInput  true  "Представление"
+// @Success      201    {object}  core.SavedView
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /views [post]
+func (h *Handler) CreateSavedView(w http.ResponseWriter, r *http.Request) {
+	if !h.savedViewsEnabled(w) {
+		return
+	}
+	v, ok := h.decodeSavedView(w, r)
+	if !ok {
+		return
+	}
+	v, err := h.SavedViews.Create(v)
+	if err != nil {
+		respondErr(w, err, "Failed to create view")
+		return
+	}
+	w.Header().Set("Location", "/api/v1/views/"+strconv.FormatInt(v.ID, 10))
+	respondWithJSON(w, http.StatusCreated, v)
+}
+
+// ListSavedViews godoc
+// @Summary      Представления пользователя
+// @Description  Свои представления и те, которыми поделились с пользователем или его командами
+// @Tags         views
+// @Produce      json
+// @Success      200  {array}   core.SavedView
+// @Failure      404  {object}  map[string]string
+// @Router       /views [get]
+func (h *Handler) ListSavedViews(w http.ResponseWriter, r *http.Request) {
+	if !h.savedViewsEnabled(w) {
+		return
+	}
+	list := h.SavedViews.ListFor(auth.FromContext(r.Context()))
+	if list == nil {
+		list = []core.SavedView{}
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetSavedView godoc
+// @Summary      Представление
+// @Tags         views
+// @Produce      json
+// @Param        id   path      int  true  "ID"
+// @Success      200  {object}  core.SavedView
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /views/{id} [get]
+func (h *Handler) GetSavedView(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.loadSavedView(w, r, false)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, v)
+}
+
+// PutSavedView godoc
+// @Summary      Заменить представление
+// @Description  Доступно только владельцу
+// @Tags         views
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                  true  "ID"
+// @Param        input  body      core.SavedViewInput  true  "Представление"
+// @Success      200    {object}  core.SavedView
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /views/{id} [put]
+func (h *Handler) PutSavedView(w http.ResponseWriter, r *http.Request) {
+	old, ok := h.loadSavedView(w, r, true)
+	if !ok {
+		return
+	}
+	v, ok := h.decodeSavedView(w, r)
+	if !ok {
+		return
+	}
+	v.ID = old.ID
+	v, err := h.SavedViews.Replace(v)
+	if err != nil {
+		respondErr(w, err, "Failed to update view")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, v)
+}
+
+// DeleteSavedView godoc
+// @Summary      Удалить представление
+// @Description  Доступно только владельцу
+// @Tags         views
+// @Param        id   path  int  true  "ID"
+// @Success      204  "Представление удалено"
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /views/{id} [delete]
+func (h *Handler) DeleteSavedView(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.loadSavedView(w, r, true)
+	if !ok {
+		return
+	}
+	if err := h.SavedViews.Delete(v.ID); err != nil {
+		respondErr(w, err, "Failed to delete view")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SavedViewNotes godoc
+// @Summary      Заметки представления
+// @Description  Заметки, подходящие под фильтр представления, из тех, что может читать вызывающий, в порядке sort и только с полями fields, если они заданы
+// @Tags         views
+// @Produce      json
+// @Param        id     path   int  true   "ID"
+// @Param        page   query  int  false  "Номер страницы"
+// @Param        limit  query  int  false  "Размер страницы; по умолчанию page_size из настроек, без него — все"
+// @Success      200    {array}    core.Note
+// @Header       200    {integer}  X-Total-Count  "Общее количество"
+// @Failure      400    {object}   map[string]string
+// @Failure      404    {object}   map[string]string
+// @Failure      500    {object}   map[string]string
+// @Router       /views/{id}/notes [get]
+func (h *Handler) SavedViewNotes(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.loadSavedView(w, r, false)
+	if !ok {
+		return
+	}
+
+	// The view runs as a GET /notes of its query, with the paging of the
+	// request.
+	query, _ := url.ParseQuery(v.Query)
+	for _, param := range []string{"page", "limit"} {
+		if value := r.URL.Query().Get(param); value != "" {
+			query.Set(param, value)
+		}
+	}
+	vr := r.Clone(r.Context())
+	vr.URL.RawQuery = query.Encode()
+
+	scope, ok := h.listScope(w, vr)
+	if !ok {
+		return
+	}
+	notes, err := h.listNotes(vr, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+	if key := strings.TrimPrefix(v.Sort, "-"); key != "" {
+		less := viewSorts[key]
+		if v.Sort[0] == '-' {
+			less = func(a, b core.Note) bool { return viewSorts[key](b, a) }
+		}
+		sort.SliceStable(notes, func(i, j int) bool { return less(notes[i], notes[j]) })
+	}
+	notes, ok = h.paginate(w, vr, notes)
+	if !ok {
+		return
+	}
+	for i := range notes {
+		h.withPaths(&notes[i])
+	}
+	if len(v.Fields) == 0 {
+		respondWithJSON(w, http.StatusOK, notes)
+		return
+	}
+
+	picked := make([]map[string]json.RawMessage, 0, len(notes))
+	for _, n := range notes {
+		data, err := json.Marshal(n)
+		if err != nil {
+			respondError(w, CodeInternal, "Failed to get notes")
+			return
+		}
+		var all map[string]json.RawMessage
+		json.Unmarshal(data, &all)
+		fields := make(map[string]json.RawMessage, len(v.Fields))
+		for _, f := range v.Fields {
+			if value, ok := all[f]; ok {
+				fields[f] = value
+			}
+		}
+		picked = append(picked, fields)
+	}
+	respondWithJSON(w, http.StatusOK, picked)
+}
+
+func (h *Handler) savedViewsEnabled(w http.ResponseWriter) bool {
+	if h.SavedViews == nil {
+		respondError(w, CodeFeatureDisabled, "Saved views are not enabled")
+		return false
+	}
+	return true
+}
+
+// loadSavedView returns the view of the URL if the caller can run it, and
+// with owner, only if they own it.
+func (h *Handler) loadSavedView(w http.ResponseWriter, r *http.Request, owner bool) (*core.SavedView, bool) {
+	if !h.savedViewsEnabled(w) {
+		return nil, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid view ID")
+		return nil, false
+	}
+	p := auth.FromContext(r.Context())
+	v, err := h.SavedViews.GetByID(id)
+	if err == nil && !v.Visible(p) {
+		err = repo.ErrSavedViewNotFound
+	}
+	if err != nil {
+		respondErr(w, err, "Failed to get view")
+		return nil, false
+	}
+	if owner && v.OwnerID != p.UserID {
+		respondError(w, CodeForbidden, "Only the owner can change a view")
+		return nil, false
+	}
+	return v, true
+}
+
+// decodeSavedView reads and validates a core.SavedViewInput.
+func (h *Handler) decodeSavedView(w http.ResponseWriter, r *http.Request) (core.SavedView, bool) {
+	var in core.SavedViewInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return core.SavedView{}, false
+	}
+
+	p := auth.FromContext(r.Context())
+	v := core.SavedView{
+		OwnerID: p.UserID,
+		Name:    strings.TrimSpace(in.Name),
+		Query:   strings.TrimPrefix(strings.TrimSpace(in.Query), "?"),
+		Sort:    strings.TrimSpace(in.Sort),
+	}
+	invalid := &core.ErrValidation{Fields: map[string]string{}}
+	if v.Name == "" {
+		invalid.Fields["name"] = "name is required"
+	}
+	if query, err := url.ParseQuery(v.Query); err != nil {
+		invalid.Fields["query"] = "query is not a URL query"
+	} else {
+		for param := range query {
+			if !viewFilters[param] && !strings.HasPrefix(param, "prop.") {
+				invalid.Fields["query"] = "unknown filter " + param
+			}
+		}
+		if q := query.Get("q"); q != "" {
+			if h.Search == nil {
+				invalid.Fields["query"] = "search is not enabled"
+			} else if _, err := h.parseQuery(p.UserID, q); err != nil {
+				invalid.Fields["query"] = "q " + err.Error()
+			}
+		}
+		v.Query = query.Encode()
+	}
+	if _, ok := viewSorts[strings.TrimPrefix(v.Sort, "-")]; v.Sort != "" && !ok {
+		invalid.Fields["sort"] = "sort must be created, updated or title, optionally after -"
+	}
+	for _, f := range in.Fields {
+		name := ""
+		for _, known := range noteFields {
+			if strings.EqualFold(strings.TrimSpace(f), known) {
+				name = known
+			}
+		}
+		if name == "" {
+			invalid.Fields["fields"] = "unknown field " + f
+			continue
+		}
+		v.Fields = append(v.Fields, name)
+	}
+	for _, grantee := range in.SharedWith {
+		grantee = strings.TrimSpace(grantee)
+		if grantee == "" || grantee == core.TeamPrefix {
+			invalid.Fields["shared_with"] = "grantees must be users or team:<team>"
+			continue
+		}
+		if grantee != p.UserID {
+			v.SharedWith = append(v.SharedWith, grantee)
+		}
+	}
+	if len(invalid.Fields) > 0 {
+		respondErr(w, invalid, "")
+		return core.SavedView{}, false
+	}
+	return v, true
+}
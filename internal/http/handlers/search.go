@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/search"
+)
+
+// SearchResults are a page of search results and facets over all of them.
+type SearchResults struct {
+	Total  int           `json:"total" example:"42"`
+	Notes  []core.Note   `json:"notes"`
+	Facets search.Facets `json:"facets"`
+}
+
+// SearchNotes godoc
+// @Summary      Поиск по всем заметкам
+// @Description  Поиск по доступным заметкам на языке запросов (см. GET /search/validate), лучшие совпадения первыми. Кроме страницы результатов возвращает фасеты по всем результатам: сколько их по тегам, блокнотам, дате создания (в часовом поясе из настроек) и авторам. Фильтры — как у GET /notes
+// @Tags         search
+// @Produce      json
+// @Param        q      query  string  true   "Запрос, например tag:work AND created:>2024-01-01 AND \"точная фраза\""
+// @Param        page   query  int     false  "Номер страницы"
+// @Param        limit  query  int     false  "Размер страницы; по умолчанию page_size из настроек, без него — все"
+// @Param        type   query  string  false  "Тип заметки" Enums(note, snippet)
+// @Param        tag    query  string  false  "Тег, включая вложенные (project → project/alpha)"
+// @Param        state  query  string  false  "Состояния жизненного цикла через запятую"
+// @Param        notebook_id  query  int  false  "Только заметки блокнота"
+// @Param        prop.{name}  query  string  false  "Фильтр по свойству, например prop.status=done"
+// @Success      200    {object}   SearchResults
+// @Header       200    {integer}  X-Total-Count  "Общее количество"
+// @Failure      400    {object}   map[string]string
+// @Failure      404    {object}   map[string]string
+// @Failure      500    {object}   map[string]string
+// @Router       /search [get]
+func (h *Handler) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	if h.Search == nil {
+		respondError(w, CodeFeatureDisabled, "Search is not enabled")
+		return
+	}
+	if r.URL.Query().Get("q") == "" {
+		respondError(w, CodeInvalidRequest, "Missing q")
+		return
+	}
+	scope, ok := h.listScope(w, r)
+	if !ok {
+		return
+	}
+
+	notes, err := h.listNotes(r, scope)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to search notes")
+		return
+	}
+	loc := h.preferences(auth.FromContext(r.Context()).UserID).Location()
+	results := SearchResults{Total: len(notes), Facets: search.CountFacets(notes, h.now().In(loc))}
+	for i, f := range results.Facets.Notebooks {
+		id, _ := strconv.ParseInt(f.Value, 10, 64)
+		if nb, err := h.Notebooks.GetByID(id); err == nil {
+			results.Facets.Notebooks[i].Label = nb.Name
+		}
+	}
+
+	results.Notes, ok = h.paginate(w, r, notes)
+	if !ok {
+		return
+	}
+	for i := range results.Notes {
+		h.withPaths(&results.Notes[i])
+	}
+	respondWithJSON(w, http.StatusOK, results)
+}
+
+// ReindexSearch godoc
+// @Summary      Перестроить поисковый индекс
+// @Description  Строит новый индекс в фоне и подменяет им текущий; поиск работает всё это время
+// @Tags         admin
+// @Produce      json
+// @Success      202  {object}  search.Job
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/search/reindex [post]
+func (h *Handler) ReindexSearch(w http.ResponseWriter, r *http.Request) {
+	if !h.canAdminSearch(w, r) {
+		return
+	}
+
+	snapshotter, ok := noteStore[noteSnapshotter](h, w, "Reindexing")
+	if !ok {
+		return
+	}
+	job, err := h.Search.Reindex(snapshotter.Snapshot)
+	if err != nil {
+		respondError(w, CodeConflict, "Reindex already running")
+		return
+	}
+	w.Header().Set("Location", "/api/v1/admin/search/reindex")
+	respondWithJSON(w, http.StatusAccepted, job)
+}
+
+// ReindexStatus godoc
+// @Summary      Ход перестроения индекса
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  search.Job
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/search/reindex [get]
+func (h *Handler) ReindexStatus(w http.ResponseWriter, r *http.Request) {
+	if !h.canAdminSearch(w, r) {
+		return
+	}
+
+	job := h.Search.Job()
+	if job == nil {
+		respondError(w, CodeNotFound, "No reindex has run")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+func (h *Handler) canAdminSearch(w http.ResponseWriter, r *http.Request) bool {
+	if h.Search == nil {
+		respondError(w, CodeFeatureDisabled, "Search is not enabled")
+		return false
+	}
+	return requireAdmin(w, r)
+}
+
+// QueryValidation tells whether a search query parses: Query is its
+// canonical form and Language the analyzer of its words when it does,
+// Error where it goes wrong when not.
+type QueryValidation struct {
+	Valid    bool               `json:"valid" example:"true"`
+	Language string             `json:"language,omitempty" example:"russian"`
+	Query    string             `json:"query,omitempty" example:"tag:work AND created:>2024-01-01 AND \"exact phrase\""`
+	Error    *search.ParseError `json:"error,omitempty"`
+}
+
+// ValidateSearchQuery godoc
+// @Summary      Проверить поисковый запрос
+// @Description  Разбирает запрос на языке поиска так же, как параметр q у GET /search и GET /notes. Язык: слова; "точная фраза"; поля tag:, notebook:, type:, author:, pinned:, title:; даты created: и updated: с >, >=, <, <= (YYYY-MM-DD, в часовом поясе из настроек); AND (или пробел), OR, NOT (или - перед полем) и скобки. Другие слова с двоеточием (10:30, ссылки) и слова с - в начале ищутся как обычные слова. Слова приводятся к основе анализатором языка из настроек (search_language) или сервера, так что «заметки» находят «заметка»; стоп-слова пропускаются. Для верного запроса возвращает его каноническую запись и язык, для неверного — позицию (в символах от 0) и описание ошибки
+// @Tags         search
+// @Produce      json
+// @Param        q    query     string  true  "Запрос"
+// @Success      200  {object}  QueryValidation
+// @Failure      404  {object}  map[string]string
+// @Router       /search/validate [get]
+func (h *Handler) ValidateSearchQuery(w http.ResponseWriter, r *http.Request) {
+	if h.Search == nil {
+		respondError(w, CodeFeatureDisabled, "Search is not enabled")
+		return
+	}
+
+	q, err := h.parseQuery(auth.FromContext(r.Context()).UserID, r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithJSON(w, http.StatusOK, QueryValidation{Error: err.(*search.ParseError)})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, QueryValidation{Valid: true, Language: q.Analyzer().Name, Query: q.String()})
+}
+
+// parseQuery parses a search query with the analyzer and the time zone
+// of userID.
+func (h *Handler) parseQuery(userID, query string) (*search.Query, error) {
+	prefs := h.preferences(userID)
+	a, ok := search.AnalyzerByName(prefs.SearchLanguage)
+	if !ok {
+		a = h.Search.Analyzer
+	}
+	if a == nil {
+		a = search.Simple
+	}
+	return search.Parse(query, a, prefs.Location())
+}
+
+// searchNotes keeps the notes matching query, best match first. Callers
+// check that query parses; when it does not, nothing matches.
+func (h *Handler) searchNotes(userID string, notes []core.Note, query string) []core.Note {
+	q, err := h.parseQuery(userID, query)
+	if err != nil {
+		return []core.Note{}
+	}
+	return h.Search.Match(notes, q)
+}
+
+// TypeaheadHit is a note suggested while the user types.
+type TypeaheadHit struct {
+	ID    int64  `json:"id" example:"1"`
+	Title string `json:"title" example:"План релиза"`
+}
+
+// Typeahead godoc
+// @Summary      Подсказки при вводе
+// @Description  Быстрый поиск по началам слов заголовков для строки поиска: каждое слово запроса должно быть началом слова заголовка. Сначала заголовки, которые начинаются с запроса, затем более короткие и более новые. Индекс обновляется не чаще раза в секунду, так что только что изменённые заголовки могут появиться с задержкой
+// @Tags         search
+// @Produce      json
+// @Param        q      query  string  true   "Начало заголовка"
+// @Param        limit  query  int     false  "Сколько подсказок вернуть (по умолчанию 8, не больше 20)"
+// @Success      200    {array}   TypeaheadHit
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /search/typeahead [get]
+func (h *Handler) Typeahead(w http.ResponseWriter, r *http.Request) {
+	if h.Titles == nil {
+		respondError(w, CodeFeatureDisabled, "Typeahead is not enabled")
+		return
+	}
+	limit := 8
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 20 {
+			respondError(w, CodeInvalidRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	p := auth.FromContext(r.Context())
+	titles := make(map[int64]string)
+	ids := h.Titles.Lookup(r.URL.Query().Get("q"), limit, func(id int64) bool {
+		n, err := h.Repo.GetByID(id)
+		if err != nil || !h.noteRole(p, *n).Allows(core.RoleViewer) {
+			return false
+		}
+		titles[id] = n.Title
+		return true
+	})
+	hits := make([]TypeaheadHit, len(ids))
+	for i, id := range ids {
+		hits[i] = TypeaheadHit{ID: id, Title: titles[id]}
+	}
+	respondWithJSON(w, http.StatusOK, hits)
+}
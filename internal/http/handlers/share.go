@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/qr"
+	"example.com/notes-api/internal/render"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// SharePath is where public links are served, outside the API.
+const SharePath = "/s/"
+
+type PublicLinkResponse struct {
+	URL   string `json:"url" example:"https://notes.example.com/s/3q2-7wXz9kPbLm0aRt5uVyQe"`
+	Token string `json:"token" example:"3q2-7wXz9kPbLm0aRt5uVyQe"`
+}
+
+// GetPublicLink godoc
+// @Summary      Публичная ссылка на заметку
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  PublicLinkResponse
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string  "Заметки нет или она не опубликована"
+// @Router       /notes/{id}/public-link [get]
+func (h *Handler) GetPublicLink(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if note.ShareToken == "" {
+		respondError(w, CodeNotFound, "Note has no public link")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.publicLink(r, note.ShareToken))
+}
+
+// GetPublicLinkQR godoc
+// @Summary      QR-код публичной ссылки
+// @Description  PNG с QR-кодом ссылки, чтобы открыть заметку с телефона
+// @Tags         notes
+// @Produce      png
+// @Param        id     path      string  true   "ID или публичный UUID"
+// @Param        scale  query     int     false  "Пикселей на модуль (1-32)"  default(8)
+// @Success      200    {file}    binary
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string  "Заметки нет или она не опубликована"
+// @Router       /notes/{id}/public-link/qr.png [get]
+func (h *Handler) GetPublicLinkQR(w http.ResponseWriter, r *http.Request) {
+	scale := 8
+	if v := r.URL.Query().Get("scale"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 32 {
+			respondError(w, CodeInvalidRequest, "scale must be between 1 and 32")
+			return
+		}
+		scale = n
+	}
+
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if note.ShareToken == "" {
+		respondError(w, CodeNotFound, "Note has no public link")
+		return
+	}
+
+	code, err := qr.Encode([]byte(h.publicLink(r, note.ShareToken).URL))
+	if err != nil {
+		respondError(w, CodeInternal, "Public link is too long for a QR code")
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code.Image(scale)); err != nil {
+		respondError(w, CodeInternal, "Failed to render QR code")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	// The image embeds the link, which is revoked by deleting it.
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write(buf.Bytes())
+}
+
+// CreatePublicLink godoc
+// @Summary      Опубликовать заметку
+// @Description  Любой, у кого есть ссылка, увидит заметку без авторизации, в том числе превью в мессенджерах. Повторный вызов возвращает ту же ссылку
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      201  {object}  PublicLinkResponse
+// @Success      200  {object}  PublicLinkResponse  "Ссылка уже была"
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/public-link [post]
+func (h *Handler) CreatePublicLink(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+
+	status := http.StatusOK
+	if note.ShareToken == "" {
+		status = http.StatusCreated
+	}
+	sharer, ok := noteStore[noteSharer](h, w, "Public links")
+	if !ok {
+		return
+	}
+	note, err := sharer.Share(note.ID)
+	if err != nil {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return
+	}
+	if status == http.StatusCreated {
+		h.fireRules(core.TriggerNoteShared, *note)
+	}
+	respondWithJSON(w, status, h.publicLink(r, note.ShareToken))
+}
+
+// DeletePublicLink godoc
+// @Summary      Отозвать публичную ссылку
+// @Tags         notes
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/public-link [delete]
+func (h *Handler) DeletePublicLink(w http.ResponseWriter, r *http.Request) {
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	sharer, ok := noteStore[noteSharer](h, w, "Public links")
+	if !ok {
+		return
+	}
+	if err := sharer.Unshare(note.ID); err != nil {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SharedNote serves the public page of a shared note at SharePath{token}.
+// It is not part of the API: it answers HTML to anyone holding the link.
+func (h *Handler) SharedNote(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	sharer, ok := h.Repo.(noteSharer)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := sharer.ResolveShare(token)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	note, err := h.getNote(id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNoteNotFound) {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, "Failed to get note", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Share pages must not leak the link to the sites they link to.
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src https: http:; style-src 'unsafe-inline'")
+	if err := render.Shared(w, render.NotePage(*note, h.publicLink(r, token).URL, h.Emoji.URLs())); err != nil {
+		log.Printf("render shared note %d: %v", id, err)
+	}
+}
+
+func (h *Handler) publicLink(r *http.Request, token string) PublicLinkResponse {
+	return PublicLinkResponse{URL: h.baseURL(r) + SharePath + token, Token: token}
+}
+
+// baseURL returns BaseURL, or the address the request was made to.
+func (h *Handler) baseURL(r *http.Request) string {
+	if h.BaseURL != "" {
+		return strings.TrimRight(h.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bytes"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/site"
+)
+
+// siteTitle heads every page of an exported site.
+const siteTitle = "Заметки"
+
+// siteArchive zips a static site of the notes and notebooks p can read.
+func (h *Handler) siteArchive(p core.Principal) ([]byte, error) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+	notebooks, err := h.Notebooks.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	s := site.Site{Title: siteTitle, Generated: h.now()}
+	if h.Emoji != nil {
+		s.Emoji = h.Emoji.URLs()
+	}
+	for _, nb := range notebooks {
+		if h.Notebooks.Role(p, nb.ID).Allows(core.RoleViewer) {
+			s.Notebooks = append(s.Notebooks, nb)
+		}
+	}
+	for _, n := range notes {
+		if h.noteRole(p, n).Allows(core.RoleViewer) {
+			h.withPaths(&n)
+			s.Notes = append(s.Notes, n)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := site.Write(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetNoteBySlug godoc
+// @Summary      Получить заметку по slug
+// @Description  Slug строится из заголовка. Прежние slug переименованных заметок перенаправляют (301) на текущий
+// @Tags         notes
+// @Produce      json
+// @Param        slug  path  string  true  "Slug"  example(plany-na-2025)
+// @Success      200   {object}  core.Note
+// @Success      301   "Заметка переименована; Location указывает на текущий slug"
+// @Failure      404   {object}  map[string]string
+// @Router       /notes/slug/{slug} [get]
+func (h *Handler) GetNoteBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	resolver, ok := h.Repo.(noteResolver)
+	if !ok {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return
+	}
+	id, err := resolver.ResolveSlug(slug)
+	if err != nil {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return
+	}
+	note, err := h.getNote(id)
+	if err != nil {
+		respondErr(w, err, "Failed to get note")
+		return
+	}
+	if !h.canRead(r, *note) {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return
+	}
+
+	if note.Slug != slug {
+		http.Redirect(w, r, "/api/v1/notes/slug/"+note.Slug, http.StatusMovedPermanently)
+		return
+	}
+
+	h.recordView(r, *note)
+	h.withPaths(note)
+	respondWithJSON(w, http.StatusOK, note)
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/metrics"
+	"example.com/notes-api/internal/repo"
+)
+
+const (
+	defaultActivityDays = 30
+	maxActivityDays     = 366
+
+	defaultInstanceDays = 14
+	instanceTop         = 10
+)
+
+type ActivityDay struct {
+	Date    string `json:"date" example:"2025-01-31"`
+	Created int    `json:"created"`
+	Updated int    `json:"updated"`
+}
+
+// ActivityStats godoc
+// @Summary      Активность по дням
+// @Description  Сколько доступных заметок создано и изменено за каждый из последних дней. Дни считаются в часовом поясе tz, иначе в поясе из настроек, иначе в UTC
+// @Tags         stats
+// @Produce      json
+// @Param        days  query     int     false  "Сколько дней, включая сегодня (по умолчанию 30, не больше 366)"
+// @Param        tz    query     string  false  "Часовой пояс IANA"  example(Europe/Moscow)
+// @Success      200   {array}   ActivityDay
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /stats/activity [get]
+func (h *Handler) ActivityStats(w http.ResponseWriter, r *http.Request) {
+	loc, ok := h.location(w, r)
+	if !ok {
+		return
+	}
+
+	days := defaultActivityDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxActivityDays {
+			respondError(w, CodeInvalidRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notes")
+		return
+	}
+	notes = h.readable(r, notes)
+
+	now := h.now().In(loc)
+	first := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, loc)
+	stats := make([]ActivityDay, days)
+	index := make(map[string]int, days)
+	for i := range stats {
+		stats[i].Date = first.AddDate(0, 0, i).Format(time.DateOnly)
+		index[stats[i].Date] = i
+	}
+
+	for _, n := range notes {
+		if i, ok := index[n.CreatedAt.In(loc).Format(time.DateOnly)]; ok {
+			stats[i].Created++
+		}
+		if n.UpdatedAt != nil {
+			if i, ok := index[n.UpdatedAt.In(loc).Format(time.DateOnly)]; ok {
+				stats[i].Updated++
+			}
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+type StorageStats struct {
+	Total     repo.Usage        `json:"total"`
+	Notebooks []NotebookStorage `json:"notebooks"`
+	Tags      []TagStorage      `json:"tags"`
+	Blocks    []BlockStorage    `json:"blocks"`
+}
+
+type NotebookStorage struct {
+	// NotebookID is 0 for notes outside notebooks.
+	NotebookID int64  `json:"notebook_id"`
+	Name       string `json:"name,omitempty" example:"Работа"`
+	repo.Usage
+}
+
+type TagStorage struct {
+	Tag string `json:"tag" example:"work"`
+	repo.Usage
+}
+
+type BlockStorage struct {
+	Type core.BlockType `json:"type" example:"image"`
+	repo.Usage
+}
+
+// StorageStats godoc
+// @Summary      Занятое место
+// @Description  Сколько заметок и байт текста у пользователя всего, по блокнотам, тегам и типам блоков (для типов блоков считаются блоки). Счётчики ведутся при изменениях, запрос ничего не пересчитывает. Каждая группа отсортирована по убыванию байт
+// @Tags         stats
+// @Produce      json
+// @Success      200  {object}  StorageStats
+// @Failure      404  {object}  map[string]string  "Учёт места выключен"
+// @Router       /stats/storage [get]
+func (h *Handler) StorageStats(w http.ResponseWriter, r *http.Request) {
+	if h.Storage == nil {
+		respondError(w, CodeFeatureDisabled, "Storage usage is not enabled")
+		return
+	}
+	report := h.Storage.For(auth.FromContext(r.Context()).UserID)
+
+	stats := StorageStats{
+		Total:     report.Total,
+		Notebooks: make([]NotebookStorage, 0, len(report.Notebooks)),
+		Tags:      make([]TagStorage, 0, len(report.Tags)),
+		Blocks:    make([]BlockStorage, 0, len(report.Blocks)),
+	}
+	for id, u := range report.Notebooks {
+		s := NotebookStorage{NotebookID: id, Usage: u}
+		if id != 0 {
+			if nb, err := h.Notebooks.GetByID(id); err == nil {
+				s.Name = nb.Name
+			}
+		}
+		stats.Notebooks = append(stats.Notebooks, s)
+	}
+	for tag, u := range report.Tags {
+		stats.Tags = append(stats.Tags, TagStorage{Tag: tag, Usage: u})
+	}
+	for t, u := range report.Blocks {
+		stats.Blocks = append(stats.Blocks, BlockStorage{Type: t, Usage: u})
+	}
+	slices.SortFunc(stats.Notebooks, func(a, b NotebookStorage) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.NotebookID, b.NotebookID))
+	})
+	slices.SortFunc(stats.Tags, func(a, b TagStorage) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Tag, b.Tag))
+	})
+	slices.SortFunc(stats.Blocks, func(a, b BlockStorage) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Type, b.Type))
+	})
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+type InstanceStats struct {
+	// Users counts users who own at least one note.
+	Users     int           `json:"users"`
+	Notes     int           `json:"notes"`
+	Bytes     int64         `json:"bytes"`
+	Notebooks int           `json:"notebooks"`
+	TopUsers  []UserStorage `json:"top_users"`
+	// Requests and Errors are absent when metrics are disabled.
+	Requests []metrics.DayCount    `json:"requests_per_day,omitempty"`
+	Errors   []metrics.StatusCount `json:"top_errors,omitempty"`
+}
+
+type UserStorage struct {
+	UserID string `json:"user_id" example:"alice"`
+	repo.Usage
+}
+
+// InstanceStats godoc
+// @Summary      Статистика инстанса
+// @Description  Для админов: пользователи, заметки и место по счётчикам хранилища, крупнейшие пользователи, запросы по дням (UTC) и самые частые коды ошибок с момента запуска
+// @Tags         admin
+// @Produce      json
+// @Param        days  query     int  false  "Сколько дней запросов, включая сегодня (по умолчанию 14, не больше 90)"
+// @Success      200   {object}  InstanceStats
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string  "Учёт места выключен"
+// @Failure      500   {object}  map[string]string
+// @Router       /admin/stats [get]
+func (h *Handler) InstanceStats(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if h.Storage == nil {
+		respondError(w, CodeFeatureDisabled, "Storage usage is not enabled")
+		return
+	}
+
+	days := defaultInstanceDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > metrics.DailyRetention {
+			respondError(w, CodeInvalidRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+
+	notebooks, err := h.Notebooks.GetAll()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get notebooks")
+		return
+	}
+
+	stats := InstanceStats{Notebooks: len(notebooks), TopUsers: []UserStorage{}}
+	for id, u := range h.Storage.Owners() {
+		stats.Users++
+		stats.Notes += u.Count
+		stats.Bytes += u.Bytes
+		stats.TopUsers = append(stats.TopUsers, UserStorage{UserID: id, Usage: u})
+	}
+	slices.SortFunc(stats.TopUsers, func(a, b UserStorage) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.UserID, b.UserID))
+	})
+	if len(stats.TopUsers) > instanceTop {
+		stats.TopUsers = stats.TopUsers[:instanceTop]
+	}
+
+	if h.Metrics != nil {
+		summary := h.Metrics.Summary(days, instanceTop)
+		stats.Requests, stats.Errors = summary.Days, summary.Errors
+	}
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+// location returns the time zone that date buckets are computed in: the
+// tz query parameter, else the caller's preference, else UTC.
+func (h *Handler) location(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return h.preferences(auth.FromContext(r.Context()).UserID).Location(), true
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Unknown time zone")
+		return nil, false
+	}
+	return loc, true
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+)
+
+// SyncStatus godoc
+// @Summary      Состояние синхронизации
+// @Description  Итоги последнего запуска и недавние конфликты
+// @Tags         sync
+// @Produce      json
+// @Success      200  {object}  cloudsync.Status
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /sync/status [get]
+func (h *Handler) SyncStatus(w http.ResponseWriter, r *http.Request) {
+	if !h.canSync(w, r) {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Sync.Status())
+}
+
+// RunSync godoc
+// @Summary      Запустить синхронизацию
+// @Description  Выполняет синхронизацию немедленно, не дожидаясь расписания
+// @Tags         sync
+// @Produce      json
+// @Success      200  {object}  cloudsync.Status
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      502  {object}  map[string]string
+// @Router       /sync/run [post]
+func (h *Handler) RunSync(w http.ResponseWriter, r *http.Request) {
+	if !h.canSync(w, r) {
+		return
+	}
+	if err := h.Sync.Run(r.Context()); err != nil {
+		respondError(w, CodeUpstreamFailed, "Sync failed: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.Sync.Status())
+}
+
+// canSync allows admins and the user whose notes are synced.
+func (h *Handler) canSync(w http.ResponseWriter, r *http.Request) bool {
+	if h.Sync == nil {
+		respondError(w, CodeFeatureDisabled, "Sync is not configured")
+		return false
+	}
+
+	p := auth.FromContext(r.Context())
+	if !p.Admin && p.UserID != h.Sync.Principal.UserID {
+		respondError(w, CodeForbidden, "Forbidden")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+type RenameTagRequest struct {
+	Name string `json:"name" example:"projects"`
+}
+
+type MergeTagsRequest struct {
+	Source string `json:"source" example:"todo"`
+	Target string `json:"target" example:"tasks"`
+}
+
+// BulkTagRequest picks the notes a bulk tag job changes: those the caller
+// can edit that match every filter given.
+type BulkTagRequest struct {
+	Query      string `json:"q,omitempty" example:"отчёт"`
+	NotebookID int64  `json:"notebook_id,omitempty" example:"1"`
+	// From and To bound the creation time; To is exclusive.
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+type TagChangeResponse struct {
+	Tag          string `json:"tag"`
+	NotesUpdated int    `json:"notes_updated"`
+}
+
+// BulkTagReport is the result file of a bulk tag job.
+type BulkTagReport struct {
+	TagChangeResponse
+	// UndoToken reverts the job through POST /undo/{token} until
+	// UndoExpires; it is missing when undo is off or nothing changed.
+	UndoToken   string     `json:"undo_token,omitempty"`
+	UndoExpires *time.Time `json:"undo_expires,omitempty"`
+}
+
+// ListTags godoc
+// @Summary      Список тегов
+// @Tags         tags
+// @Produce      json
+// @Success      200  {array}   core.TagCount
+// @Failure      500  {object}  map[string]string
+// @Router       /tags [get]
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get tags")
+		return
+	}
+	notes = h.readable(r, notes)
+
+	respondWithJSON(w, http.StatusOK, core.CountTags(notes))
+}
+
+// TagTree godoc
+// @Summary      Дерево тегов
+// @Description  Иерархия тегов вида project/alpha с количеством заметок на каждом уровне
+// @Tags         tags
+// @Produce      json
+// @Success      200  {array}   core.TagNode
+// @Failure      500  {object}  map[string]string
+// @Router       /tags/tree [get]
+func (h *Handler) TagTree(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to get tags")
+		return
+	}
+	notes = h.readable(r, notes)
+
+	respondWithJSON(w, http.StatusOK, core.TagTree(notes))
+}
+
+// RenameTag godoc
+// @Summary      Переименовать тег
+// @Description  Атомарно переименовывает тег вместе с вложенными тегами во всех заметках, доступных на запись. Заметки, заблокированные для правки другим пользователем, пропускаются
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        name   path      string            true  "Текущее имя тега"
+// @Param        input  body      RenameTagRequest  true  "Новое имя"
+// @Success      200    {object}  TagChangeResponse
+// @Header       200    {string}  Undo-Token  "Токен для POST /undo/{token}"
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      423    {object}  ErrorResponse  "Все заметки с тегом заблокированы другими пользователями (note_locked)"
+// @Router       /tags/{name} [patch]
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	from := core.NormalizeTag(chi.URLParam(r, "name"))
+
+	var req RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	to := core.NormalizeTag(req.Name)
+	if from == "" || to == "" {
+		respondError(w, CodeInvalidRequest, "Tag name is required")
+		return
+	}
+
+	h.changeTag(w, r, from, to)
+}
+
+// MergeTags godoc
+// @Summary      Слить теги
+// @Description  Заменяет тег source на target во всех заметках, доступных на запись. Заметки, заблокированные для правки другим пользователем, пропускаются
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        input  body      MergeTagsRequest  true  "Исходный и целевой теги"
+// @Success      200    {object}  TagChangeResponse
+// @Header       200    {string}  Undo-Token  "Токен для POST /undo/{token}"
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      423    {object}  ErrorResponse  "Все заметки с тегом заблокированы другими пользователями (note_locked)"
+// @Router       /tags/merge [post]
+func (h *Handler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	var req MergeTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	source, target := core.NormalizeTag(req.Source), core.NormalizeTag(req.Target)
+	if source == "" || target == "" {
+		respondError(w, CodeInvalidRequest, "Source and target tags are required")
+		return
+	}
+
+	h.changeTag(w, r, source, target)
+}
+
+func (h *Handler) changeTag(w http.ResponseWriter, r *http.Request, from, to string) {
+	if core.TagMatches(to, from) {
+		respondError(w, CodeInvalidRequest, "Target tag must not be the source tag or its descendant")
+		return
+	}
+
+	renamer, ok := noteStore[tagRenamer](h, w, "Renaming tags")
+	if !ok {
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	editable := func(n core.Note) bool { return h.noteRole(p, n).Allows(core.RoleEditor) }
+	all, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to update tag")
+		return
+	}
+	var before []core.Note
+	for _, n := range all {
+		if core.HasTag(n, from) && editable(n) {
+			before = append(before, n)
+		}
+	}
+
+	count, err := renamer.RenameTag(p.UserID, from, to, editable)
+	if err != nil {
+		respondErr(w, err, "Failed to update tag")
+		return
+	}
+
+	h.offerUndo(w, r, "rename_tag", func() ([]core.Note, error) {
+		restored := make([]core.Note, 0, len(before))
+		for _, old := range before {
+			// Notes deleted or locked by someone else since are left as they are.
+			var locked *repo.LockedError
+			if err := h.updateNote(p.UserID, old.ID, map[string]interface{}{"tags": old.Tags}); errors.Is(err, repo.ErrNoteNotFound) || errors.As(err, &locked) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			n, err := h.Repo.GetByID(old.ID)
+			if err != nil {
+				return nil, err
+			}
+			restored = append(restored, *n)
+		}
+		return restored, nil
+	})
+	respondWithJSON(w, http.StatusOK, TagChangeResponse{Tag: to, NotesUpdated: count})
+}
+
+// ApplyTag godoc
+// @Summary      Добавить тег заметкам по фильтру
+// @Description  Добавляет тег всем доступным на запись заметкам, подходящим под все заданные фильтры: поиск q, блокнот notebook_id, дата создания from (включительно) — to (не включительно). Работает в фоне: ход — в GET /jobs/{id}, итог (BulkTagReport) — по result_url; его undo_token отменяет задачу через POST /undo/{token}. Заметки, заблокированные для правки другим пользователем, не меняются и попадают в ошибки задачи
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        name   path      string          true  "Тег"
+// @Param        input  body      BulkTagRequest  true  "Фильтр заметок"
+// @Success      202    {object}  JobResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string  "Фоновые задачи выключены"
+// @Failure      503    {object}  map[string]string  "Очередь задач переполнена"
+// @Router       /tags/{name}/apply [post]
+func (h *Handler) ApplyTag(w http.ResponseWriter, r *http.Request) {
+	h.bulkTag(w, r, "apply_tag", addTag, dropTag)
+}
+
+// RemoveTag godoc
+// @Summary      Снять тег с заметок по фильтру
+// @Description  Снимает тег (без вложенных) со всех доступных на запись заметок, подходящих под все заданные фильтры, как POST /tags/{name}/apply. Работает в фоне; итог и его undo_token — как у POST /tags/{name}/apply
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        name   path      string          true  "Тег"
+// @Param        input  body      BulkTagRequest  true  "Фильтр заметок"
+// @Success      202    {object}  JobResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string  "Фоновые задачи выключены"
+// @Failure      503    {object}  map[string]string  "Очередь задач переполнена"
+// @Router       /tags/{name}/remove [post]
+func (h *Handler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	h.bulkTag(w, r, "remove_tag", dropTag, addTag)
+}
+
+// addTag returns tags with tag added, or nil when they have it.
+func addTag(tags []string, tag string) []string {
+	if slices.Contains(tags, tag) {
+		return nil
+	}
+	return append(tags, tag)
+}
+
+// dropTag returns tags without tag, leaving its descendants, or nil when
+// they do not have it.
+func dropTag(tags []string, tag string) []string {
+	if !slices.Contains(tags, tag) {
+		return nil
+	}
+	return slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+}
+
+// bulkTag submits a job that sets the tags of every note matching the
+// request to change(tags, tag), skipping notes for which it returns nil.
+// Undoing the job applies revert to the notes it changed.
+func (h *Handler) bulkTag(w http.ResponseWriter, r *http.Request, kind string, change, revert func(tags []string, tag string) []string) {
+	if h.Jobs == nil {
+		respondError(w, CodeFeatureDisabled, "Background jobs are not enabled")
+		return
+	}
+	tag := core.NormalizeTag(chi.URLParam(r, "name"))
+	if tag == "" {
+		respondError(w, CodeInvalidRequest, "Tag name is required")
+		return
+	}
+
+	var req BulkTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	invalid := &core.ErrValidation{Fields: map[string]string{}}
+	p := auth.FromContext(r.Context())
+	if req.Query != "" {
+		if h.Search == nil {
+			invalid.Fields["q"] = "search is not enabled"
+		} else if _, err := h.parseQuery(p.UserID, req.Query); err != nil {
+			invalid.Fields["q"] = err.Error()
+		}
+	}
+	if req.NotebookID != 0 && !h.Notebooks.Role(p, req.NotebookID).Allows(core.RoleViewer) {
+		invalid.Fields["notebook_id"] = "notebook not found"
+	}
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		invalid.Fields["to"] = "to must be after from"
+	}
+	if len(invalid.Fields) > 0 {
+		respondErr(w, invalid, "")
+		return
+	}
+
+	job, err := h.Jobs.Submit(kind, p.UserID, func(ctx context.Context, progress *jobs.Progress) (*jobs.Result, error) {
+		notes, err := h.bulkTagNotes(p, req)
+		if err != nil {
+			return nil, err
+		}
+		progress.SetTotal(len(notes))
+
+		// The notes are listed once; each is changed as it is by the time
+		// the job reaches it.
+		var updated []int64
+		for _, n := range notes {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			changed, err := h.changeTags(p.UserID, n.ID, func(tags []string) []string { return change(tags, tag) })
+			if err != nil {
+				progress.Error("note " + strconv.FormatInt(n.ID, 10) + ": " + err.Error())
+			} else if changed {
+				updated = append(updated, n.ID)
+			}
+			progress.Step()
+		}
+
+		report := BulkTagReport{TagChangeResponse: TagChangeResponse{Tag: tag, NotesUpdated: len(updated)}}
+		if h.Undo != nil && len(updated) > 0 {
+			ticket := h.Undo.Push(p.UserID, kind, func() ([]core.Note, error) {
+				return h.revertTags(p.UserID, updated, func(tags []string) []string { return revert(tags, tag) })
+			})
+			report.UndoToken, report.UndoExpires = ticket.Token, &ticket.ExpiresAt
+		}
+		body, _ := json.MarshalIndent(report, "", "  ")
+		return &jobs.Result{Name: kind + "-report.json", ContentType: "application/json", Data: body}, nil
+	})
+	if err != nil {
+		respondError(w, CodeUnavailable, "Too many jobs queued")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/jobs/"+strconv.FormatInt(job.ID, 10))
+	respondWithJSON(w, http.StatusAccepted, h.jobResponse(job))
+}
+
+// changeTags sets the tags of a note to change(tags) on behalf of userID,
+// reporting false when change returns nil. Repositories without
+// ChangeTags get a read and a separate write.
+func (h *Handler) changeTags(userID string, id int64, change func(tags []string) []string) (bool, error) {
+	if changer, ok := h.Repo.(tagChanger); ok {
+		return changer.ChangeTags(userID, id, change)
+	}
+	n, err := h.Repo.GetByID(id)
+	if err != nil {
+		return false, err
+	}
+	tags := change(append([]string(nil), n.Tags...))
+	if tags == nil {
+		return false, nil
+	}
+	return true, h.updateNote(userID, id, map[string]interface{}{"tags": core.NormalizeTags(tags)})
+}
+
+// revertTags applies change to the tags of the notes ids for undo and
+// returns the notes. Notes deleted or locked by someone else since are
+// left as they are.
+func (h *Handler) revertTags(userID string, ids []int64, change func(tags []string) []string) ([]core.Note, error) {
+	reverted := make([]core.Note, 0, len(ids))
+	for _, id := range ids {
+		var locked *repo.LockedError
+		if _, err := h.changeTags(userID, id, change); errors.Is(err, repo.ErrNoteNotFound) || errors.As(err, &locked) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		n, err := h.Repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		reverted = append(reverted, *n)
+	}
+	return reverted, nil
+}
+
+// bulkTagNotes returns the notes p can edit that match req.
+func (h *Handler) bulkTagNotes(p core.Principal, req BulkTagRequest) ([]core.Note, error) {
+	all, err := h.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]core.Note, 0, len(all))
+	for _, n := range all {
+		switch {
+		case !h.noteRole(p, n).Allows(core.RoleEditor):
+		case req.NotebookID != 0 && n.NotebookID != req.NotebookID:
+		case req.From != nil && n.CreatedAt.Before(*req.From):
+		case req.To != nil && !n.CreatedAt.Before(*req.To):
+		default:
+			notes = append(notes, n)
+		}
+	}
+	if req.Query != "" {
+		notes = h.searchNotes(p.UserID, notes, req.Query)
+	}
+	return notes, nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/render"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListPrintTemplates godoc
+// @Summary      Шаблоны печати
+// @Description  Для админов: шаблоны для GET /notes/{id}/print?template=
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   render.Template
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/print-templates [get]
+func (h *Handler) ListPrintTemplates(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !h.printTemplatesEnabled(w) {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.PrintTemplates.List())
+}
+
+// PutPrintTemplate godoc
+// @Summary      Сохранить шаблон печати
+// @Description  Для админов. header и footer — строки text/template над данными страницы (.Title, .Notebook, .Tags, .Created, .Updated), они печатаются над и под заметкой в HTML и PDF. meta — где вывести строку метаданных: top, bottom или none. css дополняет стандартную вёрстку, а html полностью заменяет её шаблоном html/template; оба действуют только на HTML. Шаблон с ошибкой не сохраняется
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        name   path      string           true  "Имя: строчные буквы, цифры, - и _"
+// @Param        input  body      render.Template  true  "Шаблон"
+// @Success      200    {object}  render.Template
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /admin/print-templates/{name} [put]
+func (h *Handler) PutPrintTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !h.printTemplatesEnabled(w) {
+		return
+	}
+
+	var t render.Template
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+	t.Name = chi.URLParam(r, "name")
+	t.Author = auth.FromContext(r.Context()).UserID
+	t.SavedAt = h.now()
+
+	saved, err := h.PrintTemplates.Put(t)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, saved)
+}
+
+// DeletePrintTemplate godoc
+// @Summary      Удалить шаблон печати
+// @Tags         admin
+// @Param        name  path  string  true  "Имя"
+// @Success      204  "Шаблон удалён"
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/print-templates/{name} [delete]
+func (h *Handler) DeletePrintTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !h.printTemplatesEnabled(w) {
+		return
+	}
+	if err := h.PrintTemplates.Delete(chi.URLParam(r, "name")); err != nil {
+		respondErr(w, err, "Failed to delete template")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) printTemplatesEnabled(w http.ResponseWriter) bool {
+	if h.PrintTemplates == nil {
+		respondError(w, CodeFeatureDisabled, "Print templates are not enabled")
+		return false
+	}
+	return true
+}
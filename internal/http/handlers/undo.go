@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/undo"
+	"github.com/go-chi/chi/v5"
+)
+
+type UndoResponse struct {
+	Operation string      `json:"operation" example:"delete" enums:"delete,move,copy,rename_tag,apply_tag,remove_tag"`
+	Notes     []core.Note `json:"notes"`
+}
+
+// UndoOperation godoc
+// @Summary      Отменить операцию
+// @Description  Удаление заметки, перемещение и копирование заметок, переименование и слияние тегов возвращают заголовок Undo-Token; задачи массового добавления и снятия тега — поле undo_token в итоге. В течение нескольких секунд (Undo-Expires) токен позволяет отменить операцию один раз. Вложения удалённой заметки не восстанавливаются
+// @Tags         notes
+// @Produce      json
+// @Param        token  path      string  true  "Undo-Token"
+// @Success      200    {object}  UndoResponse
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "Отменяемое уже изменилось, например удалённая заметка создана заново или её заголовок занят в блокноте с уникальными заголовками, куда её удаление или перемещение отменяется (duplicate_title)"
+// @Router       /undo/{token} [post]
+func (h *Handler) UndoOperation(w http.ResponseWriter, r *http.Request) {
+	if h.Undo == nil {
+		respondError(w, CodeFeatureDisabled, "Undo is not enabled")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	op, notes, err := h.Undo.Undo(chi.URLParam(r, "token"), p.UserID)
+	if err != nil {
+		respondErr(w, err, "Failed to undo "+op)
+		return
+	}
+
+	for i := range notes {
+		h.withPaths(&notes[i])
+	}
+	respondWithJSON(w, http.StatusOK, UndoResponse{Operation: op, Notes: notes})
+}
+
+// offerUndo registers revert for the caller's operation and hands out its
+// token in the Undo-Token header.
+func (h *Handler) offerUndo(w http.ResponseWriter, r *http.Request, operation string, revert undo.Revert) {
+	if h.Undo == nil {
+		return
+	}
+	ticket := h.Undo.Push(auth.FromContext(r.Context()).UserID, operation, revert)
+	w.Header().Set("Undo-Token", ticket.Token)
+	w.Header().Set("Undo-Expires", ticket.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// restoreNote reverts the deletion of n. A notebook deleted in the
+// meantime leaves the note unfiled.
+func (h *Handler) restoreNote(restorer noteRestorer, n core.Note) ([]core.Note, error) {
+	if n.NotebookID != 0 {
+		if _, err := h.Notebooks.GetByID(n.NotebookID); err != nil {
+			n.NotebookID = 0
+		}
+	}
+	n.Path = nil
+	if err := restorer.Restore(n); err != nil {
+		return nil, err
+	}
+	restored, err := h.Repo.GetByID(n.ID)
+	if err != nil {
+		return nil, err
+	}
+	return []core.Note{*restored}, nil
+}
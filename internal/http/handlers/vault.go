@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/vault"
+)
+
+const maxVaultSize = 32 << 20
+
+type VaultImportResponse struct {
+	NotebooksCreated int      `json:"notebooks_created" example:"2"`
+	NotesCreated     int      `json:"notes_created" example:"10"`
+	Skipped          []string `json:"skipped"`
+}
+
+// ExportVault godoc
+// @Summary      Экспорт в формате Obsidian
+// @Description  ZIP-архив: папка на каждый блокнот, Markdown-файл на каждую заметку. Устарел: большие архивы не успевают собраться за время запроса, вместо него POST /export?format=vault. Ответы несут заголовки Deprecation, Sunset и Link
+// @Tags         vault
+// @Deprecated
+// @Produce      application/zip
+// @Success      200  {file}    binary
+// @Failure      500  {object}  map[string]string
+// @Router       /export/vault [get]
+func (h *Handler) ExportVault(w http.ResponseWriter, r *http.Request) {
+	data, err := h.vaultArchive(auth.FromContext(r.Context()))
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to export vault")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="vault.zip"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// vaultArchive zips every note and notebook p can read as a vault.
+func (h *Handler) vaultArchive(p core.Principal) ([]byte, error) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+	notebooks, err := h.Notebooks.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var folders [][]string
+	for _, nb := range notebooks {
+		if h.Notebooks.Role(p, nb.ID).Allows(core.RoleViewer) {
+			folders = append(folders, refNames(nb.Path))
+		}
+	}
+
+	entries := make([]vault.Entry, 0, len(notes))
+	for _, n := range notes {
+		if h.noteRole(p, n).Allows(core.RoleViewer) {
+			entries = append(entries, vault.Entry{Folder: refNames(h.Notebooks.Path(n.NotebookID)), Note: n})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := vault.Write(&buf, folders, entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportVault godoc
+// @Summary      Импорт из формата Obsidian
+// @Description  Папки становятся блокнотами, Markdown-файлы — заметками. Вложения и прочие файлы пропускаются и перечисляются в skipped
+// @Tags         vault
+// @Accept       application/zip
+// @Produce      json
+// @Param        notebook_id  query     int   false  "Родительский блокнот для импорта"
+// @Param        dry_run      query     bool  false  "Только разобрать архив: ответ 200 с тем, что было бы создано (или Prefer: dry-run)"
+// @Success      201          {object}  VaultImportResponse
+// @Success      200          {object}  VaultImportResponse  "dry_run: ничего не создано"
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      413          {object}  map[string]string
+// @Router       /import/vault [post]
+func (h *Handler) ImportVault(w http.ResponseWriter, r *http.Request) {
+	parentID, ok := h.importParent(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxVaultSize))
+	if err != nil {
+		respondError(w, CodeTooLarge, "Vault is too large")
+		return
+	}
+
+	v, err := vault.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid vault archive")
+		return
+	}
+
+	if dryRun(r) {
+		skipped := v.Skipped
+		if skipped == nil {
+			skipped = []string{}
+		}
+		respondDryRun(w, VaultImportResponse{NotebooksCreated: len(v.Folders), NotesCreated: len(v.Entries), Skipped: skipped})
+		return
+	}
+
+	resp, _, err := h.importVault(auth.FromContext(r.Context()).UserID, parentID, v, func() {})
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to import vault")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+// importParent reads the notebook_id an import goes into, checking the
+// caller may add to it. 0 imports at the top level.
+func (h *Handler) importParent(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	raw := r.URL.Query().Get("notebook_id")
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		respondError(w, CodeInvalidRequest, "Invalid notebook_id")
+		return 0, false
+	}
+	if _, err := h.Notebooks.GetByID(id); err != nil {
+		respondError(w, CodeInvalidRequest, "Notebook not found")
+		return 0, false
+	}
+	if !h.Notebooks.Role(auth.FromContext(r.Context()), id).Allows(core.RoleEditor) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return 0, false
+	}
+	return id, true
+}
+
+// importVault creates the folders of v as notebooks under parentID and its
+// notes in them, owned by ownerID, calling step after each. It returns the
+// IDs of the notes created.
+func (h *Handler) importVault(ownerID string, parentID int64, v *vault.Vault, step func()) (VaultImportResponse, []int64, error) {
+	resp := VaultImportResponse{Skipped: v.Skipped}
+	if resp.Skipped == nil {
+		resp.Skipped = []string{}
+	}
+
+	folders := map[string]int64{"": parentID}
+	for _, folder := range v.Folders {
+		parent := folders[strings.Join(folder[:len(folder)-1], "/")]
+		id, err := h.Notebooks.Create(core.Notebook{Name: folder[len(folder)-1], ParentID: parent, OwnerID: ownerID})
+		if err != nil {
+			return resp, nil, fmt.Errorf("create notebook %q: %w", folder[len(folder)-1], err)
+		}
+		folders[strings.Join(folder, "/")] = id
+		resp.NotebooksCreated++
+		step()
+	}
+
+	ids := make([]int64, 0, len(v.Entries))
+	for _, e := range v.Entries {
+		note := e.Note
+		note.OwnerID = ownerID
+		note.NotebookID = folders[strings.Join(e.Folder, "/")]
+		id, err := h.Repo.Create(note)
+		if err != nil {
+			return resp, ids, fmt.Errorf("create note %q: %w", note.Title, err)
+		}
+		ids = append(ids, id)
+		resp.NotesCreated++
+		step()
+	}
+
+	return resp, ids, nil
+}
+
+func refNames(path []core.NotebookRef) []string {
+	names := make([]string, len(path))
+	for i, ref := range path {
+		names[i] = ref.Name
+	}
+	return names
+}
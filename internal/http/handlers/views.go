@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// GetNoteViews godoc
+// @Summary      Кто просматривал заметку
+// @Description  Когда пользователи, которым доступна заметка, открывали её в последний раз. Просмотры владельца и администраторов не учитываются. Доступно только владельцу
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {array}   core.NoteView
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/views [get]
+func (h *Handler) GetNoteViews(w http.ResponseWriter, r *http.Request) {
+	if h.Views == nil {
+		respondError(w, CodeFeatureDisabled, "Read receipts are not enabled")
+		return
+	}
+
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	if !p.Admin && note.OwnerID != p.UserID {
+		respondError(w, CodeForbidden, "Only the owner can see who viewed the note")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, h.Views.For(note.ID))
+}
+
+// recordView notes that the caller viewed a note: in their recently
+// viewed notes and, when it is shared with them, for its read receipts.
+func (h *Handler) recordView(r *http.Request, n core.Note) {
+	h.recordRecent(r, n)
+	if h.Views == nil {
+		return
+	}
+	p := auth.FromContext(r.Context())
+	if p.Admin || n.OwnerID == p.UserID {
+		return
+	}
+	h.Views.Record(n.ID, p.UserID, h.now())
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/notify"
+)
+
+type WatchResponse struct {
+	Watching bool `json:"watching"`
+}
+
+type MarkReadRequest struct {
+	// IDs lists the notifications to mark read; empty marks all.
+	IDs []int64 `json:"ids,omitempty" example:"1,2"`
+}
+
+type MarkReadResponse struct {
+	Marked int `json:"marked"`
+}
+
+// WatchNote godoc
+// @Summary      Следить за заметкой
+// @Description  Уведомлять об изменениях и удалении заметки, в том числе чужой, если она доступна
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  WatchResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/watch [post]
+func (h *Handler) WatchNote(w http.ResponseWriter, r *http.Request) {
+	h.setWatch(w, r, true)
+}
+
+// UnwatchNote godoc
+// @Summary      Перестать следить за заметкой
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path      string  true  "ID или публичный UUID"
+// @Success      200  {object}  WatchResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /notes/{id}/watch [delete]
+func (h *Handler) UnwatchNote(w http.ResponseWriter, r *http.Request) {
+	h.setWatch(w, r, false)
+}
+
+func (h *Handler) setWatch(w http.ResponseWriter, r *http.Request, watch bool) {
+	if h.Watches == nil || h.Notifications == nil {
+		respondError(w, CodeFeatureDisabled, "Notifications are not enabled")
+		return
+	}
+
+	note, ok := h.loadNote(w, r)
+	if !ok {
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	if watch {
+		h.Watches.Watch(note.ID, p)
+	} else {
+		h.Watches.Unwatch(note.ID, p.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, WatchResponse{Watching: watch})
+}
+
+// ListNotifications godoc
+// @Summary      Мои уведомления
+// @Description  Новые первыми; хранятся последние 500
+// @Tags         notifications
+// @Produce      json
+// @Param        unread  query     bool  false  "Только непрочитанные"
+// @Success      200     {array}   notify.Notification
+// @Failure      404     {object}  map[string]string
+// @Router       /notifications [get]
+func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	if h.Notifications == nil {
+		respondError(w, CodeFeatureDisabled, "Notifications are not enabled")
+		return
+	}
+
+	unread, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+	respondWithJSON(w, http.StatusOK, h.Notifications.List(auth.FromContext(r.Context()).UserID, unread))
+}
+
+// MarkNotificationsRead godoc
+// @Summary      Отметить уведомления прочитанными
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        input  body      MarkReadRequest  false  "Какие уведомления; без ids — все"
+// @Success      200    {object}  MarkReadResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /notifications/read [post]
+func (h *Handler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	if h.Notifications == nil {
+		respondError(w, CodeFeatureDisabled, "Notifications are not enabled")
+		return
+	}
+
+	var req MarkReadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, CodeInvalidJSON, "Invalid JSON")
+			return
+		}
+	}
+
+	marked := h.Notifications.MarkRead(auth.FromContext(r.Context()).UserID, req.IDs)
+	respondWithJSON(w, http.StatusOK, MarkReadResponse{Marked: marked})
+}
+
+// notifyWatchers tells the watchers of a note, other than the caller and
+// those who lost access to it, what the caller did.
+func (h *Handler) notifyWatchers(r *http.Request, n core.Note, typ string) {
+	if h.Watches == nil || h.Notifications == nil {
+		return
+	}
+
+	actor := auth.FromContext(r.Context()).UserID
+	now := h.now()
+	for _, p := range h.Watches.Watchers(n.ID) {
+		if p.UserID == actor || !h.noteRole(p, n).Allows(core.RoleViewer) ||
+			!h.preferences(p.UserID).Notifications.Watched {
+			continue
+		}
+		h.Notifications.Add(p.UserID, notify.Notification{
+			Type:   typ,
+			NoteID: n.ID,
+			Title:  n.Title,
+			Actor:  actor,
+			At:     now,
+		})
+	}
+	if typ == notify.NoteDeleted {
+		h.Watches.Forget(n.ID)
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/core"
+)
+
+// zapierPollLimit is how many items a polling trigger returns. Zapier only
+// looks at the newest ones and dedupes them by id.
+const zapierPollLimit = 50
+
+// ZapierNote is the flat shape no-code tools expect: a lowercase id that is
+// unique per trigger event, and scalar fields that map easily onto forms.
+type ZapierNote struct {
+	ID         string    `json:"id" example:"12"`
+	NoteID     int64     `json:"note_id" example:"12"`
+	Title      string    `json:"title" example:"Новая заметка"`
+	Content    string    `json:"content" example:"Текст заметки"`
+	Tags       string    `json:"tags" example:"work, ideas"`
+	NotebookID int64     `json:"notebook_id" example:"1"`
+	Pinned     bool      `json:"pinned"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ZapierMe struct {
+	UserID string `json:"user_id" example:"alice"`
+}
+
+type ZapierCreateRequest struct {
+	Title      string `json:"title" example:"Из Zapier"`
+	Content    string `json:"content" example:"Текст"`
+	Tags       string `json:"tags" example:"inbox, zapier"`
+	NotebookID int64  `json:"notebook_id" example:"0"`
+}
+
+type ZapierAppendRequest struct {
+	NoteID  int64  `json:"note_id" example:"12"`
+	Content string `json:"content" example:"Ещё строка"`
+}
+
+// ZapierMe godoc
+// @Summary      Проверка ключа для Zapier/IFTTT
+// @Tags         zapier
+// @Produce      json
+// @Success      200  {object}  ZapierMe
+// @Failure      401  {object}  map[string]string
+// @Router       /zapier/me [get]
+func (h *Handler) ZapierMe(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, ZapierMe{UserID: auth.FromContext(r.Context()).UserID})
+}
+
+// ZapierNewNotes godoc
+// @Summary      Триггер: новая заметка
+// @Description  Новейшие заметки первыми; id совпадает с ID заметки
+// @Tags         zapier
+// @Produce      json
+// @Param        notebook_id  query     int  false  "Только из блокнота"
+// @Success      200          {array}   ZapierNote
+// @Router       /zapier/triggers/new_note [get]
+func (h *Handler) ZapierNewNotes(w http.ResponseWriter, r *http.Request) {
+	notes, ok := h.zapierNotes(w, r)
+	if !ok {
+		return
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].ID > notes[j].ID })
+
+	items := make([]ZapierNote, 0, zapierPollLimit)
+	for _, n := range limitNotes(notes, zapierPollLimit) {
+		items = append(items, zapierNote(n, strconv.FormatInt(n.ID, 10)))
+	}
+	respondWithJSON(w, http.StatusOK, items)
+}
+
+// ZapierNewTaggedNotes godoc
+// @Summary      Триггер: заметка получила тег
+// @Description  id составлен из ID заметки и тега, поэтому заметка, помеченная позже, тоже срабатывает
+// @Tags         zapier
+// @Produce      json
+// @Param        tag          query     string  true   "Тег (включая вложенные)"
+// @Param        notebook_id  query     int     false  "Только из блокнота"
+// @Success      200          {array}   ZapierNote
+// @Failure      400          {object}  map[string]string
+// @Router       /zapier/triggers/new_tagged_note [get]
+func (h *Handler) ZapierNewTaggedNotes(w http.ResponseWriter, r *http.Request) {
+	tag := core.NormalizeTag(r.URL.Query().Get("tag"))
+	if tag == "" {
+		respondError(w, CodeInvalidRequest, "Tag is required")
+		return
+	}
+
+	notes, ok := h.zapierNotes(w, r)
+	if !ok {
+		return
+	}
+
+	tagged := notes[:0]
+	for _, n := range notes {
+		if core.HasTag(n, tag) {
+			tagged = append(tagged, n)
+		}
+	}
+	// A note is tagged at its last update at the latest, which is the best
+	// ordering available without a tag history.
+	sort.SliceStable(tagged, func(i, j int) bool {
+		return lastActivity(tagged[i]).After(lastActivity(tagged[j]))
+	})
+
+	items := make([]ZapierNote, 0, zapierPollLimit)
+	for _, n := range limitNotes(tagged, zapierPollLimit) {
+		items = append(items, zapierNote(n, strconv.FormatInt(n.ID, 10)+":"+tag))
+	}
+	respondWithJSON(w, http.StatusOK, items)
+}
+
+// ZapierCreateNote godoc
+// @Summary      Действие: создать заметку
+// @Tags         zapier
+// @Accept       json
+// @Produce      json
+// @Param        input  body      ZapierCreateRequest  true  "Заметка; теги через запятую"
+// @Success      201    {object}  ZapierNote
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      409    {object}  map[string]string  "В блокноте уже есть заметка с таким заголовком (duplicate_title)"
+// @Router       /zapier/actions/create_note [post]
+func (h *Handler) ZapierCreateNote(w http.ResponseWriter, r *http.Request) {
+	var req ZapierCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		respondError(w, CodeInvalidRequest, "Title is required")
+		return
+	}
+
+	p := auth.FromContext(r.Context())
+	if req.NotebookID != 0 {
+		if _, err := h.Notebooks.GetByID(req.NotebookID); err != nil {
+			respondError(w, CodeInvalidRequest, "Notebook not found")
+			return
+		}
+		if !h.Notebooks.Role(p, req.NotebookID).Allows(core.RoleEditor) {
+			respondError(w, CodeForbidden, "Forbidden")
+			return
+		}
+	}
+
+	n := core.Note{
+		OwnerID:    p.UserID,
+		NotebookID: req.NotebookID,
+		Type:       core.NoteTypeNote,
+		Title:      req.Title,
+		Content:    req.Content,
+		Tags:       core.NormalizeTags(strings.Split(req.Tags, ",")),
+	}
+	if err := h.applyNotebookDefaults(&n); err != nil {
+		respondErr(w, err, "Failed to apply notebook settings")
+		return
+	}
+	id, err := h.Repo.Create(n)
+	if err != nil {
+		respondErr(w, err, "Failed to create note")
+		return
+	}
+
+	note, err := h.Repo.GetByID(id)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve created note")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, zapierNote(*note, strconv.FormatInt(id, 10)))
+}
+
+// ZapierAppendToNote godoc
+// @Summary      Действие: дописать текст в заметку
+// @Tags         zapier
+// @Accept       json
+// @Produce      json
+// @Param        input  body      ZapierAppendRequest  true  "Заметка и текст"
+// @Success      200    {object}  ZapierNote
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      423    {object}  ErrorResponse  "Заметку заблокировал для правки другой пользователь (note_locked)"
+// @Router       /zapier/actions/append_to_note [post]
+func (h *Handler) ZapierAppendToNote(w http.ResponseWriter, r *http.Request) {
+	var req ZapierAppendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, CodeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	note, err := h.Repo.GetByID(req.NoteID)
+	if err != nil || !h.canRead(r, *note) {
+		respondError(w, CodeNoteNotFound, "Note not found")
+		return
+	}
+	if !h.canWrite(r, *note) {
+		respondError(w, CodeForbidden, "Forbidden")
+		return
+	}
+	if !h.unlocked(w, r, *note) {
+		return
+	}
+	if len(note.Blocks) > 0 {
+		respondError(w, CodeInvalidRequest, "Cannot append to a block note")
+		return
+	}
+
+	content := req.Content
+	if note.Content != "" {
+		content = note.Content + "\n" + req.Content
+	}
+	if err := h.updateNote(auth.FromContext(r.Context()).UserID, note.ID, map[string]interface{}{"content": content}); err != nil {
+		respondErr(w, err, "Failed to update note")
+		return
+	}
+
+	note, err = h.Repo.GetByID(note.ID)
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve updated note")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, zapierNote(*note, strconv.FormatInt(note.ID, 10)))
+}
+
+// zapierNotes returns the caller's readable notes, optionally limited to a
+// notebook.
+func (h *Handler) zapierNotes(w http.ResponseWriter, r *http.Request) ([]core.Note, bool) {
+	notes, err := h.Repo.List()
+	if err != nil {
+		respondError(w, CodeInternal, "Failed to retrieve notes")
+		return nil, false
+	}
+	notes = h.readable(r, notes)
+
+	if raw := r.URL.Query().Get("notebook_id"); raw != "" {
+		notebookID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			respondError(w, CodeInvalidRequest, "Invalid notebook_id")
+			return nil, false
+		}
+		filtered := notes[:0]
+		for _, n := range notes {
+			if n.NotebookID == notebookID {
+				filtered = append(filtered, n)
+			}
+		}
+		notes = filtered
+	}
+
+	return notes, true
+}
+
+func zapierNote(n core.Note, id string) ZapierNote {
+	content := n.Content
+	if len(n.Blocks) > 0 {
+		content = core.PlainText(n.Blocks)
+	}
+	return ZapierNote{
+		ID:         id,
+		NoteID:     n.ID,
+		Title:      n.Title,
+		Content:    content,
+		Tags:       strings.Join(n.Tags, ", "),
+		NotebookID: n.NotebookID,
+		Pinned:     n.Pinned,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  lastActivity(n),
+	}
+}
@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"net/http"
+	"time"
+
+	"example.com/notes-api/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// instrument records every request under its route pattern rather than its
+// path, so that IDs never end up in metric labels.
+func instrument(reg *metrics.Registry) func(http.Handler) http.Handler {
+	known := map[string]bool{http.MethodOptions: true}
+	for _, method := range routeMethods {
+		known[method] = true
+	}
+	for _, method := range davMethods {
+		known[method] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			if route == "" {
+				route = metrics.Unmatched
+			}
+			method := r.Method
+			if !known[method] {
+				method = "other"
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			reg.Observe(route, method, status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"example.com/notes-api/internal/http/handlers"
+	"github.com/go-chi/chi/v5"
+)
+
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// routeTable looks up the methods of routes for HEAD, OPTIONS and 405
+// responses. The middleware gets it before any route is added, so it
+// flattens the routes on first use, when they are all there, and keeps
+// the copy.
+type routeTable struct {
+	routes chi.Routes
+	once   sync.Once
+	flat   *chi.Mux
+}
+
+func (t *routeTable) mux() *chi.Mux {
+	t.once.Do(func() { t.flat = flatten(t.routes) })
+	return t.flat
+}
+
+// allowedMethods returns the methods routed for path, in a stable order.
+// HEAD is implied by GET, and OPTIONS by any route at all.
+func (t *routeTable) allowedMethods(path string) []string {
+	flat := t.mux()
+
+	var allowed []string
+	for _, method := range routeMethods {
+		if method == http.MethodHead && len(allowed) > 0 && allowed[len(allowed)-1] == http.MethodHead {
+			continue
+		}
+		if !flat.Match(chi.NewRouteContext(), method, path) {
+			continue
+		}
+		allowed = append(allowed, method)
+		if method == http.MethodGet {
+			allowed = append(allowed, http.MethodHead)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return nil
+	}
+	return append(allowed, http.MethodOptions)
+}
+
+// flatten copies every route of a nested router into a single-level mux.
+// Match on the original tree reports any method as allowed on the root of
+// a mounted sub-router, so lookups go through the flat copy instead.
+func flatten(routes chi.Routes) *chi.Mux {
+	flat := chi.NewRouter()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if method == "*" {
+			return nil
+		}
+		flat.Method(method, route, noop)
+		if trimmed := strings.TrimSuffix(route, "/"); trimmed != "" && trimmed != route {
+			flat.Method(method, trimmed, noop)
+		}
+		return nil
+	})
+
+	return flat
+}
+
+// headAndOptions answers OPTIONS with the Allow list for the path and serves
+// HEAD through the GET handler, discarding the body but keeping Content-Length.
+// Routes that handle OPTIONS or HEAD themselves are left alone.
+func (t *routeTable) headAndOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := chi.RouteContext(r.Context())
+
+		switch r.Method {
+		case http.MethodOptions:
+			if t.mux().Match(chi.NewRouteContext(), http.MethodOptions, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed := t.allowedMethods(r.URL.Path)
+			if allowed == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusNoContent)
+			return
+
+		case http.MethodHead:
+			if t.mux().Match(chi.NewRouteContext(), http.MethodHead, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rctx.RouteMethod = http.MethodGet
+
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			hw := &headResponseWriter{ResponseWriter: w, stop: cancel}
+			next.ServeHTTP(hw, r.WithContext(ctx))
+			hw.flush()
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type headResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+	// stop ends the request, for streams: the headers are all HEAD
+	// answers with, and they have no length.
+	stop     func()
+	streamed bool
+	flushed  bool
+}
+
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += len(b)
+	return len(b), nil
+}
+
+// Flush sends the headers of a streaming response and ends the stream,
+// whose body HEAD leaves out anyway.
+func (w *headResponseWriter) Flush() {
+	w.streamed = true
+	w.flush()
+	w.stop()
+}
+
+func (w *headResponseWriter) flush() {
+	if w.flushed {
+		return
+	}
+	w.flushed = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.streamed && w.Header().Get("Content-Length") == "" && w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		w.Header().Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (t *routeTable) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if allowed := t.allowedMethods(r.URL.Path); allowed != nil {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	respondError(w, handlers.CodeMethodNotAllowed, "Method not allowed")
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	respondError(w, handlers.CodeNotFound, "Not found")
+}
+
+func respondError(w http.ResponseWriter, c handlers.ErrorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(c.Status)
+	json.NewEncoder(w).Encode(handlers.ErrorResponse{Error: message, Code: c.Code})
+}
@@ -0,0 +1,28 @@
+package httpx
+
+import (
+	"net/http"
+	"sync"
+
+	"example.com/notes-api/internal/apispec"
+)
+
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	reg, err := apispec.Default()
+	if err != nil {
+		return nil, err
+	}
+	return reg.OpenAPI()
+})
+
+// serveOpenAPI answers the OpenAPI 3.1 document of the registry built into
+// the binary.
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := openAPIDocument()
+	if err != nil {
+		http.Error(w, "OpenAPI document unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(doc)
+}
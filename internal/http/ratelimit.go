@@ -0,0 +1,61 @@
+package httpx
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/ratelimit"
+)
+
+// rateLimit applies the caller's plan limit. Authenticated callers are
+// limited per user, anonymous ones per client address. When the limiter
+// itself fails, requests are let through rather than failing the API.
+func rateLimit(limiter ratelimit.Limiter, plans ratelimit.Plans) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := auth.FromContext(r.Context())
+			plan, limit := plans.For(p.Admin)
+			if limit.Unlimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := plan + ":user:" + p.UserID
+			if p.UserID == auth.Anonymous.UserID {
+				host, _, err := net.SplitHostPort(r.RemoteAddr)
+				if err != nil {
+					host = r.RemoteAddr
+				}
+				key = plan + ":ip:" + host
+			}
+
+			res, err := limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				log.Printf("rate limit: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Rate))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(res.ResetAfter))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", seconds(res.RetryAfter))
+				respondError(w, handlers.CodeRateLimited, "Too many requests")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,100 @@
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/record"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// secretPaths are the paths whose next segment is a credential: public
+// link tokens and inbound webhook tokens.
+var secretPaths = []string{handlers.SharePath, "/api/v1/inbound/"}
+
+// secretParams are the query parameters of signed download URLs.
+var secretParams = []string{"sig", "expires"}
+
+// recordedURL returns the request URI of u with the credentials it
+// carries replaced by record.Redacted.
+func recordedURL(u *url.URL) string {
+	clean := *u
+	for _, prefix := range secretPaths {
+		if rest, ok := strings.CutPrefix(clean.Path, prefix); ok && rest != "" {
+			_, tail, _ := strings.Cut(rest, "/")
+			if tail != "" {
+				tail = "/" + tail
+			}
+			clean.Path = prefix + record.Redacted + tail
+			clean.RawPath = ""
+		}
+	}
+	if q := clean.Query(); len(q) > 0 {
+		changed := false
+		for _, name := range secretParams {
+			if q.Has(name) {
+				q.Set(name, record.Redacted)
+				changed = true
+			}
+		}
+		if changed {
+			clean.RawQuery = q.Encode()
+		}
+	}
+	return clean.RequestURI()
+}
+
+// recordRequests stores every request with the status it was answered
+// with. The caller is identified by user ID, resolved from tokens, rather
+// than by credentials, which are also left out of URLs and bodies.
+func recordRequests(rec *record.Recorder, tokens auth.Tokens) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e := record.Entry{
+				Time:   time.Now().UTC(),
+				Method: r.Method,
+				URL:    recordedURL(r.URL),
+				Header: record.Sanitize(r.Header),
+			}
+			if token, ok := credentials(r); ok {
+				if p, found := tokens[token]; found {
+					e.User = p.UserID
+				}
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, record.MaxBody+1))
+			if err != nil {
+				respondError(w, handlers.CodeInvalidRequest, "Failed to read body")
+				return
+			}
+			if len(body) > record.MaxBody {
+				e.BodyOmitted = true
+			} else {
+				e.Body = record.SanitizeBody(body)
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				e.Status = ww.Status()
+				if e.Status == 0 {
+					e.Status = http.StatusOK
+				}
+				if err := rec.Record(e); err != nil {
+					log.Printf("record: %v", err)
+				}
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
@@ -2,32 +2,330 @@ package httpx
 
 import (
 	"net/http"
+	"time"
 
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/faults"
 	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/metrics"
+	"example.com/notes-api/internal/ratelimit"
+	"example.com/notes-api/internal/record"
+	"example.com/notes-api/internal/webui"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-func NewRouter(h *handlers.Handler) *chi.Mux {
+type Options struct {
+	// Tokens enables bearer authentication on the API. When empty, every
+	// request runs as auth.Anonymous.
+	Tokens auth.Tokens
+	// Metrics, when set, records every request and is served at /metrics.
+	Metrics *metrics.Registry
+	// RateLimiter, when set, limits every authenticated route to the
+	// caller's plan in RateLimits.
+	RateLimiter ratelimit.Limiter
+	RateLimits  ratelimit.Plans
+	// Ready, when set, answers readiness probes at /readyz.
+	Ready http.Handler
+	// Faults, when set, injects latency, errors and dropped connections
+	// into matching requests. It is for testing clients only.
+	Faults *faults.Injector
+	// Recorder, when set, stores every request for notes-replay.
+	Recorder *record.Recorder
+}
+
+// davMethods are the WebDAV extension methods; chi only routes methods it
+// has been told about.
+var davMethods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+func init() {
+	for _, method := range davMethods {
+		chi.RegisterMethod(method)
+	}
+}
+
+func NewRouter(h *handlers.Handler, opts Options) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	if opts.Metrics != nil {
+		r.Use(instrument(opts.Metrics))
+	}
+	if opts.Recorder != nil {
+		r.Use(recordRequests(opts.Recorder, opts.Tokens))
+	}
+	if opts.Faults != nil {
+		r.Use(injectFaults(opts.Faults))
+	}
+	routes := &routeTable{routes: r}
+	r.Use(routes.headAndOptions)
+
+	r.NotFound(notFound)
+	r.MethodNotAllowed(routes.methodNotAllowed)
 
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(envelope)
+		r.Use(authenticate(opts.Tokens, "/api/v1/zapier/"))
+		if opts.RateLimiter != nil {
+			r.Use(rateLimit(opts.RateLimiter, opts.RateLimits))
+		}
+
 		r.Route("/notes", func(r chi.Router) {
 			r.Post("/", h.CreateNote)
 			r.Get("/", h.ListNotes)
+			r.Get("/count", h.CountNotes)
+			r.Get("/random", h.RandomNote)
+			r.Get("/favorites", h.ListFavorites)
+			r.Get("/recent", h.ListRecentNotes)
+			r.Get("/nearby", h.NearbyNotes)
+			r.Get("/slug/{slug}", h.GetNoteBySlug)
+			r.Patch("/reorder", h.ReorderNotes)
+			r.Post("/move", h.BulkMoveNotes)
+			r.Post("/copy", h.BulkCopyNotes)
 			r.Route("/{id}", func(r chi.Router) {
 				r.Get("/", h.GetNote)
+				r.Head("/", h.HeadNote)
 				r.Patch("/", h.PatchNote)
 				r.Delete("/", h.DeleteNote)
+				r.Get("/markdown", h.GetNoteMarkdown)
+				r.Get("/highlight", h.GetNoteHighlighted)
+				r.Get("/print", h.GetNotePrint)
+				r.Post("/move", h.MoveNote)
+				r.Post("/copy", h.CopyNote)
+				r.Get("/transitions", h.GetNoteTransitions)
+				r.Post("/transitions", h.TransitionNote)
+				r.Get("/comments", h.ListComments)
+				r.Post("/comments", h.CreateComment)
+				r.Patch("/comments/{cid}", h.UpdateComment)
+				r.Delete("/comments/{cid}", h.DeleteComment)
+				r.Get("/lock", h.GetNoteLock)
+				r.Post("/lock", h.LockNote)
+				r.Delete("/lock", h.UnlockNote)
+				r.Get("/presence", h.GetPresence)
+				r.Post("/presence", h.UpdatePresence)
+				r.Delete("/presence", h.LeavePresence)
+				r.Post("/reactions", h.AddReaction)
+				r.Delete("/reactions", h.RemoveReaction)
+				r.Get("/views", h.GetNoteViews)
+				r.Post("/favorite", h.FavoriteNote)
+				r.Delete("/favorite", h.UnfavoriteNote)
+				r.Post("/watch", h.WatchNote)
+				r.Delete("/watch", h.UnwatchNote)
+				r.Get("/public-link", h.GetPublicLink)
+				r.Post("/public-link", h.CreatePublicLink)
+				r.Delete("/public-link", h.DeletePublicLink)
+				r.Get("/public-link/qr.png", h.GetPublicLinkQR)
+				r.Get("/link-previews", h.GetLinkPreviews)
+				r.Post("/email", h.EmailNote)
+				r.Get("/attachments", h.ListAttachments)
+				r.Get("/attachments/{aid}", h.GetAttachment)
+				r.Delete("/attachments/{aid}", h.DeleteAttachment)
+				r.Post("/uploads", h.StartUpload)
+			})
+		})
+
+		r.Get("/search", h.SearchNotes)
+		r.Get("/search/validate", h.ValidateSearchQuery)
+		r.Get("/search/typeahead", h.Typeahead)
+		r.Post("/clip", h.ClipPage)
+		r.Get("/lifecycle", h.GetLifecycle)
+
+		r.Route("/emoji", func(r chi.Router) {
+			r.Get("/", h.ListEmoji)
+			r.Put("/{name}", h.PutEmoji)
+			r.Delete("/{name}", h.DeleteEmoji)
+		})
+
+		r.Route("/note-templates", func(r chi.Router) {
+			r.Post("/", h.CreateNoteTemplate)
+			r.Get("/", h.ListNoteTemplates)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetNoteTemplate)
+				r.Put("/", h.PutNoteTemplate)
+				r.Delete("/", h.DeleteNoteTemplate)
+				r.Post("/notes", h.CreateNoteFromTemplate)
+			})
+		})
+
+		r.Route("/review", func(r chi.Router) {
+			r.Get("/next", h.NextReview)
+			r.Post("/{id}/done", h.ReviewDone)
+		})
+
+		r.Route("/change-requests", func(r chi.Router) {
+			r.Get("/", h.ListChangeRequests)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetChangeRequest)
+				r.Get("/diff", h.GetChangeDiff)
+				r.Post("/approve", h.ApproveChangeRequest)
+				r.Post("/reject", h.RejectChangeRequest)
+			})
+		})
+
+		r.Route("/rules", func(r chi.Router) {
+			r.Post("/", h.CreateRule)
+			r.Get("/", h.ListRules)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetRule)
+				r.Put("/", h.PutRule)
+				r.Delete("/", h.DeleteRule)
+			})
+		})
+
+		r.Route("/inbound-hooks", func(r chi.Router) {
+			r.Post("/", h.CreateInboundHook)
+			r.Get("/", h.ListInboundHooks)
+			r.Delete("/{id}", h.DeleteInboundHook)
+		})
+
+		r.Route("/views", func(r chi.Router) {
+			r.Post("/", h.CreateSavedView)
+			r.Get("/", h.ListSavedViews)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetSavedView)
+				r.Put("/", h.PutSavedView)
+				r.Delete("/", h.DeleteSavedView)
+				r.Get("/notes", h.SavedViewNotes)
+			})
+		})
+
+		r.Route("/notebooks", func(r chi.Router) {
+			r.Post("/", h.CreateNotebook)
+			r.Get("/", h.ListNotebooks)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetNotebook)
+				r.Patch("/", h.PatchNotebook)
+				r.Delete("/", h.DeleteNotebook)
+				r.Put("/shares", h.ShareNotebook)
+				r.Delete("/shares/{grantee}", h.UnshareNotebook)
+				r.Get("/settings", h.GetNotebookSettings)
+				r.Put("/settings", h.PutNotebookSettings)
+			})
+		})
 
+		r.Route("/collections", func(r chi.Router) {
+			r.Post("/", h.CreateCollection)
+			r.Get("/", h.ListCollections)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetCollection)
+				r.Patch("/", h.PatchCollection)
+				r.Delete("/", h.DeleteCollection)
+				r.Get("/notes", h.ListCollectionNotes)
 			})
 		})
+
+		r.Get("/boards/{property}", h.GetBoard)
+		r.Patch("/boards/{property}/cards/{id}", h.MoveCard)
+
+		r.Get("/me/preferences", h.GetPreferences)
+		r.Patch("/me/preferences", h.PatchPreferences)
+
+		r.Get("/notifications", h.ListNotifications)
+		r.Post("/notifications/read", h.MarkNotificationsRead)
+
+		r.Get("/dashboard", h.GetDashboard)
+		r.Get("/stats/activity", h.ActivityStats)
+		r.Get("/stats/storage", h.StorageStats)
+		r.Get("/events", h.StreamEvents)
+
+		r.Route("/tags", func(r chi.Router) {
+			r.Get("/", h.ListTags)
+			r.Get("/tree", h.TagTree)
+			r.Post("/merge", h.MergeTags)
+			r.Patch("/{name}", h.RenameTag)
+			r.Post("/{name}/apply", h.ApplyTag)
+			r.Post("/{name}/remove", h.RemoveTag)
+		})
+
+		r.Route("/journal", func(r chi.Router) {
+			r.Get("/", h.ListJournal)
+			r.Get("/{date}", h.GetJournal)
+			r.Post("/{date}", h.CreateJournal)
+		})
+
+		r.Route("/zapier", func(r chi.Router) {
+			r.Get("/me", h.ZapierMe)
+			r.Get("/triggers/new_note", h.ZapierNewNotes)
+			r.Get("/triggers/new_tagged_note", h.ZapierNewTaggedNotes)
+			r.Post("/actions/create_note", h.ZapierCreateNote)
+			r.Post("/actions/append_to_note", h.ZapierAppendToNote)
+		})
+
+		r.Get("/sync/status", h.SyncStatus)
+		r.Post("/sync/run", h.RunSync)
+
+		// The synchronous export holds the request open while the archive
+		// is built, which large accounts run into timeouts with.
+		r.With(deprecated(opts.Metrics, Deprecation{
+			Since:     time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+			Sunset:    time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC),
+			Successor: "/api/v1/export?format=vault",
+		})).Get("/export/vault", h.ExportVault)
+		r.Post("/import/vault", h.ImportVault)
+		r.Post("/import/jobs", h.CreateImportJob)
+		r.Post("/export", h.CreateExportJob)
+		r.Get("/jobs/{id}", h.GetJob)
+		r.Get("/jobs/{id}/result", h.GetJobResult)
+
+		r.Post("/undo/{token}", h.UndoOperation)
+		r.Get("/debug/echo", h.DebugEcho)
+		r.Get("/errors", h.ListErrorCodes)
+
+		r.Get("/uploads/{id}", h.GetUpload)
+		r.Patch("/uploads/{id}", h.WriteUpload)
+		r.Delete("/uploads/{id}", h.CancelUpload)
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Get("/cdc", h.StreamCDC)
+			r.Get("/stats", h.InstanceStats)
+			r.Post("/search/reindex", h.ReindexSearch)
+			r.Get("/search/reindex", h.ReindexStatus)
+			r.Get("/audit", h.ListAudit)
+			r.Post("/attachments/{id}/release", h.ReleaseAttachment)
+			r.Get("/print-templates", h.ListPrintTemplates)
+			r.Put("/print-templates/{name}", h.PutPrintTemplate)
+			r.Delete("/print-templates/{name}", h.DeletePrintTemplate)
+		})
 	})
 
+	// Public links need no token: the link itself is the credential.
+	r.Get(handlers.SharePath+"{token}", h.SharedNote)
+	// Inbound webhooks are authorized by the token in their URL, which
+	// the systems posting to them cannot add headers to.
+	r.With(envelope).Post("/api/v1/inbound/{token}", h.ReceiveInbound)
+	// Export downloads are authorized by their signed URL.
+	r.Get(handlers.DownloadPath+"*", h.Download)
+
+	// The UI is public; it asks for a token when the API wants one.
+	r.Get("/app", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/app/", http.StatusMovedPermanently)
+	})
+	r.Handle("/app/*", http.StripPrefix("/app", webui.Handler()))
+
+	r.Route("/dav", func(r chi.Router) {
+		r.Use(authenticate(opts.Tokens, "/dav"))
+		if opts.RateLimiter != nil {
+			r.Use(rateLimit(opts.RateLimiter, opts.RateLimits))
+		}
+
+		dav := h.WebDAV("/dav")
+		r.Handle("/", dav)
+		r.Handle("/*", dav)
+	})
+
+	if opts.Metrics != nil {
+		r.Method(http.MethodGet, "/metrics", opts.Metrics)
+	}
+
+	if opts.Ready != nil {
+		r.Method(http.MethodGet, "/readyz", opts.Ready)
+	}
+
+	// The API description is public, like the UI.
+	r.Get("/openapi.json", serveOpenAPI)
+
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,266 @@
+// Package importer reads notes exported by other applications into the
+// same shape as an Obsidian vault, so that every format is imported the
+// same way.
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/vault"
+)
+
+var ErrInvalidFile = errors.New("invalid import file")
+
+type enexNote struct {
+	Title     string   `xml:"title"`
+	Content   string   `xml:"content"`
+	Tags      []string `xml:"tag"`
+	Resources []struct {
+		Mime     string `xml:"mime"`
+		FileName string `xml:"resource-attributes>file-name"`
+	} `xml:"resource"`
+}
+
+// ENEX reads an Evernote export. Note bodies are converted from ENML to
+// Markdown; attachments have no counterpart and are listed in Skipped.
+func ENEX(r io.Reader) (*vault.Vault, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	v := &vault.Vault{}
+	root := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !root {
+			if start.Name.Local != "en-export" {
+				return nil, fmt.Errorf("%w: not an Evernote export", ErrInvalidFile)
+			}
+			root = true
+			continue
+		}
+		if start.Name.Local != "note" {
+			if err := d.Skip(); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+			}
+			continue
+		}
+
+		var n enexNote
+		if err := d.DecodeElement(&n, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		title := strings.TrimSpace(n.Title)
+		if title == "" {
+			title = "Untitled"
+		}
+		for i, res := range n.Resources {
+			name := res.FileName
+			if name == "" {
+				name = fmt.Sprintf("attachment %d (%s)", i+1, res.Mime)
+			}
+			v.Skipped = append(v.Skipped, title+"/"+name)
+		}
+		v.Entries = append(v.Entries, vault.Entry{Note: core.Note{
+			Title:   title,
+			Content: enml(n.Content),
+			Tags:    core.NormalizeTags(n.Tags),
+		}})
+	}
+	if !root {
+		return nil, fmt.Errorf("%w: not an Evernote export", ErrInvalidFile)
+	}
+	return v, nil
+}
+
+var (
+	spaces   = regexp.MustCompile(`[ \t\r\n\x{a0}]+`)
+	newlines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// enml converts an ENML note body, a restricted XHTML, to Markdown. Only
+// structure that Markdown can hold is kept; other markup is dropped.
+func enml(src string) string {
+	d := xml.NewDecoder(strings.NewReader(src))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var b strings.Builder
+	var links []string
+	lineStart := true
+	newline := func() {
+		if !lineStart {
+			b.WriteString("\n")
+			lineStart = true
+		}
+	}
+	write := func(s string) {
+		b.WriteString(s)
+		lineStart = false
+	}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch name := t.Name.Local; name {
+			case "div", "p", "ul", "ol", "table", "tr", "blockquote":
+				newline()
+			case "br":
+				b.WriteString("\n")
+				lineStart = true
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				newline()
+				b.WriteString("\n")
+				write(strings.Repeat("#", int(name[1]-'0')) + " ")
+			case "li":
+				newline()
+				write("- ")
+			case "en-todo":
+				newline()
+				if attr(t, "checked") == "true" {
+					write("- [x] ")
+				} else {
+					write("- [ ] ")
+				}
+			case "a":
+				links = append(links, attr(t, "href"))
+				write("[")
+			case "hr":
+				newline()
+				write("---")
+				newline()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "div", "p", "li", "h1", "h2", "h3", "h4", "h5", "h6", "tr", "blockquote":
+				newline()
+			case "a":
+				if len(links) > 0 {
+					write("](" + links[len(links)-1] + ")")
+					links = links[:len(links)-1]
+				}
+			}
+		case xml.CharData:
+			text := spaces.ReplaceAllString(string(t), " ")
+			if lineStart {
+				text = strings.TrimLeft(text, " ")
+			}
+			if text != "" {
+				write(text)
+			}
+		}
+	}
+	return strings.TrimSpace(newlines.ReplaceAllString(b.String(), "\n\n"))
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// CSV reads a table with a header row. The title column is required;
+// content (or body), tags and notebook (or folder) are optional, and other
+// columns are ignored. Tags are separated by commas or semicolons, and
+// notebooks are paths like Work/Projects. Rows without a title or content
+// are listed in Skipped by line.
+func CSV(r io.Reader) (*vault.Vault, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "body":
+			name = "content"
+		case "folder":
+			name = "notebook"
+		}
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: no title column", ErrInvalidFile)
+	}
+
+	v := &vault.Vault{}
+	seen := make(map[string]bool)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		title, content := field("title"), field("content")
+		if title == "" && content == "" {
+			line, _ := cr.FieldPos(0)
+			v.Skipped = append(v.Skipped, fmt.Sprintf("line %d", line))
+			continue
+		}
+		if title == "" {
+			title = "Untitled"
+		}
+
+		var folder []string
+		for _, name := range strings.Split(field("notebook"), "/") {
+			if name = strings.TrimSpace(name); name != "" {
+				folder = append(folder, name)
+			}
+		}
+		for i := range folder {
+			if key := strings.Join(folder[:i+1], "/"); !seen[key] {
+				seen[key] = true
+				v.Folders = append(v.Folders, folder[:i+1])
+			}
+		}
+
+		tags := strings.FieldsFunc(field("tags"), func(r rune) bool { return r == ',' || r == ';' })
+		v.Entries = append(v.Entries, vault.Entry{Folder: folder, Note: core.Note{
+			Title:   title,
+			Content: content,
+			Tags:    core.NormalizeTags(tags),
+		}})
+	}
+	return v, nil
+}
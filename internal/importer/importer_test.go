@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const enex = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20250106T120000Z" application="Evernote">
+  <note>
+    <title>Покупки</title>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><h2>На неделю</h2><div><en-todo checked="true"/>молоко</div><div><en-todo/>хлеб&nbsp;и  сыр</div>
+<div><br/></div><ul><li>см. <a href="https://example.com">список</a></li></ul><en-media hash="abc" type="image/png"/></en-note>]]></content>
+    <tag>Дом</tag>
+    <tag>shopping</tag>
+    <resource>
+      <data encoding="base64">iVBORw0KGgo=</data>
+      <mime>image/png</mime>
+      <resource-attributes><file-name>чек.png</file-name></resource-attributes>
+    </resource>
+  </note>
+  <note><title></title><content><![CDATA[<en-note>без заголовка</en-note>]]></content></note>
+</en-export>`
+
+func TestENEX(t *testing.T) {
+	v, err := ENEX(strings.NewReader(enex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Entries) != 2 {
+		t.Fatalf("%d notes", len(v.Entries))
+	}
+	n := v.Entries[0].Note
+	want := "## На неделю\n- [x] молоко\n- [ ] хлеб и сыр\n\n- см. [список](https://example.com)"
+	if n.Title != "Покупки" || n.Content != want {
+		t.Errorf("note = %q\n%q", n.Title, n.Content)
+	}
+	if !reflect.DeepEqual(n.Tags, []string{"дом", "shopping"}) {
+		t.Errorf("tags = %q", n.Tags)
+	}
+	if !reflect.DeepEqual(v.Skipped, []string{"Покупки/чек.png"}) {
+		t.Errorf("skipped = %q", v.Skipped)
+	}
+	if n := v.Entries[1].Note; n.Title != "Untitled" || n.Content != "без заголовка" {
+		t.Errorf("second note = %+v", n)
+	}
+
+	if _, err := ENEX(strings.NewReader(`<html></html>`)); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("HTML: err = %v", err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	v, err := CSV(strings.NewReader("\ufeffTitle,Body,Tags,Folder,Extra\n" +
+		"План,\"строка 1\nстрока 2\",\"work; q1\",Работа/Проекты,x\n" +
+		",,,,\n" +
+		"Короткая,,,Работа\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Folders, [][]string{{"Работа"}, {"Работа", "Проекты"}}) {
+		t.Errorf("folders = %q", v.Folders)
+	}
+	if len(v.Entries) != 2 {
+		t.Fatalf("%d notes", len(v.Entries))
+	}
+	e := v.Entries[0]
+	if e.Note.Content != "строка 1\nстрока 2" || !reflect.DeepEqual(e.Note.Tags, []string{"work", "q1"}) || len(e.Folder) != 2 {
+		t.Errorf("first entry = %+v", e)
+	}
+	if !reflect.DeepEqual(v.Skipped, []string{"line 4"}) {
+		t.Errorf("skipped = %q", v.Skipped)
+	}
+
+	if _, err := CSV(strings.NewReader("name,content\nx,y\n")); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("no title column: err = %v", err)
+	}
+}
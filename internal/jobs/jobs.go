@@ -0,0 +1,223 @@
+// Package jobs runs long operations, such as imports, in the background so
+// that requests return at once and clients poll for progress.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+)
+
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+var ErrQueueFull = errors.New("job queue is full")
+
+// MaxErrors bounds the errors a job keeps; the count goes on in ErrorCount.
+const MaxErrors = 100
+
+// Job reports the progress of a background operation.
+type Job struct {
+	ID         int64      `json:"id" example:"1"`
+	Kind       string     `json:"kind" example:"import"`
+	State      string     `json:"state" example:"running" enums:"queued,running,done,failed"`
+	Total      int        `json:"total" example:"120"`
+	Done       int        `json:"done" example:"30"`
+	Errors     []string   `json:"errors"`
+	ErrorCount int        `json:"error_count" example:"0"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// OwnerID is the user who started the job; only they can see it.
+	OwnerID string `json:"-"`
+	// HasResult tells whether Result returns a file for the job.
+	HasResult bool `json:"-"`
+}
+
+// Result is a file a job produces, such as a report or an archive. Files
+// stored elsewhere leave Data empty and give their URL.
+type Result struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	URL         string
+}
+
+// Func does the work of a job, reporting progress through p. A returned
+// error fails the job; errors about single items go to p.Error instead.
+type Func func(ctx context.Context, p *Progress) (*Result, error)
+
+// Queue runs jobs on a fixed number of workers in submission order.
+// Finished jobs are forgotten after Retention.
+type Queue struct {
+	Clock     clock.Clock
+	Retention time.Duration
+	// OnFinish, when set, is called with every job that finishes.
+	OnFinish func(Job)
+
+	mu      sync.Mutex
+	next    int64
+	jobs    map[int64]*entry
+	pending chan *entry
+	workers int
+}
+
+type entry struct {
+	job    Job
+	fn     Func
+	result *Result
+}
+
+func NewQueue(workers int) *Queue {
+	return &Queue{
+		Clock:     clock.System{},
+		Retention: 24 * time.Hour,
+		jobs:      make(map[int64]*entry),
+		pending:   make(chan *entry, 1024),
+		workers:   workers,
+	}
+}
+
+// Run starts the workers. They stop when ctx is done.
+func (q *Queue) Run(ctx context.Context) {
+	for i := 0; i < q.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-q.pending:
+					q.run(ctx, e)
+				}
+			}
+		}()
+	}
+}
+
+// Submit queues fn as a job of the given kind owned by ownerID.
+func (q *Queue) Submit(kind, ownerID string, fn Func) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.Clock.Now()
+	for id, e := range q.jobs {
+		if e.job.FinishedAt != nil && now.Sub(*e.job.FinishedAt) > q.Retention {
+			delete(q.jobs, id)
+		}
+	}
+
+	e := &entry{
+		job: Job{ID: q.next + 1, Kind: kind, State: StateQueued, Errors: []string{}, CreatedAt: now, OwnerID: ownerID},
+		fn:  fn,
+	}
+	select {
+	case q.pending <- e:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	q.next++
+	q.jobs[e.job.ID] = e
+	return e.snapshot(), nil
+}
+
+// Get returns the job with id.
+func (q *Queue) Get(id int64) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return e.snapshot(), true
+}
+
+// Result returns the file of a finished job, or nil.
+func (q *Queue) Result(id int64) *Result {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if e, ok := q.jobs[id]; ok {
+		return e.result
+	}
+	return nil
+}
+
+func (e *entry) snapshot() Job {
+	job := e.job
+	job.Errors = append([]string{}, e.job.Errors...)
+	return job
+}
+
+func (q *Queue) run(ctx context.Context, e *entry) {
+	q.mu.Lock()
+	now := q.Clock.Now()
+	e.job.State = StateRunning
+	e.job.StartedAt = &now
+	q.mu.Unlock()
+
+	result, err := e.fn(ctx, &Progress{q: q, e: e})
+
+	q.mu.Lock()
+	now = q.Clock.Now()
+	e.job.FinishedAt = &now
+	e.job.State = StateDone
+	if err != nil {
+		e.job.State = StateFailed
+		e.addError(err.Error())
+	}
+	if result != nil {
+		e.result = result
+		e.job.HasResult = true
+	}
+	job := e.snapshot()
+	q.mu.Unlock()
+
+	if q.OnFinish != nil {
+		q.OnFinish(job)
+	}
+}
+
+func (e *entry) addError(msg string) {
+	e.job.ErrorCount++
+	if len(e.job.Errors) < MaxErrors {
+		e.job.Errors = append(e.job.Errors, msg)
+	}
+}
+
+// Progress lets a running job report how far it got.
+type Progress struct {
+	q *Queue
+	e *entry
+}
+
+// SetTotal sets the number of steps the job will take.
+func (p *Progress) SetTotal(n int) {
+	p.q.mu.Lock()
+	defer p.q.mu.Unlock()
+
+	p.e.job.Total = n
+}
+
+// Step records one step done.
+func (p *Progress) Step() {
+	p.q.mu.Lock()
+	defer p.q.mu.Unlock()
+
+	p.e.job.Done++
+}
+
+// Error records an error that does not stop the job.
+func (p *Progress) Error(msg string) {
+	p.q.mu.Lock()
+	defer p.q.mu.Unlock()
+
+	p.e.addError(msg)
+}
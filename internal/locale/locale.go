@@ -0,0 +1,158 @@
+// Package locale formats dates for display in the reader's language, for
+// clients too thin to do it themselves.
+package locale
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Styles of display dates.
+const (
+	Short  = "short"
+	Medium = "medium"
+	Long   = "long"
+)
+
+// Default is the language used when none of the requested ones is known.
+const Default = "en"
+
+var ErrUnknownStyle = errors.New("date_format must be short, medium or long")
+
+// language holds what it takes to write dates in one language. Layouts
+// are Go time layouts in which "Jan" and "January" stand for the
+// abbreviated and full month names, which are substituted afterwards.
+type language struct {
+	short, medium, long string
+	months, abbr        [12]string
+}
+
+var languages = map[string]language{
+	"en": {
+		short:  "01/02/2006",
+		medium: "Jan 2, 2006, 3:04 PM",
+		long:   "January 2, 2006 at 3:04 PM MST",
+		months: [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		abbr:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	},
+	"ru": {
+		short:  "02.01.2006",
+		medium: "2 Jan 2006 г., 15:04",
+		long:   "2 January 2006 г. в 15:04 MST",
+		months: [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		abbr:   [12]string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.", "окт.", "нояб.", "дек."},
+	},
+	"de": {
+		short:  "02.01.2006",
+		medium: "02.01.2006, 15:04",
+		long:   "2. January 2006 um 15:04 MST",
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		abbr:   [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+	},
+	"fr": {
+		short:  "02/01/2006",
+		medium: "2 Jan 2006, 15:04",
+		long:   "2 January 2006 à 15:04 MST",
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		abbr:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+	},
+	"es": {
+		short:  "02/01/2006",
+		medium: "2 Jan 2006, 15:04",
+		long:   "2 de January de 2006, 15:04 MST",
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		abbr:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	},
+}
+
+// Supported reports whether lang, such as "ru" or "ru-RU", is a known
+// language.
+func Supported(lang string) bool {
+	_, ok := languages[base(lang)]
+	return ok
+}
+
+// Match picks the known language the Accept-Language header prefers,
+// falling back to fallback and then to Default.
+func Match(acceptLanguage, fallback string) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if Supported(tag) && q > 0 {
+			choices = append(choices, choice{base(tag), q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	if len(choices) > 0 {
+		return choices[0].lang
+	}
+	if Supported(fallback) {
+		return base(fallback)
+	}
+	return Default
+}
+
+func base(tag string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return lang
+}
+
+// Formatter writes dates in one language, style and time zone.
+type Formatter struct {
+	Lang     string
+	Style    string
+	Location *time.Location
+}
+
+// NewFormatter returns a formatter for lang, falling back to Default, in
+// style, Medium when empty.
+func NewFormatter(lang, style string, loc *time.Location) (Formatter, error) {
+	switch style {
+	case "":
+		style = Medium
+	case Short, Medium, Long:
+	default:
+		return Formatter{}, ErrUnknownStyle
+	}
+	if !Supported(lang) {
+		lang = Default
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return Formatter{Lang: base(lang), Style: style, Location: loc}, nil
+}
+
+func (f Formatter) Format(t time.Time) string {
+	l := languages[f.Lang]
+	t = t.In(f.Location)
+	layout := l.medium
+	switch f.Style {
+	case Short:
+		layout = l.short
+	case Long:
+		layout = l.long
+	}
+	// Month names are swapped for placeholders that time.Format leaves
+	// alone, so that translations cannot be read as layout elements.
+	layout = strings.Replace(layout, "January", "\x01", 1)
+	layout = strings.Replace(layout, "Jan", "\x02", 1)
+	s := t.Format(layout)
+	s = strings.Replace(s, "\x01", l.months[t.Month()-1], 1)
+	return strings.Replace(s, "\x02", l.abbr[t.Month()-1], 1)
+}
@@ -0,0 +1,47 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct{ accept, fallback, want string }{
+		{"", "", "en"},
+		{"", "ru-RU", "ru"},
+		{"de-DE,de;q=0.9,en;q=0.8", "ru", "de"},
+		{"ja, fr;q=0.5, es;q=0.7", "", "es"},
+		{"ja", "xx", "en"},
+		{"ru;q=0", "fr", "fr"},
+	} {
+		if got := Match(tt.accept, tt.fallback); got != tt.want {
+			t.Errorf("Match(%q, %q) = %q, want %q", tt.accept, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	at := time.Date(2024, time.March, 5, 14, 7, 0, 0, time.UTC)
+	for _, tt := range []struct{ lang, style, want string }{
+		{"en", Short, "03/05/2024"},
+		{"en", Medium, "Mar 5, 2024, 5:07 PM"},
+		{"en", Long, "March 5, 2024 at 5:07 PM MSK"},
+		{"ru", Medium, "5 мар. 2024 г., 17:07"},
+		{"ru", Long, "5 марта 2024 г. в 17:07 MSK"},
+		{"de", Long, "5. März 2024 um 17:07 MSK"},
+		{"fr", Medium, "5 mars 2024, 17:07"},
+		{"es", Long, "5 de marzo de 2024, 17:07 MSK"},
+	} {
+		f, err := NewFormatter(tt.lang, tt.style, moscow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Format(at); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.lang, tt.style, got, tt.want)
+		}
+	}
+	if _, err := NewFormatter("en", "full", nil); err != ErrUnknownStyle {
+		t.Errorf("err = %v", err)
+	}
+}
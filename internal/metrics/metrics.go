@@ -0,0 +1,339 @@
+// Package metrics records HTTP request metrics and serves them in the
+// Prometheus text format. Labels are limited to route, method and
+// status_class so that series stay few and alert rules stay valid across
+// releases.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+)
+
+// DefaultBuckets are the request duration buckets, in seconds. New adds
+// the latency threshold when it is not one of them, so error budgets can
+// be computed from the histogram alone.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// DailyRetention is how many days of request counts Summary can report.
+const DailyRetention = 90
+
+// Unmatched is the route label of requests that matched no route, so that
+// scanners cannot blow up the number of series.
+const Unmatched = "unmatched"
+
+type key struct {
+	route  string
+	method string
+}
+
+type series struct {
+	statuses      map[string]uint64
+	buckets       []uint64
+	sum           float64
+	count         uint64
+	slowRequests  uint64
+	errorRequests uint64
+}
+
+// Registry holds request metrics. A request counts against the error
+// budget when it fails with a 5xx status or takes longer than Latency.
+type Registry struct {
+	Latency   time.Duration
+	Objective float64
+	// Clock dates the daily request counts.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	buckets []float64
+	series  map[key]*series
+	funcs   []metricFunc
+	// daily and errors back Summary; they are not exported, as per-day
+	// and per-code series are what the labels deliberately avoid.
+	daily  map[string]uint64
+	errors map[int]uint64
+	// deprecated counts calls to deprecated routes, to tell when one is
+	// no longer used and can go.
+	deprecated map[key]uint64
+}
+
+type metricFunc struct {
+	name, help, typ string
+	fn              func() float64
+}
+
+func New(latency time.Duration, objective float64) *Registry {
+	buckets := append([]float64(nil), DefaultBuckets...)
+	threshold := latency.Seconds()
+	found := false
+	for _, b := range buckets {
+		if b == threshold {
+			found = true
+		}
+	}
+	if !found {
+		buckets = append(buckets, threshold)
+		sort.Float64s(buckets)
+	}
+
+	return &Registry{
+		Latency:   latency,
+		Objective: objective,
+		Clock:     clock.System{},
+		buckets:   buckets,
+		series:    make(map[key]*series),
+		daily:     make(map[string]uint64),
+		errors:    make(map[int]uint64),
+
+		deprecated: make(map[key]uint64),
+	}
+}
+
+// CounterFunc exports a counter whose value is read from fn at scrape time.
+// name may carry labels, as in backend_up{backend="redis"}; series of one
+// metric must be registered one after another.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.addFunc(metricFunc{name: name, help: help, typ: "counter", fn: fn})
+}
+
+// GaugeFunc exports a gauge whose value is read from fn at scrape time.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.addFunc(metricFunc{name: name, help: help, typ: "gauge", fn: fn})
+}
+
+func (r *Registry) addFunc(f metricFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.funcs = append(r.funcs, f)
+}
+
+func (r *Registry) Observe(route, method string, status int, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key{route: route, method: method}
+	s, ok := r.series[k]
+	if !ok {
+		s = &series{statuses: make(map[string]uint64), buckets: make([]uint64, len(r.buckets))}
+		r.series[k] = s
+	}
+
+	s.statuses[StatusClass(status)]++
+	seconds := d.Seconds()
+	for i, b := range r.buckets {
+		if seconds <= b {
+			s.buckets[i]++
+		}
+	}
+	s.sum += seconds
+	s.count++
+
+	if status >= 500 {
+		s.errorRequests++
+	}
+	if d > r.Latency {
+		s.slowRequests++
+	}
+
+	day := r.Clock.Now().Format(time.DateOnly)
+	if _, ok := r.daily[day]; !ok {
+		cutoff := r.Clock.Now().AddDate(0, 0, -DailyRetention).Format(time.DateOnly)
+		for d := range r.daily {
+			if d <= cutoff {
+				delete(r.daily, d)
+			}
+		}
+	}
+	r.daily[day]++
+	if status >= 400 {
+		r.errors[status]++
+	}
+}
+
+type DayCount struct {
+	Date     string `json:"date" example:"2025-01-31"`
+	Requests uint64 `json:"requests"`
+}
+
+type StatusCount struct {
+	Status   int    `json:"status" example:"404"`
+	Requests uint64 `json:"requests"`
+}
+
+// Summary is an overview of the traffic since the process started.
+type Summary struct {
+	// Days holds the last days, oldest first, in UTC.
+	Days []DayCount
+	// Errors holds the most frequent 4xx and 5xx statuses, most
+	// frequent first.
+	Errors []StatusCount
+}
+
+// Summary reports request counts for the last days (at most
+// DailyRetention) and the top error statuses.
+func (r *Registry) Summary(days, top int) Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var s Summary
+	now := r.Clock.Now()
+	for i := min(days, DailyRetention) - 1; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i).Format(time.DateOnly)
+		s.Days = append(s.Days, DayCount{Date: day, Requests: r.daily[day]})
+	}
+
+	for status, n := range r.errors {
+		s.Errors = append(s.Errors, StatusCount{Status: status, Requests: n})
+	}
+	sort.Slice(s.Errors, func(i, j int) bool {
+		if s.Errors[i].Requests != s.Errors[j].Requests {
+			return s.Errors[i].Requests > s.Errors[j].Requests
+		}
+		return s.Errors[i].Status < s.Errors[j].Status
+	})
+	if len(s.Errors) > top {
+		s.Errors = s.Errors[:top]
+	}
+	return s
+}
+
+// Deprecated counts a call to a deprecated route.
+func (r *Registry) Deprecated(route, method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deprecated[key{route: route, method: method}]++
+}
+
+// StatusClass maps a status code to its class label, such as "2xx".
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]key, 0, len(r.series))
+	for k := range r.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	cw := &countingWriter{w: w}
+
+	cw.printf("# HELP http_requests_total Requests served, by route, method and status class.\n")
+	cw.printf("# TYPE http_requests_total counter\n")
+	for _, k := range keys {
+		s := r.series[k]
+		classes := make([]string, 0, len(s.statuses))
+		for c := range s.statuses {
+			classes = append(classes, c)
+		}
+		sort.Strings(classes)
+		for _, c := range classes {
+			cw.printf("http_requests_total{%s,status_class=%q} %d\n", labels(k), c, s.statuses[c])
+		}
+	}
+
+	cw.printf("# HELP http_request_duration_seconds Request latency, by route and method.\n")
+	cw.printf("# TYPE http_request_duration_seconds histogram\n")
+	for _, k := range keys {
+		s := r.series[k]
+		for i, b := range r.buckets {
+			cw.printf("http_request_duration_seconds_bucket{%s,le=%q} %d\n", labels(k), formatFloat(b), s.buckets[i])
+		}
+		cw.printf("http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels(k), s.count)
+		cw.printf("http_request_duration_seconds_sum{%s} %s\n", labels(k), formatFloat(s.sum))
+		cw.printf("http_request_duration_seconds_count{%s} %d\n", labels(k), s.count)
+	}
+
+	cw.printf("# HELP http_slo_errors_total Requests that used up error budget, by route, method and reason.\n")
+	cw.printf("# TYPE http_slo_errors_total counter\n")
+	for _, k := range keys {
+		s := r.series[k]
+		cw.printf("http_slo_errors_total{%s,reason=\"error\"} %d\n", labels(k), s.errorRequests)
+		cw.printf("http_slo_errors_total{%s,reason=\"latency\"} %d\n", labels(k), s.slowRequests)
+	}
+
+	cw.printf("# HELP http_slo_latency_threshold_seconds Latency above which a request counts against the error budget.\n")
+	cw.printf("# TYPE http_slo_latency_threshold_seconds gauge\n")
+	cw.printf("http_slo_latency_threshold_seconds %s\n", formatFloat(r.Latency.Seconds()))
+	cw.printf("# HELP http_slo_objective Target ratio of good requests.\n")
+	cw.printf("# TYPE http_slo_objective gauge\n")
+	cw.printf("http_slo_objective %s\n", formatFloat(r.Objective))
+
+	deprecated := make([]key, 0, len(r.deprecated))
+	for k := range r.deprecated {
+		deprecated = append(deprecated, k)
+	}
+	sort.Slice(deprecated, func(i, j int) bool {
+		if deprecated[i].route != deprecated[j].route {
+			return deprecated[i].route < deprecated[j].route
+		}
+		return deprecated[i].method < deprecated[j].method
+	})
+	cw.printf("# HELP http_deprecated_requests_total Requests to deprecated routes, by route and method.\n")
+	cw.printf("# TYPE http_deprecated_requests_total counter\n")
+	for _, k := range deprecated {
+		cw.printf("http_deprecated_requests_total{%s} %d\n", labels(k), r.deprecated[k])
+	}
+
+	last := ""
+	for _, f := range r.funcs {
+		base, _, _ := strings.Cut(f.name, "{")
+		if base != last {
+			cw.printf("# HELP %s %s\n", base, f.help)
+			cw.printf("# TYPE %s %s\n", base, f.typ)
+			last = base
+		}
+		cw.printf("%s %s\n", f.name, formatFloat(f.fn()))
+	}
+
+	return cw.n, cw.err
+}
+
+func labels(k key) string {
+	return fmt.Sprintf("route=%q,method=%q", k.route, k.method)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
@@ -0,0 +1,195 @@
+package nats_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/nats"
+	"example.com/notes-api/internal/testutil"
+)
+
+// handshake plays the server side of a connection: INFO, then the
+// client's CONNECT and PING. It returns the CONNECT options, or nil when
+// the client sent something else.
+func handshake(c *testutil.PeerConn) map[string]interface{} {
+	c.Send(`INFO {"server_id":"test","version":"2.10.0","max_payload":1048576}`)
+	connect, ok := strings.CutPrefix(c.Line(), "CONNECT ")
+	if !ok {
+		return nil
+	}
+	var opts map[string]interface{}
+	if json.Unmarshal([]byte(connect), &opts) != nil || !c.Expect("PING") {
+		return nil
+	}
+	c.Send("PONG")
+	return opts
+}
+
+func TestConnect(t *testing.T) {
+	for _, tc := range []struct {
+		userinfo string
+		want     map[string]interface{}
+	}{
+		{"", map[string]interface{}{}},
+		{"notes:s3cret@", map[string]interface{}{"user": "notes", "pass": "s3cret"}},
+		{"t0ken@", map[string]interface{}{"auth_token": "t0ken"}},
+	} {
+		got := make(chan map[string]interface{}, 1)
+		peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+			got <- handshake(c)
+			c.Expect("PING")
+		})
+		client := nats.New("nats://" + tc.userinfo + peer.Addr)
+		if err := client.Ping(context.Background()); err != nil {
+			t.Errorf("%q: Ping: %v", tc.userinfo, err)
+			continue
+		}
+		opts := <-got
+		for key, want := range map[string]interface{}{"verbose": false, "pedantic": false, "name": "notes-api", "lang": "go"} {
+			if opts[key] != want {
+				t.Errorf("%q: CONNECT %s = %v, want %v", tc.userinfo, key, opts[key], want)
+			}
+		}
+		creds := map[string]interface{}{}
+		for _, key := range []string{"user", "pass", "auth_token"} {
+			if v, ok := opts[key]; ok {
+				creds[key] = v
+			}
+		}
+		if !reflect.DeepEqual(creds, tc.want) {
+			t.Errorf("%q: credentials = %v, want %v", tc.userinfo, creds, tc.want)
+		}
+		if s := client.String(); strings.Contains(s, "s3cret") || strings.Contains(s, "t0ken") {
+			t.Errorf("String() = %q shows credentials", s)
+		}
+	}
+}
+
+func TestConnectErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		serve func(c *testutil.PeerConn)
+		err   string
+	}{
+		{"no INFO", func(c *testutil.PeerConn) { c.Send("+OK") }, nats.ErrMalformed.Error()},
+		{"refused", func(c *testutil.PeerConn) {
+			c.Send(`INFO {}`)
+			c.Line()
+			c.Send("-ERR 'Authorization Violation'")
+		}, "nats: 'Authorization Violation'"},
+		{"no PONG", func(c *testutil.PeerConn) {
+			c.Send(`INFO {}`)
+			c.Line()
+			c.Send("+OK")
+		}, nats.ErrMalformed.Error()},
+		{"closed", func(c *testutil.PeerConn) {}, "EOF"},
+	} {
+		peer := testutil.Listen(t, tc.serve)
+		err := nats.New("nats://"+peer.Addr).Publish(context.Background(), "notes", []byte("x"))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%s: Publish = %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	type pub struct {
+		line, payload string
+	}
+	pubs := make(chan pub, 4)
+	pongs := make(chan bool, 1)
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+		if handshake(c) == nil {
+			return
+		}
+		line := c.Line()
+		pubs <- pub{line, string(c.Bytes(len("a\r\nb\r\n")))}
+		// Verbose acknowledgements are ignored, server pings answered.
+		c.Send("+OK", "PING")
+		pongs <- c.Line() == "PONG"
+		// Then the server goes away.
+		c.Send("-ERR 'Stale Connection'")
+	})
+	client := nats.New("nats://" + peer.Addr)
+	ctx := context.Background()
+
+	if err := client.Publish(ctx, "notes.note.created", []byte("a\r\nb")); err != nil {
+		t.Fatal(err)
+	}
+	if p := <-pubs; p.line != "PUB notes.note.created 4" || p.payload != "a\r\nb\r\n" {
+		t.Errorf("server got %+v", p)
+	}
+	if !<-pongs {
+		t.Error("client did not answer PING")
+	}
+
+	// The next publish reconnects, though one may fail before the client
+	// notices the connection is gone.
+	deadline := time.Now().Add(5 * time.Second)
+	for peer.Accepted() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("client did not reconnect")
+		}
+		client.Publish(ctx, "notes.note.updated", []byte("a\r\nb"))
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case p := <-pubs:
+		if p.line != "PUB notes.note.updated 4" {
+			t.Errorf("server got %+v after reconnecting", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing published after reconnecting")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+		if handshake(c) == nil || !c.Expect("SUB notes.> 1") {
+			return
+		}
+		c.Send("MSG notes.note.created 1 5", "first")
+		c.Send("PING")
+		c.Expect("PONG")
+		c.Send("MSG notes.note.updated 1 _INBOX.1 8", "sec\r\nond")
+		c.Line() // until the client leaves
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan string, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- nats.New("nats://"+peer.Addr).Subscribe(ctx, "notes.>", func(msg []byte) { got <- string(msg) })
+	}()
+	for _, want := range []string{"first", "sec\r\nond"} {
+		select {
+		case msg := <-got:
+			if msg != want {
+				t.Errorf("message = %q, want %q", msg, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no message %q", want)
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Subscribe = %v after cancel", err)
+	}
+
+	// An error from the server ends the subscription.
+	peer = testutil.Listen(t, func(c *testutil.PeerConn) {
+		if handshake(c) != nil && c.Expect("SUB notes.> 1") {
+			c.Send("-ERR 'Permissions Violation for Subscription to \"notes.>\"'")
+		}
+	})
+	err := nats.New("nats://"+peer.Addr).Subscribe(context.Background(), "notes.>", func([]byte) {})
+	if err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("Subscribe = %v, want the server error", err)
+	}
+}
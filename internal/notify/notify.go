@@ -0,0 +1,99 @@
+// Package notify keeps each user's in-app notifications and the notes they
+// watch.
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	NoteUpdated = "note.updated"
+	NoteDeleted = "note.deleted"
+	JobDone     = "job.done"
+	JobFailed   = "job.failed"
+	// ChangeRequested tells the owner of a notebook that requires approval
+	// about an edit to decide; ChangeApproved and ChangeRejected tell its
+	// author the decision.
+	ChangeRequested = "change.requested"
+	ChangeApproved  = "change.approved"
+	ChangeRejected  = "change.rejected"
+	// CommentAdded tells watchers of a note about a new comment on it.
+	CommentAdded = "comment.added"
+)
+
+// inboxLimit caps the notifications kept per user; the oldest go first.
+const inboxLimit = 500
+
+type Notification struct {
+	ID     int64     `json:"id"`
+	Type   string    `json:"type" example:"note.updated"`
+	NoteID int64     `json:"note_id" example:"1"`
+	JobID  int64     `json:"job_id,omitempty"`
+	Title  string    `json:"title" example:"Планы"`
+	Actor  string    `json:"actor" example:"alice"`
+	At     time.Time `json:"at"`
+	Read   bool      `json:"read"`
+	// ChangeID is the change request of change.* notifications.
+	ChangeID int64 `json:"change_id,omitempty"`
+}
+
+type Inbox struct {
+	mu    sync.Mutex
+	next  int64
+	users map[string][]Notification
+}
+
+func NewInbox() *Inbox {
+	return &Inbox{next: 1, users: make(map[string][]Notification)}
+}
+
+// Add delivers n to userID.
+func (b *Inbox) Add(userID string, n Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n.ID = b.next
+	b.next++
+	list := append(b.users[userID], n)
+	if len(list) > inboxLimit {
+		list = append([]Notification(nil), list[len(list)-inboxLimit:]...)
+	}
+	b.users[userID] = list
+}
+
+// List returns the notifications of userID, newest first.
+func (b *Inbox) List(userID string, unreadOnly bool) []Notification {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := b.users[userID]
+	out := make([]Notification, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		if !unreadOnly || !list[i].Read {
+			out = append(out, list[i])
+		}
+	}
+	return out
+}
+
+// MarkRead marks the given notifications of userID read, or all of them
+// when ids is empty. It returns how many were unread.
+func (b *Inbox) MarkRead(userID string, ids []int64) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	want := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	marked := 0
+	list := b.users[userID]
+	for i := range list {
+		if !list[i].Read && (len(ids) == 0 || want[list[i].ID]) {
+			list[i].Read = true
+			marked++
+		}
+	}
+	return marked
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Watches records who watches which note. Watchers are kept as the
+// principal they watched as, so that deliveries can check they still may
+// read the note.
+type Watches struct {
+	mu    sync.Mutex
+	notes map[int64]map[string]core.Principal
+}
+
+func NewWatches() *Watches {
+	return &Watches{notes: make(map[int64]map[string]core.Principal)}
+}
+
+func (w *Watches) Watch(noteID int64, p core.Principal) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	watchers := w.notes[noteID]
+	if watchers == nil {
+		watchers = make(map[string]core.Principal)
+		w.notes[noteID] = watchers
+	}
+	watchers[p.UserID] = p
+}
+
+func (w *Watches) Unwatch(noteID int64, userID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.notes[noteID], userID)
+	if len(w.notes[noteID]) == 0 {
+		delete(w.notes, noteID)
+	}
+}
+
+func (w *Watches) Watching(noteID int64, userID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, ok := w.notes[noteID][userID]
+	return ok
+}
+
+// Watchers returns the watchers of a note ordered by user ID.
+func (w *Watches) Watchers(noteID int64) []core.Principal {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	watchers := make([]core.Principal, 0, len(w.notes[noteID]))
+	for _, p := range w.notes[noteID] {
+		watchers = append(watchers, p)
+	}
+	sort.Slice(watchers, func(i, j int) bool { return watchers[i].UserID < watchers[j].UserID })
+	return watchers
+}
+
+// Forget drops every watch of a deleted note.
+func (w *Watches) Forget(noteID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.notes, noteID)
+}
@@ -0,0 +1,87 @@
+// Package ocr recognizes text in images through an external OCR service.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Recognizer returns the text in an image.
+type Recognizer interface {
+	Recognize(ctx context.Context, name string, image []byte) (string, error)
+}
+
+// Images reports whether OCR applies to files of contentType.
+func Images(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif", "image/bmp", "image/tiff", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Tesseract calls a tesseract-server (github.com/hertzg/tesseract-server)
+// at URL, e.g. http://ocr:8884.
+type Tesseract struct {
+	URL string
+	// Languages are the Tesseract language codes to recognize, such as
+	// eng and rus; empty uses the server's default.
+	Languages []string
+	Client    *http.Client
+}
+
+func (t Tesseract) Recognize(ctx context.Context, name string, image []byte) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	options := map[string]interface{}{}
+	if len(t.Languages) > 0 {
+		options["languages"] = t.Languages
+	}
+	opts, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	mw.WriteField("options", string(opts))
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	fw.Write(image)
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(t.URL, "/")+"/tesseract", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("tesseract: %s", resp.Status)
+	}
+
+	var result struct {
+		Data struct {
+			Stdout string `json:"stdout"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("tesseract: %v", err)
+	}
+	return strings.TrimSpace(result.Data.Stdout), nil
+}
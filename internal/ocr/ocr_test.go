@@ -0,0 +1,39 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTesseract(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tesseract" {
+			http.NotFound(w, r)
+			return
+		}
+		var options struct{ Languages []string }
+		json.Unmarshal([]byte(r.FormValue("options")), &options)
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		image, _ := io.ReadAll(f)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{
+			"stdout": string(image) + " " + options.Languages[0] + "\n\f",
+		}})
+	}))
+	defer srv.Close()
+
+	text, err := Tesseract{URL: srv.URL + "/", Languages: []string{"rus"}}.Recognize(context.Background(), "a.png", []byte("доска"))
+	if err != nil || text != "доска rus" {
+		t.Errorf("Recognize = %q, %v", text, err)
+	}
+	if _, err := (Tesseract{URL: srv.URL + "/missing"}).Recognize(context.Background(), "a.png", nil); err == nil {
+		t.Error("no error for a failed request")
+	}
+}
@@ -0,0 +1,220 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+)
+
+var ErrInvalidFont = errors.New("invalid or unsupported TrueType font")
+
+// Font is a TrueType font embedded whole into documents. Only what layout
+// and embedding need is read from it: the character map, advance widths
+// and vertical metrics.
+type Font struct {
+	data       []byte
+	unitsPerEm float64
+	ascent     int16
+	descent    int16
+	bbox       [4]int16
+	glyphs     map[rune]uint16
+	advances   []uint16
+}
+
+// LoadFont reads a TrueType (.ttf) font file.
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+func ParseFont(data []byte) (*Font, error) {
+	tables, err := tableDirectory(data)
+	if err != nil {
+		return nil, err
+	}
+	head, hhea, hmtx, cmap := tables["head"], tables["hhea"], tables["hmtx"], tables["cmap"]
+	if len(head) < 54 || len(hhea) < 36 || cmap == nil {
+		return nil, ErrInvalidFont
+	}
+
+	f := &Font{
+		data:       data,
+		unitsPerEm: float64(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int16(binary.BigEndian.Uint16(hhea[4:])),
+		descent:    int16(binary.BigEndian.Uint16(hhea[6:])),
+	}
+	if f.unitsPerEm == 0 {
+		return nil, ErrInvalidFont
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int16(binary.BigEndian.Uint16(head[36+2*i:]))
+	}
+
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	if metrics == 0 || len(hmtx) < 4*metrics {
+		return nil, ErrInvalidFont
+	}
+	f.advances = make([]uint16, metrics)
+	for i := range f.advances {
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+
+	if f.glyphs, err = parseCmap(cmap); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func tableDirectory(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 {
+		// OpenType CFF fonts ("OTTO") cannot be embedded as FontFile2.
+		return nil, ErrInvalidFont
+	}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*count {
+		return nil, ErrInvalidFont
+	}
+
+	tables := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		rec := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, ErrInvalidFont
+		}
+		tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// parseCmap reads the Unicode character map, preferring the full-range
+// format 12 subtable over the BMP-only format 4 one.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, ErrInvalidFont
+	}
+	var format4, format12 []byte
+	count := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < count && 4+8*i+8 <= len(cmap); i++ {
+		rec := cmap[4+8*i:]
+		platform, encoding := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[2:])
+		offset := binary.BigEndian.Uint32(rec[4:])
+		if int(offset)+4 > len(cmap) {
+			continue
+		}
+		sub := cmap[offset:]
+		unicode := platform == 0 || platform == 3 && (encoding == 1 || encoding == 10)
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			if unicode && format4 == nil {
+				format4 = sub
+			}
+		case 12:
+			if unicode && format12 == nil {
+				format12 = sub
+			}
+		}
+	}
+
+	switch {
+	case format12 != nil:
+		return parseFormat12(format12)
+	case format4 != nil:
+		return parseFormat4(format4)
+	}
+	return nil, ErrInvalidFont
+}
+
+func parseFormat4(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 14 {
+		return nil, ErrInvalidFont
+	}
+	segs := int(binary.BigEndian.Uint16(sub[6:])) / 2
+	ends, starts, deltas, ranges := 14, 16+2*segs, 16+4*segs, 16+6*segs
+	if len(sub) < ranges+2*segs {
+		return nil, ErrInvalidFont
+	}
+
+	glyphs := make(map[rune]uint16)
+	for s := 0; s < segs; s++ {
+		end := binary.BigEndian.Uint16(sub[ends+2*s:])
+		start := binary.BigEndian.Uint16(sub[starts+2*s:])
+		delta := binary.BigEndian.Uint16(sub[deltas+2*s:])
+		rangeOffset := int(binary.BigEndian.Uint16(sub[ranges+2*s:]))
+		for c := int(start); c <= int(end) && c != 0xFFFF; c++ {
+			var g uint16
+			if rangeOffset == 0 {
+				g = uint16(c) + delta
+			} else {
+				addr := ranges + 2*s + rangeOffset + 2*(c-int(start))
+				if addr+2 > len(sub) {
+					break
+				}
+				if g = binary.BigEndian.Uint16(sub[addr:]); g != 0 {
+					g += delta
+				}
+			}
+			if g != 0 {
+				glyphs[rune(c)] = g
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+func parseFormat12(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 16 {
+		return nil, ErrInvalidFont
+	}
+	groups := int(binary.BigEndian.Uint32(sub[12:]))
+	if groups < 0 || len(sub) < 16+12*groups {
+		return nil, ErrInvalidFont
+	}
+
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < groups; i++ {
+		g := sub[16+12*i:]
+		start, end, glyph := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:]), binary.BigEndian.Uint32(g[8:])
+		if end < start || end-start > 0x10FFFF {
+			return nil, ErrInvalidFont
+		}
+		for c := start; c <= end; c++ {
+			glyphs[rune(c)] = uint16(glyph + c - start)
+		}
+	}
+	return glyphs, nil
+}
+
+// glyph returns the glyph of r, or 0 (.notdef) when the font lacks it.
+func (f *Font) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// advance returns the width of glyph g in thousandths of the font size,
+// the unit PDF widths use.
+func (f *Font) advance(g uint16) float64 {
+	i := int(g)
+	if i >= len(f.advances) {
+		i = len(f.advances) - 1
+	}
+	return float64(f.advances[i]) * 1000 / f.unitsPerEm
+}
+
+// Width returns the width of s at the given size, in points.
+func (f *Font) Width(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		w += f.advance(f.glyph(r))
+	}
+	return w * size / 1000
+}
+
+func (f *Font) scale(v int16) int {
+	return int(float64(v) * 1000 / f.unitsPerEm)
+}
@@ -0,0 +1,257 @@
+// Package pdf writes simple text documents: wrapped lines of text in one
+// embedded TrueType font, on as many A4 pages as they take. It is enough
+// for printing notes, which are text, and needs no external tools.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Page geometry, in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+	Margin     = 56.0
+)
+
+// Style describes how a run of text is laid out.
+type Style struct {
+	Size float64
+	// Indent shifts the text right of the margin.
+	Indent float64
+	// Marker, such as a bullet, is drawn before the first line; wrapped
+	// lines align with the text after it.
+	Marker string
+	// Gray draws the text in a lighter color, for secondary details.
+	Gray bool
+}
+
+// Document collects pages as text is added.
+type Document struct {
+	// Title is stored in the document information.
+	Title string
+
+	font  *Font
+	pages []*bytes.Buffer
+	y     float64
+	used  map[uint16]rune
+}
+
+func New(font *Font) *Document {
+	d := &Document{font: font, used: make(map[uint16]rune)}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+	d.y = PageHeight - Margin
+}
+
+// Gap leaves vertical space, unless it would start a page.
+func (d *Document) Gap(h float64) {
+	if d.y-h < Margin {
+		d.y = Margin
+		return
+	}
+	d.y -= h
+}
+
+// Text adds text in the given style, wrapping it to the page width and
+// keeping its line breaks.
+func (d *Document) Text(text string, s Style) {
+	left := Margin + s.Indent
+	textLeft := left + d.font.Width(s.Marker, s.Size)
+	width := PageWidth - Margin - textLeft
+
+	first := true
+	for _, para := range strings.Split(text, "\n") {
+		for _, line := range d.wrap(para, s.Size, width) {
+			if first && s.Marker != "" {
+				d.line(left, s.Marker+line, s)
+			} else {
+				d.line(textLeft, line, s)
+			}
+			first = false
+		}
+	}
+}
+
+func (d *Document) line(x float64, text string, s Style) {
+	height := s.Size * 1.4
+	if d.y-height < Margin {
+		d.newPage()
+	}
+	d.y -= height
+
+	page := d.pages[len(d.pages)-1]
+	if s.Gray {
+		page.WriteString("0.45 g\n")
+	}
+	fmt.Fprintf(page, "BT /F1 %.2f Tf %.2f %.2f Td <", s.Size, x, d.y+0.3*s.Size)
+	for _, r := range text {
+		g := d.font.glyph(r)
+		if _, ok := d.used[g]; !ok {
+			d.used[g] = r
+		}
+		fmt.Fprintf(page, "%04X", g)
+	}
+	page.WriteString("> Tj ET\n")
+	if s.Gray {
+		page.WriteString("0 g\n")
+	}
+}
+
+// wrap breaks text into lines at most width wide, at spaces where it can
+// and inside words that are wider than a line.
+func (d *Document) wrap(text string, size, width float64) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	var cur string
+	for _, w := range words {
+		candidate := w
+		if cur != "" {
+			candidate = cur + " " + w
+		}
+		if d.font.Width(candidate, size) <= width {
+			cur = candidate
+			continue
+		}
+		if cur != "" {
+			lines = append(lines, cur)
+		}
+		cur = ""
+		for _, r := range w {
+			if cur != "" && d.font.Width(cur+string(r), size) > width {
+				lines = append(lines, cur)
+				cur = ""
+			}
+			cur += string(r)
+		}
+	}
+	return append(lines, cur)
+}
+
+// WriteTo writes the document as a PDF file.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(data)
+		zw.Close()
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n<< %s /Filter /FlateDecode /Length %d >>\nstream\n", len(offsets), dict, z.Len())
+		out.Write(z.Bytes())
+		out.WriteString("\nendstream\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-8 are fixed; pages follow as page and content pairs.
+	const firstPage = 9
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	f := d.font
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /NotesFont /Encoding /Identity-H /DescendantFonts [4 0 R] /ToUnicode 7 0 R >>")
+	object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /NotesFont "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor 5 0 R /CIDToGIDMap /Identity /W [%s] >>", d.widths()))
+	object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /NotesFont /Flags 32 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 6 0 R >>",
+		f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent)))
+	stream(fmt.Sprintf("/Length1 %d", len(f.data)), f.data)
+	stream("", d.toUnicode())
+	object(fmt.Sprintf("<< /Title %s /Producer (notes-api) >>", textString(d.Title)))
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", PageWidth, PageHeight, firstPage+2*i+1))
+		stream("", page.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 8 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+func (d *Document) sortedGlyphs() []uint16 {
+	glyphs := make([]uint16, 0, len(d.used))
+	for g := range d.used {
+		glyphs = append(glyphs, g)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// widths lists the advance of every glyph used, in the W array format.
+func (d *Document) widths() string {
+	var b strings.Builder
+	for _, g := range d.sortedGlyphs() {
+		fmt.Fprintf(&b, "%d [%d] ", g, int(d.font.advance(g)))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// toUnicode maps glyphs back to text, so that it can be searched and
+// copied from viewers.
+func (d *Document) toUnicode() []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	glyphs := d.sortedGlyphs()
+	for len(glyphs) > 0 {
+		// bfchar sections hold at most 100 entries.
+		n := min(len(glyphs), 100)
+		fmt.Fprintf(&b, "%d beginbfchar\n", n)
+		for _, g := range glyphs[:n] {
+			fmt.Fprintf(&b, "<%04X> <%s>\n", g, utf16Hex(string(d.used[g])))
+		}
+		b.WriteString("endbfchar\n")
+		glyphs = glyphs[n:]
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// textString encodes s as a PDF text string in UTF-16BE.
+func textString(s string) string {
+	return "<FEFF" + utf16Hex(s) + ">"
+}
+
+func utf16Hex(s string) string {
+	var b strings.Builder
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
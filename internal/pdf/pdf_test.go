@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// fontPath is a TrueType font most Linux systems ship.
+const fontPath = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+
+func TestDocument(t *testing.T) {
+	font, err := LoadFont(fontPath)
+	if err != nil {
+		t.Skipf("no test font: %v", err)
+	}
+
+	doc := New(font)
+	doc.Title = "Заметка"
+	doc.Text("Заголовок", Style{Size: 20})
+	for i := 0; i < 80; i++ {
+		doc.Text(fmt.Sprintf("Строка %d с текстом, который переносится по словам, если не помещается в ширину страницы A4.", i), Style{Size: 11, Marker: "• "})
+	}
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if len(doc.pages) < 2 {
+		t.Errorf("%d pages, want the text to overflow the first", len(doc.pages))
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF file: %q...", data[:20])
+	}
+
+	// Every xref entry must point at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	if len(entries) != 8+2*len(doc.pages) {
+		t.Fatalf("%d xref entries for %d pages", len(entries), len(doc.pages))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[off:off+12])
+		}
+	}
+}
+
+func TestParseFontRejectsGarbage(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("OTTO\x00\x01"), bytes.Repeat([]byte{0, 1, 0, 0}, 10)} {
+		if _, err := ParseFont(data); err == nil {
+			t.Errorf("ParseFont(%q) succeeded", data)
+		}
+	}
+}
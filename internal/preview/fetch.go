@@ -0,0 +1,297 @@
+// Package preview fetches the title, description and icon of pages linked
+// from notes, so that clients can show links as cards.
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// MaxPageSize bounds how much of a page is read looking for its metadata.
+const MaxPageSize = 512 << 10
+
+var (
+	ErrNotHTML         = errors.New("not an HTML page")
+	ErrForbiddenTarget = errors.New("address not allowed")
+)
+
+// Page is the metadata found on a page.
+type Page struct {
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+	Favicon     string
+}
+
+// Fetcher reads pages. Its client only connects to public addresses, so
+// that notes cannot make the server reach internal services (SSRF).
+type Fetcher struct {
+	// Client replaces the safe client, in tests.
+	Client *http.Client
+}
+
+// SafeClient returns a client that refuses to connect to loopback,
+// private, link-local and other non-public addresses. The check runs on
+// the resolved address of every connection, redirects included, so DNS
+// names pointing inside the network are refused too.
+func SafeClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !Public(ip) {
+				return ErrForbiddenTarget
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrForbiddenTarget
+			}
+			return nil
+		},
+	}
+}
+
+// Public reports whether ip is a routable public address. IPv6 addresses
+// that carry an IPv4 one for NAT64, 6to4 or Teredo must carry a public
+// one, since the gateway or relay delivers to it.
+func Public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	// "This network" and carrier-grade NAT, which cloud providers also
+	// use internally.
+	if ip4 := ip.To4(); ip4 != nil && (ip4[0] == 0 || ip4[0] == 100 && ip4[1]&0xc0 == 64) {
+		return false
+	}
+	// Local-use NAT64 prefixes map addresses however the network likes.
+	if nat64Local.Contains(ip) {
+		return false
+	}
+	for _, ip4 := range embeddedIPv4(ip) {
+		if !Public(ip4) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	nat64      = mustParseCIDR("64:ff9b::/96")
+	nat64Local = mustParseCIDR("64:ff9b:1::/48")
+	sixToFour  = mustParseCIDR("2002::/16")
+	teredo     = mustParseCIDR("2001::/32")
+)
+
+// embeddedIPv4 returns the IPv4 addresses an IPv6 address is translated or
+// tunnelled to.
+func embeddedIPv4(ip net.IP) []net.IP {
+	if ip.To4() != nil {
+		return nil
+	}
+	ip = ip.To16()
+	switch {
+	case nat64.Contains(ip):
+		return []net.IP{net.IPv4(ip[12], ip[13], ip[14], ip[15])}
+	case sixToFour.Contains(ip):
+		return []net.IP{net.IPv4(ip[2], ip[3], ip[4], ip[5])}
+	case teredo.Contains(ip):
+		// The Teredo server, and the client's address with its bits
+		// flipped.
+		return []net.IP{
+			net.IPv4(ip[4], ip[5], ip[6], ip[7]),
+			net.IPv4(^ip[12], ^ip[13], ^ip[14], ^ip[15]),
+		}
+	}
+	return nil
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+var defaultClient = SafeClient(10 * time.Second)
+
+// Fetch reads the page at rawURL and the metadata in its head.
+func (f Fetcher) Fetch(ctx context.Context, rawURL string) (Page, error) {
+	body, final, err := f.Read(ctx, rawURL, MaxPageSize)
+	if err != nil {
+		return Page{}, err
+	}
+	return parse(bytes.NewReader(body), final), nil
+}
+
+// Read returns up to limit bytes of the HTML page at rawURL and its final
+// URL after redirects, which relative links resolve against.
+func (f Fetcher) Read(ctx context.Context, rawURL string, limit int64) ([]byte, *url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, nil, ErrForbiddenTarget
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "notes-api")
+
+	client := f.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("page: %s", resp.Status)
+	}
+	if t, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); t != "text/html" && t != "application/xhtml+xml" {
+		return nil, nil, ErrNotHTML
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Request.URL, nil
+}
+
+// parse reads metadata from the head of a page. Open Graph and Twitter
+// tags win over the plain title and description.
+func parse(r io.Reader, base *url.URL) Page {
+	var p Page
+	var title, description, icon string
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finish(p, title, description, icon, base)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "title":
+				inTitle = title == ""
+			case "meta":
+				key := strings.ToLower(attr(tok, "property"))
+				if key == "" {
+					key = strings.ToLower(attr(tok, "name"))
+				}
+				content := strings.TrimSpace(attr(tok, "content"))
+				switch key {
+				case "og:title":
+					p.Title = content
+				case "twitter:title":
+					p.Title = first(p.Title, content)
+				case "og:description":
+					p.Description = content
+				case "twitter:description":
+					p.Description = first(p.Description, content)
+				case "description":
+					description = first(description, content)
+				case "og:image":
+					p.Image = content
+				case "twitter:image":
+					p.Image = first(p.Image, content)
+				case "og:site_name":
+					p.SiteName = content
+				}
+			case "link":
+				for _, rel := range strings.Fields(strings.ToLower(attr(tok, "rel"))) {
+					if rel == "icon" && icon == "" {
+						icon = attr(tok, "href")
+					}
+				}
+			case "body":
+				return finish(p, title, description, icon, base)
+			}
+		case html.TextToken:
+			if inTitle {
+				title = strings.Join(strings.Fields(string(z.Text())), " ")
+				inTitle = false
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return finish(p, title, description, icon, base)
+			}
+		}
+	}
+}
+
+func finish(p Page, title, description, icon string, base *url.URL) Page {
+	p.Title = first(p.Title, title)
+	p.Description = first(p.Description, description)
+	if icon == "" {
+		icon = "/favicon.ico"
+	}
+	p.Favicon = resolve(base, icon)
+	p.Image = resolve(base, p.Image)
+	return p
+}
+
+// resolve makes ref absolute against base, keeping only http(s) links.
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func first(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
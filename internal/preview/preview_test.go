@@ -0,0 +1,111 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post")
+	page := parse(strings.NewReader(`<!doctype html><html><head>
+		<title> Post
+		title </title>
+		<meta name="description" content="Plain description">
+		<meta property="og:title" content="Open Graph title">
+		<meta property="og:image" content="/img/cover.png">
+		<link rel="shortcut icon" href="icon.png">
+		</head><body><title>not this</title></body></html>`), base)
+	want := Page{
+		Title:       "Open Graph title",
+		Description: "Plain description",
+		Image:       "https://example.com/img/cover.png",
+		Favicon:     "https://example.com/blog/icon.png",
+	}
+	if page != want {
+		t.Errorf("parse = %+v", page)
+	}
+
+	page = parse(strings.NewReader(`<title>Only a title</title><link rel="icon" href="javascript:alert(1)">`), base)
+	if page.Title != "Only a title" || page.Favicon != "" {
+		t.Errorf("parse = %+v", page)
+	}
+	if page = parse(strings.NewReader(`<p>no head`), base); page.Favicon != "https://example.com/favicon.ico" {
+		t.Errorf("default favicon = %q", page.Favicon)
+	}
+}
+
+func TestSafeClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<title>internal</title>"))
+	}))
+	defer srv.Close()
+
+	// The test server listens on loopback, like an internal service.
+	_, err := Fetcher{Client: SafeClient(time.Second)}.Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrForbiddenTarget) {
+		t.Errorf("Fetch(loopback) = %v", err)
+	}
+	if _, err := (Fetcher{}).Fetch(context.Background(), "file:///etc/passwd"); err != ErrForbiddenTarget {
+		t.Errorf("Fetch(file) = %v", err)
+	}
+	if page, err := (Fetcher{Client: srv.Client()}).Fetch(context.Background(), srv.URL); err != nil || page.Title != "internal" {
+		t.Errorf("Fetch with test client = %+v, %v", page, err)
+	}
+
+	for _, tc := range []struct {
+		addr   string
+		public bool
+	}{
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"::ffff:192.168.0.1", false},
+		// Carrier-grade NAT is 100.64.0.0/10.
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		// NAT64 reaches the IPv4 address in the last 32 bits.
+		{"64:ff9b::169.254.169.254", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::93.184.216.34", true},
+		{"64:ff9b:1::5db8:d822", false},
+		// 6to4 carries the IPv4 address after 2002::/16.
+		{"2002:a00:1::1", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"2002:5db8:d822::1", true},
+		// Teredo carries the server and the inverted client address.
+		{"2001:0:4136:e378:8000:63bf:f5ff:fffe", false},
+		{"2001:0:a00:1:8000:63bf:a247:27dd", false},
+		{"2001:0:4136:e378:8000:63bf:a247:27dd", true},
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+	} {
+		if got := Public(net.ParseIP(tc.addr)); got != tc.public {
+			t.Errorf("Public(%s) = %v, want %v", tc.addr, got, tc.public)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	n := core.Note{
+		Content: "See https://go.dev/doc. And [blog](https://go.dev/blog), again https://go.dev/doc",
+		Blocks:  []core.Block{{Type: core.BlockParagraph, Text: "<http://example.com/a?b=c>"}},
+	}
+	got := strings.Join(Links(n), " ")
+	if got != "https://go.dev/doc https://go.dev/blog http://example.com/a?b=c" {
+		t.Errorf("Links = %s", got)
+	}
+}
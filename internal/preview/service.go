@@ -0,0 +1,193 @@
+package preview
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+const (
+	StatePending = "pending"
+	StateReady   = "ready"
+	StateFailed  = "failed"
+)
+
+const (
+	// MaxLinks bounds the links of one note that get previews.
+	MaxLinks = 20
+	// MaxCached bounds the previews kept in memory.
+	MaxCached = 10000
+)
+
+// Preview describes a linked page. Until it has been fetched its State is
+// pending and only URL is set.
+type Preview struct {
+	URL         string     `json:"url" example:"https://go.dev/blog"`
+	State       string     `json:"state" example:"ready" enums:"pending,ready,failed"`
+	Title       string     `json:"title,omitempty" example:"The Go Blog"`
+	Description string     `json:"description,omitempty" example:"News from the Go team"`
+	Image       string     `json:"image,omitempty" example:"https://go.dev/images/go-logo-blue.svg"`
+	SiteName    string     `json:"site_name,omitempty" example:"go.dev"`
+	Favicon     string     `json:"favicon,omitempty" example:"https://go.dev/favicon.ico"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+
+	queued bool
+}
+
+// Service fetches previews of the links in notes in the background and
+// caches them by URL. Previews are refetched after TTL, failures after
+// RetryAfter.
+type Service struct {
+	Fetcher    Fetcher
+	Clock      clock.Clock
+	TTL        time.Duration
+	RetryAfter time.Duration
+
+	mu       sync.Mutex
+	previews map[string]*Preview
+	queue    chan string
+}
+
+func NewService() *Service {
+	return &Service{
+		Clock:      clock.System{},
+		TTL:        24 * time.Hour,
+		RetryAfter: time.Hour,
+		previews:   make(map[string]*Preview),
+		queue:      make(chan string, 1024),
+	}
+}
+
+// Run fetches queued links on workers until ctx is done.
+func (s *Service) Run(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case u := <-s.queue:
+					s.fetch(ctx, u)
+				}
+			}
+		}()
+	}
+}
+
+func (s *Service) fetch(ctx context.Context, u string) {
+	page, err := s.Fetcher.Fetch(ctx, u)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+	p := &Preview{URL: u, State: StateReady, FetchedAt: &now}
+	if err != nil {
+		p.State = StateFailed
+	} else {
+		p.Title, p.Description, p.Image = page.Title, page.Description, page.Image
+		p.SiteName, p.Favicon = page.SiteName, page.Favicon
+	}
+	s.previews[u] = p
+}
+
+// Apply queues the links of created and updated notes. Register it with
+// NoteRepoMem.OnChange.
+func (s *Service) Apply(c repo.Change) {
+	if c.Op == repo.ChangeDeleted {
+		return
+	}
+	s.For(Links(c.Note))
+}
+
+// For returns previews of urls in order, queueing those that are missing
+// or stale.
+func (s *Service) For(urls []string) []Preview {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+	previews := make([]Preview, 0, len(urls))
+	for _, u := range urls {
+		p, ok := s.previews[u]
+		if !ok {
+			p = &Preview{URL: u, State: StatePending}
+			s.evict(now)
+			s.previews[u] = p
+		}
+		if !p.queued && (p.State == StatePending || s.stale(p, now)) {
+			p.queued = s.enqueue(u)
+		}
+		previews = append(previews, *p)
+	}
+	return previews
+}
+
+func (s *Service) stale(p *Preview, now time.Time) bool {
+	ttl := s.TTL
+	if p.State == StateFailed {
+		ttl = s.RetryAfter
+	}
+	return p.FetchedAt != nil && now.Sub(*p.FetchedAt) >= ttl
+}
+
+// enqueue queues u for fetching unless the queue is full. The caller
+// holds s.mu.
+func (s *Service) enqueue(u string) bool {
+	select {
+	case s.queue <- u:
+		return true
+	default:
+		return false
+	}
+}
+
+// evict makes room for one more preview, dropping stale ones first. The
+// caller holds s.mu.
+func (s *Service) evict(now time.Time) {
+	if len(s.previews) < MaxCached {
+		return
+	}
+	for u, p := range s.previews {
+		if s.stale(p, now) {
+			delete(s.previews, u)
+		}
+	}
+	for u := range s.previews {
+		if len(s.previews) < MaxCached {
+			break
+		}
+		delete(s.previews, u)
+	}
+}
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]{}]+`)
+
+// Links returns the distinct http(s) URLs in a note, in order of
+// appearance, at most MaxLinks of them.
+func Links(n core.Note) []string {
+	text := n.Content
+	for _, b := range n.Blocks {
+		text += "\n" + b.Text
+		for _, item := range b.Items {
+			text += "\n" + item.Text
+		}
+	}
+
+	links := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, u := range linkPattern.FindAllString(text, -1) {
+		u = strings.TrimRight(u, ".,;:!?*_`")
+		if !seen[u] && len(links) < MaxLinks {
+			seen[u] = true
+			links = append(links, u)
+		}
+	}
+	return links
+}
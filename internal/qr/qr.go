@@ -0,0 +1,448 @@
+// Package qr encodes short byte strings, such as links, as QR codes.
+//
+// Only what public links need is implemented: byte mode, error correction
+// level M and versions 1 to 10, which hold up to 213 bytes.
+package qr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+var ErrTooLong = errors.New("qr: data too long")
+
+// QuietZone is the light border, in modules, scanners need around a code.
+const QuietZone = 4
+
+// Code is an encoded QR symbol.
+type Code struct {
+	Size    int // modules per side
+	modules []bool
+}
+
+// Black reports whether the module at column x, row y is dark.
+func (c *Code) Black(x, y int) bool {
+	return c.modules[y*c.Size+x]
+}
+
+// Image renders the code with scale pixels per module, surrounded by the
+// quiet zone.
+func (c *Code) Image(scale int) image.Image {
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Black(x, y) {
+				continue
+			}
+			px, py := (x+QuietZone)*scale, (y+QuietZone)*scale
+			for dy := 0; dy < scale; dy++ {
+				row := img.Pix[(py+dy)*img.Stride:]
+				for dx := 0; dx < scale; dx++ {
+					row[px+dx] = 1
+				}
+			}
+		}
+	}
+	return img
+}
+
+// block layout of a version at level M.
+type version struct {
+	ec     int    // EC codewords per block
+	blocks [2]int // blocks in each group
+	data   [2]int // data codewords per block in each group
+	align  []int  // alignment pattern centres
+}
+
+var versions = [...]version{
+	1:  {10, [2]int{1, 0}, [2]int{16, 0}, nil},
+	2:  {16, [2]int{1, 0}, [2]int{28, 0}, []int{6, 18}},
+	3:  {26, [2]int{1, 0}, [2]int{44, 0}, []int{6, 22}},
+	4:  {18, [2]int{2, 0}, [2]int{32, 0}, []int{6, 26}},
+	5:  {24, [2]int{2, 0}, [2]int{43, 0}, []int{6, 30}},
+	6:  {16, [2]int{4, 0}, [2]int{27, 0}, []int{6, 34}},
+	7:  {18, [2]int{4, 0}, [2]int{31, 0}, []int{6, 22, 38}},
+	8:  {22, [2]int{2, 2}, [2]int{38, 39}, []int{6, 24, 42}},
+	9:  {22, [2]int{3, 2}, [2]int{36, 37}, []int{6, 26, 46}},
+	10: {26, [2]int{4, 1}, [2]int{43, 44}, []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() int {
+	return v.blocks[0]*v.data[0] + v.blocks[1]*v.data[1]
+}
+
+// Encode encodes data in the smallest version that holds it.
+func Encode(data []byte) (*Code, error) {
+	for n := 1; n < len(versions); n++ {
+		countBits := 8
+		if n >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*versions[n].dataCodewords() {
+			continue
+		}
+
+		var b bitBuffer
+		b.append(0b0100, 4) // byte mode
+		b.append(len(data), countBits)
+		for _, c := range data {
+			b.append(int(c), 8)
+		}
+		return newCode(n, b.codewords(versions[n].dataCodewords())), nil
+	}
+	return nil, ErrTooLong
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, v>>i&1 == 1)
+	}
+}
+
+// codewords terminates and pads the buffer to capacity bytes.
+func (b bitBuffer) codewords(capacity int) []byte {
+	b.append(0, min(4, 8*capacity-len(b)))
+	b.append(0, (8-len(b)%8)%8)
+	out := make([]byte, 0, capacity)
+	for i := 0; i < len(b); i += 8 {
+		var c byte
+		for _, bit := range b[i : i+8] {
+			c <<= 1
+			if bit {
+				c |= 1
+			}
+		}
+		out = append(out, c)
+	}
+	for pad := byte(0xEC); len(out) < capacity; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// interleave splits data into blocks, appends their error correction
+// codewords and interleaves the result in transmission order.
+func interleave(v version, data []byte) []byte {
+	divisor := rsDivisor(v.ec)
+	var blocks, ecs [][]byte
+	for g := range v.blocks {
+		for i := 0; i < v.blocks[g]; i++ {
+			block := data[:v.data[g]]
+			data = data[v.data[g]:]
+			blocks = append(blocks, block)
+			ecs = append(ecs, rsRemainder(block, divisor))
+		}
+	}
+
+	var out []byte
+	for i := 0; i < max(v.data[0], v.data[1]); i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ec; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// matrix is a code under construction.
+type matrix struct {
+	size     int
+	modules  []bool
+	function []bool // modules not available for data
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.modules[y*m.size+x] = dark
+	m.function[y*m.size+x] = true
+}
+
+func newCode(n int, data []byte) *Code {
+	m := newMatrix(n)
+	m.place(interleave(versions[n], data))
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.mask(mask)
+		m.format(mask)
+		if p := m.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.mask(mask) // masks are their own inverse
+	}
+	m.mask(best)
+	m.format(best)
+	return &Code{Size: m.size, modules: m.modules}
+}
+
+// newMatrix draws the function patterns of version n.
+func newMatrix(n int) *matrix {
+	v := versions[n]
+	size := 17 + 4*n
+	m := &matrix{size: size, modules: make([]bool, size*size), function: make([]bool, size*size)}
+
+	for i := 0; i < size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		m.finder(c[0], c[1])
+	}
+	last := len(v.align) - 1
+	for i, ay := range v.align {
+		for j, ax := range v.align {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue // overlaps a finder
+			}
+			m.alignment(ax, ay)
+		}
+	}
+	m.format(0) // reserves the area until the mask is chosen
+	m.version(n)
+	return m
+}
+
+// finder draws a finder pattern and its separator around centre x, y.
+func (m *matrix) finder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			px, py := x+dx, y+dy
+			if px < 0 || px >= m.size || py < 0 || py >= m.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			m.set(px, py, d != 2 && d != 4)
+		}
+	}
+}
+
+func (m *matrix) alignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// format writes both copies of the format information for level M and the
+// given mask, plus the dark module.
+func (m *matrix) format(mask int) {
+	data := 0b00<<3 | mask // level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+// version writes both copies of the version information, present from
+// version 7 on.
+func (m *matrix) version(n int) {
+	if n < 7 {
+		return
+	}
+	rem := n
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := n<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+// place lays codewords out in the two-column zigzag from the bottom right
+// corner. Modules left over are remainder bits and stay light.
+func (m *matrix) place(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for x := right; x > right-2; x-- {
+				if m.function[y*m.size+x] {
+					continue
+				}
+				if i < 8*len(codewords) {
+					m.modules[y*m.size+x] = codewords[i/8]>>(7-i%8)&1 == 1
+				}
+				i++
+			}
+		}
+	}
+}
+
+// mask flips the data modules selected by mask pattern k.
+func (m *matrix) mask(k int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var flip bool
+			switch k {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !m.function[y*m.size+x] {
+				m.modules[y*m.size+x] = !m.modules[y*m.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the matrix by the four rules of the specification; the
+// mask with the lowest score is used.
+func (m *matrix) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			x, y = y, x
+		}
+		return m.modules[y*m.size+x]
+	}
+	finderLike := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	score := 0
+	for _, t := range []bool{false, true} {
+		for y := 0; y < m.size; y++ {
+			run := 1
+			for x := 1; x <= m.size; x++ {
+				if x < m.size && at(x, y, t) == at(x-1, y, t) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+11 <= m.size; x++ {
+				for _, p := range finderLike {
+					match := true
+					for i, dark := range p {
+						if at(x+i, y, t) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			c := at(x, y, false)
+			if c {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size && c == at(x+1, y, false) && c == at(x, y+1, false) && c == at(x+1, y+1, false) {
+				score += 3
+			}
+		}
+	}
+	total := m.size * m.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + 10*k
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, leading coefficient omitted.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,165 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" at 1-M, from the worked example in the specification's
+	// annex and countless tutorials.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("EC codewords = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	m := newMatrix(7)
+	m.format(0)
+	if got := readFormat(m.modules, m.size); got != 0b101010000010010 {
+		t.Errorf("format bits for M, mask 0 = %015b", got)
+	}
+	var bits int
+	for i := 17; i >= 0; i-- {
+		bits <<= 1
+		if m.modules[(i/3)*m.size+m.size-11+i%3] {
+			bits |= 1
+		}
+	}
+	if bits != 0x07C94 {
+		t.Errorf("version 7 bits = %018b", bits)
+	}
+}
+
+// TestRoundTrip reads codes back the way a scanner would: format from the
+// matrix, unmask, zigzag, de-interleave.
+func TestRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 14, 15, 60, 100, 150, 213} {
+		data := []byte(strings.Repeat("https://notes.example.com/s/3q2-7wXz9kPbLm0aRt5uVyQe", 5)[:n])
+		code, err := Encode(data)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", n, err)
+		}
+		version := (code.Size - 17) / 4
+		v := versions[version]
+
+		m := newMatrix(version)
+		copy(m.modules, code.modules)
+		format := readFormat(m.modules, m.size) ^ 0x5412
+		if format>>13 != 0 {
+			t.Fatalf("%d bytes: format %015b is not level M", n, format)
+		}
+		m.mask(format >> 10 & 7)
+
+		var stream []byte
+		var c byte
+		i := 0
+		for right := m.size - 1; right >= 1; right -= 2 {
+			if right == 6 {
+				right = 5
+			}
+			upward := (right+1)&2 == 0
+			for vert := 0; vert < m.size; vert++ {
+				y := vert
+				if upward {
+					y = m.size - 1 - vert
+				}
+				for x := right; x > right-2; x-- {
+					if m.function[y*m.size+x] {
+						continue
+					}
+					c <<= 1
+					if m.modules[y*m.size+x] {
+						c |= 1
+					}
+					if i++; i%8 == 0 {
+						stream = append(stream, c)
+					}
+				}
+			}
+		}
+
+		blocks := v.blocks[0] + v.blocks[1]
+		stream = stream[:v.dataCodewords()+blocks*v.ec]
+		var payload []byte
+		for b := 0; b < blocks; b++ {
+			size := v.data[0]
+			if b >= v.blocks[0] {
+				size = v.data[1]
+			}
+			var block []byte
+			for j := 0; j < size; j++ {
+				k := j * blocks
+				if j == v.data[1]-1 && size == v.data[1] && v.blocks[1] > 0 {
+					k = v.data[0]*blocks + b - v.blocks[0]
+				} else {
+					k += b
+				}
+				block = append(block, stream[k])
+			}
+			ec := make([]byte, v.ec)
+			for j := range ec {
+				ec[j] = stream[v.dataCodewords()+j*blocks+b]
+			}
+			if !bytes.Equal(rsRemainder(block, rsDivisor(v.ec)), ec) {
+				t.Errorf("%d bytes: block %d fails error correction", n, b)
+			}
+			payload = append(payload, block...)
+		}
+
+		var bits bitBuffer
+		for _, c := range payload {
+			bits.append(int(c), 8)
+		}
+		read := func(n int) (v int) {
+			for _, b := range bits[:n] {
+				v <<= 1
+				if b {
+					v |= 1
+				}
+			}
+			bits = bits[n:]
+			return v
+		}
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if mode := read(4); mode != 0b0100 {
+			t.Fatalf("%d bytes: mode %04b", n, mode)
+		}
+		got := make([]byte, read(countBits))
+		for j := range got {
+			got[j] = byte(read(8))
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("decoded %q, want %q", got, data)
+		}
+	}
+
+	if _, err := Encode(make([]byte, 214)); err != ErrTooLong {
+		t.Errorf("214 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+// readFormat reads the copy of the format bits next to the top left finder.
+func readFormat(modules []bool, size int) int {
+	at := func(x, y int) int {
+		if modules[y*size+x] {
+			return 1
+		}
+		return 0
+	}
+	var bits int
+	for i := 0; i <= 5; i++ {
+		bits |= at(8, i) << i
+	}
+	bits |= at(8, 7)<<6 | at(8, 8)<<7 | at(7, 8)<<8
+	for i := 9; i < 15; i++ {
+		bits |= at(14-i, 8) << i
+	}
+	return bits
+}
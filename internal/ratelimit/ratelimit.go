@@ -0,0 +1,157 @@
+// Package ratelimit limits request rates with the generic cell rate
+// algorithm (GCRA), either in process or shared between replicas through
+// Redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Rate requests per Period, of which up to Burst may arrive
+// at once. The zero Limit is unlimited.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Period <= 0
+}
+
+// emission is the interval at which the limit frees up one request.
+func (l Limit) emission() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+func (l Limit) burst() int {
+	if l.Burst <= 0 {
+		return l.Rate
+	}
+	return l.Burst
+}
+
+// ParseLimit reads limits such as "60/m", "1000/h" or "10/s:20", where the
+// optional suffix is the burst. An empty string is unlimited.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" {
+		return Limit{}, nil
+	}
+
+	spec, burst, hasBurst := strings.Cut(s, ":")
+	rate, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	var l Limit
+	var err error
+	if l.Rate, err = strconv.Atoi(rate); err != nil || l.Rate <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+	switch unit {
+	case "s":
+		l.Period = time.Second
+	case "m":
+		l.Period = time.Minute
+	case "h":
+		l.Period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit period %q", unit)
+	}
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit burst %q", burst)
+		}
+	}
+
+	return l, nil
+}
+
+// Plans holds the limit of each plan. Admins are on the admin plan and
+// everyone else on the free one.
+type Plans struct {
+	Free  Limit
+	Admin Limit
+}
+
+func (p Plans) For(admin bool) (string, Limit) {
+	if admin {
+		return "admin", p.Admin
+	}
+	return "free", p.Free
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long a rejected caller has to wait.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the full burst is available again.
+	ResetAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// gcra applies one request at now to the theoretical arrival time tat and
+// returns the result along with the new tat.
+func gcra(now, tat time.Time, l Limit) (Result, time.Time) {
+	emission := l.emission()
+	tolerance := time.Duration(l.burst()) * emission
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(emission)
+	allowAt := newTat.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return Result{RetryAfter: allowAt.Sub(now), ResetAfter: tat.Sub(now)}, tat
+	}
+	return Result{
+		Allowed:    true,
+		Remaining:  int(now.Sub(allowAt) / emission),
+		ResetAfter: newTat.Sub(now),
+	}, newTat
+}
+
+// Memory is a Limiter for a single replica.
+type Memory struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+	now  func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{tats: make(map[string]time.Time), now: time.Now}
+}
+
+func (m *Memory) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	if l.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	res, tat := gcra(now, m.tats[key], l)
+	m.tats[key] = tat
+
+	// Drop keys whose tat has passed; they are indistinguishable from new.
+	if len(m.tats) > 10000 {
+		for k, t := range m.tats {
+			if t.Before(now) {
+				delete(m.tats, k)
+			}
+		}
+	}
+	return res, nil
+}
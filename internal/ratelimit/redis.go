@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/redis"
+)
+
+// gcraScript is gcra run inside Redis, using the server clock so that
+// replicas with skewed clocks still share one limit. Times are in
+// microseconds.
+const gcraScript = `
+redis.replicate_commands()
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then tat = now end
+local new_tat = tat + emission
+local allow_at = new_tat - burst * emission
+if now < allow_at then
+  return {0, 0, allow_at - now, tat - now}
+end
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / emission), 0, new_tat - now}
+`
+
+// Redis is a Limiter shared by every replica using the same Redis server.
+type Redis struct {
+	Client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{Client: client}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	if l.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	reply, err := r.Client.Do(ctx, "EVAL", gcraScript, "1", "ratelimit:"+key,
+		strconv.FormatInt(l.emission().Microseconds(), 10), strconv.Itoa(l.burst()))
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, errors.New("redis: unexpected script reply")
+	}
+	nums := make([]int64, 4)
+	for i, v := range values {
+		if nums[i], ok = v.(int64); !ok {
+			return Result{}, errors.New("redis: unexpected script reply")
+		}
+	}
+
+	return Result{
+		Allowed:    nums[0] == 1,
+		Remaining:  int(nums[1]),
+		RetryAfter: time.Duration(nums[2]) * time.Microsecond,
+		ResetAfter: time.Duration(nums[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package ratelimit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/ratelimit"
+	"example.com/notes-api/internal/redis"
+	"example.com/notes-api/internal/testutil"
+)
+
+func TestRedis(t *testing.T) {
+	limit := ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 5}
+	for _, tc := range []struct {
+		name  string
+		reply []string
+		want  ratelimit.Result
+		err   bool
+	}{
+		{"allowed", []string{"*4", ":1", ":4", ":0", ":1000000"},
+			ratelimit.Result{Allowed: true, Remaining: 4, ResetAfter: time.Second}, false},
+		{"denied", []string{"*4", ":0", ":0", ":250000", ":5000000"},
+			ratelimit.Result{RetryAfter: 250 * time.Millisecond, ResetAfter: 5 * time.Second}, false},
+		{"script error", []string{"-NOSCRIPT No matching script"}, ratelimit.Result{}, true},
+		{"short reply", []string{"*3", ":1", ":4", ":0"}, ratelimit.Result{}, true},
+		{"not a number", []string{"*4", ":1", "$1", "4", ":0", ":0"}, ratelimit.Result{}, true},
+		{"nil reply", []string{"$-1"}, ratelimit.Result{}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+				cmd := c.Command()
+				if len(cmd) != 6 || cmd[0] != "EVAL" || !strings.Contains(cmd[1], "redis.call('TIME')") {
+					t.Errorf("command = %q", cmd)
+					return
+				}
+				// One key, then the emission interval in microseconds
+				// and the burst.
+				if got := cmd[2:]; got[0] != "1" || got[1] != "ratelimit:user:alice" || got[2] != "1000000" || got[3] != "5" {
+					t.Errorf("script arguments = %q", got)
+				}
+				c.Send(tc.reply...)
+			})
+			got, err := ratelimit.NewRedis(redis.New(peer.Addr, "")).Allow(context.Background(), "user:alice", limit)
+			if (err != nil) != tc.err || got != tc.want {
+				t.Errorf("Allow = %+v, %v; want %+v, error %v", got, err, tc.want, tc.err)
+			}
+		})
+	}
+
+	// Unlimited keys never reach Redis.
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {})
+	got, err := ratelimit.NewRedis(redis.New(peer.Addr, "")).Allow(context.Background(), "user:alice", ratelimit.Limit{})
+	if err != nil || !got.Allowed || peer.Accepted() != 0 {
+		t.Errorf("unlimited Allow = %+v, %v after %d connections", got, err, peer.Accepted())
+	}
+}
@@ -0,0 +1,161 @@
+// Package record stores incoming requests as JSON lines so that they can
+// be replayed against another instance. Credentials are never stored:
+// requests carry the ID of the user who made them instead, and secrets in
+// JSON bodies are replaced by Redacted.
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// MaxBody is the largest request body stored. Larger bodies are left out
+// and the entry marked, since replaying it without its body is pointless.
+const MaxBody = 1 << 20
+
+// secretHeaders are never recorded.
+var secretHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Api-Key":           true,
+}
+
+// Redacted replaces secrets in recorded bodies.
+const Redacted = "REDACTED"
+
+// secretFields are JSON fields whose values are never recorded, at any
+// depth of a body.
+var secretFields = map[string]bool{
+	"password":   true,
+	"passphrase": true,
+	"secret":     true,
+	"token":      true,
+	"key":        true,
+	"api_key":    true,
+}
+
+// webhookActions are rule actions whose url is a credential: webhook URLs
+// usually carry a token in their path.
+var webhookActions = map[string]bool{
+	"call_webhook": true,
+}
+
+// Entry is one recorded request and the status it was answered with.
+type Entry struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	User   string      `json:"user,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	// BodyOmitted is set when the body was over MaxBody.
+	BodyOmitted bool `json:"body_omitted,omitempty"`
+	Status      int  `json:"status"`
+}
+
+// Sanitize returns the headers of r that may be recorded.
+func Sanitize(h http.Header) http.Header {
+	clean := make(http.Header, len(h))
+	for key, values := range h {
+		if !secretHeaders[http.CanonicalHeaderKey(key)] {
+			clean[key] = values
+		}
+	}
+	if len(clean) == 0 {
+		return nil
+	}
+	return clean
+}
+
+// SanitizeBody returns body with the values of secret JSON fields
+// replaced by Redacted. Bodies that are not JSON, or have nothing to
+// redact, are returned as they are.
+func SanitizeBody(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return body
+	}
+	if !redact(v) {
+		return body
+	}
+	clean, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return clean
+}
+
+// redact replaces secrets within v and reports whether there were any.
+func redact(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		action, _ := v["type"].(string)
+		for name, value := range v {
+			if _, ok := value.(string); ok && (secretFields[name] || name == "url" && webhookActions[action]) {
+				v[name] = Redacted
+				changed = true
+			} else if redact(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redact(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Recorder appends entries to a file.
+type Recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// Open appends to the file at path, creating it if needed.
+func Open(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (r *Recorder) Record(e Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(e)
+}
+
+func (r *Recorder) Close() error {
+	return r.f.Close()
+}
+
+// Read calls fn with every entry in a recording, in order.
+func Read(r io.Reader, fn func(Entry) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 4*MaxBody)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
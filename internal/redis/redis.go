@@ -0,0 +1,261 @@
+// Package redis is a minimal Redis client: just enough of the protocol
+// for scripts and pub/sub, without pulling in a client library.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var ErrMalformed = errors.New("redis: malformed reply")
+
+type Client struct {
+	Addr     string
+	Password string
+	Timeout  time.Duration
+
+	conns chan *conn
+
+	acquires    atomic.Uint64
+	acquireWait atomic.Int64
+	dials       atomic.Uint64
+}
+
+func New(addr, password string) *Client {
+	return &Client{
+		Addr:     addr,
+		Password: password,
+		Timeout:  time.Second,
+		conns:    make(chan *conn, 16),
+	}
+}
+
+func (c *Client) String() string {
+	return "redis://" + c.Addr
+}
+
+// Do runs one command on a pooled connection. Replies are strings, int64s,
+// nil for null values, or []interface{} for arrays.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cn.SetDeadline(c.deadline(ctx)); err != nil {
+		cn.Close()
+		return nil, err
+	}
+	reply, err := cn.do(args...)
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+
+	select {
+	case c.conns <- cn:
+	default:
+		cn.Close()
+	}
+	return reply, nil
+}
+
+// Subscribe calls fn with every message published on channel until ctx is
+// done or the connection fails.
+func (c *Client) Subscribe(ctx context.Context, channel string, fn func([]byte)) error {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+
+	if err := cn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	if err := cn.send("SUBSCRIBE", channel); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := cn.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 {
+			return ErrMalformed
+		}
+		if kind, _ := msg[0].(string); kind == "message" {
+			payload, _ := msg[2].(string)
+			fn([]byte(payload))
+		}
+	}
+}
+
+// Ping checks the server answers, dropping idle connections when it does
+// not so that the pool is rebuilt from fresh ones.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	if err != nil {
+		c.Reset()
+	}
+	return err
+}
+
+// Reset closes every idle connection.
+func (c *Client) Reset() {
+	for {
+		select {
+		case cn := <-c.conns:
+			cn.Close()
+		default:
+			return
+		}
+	}
+}
+
+type PoolStats struct {
+	Acquires    uint64
+	AcquireWait time.Duration
+	Dials       uint64
+	Idle        int
+}
+
+// Stats reports how many connections were taken from the pool and how
+// long that took in total, including dialing new ones.
+func (c *Client) Stats() PoolStats {
+	return PoolStats{
+		Acquires:    c.acquires.Load(),
+		AcquireWait: time.Duration(c.acquireWait.Load()),
+		Dials:       c.dials.Load(),
+		Idle:        len(c.conns),
+	}
+}
+
+func (c *Client) conn(ctx context.Context) (*conn, error) {
+	start := time.Now()
+	defer func() {
+		c.acquires.Add(1)
+		c.acquireWait.Add(int64(time.Since(start)))
+	}()
+
+	select {
+	case cn := <-c.conns:
+		return cn, nil
+	default:
+	}
+	return c.dial(ctx)
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	c.dials.Add(1)
+	d := net.Dialer{Timeout: c.Timeout}
+	nc, err := d.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: nc, br: bufio.NewReader(nc)}
+
+	if c.Password != "" {
+		if err := cn.SetDeadline(c.deadline(ctx)); err != nil {
+			cn.Close()
+			return nil, err
+		}
+		if _, err := cn.do("AUTH", c.Password); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+type conn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func (c *conn) do(args ...string) (interface{}, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+func (c *conn) send(args ...string) error {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(a))+"\r\n"+a+"\r\n"...)
+	}
+	_, err := c.Write(buf)
+	return err
+}
+
+func (c *conn) read() (interface{}, error) {
+	line, err := c.br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, ErrMalformed
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.br, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, ErrMalformed
+}
@@ -0,0 +1,165 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/redis"
+	"example.com/notes-api/internal/testutil"
+)
+
+func TestReplies(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		reply []string
+		want  interface{}
+		err   string
+	}{
+		{"status", []string{"+OK"}, "OK", ""},
+		{"error", []string{"-WRONGTYPE Operation against a key holding the wrong kind of value"}, nil, "redis: WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{"integer", []string{":-42"}, int64(-42), ""},
+		{"bulk", []string{"$12", "hello\r\nworld"}, "hello\r\nworld", ""},
+		{"empty bulk", []string{"$0", ""}, "", ""},
+		{"nil bulk", []string{"$-1"}, nil, ""},
+		{"nil array", []string{"*-1"}, nil, ""},
+		{"empty array", []string{"*0"}, []interface{}{}, ""},
+		{"array", []string{"*3", "$1", "a", ":1", "$-1"}, []interface{}{"a", int64(1), nil}, ""},
+		{"nested array", []string{"*2", "*1", "+x", "*0"}, []interface{}{[]interface{}{"x"}, []interface{}{}}, ""},
+		{"error in array", []string{"*2", ":1", "-ERR nope"}, nil, "redis: ERR nope"},
+		{"unknown type", []string{"?1"}, nil, redis.ErrMalformed.Error()},
+		{"bad length", []string{"$x"}, nil, redis.ErrMalformed.Error()},
+		{"short line", []string{""}, nil, redis.ErrMalformed.Error()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+				if cmd := c.Command(); !slices.Equal(cmd, []string{"GET", "k"}) {
+					t.Errorf("command = %q", cmd)
+				}
+				c.Send(tc.reply...)
+			})
+			got, err := redis.New(peer.Addr, "").Do(context.Background(), "GET", "k")
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("Do = %#v, %v; want error %q", got, err, tc.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Do = %#v, %v; want %#v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuth(t *testing.T) {
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+		if cmd := c.Command(); !slices.Equal(cmd, []string{"AUTH", "s3cret"}) {
+			t.Errorf("first command = %q", cmd)
+			c.Send("-NOAUTH Authentication required.")
+			return
+		}
+		c.Send("+OK")
+		for c.Command() != nil {
+			c.Send("+PONG")
+		}
+	})
+	if err := redis.New(peer.Addr, "s3cret").Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	peer = testutil.Listen(t, func(c *testutil.PeerConn) {
+		c.Command()
+		c.Send("-WRONGPASS invalid username-password pair")
+	})
+	client := redis.New(peer.Addr, "wrong")
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("Ping with a wrong password succeeded")
+	}
+	if idle := client.Stats().Idle; idle != 0 {
+		t.Errorf("%d connections pooled after a failed AUTH", idle)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	// The server drops every connection after one command, as on a
+	// restart or an idle timeout.
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+		if c.Command() != nil {
+			c.Send(":1")
+		}
+	})
+	client := redis.New(peer.Addr, "")
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, "INCR", "n"); err != nil {
+		t.Fatal(err)
+	}
+	if client.Stats().Idle != 1 {
+		t.Fatalf("stats = %+v, want the connection pooled", client.Stats())
+	}
+	// The pooled connection is dead: the command fails and the connection
+	// is dropped rather than pooled again.
+	if _, err := client.Do(ctx, "INCR", "n"); err == nil {
+		t.Fatal("command on a dropped connection succeeded")
+	}
+	if client.Stats().Idle != 0 {
+		t.Fatalf("stats = %+v, want the dead connection dropped", client.Stats())
+	}
+	if n, err := client.Do(ctx, "INCR", "n"); err != nil || n != int64(1) {
+		t.Fatalf("Do after reconnecting = %v, %v", n, err)
+	}
+	if got := peer.Accepted(); got != 2 {
+		t.Errorf("%d connections, want 2", got)
+	}
+	if stats := client.Stats(); stats.Acquires != 3 || stats.Dials != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	peer := testutil.Listen(t, func(c *testutil.PeerConn) {
+		if cmd := c.Command(); !slices.Equal(cmd, []string{"SUBSCRIBE", "events"}) {
+			t.Errorf("command = %q", cmd)
+			return
+		}
+		c.Send("*3", "$9", "subscribe", "$6", "events", ":1")
+		c.Send("*3", "$7", "message", "$6", "events", "$5", "first")
+		c.Send("*3", "$7", "message", "$6", "events", "$6", "second")
+		c.Line() // until the client leaves
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan string, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- redis.New(peer.Addr, "").Subscribe(ctx, "events", func(msg []byte) { got <- string(msg) })
+	}()
+	for _, want := range []string{"first", "second"} {
+		select {
+		case msg := <-got:
+			if msg != want {
+				t.Errorf("message = %q, want %q", msg, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no message %q", want)
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Subscribe = %v after cancel", err)
+	}
+
+	// A reply that is not a pub/sub message ends the subscription.
+	peer = testutil.Listen(t, func(c *testutil.PeerConn) {
+		c.Command()
+		c.Send("+OK")
+	})
+	if err := redis.New(peer.Addr, "").Subscribe(context.Background(), "events", func([]byte) {}); err != redis.ErrMalformed {
+		t.Errorf("Subscribe = %v, want ErrMalformed", err)
+	}
+}
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Where the metadata line of a template goes.
+const (
+	MetaTop    = "top"
+	MetaBottom = "bottom"
+	MetaNone   = "none"
+)
+
+var ErrTemplateNotFound = core.NotFound("template not found")
+
+var templateName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Template customizes the print view and PDF export, for branding. Header
+// and Footer are text/template lines executed with the Page, such as
+// "{{.Title}} · ACME"; they print above and below the note in both formats.
+// HTML, when set, replaces the whole print layout: it is an html/template
+// executed with the Page, in which {{template "style"}} is the built-in
+// stylesheet. CSS is added to the built-in layout. Both are HTML only.
+type Template struct {
+	Name    string    `json:"name" example:"acme"`
+	Header  string    `json:"header,omitempty" example:"ACME Corp · {{.Notebook}}"`
+	Footer  string    `json:"footer,omitempty" example:"Confidential · {{.Title}}"`
+	Meta    string    `json:"meta,omitempty" example:"bottom" enums:"top,bottom,none"`
+	CSS     string    `json:"css,omitempty" example:"h1 { color: #c00; }"`
+	HTML    string    `json:"html,omitempty"`
+	Author  string    `json:"author" example:"root"`
+	SavedAt time.Time `json:"saved_at"`
+
+	html           *template.Template
+	header, footer *texttemplate.Template
+}
+
+// compile parses the parts of t, so that broken templates are refused
+// when they are registered rather than when a note is exported.
+func (t *Template) compile() error {
+	if !templateName.MatchString(t.Name) {
+		return errors.New("name must be lowercase letters, digits, '-' or '_'")
+	}
+	switch t.Meta {
+	case "":
+		t.Meta = MetaTop
+	case MetaTop, MetaBottom, MetaNone:
+	default:
+		return errors.New("meta must be top, bottom or none")
+	}
+
+	var err error
+	if t.header, err = texttemplate.New("header").Parse(t.Header); err != nil {
+		return fmt.Errorf("header: %w", err)
+	}
+	if t.footer, err = texttemplate.New("footer").Parse(t.Footer); err != nil {
+		return fmt.Errorf("footer: %w", err)
+	}
+	if t.HTML != "" {
+		// The built-in set cannot be cloned once it has run, so the
+		// stylesheet is parsed anew.
+		base := template.Must(template.ParseFS(templates, "templates/style.html"))
+		if t.html, err = base.New("custom").Parse(t.HTML); err != nil {
+			return fmt.Errorf("html: %w", err)
+		}
+	}
+	return nil
+}
+
+// apply fills the header, footer and layout of p from t.
+func (t *Template) apply(p *Page) error {
+	var b strings.Builder
+	if err := t.header.Execute(&b, p); err != nil {
+		return fmt.Errorf("header: %w", err)
+	}
+	p.Header = strings.TrimSpace(b.String())
+	b.Reset()
+	if err := t.footer.Execute(&b, p); err != nil {
+		return fmt.Errorf("footer: %w", err)
+	}
+	p.Footer = strings.TrimSpace(b.String())
+	p.MetaAt = t.Meta
+	p.CSS = template.CSS(t.CSS)
+	return nil
+}
+
+// Templates holds the templates admins registered, by name.
+type Templates struct {
+	mu        sync.RWMutex
+	templates map[string]*Template
+}
+
+func NewTemplates() *Templates {
+	return &Templates{templates: make(map[string]*Template)}
+}
+
+// Put validates t and registers it, replacing any template of that name.
+func (s *Templates) Put(t Template) (Template, error) {
+	if err := t.compile(); err != nil {
+		return Template{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[t.Name] = &t
+	return t, nil
+}
+
+func (s *Templates) Get(name string) (*Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.templates[name]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+// List returns the templates by name.
+func (s *Templates) List() []Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Template, 0, len(s.templates))
+	for _, t := range s.templates {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (s *Templates) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.templates[name]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(s.templates, name)
+	return nil
+}
+
+// PrintWith writes the print view of a page laid out by t; a nil t is
+// the built-in layout.
+func PrintWith(w io.Writer, p Page, t *Template) error {
+	if t == nil {
+		return Print(w, p)
+	}
+	if err := t.apply(&p); err != nil {
+		return err
+	}
+	if t.html != nil {
+		return t.html.ExecuteTemplate(w, "custom", p)
+	}
+	return Print(w, p)
+}
@@ -0,0 +1,217 @@
+package render
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var ErrEmojiNotFound = core.NotFound("emoji not found")
+
+var (
+	emojiName = regexp.MustCompile(`^[a-z0-9_+-]{1,64}$`)
+	// shortcodePattern runs on escaped text, in which colons stay as they
+	// are.
+	shortcodePattern = regexp.MustCompile(`:([a-z0-9_+-]{1,64}):`)
+)
+
+// builtinEmoji are the shortcodes every note can use, named as in Slack
+// and GitHub.
+var builtinEmoji = map[string]string{
+	"+1":                       "👍",
+	"-1":                       "👎",
+	"100":                      "💯",
+	"angry":                    "😠",
+	"arrow_down":               "⬇️",
+	"arrow_left":               "⬅️",
+	"arrow_right":              "➡️",
+	"arrow_up":                 "⬆️",
+	"beer":                     "🍺",
+	"bell":                     "🔔",
+	"blush":                    "😊",
+	"book":                     "📖",
+	"bookmark":                 "🔖",
+	"books":                    "📚",
+	"broken_heart":             "💔",
+	"bug":                      "🐛",
+	"bulb":                     "💡",
+	"cake":                     "🍰",
+	"calendar":                 "📅",
+	"calendar_spiral":          "🗓️",
+	"chart_with_upwards_trend": "📈",
+	"clap":                     "👏",
+	"cloud":                    "☁️",
+	"coffee":                   "☕",
+	"confused":                 "😕",
+	"construction":             "🚧",
+	"cry":                      "😢",
+	"email":                    "📧",
+	"exclamation":              "❗",
+	"eyes":                     "👀",
+	"fire":                     "🔥",
+	"gift":                     "🎁",
+	"grin":                     "😁",
+	"heart":                    "❤️",
+	"heart_eyes":               "😍",
+	"heavy_check_mark":         "✔️",
+	"hourglass":                "⌛",
+	"joy":                      "😂",
+	"key":                      "🔑",
+	"laughing":                 "😆",
+	"link":                     "🔗",
+	"lock":                     "🔒",
+	"mag":                      "🔍",
+	"memo":                     "📝",
+	"muscle":                   "💪",
+	"ok_hand":                  "👌",
+	"paperclip":                "📎",
+	"pizza":                    "🍕",
+	"pray":                     "🙏",
+	"pushpin":                  "📌",
+	"question":                 "❓",
+	"rainbow":                  "🌈",
+	"raised_hands":             "🙌",
+	"rocket":                   "🚀",
+	"see_no_evil":              "🙈",
+	"smile":                    "😄",
+	"smiley":                   "😃",
+	"snowflake":                "❄️",
+	"sob":                      "😭",
+	"sparkles":                 "✨",
+	"star":                     "⭐",
+	"sunglasses":               "😎",
+	"sunny":                    "☀️",
+	"tada":                     "🎉",
+	"thinking":                 "🤔",
+	"thumbsdown":               "👎",
+	"thumbsup":                 "👍",
+	"umbrella":                 "☔",
+	"warning":                  "⚠️",
+	"wave":                     "👋",
+	"white_check_mark":         "✅",
+	"wink":                     "😉",
+	"x":                        "❌",
+	"zap":                      "⚡",
+}
+
+// BuiltinEmoji is a shortcode every note can use.
+type BuiltinEmoji struct {
+	Name  string `json:"name" example:"tada"`
+	Emoji string `json:"emoji" example:"🎉"`
+}
+
+// CustomEmoji is an image admins registered under a shortcode, shown in
+// place of :name: in rendered notes.
+type CustomEmoji struct {
+	Name    string    `json:"name" example:"partyparrot"`
+	URL     string    `json:"url" example:"https://cdn.example.com/emoji/partyparrot.gif"`
+	Author  string    `json:"author" example:"root"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Builtin returns the built-in shortcodes by name.
+func Builtin() []BuiltinEmoji {
+	list := make([]BuiltinEmoji, 0, len(builtinEmoji))
+	for name, e := range builtinEmoji {
+		list = append(list, BuiltinEmoji{Name: name, Emoji: e})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Emoji holds the custom emoji of the instance, by name.
+type Emoji struct {
+	mu     sync.RWMutex
+	custom map[string]CustomEmoji
+}
+
+func NewEmoji() *Emoji {
+	return &Emoji{custom: make(map[string]CustomEmoji)}
+}
+
+// Put validates e and registers it, replacing any custom emoji of that
+// name. Built-in names cannot be taken.
+func (s *Emoji) Put(e CustomEmoji) (CustomEmoji, error) {
+	if !emojiName.MatchString(e.Name) {
+		return CustomEmoji{}, errors.New("name must be lowercase letters, digits, '_', '+' or '-'")
+	}
+	if _, ok := builtinEmoji[e.Name]; ok {
+		return CustomEmoji{}, errors.New("name is a built-in emoji")
+	}
+	if !SafeURL(e.URL) || !strings.HasPrefix(e.URL, "http") {
+		return CustomEmoji{}, errors.New("url must be an absolute http(s) URL")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.custom[e.Name] = e
+	return e, nil
+}
+
+// List returns the custom emoji by name.
+func (s *Emoji) List() []CustomEmoji {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]CustomEmoji, 0, len(s.custom))
+	for _, e := range s.custom {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (s *Emoji) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.custom[name]; !ok {
+		return ErrEmojiNotFound
+	}
+	delete(s.custom, name)
+	return nil
+}
+
+// URLs returns the image of each custom emoji by name, for rendering; a
+// nil s has none.
+func (s *Emoji) URLs() map[string]string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	urls := make(map[string]string, len(s.custom))
+	for name, e := range s.custom {
+		urls[name] = e.URL
+	}
+	return urls
+}
+
+// Shortcodes replaces the built-in shortcodes in plain text with their
+// emoji, for messages that leave the API as text.
+func Shortcodes(text string) string {
+	return shortcodePattern.ReplaceAllStringFunc(text, func(m string) string {
+		if e, ok := builtinEmoji[m[1:len(m)-1]]; ok {
+			return e
+		}
+		return m
+	})
+}
+
+// emojify replaces the shortcodes in escaped text with built-in emoji and
+// the images of custom ones. Unknown shortcodes stay as text.
+func emojify(escaped string, custom map[string]string) string {
+	return shortcodePattern.ReplaceAllStringFunc(escaped, func(m string) string {
+		name := m[1 : len(m)-1]
+		if e, ok := builtinEmoji[name]; ok {
+			return e
+		}
+		if url, ok := custom[name]; ok {
+			return `<img class="emoji" src="` + html.EscapeString(url) + `" alt="` + m + `" title="` + m + `">`
+		}
+		return m
+	})
+}
@@ -0,0 +1,34 @@
+package render
+
+import (
+	"regexp"
+	"testing"
+)
+
+// tags are the elements Markdown may produce; anything else got through
+// unescaped.
+var tags = regexp.MustCompile(`<(/?)([a-zA-Z0-9]+)`)
+
+var allowed = map[string]bool{
+	"p": true, "br": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "input": true, "blockquote": true, "img": true,
+	"a": true, "strong": true, "em": true, "code": true, "pre": true, "span": true,
+}
+
+func FuzzMarkdown(f *testing.F) {
+	f.Add("# h\n\n- [x] done\n- item\n1. one\n> quote\n![i](https://e.com/a.png)\n```go\nx := `a`\n```")
+	f.Add("[x](javascript:alert(1)) **b** *i* `c`")
+	f.Add("<script>alert(1)</script><img src=x onerror=alert(1)>")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		out := string(Markdown(src, nil))
+		for _, m := range tags.FindAllStringSubmatch(out, -1) {
+			if !allowed[m[2]] {
+				t.Fatalf("Markdown(%q) produced <%s>:\n%s", src, m[2], out)
+			}
+		}
+		if regexp.MustCompile(`(?i)(href|src)="\s*(javascript|data|vbscript):`).MatchString(out) {
+			t.Fatalf("Markdown(%q) links to a script:\n%s", src, out)
+		}
+	})
+}
@@ -0,0 +1,241 @@
+// Package render turns notes into standalone HTML pages. It understands
+// the Markdown subset notes are written in: headings, paragraphs, fenced
+// code, lists, checklists, quotes, images, links, emphasis and inline
+// code. Everything else is shown as text; nothing is passed through raw.
+package render
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/highlight"
+)
+
+var (
+	headingLine   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	checklistLine = regexp.MustCompile(`^[-*]\s+\[([ xX])\]\s+(.*)$`)
+	bulletLine    = regexp.MustCompile(`^[-*]\s+(.*)$`)
+	orderedLine   = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	imageLine     = regexp.MustCompile(`^!\[([^\]]*)\]\(([^)\s]+)\)$`)
+
+	// Inline patterns run on escaped text.
+	linkPattern   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	strongPattern = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	emPattern     = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+// Markdown renders src as HTML. Shortcodes such as :tada: become emoji:
+// built-in ones, or the images custom maps their names to.
+func Markdown(src string, custom map[string]string) template.HTML {
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+
+	var para []string
+	var list string // "ul", "ol" or "checklist" while a list is open
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+			para = nil
+		}
+		switch list {
+		case "ul", "checklist":
+			b.WriteString("</ul>\n")
+		case "ol":
+			b.WriteString("</ol>\n")
+		}
+		list = ""
+	}
+	openList := func(kind string) {
+		if list == kind {
+			return
+		}
+		flush()
+		list = kind
+		switch kind {
+		case "ul":
+			b.WriteString("<ul>\n")
+		case "ol":
+			b.WriteString("<ol>\n")
+		case "checklist":
+			b.WriteString(`<ul class="checklist">` + "\n")
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if fence, ok := strings.CutPrefix(trimmed, "```"); ok {
+			flush()
+			var code []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString(highlight.HTML(strings.Join(code, "\n"), strings.TrimSpace(fence)) + "\n")
+			continue
+		}
+
+		switch m := matchAny(trimmed); {
+		case trimmed == "":
+			flush()
+		case m.heading != nil:
+			flush()
+			level := string('0' + rune(len(m.heading[1])))
+			b.WriteString("<h" + level + ">" + inline(m.heading[2], custom) + "</h" + level + ">\n")
+		case m.checklist != nil:
+			openList("checklist")
+			b.WriteString("<li>" + checkbox(m.checklist[1] != " ") + " " + inline(m.checklist[2], custom) + "</li>\n")
+		case m.bullet != nil:
+			openList("ul")
+			b.WriteString("<li>" + inline(m.bullet[1], custom) + "</li>\n")
+		case m.ordered != nil:
+			openList("ol")
+			b.WriteString("<li>" + inline(m.ordered[1], custom) + "</li>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			b.WriteString("<blockquote>" + inline(strings.TrimSpace(trimmed[1:]), custom) + "</blockquote>\n")
+		case m.image != nil:
+			flush()
+			b.WriteString(image(m.image[2], m.image[1]) + "\n")
+		default:
+			if list != "" {
+				flush()
+			}
+			para = append(para, inline(trimmed, custom))
+		}
+	}
+	flush()
+	return template.HTML(b.String())
+}
+
+type lineMatch struct {
+	heading, checklist, bullet, ordered, image []string
+}
+
+func matchAny(line string) lineMatch {
+	var m lineMatch
+	if m.heading = headingLine.FindStringSubmatch(line); m.heading != nil {
+		return m
+	}
+	if m.checklist = checklistLine.FindStringSubmatch(line); m.checklist != nil {
+		return m
+	}
+	if m.bullet = bulletLine.FindStringSubmatch(line); m.bullet != nil {
+		return m
+	}
+	if m.ordered = orderedLine.FindStringSubmatch(line); m.ordered != nil {
+		return m
+	}
+	m.image = imageLine.FindStringSubmatch(line)
+	return m
+}
+
+// inline escapes text and applies links, emphasis, emoji and inline code.
+// Code spans are cut out first so that nothing inside them is formatted.
+func inline(text string, custom map[string]string) string {
+	var b strings.Builder
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		switch {
+		case i%2 == 1 && i < len(parts)-1:
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+		case i%2 == 1:
+			// An unmatched backtick is text.
+			b.WriteString("`" + format(part, custom))
+		default:
+			b.WriteString(format(part, custom))
+		}
+	}
+	return b.String()
+}
+
+// format applies links, emoji and emphasis to text. Emoji stay out of link
+// targets.
+func format(text string, custom map[string]string) string {
+	escaped := html.EscapeString(text)
+	var b strings.Builder
+	last := 0
+	for _, m := range linkPattern.FindAllStringSubmatchIndex(escaped, -1) {
+		b.WriteString(emojify(escaped[last:m[0]], custom))
+		last = m[1]
+		href := html.UnescapeString(escaped[m[4]:m[5]])
+		if !SafeURL(href) {
+			b.WriteString(escaped[m[0]:m[1]])
+			continue
+		}
+		b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener">` + emojify(escaped[m[2]:m[3]], custom) + "</a>")
+	}
+	b.WriteString(emojify(escaped[last:], custom))
+	s := b.String()
+	s = strongPattern.ReplaceAllString(s, "<strong>$1</strong>")
+	s = emPattern.ReplaceAllString(s, "<em>$1</em>")
+	return s
+}
+
+// SafeURL reports whether u may be linked to: absolute http(s) and mailto
+// only, which rules out javascript: and data: URLs.
+func SafeURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto":
+		return true
+	}
+	return false
+}
+
+func image(src, alt string) string {
+	if !SafeURL(src) || strings.HasPrefix(src, "mailto:") {
+		return "<p>" + html.EscapeString(alt) + "</p>"
+	}
+	return `<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `" loading="lazy">`
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return `<input type="checkbox" disabled checked>`
+	}
+	return `<input type="checkbox" disabled>`
+}
+
+// Body renders the content of a note: its blocks when it has any, else
+// its Markdown content. Snippets are rendered as a single code block.
+// Shortcodes outside code become emoji, as in Markdown.
+func Body(n core.Note, custom map[string]string) template.HTML {
+	if n.Type == core.NoteTypeSnippet {
+		return template.HTML(highlight.HTML(n.Content, n.Language))
+	}
+	if len(n.Blocks) == 0 {
+		return Markdown(n.Content, custom)
+	}
+
+	var b strings.Builder
+	for _, block := range n.Blocks {
+		switch block.Type {
+		case core.BlockHeading:
+			level := string('0' + rune(block.Level))
+			b.WriteString("<h" + level + ">" + emojify(html.EscapeString(block.Text), custom) + "</h" + level + ">\n")
+		case core.BlockCode:
+			b.WriteString(highlight.HTML(block.Text, block.Language) + "\n")
+		case core.BlockChecklist:
+			b.WriteString(`<ul class="checklist">` + "\n")
+			for _, item := range block.Items {
+				b.WriteString("<li>" + checkbox(item.Checked) + " " + emojify(html.EscapeString(item.Text), custom) + "</li>\n")
+			}
+			b.WriteString("</ul>\n")
+		case core.BlockImage:
+			b.WriteString(image(block.URL, block.Alt) + "\n")
+		default:
+			b.WriteString("<p>" + strings.ReplaceAll(emojify(html.EscapeString(block.Text), custom), "\n", "<br>\n") + "</p>\n")
+		}
+	}
+	return template.HTML(b.String())
+}
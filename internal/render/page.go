@@ -0,0 +1,146 @@
+package render
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"example.com/notes-api/internal/core"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var pages = template.Must(template.ParseFS(templates, "templates/*.html"))
+
+// descriptionLength is how much of the note unfurls in chats.
+const descriptionLength = 200
+
+// Page is a note rendered for people outside the API.
+type Page struct {
+	Title string
+	// Description is a plain-text excerpt for OpenGraph previews.
+	Description string
+	// URL is the canonical address of the page, if it has one.
+	URL string
+	// Image is the first image of the note, used as the preview image.
+	Image string
+	// Images lists every image the note shows, for the print view.
+	Images []string
+	// Notebook is the notebook path of the note, such as "Работа / Отчёты".
+	Notebook string
+	Tags     []string
+	Body     template.HTML
+	Created  time.Time
+	Updated  time.Time
+	// Edited is set when the note changed after it was created.
+	Edited bool
+
+	// Header, Footer, MetaAt and CSS come from the Template the page is
+	// printed with.
+	Header string
+	Footer string
+	MetaAt string
+	CSS    template.CSS
+}
+
+// NotePage prepares a note for display at url, with the custom emoji of
+// the instance.
+func NotePage(n core.Note, url string, custom map[string]string) Page {
+	updated := n.CreatedAt
+	if n.UpdatedAt != nil {
+		updated = *n.UpdatedAt
+	}
+	images := Images(n)
+	page := Page{
+		Title:       n.Title,
+		Description: excerpt(n.Content, descriptionLength),
+		URL:         url,
+		Images:      images,
+		Notebook:    notebookPath(n.Path),
+		Tags:        n.Tags,
+		Body:        Body(n, custom),
+		Created:     n.CreatedAt,
+		Updated:     updated,
+		Edited:      n.UpdatedAt != nil,
+	}
+	if len(images) > 0 {
+		page.Image = images[0]
+	}
+	return page
+}
+
+// Meta summarizes where the note lives and when it was written, for the
+// print view.
+func (p Page) Meta() string {
+	var parts []string
+	if p.Notebook != "" {
+		parts = append(parts, p.Notebook)
+	}
+	parts = append(parts, "Создано "+p.Created.Format("02.01.2006 15:04"))
+	if p.Edited {
+		parts = append(parts, "Изменено "+p.Updated.Format("02.01.2006 15:04"))
+	}
+	for _, tag := range p.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// MetaIn reports whether the metadata line goes at position, one of
+// MetaTop, MetaBottom and MetaNone.
+func (p Page) MetaIn(position string) bool {
+	if p.MetaAt == "" {
+		return position == MetaTop
+	}
+	return p.MetaAt == position
+}
+
+// Print writes the print view of a page.
+func Print(w io.Writer, p Page) error {
+	return pages.ExecuteTemplate(w, "print.html", p)
+}
+
+// Shared writes the page for a public share link.
+func Shared(w io.Writer, p Page) error {
+	return pages.ExecuteTemplate(w, "shared.html", p)
+}
+
+func excerpt(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+// Images returns the URLs of the images in a note, in order.
+func Images(n core.Note) []string {
+	var images []string
+	for _, b := range n.Blocks {
+		if b.Type == core.BlockImage && SafeURL(b.URL) {
+			images = append(images, b.URL)
+		}
+	}
+	if len(n.Blocks) > 0 {
+		return images
+	}
+	for _, line := range strings.Split(n.Content, "\n") {
+		if m := imageLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil && SafeURL(m[2]) {
+			images = append(images, m[2])
+		}
+	}
+	return images
+}
+
+func notebookPath(path []core.NotebookRef) string {
+	names := make([]string, len(path))
+	for i, ref := range path {
+		names[i] = ref.Name
+	}
+	return strings.Join(names, " / ")
+}
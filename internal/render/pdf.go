@@ -0,0 +1,193 @@
+package render
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/pdf"
+)
+
+var (
+	plainLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	plainEmphasis = regexp.MustCompile(`\*\*([^*]+)\*\*|\*([^*]+)\*`)
+)
+
+// item is one printable element of a note.
+type item struct {
+	kind    string // heading, paragraph, code, bullet, ordered, check, quote or image
+	text    string
+	level   int
+	checked bool
+	number  int
+}
+
+// PDF writes the print view of a note as a PDF document set in font,
+// with the header, footer and metadata placement of t if it is not nil.
+func PDF(w io.Writer, n core.Note, font *pdf.Font, t *Template) error {
+	p := NotePage(n, "", nil)
+	if t != nil {
+		if err := t.apply(&p); err != nil {
+			return err
+		}
+	}
+	doc := pdf.New(font)
+	doc.Title = n.Title
+
+	if p.Header != "" {
+		doc.Text(p.Header, pdf.Style{Size: 9, Gray: true})
+		doc.Gap(8)
+	}
+	doc.Text(n.Title, pdf.Style{Size: 20})
+	if p.MetaIn(MetaTop) {
+		doc.Text(p.Meta(), pdf.Style{Size: 9, Gray: true})
+	}
+	doc.Gap(12)
+
+	body := pdf.Style{Size: 11}
+	for _, it := range outline(n) {
+		switch it.kind {
+		case "heading":
+			doc.Gap(6)
+			doc.Text(it.text, pdf.Style{Size: max(16-2*float64(it.level-1), 12)})
+		case "code":
+			doc.Text(it.text, pdf.Style{Size: 9.5, Indent: 12})
+			doc.Gap(4)
+		case "bullet":
+			doc.Text(it.text, pdf.Style{Size: body.Size, Indent: 12, Marker: "• "})
+		case "ordered":
+			doc.Text(it.text, pdf.Style{Size: body.Size, Indent: 12, Marker: strconv.Itoa(it.number) + ". "})
+		case "check":
+			marker := "☐ "
+			if it.checked {
+				marker = "☑ "
+			}
+			doc.Text(it.text, pdf.Style{Size: body.Size, Indent: 12, Marker: marker})
+		case "quote":
+			doc.Text(it.text, pdf.Style{Size: body.Size, Indent: 16, Gray: true})
+		case "image":
+			doc.Text(it.text, pdf.Style{Size: body.Size, Gray: true, Marker: "[изображение] "})
+		default:
+			doc.Text(it.text, body)
+			doc.Gap(4)
+		}
+	}
+
+	if len(p.Images) > 0 {
+		doc.Gap(12)
+		doc.Text("Изображения", pdf.Style{Size: 12})
+		for i, url := range p.Images {
+			doc.Text(url, pdf.Style{Size: 9, Indent: 12, Marker: strconv.Itoa(i+1) + ". "})
+		}
+	}
+	if p.MetaIn(MetaBottom) {
+		doc.Gap(12)
+		doc.Text(p.Meta(), pdf.Style{Size: 9, Gray: true})
+	}
+	if p.Footer != "" {
+		doc.Gap(12)
+		doc.Text(p.Footer, pdf.Style{Size: 9, Gray: true})
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// outline flattens a note into printable items: its blocks, or its
+// Markdown content read the same way Markdown does.
+func outline(n core.Note) []item {
+	if n.Type == core.NoteTypeSnippet {
+		return []item{{kind: "code", text: n.Content}}
+	}
+
+	var items []item
+	if len(n.Blocks) > 0 {
+		for _, b := range n.Blocks {
+			switch b.Type {
+			case core.BlockHeading:
+				items = append(items, item{kind: "heading", text: b.Text, level: b.Level})
+			case core.BlockCode:
+				items = append(items, item{kind: "code", text: b.Text})
+			case core.BlockChecklist:
+				for _, ci := range b.Items {
+					items = append(items, item{kind: "check", text: ci.Text, checked: ci.Checked})
+				}
+			case core.BlockImage:
+				items = append(items, item{kind: "image", text: imageText(b.Alt, b.URL)})
+			default:
+				items = append(items, item{kind: "paragraph", text: b.Text})
+			}
+		}
+		return items
+	}
+
+	lines := strings.Split(strings.ReplaceAll(n.Content, "\r\n", "\n"), "\n")
+	var para []string
+	number := 0
+	flush := func() {
+		if len(para) > 0 {
+			items = append(items, item{kind: "paragraph", text: strings.Join(para, "\n")})
+			para = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if _, ok := strings.CutPrefix(trimmed, "```"); ok {
+			flush()
+			var code []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+				code = append(code, lines[i])
+			}
+			items = append(items, item{kind: "code", text: strings.Join(code, "\n")})
+			continue
+		}
+
+		m := matchAny(trimmed)
+		if m.ordered == nil {
+			number = 0
+		}
+		switch {
+		case trimmed == "":
+			flush()
+		case m.heading != nil:
+			flush()
+			items = append(items, item{kind: "heading", text: plain(m.heading[2]), level: len(m.heading[1])})
+		case m.checklist != nil:
+			flush()
+			items = append(items, item{kind: "check", text: plain(m.checklist[2]), checked: m.checklist[1] != " "})
+		case m.bullet != nil:
+			flush()
+			items = append(items, item{kind: "bullet", text: plain(m.bullet[1])})
+		case m.ordered != nil:
+			flush()
+			number++
+			items = append(items, item{kind: "ordered", text: plain(m.ordered[1]), number: number})
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			items = append(items, item{kind: "quote", text: plain(strings.TrimSpace(trimmed[1:]))})
+		case m.image != nil:
+			flush()
+			items = append(items, item{kind: "image", text: imageText(m.image[1], m.image[2])})
+		default:
+			para = append(para, plain(trimmed))
+		}
+	}
+	flush()
+	return items
+}
+
+// plain drops inline Markdown, keeping link targets readable on paper.
+func plain(s string) string {
+	s = plainLink.ReplaceAllString(s, "$1 ($2)")
+	s = plainEmphasis.ReplaceAllString(s, "$1$2")
+	return strings.ReplaceAll(s, "`", "")
+}
+
+func imageText(alt, url string) string {
+	if alt == "" {
+		return url
+	}
+	return alt + " (" + url + ")"
+}
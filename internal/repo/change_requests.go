@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrChangeRequestNotFound = core.NotFound("change request not found")
+	ErrChangeDecided         = core.Conflict("change request is already decided")
+)
+
+type ChangeRequestRepoMem struct {
+	// Clock stamps creation and decision times.
+	Clock clock.Clock
+
+	mu       sync.RWMutex
+	requests map[int64]*core.ChangeRequest
+	next     int64
+}
+
+func NewChangeRequestRepoMem() *ChangeRequestRepoMem {
+	return &ChangeRequestRepoMem{
+		requests: make(map[int64]*core.ChangeRequest),
+		next:     1,
+		Clock:    clock.System{},
+	}
+}
+
+// Create stores cr as a new pending request.
+func (r *ChangeRequestRepoMem) Create(cr core.ChangeRequest) core.ChangeRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cr.ID = r.next
+	cr.Status = core.ChangePending
+	cr.CreatedAt = r.Clock.Now()
+	cr.DecidedBy, cr.DecidedAt, cr.Reason = "", nil, ""
+	r.requests[cr.ID] = &cr
+	r.next++
+	return cloneChangeRequest(cr)
+}
+
+func (r *ChangeRequestRepoMem) GetByID(id int64) (*core.ChangeRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cr, exists := r.requests[id]
+	if !exists {
+		return nil, ErrChangeRequestNotFound
+	}
+	crCopy := cloneChangeRequest(*cr)
+	return &crCopy, nil
+}
+
+// List returns the requests keep accepts, newest first.
+func (r *ChangeRequestRepoMem) List(keep func(core.ChangeRequest) bool) []core.ChangeRequest {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.ChangeRequest
+	for _, cr := range r.requests {
+		if keep(*cr) {
+			list = append(list, cloneChangeRequest(*cr))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// Decide approves or rejects a pending request on behalf of by. apply,
+// when set, runs first with the request locked, so that a change is
+// applied once however many times it is approved at the same time; the
+// request stays pending when apply fails.
+func (r *ChangeRequestRepoMem) Decide(id int64, status, by, reason string, apply func(core.ChangeRequest) error) (core.ChangeRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cr, exists := r.requests[id]
+	if !exists {
+		return core.ChangeRequest{}, ErrChangeRequestNotFound
+	}
+	if cr.Status != core.ChangePending {
+		return core.ChangeRequest{}, ErrChangeDecided
+	}
+	if apply != nil {
+		if err := apply(cloneChangeRequest(*cr)); err != nil {
+			return core.ChangeRequest{}, err
+		}
+	}
+	now := r.Clock.Now()
+	cr.Status, cr.DecidedBy, cr.DecidedAt, cr.Reason = status, by, &now, reason
+	return cloneChangeRequest(*cr), nil
+}
+
+func cloneChangeRequest(cr core.ChangeRequest) core.ChangeRequest {
+	changes := make(map[string]interface{}, len(cr.Changes))
+	for k, v := range cr.Changes {
+		changes[k] = v
+	}
+	cr.Changes = changes
+	return cr
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+)
+
+// ChangeLog retains recent changes so that consumers can resume from a
+// sequence number after a disconnect. Register Append with OnChange.
+type ChangeLog struct {
+	mu       sync.Mutex
+	capacity int
+	changes  []Change
+	// dropped is the Seq of the newest change no longer retained.
+	dropped int64
+	wake    chan struct{}
+}
+
+// NewChangeLog returns a log keeping at least the last capacity changes.
+func NewChangeLog(capacity int) *ChangeLog {
+	return &ChangeLog{capacity: capacity, wake: make(chan struct{})}
+}
+
+func (l *ChangeLog) Append(c Change) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.changes = append(l.changes, c)
+	if len(l.changes) > 2*l.capacity {
+		cut := len(l.changes) - l.capacity
+		l.dropped = l.changes[cut-1].Seq
+		l.changes = append([]Change(nil), l.changes[cut:]...)
+	}
+
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// Since returns the retained changes after seq and a channel that is
+// closed when the next change arrives. ok is false when some changes after
+// seq are no longer retained.
+func (l *ChangeLog) Since(seq int64) (changes []Change, wait <-chan struct{}, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if seq < l.dropped {
+		return nil, nil, false
+	}
+	i := sort.Search(len(l.changes), func(i int) bool { return l.changes[i].Seq > seq })
+	return append([]Change(nil), l.changes[i:]...), l.wake, true
+}
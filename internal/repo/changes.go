@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"sort"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change describes one mutation of a note. Note is the state after the
+// change, or the last state for deletions. Seq numbers every change of the
+// repository in order, starting at 1.
+type Change struct {
+	Seq  int64
+	Op   string
+	Note core.Note
+	At   time.Time
+}
+
+// OnChange registers fn to be called for every note mutation, in the order
+// the mutations happen. fn runs with the repository locked, so it must not
+// block or call back into the repository.
+func (r *NoteRepoMem) OnChange(fn func(Change)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, fn)
+}
+
+// emit must be called with the write lock held.
+func (r *NoteRepoMem) emit(op string, n *core.Note) {
+	r.seq++
+	if len(r.listeners) == 0 {
+		return
+	}
+
+	c := Change{Seq: r.seq, Op: op, Note: *n, At: r.Clock.Now()}
+	c.Note.Tags = append([]string(nil), n.Tags...)
+	c.Note.Blocks = append([]core.Block(nil), n.Blocks...)
+	for _, fn := range r.listeners {
+		fn(c)
+	}
+}
+
+// Snapshot returns every note along with the sequence number of the last
+// change they include, so that a consumer can follow up with the changes
+// after it.
+func (r *NoteRepoMem) Snapshot() ([]core.Note, int64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notes := make([]core.Note, 0, len(r.notes))
+	for _, note := range r.notes {
+		notes = append(notes, *note)
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+
+	return notes, r.seq
+}
@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var ErrCollectionNotFound = core.NotFound("collection not found")
+
+type CollectionRepoMem struct {
+	// Clock stamps creation and update times.
+	Clock clock.Clock
+
+	mu          sync.RWMutex
+	collections map[int64]*core.Collection
+	next        int64
+}
+
+func NewCollectionRepoMem() *CollectionRepoMem {
+	return &CollectionRepoMem{
+		collections: make(map[int64]*core.Collection),
+		next:        1,
+		Clock:       clock.System{},
+	}
+}
+
+func (r *CollectionRepoMem) Create(c core.Collection) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.ID = r.next
+	c.CreatedAt = r.Clock.Now()
+	c.UpdatedAt = nil
+	r.collections[c.ID] = &c
+	r.next++
+
+	return c.ID, nil
+}
+
+func (r *CollectionRepoMem) GetByID(id int64) (*core.Collection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.collections[id]
+	if !exists {
+		return nil, ErrCollectionNotFound
+	}
+
+	cCopy := *c
+	return &cCopy, nil
+}
+
+func (r *CollectionRepoMem) GetAll() ([]core.Collection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	collections := make([]core.Collection, 0, len(r.collections))
+	for _, c := range r.collections {
+		collections = append(collections, *c)
+	}
+
+	sort.Slice(collections, func(i, j int) bool { return collections[i].ID < collections[j].ID })
+
+	return collections, nil
+}
+
+func (r *CollectionRepoMem) Update(id int64, name *string, schema *[]core.PropertyDef) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.collections[id]
+	if !exists {
+		return ErrCollectionNotFound
+	}
+
+	if name != nil {
+		c.Name = *name
+	}
+	if schema != nil {
+		// Replace rather than modify: copies handed out share the old slice.
+		c.Schema = append([]core.PropertyDef(nil), (*schema)...)
+	}
+
+	now := r.Clock.Now()
+	c.UpdatedAt = &now
+
+	return nil
+}
+
+func (r *CollectionRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.collections[id]; !exists {
+		return ErrCollectionNotFound
+	}
+
+	delete(r.collections, id)
+	return nil
+}
@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var ErrCommentNotFound = core.NotFound("comment not found")
+
+// CommentRepoMem keeps the comment threads of notes.
+type CommentRepoMem struct {
+	// Clock stamps creation, edit and resolution times.
+	Clock clock.Clock
+
+	mu       sync.RWMutex
+	comments map[int64]*core.Comment
+	next     int64
+}
+
+func NewCommentRepoMem() *CommentRepoMem {
+	return &CommentRepoMem{
+		comments: make(map[int64]*core.Comment),
+		next:     1,
+		Clock:    clock.System{},
+	}
+}
+
+// Create stores c as a new comment. A reply to a reply joins the thread
+// of the comment it answers.
+func (r *CommentRepoMem) Create(c core.Comment) (core.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.ReplyTo != 0 {
+		parent, exists := r.comments[c.ReplyTo]
+		if !exists || parent.NoteID != c.NoteID {
+			return core.Comment{}, core.Invalid("reply_to", "reply_to is not a comment on this note")
+		}
+		if parent.ReplyTo != 0 {
+			c.ReplyTo = parent.ReplyTo
+		}
+	}
+	c.ID = r.next
+	c.CreatedAt = r.Clock.Now()
+	c.UpdatedAt = nil
+	c.Resolved, c.ResolvedBy, c.ResolvedAt = false, "", nil
+	r.comments[c.ID] = &c
+	r.next++
+	return c, nil
+}
+
+func (r *CommentRepoMem) GetByID(id int64) (*core.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.comments[id]
+	if !exists {
+		return nil, ErrCommentNotFound
+	}
+	cCopy := *c
+	return &cCopy, nil
+}
+
+// ListByNote returns the comments of a note, oldest first.
+func (r *CommentRepoMem) ListByNote(noteID int64) []core.Comment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.Comment
+	for _, c := range r.comments {
+		if c.NoteID == noteID {
+			list = append(list, *c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Edit replaces the body of a comment.
+func (r *CommentRepoMem) Edit(id int64, body string) (core.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.comments[id]
+	if !exists {
+		return core.Comment{}, ErrCommentNotFound
+	}
+	now := r.Clock.Now()
+	c.Body, c.UpdatedAt = body, &now
+	return *c, nil
+}
+
+// Resolve marks the thread a comment starts as resolved by by, or reopens
+// it.
+func (r *CommentRepoMem) Resolve(id int64, resolved bool, by string) (core.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.comments[id]
+	if !exists {
+		return core.Comment{}, ErrCommentNotFound
+	}
+	if c.ReplyTo != 0 {
+		return core.Comment{}, core.Invalid("resolved", "only the first comment of a thread can be resolved")
+	}
+	if c.Resolved == resolved {
+		return *c, nil
+	}
+	c.Resolved, c.ResolvedBy, c.ResolvedAt = false, "", nil
+	if resolved {
+		now := r.Clock.Now()
+		c.Resolved, c.ResolvedBy, c.ResolvedAt = true, by, &now
+	}
+	return *c, nil
+}
+
+// Delete removes a comment, and with the first comment of a thread its
+// replies.
+func (r *CommentRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.comments[id]; !exists {
+		return ErrCommentNotFound
+	}
+	delete(r.comments, id)
+	for rid, c := range r.comments {
+		if c.ReplyTo == id {
+			delete(r.comments, rid)
+		}
+	}
+	return nil
+}
+
+// Apply drops the comments of deleted notes.
+func (r *CommentRepoMem) Apply(c Change) {
+	if c.Op != ChangeDeleted {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, comment := range r.comments {
+		if comment.NoteID == c.Note.ID {
+			delete(r.comments, id)
+		}
+	}
+}
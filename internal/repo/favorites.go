@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FavoriteRepoMem keeps the notes each user marked as favorite. Unlike
+// pins, favorites belong to the user, so anyone can favorite a note shared
+// with them.
+type FavoriteRepoMem struct {
+	mu    sync.RWMutex
+	users map[string]map[int64]time.Time
+}
+
+func NewFavoriteRepoMem() *FavoriteRepoMem {
+	return &FavoriteRepoMem{users: make(map[string]map[int64]time.Time)}
+}
+
+// Add marks a note as a favorite of userID at t; a note already marked
+// keeps its time.
+func (r *FavoriteRepoMem) Add(userID string, noteID int64, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.users[userID] == nil {
+		r.users[userID] = make(map[int64]time.Time)
+	}
+	if _, ok := r.users[userID][noteID]; !ok {
+		r.users[userID][noteID] = t
+	}
+}
+
+func (r *FavoriteRepoMem) Remove(userID string, noteID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users[userID], noteID)
+	if len(r.users[userID]) == 0 {
+		delete(r.users, userID)
+	}
+}
+
+// Has reports whether a note is a favorite of userID.
+func (r *FavoriteRepoMem) Has(userID string, noteID int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.users[userID][noteID]
+	return ok
+}
+
+// List returns the IDs of the favorites of userID, most recently marked
+// first.
+func (r *FavoriteRepoMem) List(userID string) []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	favorites := r.users[userID]
+	ids := make([]int64, 0, len(favorites))
+	for id := range favorites {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := favorites[ids[i]], favorites[ids[j]]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return ids[i] > ids[j]
+	})
+	return ids
+}
+
+// Apply forgets deleted notes for every user.
+func (r *FavoriteRepoMem) Apply(c Change) {
+	if c.Op != ChangeDeleted {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, favorites := range r.users {
+		delete(favorites, c.Note.ID)
+		if len(favorites) == 0 {
+			delete(r.users, userID)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var ErrInboundHookNotFound = core.NotFound("inbound hook not found")
+
+type InboundHookRepoMem struct {
+	// Clock stamps creation times.
+	Clock clock.Clock
+
+	mu      sync.RWMutex
+	hooks   map[int64]*core.InboundHook
+	byToken map[string]int64
+	next    int64
+}
+
+func NewInboundHookRepoMem() *InboundHookRepoMem {
+	return &InboundHookRepoMem{
+		hooks:   make(map[int64]*core.InboundHook),
+		byToken: make(map[string]int64),
+		next:    1,
+		Clock:   clock.System{},
+	}
+}
+
+// Create stores h under a new ID and a new random token.
+func (r *InboundHookRepoMem) Create(h core.InboundHook) core.InboundHook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b [18]byte
+	rand.Read(b[:])
+	h.ID = r.next
+	h.Token = base64.RawURLEncoding.EncodeToString(b[:])
+	h.CreatedAt = r.Clock.Now()
+	h.LastUsedAt = nil
+	r.hooks[h.ID] = &h
+	r.byToken[h.Token] = h.ID
+	r.next++
+	return h
+}
+
+func (r *InboundHookRepoMem) GetByID(id int64) (*core.InboundHook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, exists := r.hooks[id]
+	if !exists {
+		return nil, ErrInboundHookNotFound
+	}
+	hCopy := *h
+	return &hCopy, nil
+}
+
+// ByToken returns the hook of a token and marks it used at now.
+func (r *InboundHookRepoMem) ByToken(token string, now time.Time) (*core.InboundHook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, exists := r.hooks[r.byToken[token]]
+	if !exists {
+		return nil, ErrInboundHookNotFound
+	}
+	h.LastUsedAt = &now
+	hCopy := *h
+	return &hCopy, nil
+}
+
+// ListFor returns the hooks of ownerID by ID.
+func (r *InboundHookRepoMem) ListFor(ownerID string) []core.InboundHook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.InboundHook
+	for _, h := range r.hooks {
+		if h.OwnerID == ownerID {
+			list = append(list, *h)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (r *InboundHookRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, exists := r.hooks[id]
+	if !exists {
+		return ErrInboundHookNotFound
+	}
+	delete(r.byToken, h.Token)
+	delete(r.hooks, id)
+	return nil
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"sort"
+
+	"example.com/notes-api/internal/core"
+)
+
+// GetJournal returns the owner's journal note for date, or nil if there
+// is none.
+func (r *NoteRepoMem) GetJournal(ownerID, date string) (*core.Note, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if note := r.journalNote(ownerID, date); note != nil {
+		noteCopy := *note
+		return &noteCopy, nil
+	}
+	return nil, nil
+}
+
+// GetOrCreateJournal returns the journal note of n.OwnerID for
+// n.JournalDate, creating n
+// if it does not exist yet. The check and the insert share one lock, so
+// concurrent calls for the same day produce a single note.
+func (r *NoteRepoMem) GetOrCreateJournal(n core.Note) (*core.Note, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if note := r.journalNote(n.OwnerID, n.JournalDate); note != nil {
+		noteCopy := *note
+		return &noteCopy, false, nil
+	}
+
+	noteCopy := *r.insert(n)
+	return &noteCopy, true, nil
+}
+
+// ListJournal returns the owner's journal notes between from and to
+// inclusive, newest first. Empty bounds are open.
+func (r *NoteRepoMem) ListJournal(ownerID, from, to string) ([]core.Note, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notes []core.Note
+	for _, note := range r.notes {
+		if note.JournalDate == "" || note.OwnerID != ownerID {
+			continue
+		}
+		if (from != "" && note.JournalDate < from) || (to != "" && note.JournalDate > to) {
+			continue
+		}
+		notes = append(notes, *note)
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].JournalDate > notes[j].JournalDate
+	})
+
+	return notes, nil
+}
+
+// journalNote must be called with the lock held.
+func (r *NoteRepoMem) journalNote(ownerID, date string) *core.Note {
+	for _, note := range r.notes {
+		if note.JournalDate == date && note.OwnerID == ownerID {
+			return note
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+// LastModified tracks when each user's visible set of notes last changed.
+// Notes in notebooks can be visible to anyone through shares, so changes
+// to them, like notebook changes, count for every user.
+type LastModified struct {
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	owners   map[string]time.Time
+	shared   time.Time
+	all      time.Time
+	notebook map[int64]int64
+}
+
+func NewLastModified() *LastModified {
+	c := clock.System{}
+	now := c.Now()
+	return &LastModified{
+		Clock:    c,
+		owners:   make(map[string]time.Time),
+		shared:   now,
+		all:      now,
+		notebook: make(map[int64]int64),
+	}
+}
+
+// Apply records a note change. Register it with NoteRepoMem.OnChange.
+func (l *LastModified) Apply(c Change) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.owners[c.Note.OwnerID] = c.At
+	l.all = c.At
+	// A note moved out of a notebook disappears for its readers too.
+	if c.Note.NotebookID != 0 || l.notebook[c.Note.ID] != 0 {
+		l.shared = c.At
+	}
+
+	if c.Op == ChangeDeleted || c.Note.NotebookID == 0 {
+		delete(l.notebook, c.Note.ID)
+	} else {
+		l.notebook[c.Note.ID] = c.Note.NotebookID
+	}
+}
+
+// Touch records a change that can affect every user, such as a new share.
+// Register it with NotebookRepoMem.OnChange.
+func (l *LastModified) Touch() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Clock.Now()
+	l.shared = now
+	l.all = now
+}
+
+// For returns when the notes visible to p last changed.
+func (l *LastModified) For(p core.Principal) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if p.Admin {
+		return l.all
+	}
+	if t := l.owners[p.UserID]; t.After(l.shared) {
+		return t
+	}
+	return l.shared
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"fmt"
+
+	"example.com/notes-api/internal/core"
+)
+
+// EffectiveLifecycle returns Lifecycle, or core.DefaultLifecycle when it
+// is empty.
+func (r *NoteRepoMem) EffectiveLifecycle() core.Lifecycle {
+	return r.Lifecycle.OrDefault()
+}
+
+// Transition moves the note to state to on behalf of userID, when the
+// lifecycle allows it from the state the note is in. Unknown states are
+// invalid, moves the lifecycle has no transition for are
+// ErrInvalidTransition, and a note someone else has locked is a
+// *LockedError.
+func (r *NoteRepoMem) Transition(userID string, id int64, to string) (*core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return nil, ErrNoteNotFound
+	}
+	if err := r.checkLock(id, userID); err != nil {
+		return nil, err
+	}
+	lifecycle := r.EffectiveLifecycle()
+	if !lifecycle.Has(to) {
+		return nil, core.Invalid("to", "unknown state")
+	}
+	from := note.State
+	if from == to {
+		noteCopy := *note
+		return &noteCopy, nil
+	}
+	// Notes stored before the lifecycle changed may be in a state it no
+	// longer has; they start over.
+	if !lifecycle.Has(from) {
+		from = lifecycle.Initial()
+	}
+	if from != to && !lifecycle.Allows(from, to) {
+		return nil, fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
+	}
+
+	note.State = to
+	now := r.Clock.Now()
+	note.UpdatedAt = &now
+	r.emit(ChangeUpdated, note)
+
+	noteCopy := *note
+	return &noteCopy, nil
+}
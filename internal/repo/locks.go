@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+// DefaultLockTTL is how long an edit lock lasts without a heartbeat.
+const DefaultLockTTL = 2 * time.Minute
+
+var ErrLockNotFound = core.NotFound("note is not locked")
+
+// LockedError is returned when someone other than the caller holds the
+// edit lock of a note.
+type LockedError struct {
+	Lock core.NoteLock
+}
+
+func (e *LockedError) Error() string {
+	return "note is locked by " + e.Lock.HolderID
+}
+
+func (e *LockedError) Unwrap() error { return core.ErrConflict }
+
+// LockRepoMem keeps the edit locks of notes. Expired locks are treated as
+// released.
+type LockRepoMem struct {
+	// Clock stamps and expires locks.
+	Clock clock.Clock
+	// TTL is how long a lock lasts; zero uses DefaultLockTTL.
+	TTL time.Duration
+
+	mu    sync.Mutex
+	locks map[int64]core.NoteLock
+}
+
+func NewLockRepoMem() *LockRepoMem {
+	return &LockRepoMem{Clock: clock.System{}, locks: make(map[int64]core.NoteLock)}
+}
+
+// Acquire locks a note for userID, or extends the lock userID already
+// holds.
+func (r *LockRepoMem) Acquire(noteID int64, userID string) (core.NoteLock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.Clock.Now()
+	l, held := r.held(noteID, now)
+	if held && l.HolderID != userID {
+		return core.NoteLock{}, &LockedError{Lock: l}
+	}
+	if !held {
+		l = core.NoteLock{NoteID: noteID, HolderID: userID, AcquiredAt: now}
+	}
+	ttl := r.TTL
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+	l.ExpiresAt = now.Add(ttl)
+	r.locks[noteID] = l
+	return l, nil
+}
+
+// Get returns the lock of a note, if someone holds it.
+func (r *LockRepoMem) Get(noteID int64) (core.NoteLock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, held := r.held(noteID, r.Clock.Now())
+	if !held {
+		return core.NoteLock{}, ErrLockNotFound
+	}
+	return l, nil
+}
+
+// Check returns a *LockedError when someone other than userID holds the
+// lock of a note.
+func (r *LockRepoMem) Check(noteID int64, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, held := r.held(noteID, r.Clock.Now()); held && l.HolderID != userID {
+		return &LockedError{Lock: l}
+	}
+	return nil
+}
+
+// Release unlocks a note on behalf of userID, who must hold the lock
+// unless force is set.
+func (r *LockRepoMem) Release(noteID int64, userID string, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, held := r.held(noteID, r.Clock.Now())
+	if !held {
+		return ErrLockNotFound
+	}
+	if l.HolderID != userID && !force {
+		return &LockedError{Lock: l}
+	}
+	delete(r.locks, noteID)
+	return nil
+}
+
+// held returns the lock of a note unless it expired by now, dropping
+// expired ones. The caller holds r.mu.
+func (r *LockRepoMem) held(noteID int64, now time.Time) (core.NoteLock, bool) {
+	l, ok := r.locks[noteID]
+	if ok && !now.Before(l.ExpiresAt) {
+		delete(r.locks, noteID)
+		return core.NoteLock{}, false
+	}
+	return l, ok
+}
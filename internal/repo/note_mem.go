@@ -1,27 +1,77 @@
 package repo
 
 import (
-	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"example.com/notes-api/internal/clock"
 	"example.com/notes-api/internal/core"
 )
 
 var (
-	ErrNoteNotFound = errors.New("note not found")
+	ErrNoteNotFound = core.NotFound("note not found")
+	ErrNoteExists   = core.Conflict("note already exists")
+	// ErrInvalidTransition is returned for lifecycle moves the lifecycle
+	// does not allow.
+	ErrInvalidTransition = core.Conflict("transition not allowed")
 )
 
+// DuplicateTitleError is returned when a note would share its title with
+// NoteID in a notebook that requires unique titles.
+type DuplicateTitleError struct {
+	NoteID int64
+}
+
+func (e *DuplicateTitleError) Error() string {
+	return "a note titled the same already exists in the notebook"
+}
+
+func (e *DuplicateTitleError) Unwrap() error { return core.ErrConflict }
+
 type NoteRepoMem struct {
+	// Clock stamps creation and update times.
+	Clock clock.Clock
+	// UniqueTitles, when set, reports whether no two notes of a notebook
+	// may share a title, ignoring case and surrounding spaces.
+	UniqueTitles func(notebookID int64) bool
+	// Rand, when set, supplies the random bits of public IDs in place of
+	// crypto/rand, so that seeded data gets the same IDs every time.
+	Rand io.Reader
+	// Lifecycle is the state machine of notes; empty uses
+	// core.DefaultLifecycle.
+	Lifecycle core.Lifecycle
+	// Locks, when set, holds the edit locks of notes. Writes made on
+	// behalf of a user check it under the repository lock, so a lock
+	// cannot be taken between the check and the write.
+	Locks *LockRepoMem
+
 	mu    sync.RWMutex
 	notes map[int64]*core.Note
 	next  int64
+	// public maps public IDs to IDs.
+	public map[string]int64
+	// slugs maps current and former slugs to IDs, so links made before a
+	// rename keep working.
+	slugs map[string]int64
+	// shares maps public link tokens to IDs.
+	shares map[string]int64
+
+	seq       int64
+	listeners []func(Change)
 }
 
 func NewNoteRepoMem() *NoteRepoMem {
 	return &NoteRepoMem{
-		notes: make(map[int64]*core.Note),
-		next:  1,
+		notes:  make(map[int64]*core.Note),
+		next:   1,
+		public: make(map[string]int64),
+		slugs:  make(map[string]int64),
+		shares: make(map[string]int64),
+		Clock:  clock.System{},
 	}
 }
 
@@ -29,13 +79,93 @@ func (r *NoteRepoMem) Create(n core.Note) (int64, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.checkTitle(n.NotebookID, n.Title); err != nil {
+		return 0, err
+	}
+	return r.insert(n).ID, nil
+}
+
+// CheckTitle returns the *DuplicateTitleError Create would return for a
+// note titled title in notebookID, if any.
+func (r *NoteRepoMem) CheckTitle(notebookID int64, title string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.checkTitle(notebookID, title)
+}
+
+// checkTitle must be called with the lock held.
+func (r *NoteRepoMem) checkTitle(notebookID int64, title string) error {
+	if taken := r.titlesIn(notebookID); taken != nil {
+		if id, ok := taken[foldTitle(title)]; ok {
+			return &DuplicateTitleError{NoteID: id}
+		}
+	}
+	return nil
+}
+
+// titlesIn maps the folded titles of the notes in notebookID to their
+// IDs, the lowest when titles repeat, or returns nil when the notebook
+// allows repeated titles. It must be called with the lock held.
+func (r *NoteRepoMem) titlesIn(notebookID int64) map[string]int64 {
+	if r.UniqueTitles == nil || !r.UniqueTitles(notebookID) {
+		return nil
+	}
+	taken := make(map[string]int64)
+	for _, n := range r.notes {
+		if n.NotebookID != notebookID {
+			continue
+		}
+		key := foldTitle(n.Title)
+		if id, ok := taken[key]; !ok || n.ID < id {
+			taken[key] = n.ID
+		}
+	}
+	return taken
+}
+
+func foldTitle(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// checkRename returns a *DuplicateTitleError when updates would give note
+// the title of another note of its notebook. It must be called with the
+// lock held.
+func (r *NoteRepoMem) checkRename(note *core.Note, updates map[string]interface{}) error {
+	title, ok := updates["title"].(string)
+	if !ok || title == "" {
+		return nil
+	}
+	if taken := r.titlesIn(note.NotebookID); taken != nil {
+		if id, ok := taken[foldTitle(title)]; ok && id != note.ID {
+			return &DuplicateTitleError{NoteID: id}
+		}
+	}
+	return nil
+}
+
+// insert must be called with the write lock held.
+func (r *NoteRepoMem) insert(n core.Note) *core.Note {
 	n.ID = r.next
-	n.CreatedAt = time.Now()
+	n.Position = r.nextPosition(n.NotebookID)
+	n.CreatedAt = r.Clock.Now()
+	if r.Rand != nil {
+		n.PublicID = core.PublicID(n.CreatedAt, r.Rand)
+	} else {
+		n.PublicID = core.NewPublicID()
+	}
 	n.UpdatedAt = nil
+	// Imported notes may come with a state of another lifecycle.
+	if lifecycle := r.Lifecycle.OrDefault(); !lifecycle.Has(n.State) {
+		n.State = lifecycle.Initial()
+	}
 	r.notes[n.ID] = &n
+	r.public[n.PublicID] = n.ID
+	r.assignSlug(&n)
 	r.next++
+	r.emit(ChangeCreated, &n)
 
-	return n.ID, nil
+	return &n
 }
 
 func (r *NoteRepoMem) GetByID(id int64) (*core.Note, error) {
@@ -51,7 +181,52 @@ func (r *NoteRepoMem) GetByID(id int64) (*core.Note, error) {
 	return &noteCopy, nil
 }
 
-func (r *NoteRepoMem) GetAll() ([]core.Note, error) {
+// Resolve returns the ID of the note with the given public ID.
+func (r *NoteRepoMem) Resolve(publicID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.public[strings.ToLower(publicID)]
+	if !exists {
+		return 0, ErrNoteNotFound
+	}
+	return id, nil
+}
+
+// ResolveSlug returns the ID of the note with the given slug, current or
+// former.
+func (r *NoteRepoMem) ResolveSlug(slug string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.slugs[slug]
+	if !exists {
+		return 0, ErrNoteNotFound
+	}
+	return id, nil
+}
+
+// assignSlug derives the slug of n from its title, numbering it when
+// another note holds the slug. It must be called with the write lock held.
+func (r *NoteRepoMem) assignSlug(n *core.Note) {
+	n.Slug = r.slugFor(n)
+	r.slugs[n.Slug] = n.ID
+}
+
+// slugFor returns the slug assignSlug would give n. It must be called
+// with the lock held.
+func (r *NoteRepoMem) slugFor(n *core.Note) string {
+	base := core.Slugify(n.Title)
+	slug := base
+	for i := 2; ; i++ {
+		if id, taken := r.slugs[slug]; !taken || id == n.ID {
+			return slug
+		}
+		slug = base + "-" + strconv.Itoa(i)
+	}
+}
+
+func (r *NoteRepoMem) List() ([]core.Note, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -60,18 +235,111 @@ func (r *NoteRepoMem) GetAll() ([]core.Note, error) {
 		notes = append(notes, *note)
 	}
 
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+
 	return notes, nil
 }
 
+// Nearby returns geotagged notes within radius meters of the point,
+// closest first.
+func (r *NoteRepoMem) Nearby(lat, lon, radius float64) ([]core.NearbyNote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found []core.NearbyNote
+	for _, note := range r.notes {
+		if note.Latitude == nil || note.Longitude == nil {
+			continue
+		}
+		d := core.Haversine(lat, lon, *note.Latitude, *note.Longitude)
+		if d <= radius {
+			found = append(found, core.NearbyNote{Note: *note, DistanceMeters: d})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].DistanceMeters < found[j].DistanceMeters
+	})
+
+	return found, nil
+}
+
 func (r *NoteRepoMem) UpdatePartial(id int64, updates map[string]interface{}) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	return r.update(id, updates)
+}
+
+// UpdateAs is UpdatePartial on behalf of userID. It returns a
+// *LockedError when someone else holds the edit lock of the note; an
+// empty userID, for writes nobody made by hand, yields to every lock.
+func (r *NoteRepoMem) UpdateAs(userID string, id int64, updates map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkLock(id, userID); err != nil {
+		return err
+	}
+	return r.update(id, updates)
+}
+
+// checkLock returns a *LockedError when someone other than userID holds
+// the edit lock of a note. It must be called with the lock held.
+func (r *NoteRepoMem) checkLock(id int64, userID string) error {
+	if r.Locks == nil {
+		return nil
+	}
+	return r.Locks.Check(id, userID)
+}
+
+func (r *NoteRepoMem) update(id int64, updates map[string]interface{}) error {
 	note, exists := r.notes[id]
 	if !exists {
 		return ErrNoteNotFound
 	}
+	if err := r.checkRename(note, updates); err != nil {
+		return err
+	}
 
+	r.apply(note, updates)
+	if title, ok := updates["title"].(string); ok && title != "" {
+		r.assignSlug(note)
+	}
+	now := r.Clock.Now()
+	note.UpdatedAt = &now
+	r.emit(ChangeUpdated, note)
+
+	return nil
+}
+
+// PreviewUpdate returns the note as UpdatePartial would leave it, without
+// changing it.
+func (r *NoteRepoMem) PreviewUpdate(id int64, updates map[string]interface{}) (*core.Note, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return nil, ErrNoteNotFound
+	}
+	if err := r.checkRename(note, updates); err != nil {
+		return nil, err
+	}
+
+	preview := *note
+	r.apply(&preview, updates)
+	if title, ok := updates["title"].(string); ok && title != "" {
+		preview.Slug = r.slugFor(&preview)
+	}
+	now := r.Clock.Now()
+	preview.UpdatedAt = &now
+	return &preview, nil
+}
+
+// apply writes updates to note, all but its slug. It must be called with
+// the lock held.
+func (r *NoteRepoMem) apply(note *core.Note, updates map[string]interface{}) {
 	if title, ok := updates["title"].(string); ok && title != "" {
 		note.Title = title
 	}
@@ -80,20 +348,111 @@ func (r *NoteRepoMem) UpdatePartial(id int64, updates map[string]interface{}) er
 		note.Content = content
 	}
 
-	now := time.Now()
-	note.UpdatedAt = &now
+	if language, ok := updates["language"].(string); ok && note.Type == core.NoteTypeSnippet {
+		note.Language = language
+	}
 
-	return nil
+	if lat, ok := updates["latitude"].(float64); ok {
+		if lon, ok := updates["longitude"].(float64); ok {
+			note.Latitude, note.Longitude = &lat, &lon
+		}
+	}
+
+	if tags, ok := updates["tags"].([]string); ok {
+		note.Tags = tags
+	}
+
+	if pinned, ok := updates["pinned"].(bool); ok {
+		note.Pinned = pinned
+	}
+
+	if remindAt, ok := updates["remind_at"].(time.Time); ok {
+		note.RemindAt = &remindAt
+	}
+
+	if color, ok := updates["color"].(string); ok {
+		note.Color = color
+	}
+
+	// Properties are replaced, never modified in place: earlier copies of
+	// the note share the old map.
+	if props, ok := updates["properties"].(map[string]interface{}); ok {
+		note.Properties = core.MergeProperties(note.Properties, props)
+	}
+
+	if collectionID, ok := updates["collection_id"].(int64); ok {
+		note.CollectionID = collectionID
+	}
+
+	if blocks, ok := updates["blocks"].([]core.Block); ok {
+		if len(blocks) == 0 {
+			blocks = nil
+		}
+		note.Blocks = blocks
+	}
 }
 
 func (r *NoteRepoMem) Delete(id int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.notes[id]; !exists {
+	return r.delete(id)
+}
+
+// DeleteAs is Delete on behalf of userID, refusing notes someone else
+// has locked as UpdateAs does.
+func (r *NoteRepoMem) DeleteAs(userID string, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkLock(id, userID); err != nil {
+		return err
+	}
+	return r.delete(id)
+}
+
+func (r *NoteRepoMem) delete(id int64) error {
+	note, exists := r.notes[id]
+	if !exists {
 		return ErrNoteNotFound
 	}
 
 	delete(r.notes, id)
+	delete(r.public, note.PublicID)
+	delete(r.shares, note.ShareToken)
+	for slug, noteID := range r.slugs {
+		if noteID == id {
+			delete(r.slugs, slug)
+		}
+	}
+	r.emit(ChangeDeleted, note)
+	return nil
+}
+
+// Restore puts a deleted note back under its ID, public ID and public
+// link, for undoing Delete. Its slug is kept unless another note took it
+// in the meantime; a note that took its title in a notebook with unique
+// titles makes it a *DuplicateTitleError.
+func (r *NoteRepoMem) Restore(n core.Note) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.notes[n.ID]; exists {
+		return ErrNoteExists
+	}
+	if err := r.checkTitle(n.NotebookID, n.Title); err != nil {
+		return err
+	}
+	r.notes[n.ID] = &n
+	r.public[n.PublicID] = n.ID
+	if n.ShareToken != "" {
+		r.shares[n.ShareToken] = n.ID
+	}
+	if id, taken := r.slugs[n.Slug]; !taken || id == n.ID {
+		r.slugs[n.Slug] = n.ID
+	} else {
+		r.assignSlug(&n)
+	}
+	r.emit(ChangeCreated, &n)
 	return nil
 }
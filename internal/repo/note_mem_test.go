@@ -0,0 +1,88 @@
+package repo_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestNoteRepoMemConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.NoteRepository {
+		return repo.NewNoteRepoMem()
+	})
+}
+
+func BenchmarkNoteRepoMem(b *testing.B) {
+	repotest.Bench(b, func(b *testing.B) repotest.NoteRepository {
+		return repo.NewNoteRepoMem()
+	})
+}
+
+func TestNoteRepoMemUniqueTitles(t *testing.T) {
+	r := repo.NewNoteRepoMem()
+	r.UniqueTitles = func(notebookID int64) bool { return notebookID == 1 }
+
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Create(core.Note{NotebookID: 1, Title: "Standup"}); err == nil {
+				created.Add(1)
+			} else if !errors.Is(err, core.ErrConflict) {
+				t.Errorf("Create: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created.Load() != 1 {
+		t.Fatalf("created %d notes titled Standup, want 1", created.Load())
+	}
+
+	_, err := r.Create(core.Note{NotebookID: 1, Title: " standup "})
+	var dup *repo.DuplicateTitleError
+	if !errors.As(err, &dup) || dup.NoteID == 0 {
+		t.Errorf("Create with a folded duplicate = %v", err)
+	}
+	if _, err := r.Create(core.Note{NotebookID: 2, Title: "Standup"}); err != nil {
+		t.Errorf("Create in a notebook without the constraint: %v", err)
+	}
+	other, _ := r.Create(core.Note{NotebookID: 1, Title: "Retro"})
+	if err := r.UpdatePartial(other, map[string]interface{}{"title": "STANDUP"}); !errors.As(err, &dup) {
+		t.Errorf("rename onto a taken title = %v", err)
+	}
+	if err := r.UpdatePartial(other, map[string]interface{}{"title": "Retro"}); err != nil {
+		t.Errorf("rename onto its own title: %v", err)
+	}
+}
+
+func TestNoteRepoMemChangeTags(t *testing.T) {
+	r := repo.NewNoteRepoMem()
+	id, _ := r.Create(core.Note{Title: "Plan"})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tag := string(rune('a' + i))
+			if _, err := r.ChangeTags("", id, func(tags []string) []string { return append(tags, tag) }); err != nil {
+				t.Errorf("ChangeTags: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n, _ := r.GetByID(id); len(n.Tags) != 8 {
+		t.Fatalf("tags = %v, want all 8 concurrent additions", n.Tags)
+	}
+
+	if changed, err := r.ChangeTags("", id, func([]string) []string { return nil }); changed || err != nil {
+		t.Errorf("ChangeTags without a change = %v, %v", changed, err)
+	}
+}
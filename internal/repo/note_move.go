@@ -0,0 +1,130 @@
+package repo
+
+import "example.com/notes-api/internal/core"
+
+// Move files the notes into notebookID on behalf of userID, appending
+// them after its current notes in the given order. Either every note is
+// moved or none is; a note someone else has locked makes it a
+// *LockedError.
+func (r *NoteRepoMem) Move(userID string, ids []int64, notebookID int64) ([]core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		if _, exists := r.notes[id]; !exists {
+			return nil, ErrNoteNotFound
+		}
+		if err := r.checkLock(id, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.checkTransfer(ids, notebookID, false); err != nil {
+		return nil, err
+	}
+
+	now := r.Clock.Now()
+	moved := make([]core.Note, 0, len(ids))
+	for _, id := range ids {
+		note := r.notes[id]
+		if note.NotebookID != notebookID {
+			note.Position = r.nextPosition(notebookID)
+			note.NotebookID = notebookID
+			note.UpdatedAt = &now
+			r.emit(ChangeUpdated, note)
+		}
+		moved = append(moved, *note)
+	}
+
+	return moved, nil
+}
+
+// MoveBack returns notes to the notebooks and positions recorded in
+// snapshots, for undoing Move. Notes deleted since are skipped. When a
+// note would repeat a title taken in its old notebook in the meantime,
+// none is moved and the error is a *DuplicateTitleError.
+func (r *NoteRepoMem) MoveBack(snapshots []core.Note) ([]core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	back := make(map[int64][]int64)
+	for _, old := range snapshots {
+		if _, exists := r.notes[old.ID]; exists {
+			back[old.NotebookID] = append(back[old.NotebookID], old.ID)
+		}
+	}
+	for notebookID, ids := range back {
+		if err := r.checkTransfer(ids, notebookID, false); err != nil {
+			return nil, err
+		}
+	}
+
+	now := r.Clock.Now()
+	moved := make([]core.Note, 0, len(snapshots))
+	for _, old := range snapshots {
+		note, exists := r.notes[old.ID]
+		if !exists {
+			continue
+		}
+		if note.NotebookID != old.NotebookID || note.Position != old.Position {
+			note.NotebookID, note.Position = old.NotebookID, old.Position
+			note.UpdatedAt = &now
+			r.emit(ChangeUpdated, note)
+		}
+		moved = append(moved, *note)
+	}
+	return moved, nil
+}
+
+// Copy duplicates the notes into notebookID on behalf of ownerID. Journal
+// dates are not copied, so a copy never competes with the original day.
+func (r *NoteRepoMem) Copy(ids []int64, notebookID int64, ownerID string) ([]core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		if _, exists := r.notes[id]; !exists {
+			return nil, ErrNoteNotFound
+		}
+	}
+	if err := r.checkTransfer(ids, notebookID, true); err != nil {
+		return nil, err
+	}
+
+	copies := make([]core.Note, 0, len(ids))
+	for _, id := range ids {
+		n := *r.notes[id]
+		n.OwnerID = ownerID
+		n.NotebookID = notebookID
+		n.JournalDate = ""
+		n.Pinned = false
+		n.Reactions, n.ReactedBy = nil, nil
+		n.ShareToken = ""
+		n.Tags = append([]string(nil), n.Tags...)
+		n.Blocks = append([]core.Block(nil), n.Blocks...)
+		copies = append(copies, *r.insert(n))
+	}
+
+	return copies, nil
+}
+
+// checkTransfer returns a *DuplicateTitleError when moving or copying the
+// notes into notebookID would repeat a title there. It must be called
+// with the lock held.
+func (r *NoteRepoMem) checkTransfer(ids []int64, notebookID int64, copying bool) error {
+	taken := r.titlesIn(notebookID)
+	if taken == nil {
+		return nil
+	}
+	for _, id := range ids {
+		note := r.notes[id]
+		if !copying && note.NotebookID == notebookID {
+			continue
+		}
+		key := foldTitle(note.Title)
+		if other, ok := taken[key]; ok {
+			return &DuplicateTitleError{NoteID: other}
+		}
+		taken[key] = id
+	}
+	return nil
+}
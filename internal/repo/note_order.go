@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"sort"
+
+	"example.com/notes-api/internal/core"
+)
+
+// positionGap spaces positions so that appending a note never renumbers
+// its neighbours and a reorder only touches the notes being moved.
+const positionGap int64 = 1024
+
+var ErrNoteNotInNotebook = core.Invalid("ids", "ids must be distinct notes of the notebook")
+
+// nextPosition must be called with the write lock held.
+func (r *NoteRepoMem) nextPosition(notebookID int64) int64 {
+	var max int64
+	for _, note := range r.notes {
+		if note.NotebookID == notebookID && note.Position > max {
+			max = note.Position
+		}
+	}
+	return max + positionGap
+}
+
+// Reorder places the given notes of a notebook in the given order. The
+// listed notes reuse the slots they already occupy, so notes left out of
+// ids keep their places. When every note of the notebook is listed, the
+// positions are respaced by positionGap.
+func (r *NoteRepoMem) Reorder(notebookID int64, ids []int64) ([]core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[int64]bool, len(ids))
+	slots := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		note, exists := r.notes[id]
+		if !exists {
+			return nil, ErrNoteNotFound
+		}
+		if note.NotebookID != notebookID || seen[id] {
+			return nil, ErrNoteNotInNotebook
+		}
+		seen[id] = true
+		slots = append(slots, note.Position)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+
+	total := 0
+	for _, note := range r.notes {
+		if note.NotebookID == notebookID {
+			total++
+		}
+	}
+
+	for i, id := range ids {
+		note := r.notes[id]
+		position := slots[i]
+		if total == len(ids) {
+			position = int64(i+1) * positionGap
+		}
+		if note.Position != position {
+			note.Position = position
+			r.emit(ChangeUpdated, note)
+		}
+	}
+
+	return r.notebookNotes(notebookID), nil
+}
+
+// notebookNotes must be called with the lock held.
+func (r *NoteRepoMem) notebookNotes(notebookID int64) []core.Note {
+	notes := make([]core.Note, 0)
+	for _, note := range r.notes {
+		if note.NotebookID == notebookID {
+			notes = append(notes, *note)
+		}
+	}
+	core.SortByPosition(notes)
+	return notes
+}
@@ -0,0 +1,339 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrNotebookNotFound = core.NotFound("notebook not found")
+	ErrParentNotFound   = core.Invalid("parent_id", "parent notebook not found")
+	ErrNotebookCycle    = core.Invalid("parent_id", "notebook cannot be moved into itself or its descendant")
+	ErrNotebookNotEmpty = core.Conflict("notebook is not empty")
+)
+
+type NotebookRepoMem struct {
+	// Clock stamps creation and update times.
+	Clock clock.Clock
+
+	mu        sync.RWMutex
+	notebooks map[int64]*core.Notebook
+	next      int64
+
+	listeners []func()
+}
+
+func NewNotebookRepoMem() *NotebookRepoMem {
+	return &NotebookRepoMem{
+		notebooks: make(map[int64]*core.Notebook),
+		next:      1,
+		Clock:     clock.System{},
+	}
+}
+
+func (r *NotebookRepoMem) Create(nb core.Notebook) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if nb.ParentID != 0 {
+		if _, exists := r.notebooks[nb.ParentID]; !exists {
+			return 0, ErrParentNotFound
+		}
+	}
+
+	nb.ID = r.next
+	nb.CreatedAt = r.Clock.Now()
+	nb.UpdatedAt = nil
+	r.notebooks[nb.ID] = &nb
+	r.next++
+
+	return nb.ID, nil
+}
+
+func (r *NotebookRepoMem) GetByID(id int64) (*core.Notebook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return nil, ErrNotebookNotFound
+	}
+
+	nbCopy := *nb
+	nbCopy.Path = r.path(id)
+	return &nbCopy, nil
+}
+
+func (r *NotebookRepoMem) GetAll() ([]core.Notebook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notebooks := make([]core.Notebook, 0, len(r.notebooks))
+	for id, nb := range r.notebooks {
+		nbCopy := *nb
+		nbCopy.Path = r.path(id)
+		notebooks = append(notebooks, nbCopy)
+	}
+
+	sort.Slice(notebooks, func(i, j int) bool { return notebooks[i].ID < notebooks[j].ID })
+
+	return notebooks, nil
+}
+
+func (r *NotebookRepoMem) Update(id int64, name *string, parentID *int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return ErrNotebookNotFound
+	}
+
+	if parentID != nil && *parentID != 0 {
+		if _, exists := r.notebooks[*parentID]; !exists {
+			return ErrParentNotFound
+		}
+		for cur := *parentID; cur != 0; cur = r.notebooks[cur].ParentID {
+			if cur == id {
+				return ErrNotebookCycle
+			}
+		}
+	}
+
+	if name != nil {
+		nb.Name = *name
+	}
+	if parentID != nil {
+		nb.ParentID = *parentID
+	}
+
+	now := r.Clock.Now()
+	nb.UpdatedAt = &now
+	r.changed()
+
+	return nil
+}
+
+// SetUniqueTitles turns the unique title constraint of a notebook on or
+// off. Turning it on leaves titles already repeated alone; only new notes
+// and renames are checked.
+func (r *NotebookRepoMem) SetUniqueTitles(id int64, on bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return ErrNotebookNotFound
+	}
+	nb.UniqueTitles = on
+	now := r.Clock.Now()
+	nb.UpdatedAt = &now
+	r.changed()
+	return nil
+}
+
+// UniqueTitles reports whether the notes of a notebook must have unique
+// titles; unknown notebooks do not require them.
+func (r *NotebookRepoMem) UniqueTitles(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nb, exists := r.notebooks[id]
+	return exists && nb.UniqueTitles
+}
+
+// SetRequireApproval turns approval mode of a notebook on or off. Turning
+// it off leaves pending change requests to be decided.
+func (r *NotebookRepoMem) SetRequireApproval(id int64, on bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return ErrNotebookNotFound
+	}
+	nb.RequireApproval = on
+	now := r.Clock.Now()
+	nb.UpdatedAt = &now
+	r.changed()
+	return nil
+}
+
+// RequiresApproval reports whether edits of the notes of a notebook by
+// anyone but its owner need approval; unknown notebooks do not.
+func (r *NotebookRepoMem) RequiresApproval(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nb, exists := r.notebooks[id]
+	return exists && nb.RequireApproval
+}
+
+// SetSettings replaces the defaults of a notebook; zero settings remove
+// them.
+func (r *NotebookRepoMem) SetSettings(id int64, s core.NotebookSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return ErrNotebookNotFound
+	}
+	// Replace rather than modify: copies handed out share the old settings.
+	nb.Settings = nil
+	if !s.IsZero() {
+		s.Tags = append([]string(nil), s.Tags...)
+		nb.Settings = &s
+	}
+	now := r.Clock.Now()
+	nb.UpdatedAt = &now
+	r.changed()
+	return nil
+}
+
+// Settings returns the defaults of a notebook; unknown notebooks and
+// notebooks without settings have zero ones.
+func (r *NotebookRepoMem) Settings(id int64) core.NotebookSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists || nb.Settings == nil {
+		return core.NotebookSettings{}
+	}
+	return *nb.Settings
+}
+
+func (r *NotebookRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.notebooks[id]; !exists {
+		return ErrNotebookNotFound
+	}
+	for _, nb := range r.notebooks {
+		if nb.ParentID == id {
+			return ErrNotebookNotEmpty
+		}
+	}
+
+	delete(r.notebooks, id)
+	r.changed()
+	return nil
+}
+
+// SetShare grants or changes the role of a grantee on the notebook.
+func (r *NotebookRepoMem) SetShare(id int64, share core.Share) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return ErrNotebookNotFound
+	}
+
+	shares := make([]core.Share, 0, len(nb.Shares)+1)
+	for _, s := range nb.Shares {
+		if s.Grantee != share.Grantee {
+			shares = append(shares, s)
+		}
+	}
+	nb.Shares = append(shares, share)
+	r.changed()
+
+	return nil
+}
+
+func (r *NotebookRepoMem) RemoveShare(id int64, grantee string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, exists := r.notebooks[id]
+	if !exists {
+		return ErrNotebookNotFound
+	}
+
+	shares := make([]core.Share, 0, len(nb.Shares))
+	for _, s := range nb.Shares {
+		if s.Grantee != grantee {
+			shares = append(shares, s)
+		}
+	}
+	nb.Shares = shares
+	r.changed()
+
+	return nil
+}
+
+// OnChange registers fn to be called after a notebook is renamed, moved,
+// deleted or reshared, which changes note paths and visibility. fn runs
+// with the repository locked.
+func (r *NotebookRepoMem) OnChange(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, fn)
+}
+
+// changed must be called with the write lock held.
+func (r *NotebookRepoMem) changed() {
+	for _, fn := range r.listeners {
+		fn()
+	}
+}
+
+// Role resolves the principal's role on a notebook. Ownership and shares
+// are inherited down the tree: the strongest grant on the notebook or any
+// of its ancestors wins.
+func (r *NotebookRepoMem) Role(p core.Principal, id int64) core.Role {
+	if p.Admin {
+		return core.RoleOwner
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	role := core.RoleNone
+	for cur := id; cur != 0; {
+		nb, exists := r.notebooks[cur]
+		if !exists {
+			break
+		}
+		if nb.OwnerID == p.UserID {
+			return core.RoleOwner
+		}
+		for _, s := range nb.Shares {
+			if p.Matches(s.Grantee) {
+				role = core.MaxRole(role, s.Role)
+			}
+		}
+		cur = nb.ParentID
+	}
+
+	return role
+}
+
+// Path returns the breadcrumbs from the root notebook down to id.
+func (r *NotebookRepoMem) Path(id int64) []core.NotebookRef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.path(id)
+}
+
+// path must be called with the lock held.
+func (r *NotebookRepoMem) path(id int64) []core.NotebookRef {
+	var crumbs []core.NotebookRef
+	for cur := id; cur != 0; {
+		nb, exists := r.notebooks[cur]
+		if !exists {
+			break
+		}
+		crumbs = append([]core.NotebookRef{{ID: nb.ID, Name: nb.Name}}, crumbs...)
+		cur = nb.ParentID
+	}
+	return crumbs
+}
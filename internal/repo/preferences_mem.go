@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+type PreferenceRepoMem struct {
+	mu    sync.RWMutex
+	users map[string]core.Preferences
+}
+
+func NewPreferenceRepoMem() *PreferenceRepoMem {
+	return &PreferenceRepoMem{users: make(map[string]core.Preferences)}
+}
+
+// Get returns the preferences of userID, or the defaults when they never
+// changed them.
+func (r *PreferenceRepoMem) Get(userID string) core.Preferences {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if p, ok := r.users[userID]; ok {
+		return p
+	}
+	return core.DefaultPreferences()
+}
+
+// Update applies u to the preferences of userID atomically.
+func (r *PreferenceRepoMem) Update(userID string, u core.PreferencesUpdate) (core.Preferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.users[userID]
+	if !ok {
+		p = core.DefaultPreferences()
+	}
+	p, err := u.Apply(p)
+	if err != nil {
+		return p, err
+	}
+	r.users[userID] = p
+	return p, nil
+}
+
+// Users returns the IDs of users who changed their preferences, sorted.
+func (r *PreferenceRepoMem) Users() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
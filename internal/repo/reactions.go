@@ -0,0 +1,24 @@
+package repo
+
+import "example.com/notes-api/internal/core"
+
+// React adds (or, with add false, removes) the reaction of userID to a
+// note and returns the note. Reactions do not touch UpdatedAt: they are
+// not edits of the note.
+func (r *NoteRepoMem) React(id int64, userID, emoji string, add bool) (*core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return nil, ErrNoteNotFound
+	}
+
+	if core.ReactedBy(*note, userID, emoji) != add {
+		note.ReactedBy, note.Reactions = core.WithReaction(*note, userID, emoji, add)
+		r.emit(ChangeUpdated, note)
+	}
+
+	noteCopy := *note
+	return &noteCopy, nil
+}
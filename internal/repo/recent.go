@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRecentDebounce is how long further views of a note do not
+	// move it in the list of recently viewed notes.
+	DefaultRecentDebounce = time.Minute
+	// DefaultRecentLimit is how many notes each user's list keeps.
+	DefaultRecentLimit = 50
+)
+
+type recentView struct {
+	noteID int64
+	at     time.Time
+}
+
+// RecentRepoMem keeps the notes each user viewed last, most recent first.
+type RecentRepoMem struct {
+	// Debounce defaults to DefaultRecentDebounce and Limit to
+	// DefaultRecentLimit.
+	Debounce time.Duration
+	Limit    int
+
+	mu    sync.Mutex
+	users map[string][]recentView
+}
+
+func NewRecentRepoMem() *RecentRepoMem {
+	return &RecentRepoMem{users: make(map[string][]recentView)}
+}
+
+// Record moves the note to the front of the list of userID, unless they
+// viewed it less than Debounce before t.
+func (r *RecentRepoMem) Record(userID string, noteID int64, t time.Time) {
+	debounce := r.Debounce
+	if debounce == 0 {
+		debounce = DefaultRecentDebounce
+	}
+	limit := r.Limit
+	if limit == 0 {
+		limit = DefaultRecentLimit
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	views := r.users[userID]
+	for i, v := range views {
+		if v.noteID != noteID {
+			continue
+		}
+		if t.Sub(v.at) < debounce {
+			return
+		}
+		views = append(views[:i], views[i+1:]...)
+		break
+	}
+	views = append([]recentView{{noteID: noteID, at: t}}, views...)
+	if len(views) > limit {
+		views = views[:limit]
+	}
+	r.users[userID] = views
+}
+
+// List returns the IDs of the notes userID viewed, most recent first.
+func (r *RecentRepoMem) List(userID string) []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]int64, len(r.users[userID]))
+	for i, v := range r.users[userID] {
+		ids[i] = v.noteID
+	}
+	return ids
+}
+
+// Clear forgets what userID viewed.
+func (r *RecentRepoMem) Clear(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, userID)
+}
+
+// Apply forgets deleted notes for every user.
+func (r *RecentRepoMem) Apply(c Change) {
+	if c.Op != ChangeDeleted {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, views := range r.users {
+		for i, v := range views {
+			if v.noteID == c.Note.ID {
+				r.users[userID] = append(views[:i], views[i+1:]...)
+				break
+			}
+		}
+		if len(r.users[userID]) == 0 {
+			delete(r.users, userID)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package repotest
+
+import (
+	"fmt"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+)
+
+// benchNotes is how many notes the read benchmarks start from.
+const benchNotes = 1000
+
+// Bench runs the same benchmarks against a backend, so that backends can
+// be compared with benchstat. newRepo must return an empty repository.
+func Bench(b *testing.B, newRepo func(b *testing.B) NoteRepository) {
+	benchmarks := []struct {
+		name string
+		fn   func(*testing.B, NoteRepository)
+	}{
+		{"Create", benchCreate},
+		{"GetByID", benchGetByID},
+		{"List", benchList},
+		{"UpdatePartial", benchUpdatePartial},
+		{"ParallelMixed", benchParallelMixed},
+	}
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			bb.fn(b, newRepo(b))
+		})
+	}
+}
+
+func fill(b *testing.B, r NoteRepository, count int) []int64 {
+	b.Helper()
+	ids := make([]int64, count)
+	for i := range ids {
+		id, err := r.Create(benchNote(i))
+		if err != nil {
+			b.Fatalf("Create: %v", err)
+		}
+		ids[i] = id
+	}
+	b.ResetTimer()
+	return ids
+}
+
+func benchNote(i int) core.Note {
+	return core.Note{
+		OwnerID: "alice",
+		Type:    core.NoteTypeNote,
+		Title:   fmt.Sprintf("Заметка %d", i),
+		Content: "купить молоко, хлеб и сыр",
+		Tags:    []string{"home", "shopping"},
+	}
+}
+
+func benchCreate(b *testing.B, r NoteRepository) {
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Create(benchNote(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchGetByID(b *testing.B, r NoteRepository) {
+	ids := fill(b, r, benchNotes)
+	for i := 0; i < b.N; i++ {
+		if _, err := r.GetByID(ids[i%len(ids)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchList(b *testing.B, r NoteRepository) {
+	fill(b, r, benchNotes)
+	for i := 0; i < b.N; i++ {
+		if _, err := r.List(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchUpdatePartial(b *testing.B, r NoteRepository) {
+	ids := fill(b, r, benchNotes)
+	for i := 0; i < b.N; i++ {
+		if err := r.UpdatePartial(ids[i%len(ids)], map[string]interface{}{"content": "обновлено"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchParallelMixed reads nine times for every write, the ratio
+// notes-bench uses by default.
+func benchParallelMixed(b *testing.B, r NoteRepository) {
+	ids := fill(b, r, benchNotes)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			id := ids[i%len(ids)]
+			var err error
+			if i%10 == 0 {
+				err = r.UpdatePartial(id, map[string]interface{}{"content": "обновлено"})
+			} else {
+				_, err = r.GetByID(id)
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,226 @@
+// Package repotest is a conformance suite for note repositories: every
+// backend must pass it to be used in place of the in-memory one.
+package repotest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// NoteRepository is the part of a note repository the suite exercises.
+type NoteRepository interface {
+	core.NoteRepository
+	Resolve(publicID string) (int64, error)
+	ResolveSlug(slug string) (int64, error)
+}
+
+// Run runs the suite. newRepo must return an empty repository.
+func Run(t *testing.T, newRepo func(t *testing.T) NoteRepository) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, NoteRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetMissing", testGetMissing},
+		{"ListOrdered", testListOrdered},
+		{"ReturnsCopies", testReturnsCopies},
+		{"UpdatePartial", testUpdatePartial},
+		{"Delete", testDelete},
+		{"PublicIDsAndSlugs", testPublicIDsAndSlugs},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func create(t *testing.T, r NoteRepository, n core.Note) int64 {
+	t.Helper()
+	id, err := r.Create(n)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return id
+}
+
+func get(t *testing.T, r NoteRepository, id int64) *core.Note {
+	t.Helper()
+	n, err := r.GetByID(id)
+	if err != nil {
+		t.Fatalf("GetByID(%d): %v", id, err)
+	}
+	return n
+}
+
+func testCreateAndGet(t *testing.T, r NoteRepository) {
+	id := create(t, r, core.Note{OwnerID: "alice", Type: core.NoteTypeNote, Title: "Hello", Content: "text", Tags: []string{"a"}})
+	n := get(t, r, id)
+	if n.ID != id || n.OwnerID != "alice" || n.Title != "Hello" || n.Content != "text" || len(n.Tags) != 1 {
+		t.Errorf("GetByID = %+v", n)
+	}
+	if n.CreatedAt.IsZero() || n.UpdatedAt != nil {
+		t.Errorf("new note times: created %v, updated %v", n.CreatedAt, n.UpdatedAt)
+	}
+	if n.CreatedAt.Location().String() != "UTC" {
+		t.Errorf("CreatedAt in %v, want UTC", n.CreatedAt.Location())
+	}
+}
+
+func testGetMissing(t *testing.T, r NoteRepository) {
+	if _, err := r.GetByID(42); err != repo.ErrNoteNotFound {
+		t.Errorf("GetByID(missing) error = %v, want ErrNoteNotFound", err)
+	}
+	if err := r.UpdatePartial(42, map[string]interface{}{"title": "x"}); err != repo.ErrNoteNotFound {
+		t.Errorf("UpdatePartial(missing) error = %v, want ErrNoteNotFound", err)
+	}
+	if err := r.Delete(42); err != repo.ErrNoteNotFound {
+		t.Errorf("Delete(missing) error = %v, want ErrNoteNotFound", err)
+	}
+}
+
+func testListOrdered(t *testing.T, r NoteRepository) {
+	for i := 0; i < 20; i++ {
+		create(t, r, core.Note{Title: fmt.Sprint("note ", i)})
+	}
+	notes, err := r.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 20 {
+		t.Fatalf("List returned %d notes, want 20", len(notes))
+	}
+	for i := 1; i < len(notes); i++ {
+		if notes[i-1].ID >= notes[i].ID {
+			t.Fatalf("List not ordered by ID: %d before %d", notes[i-1].ID, notes[i].ID)
+		}
+	}
+}
+
+func testReturnsCopies(t *testing.T, r NoteRepository) {
+	id := create(t, r, core.Note{Title: "original"})
+	n := get(t, r, id)
+	n.Title = "changed"
+	if got := get(t, r, id).Title; got != "original" {
+		t.Errorf("modifying a returned note changed the stored one: %q", got)
+	}
+}
+
+func testUpdatePartial(t *testing.T, r NoteRepository) {
+	id := create(t, r, core.Note{Title: "title", Content: "content", Tags: []string{"a"}})
+	if err := r.UpdatePartial(id, map[string]interface{}{"content": "new", "tags": []string{"b"}}); err != nil {
+		t.Fatal(err)
+	}
+	n := get(t, r, id)
+	if n.Title != "title" || n.Content != "new" || len(n.Tags) != 1 || n.Tags[0] != "b" {
+		t.Errorf("after update = %+v", n)
+	}
+	if n.UpdatedAt == nil || n.UpdatedAt.Before(n.CreatedAt) {
+		t.Errorf("UpdatedAt = %v, created %v", n.UpdatedAt, n.CreatedAt)
+	}
+}
+
+func testDelete(t *testing.T, r NoteRepository) {
+	keep := create(t, r, core.Note{Title: "keep"})
+	drop := create(t, r, core.Note{Title: "drop"})
+	if err := r.Delete(drop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetByID(drop); err != repo.ErrNoteNotFound {
+		t.Errorf("GetByID(deleted) error = %v", err)
+	}
+	notes, _ := r.List()
+	if len(notes) != 1 || notes[0].ID != keep {
+		t.Errorf("List after delete = %+v", notes)
+	}
+	if next := create(t, r, core.Note{Title: "next"}); next == drop {
+		t.Errorf("ID %d of a deleted note was reused", drop)
+	}
+}
+
+func testPublicIDsAndSlugs(t *testing.T, r NoteRepository) {
+	a := get(t, r, create(t, r, core.Note{Title: "Same title"}))
+	b := get(t, r, create(t, r, core.Note{Title: "Same title"}))
+	if !core.IsPublicID(a.PublicID) || a.PublicID == b.PublicID {
+		t.Errorf("public IDs %q, %q", a.PublicID, b.PublicID)
+	}
+	if a.Slug == b.Slug {
+		t.Errorf("both notes have slug %q", a.Slug)
+	}
+	if id, err := r.Resolve(b.PublicID); err != nil || id != b.ID {
+		t.Errorf("Resolve = %d, %v", id, err)
+	}
+
+	old := a.Slug
+	if err := r.UpdatePartial(a.ID, map[string]interface{}{"title": "Renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := r.ResolveSlug(old); err != nil || id != a.ID {
+		t.Errorf("ResolveSlug(former slug) = %d, %v", id, err)
+	}
+	if id, err := r.ResolveSlug(get(t, r, a.ID).Slug); err != nil || id != a.ID {
+		t.Errorf("ResolveSlug(new slug) = %d, %v", id, err)
+	}
+}
+
+func testConcurrentCreates(t *testing.T, r NoteRepository) {
+	const n = 200
+	var wg sync.WaitGroup
+	ids := make(chan int64, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := r.Create(core.Note{Title: "concurrent"})
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, n)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("ID %d handed out twice", id)
+		}
+		seen[id] = true
+	}
+	if notes, _ := r.List(); len(notes) != n {
+		t.Errorf("List returned %d notes, want %d", len(notes), n)
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, r NoteRepository) {
+	id := create(t, r, core.Note{Title: "counter"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := r.UpdatePartial(id, map[string]interface{}{"content": fmt.Sprint(i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := r.GetByID(id); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := get(t, r, id); n.Content == "" || n.UpdatedAt == nil {
+		t.Errorf("after concurrent updates = %+v", n)
+	}
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// ReviewRepoMem keeps the spaced-repetition review state of each user's
+// notes.
+type ReviewRepoMem struct {
+	mu     sync.RWMutex
+	states map[string]map[int64]core.ReviewState
+}
+
+func NewReviewRepoMem() *ReviewRepoMem {
+	return &ReviewRepoMem{states: make(map[string]map[int64]core.ReviewState)}
+}
+
+// For returns the review states of userID by note ID.
+func (r *ReviewRepoMem) For(userID string) map[int64]core.ReviewState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	states := make(map[int64]core.ReviewState, len(r.states[userID]))
+	for id, s := range r.states[userID] {
+		states[id] = s
+	}
+	return states
+}
+
+// Get returns the review state of a note for userID, and whether the
+// user has reviewed it.
+func (r *ReviewRepoMem) Get(userID string, noteID int64) (core.ReviewState, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.states[userID][noteID]
+	return s, ok
+}
+
+// Record stores a review of a note by userID at now and returns the new
+// state.
+func (r *ReviewRepoMem) Record(userID string, noteID int64, grade string, now time.Time) core.ReviewState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.states[userID] == nil {
+		r.states[userID] = make(map[int64]core.ReviewState)
+	}
+	s := r.states[userID][noteID]
+	s.NoteID = noteID
+	s = s.Reviewed(grade, now)
+	r.states[userID][noteID] = s
+	return s
+}
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var ErrRuleNotFound = core.NotFound("rule not found")
+
+type RuleRepoMem struct {
+	// Clock stamps creation and update times.
+	Clock clock.Clock
+
+	mu    sync.RWMutex
+	rules map[int64]*core.Rule
+	next  int64
+	// checked is when reminders were last looked at for reminder_due
+	// rules; zero until the first check.
+	checked time.Time
+}
+
+func NewRuleRepoMem() *RuleRepoMem {
+	return &RuleRepoMem{
+		rules: make(map[int64]*core.Rule),
+		next:  1,
+		Clock: clock.System{},
+	}
+}
+
+func (r *RuleRepoMem) Create(rule core.Rule) core.Rule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule.ID = r.next
+	rule.CreatedAt = r.Clock.Now()
+	rule.UpdatedAt = rule.CreatedAt
+	rule.Runs, rule.LastRunAt = 0, nil
+	r.rules[rule.ID] = &rule
+	r.next++
+	return cloneRule(rule)
+}
+
+func (r *RuleRepoMem) GetByID(id int64) (*core.Rule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, exists := r.rules[id]
+	if !exists {
+		return nil, ErrRuleNotFound
+	}
+	ruleCopy := cloneRule(*rule)
+	return &ruleCopy, nil
+}
+
+// ListFor returns the rules of ownerID by ID.
+func (r *RuleRepoMem) ListFor(ownerID string) []core.Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.Rule
+	for _, rule := range r.rules {
+		if rule.OwnerID == ownerID {
+			list = append(list, cloneRule(*rule))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Matching returns the enabled rules of the owner of n that wait for
+// event and whose trigger matches n, by ID. It does not call the note
+// repository, so change listeners can use it.
+func (r *RuleRepoMem) Matching(event string, n core.Note) []core.Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.Rule
+	for _, rule := range r.rules {
+		if rule.Enabled && rule.OwnerID == n.OwnerID && rule.When.Event == event && rule.When.Matches(n) {
+			list = append(list, cloneRule(*rule))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Replace overwrites the rule with rule, keeping its ID, owner, runs and
+// creation time.
+func (r *RuleRepoMem) Replace(rule core.Rule) (core.Rule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.rules[rule.ID]
+	if !exists {
+		return core.Rule{}, ErrRuleNotFound
+	}
+	rule.OwnerID, rule.CreatedAt = old.OwnerID, old.CreatedAt
+	rule.Runs, rule.LastRunAt = old.Runs, old.LastRunAt
+	rule.UpdatedAt = r.Clock.Now()
+	r.rules[rule.ID] = &rule
+	return cloneRule(rule), nil
+}
+
+// RecordRun counts a run of the rule at at. Rules deleted while running
+// are ignored.
+func (r *RuleRepoMem) RecordRun(id int64, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rule, exists := r.rules[id]; exists {
+		rule.Runs++
+		rule.LastRunAt = &at
+	}
+}
+
+func (r *RuleRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.rules[id]; !exists {
+		return ErrRuleNotFound
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+// AdvanceReminders moves the reminder check on to now and returns when
+// the previous one ran, so that reminders due in between fire once. The
+// first call returns now: reminders that came due before the server
+// started are not fired late.
+func (r *RuleRepoMem) AdvanceReminders(now time.Time) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := r.checked
+	if since.IsZero() {
+		since = now
+	}
+	if now.After(r.checked) {
+		r.checked = now
+	}
+	return since
+}
+
+func cloneRule(rule core.Rule) core.Rule {
+	rule.OwnerTeams = append([]string(nil), rule.OwnerTeams...)
+	rule.Then = append([]core.RuleAction(nil), rule.Then...)
+	for i := range rule.Then {
+		rule.Then[i].To = append([]string(nil), rule.Then[i].To...)
+	}
+	return rule
+}
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var ErrSavedViewNotFound = core.NotFound("view not found")
+
+type SavedViewRepoMem struct {
+	// Clock stamps creation and update times.
+	Clock clock.Clock
+
+	mu    sync.RWMutex
+	views map[int64]*core.SavedView
+	next  int64
+}
+
+func NewSavedViewRepoMem() *SavedViewRepoMem {
+	return &SavedViewRepoMem{
+		views: make(map[int64]*core.SavedView),
+		next:  1,
+		Clock: clock.System{},
+	}
+}
+
+func (r *SavedViewRepoMem) Create(v core.SavedView) (core.SavedView, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v.ID = r.next
+	v.CreatedAt = r.Clock.Now()
+	v.UpdatedAt = nil
+	r.views[v.ID] = &v
+	r.next++
+	return v, nil
+}
+
+func (r *SavedViewRepoMem) GetByID(id int64) (*core.SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, exists := r.views[id]
+	if !exists {
+		return nil, ErrSavedViewNotFound
+	}
+	vCopy := *v
+	return &vCopy, nil
+}
+
+// ListFor returns the views p owns or that are shared with p, by ID.
+func (r *SavedViewRepoMem) ListFor(p core.Principal) []core.SavedView {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.SavedView
+	for _, v := range r.views {
+		if v.Visible(p) {
+			list = append(list, *v)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Replace overwrites the view with v, keeping its ID, owner and creation
+// time.
+func (r *SavedViewRepoMem) Replace(v core.SavedView) (core.SavedView, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.views[v.ID]
+	if !exists {
+		return core.SavedView{}, ErrSavedViewNotFound
+	}
+	v.OwnerID, v.CreatedAt = old.OwnerID, old.CreatedAt
+	now := r.Clock.Now()
+	v.UpdatedAt = &now
+	r.views[v.ID] = &v
+	return v, nil
+}
+
+func (r *SavedViewRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.views[id]; !exists {
+		return ErrSavedViewNotFound
+	}
+	delete(r.views, id)
+	return nil
+}
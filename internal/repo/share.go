@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Share gives the note a public link token, keeping the one it already
+// has. Sharing is not an edit: UpdatedAt is left alone.
+func (r *NoteRepoMem) Share(id int64) (*core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return nil, ErrNoteNotFound
+	}
+
+	if note.ShareToken == "" {
+		var b [18]byte
+		rand.Read(b[:])
+		note.ShareToken = base64.RawURLEncoding.EncodeToString(b[:])
+		r.shares[note.ShareToken] = id
+	}
+
+	noteCopy := *note
+	return &noteCopy, nil
+}
+
+// Unshare revokes the public link of the note, if it has one.
+func (r *NoteRepoMem) Unshare(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return ErrNoteNotFound
+	}
+
+	delete(r.shares, note.ShareToken)
+	note.ShareToken = ""
+	return nil
+}
+
+// ResolveShare returns the ID of the note shared under token.
+func (r *NoteRepoMem) ResolveShare(token string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.shares[token]
+	if !exists {
+		return 0, ErrNoteNotFound
+	}
+	return id, nil
+}
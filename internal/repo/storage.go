@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Usage is how much a group of notes or blocks takes up.
+type Usage struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (u *Usage) add(o Usage, sign int) {
+	u.Count += sign * o.Count
+	u.Bytes += int64(sign) * o.Bytes
+}
+
+// StorageReport breaks down the usage of one owner's notes. A note counts
+// towards each of its tags; block types count blocks rather than notes.
+type StorageReport struct {
+	Total     Usage
+	Notebooks map[int64]Usage
+	Tags      map[string]Usage
+	Blocks    map[core.BlockType]Usage
+}
+
+// StorageUsage maintains a StorageReport per owner as notes change, so
+// reports never scan the notes.
+type StorageUsage struct {
+	mu     sync.Mutex
+	notes  map[int64]noteUsage
+	owners map[string]*StorageReport
+}
+
+// noteUsage is what a note was last counted as, to be taken back when it
+// changes.
+type noteUsage struct {
+	owner    string
+	notebook int64
+	tags     []string
+	total    Usage
+	blocks   map[core.BlockType]Usage
+}
+
+func NewStorageUsage() *StorageUsage {
+	return &StorageUsage{notes: make(map[int64]noteUsage), owners: make(map[string]*StorageReport)}
+}
+
+// Apply counts a note change. Register it with NoteRepoMem.OnChange.
+func (s *StorageUsage) Apply(c Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.notes[c.Note.ID]; ok {
+		s.count(old, -1)
+		delete(s.notes, c.Note.ID)
+	}
+	if c.Op == ChangeDeleted {
+		return
+	}
+
+	u := noteUsage{
+		owner:    c.Note.OwnerID,
+		notebook: c.Note.NotebookID,
+		tags:     c.Note.Tags,
+		total:    Usage{Count: 1, Bytes: int64(len(c.Note.Title) + len(c.Note.Content))},
+		blocks:   make(map[core.BlockType]Usage),
+	}
+	for _, b := range c.Note.Blocks {
+		size := int64(blockSize(b))
+		u.total.Bytes += size
+		bu := u.blocks[b.Type]
+		bu.add(Usage{Count: 1, Bytes: size}, 1)
+		u.blocks[b.Type] = bu
+	}
+	s.notes[c.Note.ID] = u
+	s.count(u, 1)
+}
+
+// count adds u to its owner's report, or takes it back when sign is -1.
+// Groups that become empty are dropped.
+func (s *StorageUsage) count(u noteUsage, sign int) {
+	r := s.owners[u.owner]
+	if r == nil {
+		r = &StorageReport{
+			Notebooks: make(map[int64]Usage),
+			Tags:      make(map[string]Usage),
+			Blocks:    make(map[core.BlockType]Usage),
+		}
+		s.owners[u.owner] = r
+	}
+
+	r.Total.add(u.total, sign)
+	addTo(r.Notebooks, u.notebook, u.total, sign)
+	for _, t := range u.tags {
+		addTo(r.Tags, t, u.total, sign)
+	}
+	for t, bu := range u.blocks {
+		addTo(r.Blocks, t, bu, sign)
+	}
+	if r.Total.Count == 0 {
+		delete(s.owners, u.owner)
+	}
+}
+
+func addTo[K comparable](m map[K]Usage, k K, u Usage, sign int) {
+	v := m[k]
+	v.add(u, sign)
+	if v.Count == 0 {
+		delete(m, k)
+	} else {
+		m[k] = v
+	}
+}
+
+// For returns a copy of the owner's report.
+func (s *StorageUsage) For(ownerID string) StorageReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := StorageReport{
+		Notebooks: make(map[int64]Usage),
+		Tags:      make(map[string]Usage),
+		Blocks:    make(map[core.BlockType]Usage),
+	}
+	r := s.owners[ownerID]
+	if r == nil {
+		return out
+	}
+	out.Total = r.Total
+	for k, v := range r.Notebooks {
+		out.Notebooks[k] = v
+	}
+	for k, v := range r.Tags {
+		out.Tags[k] = v
+	}
+	for k, v := range r.Blocks {
+		out.Blocks[k] = v
+	}
+	return out
+}
+
+// Owners returns the total usage of every owner with notes.
+func (s *StorageUsage) Owners() map[string]Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owners := make(map[string]Usage, len(s.owners))
+	for id, r := range s.owners {
+		owners[id] = r.Total
+	}
+	return owners
+}
+
+// blockSize is the number of bytes of text a block stores.
+func blockSize(b core.Block) int {
+	n := len(b.Text) + len(b.Language) + len(b.URL) + len(b.Alt)
+	for _, item := range b.Items {
+		n += len(item.Text)
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package repo
+
+import "example.com/notes-api/internal/core"
+
+var ErrTagNotFound = core.NotFound("tag not found")
+
+// RenameTag replaces tag from with to on every note accepted by match
+// under a single lock,
+// so no reader observes a half-renamed set. Descendants move along with
+// the tag (project/alpha becomes work/alpha when project is renamed to
+// work), and notes that already carry the new name keep one copy. Notes
+// someone other than userID has locked keep their tags. It returns the
+// number of notes changed, and a *LockedError when locks left none to
+// change.
+func (r *NoteRepoMem) RenameTag(userID, from, to string, match func(core.Note) bool) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.Clock.Now()
+	changed := 0
+	var locked error
+	for _, note := range r.notes {
+		if !core.HasTag(*note, from) || !match(*note) {
+			continue
+		}
+		if err := r.checkLock(note.ID, userID); err != nil {
+			locked = err
+			continue
+		}
+		tags := make([]string, 0, len(note.Tags))
+		for _, t := range note.Tags {
+			if core.TagMatches(t, from) {
+				t = to + t[len(from):]
+			}
+			tags = append(tags, t)
+		}
+		note.Tags = core.NormalizeTags(tags)
+		note.UpdatedAt = &now
+		r.emit(ChangeUpdated, note)
+		changed++
+	}
+
+	if changed == 0 && locked != nil {
+		return 0, locked
+	}
+	if changed == 0 {
+		return 0, ErrTagNotFound
+	}
+	return changed, nil
+}
+
+// ChangeTags sets the tags of a note to change(tags) on behalf of userID,
+// reading and writing them under one lock so that edits made since the
+// caller last read the note are kept. change returns nil to leave the
+// note alone, which ChangeTags reports as false. A note someone other
+// than userID has locked is a *LockedError.
+func (r *NoteRepoMem) ChangeTags(userID string, id int64, change func(tags []string) []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return false, ErrNoteNotFound
+	}
+	if err := r.checkLock(id, userID); err != nil {
+		return false, err
+	}
+	tags := change(append([]string(nil), note.Tags...))
+	if tags == nil {
+		return false, nil
+	}
+	note.Tags = core.NormalizeTags(tags)
+	now := r.Clock.Now()
+	note.UpdatedAt = &now
+	r.emit(ChangeUpdated, note)
+	return true, nil
+}
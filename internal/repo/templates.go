@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+var ErrTemplateNotFound = core.NotFound("note template not found")
+
+type TemplateRepoMem struct {
+	// Clock stamps creation and update times.
+	Clock clock.Clock
+
+	mu        sync.RWMutex
+	templates map[int64]*core.NoteTemplate
+	next      int64
+}
+
+func NewTemplateRepoMem() *TemplateRepoMem {
+	return &TemplateRepoMem{
+		templates: make(map[int64]*core.NoteTemplate),
+		next:      1,
+		Clock:     clock.System{},
+	}
+}
+
+func (r *TemplateRepoMem) Create(t core.NoteTemplate) (core.NoteTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.ID = r.next
+	t.CreatedAt = r.Clock.Now()
+	t.UpdatedAt = nil
+	r.templates[t.ID] = &t
+	r.next++
+	return t, nil
+}
+
+func (r *TemplateRepoMem) GetByID(id int64) (*core.NoteTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.templates[id]
+	if !exists {
+		return nil, ErrTemplateNotFound
+	}
+	tCopy := *t
+	return &tCopy, nil
+}
+
+// ListByOwner returns the templates of ownerID by ID.
+func (r *TemplateRepoMem) ListByOwner(ownerID string) []core.NoteTemplate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []core.NoteTemplate
+	for _, t := range r.templates {
+		if t.OwnerID == ownerID {
+			list = append(list, *t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Replace overwrites the template with t, keeping its ID, owner, creation
+// time and run history.
+func (r *TemplateRepoMem) Replace(t core.NoteTemplate) (core.NoteTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.templates[t.ID]
+	if !exists {
+		return core.NoteTemplate{}, ErrTemplateNotFound
+	}
+	t.OwnerID, t.CreatedAt = old.OwnerID, old.CreatedAt
+	t.LastRunAt, t.LastNoteID = old.LastRunAt, old.LastNoteID
+	now := r.Clock.Now()
+	t.UpdatedAt = &now
+	r.templates[t.ID] = &t
+	return t, nil
+}
+
+func (r *TemplateRepoMem) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[id]; !exists {
+		return ErrTemplateNotFound
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+// Due claims the scheduled templates whose next run is at or before now:
+// each is returned once with the run it is due for in NextRunAt, and
+// stored with its next run from next, or none when next returns nil.
+// Runs missed while the server was down collapse into one.
+func (r *TemplateRepoMem) Due(now time.Time, next func(core.NoteTemplate, time.Time) *time.Time) []core.NoteTemplate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []core.NoteTemplate
+	for _, t := range r.templates {
+		if t.NextRunAt == nil || t.NextRunAt.After(now) {
+			continue
+		}
+		due = append(due, *t)
+		runAt := *t.NextRunAt
+		t.LastRunAt = &runAt
+		t.NextRunAt = next(*t, now)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	return due
+}
+
+// Ran records the note a scheduled run of template id created.
+func (r *TemplateRepoMem) Ran(id, noteID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, exists := r.templates[id]; exists {
+		t.LastNoteID = noteID
+	}
+}
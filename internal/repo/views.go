@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// ViewLog remembers when each user last viewed each note.
+type ViewLog struct {
+	mu    sync.Mutex
+	notes map[int64]map[string]*core.NoteView
+}
+
+func NewViewLog() *ViewLog {
+	return &ViewLog{notes: make(map[int64]map[string]*core.NoteView)}
+}
+
+// Record counts a view of the note by userID at t.
+func (l *ViewLog) Record(noteID int64, userID string, t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	views := l.notes[noteID]
+	if views == nil {
+		views = make(map[string]*core.NoteView)
+		l.notes[noteID] = views
+	}
+	v := views[userID]
+	if v == nil {
+		v = &core.NoteView{UserID: userID, FirstViewedAt: t}
+		views[userID] = v
+	}
+	v.Views++
+	v.LastViewedAt = t
+}
+
+// For returns the views of a note, most recent first.
+func (l *ViewLog) For(noteID int64) []core.NoteView {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	views := make([]core.NoteView, 0, len(l.notes[noteID]))
+	for _, v := range l.notes[noteID] {
+		views = append(views, *v)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if !views[i].LastViewedAt.Equal(views[j].LastViewedAt) {
+			return views[i].LastViewedAt.After(views[j].LastViewedAt)
+		}
+		return views[i].UserID < views[j].UserID
+	})
+	return views
+}
+
+// Apply forgets the views of deleted notes. Register it with
+// NoteRepoMem.OnChange.
+func (l *ViewLog) Apply(c Change) {
+	if c.Op != ChangeDeleted {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.notes, c.Note.ID)
+}
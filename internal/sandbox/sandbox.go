@@ -0,0 +1,150 @@
+// Package sandbox fills the in-memory repositories with demo data. The
+// data only depends on the seed, so that frontend demos and client CI see
+// the same notebooks, notes, IDs and timestamps on every start.
+package sandbox
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// Epoch is when the last demo note was written; earlier ones go back from
+// it over Days days.
+var Epoch = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+const (
+	Days = 30
+	// NotesPerUser is how many notes each user gets.
+	NotesPerUser = 40
+)
+
+type Repos struct {
+	Notes       *repo.NoteRepoMem
+	Notebooks   *repo.NotebookRepoMem
+	Collections *repo.CollectionRepoMem
+}
+
+var (
+	notebooks = []string{"Работа", "Дом", "Учёба", "Путешествия"}
+	subjects  = []string{"Планы", "Идеи", "Встреча", "Список покупок", "Отчёт", "Заметки", "Черновик", "Вопросы"}
+	topics    = []string{"по проекту", "на неделю", "к отпуску", "по курсу Go", "для команды", "про ремонт", "к релизу"}
+	sentences = []string{
+		"Купить молоко, хлеб и сыр.",
+		"Обсудить сроки с командой.",
+		"Прочитать главу про конкурентность.",
+		"Забронировать билеты до пятницы.",
+		"Проверить логи после деплоя.",
+		"Позвонить в сервис по поводу стиральной машины.",
+		"Набросать структуру доклада.",
+		"Сравнить тарифы хостинга.",
+	}
+	tags     = []string{"work", "home", "ideas", "todo", "go", "travel", "project/alpha", "project/beta"}
+	statuses = []string{"todo", "doing", "done"}
+	places   = [][2]float64{{55.7558, 37.6173}, {59.9343, 30.3351}, {56.8389, 60.6057}}
+	snippet  = "func main() {\n\tfmt.Println(\"hello\")\n}"
+)
+
+// Fill creates demo data for each user. It must run before the server
+// starts: it swaps the repositories' clocks while it writes, and leaves
+// them on the system clock.
+func Fill(r Repos, users []string, seed int64) error {
+	rnd := rand.New(rand.NewSource(seed))
+	clk := clock.NewFake(Epoch.AddDate(0, 0, -Days))
+	r.Notes.Clock, r.Notebooks.Clock, r.Collections.Clock = clk, clk, clk
+	r.Notes.Rand = rnd
+	defer func() {
+		r.Notes.Clock, r.Notebooks.Clock, r.Collections.Clock = clock.System{}, clock.System{}, clock.System{}
+		r.Notes.Rand = nil
+	}()
+
+	// Each note moves the clock forward by the same share of the period.
+	step := time.Duration(Days) * 24 * time.Hour / time.Duration(NotesPerUser*len(users))
+
+	for _, user := range users {
+		var books []int64
+		for _, name := range notebooks {
+			id, err := r.Notebooks.Create(core.Notebook{Name: name, OwnerID: user})
+			if err != nil {
+				return err
+			}
+			books = append(books, id)
+		}
+		tasks, err := r.Collections.Create(core.Collection{
+			OwnerID: user,
+			Name:    "Задачи",
+			Schema: []core.PropertyDef{
+				{Name: "status", Type: "string", Required: true},
+				{Name: "due", Type: "date"},
+				{Name: "estimate", Type: "number"},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < NotesPerUser; i++ {
+			if _, err := r.Notes.Create(note(rnd, user, books, tasks, i)); err != nil {
+				return err
+			}
+			clk.Advance(step)
+		}
+	}
+
+	// Edit a few notes so that the data has update times too.
+	notes, err := r.Notes.List()
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		if rnd.Intn(5) != 0 {
+			continue
+		}
+		update := map[string]interface{}{"content": n.Content + "\n" + pick(rnd, sentences)}
+		if err := r.Notes.UpdatePartial(n.ID, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func note(rnd *rand.Rand, user string, books []int64, tasks int64, i int) core.Note {
+	n := core.Note{
+		OwnerID:    user,
+		NotebookID: books[rnd.Intn(len(books))],
+		Type:       core.NoteTypeNote,
+		Title:      fmt.Sprintf("%s %s", pick(rnd, subjects), pick(rnd, topics)),
+		Content:    pick(rnd, sentences) + " " + pick(rnd, sentences),
+		Tags:       core.NormalizeTags([]string{pick(rnd, tags), pick(rnd, tags)}),
+		Pinned:     rnd.Intn(8) == 0,
+	}
+
+	switch i % 10 {
+	case 3:
+		n.Type = core.NoteTypeSnippet
+		n.Language = "go"
+		n.Content = snippet
+	case 5:
+		p := places[rnd.Intn(len(places))]
+		n.Latitude, n.Longitude = &p[0], &p[1]
+	case 7:
+		remind := Epoch.AddDate(0, 0, 1+rnd.Intn(14))
+		n.RemindAt = &remind
+	case 1, 8:
+		n.CollectionID = tasks
+		n.Properties = map[string]interface{}{
+			"status":   pick(rnd, statuses),
+			"due":      Epoch.AddDate(0, 0, rnd.Intn(21)).Format(time.DateOnly),
+			"estimate": float64(1 + rnd.Intn(8)),
+		}
+	}
+	return n
+}
+
+func pick(rnd *rand.Rand, from []string) string {
+	return from[rnd.Intn(len(from))]
+}
@@ -0,0 +1,312 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Analyzer turns text into index terms: it normalizes and tokenizes the
+// text, drops stop words and stems what is left. Notes are indexed with
+// every analyzer, so each user can search with their own.
+type Analyzer struct {
+	Name string
+	stop map[string]bool
+	stem func(string) string
+}
+
+var (
+	// Simple only normalizes and tokenizes.
+	Simple = &Analyzer{Name: "simple"}
+	// English stems English words, so "notes" matches "note".
+	English = &Analyzer{Name: "english", stop: stopWords(englishStop), stem: stemEnglish}
+	// Russian stems Russian words, so "заметки" matches "заметка".
+	Russian = &Analyzer{Name: "russian", stop: stopWords(russianStop), stem: stemRussian}
+)
+
+// Analyzers returns every analyzer.
+func Analyzers() []*Analyzer {
+	return []*Analyzer{Simple, English, Russian}
+}
+
+// AnalyzerByName returns the analyzer called name.
+func AnalyzerByName(name string) (*Analyzer, bool) {
+	for _, a := range Analyzers() {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// Terms returns the index terms of s.
+func (a *Analyzer) Terms(s string) []string {
+	tokens := Tokenize(s)
+	terms := tokens[:0]
+	for _, t := range tokens {
+		if a.stop[t] {
+			continue
+		}
+		if a.stem != nil {
+			t = a.stem(t)
+		}
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// Tokenize normalizes s and splits it into runs of letters and digits.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Normalize(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// folds maps letters to the ones they are searched as.
+var folds = map[rune]rune{
+	'ё': 'е', 'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a',
+	'ç': 'c', 'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ì': 'i', 'í': 'i',
+	'î': 'i', 'ï': 'i', 'ñ': 'n', 'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o',
+	'ö': 'o', 'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y',
+}
+
+// Normalize lowercases s, folds letters with diacritics that are often
+// left out, such as ё and é, to their base letters, and drops invisible
+// characters such as soft hyphens.
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if f, ok := folds[r]; ok {
+			return f
+		}
+		if unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+const englishStop = "a an and are as at be but by for if in into is it no not of on or such that the their then there these they this to was will with"
+
+const russianStop = "а без бы был была были было быть в вам вас во вот все всё вы где да для до его её ее если есть ещё еще же за и из или им их к как ко когда кто ли мне мы на над не нет ни но о об он она они оно от по под при с со так там то тоже ты у уж уже что чтобы это я"
+
+func stopWords(list string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		m[Normalize(w)] = true
+	}
+	return m
+}
+
+// stemEnglish applies step 1 of the Porter stemmer: it removes plurals
+// and -ed and -ing, leaving derivational suffixes alone.
+func stemEnglish(w string) string {
+	for _, r := range w {
+		if r < 'a' || r > 'z' {
+			return w
+		}
+	}
+	if len(w) <= 2 {
+		return w
+	}
+
+	// Step 1a.
+	switch {
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	// Step 1b.
+	removed := false
+	switch {
+	case strings.HasSuffix(w, "eed"):
+		if measure(w[:len(w)-3]) > 0 {
+			w = w[:len(w)-1]
+		}
+	case strings.HasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		w, removed = w[:len(w)-2], true
+	case strings.HasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		w, removed = w[:len(w)-3], true
+	}
+	if removed {
+		n := len(w)
+		switch {
+		case strings.HasSuffix(w, "at"), strings.HasSuffix(w, "bl"), strings.HasSuffix(w, "iz"):
+			w += "e"
+		case n >= 2 && w[n-1] == w[n-2] && consonant(w, n-1) && !strings.ContainsRune("lsz", rune(w[n-1])):
+			w = w[:n-1]
+		case measure(w) == 1 && cvc(w):
+			w += "e"
+		}
+	}
+
+	// Step 1c.
+	if strings.HasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		w = w[:len(w)-1] + "i"
+	}
+	return w
+}
+
+// consonant reports whether w[i] is a consonant in the sense of Porter:
+// y is one unless it follows a consonant.
+func consonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !consonant(w, i-1)
+	}
+	return true
+}
+
+func hasVowel(w string) bool {
+	for i := range w {
+		if !consonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// measure counts the vowel-consonant sequences of w.
+func measure(w string) int {
+	m := 0
+	vowel := false
+	for i := range w {
+		if !consonant(w, i) {
+			vowel = true
+		} else if vowel {
+			m++
+			vowel = false
+		}
+	}
+	return m
+}
+
+// cvc reports whether w ends consonant-vowel-consonant, the last not w,
+// x or y.
+func cvc(w string) bool {
+	n := len(w)
+	return n >= 3 && consonant(w, n-3) && !consonant(w, n-2) && consonant(w, n-1) && !strings.ContainsRune("wxy", rune(w[n-1]))
+}
+
+// Endings of the Snowball Russian stemmer. Those in the first group of a
+// kind must follow а or я.
+var (
+	ruGerund1     = []string{"в", "вши", "вшись"}
+	ruGerund2     = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	ruReflexive   = []string{"ся", "сь"}
+	ruAdjective   = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	ruParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	ruParticiple2 = []string{"ивш", "ывш", "ующ"}
+	ruVerb1       = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	ruVerb2       = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"}
+	ruNoun        = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й", "иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я"}
+	ruSuperlative = []string{"ейше", "ейш"}
+	ruDerivation  = []string{"ость", "ост"}
+)
+
+func ruVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// stemRussian implements the Snowball Russian stemmer.
+func stemRussian(w string) string {
+	r := []rune(w)
+	for _, c := range r {
+		if c < 'а' || c > 'я' {
+			return w
+		}
+	}
+
+	rv := len(r)
+	for i, c := range r {
+		if ruVowel(c) {
+			rv = i + 1
+			break
+		}
+	}
+	r2 := region(r, region(r, 0))
+
+	// Step 1.
+	if s, ok := ruEnding(r, rv, ruGerund1, ruGerund2); ok {
+		r = s
+	} else {
+		if s, ok := ruEnding(r, rv, nil, ruReflexive); ok {
+			r = s
+		}
+		if s, ok := ruEnding(r, rv, nil, ruAdjective); ok {
+			r = s
+			if s, ok := ruEnding(r, rv, ruParticiple1, ruParticiple2); ok {
+				r = s
+			}
+		} else if s, ok := ruEnding(r, rv, ruVerb1, ruVerb2); ok {
+			r = s
+		} else if s, ok := ruEnding(r, rv, nil, ruNoun); ok {
+			r = s
+		}
+	}
+
+	// Step 2.
+	if s, ok := ruEnding(r, rv, nil, []string{"и"}); ok {
+		r = s
+	}
+
+	// Step 3.
+	if s, ok := ruEnding(r, max(rv, r2), nil, ruDerivation); ok {
+		r = s
+	}
+
+	// Step 4.
+	if s, ok := ruEnding(r, rv, nil, []string{"нн"}); ok {
+		r = append(s, 'н')
+	} else if s, ok := ruEnding(r, rv, nil, ruSuperlative); ok {
+		r = s
+		if s, ok := ruEnding(r, rv, nil, []string{"нн"}); ok {
+			r = append(s, 'н')
+		}
+	} else if s, ok := ruEnding(r, rv, nil, []string{"ь"}); ok {
+		r = s
+	}
+	return string(r)
+}
+
+// region returns where the region after the first non-vowel following a
+// vowel at or after start begins.
+func region(r []rune, start int) int {
+	for i := start + 1; i < len(r); i++ {
+		if !ruVowel(r[i]) && ruVowel(r[i-1]) {
+			return i + 1
+		}
+	}
+	return len(r)
+}
+
+// ruEnding removes the longest ending of r among after and plain that
+// lies at or after start. Endings in after must follow а or я, which
+// stays; when the longest ending does not, nothing is removed.
+func ruEnding(r []rune, start int, after, plain []string) ([]rune, bool) {
+	best, needsA := 0, false
+	match := func(endings []string, follows bool) {
+		for _, e := range endings {
+			n := utf8.RuneCountInString(e)
+			if n > best && n <= len(r)-start && string(r[len(r)-n:]) == e {
+				best, needsA = n, follows
+			}
+		}
+	}
+	match(after, true)
+	match(plain, false)
+	if best == 0 {
+		return r, false
+	}
+
+	cut := len(r) - best
+	if needsA && (cut-1 < start || r[cut-1] != 'а' && r[cut-1] != 'я') {
+		return r, false
+	}
+	return r[:cut], true
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Date buckets of the Created facet, newest first.
+const (
+	CreatedToday = "today"
+	CreatedWeek  = "week"
+	CreatedMonth = "month"
+	CreatedYear  = "year"
+	CreatedOlder = "older"
+)
+
+// Facet is how many results share a value of a field. Label is the value
+// for people, when it is an ID.
+type Facet struct {
+	Value string `json:"value" example:"work"`
+	Label string `json:"label,omitempty" example:"Работа"`
+	Count int    `json:"count" example:"3"`
+}
+
+// Facets break search results down by field, so a client can offer
+// filters without asking again. Values with no results are left out.
+type Facets struct {
+	Tags []Facet `json:"tags"`
+	// Notebooks are by notebook ID; notes outside notebooks are not counted.
+	Notebooks []Facet `json:"notebooks"`
+	// Created is by how long ago the note was created, in the buckets
+	// today, week, month, year and older, each excluding the ones before.
+	Created []Facet `json:"created"`
+	// Authors are by owner ID.
+	Authors []Facet `json:"authors"`
+}
+
+// CountFacets counts notes by field. Date buckets are relative to now, in
+// its location.
+func CountFacets(notes []core.Note, now time.Time) Facets {
+	tags := make(map[string]int)
+	notebooks := make(map[string]int)
+	created := make(map[string]int)
+	authors := make(map[string]int)
+	for _, n := range notes {
+		for _, tag := range n.Tags {
+			tags[tag]++
+		}
+		if n.NotebookID != 0 {
+			notebooks[strconv.FormatInt(n.NotebookID, 10)]++
+		}
+		created[createdBucket(n.CreatedAt, now)]++
+		authors[n.OwnerID]++
+	}
+
+	f := Facets{
+		Tags:      byCount(tags),
+		Notebooks: byCount(notebooks),
+		Created:   []Facet{},
+		Authors:   byCount(authors),
+	}
+	for _, bucket := range []string{CreatedToday, CreatedWeek, CreatedMonth, CreatedYear, CreatedOlder} {
+		if created[bucket] > 0 {
+			f.Created = append(f.Created, Facet{Value: bucket, Count: created[bucket]})
+		}
+	}
+	return f
+}
+
+func createdBucket(t, now time.Time) string {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch {
+	case !t.Before(today):
+		return CreatedToday
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return CreatedWeek
+	case !t.Before(today.AddDate(0, -1, 0)):
+		return CreatedMonth
+	case !t.Before(today.AddDate(-1, 0, 0)):
+		return CreatedYear
+	}
+	return CreatedOlder
+}
+
+// byCount returns the counts most common first, then by value.
+func byCount(counts map[string]int) []Facet {
+	facets := make([]Facet, 0, len(counts))
+	for v, c := range counts {
+		facets = append(facets, Facet{Value: v, Count: c})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets
+}
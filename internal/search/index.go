@@ -0,0 +1,196 @@
+// Package search keeps an in-memory full-text index of notes, updated from
+// repository changes and rebuilt online when its schema changes.
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+// SchemaVersion identifies how notes are tokenized and weighted. Bump it
+// whenever the analyzers or the field weights change; existing indexes
+// then need a reindex to match.
+const SchemaVersion = 3
+
+// Field weights: a term in the title counts as much as three in the body.
+// Text extracted from attachments counts like the body.
+const (
+	titleWeight      = 3
+	tagWeight        = 2
+	contentWeight    = 1
+	attachmentWeight = 1
+)
+
+// Index is an inverted index from terms to the notes containing them,
+// with a table of terms for each analyzer. Besides the note itself, each
+// note can have extra text, such as text recognized in its attachments.
+type Index struct {
+	mu     sync.RWMutex
+	tables map[*Analyzer]*table
+}
+
+type table struct {
+	postings map[string]map[int64]int
+	terms    map[int64]map[string]int
+	notes    map[int64]map[string]int
+	extra    map[int64]map[string]int
+}
+
+func NewIndex() *Index {
+	ix := &Index{tables: make(map[*Analyzer]*table)}
+	for _, a := range Analyzers() {
+		ix.tables[a] = &table{
+			postings: make(map[string]map[int64]int),
+			terms:    make(map[int64]map[string]int),
+			notes:    make(map[int64]map[string]int),
+			extra:    make(map[int64]map[string]int),
+		}
+	}
+	return ix
+}
+
+func weights(a *Analyzer, n core.Note) map[string]int {
+	tf := make(map[string]int)
+	for _, t := range a.Terms(n.Title) {
+		tf[t] += titleWeight
+	}
+	for _, tag := range n.Tags {
+		for _, t := range a.Terms(tag) {
+			tf[t] += tagWeight
+		}
+	}
+	for _, t := range a.Terms(n.Content) {
+		tf[t] += contentWeight
+	}
+	return tf
+}
+
+// Add indexes n, replacing any previous version of it.
+func (ix *Index) Add(n core.Note) {
+	tfs := make(map[*Analyzer]map[string]int, len(ix.tables))
+	for a := range ix.tables {
+		tfs[a] = weights(a, n)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for a, t := range ix.tables {
+		t.notes[n.ID] = tfs[a]
+		t.update(n.ID)
+	}
+}
+
+// SetExtra replaces the extra text of a note. It can be set before the
+// note is added.
+func (ix *Index) SetExtra(id int64, text string) {
+	tfs := make(map[*Analyzer]map[string]int, len(ix.tables))
+	for a := range ix.tables {
+		tf := make(map[string]int)
+		for _, t := range a.Terms(text) {
+			tf[t] += attachmentWeight
+		}
+		tfs[a] = tf
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for a, t := range ix.tables {
+		if len(tfs[a]) == 0 {
+			delete(t.extra, id)
+		} else {
+			t.extra[id] = tfs[a]
+		}
+		if _, ok := t.notes[id]; ok {
+			t.update(id)
+		}
+	}
+}
+
+// update reindexes a note from its fields and extra text.
+func (t *table) update(id int64) {
+	t.remove(id)
+	tf := make(map[string]int, len(t.notes[id])+len(t.extra[id]))
+	for term, w := range t.notes[id] {
+		tf[term] += w
+	}
+	for term, w := range t.extra[id] {
+		tf[term] += w
+	}
+	for term, w := range tf {
+		p, ok := t.postings[term]
+		if !ok {
+			p = make(map[int64]int)
+			t.postings[term] = p
+		}
+		p[id] = w
+	}
+	t.terms[id] = tf
+}
+
+// Remove drops a note and its extra text.
+func (ix *Index) Remove(id int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, t := range ix.tables {
+		t.remove(id)
+		delete(t.notes, id)
+		delete(t.extra, id)
+	}
+}
+
+func (t *table) remove(id int64) {
+	for term := range t.terms[id] {
+		delete(t.postings[term], id)
+		if len(t.postings[term]) == 0 {
+			delete(t.postings, term)
+		}
+	}
+	delete(t.terms, id)
+}
+
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.tables[Simple].terms)
+}
+
+// Match keeps the notes matching q. When q has words outside NOT, they
+// are scored by weighted term frequency scaled by inverse document
+// frequency, so rare terms matter more, best first; otherwise they keep
+// their order.
+func (ix *Index) Match(notes []core.Note, q *Query) []core.Note {
+	words := q.words()
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	t := ix.tables[q.analyzer]
+	total := float64(len(t.terms))
+	matched := make([]core.Note, 0)
+	scores := make(map[int64]float64)
+	for _, n := range notes {
+		terms := t.terms[n.ID]
+		if !q.Match(n, terms) {
+			continue
+		}
+		matched = append(matched, n)
+		for _, term := range words {
+			if p := t.postings[term]; len(p) > 0 {
+				scores[n.ID] += float64(terms[term]) * math.Log(1+total/float64(len(p)))
+			}
+		}
+	}
+	if len(words) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			return scores[matched[i].ID] > scores[matched[j].ID]
+		})
+	}
+	return matched
+}
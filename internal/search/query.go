@@ -0,0 +1,549 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Query is a parsed search query. Its syntax:
+//
+//	word               notes containing the word, in any field
+//	"exact phrase"     notes whose title, tags or text contain the phrase
+//	field:value        tag:, notebook:, type:, author:, pinned:, title:
+//	created:>DATE      also updated:, with >, >=, <, <= or none for the day
+//	a AND b, a b       both
+//	a OR b             either
+//	NOT a, -field:x    not a
+//	( ... )            grouping
+//
+// AND binds tighter than OR. Operators are upper case; dates are
+// YYYY-MM-DD. Only the fields above are fields: other words with a colon,
+// such as 10:30 or URLs, and words starting with -, are plain words, so a
+// plain list of words finds what it found before the syntax existed.
+type Query struct {
+	root     node
+	analyzer *Analyzer
+}
+
+// ParseError is a syntax error of a query at Pos, counted in characters
+// from 0.
+type ParseError struct {
+	Pos     int    `json:"pos" example:"4"`
+	Message string `json:"message" example:"notebook: needs a notebook ID"`
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("at %d: %s", e.Pos, e.Message)
+}
+
+// Parse parses a query whose words are analyzed by a. Dates are days in
+// loc. Errors are *ParseError.
+func Parse(s string, a *Analyzer, loc *time.Location) (*Query, error) {
+	p := &parser{src: s, analyzer: a, loc: loc}
+	p.next()
+	if p.tok.kind == tokEOF {
+		return nil, p.errorf(p.tok, "empty query")
+	}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf(p.tok, "unexpected %s", p.tok.text)
+	}
+	if root == nil {
+		return nil, &ParseError{Message: "nothing to search for"}
+	}
+	return &Query{root: root, analyzer: a}, nil
+}
+
+// Analyzer returns the analyzer of the words of the query.
+func (q *Query) Analyzer() *Analyzer {
+	return q.analyzer
+}
+
+// String returns the query in canonical form, with operators explicit.
+func (q *Query) String() string {
+	return q.root.String()
+}
+
+// Match reports whether n matches the query; terms are the indexed terms
+// of n.
+func (q *Query) Match(n core.Note, terms map[string]int) bool {
+	return q.root.match(n, terms)
+}
+
+// words returns the words that count towards the score: those not under
+// NOT.
+func (q *Query) words() []string {
+	var words []string
+	var walk func(node, bool)
+	walk = func(nd node, negated bool) {
+		switch nd := nd.(type) {
+		case *andNode:
+			for _, c := range nd.nodes {
+				walk(c, negated)
+			}
+		case *orNode:
+			for _, c := range nd.nodes {
+				walk(c, negated)
+			}
+		case *notNode:
+			walk(nd.node, !negated)
+		case *wordNode:
+			if !negated {
+				words = append(words, nd.terms...)
+			}
+		}
+	}
+	walk(q.root, false)
+	return words
+}
+
+type node interface {
+	match(n core.Note, terms map[string]int) bool
+	String() string
+}
+
+type andNode struct{ nodes []node }
+
+func (a *andNode) match(n core.Note, terms map[string]int) bool {
+	for _, c := range a.nodes {
+		if !c.match(n, terms) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *andNode) String() string {
+	parts := make([]string, len(a.nodes))
+	for i, c := range a.nodes {
+		parts[i] = c.String()
+		if _, ok := c.(*orNode); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+type orNode struct{ nodes []node }
+
+func (o *orNode) match(n core.Note, terms map[string]int) bool {
+	for _, c := range o.nodes {
+		if c.match(n, terms) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *orNode) String() string {
+	parts := make([]string, len(o.nodes))
+	for i, c := range o.nodes {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, " OR ")
+}
+
+type notNode struct{ node node }
+
+func (x *notNode) match(n core.Note, terms map[string]int) bool {
+	return !x.node.match(n, terms)
+}
+
+func (x *notNode) String() string {
+	switch x.node.(type) {
+	case *andNode, *orNode:
+		return "NOT (" + x.node.String() + ")"
+	}
+	return "NOT " + x.node.String()
+}
+
+// wordNode matches notes with every term of a word in the index.
+type wordNode struct {
+	text  string
+	terms []string
+}
+
+func (w *wordNode) match(n core.Note, terms map[string]int) bool {
+	for _, t := range w.terms {
+		if terms[t] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *wordNode) String() string { return w.text }
+
+type phraseNode struct{ text string }
+
+func (p *phraseNode) match(n core.Note, _ map[string]int) bool {
+	phrase := Normalize(p.text)
+	if strings.Contains(Normalize(n.Title), phrase) || strings.Contains(Normalize(n.Content), phrase) {
+		return true
+	}
+	for _, tag := range n.Tags {
+		if strings.Contains(Normalize(tag), phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *phraseNode) String() string { return `"` + p.text + `"` }
+
+// fieldNode matches notes by a field; text is how it was written.
+type fieldNode struct {
+	text string
+	pred func(core.Note) bool
+}
+
+func (f *fieldNode) match(n core.Note, _ map[string]int) bool { return f.pred(n) }
+
+func (f *fieldNode) String() string { return f.text }
+
+const (
+	tokEOF = iota
+	tokWord
+	tokPhrase
+	tokField
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+// fields are the names a field:value token may start with.
+var fields = map[string]bool{
+	"tag": true, "notebook": true, "type": true, "author": true,
+	"pinned": true, "title": true, "created": true, "updated": true,
+}
+
+// fieldName returns the field s starts with, as in tag:x, if any.
+func fieldName(s string) (string, bool) {
+	name, _, ok := strings.Cut(s, ":")
+	name = strings.ToLower(name)
+	return name, ok && fields[name]
+}
+
+type token struct {
+	kind int
+	// pos is the offset of the token in bytes.
+	pos  int
+	text string
+	// field and value split a field token; value is unquoted.
+	field, value string
+}
+
+type parser struct {
+	src      string
+	analyzer *Analyzer
+	loc      *time.Location
+	off      int
+	tok      token
+	err      *ParseError
+}
+
+func (p *parser) errorf(t token, format string, args ...any) *ParseError {
+	return &ParseError{Pos: utf8.RuneCountInString(p.src[:t.pos]), Message: fmt.Sprintf(format, args...)}
+}
+
+// next reads the next token into p.tok, recording the first lexical
+// error in p.err.
+func (p *parser) next() {
+	for p.off < len(p.src) {
+		r, n := utf8.DecodeRuneInString(p.src[p.off:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.off += n
+	}
+	start := p.off
+	if p.off == len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start, text: "end of query"}
+		return
+	}
+
+	switch p.src[p.off] {
+	case '(':
+		p.off++
+		p.tok = token{kind: tokLParen, pos: start, text: "("}
+		return
+	case ')':
+		p.off++
+		p.tok = token{kind: tokRParen, pos: start, text: ")"}
+		return
+	case '-':
+		if _, ok := fieldName(p.src[p.off+1:]); ok {
+			p.off++
+			p.tok = token{kind: tokNot, pos: start, text: "-"}
+			return
+		}
+	case '"':
+		text, ok := p.quoted()
+		p.tok = token{kind: tokPhrase, pos: start, text: text}
+		if !ok && p.err == nil {
+			p.err = p.errorf(p.tok, "unterminated phrase")
+		}
+		return
+	}
+
+	for p.off < len(p.src) {
+		r, n := utf8.DecodeRuneInString(p.src[p.off:])
+		if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+			break
+		}
+		p.off += n
+	}
+	word := p.src[start:p.off]
+	switch word {
+	case "AND":
+		p.tok = token{kind: tokAnd, pos: start, text: word}
+		return
+	case "OR":
+		p.tok = token{kind: tokOr, pos: start, text: word}
+		return
+	case "NOT":
+		p.tok = token{kind: tokNot, pos: start, text: word}
+		return
+	}
+	field, ok := fieldName(word)
+	if !ok {
+		p.tok = token{kind: tokWord, pos: start, text: word}
+		return
+	}
+
+	p.off = start + len(field) + 1
+	var value string
+	if p.off < len(p.src) && p.src[p.off] == '"' {
+		var closed bool
+		value, closed = p.quoted()
+		if !closed && p.err == nil {
+			p.err = p.errorf(token{pos: start}, "unterminated phrase")
+		}
+	} else {
+		valueStart := p.off
+		for p.off < len(p.src) {
+			r, n := utf8.DecodeRuneInString(p.src[p.off:])
+			if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+				break
+			}
+			p.off += n
+		}
+		value = p.src[valueStart:p.off]
+	}
+	p.tok = token{kind: tokField, pos: start, text: p.src[start:p.off], field: field, value: value}
+}
+
+// quoted reads a phrase starting at the opening quote, and whether it was
+// closed.
+func (p *parser) quoted() (string, bool) {
+	p.off++
+	end := strings.IndexByte(p.src[p.off:], '"')
+	if end < 0 {
+		text := p.src[p.off:]
+		p.off = len(p.src)
+		return text, false
+	}
+	text := p.src[p.off : p.off+end]
+	p.off += end + 1
+	return text, true
+}
+
+// or parses operands separated by OR, and returns nil when each was
+// dropped.
+func (p *parser) or() (node, error) {
+	var nodes []node
+	for {
+		nd, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		if nd != nil {
+			nodes = append(nodes, nd)
+		}
+		if p.tok.kind != tokOr {
+			break
+		}
+		p.next()
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+	return &orNode{nodes: nodes}, nil
+}
+
+// and parses operands separated by AND or nothing, and returns nil when
+// each was dropped.
+func (p *parser) and() (node, error) {
+	var nodes []node
+	for {
+		nd, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if nd != nil {
+			nodes = append(nodes, nd)
+		}
+		switch p.tok.kind {
+		case tokAnd:
+			p.next()
+			continue
+		case tokWord, tokPhrase, tokField, tokLParen, tokNot:
+			continue
+		}
+		break
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+	return &andNode{nodes: nodes}, nil
+}
+
+// unary parses an operand, possibly negated. Words without terms, such
+// as stop words, are dropped: it returns nil for them.
+func (p *parser) unary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	t := p.tok
+	switch t.kind {
+	case tokNot:
+		p.next()
+		nd, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if nd == nil {
+			return nil, p.errorf(t, "nothing to negate")
+		}
+		return &notNode{node: nd}, nil
+	case tokLParen:
+		p.next()
+		if p.tok.kind == tokRParen {
+			return nil, p.errorf(p.tok, "empty parentheses")
+		}
+		nd, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf(p.tok, "missing )")
+		}
+		p.next()
+		return nd, nil
+	case tokWord:
+		p.next()
+		terms := p.analyzer.Terms(t.text)
+		if len(terms) == 0 {
+			return nil, nil
+		}
+		return &wordNode{text: t.text, terms: terms}, nil
+	case tokPhrase:
+		p.next()
+		if strings.TrimSpace(t.text) == "" {
+			return nil, p.errorf(t, "empty phrase")
+		}
+		return &phraseNode{text: t.text}, nil
+	case tokField:
+		p.next()
+		return p.field(t)
+	}
+	return nil, p.errorf(t, "unexpected %s", t.text)
+}
+
+func (p *parser) field(t token) (node, error) {
+	op, value := "", t.value
+	if t.field == "created" || t.field == "updated" {
+		for _, o := range []string{">=", "<=", ">", "<"} {
+			if v, ok := strings.CutPrefix(value, o); ok {
+				op, value = o, v
+				break
+			}
+		}
+	}
+	if value == "" {
+		return nil, p.errorf(t, "%s: needs a value", t.field)
+	}
+
+	text := t.field + ":" + op + value
+	if strings.ContainsFunc(value, unicode.IsSpace) {
+		text = t.field + `:"` + value + `"`
+	}
+	nd := &fieldNode{text: text}
+	switch t.field {
+	case "tag":
+		tag := core.NormalizeTag(value)
+		nd.pred = func(n core.Note) bool { return core.HasTag(n, tag) }
+	case "notebook":
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, p.errorf(t, "notebook: needs a notebook ID")
+		}
+		nd.pred = func(n core.Note) bool { return n.NotebookID == id }
+	case "type":
+		if value != core.NoteTypeNote && value != core.NoteTypeSnippet {
+			return nil, p.errorf(t, "type: is note or snippet")
+		}
+		nd.pred = func(n core.Note) bool { return n.Type == value }
+	case "author":
+		nd.pred = func(n core.Note) bool { return n.OwnerID == value }
+	case "pinned":
+		pinned, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, p.errorf(t, "pinned: is true or false")
+		}
+		nd.pred = func(n core.Note) bool { return n.Pinned == pinned }
+	case "title":
+		value := Normalize(value)
+		nd.pred = func(n core.Note) bool { return strings.Contains(Normalize(n.Title), value) }
+	case "created", "updated":
+		day, err := time.ParseInLocation("2006-01-02", value, p.loc)
+		if err != nil {
+			return nil, p.errorf(t, "%s: needs a date as YYYY-MM-DD", t.field)
+		}
+		field := t.field
+		nd.pred = func(n core.Note) bool {
+			at := n.CreatedAt
+			if field == "updated" {
+				if n.UpdatedAt == nil {
+					return false
+				}
+				at = *n.UpdatedAt
+			}
+			return inDays(at, day, op)
+		}
+	}
+	return nd, nil
+}
+
+// inDays compares t with the day starting at day.
+func inDays(t, day time.Time, op string) bool {
+	next := day.AddDate(0, 0, 1)
+	switch op {
+	case ">":
+		return !t.Before(next)
+	case ">=":
+		return !t.Before(day)
+	case "<":
+		return t.Before(day)
+	case "<=":
+		return t.Before(next)
+	}
+	return !t.Before(day) && t.Before(next)
+}
@@ -0,0 +1,202 @@
+package search
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+var ErrReindexRunning = errors.New("reindex already running")
+
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+)
+
+// Job reports the progress of a reindex.
+type Job struct {
+	ID            int64      `json:"id" example:"1"`
+	State         string     `json:"state" example:"running" enums:"running,done"`
+	SchemaVersion int        `json:"schema_version" example:"1"`
+	Total         int        `json:"total" example:"1200"`
+	Done          int64      `json:"done" example:"300"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// Service serves searches from the current index while a reindex builds a
+// replacement in the background. Changes made during the build are
+// replayed onto the new index before it is swapped in, so searches never
+// see a partial or stale index.
+type Service struct {
+	// Analyzer analyzes the searches of users who chose none; nil means
+	// Simple.
+	Analyzer *Analyzer
+	// Clock times reindex jobs.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	current *Index
+	next    *Index
+	pending []repo.Change
+	job     *Job
+	done    atomic.Int64
+	jobs    int64
+	// texts holds the text found in attachments, by note and attachment.
+	texts map[int64]map[int64]string
+}
+
+func NewService() *Service {
+	return &Service{Clock: clock.System{}, current: NewIndex(), texts: make(map[int64]map[int64]string)}
+}
+
+// Apply updates the index with a repository change. Register it with
+// NoteRepoMem.OnChange.
+func (s *Service) Apply(c repo.Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apply(s.current, c)
+	if s.next != nil {
+		s.pending = append(s.pending, c)
+	}
+	if c.Op == repo.ChangeDeleted {
+		delete(s.texts, c.Note.ID)
+	}
+}
+
+// SetAttachmentText makes text found in an attachment, such as by OCR,
+// searchable as part of its note. Empty text removes it.
+func (s *Service) SetAttachmentText(noteID, attachmentID int64, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	texts := s.texts[noteID]
+	if texts == nil {
+		texts = make(map[int64]string)
+		s.texts[noteID] = texts
+	}
+	if text == "" {
+		delete(texts, attachmentID)
+	} else {
+		texts[attachmentID] = text
+	}
+	if len(texts) == 0 {
+		delete(s.texts, noteID)
+	}
+
+	joined := s.attachmentText(noteID)
+	s.current.SetExtra(noteID, joined)
+	if s.next != nil {
+		s.next.SetExtra(noteID, joined)
+	}
+}
+
+// attachmentText joins the texts of a note's attachments in attachment
+// order. The caller holds s.mu.
+func (s *Service) attachmentText(noteID int64) string {
+	ids := make([]int64, 0, len(s.texts[noteID]))
+	for id := range s.texts[noteID] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = s.texts[noteID][id]
+	}
+	return strings.Join(parts, "\n")
+}
+
+func apply(ix *Index, c repo.Change) {
+	if c.Op == repo.ChangeDeleted {
+		ix.Remove(c.Note.ID)
+	} else {
+		ix.Add(c.Note)
+	}
+}
+
+// Match keeps the notes matching q; see Index.Match.
+func (s *Service) Match(notes []core.Note, q *Query) []core.Note {
+	s.mu.Lock()
+	ix := s.current
+	s.mu.Unlock()
+
+	return ix.Match(notes, q)
+}
+
+// Job returns the status of the last reindex, or nil if none ran.
+func (s *Service) Job() *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.job == nil {
+		return nil
+	}
+	job := *s.job
+	if job.State == JobRunning {
+		job.Done = s.done.Load()
+	}
+	return &job
+}
+
+// Reindex rebuilds the index in the background from snapshot, which must
+// return every note and the sequence number of the last change included,
+// such as NoteRepoMem.Snapshot.
+func (s *Service) Reindex(snapshot func() ([]core.Note, int64)) (*Job, error) {
+	s.mu.Lock()
+	if s.next != nil {
+		s.mu.Unlock()
+		return nil, ErrReindexRunning
+	}
+	// Start collecting changes before the snapshot so none fall in between.
+	s.next = NewIndex()
+	s.pending = nil
+	s.mu.Unlock()
+
+	notes, seq := snapshot()
+
+	s.mu.Lock()
+	s.jobs++
+	s.job = &Job{ID: s.jobs, State: JobRunning, SchemaVersion: SchemaVersion, Total: len(notes), StartedAt: s.Clock.Now()}
+	s.done.Store(0)
+	job := *s.job
+	s.mu.Unlock()
+
+	go s.build(notes, seq)
+	return &job, nil
+}
+
+func (s *Service) build(notes []core.Note, seq int64) {
+	ix := s.next
+	for _, n := range notes {
+		ix.Add(n)
+		s.done.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.pending {
+		if c.Seq > seq {
+			apply(ix, c)
+		}
+	}
+	for noteID := range s.texts {
+		ix.SetExtra(noteID, s.attachmentText(noteID))
+	}
+	s.current = ix
+	s.next = nil
+	s.pending = nil
+
+	now := s.Clock.Now()
+	s.job.State = JobDone
+	s.job.Done = int64(len(notes))
+	s.job.FinishedAt = &now
+}
@@ -0,0 +1,160 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/repo"
+)
+
+// DefaultTitleDebounce is how long Titles serves lookups from its sorted
+// words before rebuilding them with the changes since.
+const DefaultTitleDebounce = time.Second
+
+type titleWord struct {
+	word string
+	id   int64
+}
+
+// Titles is a prefix index over note titles for search as you type.
+// Lookups search a sorted list of title words. Rebuilding it is costly,
+// so changes are batched: the list is rebuilt on a lookup at most once
+// per Debounce, and lookups in between may miss the latest titles.
+type Titles struct {
+	// Clock times the debounce; Debounce defaults to
+	// DefaultTitleDebounce.
+	Clock    clock.Clock
+	Debounce time.Duration
+
+	mu sync.Mutex
+	// titles are the words of each title, normalized and joined by
+	// spaces.
+	titles  map[int64]string
+	words   []titleWord
+	dirty   bool
+	builtAt time.Time
+}
+
+func NewTitles() *Titles {
+	return &Titles{Clock: clock.System{}, titles: make(map[int64]string)}
+}
+
+// Apply records a repository change. Register it with
+// NoteRepoMem.OnChange.
+func (t *Titles) Apply(c repo.Change) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	title := strings.Join(Tokenize(c.Note.Title), " ")
+	old, ok := t.titles[c.Note.ID]
+	switch {
+	case c.Op == repo.ChangeDeleted:
+		delete(t.titles, c.Note.ID)
+	case !ok || old != title:
+		t.titles[c.Note.ID] = title
+	default:
+		return
+	}
+	t.dirty = true
+}
+
+// Lookup returns up to limit IDs of notes for which keep returns true and
+// whose title has words starting with every word of query. Titles that
+// start with the query come first, then shorter titles, then newer notes.
+func (t *Titles) Lookup(query string, limit int, keep func(id int64) bool) []int64 {
+	prefixes := Tokenize(query)
+	if len(prefixes) == 0 || limit <= 0 {
+		return []int64{}
+	}
+
+	t.mu.Lock()
+	t.rebuild()
+	words := t.words
+	t.mu.Unlock()
+
+	var found map[int64]bool
+	for _, prefix := range prefixes {
+		next := make(map[int64]bool)
+		i := sort.Search(len(words), func(i int) bool { return words[i].word >= prefix })
+		for ; i < len(words) && strings.HasPrefix(words[i].word, prefix); i++ {
+			if found == nil || found[words[i].id] {
+				next[words[i].id] = true
+			}
+		}
+		found = next
+	}
+
+	t.mu.Lock()
+	type candidate struct {
+		id    int64
+		start bool
+		len   int
+	}
+	joined := strings.Join(prefixes, " ")
+	candidates := make([]candidate, 0, len(found))
+	for id := range found {
+		title, ok := t.titles[id]
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, candidate{id: id, start: strings.HasPrefix(title, joined), len: len(title)})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.start != b.start {
+			return a.start
+		}
+		if a.len != b.len {
+			return a.len < b.len
+		}
+		return a.id > b.id
+	})
+	ids := make([]int64, 0, limit)
+	for _, c := range candidates {
+		if len(ids) == limit {
+			break
+		}
+		if keep(c.id) {
+			ids = append(ids, c.id)
+		}
+	}
+	return ids
+}
+
+// rebuild sorts the title words again if titles changed and the last
+// build is older than the debounce. The caller holds t.mu.
+func (t *Titles) rebuild() {
+	debounce := t.Debounce
+	if debounce == 0 {
+		debounce = DefaultTitleDebounce
+	}
+	now := t.Clock.Now()
+	if !t.dirty || now.Sub(t.builtAt) < debounce {
+		return
+	}
+
+	words := make([]titleWord, 0, len(t.words))
+	for id, title := range t.titles {
+		seen := make(map[string]bool)
+		for _, w := range strings.Fields(title) {
+			if !seen[w] {
+				seen[w] = true
+				words = append(words, titleWord{word: w, id: id})
+			}
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].word != words[j].word {
+			return words[i].word < words[j].word
+		}
+		return words[i].id < words[j].id
+	})
+	t.words = words
+	t.dirty = false
+	t.builtAt = now
+}
@@ -0,0 +1,318 @@
+// Package site builds a static HTML site of notes: an index by notebook
+// and tag, a page per note and a search page over an index embedded in the
+// site, zipped so that it can be published to any static host.
+package site
+
+import (
+	"archive/zip"
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/render"
+)
+
+//go:embed templates
+var files embed.FS
+
+var pages = template.Must(template.ParseFS(files, "templates/*.html"))
+
+// Site is what an export publishes. Notebooks are those whose pages the
+// site has, with their paths; notes in other notebooks are only listed
+// by tag and on the index.
+type Site struct {
+	Title     string
+	Notebooks []core.Notebook
+	Notes     []core.Note
+	// Emoji maps custom emoji shortcodes to image URLs.
+	Emoji map[string]string
+	// Generated dates the files that are not notes.
+	Generated time.Time
+}
+
+type link struct {
+	// URL is relative to the root of the site.
+	URL   string
+	Title string
+	Count int
+}
+
+type noteLink struct {
+	URL     string
+	Title   string
+	Updated time.Time
+}
+
+type layout struct {
+	// Root leads from the page to the root of the site.
+	Root  string
+	Site  string
+	Title string
+}
+
+type indexPage struct {
+	layout
+	Notebooks []link
+	Tags      []link
+	Notes     []noteLink
+}
+
+type listPage struct {
+	layout
+	Notebooks []link
+	Notes     []noteLink
+}
+
+type notePage struct {
+	layout
+	Notebook *link
+	Tags     []link
+	Body     template.HTML
+	Created  time.Time
+	Updated  time.Time
+	Edited   bool
+}
+
+// entry is a note in the search index.
+type entry struct {
+	Title    string   `json:"title"`
+	URL      string   `json:"url"`
+	Notebook string   `json:"notebook,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Text     string   `json:"text"`
+}
+
+// Write zips the site: index.html, search.html, a page per note in notes/,
+// per notebook in notebooks/ and per tag in tags/, and the stylesheet and
+// search scripts.
+func Write(w io.Writer, s Site) error {
+	b := newBuilder(s)
+	zw := zip.NewWriter(w)
+
+	put := func(name string, modified time.Time, write func(io.Writer) error) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		return write(fw)
+	}
+	page := func(name, tmpl string, data any) error {
+		return put(name, s.Generated, func(w io.Writer) error { return pages.ExecuteTemplate(w, tmpl, data) })
+	}
+	static := func(name string) error {
+		return put(name, s.Generated, func(w io.Writer) error {
+			data, err := files.ReadFile("templates/" + name)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		})
+	}
+
+	if err := page("index.html", "index.html", b.index()); err != nil {
+		return err
+	}
+	if err := page("search.html", "search.html", layout{Site: s.Title, Title: "Поиск"}); err != nil {
+		return err
+	}
+	for _, name := range []string{"style.css", "search.js"} {
+		if err := static(name); err != nil {
+			return err
+		}
+	}
+	index, err := json.Marshal(b.searchIndex())
+	if err != nil {
+		return err
+	}
+	if err := put("search-index.js", s.Generated, func(w io.Writer) error {
+		_, err := io.WriteString(w, "var searchIndex = "+string(index)+";\n")
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, nb := range b.notebooks {
+		if err := page(b.notebookURL[nb.ID], "list.html", b.notebookPage(nb)); err != nil {
+			return err
+		}
+	}
+	for _, tag := range b.tags {
+		if err := page(b.tagURL[tag], "list.html", b.tagPage(tag)); err != nil {
+			return err
+		}
+	}
+	for _, n := range s.Notes {
+		updated := n.CreatedAt
+		if n.UpdatedAt != nil {
+			updated = *n.UpdatedAt
+		}
+		if err := put(b.noteURL[n.ID], updated, func(w io.Writer) error {
+			return pages.ExecuteTemplate(w, "note.html", b.notePage(n))
+		}); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// builder names the pages of a site and prepares their data.
+type builder struct {
+	site        Site
+	notebooks   []core.Notebook
+	tags        []string
+	noteURL     map[int64]string
+	notebookURL map[int64]string
+	tagURL      map[string]string
+}
+
+func newBuilder(s Site) *builder {
+	b := &builder{
+		site:        s,
+		noteURL:     make(map[int64]string),
+		notebookURL: make(map[int64]string),
+		tagURL:      make(map[string]string),
+	}
+
+	b.notebooks = append([]core.Notebook(nil), s.Notebooks...)
+	sort.Slice(b.notebooks, func(i, j int) bool { return pathName(b.notebooks[i].Path) < pathName(b.notebooks[j].Path) })
+	for _, nb := range b.notebooks {
+		b.notebookURL[nb.ID] = "notebooks/" + fileName(nb.ID, nb.Name)
+	}
+	for _, n := range s.Notes {
+		b.noteURL[n.ID] = "notes/" + fileName(n.ID, n.Title)
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range core.CountTags(s.Notes) {
+		b.tags = append(b.tags, t.Tag)
+	}
+	sort.Strings(b.tags)
+	for _, tag := range b.tags {
+		name := core.Slugify(tag)
+		if name == "" {
+			name = "tag"
+		}
+		unique := name
+		for i := 2; seen[unique]; i++ {
+			unique = name + "-" + strconv.Itoa(i)
+		}
+		seen[unique] = true
+		b.tagURL[tag] = "tags/" + unique + ".html"
+	}
+	return b
+}
+
+// fileName names the page of a note or notebook after its ID, which keeps
+// names unique, and its slug, which makes them readable.
+func fileName(id int64, title string) string {
+	name := strconv.FormatInt(id, 10)
+	if slug := core.Slugify(title); slug != "" {
+		name += "-" + slug
+	}
+	return name + ".html"
+}
+
+func pathName(path []core.NotebookRef) string {
+	names := make([]string, len(path))
+	for i, ref := range path {
+		names[i] = ref.Name
+	}
+	return strings.Join(names, " / ")
+}
+
+// notes returns links to the notes keep picks, most recently updated
+// first.
+func (b *builder) notes(keep func(core.Note) bool) []noteLink {
+	var links []noteLink
+	for _, n := range b.site.Notes {
+		if !keep(n) {
+			continue
+		}
+		updated := n.CreatedAt
+		if n.UpdatedAt != nil {
+			updated = *n.UpdatedAt
+		}
+		links = append(links, noteLink{URL: b.noteURL[n.ID], Title: n.Title, Updated: updated})
+	}
+	sort.SliceStable(links, func(i, j int) bool { return links[i].Updated.After(links[j].Updated) })
+	return links
+}
+
+func (b *builder) index() indexPage {
+	p := indexPage{layout: layout{Site: b.site.Title, Title: b.site.Title}}
+	counts := make(map[int64]int)
+	for _, n := range b.site.Notes {
+		counts[n.NotebookID]++
+	}
+	for _, nb := range b.notebooks {
+		p.Notebooks = append(p.Notebooks, link{URL: b.notebookURL[nb.ID], Title: pathName(nb.Path), Count: counts[nb.ID]})
+	}
+	tagCounts := make(map[string]int)
+	for _, t := range core.CountTags(b.site.Notes) {
+		tagCounts[t.Tag] = t.Count
+	}
+	for _, tag := range b.tags {
+		p.Tags = append(p.Tags, link{URL: b.tagURL[tag], Title: tag, Count: tagCounts[tag]})
+	}
+	p.Notes = b.notes(func(core.Note) bool { return true })
+	return p
+}
+
+func (b *builder) notebookPage(nb core.Notebook) listPage {
+	p := listPage{layout: layout{Root: "../", Site: b.site.Title, Title: pathName(nb.Path)}}
+	for _, child := range b.notebooks {
+		if child.ParentID == nb.ID {
+			p.Notebooks = append(p.Notebooks, link{URL: b.notebookURL[child.ID], Title: child.Name})
+		}
+	}
+	p.Notes = b.notes(func(n core.Note) bool { return n.NotebookID == nb.ID })
+	return p
+}
+
+func (b *builder) tagPage(tag string) listPage {
+	p := listPage{layout: layout{Root: "../", Site: b.site.Title, Title: "#" + tag}}
+	p.Notes = b.notes(func(n core.Note) bool { return core.HasTag(n, tag) })
+	return p
+}
+
+func (b *builder) notePage(n core.Note) notePage {
+	p := notePage{
+		layout:  layout{Root: "../", Site: b.site.Title, Title: n.Title},
+		Body:    render.Body(n, b.site.Emoji),
+		Created: n.CreatedAt,
+		Updated: n.CreatedAt,
+		Edited:  n.UpdatedAt != nil,
+	}
+	if n.UpdatedAt != nil {
+		p.Updated = *n.UpdatedAt
+	}
+	if url, ok := b.notebookURL[n.NotebookID]; ok {
+		p.Notebook = &link{URL: url, Title: pathName(n.Path)}
+	}
+	for _, tag := range n.Tags {
+		p.Tags = append(p.Tags, link{URL: b.tagURL[tag], Title: tag})
+	}
+	return p
+}
+
+func (b *builder) searchIndex() []entry {
+	entries := make([]entry, 0, len(b.site.Notes))
+	for _, n := range b.site.Notes {
+		entries = append(entries, entry{
+			Title:    n.Title,
+			URL:      b.noteURL[n.ID],
+			Notebook: pathName(n.Path),
+			Tags:     n.Tags,
+			Text:     strings.Join(strings.Fields(n.Content), " "),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,50 @@
+// Searches the notes of searchIndex for every word of the q parameter,
+// titles first.
+(function () {
+  var input = document.querySelector("nav input");
+  var results = document.getElementById("results");
+  var status = document.getElementById("status");
+
+  function normalize(s) {
+    return s.toLowerCase().replace(/ё/g, "е");
+  }
+
+  function search(q) {
+    var words = normalize(q).split(/\s+/).filter(Boolean);
+    results.innerHTML = "";
+    if (words.length === 0) {
+      status.textContent = "Введите запрос.";
+      return;
+    }
+    var hits = [];
+    searchIndex.forEach(function (note) {
+      var title = normalize(note.title);
+      var text = [title, normalize(note.notebook || ""), normalize((note.tags || []).join(" ")), normalize(note.text)].join("\n");
+      var inTitle = 0;
+      for (var i = 0; i < words.length; i++) {
+        if (text.indexOf(words[i]) < 0) {
+          return;
+        }
+        if (title.indexOf(words[i]) >= 0) {
+          inTitle++;
+        }
+      }
+      hits.push({ note: note, score: inTitle });
+    });
+    hits.sort(function (a, b) { return b.score - a.score; });
+    status.textContent = hits.length ? "Найдено: " + hits.length : "Ничего не найдено.";
+    hits.forEach(function (hit) {
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.href = hit.note.url;
+      a.textContent = hit.note.title;
+      li.appendChild(a);
+      results.appendChild(li);
+    });
+  }
+
+  var q = new URLSearchParams(location.search).get("q") || "";
+  input.value = q;
+  input.addEventListener("input", function () { search(input.value); });
+  search(q);
+})();
@@ -0,0 +1,342 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// basePath is the @BasePath of the API; contract routes are relative to it.
+const basePath = "/api/v1"
+
+// Contract holds the responses each route documents in its swag
+// annotations, which the OpenAPI spec is generated from. Checking against
+// the annotations rather than docs/swagger.json keeps the check meaningful
+// even when the generated spec is stale.
+type Contract struct {
+	routes  []*route
+	structs map[string]*ast.StructType
+}
+
+type route struct {
+	method    string
+	path      string
+	pattern   *regexp.Regexp
+	handler   string
+	responses map[int]*documented
+}
+
+type documented struct {
+	kind     string // object, array, string, file or empty
+	typ      string
+	headers  []string
+	optional map[string]bool // headers documented as sometimes left out
+}
+
+// contractPackages are parsed for response types, keyed by the name
+// annotations use for them.
+var contractPackages = map[string]string{
+	"core":      "core",
+	"handlers":  "http/handlers",
+	"notify":    "notify",
+	"search":    "search",
+	"events":    "events",
+	"cloudsync": "cloudsync",
+	"health":    "health",
+	"repo":      "repo",
+	"jobs":      "jobs",
+	"metrics":   "metrics",
+	"attach":    "attach",
+	"audit":     "audit",
+	"preview":   "preview",
+	"render":    "render",
+}
+
+var (
+	routeLine    = regexp.MustCompile(`^@Router\s+(\S+)\s+\[(\w+)\]`)
+	responseLine = regexp.MustCompile(`^@(?:Success|Failure)\s+(\d+)\s+(?:\{(\w+)\}\s+(\S+))?`)
+	headerLine   = regexp.MustCompile(`^@Header\s+(\d+)\s+\{\w+\}\s+(\S+)\s*(?:"([^"]*)")?`)
+	pathParam    = regexp.MustCompile(`\{[^}/]+\}`)
+)
+
+// optionalHeader starts the description of a header a response may leave
+// out; the tests that rely on it assert it themselves.
+const optionalHeader = "Необязательный"
+
+// LoadContract parses the annotations of the handlers package.
+func LoadContract() (*Contract, error) {
+	_, file, _, _ := runtime.Caller(0)
+	internal := filepath.Join(filepath.Dir(file), "..")
+
+	c := &Contract{structs: make(map[string]*ast.StructType)}
+	fset := token.NewFileSet()
+	for name, dir := range contractPackages {
+		pkgs, err := parser.ParseDir(fset, filepath.Join(internal, dir), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for pkgName, pkg := range pkgs {
+			if strings.HasSuffix(pkgName, "_test") {
+				continue
+			}
+			for _, f := range pkg.Files {
+				c.collectStructs(name, f)
+				if name == "handlers" {
+					c.collectRoutes(f)
+				}
+			}
+		}
+	}
+	sort.Slice(c.routes, func(i, j int) bool {
+		// Literal segments win over parameters: /notes/nearby before /notes/{id}.
+		return strings.Count(c.routes[i].path, "{") < strings.Count(c.routes[j].path, "{")
+	})
+	return c, nil
+}
+
+func (c *Contract) collectStructs(pkg string, f *ast.File) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				c.structs[pkg+"."+ts.Name.Name] = st
+			}
+		}
+	}
+}
+
+func (c *Contract) collectRoutes(f *ast.File) {
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Doc == nil {
+			continue
+		}
+		r := &route{handler: fn.Name.Name, responses: make(map[int]*documented)}
+		for _, comment := range fn.Doc.List {
+			line := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			if m := routeLine.FindStringSubmatch(line); m != nil {
+				r.path = m[1]
+				r.method = strings.ToUpper(m[2])
+			} else if m := responseLine.FindStringSubmatch(line); m != nil {
+				code, _ := strconv.Atoi(m[1])
+				d := r.response(code)
+				d.kind, d.typ = m[2], m[3]
+			} else if m := headerLine.FindStringSubmatch(line); m != nil {
+				code, _ := strconv.Atoi(m[1])
+				d := r.response(code)
+				d.headers = append(d.headers, m[2])
+				if strings.HasPrefix(m[3], optionalHeader) {
+					if d.optional == nil {
+						d.optional = make(map[string]bool)
+					}
+					d.optional[m[2]] = true
+				}
+			}
+		}
+		if r.path == "" {
+			continue
+		}
+		segments := pathParam.Split(r.path, -1)
+		for i := range segments {
+			segments[i] = regexp.QuoteMeta(segments[i])
+		}
+		r.pattern = regexp.MustCompile("^" + basePath + strings.Join(segments, `[^/]+`) + "$")
+		c.routes = append(c.routes, r)
+	}
+}
+
+func (r *route) response(code int) *documented {
+	d := r.responses[code]
+	if d == nil {
+		d = &documented{}
+		r.responses[code] = d
+	}
+	return d
+}
+
+// Check returns how a response diverges from the contract, or nil when it
+// does not. Requests outside the API base path are not checked.
+func (c *Contract) Check(method, path string, code int, header http.Header, body []byte) []string {
+	if !strings.HasPrefix(path, basePath+"/") {
+		return nil
+	}
+	path, _, _ = strings.Cut(path, "?")
+
+	find := func(method string) *route {
+		for _, candidate := range c.routes {
+			if candidate.method == method && candidate.pattern.MatchString(path) {
+				return candidate
+			}
+		}
+		return nil
+	}
+	r := find(method)
+	// The router serves HEAD through GET where HEAD has no route of its
+	// own; such answers are those of GET without the body.
+	implied := r == nil && method == http.MethodHead
+	if implied {
+		r = find(http.MethodGet)
+	}
+	if r == nil {
+		return []string{fmt.Sprintf("%s %s is not documented", method, path)}
+	}
+
+	// Errors every route can return through the middleware.
+	if code == http.StatusUnauthorized || code == http.StatusTooManyRequests {
+		return nil
+	}
+
+	d, ok := r.responses[code]
+	if !ok {
+		return []string{fmt.Sprintf("%s (%s %s) answered %d, which is not documented", r.handler, method, r.path, code)}
+	}
+
+	var problems []string
+	for _, h := range d.headers {
+		if header.Get(h) == "" && !d.optional[h] {
+			problems = append(problems, fmt.Sprintf("%s %d is missing documented header %s", r.handler, code, h))
+		}
+	}
+
+	if implied || !strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+		return problems
+	}
+	for _, p := range c.checkBody(d, body) {
+		problems = append(problems, fmt.Sprintf("%s %d: %s", r.handler, code, p))
+	}
+	return problems
+}
+
+func (c *Contract) checkBody(d *documented, body []byte) []string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []string{"body is not JSON: " + err.Error()}
+	}
+
+	switch d.kind {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("body is %T, documented as object %s", v, d.typ)}
+		}
+		return c.checkObject(d.typ, obj)
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("body is %T, documented as array of %s", v, d.typ)}
+		}
+		for _, item := range arr {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if problems := c.checkObject(d.typ, obj); len(problems) > 0 {
+				return problems
+			}
+		}
+	case "":
+		return []string{"body documented as empty"}
+	}
+	return nil
+}
+
+// checkObject reports keys of obj that the documented struct type does
+// not have. Types it cannot resolve, such as maps, are not checked.
+func (c *Contract) checkObject(typ string, obj map[string]interface{}) []string {
+	if !strings.Contains(typ, ".") {
+		typ = "handlers." + typ
+	}
+	keys, ok := c.jsonKeys(typ)
+	if !ok {
+		return nil
+	}
+
+	var problems []string
+	for key := range obj {
+		if !keys[key] {
+			problems = append(problems, fmt.Sprintf("field %q is not in %s", key, typ))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// jsonKeys returns the JSON field names of a struct type, following
+// embedded structs the way encoding/json does.
+func (c *Contract) jsonKeys(typ string) (map[string]bool, bool) {
+	st, ok := c.structs[typ]
+	if !ok {
+		return nil, false
+	}
+	pkg, _, _ := strings.Cut(typ, ".")
+
+	keys := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			s, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(s).Get("json")
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			embedded := embeddedType(pkg, field.Type)
+			if name == "" {
+				if inner, ok := c.jsonKeys(embedded); ok {
+					for k := range inner {
+						keys[k] = true
+					}
+					continue
+				}
+			}
+			if name == "" {
+				_, name, _ = strings.Cut(embedded, ".")
+			}
+			keys[name] = true
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			if name != "" {
+				keys[name] = true
+			} else {
+				keys[ident.Name] = true
+			}
+		}
+	}
+	return keys, true
+}
+
+func embeddedType(pkg string, expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedType(pkg, t.X)
+	case *ast.Ident:
+		return pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			return x.Name + "." + t.Sel.Name
+		}
+	}
+	return ""
+}
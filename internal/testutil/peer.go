@@ -0,0 +1,169 @@
+package testutil
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Peer is a fake server for the network clients (Redis, NATS and the
+// like). It listens on a local port and runs serve on every connection a
+// client opens, so that tests can script the protocol on the other end.
+type Peer struct {
+	// Addr is the host:port clients dial.
+	Addr     string
+	accepted atomic.Int32
+}
+
+// Listen starts a Peer that runs serve on each connection, closing the
+// connection when serve returns. Everything is shut down when the test
+// ends.
+func Listen(t testing.TB, serve func(c *PeerConn)) *Peer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Peer{Addr: l.Addr().String()}
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+		wg    sync.WaitGroup
+	)
+	t.Cleanup(func() {
+		l.Close()
+		mu.Lock()
+		for _, c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	})
+
+	go func() {
+		for {
+			nc, err := l.Accept()
+			if err != nil {
+				return
+			}
+			p.accepted.Add(1)
+			mu.Lock()
+			conns = append(conns, nc)
+			mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer nc.Close()
+				nc.SetDeadline(time.Now().Add(5 * time.Second))
+				serve(&PeerConn{t: t, Conn: nc, r: bufio.NewReader(nc)})
+			}()
+		}
+	}()
+	return p
+}
+
+// Accepted is the number of connections clients have opened.
+func (p *Peer) Accepted() int {
+	return int(p.accepted.Load())
+}
+
+// PeerConn is the server end of one client connection. Its methods report
+// protocol errors on the test rather than returning them; after one, reads
+// return empty values.
+type PeerConn struct {
+	net.Conn
+	t      testing.TB
+	r      *bufio.Reader
+	failed bool
+}
+
+// Line reads the next line the client sent, without its line ending. It
+// returns "" once either end has closed the connection.
+func (c *PeerConn) Line() string {
+	if c.failed {
+		return ""
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+			c.t.Errorf("peer: read: %v", err)
+		}
+		c.failed = true
+		return ""
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+// Expect reads the next line and reports it on the test unless it is
+// want. It returns whether the line matched.
+func (c *PeerConn) Expect(want string) bool {
+	got := c.Line()
+	if got != want {
+		if !c.failed {
+			c.t.Errorf("peer: client sent %q, want %q", got, want)
+		}
+		c.failed = true
+		return false
+	}
+	return true
+}
+
+// Bytes reads n bytes the client sent.
+func (c *PeerConn) Bytes(n int) []byte {
+	if c.failed {
+		return nil
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		c.t.Errorf("peer: read %d bytes: %v", n, err)
+		c.failed = true
+		return nil
+	}
+	return data
+}
+
+// Command reads a Redis command: an array of bulk strings.
+func (c *PeerConn) Command() []string {
+	n, err := strconv.Atoi(strings.TrimPrefix(c.Line(), "*"))
+	if err != nil {
+		if !c.failed {
+			c.t.Errorf("peer: not a Redis command: %v", err)
+		}
+		c.failed = true
+		return nil
+	}
+	args := make([]string, n)
+	for i := range args {
+		size, err := strconv.Atoi(strings.TrimPrefix(c.Line(), "$"))
+		if err != nil {
+			if !c.failed {
+				c.t.Errorf("peer: not a Redis bulk string: %v", err)
+			}
+			c.failed = true
+			return nil
+		}
+		data := c.Bytes(size + 2)
+		if len(data) < size {
+			return nil
+		}
+		args[i] = string(data[:size])
+	}
+	return args
+}
+
+// Send writes each line to the client, ending it with CRLF.
+func (c *PeerConn) Send(lines ...string) {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l + "\r\n")
+	}
+	c.Write([]byte(b.String()))
+}
@@ -0,0 +1,392 @@
+// Package testutil boots the whole API in process, wired like cmd/api but
+// entirely in memory and on a fake clock, for integration tests.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/attach"
+	"example.com/notes-api/internal/audit"
+	"example.com/notes-api/internal/auth"
+	"example.com/notes-api/internal/blob"
+	"example.com/notes-api/internal/cache"
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/events"
+	httpx "example.com/notes-api/internal/http"
+	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/jobs"
+	"example.com/notes-api/internal/mailer"
+	"example.com/notes-api/internal/metrics"
+	"example.com/notes-api/internal/notify"
+	"example.com/notes-api/internal/preview"
+	"example.com/notes-api/internal/ratelimit"
+	"example.com/notes-api/internal/render"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/search"
+	"example.com/notes-api/internal/undo"
+)
+
+var (
+	update   = flag.Bool("update", false, "rewrite golden files with the actual responses")
+	contract = flag.Bool("contract", true, "check every response against the API annotations of its handler")
+)
+
+var (
+	loadContract  sync.Once
+	contractCache *Contract
+	contractErr   error
+)
+
+// Bearer tokens accepted by a Server. Alice and Bob share team devs.
+const (
+	Admin = "admin-token"
+	Alice = "alice-token"
+	Bob   = "bob-token"
+	Carol = "carol-token"
+)
+
+const tokens = Admin + ":root::admin," + Alice + ":alice:devs," + Bob + ":bob:devs," + Carol + ":carol"
+
+// Epoch is the time the fake clock of a new Server starts at.
+var Epoch = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+type Server struct {
+	t       testing.TB
+	Handler *handlers.Handler
+	Router  http.Handler
+	Clock   *clock.Fake
+	// Contract, when set, fails the test on responses that diverge from
+	// the API annotations. New sets it unless tests run with -contract=false.
+	Contract *Contract
+	// Outbox holds the email the server sent.
+	Outbox *Outbox
+}
+
+// New returns a Server with every in-memory feature enabled. Background
+// work stops when the test ends.
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	fake := clock.NewFake(Epoch)
+	notes := repo.NewNoteRepoMem()
+	h := &handlers.Handler{
+		Repo:          notes,
+		Notebooks:     repo.NewNotebookRepoMem(),
+		Collections:   repo.NewCollectionRepoMem(),
+		Search:        search.NewService(),
+		ListCache:     cache.NewLists(time.Minute),
+		LastModified:  repo.NewLastModified(),
+		Reads:         &cache.Group{},
+		CDC:           repo.NewChangeLog(1000),
+		Views:         repo.NewViewLog(),
+		Storage:       repo.NewStorageUsage(),
+		Metrics:       metrics.New(time.Second, 0.99),
+		Jobs:          jobs.NewQueue(1),
+		Blobs:         blob.NewMem(),
+		Downloads:     blob.Signer{Key: []byte("test")},
+		Notifications: notify.NewInbox(),
+		Watches:       notify.NewWatches(),
+		Preferences:   repo.NewPreferenceRepoMem(),
+		Clock:         fake,
+	}
+	notes.Clock = fake
+	notes.UniqueTitles = h.Notebooks.UniqueTitles
+	h.Notebooks.Clock = fake
+	h.Collections.Clock = fake
+	h.ListCache.Clock = fake
+	h.LastModified.Clock = fake
+	h.Metrics.Clock = fake
+	h.Jobs.Clock = fake
+	h.Jobs.OnFinish = h.NotifyJob
+	h.Audit = audit.NewLog(100)
+	h.Audit.Clock = fake
+	h.Attachments = attach.NewStore(h.Blobs)
+	h.Attachments.Clock = fake
+	h.Attachments.Audit = h.Audit
+	h.Attachments.Scanner = attach.Sniffer{}
+	h.Attachments.OnReady = h.RecognizeAttachment
+	h.Previews = preview.NewService()
+	h.Previews.Clock = fake
+	h.Previews.Fetcher.Client = &http.Client{Transport: pages{}}
+	h.Clipper = &preview.Fetcher{Client: h.Previews.Fetcher.Client}
+	h.PrintTemplates = render.NewTemplates()
+	h.Emoji = render.NewEmoji()
+	h.NoteTemplates = repo.NewTemplateRepoMem()
+	h.NoteTemplates.Clock = fake
+	h.SavedViews = repo.NewSavedViewRepoMem()
+	h.SavedViews.Clock = fake
+	h.Reviews = repo.NewReviewRepoMem()
+	h.InboundHooks = repo.NewInboundHookRepoMem()
+	h.InboundHooks.Clock = fake
+	h.Rules = repo.NewRuleRepoMem()
+	h.Rules.Clock = fake
+	h.ChangeRequests = repo.NewChangeRequestRepoMem()
+	h.ChangeRequests.Clock = fake
+	h.Locks = repo.NewLockRepoMem()
+	h.Locks.Clock = fake
+	notes.Locks = h.Locks
+	h.Comments = repo.NewCommentRepoMem()
+	h.Comments.Clock = fake
+	h.Search.Analyzer = search.Russian
+	h.Search.Clock = fake
+	h.Titles = search.NewTitles()
+	h.Titles.Clock = fake
+	h.Favorites = repo.NewFavoriteRepoMem()
+	h.Recent = repo.NewRecentRepoMem()
+	// Rule webhooks go to test servers on loopback.
+	h.RuleClient = http.DefaultClient
+	h.Undo = undo.NewBuffer(30 * time.Second)
+	h.Undo.Clock = fake
+	outbox := &Outbox{}
+	h.Mailer = outbox
+	h.EmailLimiter = ratelimit.NewMemory()
+	h.EmailLimit = ratelimit.Limit{Rate: 20, Period: time.Hour, Burst: 5}
+	h.LastModified.Touch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	h.Events = events.NewHub(events.NewMemoryBroker())
+	h.Events.Run(ctx)
+	h.Presence = events.NewPresence()
+	h.Presence.Clock = fake
+	h.Presence.Follow(ctx, h.Events)
+	h.Jobs.Run(ctx)
+	h.Previews.Run(ctx, 1)
+
+	notes.OnChange(func(c repo.Change) { h.Events.Publish(events.FromChange(c)) })
+	notes.OnChange(h.Search.Apply)
+	notes.OnChange(h.Titles.Apply)
+	notes.OnChange(func(c repo.Change) { h.ListCache.Invalidate(c.Note) })
+	h.Notebooks.OnChange(h.ListCache.Flush)
+	notes.OnChange(h.LastModified.Apply)
+	h.Notebooks.OnChange(h.LastModified.Touch)
+	notes.OnChange(h.CDC.Append)
+	notes.OnChange(h.Views.Apply)
+	notes.OnChange(h.Storage.Apply)
+	notes.OnChange(h.Attachments.Apply)
+	notes.OnChange(h.Previews.Apply)
+	notes.OnChange(h.RunRules)
+	notes.OnChange(h.Comments.Apply)
+	notes.OnChange(h.Favorites.Apply)
+	notes.OnChange(h.Recent.Apply)
+
+	parsed, err := auth.ParseTokens(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		t:       t,
+		Handler: h,
+		Router:  httpx.NewRouter(h, httpx.Options{Tokens: parsed, Metrics: h.Metrics}),
+		Clock:   fake,
+		Outbox:  outbox,
+	}
+
+	if *contract {
+		loadContract.Do(func() { contractCache, contractErr = LoadContract() })
+		if contractErr != nil {
+			t.Fatalf("load API contract: %v", contractErr)
+		}
+		s.Contract = contractCache
+	}
+	return s
+}
+
+// pages stands in for the web in link previews: every page is titled
+// after its path, except on hosts under .invalid, which cannot be reached.
+// Outbox is a mailer.Mailer that keeps messages instead of sending them.
+type Outbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (o *Outbox) Send(ctx context.Context, m mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, m)
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (o *Outbox) Messages() []mailer.Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]mailer.Message(nil), o.messages...)
+}
+
+type pages struct{}
+
+func (pages) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Hostname(), ".invalid") {
+		return nil, errors.New("no such host")
+	}
+	body := "<html><head><title>" + html.EscapeString(req.URL.Path) + "</title>" +
+		`<meta property="og:site_name" content="` + html.EscapeString(req.URL.Host) + `"></head>` +
+		"<body><nav><a href=\"/\">Home</a></nav><article><p>This page was served by " + html.EscapeString(req.URL.Host) +
+		" at " + html.EscapeString(req.URL.Path) + ", for tests.</p></article></body></html>"
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// As returns a client that authenticates with token.
+func (s *Server) As(token string) *Client {
+	return &Client{s: s, token: token}
+}
+
+type Client struct {
+	s      *Server
+	token  string
+	header http.Header
+}
+
+// WithHeader returns a client that also sends the header key.
+func (c *Client) WithHeader(key, value string) *Client {
+	header := c.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(key, value)
+	return &Client{s: c.s, token: c.token, header: header}
+}
+
+func (c *Client) Get(path string) *Response {
+	return c.Do(http.MethodGet, path, nil)
+}
+
+func (c *Client) Post(path string, body interface{}) *Response {
+	return c.Do(http.MethodPost, path, body)
+}
+
+func (c *Client) Patch(path string, body interface{}) *Response {
+	return c.Do(http.MethodPatch, path, body)
+}
+
+func (c *Client) Put(path string, body interface{}) *Response {
+	return c.Do(http.MethodPut, path, body)
+}
+
+func (c *Client) Delete(path string) *Response {
+	return c.Do(http.MethodDelete, path, nil)
+}
+
+// Do sends a request to the router. A string or []byte body is sent as
+// is, anything else is encoded as JSON.
+func (c *Client) Do(method, path string, body interface{}) *Response {
+	return c.DoContext(context.Background(), method, path, body)
+}
+
+// DoContext is Do with a request context, to end streaming responses.
+func (c *Client) DoContext(ctx context.Context, method, path string, body interface{}) *Response {
+	t := c.s.t
+	t.Helper()
+
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = bytes.NewBufferString(b)
+	case []byte:
+		r = bytes.NewBuffer(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewBuffer(data)
+	}
+
+	req := httptest.NewRequest(method, path, r).WithContext(ctx)
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	w := httptest.NewRecorder()
+	c.s.Router.ServeHTTP(w, req)
+
+	// Enveloped responses wrap what the contract describes.
+	if enveloped, _ := strconv.ParseBool(req.URL.Query().Get("envelope")); c.s.Contract != nil && !enveloped {
+		for _, problem := range c.s.Contract.Check(method, req.URL.Path, w.Code, w.Header(), w.Body.Bytes()) {
+			t.Errorf("contract: %s", problem)
+		}
+	}
+
+	return &Response{t: t, req: method + " " + path, Code: w.Code, Header: w.Header(), Body: w.Body.Bytes()}
+}
+
+type Response struct {
+	t      testing.TB
+	req    string
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// Expect fails the test unless the response has the given status.
+func (r *Response) Expect(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Fatalf("%s: status %d, want %d\n%s", r.req, r.Code, code, r.Body)
+	}
+	return r
+}
+
+// JSON decodes the body into v.
+func (r *Response) JSON(v interface{}) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("%s: decode %q: %v", r.req, r.Body, err)
+	}
+	return r
+}
+
+// uuids match public IDs, which are random even on a fake clock.
+var uuids = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// Golden compares the body with testdata/<name>.golden, or rewrites the
+// file when tests run with -update. Public IDs are masked.
+func (r *Response) Golden(name string) *Response {
+	r.t.Helper()
+
+	got := uuids.ReplaceAll(r.Body, []byte("<uuid>"))
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			r.t.Fatal(err)
+		}
+		return r
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("%s: %v (run with -update to create it)", r.req, err)
+	}
+	if !bytes.Equal(got, want) {
+		r.t.Errorf("%s: body differs from %s\ngot:\n%s\nwant:\n%s", r.req, path, got, want)
+	}
+	return r
+}
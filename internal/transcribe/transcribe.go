@@ -0,0 +1,82 @@
+// Package transcribe turns speech in audio files into text through an
+// external transcription provider.
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Transcriber returns the text spoken in an audio file.
+type Transcriber interface {
+	Transcribe(ctx context.Context, name string, audio []byte) (string, error)
+}
+
+// Audio reports whether transcription applies to files of contentType.
+func Audio(contentType string) bool {
+	return strings.HasPrefix(contentType, "audio/") || contentType == "application/ogg"
+}
+
+// Whisper calls an OpenAI-compatible transcription API at URL, such as
+// https://api.openai.com or a self-hosted Whisper server.
+type Whisper struct {
+	URL    string
+	APIKey string
+	// Model defaults to whisper-1.
+	Model  string
+	Client *http.Client
+}
+
+func (t Whisper) Transcribe(ctx context.Context, name string, audio []byte) (string, error) {
+	model := t.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("model", model)
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	fw.Write(audio)
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(t.URL, "/")+"/v1/audio/transcriptions", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("transcription: %s", resp.Status)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("transcription: %v", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
@@ -0,0 +1,37 @@
+package transcribe
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWhisper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(f)
+		json.NewEncoder(w).Encode(map[string]string{
+			"text": " " + r.FormValue("model") + " " + header.Filename + " " + string(audio) + " ",
+		})
+	}))
+	defer srv.Close()
+
+	text, err := Whisper{URL: srv.URL, APIKey: "key"}.Transcribe(context.Background(), "memo.ogg", []byte("привет"))
+	if err != nil || text != "whisper-1 memo.ogg привет" {
+		t.Errorf("Transcribe = %q, %v", text, err)
+	}
+	if _, err := (Whisper{URL: srv.URL}).Transcribe(context.Background(), "memo.ogg", nil); err == nil {
+		t.Error("no error for a refused request")
+	}
+}
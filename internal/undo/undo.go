@@ -0,0 +1,83 @@
+// Package undo keeps the way back from destructive operations for a short
+// while, so that an accidental delete or bulk change can be reverted.
+package undo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/clock"
+	"example.com/notes-api/internal/core"
+)
+
+// ErrNotFound covers tokens that are unknown, expired, already used or
+// someone else's, which callers cannot tell apart.
+var ErrNotFound = core.NotFound("nothing to undo")
+
+// Ticket is handed to the caller of an operation that can be undone.
+type Ticket struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Revert reverses an operation and returns the notes it touched.
+type Revert func() ([]core.Note, error)
+
+type entry struct {
+	ownerID   string
+	operation string
+	expiresAt time.Time
+	revert    Revert
+}
+
+// Buffer holds the reverts of recent operations for Window.
+type Buffer struct {
+	Clock  clock.Clock
+	Window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+func NewBuffer(window time.Duration) *Buffer {
+	return &Buffer{Clock: clock.System{}, Window: window, entries: make(map[string]entry)}
+}
+
+// Push records how to revert an operation ownerID just did.
+func (b *Buffer) Push(ownerID, operation string, revert Revert) Ticket {
+	token := make([]byte, 16)
+	rand.Read(token)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.Clock.Now()
+	for t, e := range b.entries {
+		if !now.Before(e.expiresAt) {
+			delete(b.entries, t)
+		}
+	}
+	ticket := Ticket{Token: hex.EncodeToString(token), ExpiresAt: now.Add(b.Window)}
+	b.entries[ticket.Token] = entry{ownerID: ownerID, operation: operation, expiresAt: ticket.ExpiresAt, revert: revert}
+	return ticket
+}
+
+// Undo reverts the operation of token once, if it belongs to ownerID and
+// has not expired. It returns the name of the operation and the notes the
+// revert touched.
+func (b *Buffer) Undo(token, ownerID string) (string, []core.Note, error) {
+	b.mu.Lock()
+	e, ok := b.entries[token]
+	if ok && e.ownerID == ownerID {
+		delete(b.entries, token)
+	}
+	b.mu.Unlock()
+
+	if !ok || e.ownerID != ownerID || !b.Clock.Now().Before(e.expiresAt) {
+		return "", nil, ErrNotFound
+	}
+	notes, err := e.revert()
+	return e.operation, notes, err
+}
@@ -0,0 +1,49 @@
+package vault
+
+import (
+	"bytes"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+)
+
+func FuzzParse(f *testing.F) {
+	f.Add("---\ntags: [work, ideas]\npinned: true\n---\nbody\n")
+	f.Add("---\r\ntype: snippet\r\nlanguage: go\r\ntags:\r\n  - a\r\n  - 'b'\r\n---\r\nx := 1\r\n")
+	f.Add("\ufeff---\nunterminated: [\n")
+	f.Add("no front matter")
+
+	f.Fuzz(func(t *testing.T, data string) {
+		n := Parse(data)
+		if !core.ValidNoteType(n.Type) {
+			t.Fatalf("parsed type %q", n.Type)
+		}
+		if n.Type != core.NoteTypeSnippet && n.Language != "" {
+			t.Fatalf("language %q on a %s", n.Language, n.Type)
+		}
+	})
+}
+
+func FuzzRead(f *testing.F) {
+	var buf bytes.Buffer
+	Write(&buf, [][]string{{"Work"}}, []Entry{
+		{Folder: []string{"Work"}, Note: core.Note{Type: core.NoteTypeNote, Title: "План", Content: "body", Tags: []string{"a"}}},
+	})
+	f.Add(buf.Bytes())
+	f.Add([]byte("PK\x05\x06" + string(make([]byte, 18))))
+	f.Add([]byte("not a zip"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v, err := Read(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		for _, e := range v.Entries {
+			for _, name := range e.Folder {
+				if name == ".." || name == "." || name == "" {
+					t.Fatalf("entry escapes the vault: %q", e.Folder)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,332 @@
+// Package vault reads and writes notes in the Obsidian vault layout: one
+// folder per notebook and one Markdown file per note, named after its title
+// so that [[wiki-links]] in note bodies keep resolving.
+package vault
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var ErrInvalidArchive = errors.New("invalid vault archive")
+
+// MaxNoteSize bounds a single uncompressed note. Larger files are skipped
+// rather than inflated, since a small archive can expand without limit.
+const MaxNoteSize = 4 << 20
+
+// Entry is a note read from a vault together with the folder it lives in.
+type Entry struct {
+	Folder []string
+	Note   core.Note
+}
+
+// Vault is the content of an imported archive. Folders lists every folder,
+// including empty ones, parents before children. Skipped holds files that
+// have no counterpart in the API, such as attachments.
+type Vault struct {
+	Folders [][]string
+	Entries []Entry
+	Skipped []string
+}
+
+// Write stores the notes as a zipped vault. folders are notebook paths that
+// must exist even when they hold no notes.
+func Write(w io.Writer, folders [][]string, notes []Entry) error {
+	zw := zip.NewWriter(w)
+
+	dirs := make(map[string]bool)
+	for _, f := range folders {
+		for i := range f {
+			dir := folderPath(f[:i+1])
+			if dirs[dir] {
+				continue
+			}
+			dirs[dir] = true
+			if _, err := zw.Create(dir + "/"); err != nil {
+				return err
+			}
+		}
+	}
+
+	var namer Namer
+	for _, e := range notes {
+		name := namer.Path(e)
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified(e.Note)})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, Render(e.Note)); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// Read parses a zipped vault. Obsidian's own settings folder is ignored.
+func Read(r io.ReaderAt, size int64) (*Vault, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	v := &Vault{}
+	seen := make(map[string]bool)
+	addFolder := func(parts []string) {
+		for i := range parts {
+			key := strings.Join(parts[:i+1], "/")
+			if !seen[key] {
+				seen[key] = true
+				v.Folders = append(v.Folders, parts[:i+1])
+			}
+		}
+	}
+
+	files := append([]*zip.File(nil), zr.File...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	for _, f := range files {
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		if name == "." || strings.HasPrefix(name, "..") {
+			continue
+		}
+		parts := strings.Split(name, "/")
+		if parts[0] == ".obsidian" || parts[0] == ".trash" {
+			continue
+		}
+
+		if f.FileInfo().IsDir() {
+			addFolder(parts)
+			continue
+		}
+		if !strings.EqualFold(path.Ext(name), ".md") {
+			v.Skipped = append(v.Skipped, name)
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, MaxNoteSize+1))
+		rc.Close()
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+		if len(data) > MaxNoteSize {
+			v.Skipped = append(v.Skipped, name)
+			continue
+		}
+
+		folder := parts[:len(parts)-1]
+		addFolder(folder)
+
+		note := Parse(string(data))
+		note.Title = strings.TrimSuffix(parts[len(parts)-1], path.Ext(name))
+		v.Entries = append(v.Entries, Entry{Folder: folder, Note: note})
+	}
+
+	return v, nil
+}
+
+func folderPath(folder []string) string {
+	names := make([]string, len(folder))
+	for i, name := range folder {
+		names[i] = Sanitize(name)
+	}
+	return strings.Join(names, "/")
+}
+
+// Namer assigns unique file paths to the notes of one vault, numbering
+// duplicate titles the way Obsidian does.
+type Namer struct {
+	used map[string]bool
+}
+
+func (n *Namer) Path(e Entry) string {
+	if n.used == nil {
+		n.used = make(map[string]bool)
+	}
+
+	base := Sanitize(e.Note.Title)
+	if dir := folderPath(e.Folder); dir != "" {
+		base = dir + "/" + base
+	}
+	name := base + ".md"
+	for i := 1; n.used[strings.ToLower(name)]; i++ {
+		name = base + " " + strconv.Itoa(i) + ".md"
+	}
+	n.used[strings.ToLower(name)] = true
+	return name
+}
+
+// Sanitize replaces characters Obsidian does not allow in file names.
+func Sanitize(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '#', '^', '[', ']':
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+func modified(n core.Note) time.Time {
+	if n.UpdatedAt != nil {
+		return *n.UpdatedAt
+	}
+	return n.CreatedAt
+}
+
+// Render writes the note body preceded by YAML front matter for the fields
+// Obsidian has no native place for.
+func Render(n core.Note) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	if n.Type == core.NoteTypeSnippet {
+		b.WriteString("type: snippet\n")
+		if n.Language != "" {
+			fmt.Fprintf(&b, "language: %s\n", n.Language)
+		}
+	}
+	if len(n.Tags) > 0 {
+		fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(n.Tags, ", "))
+	}
+	if n.Pinned {
+		b.WriteString("pinned: true\n")
+	}
+	if n.State != "" {
+		fmt.Fprintf(&b, "state: %s\n", n.State)
+	}
+	if n.JournalDate != "" {
+		fmt.Fprintf(&b, "date: %s\n", n.JournalDate)
+	}
+	if n.SourceURL != "" {
+		fmt.Fprintf(&b, "source: %s\n", n.SourceURL)
+	}
+	fmt.Fprintf(&b, "created: %s\n", n.CreatedAt.UTC().Format(time.RFC3339))
+	b.WriteString("---\n")
+
+	body := n.Content
+	if len(n.Blocks) > 0 {
+		body = core.Markdown(n.Blocks)
+	}
+	b.WriteString(body)
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Parse reads a Markdown file with optional front matter. Journal dates are
+// not restored, as they could clash with entries already in the journal.
+func Parse(data string) core.Note {
+	n := core.Note{Type: core.NoteTypeNote}
+
+	body, meta, ok := splitFrontMatter(data)
+	if !ok {
+		n.Content = strings.TrimRight(data, "\n")
+		return n
+	}
+
+	for key, value := range meta {
+		if len(value) == 0 {
+			continue
+		}
+		switch key {
+		case "type":
+			if core.ValidNoteType(value[0]) {
+				n.Type = value[0]
+			}
+		case "language":
+			n.Language = value[0]
+		case "tags", "tag":
+			n.Tags = core.NormalizeTags(value)
+		case "pinned":
+			n.Pinned = value[0] == "true"
+		case "state":
+			n.State = value[0]
+		case "source":
+			n.SourceURL = value[0]
+		}
+	}
+	if n.Type != core.NoteTypeSnippet {
+		n.Language = ""
+	}
+
+	n.Content = strings.TrimRight(body, "\n")
+	return n
+}
+
+// splitFrontMatter separates a leading YAML block from the body. Values
+// are returned as lists so that both "tags: [a, b]" and block sequences
+// come out the same way.
+func splitFrontMatter(data string) (string, map[string][]string, bool) {
+	data = strings.TrimPrefix(data, "\ufeff")
+	if !strings.HasPrefix(data, "---\n") && !strings.HasPrefix(data, "---\r\n") {
+		return data, nil, false
+	}
+
+	meta := make(map[string][]string)
+	lines := strings.SplitAfter(data, "\n")
+	consumed := len(lines[0])
+
+	var key string
+	for _, raw := range lines[1:] {
+		consumed += len(raw)
+		line := strings.TrimRight(raw, "\r\n")
+		if line == "---" {
+			return data[consumed:], meta, true
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "- ") && key != "" {
+			meta[key] = append(meta[key], unquote(strings.TrimPrefix(trimmed, "- ")))
+			continue
+		}
+
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		switch {
+		case v == "":
+			meta[key] = nil
+		case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+			var items []string
+			for _, item := range strings.Split(v[1:len(v)-1], ",") {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					items = append(items, item)
+				}
+			}
+			meta[key] = items
+		default:
+			meta[key] = []string{unquote(v)}
+		}
+	}
+
+	return data, nil, false
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+"use strict";
+
+// The token is kept in localStorage and asked for again whenever the API
+// answers 401. Instances without tokens never ask.
+const api = "../api/v1";
+const $ = (id) => document.getElementById(id);
+
+const state = { notes: [], tag: "", query: "", current: null };
+
+async function request(method, path, body) {
+  const headers = { "Content-Type": "application/json" };
+  const token = localStorage.getItem("notes-token");
+  if (token) headers.Authorization = "Bearer " + token;
+
+  const res = await fetch(api + path, { method, headers, body: body && JSON.stringify(body) });
+  if (res.status === 401) {
+    const entered = prompt("Токен доступа к API:");
+    if (entered === null) throw new Error("Нужен токен");
+    localStorage.setItem("notes-token", entered.trim());
+    return request(method, path, body);
+  }
+  if (res.status === 204) return null;
+  const data = await res.json();
+  if (!res.ok) throw new Error(data.error || res.statusText);
+  return data;
+}
+
+async function loadNotes() {
+  const params = new URLSearchParams();
+  if (state.query) params.set("q", state.query);
+  if (state.tag) params.set("tag", state.tag);
+  state.notes = await request("GET", "/notes?" + params);
+  renderNotes();
+}
+
+async function loadTags() {
+  const tags = await request("GET", "/tags");
+  const list = $("tags");
+  list.replaceChildren();
+  for (const t of [{ tag: "", count: null }, ...tags]) {
+    const li = document.createElement("li");
+    li.textContent = t.tag || "Все";
+    if (t.count !== null) {
+      const count = document.createElement("span");
+      count.className = "count";
+      count.textContent = " " + t.count;
+      li.append(count);
+    }
+    li.classList.toggle("active", t.tag === state.tag);
+    li.onclick = () => { state.tag = t.tag; loadTags(); loadNotes(); };
+    list.append(li);
+  }
+}
+
+function renderNotes() {
+  const list = $("notes");
+  list.replaceChildren();
+  for (const n of state.notes) {
+    const li = document.createElement("li");
+    li.classList.toggle("active", state.current !== null && state.current.ID === n.ID);
+
+    const title = document.createElement("div");
+    title.className = "title" + (n.Pinned ? " pinned" : "");
+    title.textContent = n.Title;
+
+    const meta = document.createElement("div");
+    meta.className = "meta";
+    meta.textContent = [new Date(n.UpdatedAt || n.CreatedAt).toLocaleString(), ...(n.Tags || [])].join(" · ");
+
+    li.append(title, meta);
+    li.onclick = () => edit(n);
+    list.append(li);
+  }
+  $("empty").hidden = state.notes.length > 0;
+}
+
+function edit(note) {
+  state.current = note;
+  $("title").value = note ? note.Title : "";
+  $("content").value = note ? note.Content : "";
+  $("note-tags").value = note && note.Tags ? note.Tags.join(", ") : "";
+  $("delete").hidden = !note;
+  $("error").textContent = "";
+  $("editor").hidden = false;
+  $("title").focus();
+  renderNotes();
+}
+
+function closeEditor() {
+  state.current = null;
+  $("editor").hidden = true;
+  renderNotes();
+}
+
+async function save(event) {
+  event.preventDefault();
+  const body = {
+    title: $("title").value,
+    content: $("content").value,
+    tags: $("note-tags").value.split(",").map((t) => t.trim()).filter(Boolean),
+  };
+  try {
+    const saved = state.current
+      ? await request("PATCH", "/notes/" + state.current.ID, body)
+      : await request("POST", "/notes", body);
+    state.current = saved;
+    await Promise.all([loadNotes(), loadTags()]);
+  } catch (err) {
+    $("error").textContent = err.message;
+  }
+}
+
+async function remove() {
+  if (!state.current || !confirm("Удалить заметку «" + state.current.Title + "»?")) return;
+  try {
+    await request("DELETE", "/notes/" + state.current.ID);
+    closeEditor();
+    await Promise.all([loadNotes(), loadTags()]);
+  } catch (err) {
+    $("error").textContent = err.message;
+  }
+}
+
+let searchTimer;
+$("search").oninput = (e) => {
+  clearTimeout(searchTimer);
+  searchTimer = setTimeout(() => { state.query = e.target.value.trim(); loadNotes(); }, 250);
+};
+$("new").onclick = () => edit(null);
+$("cancel").onclick = closeEditor;
+$("delete").onclick = remove;
+$("editor").onsubmit = save;
+$("logout").onclick = () => { localStorage.removeItem("notes-token"); location.reload(); };
+
+Promise.all([loadNotes(), loadTags()]).catch((err) => alert(err.message));
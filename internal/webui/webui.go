@@ -0,0 +1,22 @@
+// Package webui is a minimal single-page notes client, embedded so that
+// the server is usable without a separate frontend. It only talks to the
+// public API under /api/v1.
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the UI. Mount it with its prefix stripped.
+func Handler() http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(root))
+}
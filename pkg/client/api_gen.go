@@ -0,0 +1,3169 @@
+// Code generated by apigen. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Upload is attach.Upload of the API.
+type Upload struct {
+	ID          string    `json:"id"`
+	NoteID      int64     `json:"note_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Sha256      string    `json:"sha256"`
+	Offset      int64     `json:"offset"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Entry is audit.Entry of the API.
+type Entry struct {
+	ID     int64     `json:"id"`
+	At     time.Time `json:"at"`
+	Actor  string    `json:"actor"`
+	Action string    `json:"action"`
+	// Target names what the action was about, e.g. note:1.
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Conflict is cloudsync.Conflict of the API.
+type Conflict struct {
+	Path   string    `json:"path"`
+	NoteID int64     `json:"note_id"`
+	CopyID int64     `json:"copy_id"`
+	At     time.Time `json:"at"`
+}
+
+// Status is cloudsync.Status of the API.
+type Status struct {
+	Remote    string     `json:"remote"`
+	Running   bool       `json:"running"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	Pushed    int        `json:"pushed"`
+	Pulled    int        `json:"pulled"`
+	Imported  int        `json:"imported"`
+	Deleted   int        `json:"deleted"`
+	// Locked counts notes left for a later run because someone else
+	// has locked them for editing.
+	Locked    int        `json:"locked"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Attachment is core.Attachment of the API.
+type Attachment struct {
+	ID          int64     `json:"id"`
+	NoteID      int64     `json:"note_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Sha256      string    `json:"sha256"`
+	OwnerID     string    `json:"owner_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Status is quarantined when a scan found the file suspicious; only
+	// admins can download it then, and ScanReason says why.
+	Status     string `json:"status"`
+	ScanReason string `json:"scan_reason,omitempty"`
+	// Text is the text recognized in the file: found by OCR in images or
+	// transcribed from audio. It is searchable as part of the note.
+	Text string `json:"text,omitempty"`
+}
+
+// Block is core.Block of the API.
+type Block struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Level    int             `json:"level,omitempty"`
+	Language string          `json:"language,omitempty"`
+	Items    []ChecklistItem `json:"items,omitempty"`
+	URL      string          `json:"url,omitempty"`
+	Alt      string          `json:"alt,omitempty"`
+}
+
+// ChangeRequest is core.ChangeRequest of the API.
+type ChangeRequest struct {
+	ID         int64  `json:"id"`
+	NoteID     int64  `json:"note_id"`
+	NotebookID int64  `json:"notebook_id"`
+	AuthorID   string `json:"author_id"`
+	Status     string `json:"status"`
+	// Changes are the fields the edit sets, named as in PATCH /notes/{id}.
+	Changes   map[string]any `json:"changes"`
+	CreatedAt time.Time      `json:"created_at"`
+	// DecidedBy approved or rejected the request at DecidedAt, giving
+	// Reason for a rejection.
+	DecidedBy string     `json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// ChecklistItem is core.ChecklistItem of the API.
+type ChecklistItem struct {
+	Text    string `json:"text"`
+	Checked bool   `json:"checked"`
+}
+
+// Collection is core.Collection of the API.
+type Collection struct {
+	ID        int64         `json:"ID"`
+	OwnerID   string        `json:"OwnerID"`
+	Name      string        `json:"Name"`
+	Schema    []PropertyDef `json:"Schema"`
+	CreatedAt time.Time     `json:"CreatedAt"`
+	UpdatedAt *time.Time    `json:"UpdatedAt"`
+}
+
+// CollectionCreate is core.CollectionCreate of the API.
+type CollectionCreate struct {
+	Name   string        `json:"name"`
+	Schema []PropertyDef `json:"schema"`
+}
+
+// CollectionUpdate is core.CollectionUpdate of the API.
+type CollectionUpdate struct {
+	Name   *string       `json:"name,omitempty"`
+	Schema []PropertyDef `json:"schema,omitempty"`
+}
+
+// Comment is core.Comment of the API.
+type Comment struct {
+	ID       int64  `json:"id"`
+	NoteID   int64  `json:"note_id"`
+	AuthorID string `json:"author_id"`
+	Body     string `json:"body"`
+	ReplyTo  int64  `json:"reply_to,omitempty"`
+	// Resolved marks a thread as settled; ResolvedBy did so at ResolvedAt.
+	Resolved   bool       `json:"resolved"`
+	ResolvedBy string     `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// DiffLine is core.DiffLine of the API.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DisplayDates is core.DisplayDates of the API.
+type DisplayDates struct {
+	Created  string `json:"Created"`
+	Updated  string `json:"Updated,omitempty"`
+	RemindAt string `json:"RemindAt,omitempty"`
+}
+
+// InboundHook is core.InboundHook of the API.
+type InboundHook struct {
+	ID         int64  `json:"id"`
+	OwnerID    string `json:"owner_id"`
+	Name       string `json:"name"`
+	Token      string `json:"token"`
+	NotebookID int64  `json:"notebook_id,omitempty"`
+	// Title defaults to the hook name and Content to the payload as sent.
+	Title      string     `json:"title,omitempty"`
+	Content    string     `json:"content,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// InboundHookInput is core.InboundHookInput of the API.
+type InboundHookInput struct {
+	Name       string   `json:"name"`
+	NotebookID int64    `json:"notebook_id,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Content    string   `json:"content,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// Lifecycle is core.Lifecycle of the API.
+type Lifecycle struct {
+	States []string `json:"states"`
+	// Transitions maps each state to the states a note in it may move to.
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// NearbyNote is core.NearbyNote of the API.
+type NearbyNote struct {
+	ID int64 `json:"ID"`
+	// PublicID is a UUIDv7 that routes accept in place of ID.
+	PublicID   string `json:"PublicID"`
+	Slug       string `json:"Slug"`
+	OwnerID    string `json:"OwnerID"`
+	NotebookID int64  `json:"NotebookID"`
+	// CollectionID is the collection whose schema the note follows.
+	CollectionID int64         `json:"CollectionID,omitempty"`
+	Path         []NotebookRef `json:"Path,omitempty"`
+	Position     int64         `json:"Position"`
+	Type         string        `json:"Type"`
+	Title        string        `json:"Title"`
+	Content      string        `json:"Content"`
+	Blocks       []Block       `json:"Blocks,omitempty"`
+	Language     string        `json:"Language,omitempty"`
+	Latitude     *float64      `json:"Latitude,omitempty"`
+	Longitude    *float64      `json:"Longitude,omitempty"`
+	JournalDate  string        `json:"JournalDate,omitempty"`
+	Tags         []string      `json:"Tags,omitempty"`
+	// SourceURL is the page a clipped note was taken from.
+	SourceURL string     `json:"SourceURL,omitempty"`
+	Pinned    bool       `json:"Pinned"`
+	State     string     `json:"State,omitempty"`
+	RemindAt  *time.Time `json:"RemindAt,omitempty"`
+	// Color is a #rrggbb color for showing the note.
+	Color string `json:"Color,omitempty"`
+	// ExpiresAt is when the retention policy of the notebook the note was
+	// created in deletes it.
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty"`
+	// Properties are typed key-value fields; see ValidateProperties.
+	Properties map[string]any `json:"Properties,omitempty"`
+	// Reactions counts the users behind each emoji in ReactedBy, which is
+	// kept out of responses.
+	Reactions map[string]int `json:"Reactions,omitempty"`
+	CreatedAt time.Time      `json:"CreatedAt"`
+	UpdatedAt *time.Time     `json:"UpdatedAt"`
+	// Display has the dates formatted for the reader, when asked for with
+	// date_format or Accept-Language.
+	Display        *DisplayDates `json:"Display,omitempty"`
+	DistanceMeters float64       `json:"DistanceMeters"`
+}
+
+// Note is core.Note of the API.
+type Note struct {
+	ID int64 `json:"ID"`
+	// PublicID is a UUIDv7 that routes accept in place of ID.
+	PublicID   string `json:"PublicID"`
+	Slug       string `json:"Slug"`
+	OwnerID    string `json:"OwnerID"`
+	NotebookID int64  `json:"NotebookID"`
+	// CollectionID is the collection whose schema the note follows.
+	CollectionID int64         `json:"CollectionID,omitempty"`
+	Path         []NotebookRef `json:"Path,omitempty"`
+	Position     int64         `json:"Position"`
+	Type         string        `json:"Type"`
+	Title        string        `json:"Title"`
+	Content      string        `json:"Content"`
+	Blocks       []Block       `json:"Blocks,omitempty"`
+	Language     string        `json:"Language,omitempty"`
+	Latitude     *float64      `json:"Latitude,omitempty"`
+	Longitude    *float64      `json:"Longitude,omitempty"`
+	JournalDate  string        `json:"JournalDate,omitempty"`
+	Tags         []string      `json:"Tags,omitempty"`
+	// SourceURL is the page a clipped note was taken from.
+	SourceURL string     `json:"SourceURL,omitempty"`
+	Pinned    bool       `json:"Pinned"`
+	State     string     `json:"State,omitempty"`
+	RemindAt  *time.Time `json:"RemindAt,omitempty"`
+	// Color is a #rrggbb color for showing the note.
+	Color string `json:"Color,omitempty"`
+	// ExpiresAt is when the retention policy of the notebook the note was
+	// created in deletes it.
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty"`
+	// Properties are typed key-value fields; see ValidateProperties.
+	Properties map[string]any `json:"Properties,omitempty"`
+	// Reactions counts the users behind each emoji in ReactedBy, which is
+	// kept out of responses.
+	Reactions map[string]int `json:"Reactions,omitempty"`
+	CreatedAt time.Time      `json:"CreatedAt"`
+	UpdatedAt *time.Time     `json:"UpdatedAt"`
+	// Display has the dates formatted for the reader, when asked for with
+	// date_format or Accept-Language.
+	Display *DisplayDates `json:"Display,omitempty"`
+}
+
+// NoteCreate is core.NoteCreate of the API.
+type NoteCreate struct {
+	NotebookID   int64          `json:"notebook_id,omitempty"`
+	CollectionID int64          `json:"collection_id,omitempty"`
+	Type         string         `json:"type,omitempty"`
+	Title        string         `json:"title"`
+	Content      string         `json:"content"`
+	Blocks       []Block        `json:"blocks,omitempty"`
+	Language     string         `json:"language,omitempty"`
+	Latitude     *float64       `json:"latitude,omitempty"`
+	Longitude    *float64       `json:"longitude,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+	Pinned       bool           `json:"pinned,omitempty"`
+	RemindAt     *time.Time     `json:"remind_at,omitempty"`
+	Color        string         `json:"color,omitempty"`
+	Properties   map[string]any `json:"properties,omitempty"`
+}
+
+// NoteLock is core.NoteLock of the API.
+type NoteLock struct {
+	NoteID     int64     `json:"note_id"`
+	HolderID   string    `json:"holder_id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// NoteTemplate is core.NoteTemplate of the API.
+type NoteTemplate struct {
+	ID         int64    `json:"id"`
+	OwnerID    string   `json:"owner_id"`
+	Name       string   `json:"name"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags,omitempty"`
+	NotebookID int64    `json:"notebook_id,omitempty"`
+	Schedule   string   `json:"schedule,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+	// NextRunAt is when the schedule next creates a note.
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	// LastNoteID is the note the schedule created last.
+	LastNoteID int64      `json:"last_note_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// NoteTemplateInput is core.NoteTemplateInput of the API.
+type NoteTemplateInput struct {
+	Name       string   `json:"name"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags,omitempty"`
+	NotebookID int64    `json:"notebook_id,omitempty"`
+	Schedule   string   `json:"schedule,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+}
+
+// NoteUpdate is core.NoteUpdate of the API.
+type NoteUpdate struct {
+	Title     *string    `json:"title,omitempty"`
+	Content   *string    `json:"content,omitempty"`
+	Blocks    []Block    `json:"blocks,omitempty"`
+	Language  *string    `json:"language,omitempty"`
+	Latitude  *float64   `json:"latitude,omitempty"`
+	Longitude *float64   `json:"longitude,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Pinned    *bool      `json:"pinned,omitempty"`
+	RemindAt  *time.Time `json:"remind_at,omitempty"`
+	// Color is a #rrggbb color; "" removes it.
+	Color *string `json:"color,omitempty"`
+	// CollectionID moves the note into a collection; 0 removes it.
+	CollectionID *int64 `json:"collection_id,omitempty"`
+	// Properties are merged into the note's; null removes a property.
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// NoteView is core.NoteView of the API.
+type NoteView struct {
+	UserID        string    `json:"user_id"`
+	Views         int       `json:"views"`
+	FirstViewedAt time.Time `json:"first_viewed_at"`
+	LastViewedAt  time.Time `json:"last_viewed_at"`
+}
+
+// Notebook is core.Notebook of the API.
+type Notebook struct {
+	ID        int64         `json:"ID"`
+	ParentID  int64         `json:"ParentID"`
+	Name      string        `json:"Name"`
+	OwnerID   string        `json:"OwnerID"`
+	Shares    []Share       `json:"Shares,omitempty"`
+	Path      []NotebookRef `json:"Path,omitempty"`
+	CreatedAt time.Time     `json:"CreatedAt"`
+	UpdatedAt *time.Time    `json:"UpdatedAt"`
+	// UniqueTitles forbids two notes of the notebook to share a title.
+	UniqueTitles bool `json:"UniqueTitles,omitempty"`
+	// RequireApproval turns edits of its notes by anyone but the owner
+	// into change requests the owner approves or rejects.
+	RequireApproval bool `json:"RequireApproval,omitempty"`
+}
+
+// NotebookCreate is core.NotebookCreate of the API.
+type NotebookCreate struct {
+	Name         string `json:"name"`
+	ParentID     int64  `json:"parent_id,omitempty"`
+	UniqueTitles bool   `json:"unique_titles,omitempty"`
+}
+
+// NotebookRef is core.NotebookRef of the API.
+type NotebookRef struct {
+	ID   int64  `json:"ID"`
+	Name string `json:"Name"`
+}
+
+// NotebookSettings is core.NotebookSettings of the API.
+type NotebookSettings struct {
+	// Tags are added to those of every new note.
+	Tags []string `json:"tags,omitempty"`
+	// Color is given to new notes that do not pick one.
+	Color string `json:"color,omitempty"`
+	// TemplateID is a note template whose title, content and tags fill in
+	// new notes that leave their title or content empty.
+	TemplateID int64 `json:"template_id,omitempty"`
+	// RetentionDays, when set, deletes new notes that many days after
+	// they are created.
+	RetentionDays int `json:"retention_days,omitempty"`
+}
+
+// NotebookUpdate is core.NotebookUpdate of the API.
+type NotebookUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	ParentID *int64  `json:"parent_id,omitempty"`
+	// UniqueTitles turns the unique title constraint on or off.
+	UniqueTitles *bool `json:"unique_titles,omitempty"`
+	// RequireApproval turns approval mode on or off; only the owner can.
+	RequireApproval *bool `json:"require_approval,omitempty"`
+}
+
+// NotificationPreferences is core.NotificationPreferences of the API.
+type NotificationPreferences struct {
+	// Watched delivers notifications about watched notes.
+	Watched bool `json:"watched"`
+	// Digest opts into the weekly digest email.
+	Digest bool `json:"digest"`
+}
+
+// NotificationPreferencesUpdate is core.NotificationPreferencesUpdate of the API.
+type NotificationPreferencesUpdate struct {
+	Watched *bool `json:"watched,omitempty"`
+	Digest  *bool `json:"digest,omitempty"`
+}
+
+// Preferences is core.Preferences of the API.
+type Preferences struct {
+	// DefaultNotebookID receives notes created without a notebook.
+	DefaultNotebookID int64  `json:"default_notebook_id"`
+	Locale            string `json:"locale"`
+	// Timezone is an IANA zone name used for digests and reminders.
+	Timezone string `json:"timezone"`
+	// PageSize is the note list limit used when a request gives none.
+	PageSize int `json:"page_size"`
+	// Email receives the weekly digest.
+	Email         string                  `json:"email"`
+	Notifications NotificationPreferences `json:"notifications"`
+	Privacy       PrivacyPreferences      `json:"privacy"`
+	// SearchLanguage is the analyzer of the user's searches; empty uses
+	// the one of the instance.
+	SearchLanguage string `json:"search_language"`
+}
+
+// PreferencesUpdate is core.PreferencesUpdate of the API.
+type PreferencesUpdate struct {
+	DefaultNotebookID *int64                         `json:"default_notebook_id,omitempty"`
+	Locale            *string                        `json:"locale,omitempty"`
+	Timezone          *string                        `json:"timezone,omitempty"`
+	PageSize          *int                           `json:"page_size,omitempty"`
+	Email             *string                        `json:"email,omitempty"`
+	Notifications     *NotificationPreferencesUpdate `json:"notifications,omitempty"`
+	Privacy           *PrivacyPreferencesUpdate      `json:"privacy,omitempty"`
+	SearchLanguage    *string                        `json:"search_language,omitempty"`
+}
+
+// PrivacyPreferences is core.PrivacyPreferences of the API.
+type PrivacyPreferences struct {
+	// RecentNotes keeps the notes the user viewed for GET /notes/recent.
+	RecentNotes bool `json:"recent_notes"`
+}
+
+// PrivacyPreferencesUpdate is core.PrivacyPreferencesUpdate of the API.
+type PrivacyPreferencesUpdate struct {
+	RecentNotes *bool `json:"recent_notes,omitempty"`
+}
+
+// PropertyDef is core.PropertyDef of the API.
+type PropertyDef struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// ReviewState is core.ReviewState of the API.
+type ReviewState struct {
+	NoteID         int64      `json:"note_id"`
+	IntervalDays   int        `json:"interval_days"`
+	Reviews        int        `json:"reviews"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at,omitempty"`
+	DueAt          time.Time  `json:"due_at"`
+}
+
+// Rule is core.Rule of the API.
+type Rule struct {
+	ID      int64        `json:"id"`
+	OwnerID string       `json:"owner_id"`
+	Name    string       `json:"name"`
+	Enabled bool         `json:"enabled"`
+	When    RuleTrigger  `json:"when"`
+	Then    []RuleAction `json:"then"`
+	// Runs counts the events the rule ran on, LastRunAt is the latest.
+	Runs      int        `json:"runs"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// RuleAction is core.RuleAction of the API.
+type RuleAction struct {
+	Type       string   `json:"type"`
+	Tag        string   `json:"tag,omitempty"`
+	NotebookID int64    `json:"notebook_id,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	To         []string `json:"to,omitempty"`
+}
+
+// RuleInput is core.RuleInput of the API.
+type RuleInput struct {
+	Name string `json:"name"`
+	// Enabled defaults to true.
+	Enabled *bool        `json:"enabled,omitempty"`
+	When    RuleTrigger  `json:"when"`
+	Then    []RuleAction `json:"then"`
+}
+
+// RuleTrigger is core.RuleTrigger of the API.
+type RuleTrigger struct {
+	Event string `json:"event"`
+	// Tag, when set, limits the rule to notes with the tag or one nested
+	// under it.
+	Tag string `json:"tag,omitempty"`
+}
+
+// SavedView is core.SavedView of the API.
+type SavedView struct {
+	ID         int64      `json:"id"`
+	OwnerID    string     `json:"owner_id"`
+	Name       string     `json:"name"`
+	Query      string     `json:"query,omitempty"`
+	Sort       string     `json:"sort,omitempty"`
+	Fields     []string   `json:"fields,omitempty"`
+	SharedWith []string   `json:"shared_with,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// SavedViewInput is core.SavedViewInput of the API.
+type SavedViewInput struct {
+	Name       string   `json:"name"`
+	Query      string   `json:"query,omitempty"`
+	Sort       string   `json:"sort,omitempty"`
+	Fields     []string `json:"fields,omitempty"`
+	SharedWith []string `json:"shared_with,omitempty"`
+}
+
+// Share is core.Share of the API.
+type Share struct {
+	Grantee string `json:"grantee"`
+	Role    string `json:"role"`
+}
+
+// TagCount is core.TagCount of the API.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagNode is core.TagNode of the API.
+type TagNode struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Count is the number of notes with exactly this tag, Total also
+	// includes notes tagged with any descendant.
+	Count    int       `json:"count"`
+	Total    int       `json:"total"`
+	Children []TagNode `json:"children,omitempty"`
+}
+
+// Event is events.Event of the API.
+type Event struct {
+	Version int    `json:"version"`
+	Type    string `json:"type"`
+	NoteID  int64  `json:"note_id"`
+	// Note is the note after a note.* event; presence.* events leave it
+	// out.
+	Note Note `json:"note,omitempty"`
+	// UserID has the note open in presence.* events, and is Typing in
+	// it.
+	UserID string    `json:"user_id,omitempty"`
+	Typing bool      `json:"typing,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Viewer is events.Viewer of the API.
+type Viewer struct {
+	UserID string    `json:"user_id"`
+	Typing bool      `json:"typing"`
+	Since  time.Time `json:"since"`
+}
+
+// ActivityDay is handlers.ActivityDay of the API.
+type ActivityDay struct {
+	Date    string `json:"date"`
+	Created int    `json:"created"`
+	Updated int    `json:"updated"`
+}
+
+// BlockStorage is handlers.BlockStorage of the API.
+type BlockStorage struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Board is handlers.Board of the API.
+type Board struct {
+	Property string        `json:"property"`
+	Columns  []BoardColumn `json:"columns"`
+}
+
+// BoardColumn is handlers.BoardColumn of the API.
+type BoardColumn struct {
+	// Value is the property value or tag of the column; cards without one
+	// are in a last column with an empty value.
+	Value string `json:"value"`
+	Cards []Note `json:"cards"`
+}
+
+// BulkMoveRequest is handlers.BulkMoveRequest of the API.
+type BulkMoveRequest struct {
+	IDS        []int64 `json:"ids"`
+	NotebookID int64   `json:"notebook_id"`
+}
+
+// BulkTagRequest is handlers.BulkTagRequest of the API.
+type BulkTagRequest struct {
+	Q          string `json:"q,omitempty"`
+	NotebookID int64  `json:"notebook_id,omitempty"`
+	// From and To bound the creation time; To is exclusive.
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// CDCRecord is handlers.CDCRecord of the API.
+type CDCRecord struct {
+	Seq    int64     `json:"seq"`
+	Op     string    `json:"op"`
+	NoteID int64     `json:"note_id,omitempty"`
+	Note   *Note     `json:"note,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// ChangeDiff is handlers.ChangeDiff of the API.
+type ChangeDiff struct {
+	ChangeRequest ChangeRequest `json:"change_request"`
+	Fields        []FieldChange `json:"fields"`
+	// Content is the line diff of the content, empty when the request
+	// leaves it alone.
+	Content []DiffLine `json:"content"`
+}
+
+// ClipRequest is handlers.ClipRequest of the API.
+type ClipRequest struct {
+	URL string `json:"url"`
+	// HTML is the page as the browser rendered it. When set the server
+	// does not fetch URL, so pages behind a login can be clipped too.
+	HTML       string   `json:"html,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	NotebookID int64    `json:"notebook_id,omitempty"`
+}
+
+// CommentThread is handlers.CommentThread of the API.
+type CommentThread struct {
+	ID       int64  `json:"id"`
+	NoteID   int64  `json:"note_id"`
+	AuthorID string `json:"author_id"`
+	Body     string `json:"body"`
+	ReplyTo  int64  `json:"reply_to,omitempty"`
+	// Resolved marks a thread as settled; ResolvedBy did so at ResolvedAt.
+	Resolved   bool       `json:"resolved"`
+	ResolvedBy string     `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	Replies    []Comment  `json:"replies"`
+}
+
+// CreateCommentRequest is handlers.CreateCommentRequest of the API.
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+	// ReplyTo answers a comment in its thread; zero starts a thread.
+	ReplyTo int64 `json:"reply_to,omitempty"`
+}
+
+// Dashboard is handlers.Dashboard of the API.
+type Dashboard struct {
+	Pinned            []Note     `json:"pinned"`
+	UpcomingReminders []Note     `json:"upcoming_reminders"`
+	RecentActivity    []Note     `json:"recent_activity"`
+	TagCloud          []TagCount `json:"tag_cloud"`
+}
+
+// EchoPrincipal is handlers.EchoPrincipal of the API.
+type EchoPrincipal struct {
+	UserID    string `json:"user_id"`
+	Admin     bool   `json:"admin"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+// EchoRateLimit is handlers.EchoRateLimit of the API.
+type EchoRateLimit struct {
+	Limit     string `json:"limit"`
+	Remaining string `json:"remaining"`
+	Reset     string `json:"reset"`
+}
+
+// EchoResponse is handlers.EchoResponse of the API.
+type EchoResponse struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Route      string              `json:"route"`
+	RequestID  string              `json:"request_id"`
+	RemoteAddr string              `json:"remote_addr"`
+	Proto      string              `json:"proto"`
+	Host       string              `json:"host"`
+	Query      map[string][]string `json:"query"`
+	Headers    map[string][]string `json:"headers"`
+	Principal  EchoPrincipal       `json:"principal"`
+	// RateLimit is the caller's limit state, when rate limits are on.
+	RateLimit *EchoRateLimit `json:"rate_limit,omitempty"`
+}
+
+// EmailNoteRequest is handlers.EmailNoteRequest of the API.
+type EmailNoteRequest struct {
+	To []string `json:"to"`
+	// Message is written above the note.
+	Message string `json:"message,omitempty"`
+}
+
+// EmailNoteResponse is handlers.EmailNoteResponse of the API.
+type EmailNoteResponse struct {
+	Sent []string `json:"sent"`
+}
+
+// EmojiList is handlers.EmojiList of the API.
+type EmojiList struct {
+	Builtin []BuiltinEmoji `json:"builtin"`
+	Custom  []CustomEmoji  `json:"custom"`
+}
+
+// ErrorCode is handlers.ErrorCode of the API.
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// ErrorResponse is handlers.ErrorResponse of the API.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Code is one of those GET /errors lists.
+	Code string `json:"code"`
+	// Fields maps invalid fields to what is wrong with them, for
+	// validation_failed.
+	Fields map[string]string `json:"fields,omitempty"`
+	// ConflictingID is the note whose title a duplicate_title request
+	// would repeat.
+	ConflictingID int64 `json:"conflicting_id,omitempty"`
+	// Lock is the edit lock someone else holds, for note_locked.
+	Lock *NoteLock `json:"lock,omitempty"`
+}
+
+// FavoriteResponse is handlers.FavoriteResponse of the API.
+type FavoriteResponse struct {
+	Favorite bool `json:"favorite"`
+}
+
+// FieldChange is handlers.FieldChange of the API.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// InboundResult is handlers.InboundResult of the API.
+type InboundResult struct {
+	NoteID   int64  `json:"note_id"`
+	PublicID string `json:"public_id"`
+	Title    string `json:"title"`
+}
+
+// InstanceStats is handlers.InstanceStats of the API.
+type InstanceStats struct {
+	// Users counts users who own at least one note.
+	Users     int           `json:"users"`
+	Notes     int           `json:"notes"`
+	Bytes     int64         `json:"bytes"`
+	Notebooks int           `json:"notebooks"`
+	TopUsers  []UserStorage `json:"top_users"`
+	// Requests and Errors are absent when metrics are disabled.
+	RequestsPerDay []DayCount    `json:"requests_per_day,omitempty"`
+	TopErrors      []StatusCount `json:"top_errors,omitempty"`
+}
+
+// JobResponse is handlers.JobResponse of the API.
+type JobResponse struct {
+	ID         int64      `json:"id"`
+	Kind       string     `json:"kind"`
+	State      string     `json:"state"`
+	Total      int        `json:"total"`
+	Done       int        `json:"done"`
+	Errors     []string   `json:"errors"`
+	ErrorCount int        `json:"error_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// ResultURL is where the file the job produced can be downloaded:
+	// /jobs/{id}/result, or a signed link that needs no token.
+	ResultURL string `json:"result_url,omitempty"`
+}
+
+// MarkReadRequest is handlers.MarkReadRequest of the API.
+type MarkReadRequest struct {
+	// IDs lists the notifications to mark read; empty marks all.
+	IDS []int64 `json:"ids,omitempty"`
+}
+
+// MarkReadResponse is handlers.MarkReadResponse of the API.
+type MarkReadResponse struct {
+	Marked int `json:"marked"`
+}
+
+// MergeTagsRequest is handlers.MergeTagsRequest of the API.
+type MergeTagsRequest struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// MoveCardRequest is handlers.MoveCardRequest of the API.
+type MoveCardRequest struct {
+	Column string `json:"column"`
+	// BeforeID places the card above another card of the column in the same
+	// notebook; without it the card keeps its position.
+	BeforeID int64 `json:"before_id,omitempty"`
+}
+
+// MoveNoteRequest is handlers.MoveNoteRequest of the API.
+type MoveNoteRequest struct {
+	NotebookID int64 `json:"notebook_id"`
+}
+
+// NoteCount is handlers.NoteCount of the API.
+type NoteCount struct {
+	Count int `json:"count"`
+}
+
+// NoteTransitions is handlers.NoteTransitions of the API.
+type NoteTransitions struct {
+	State string   `json:"state"`
+	Next  []string `json:"next"`
+}
+
+// NotebookStorage is handlers.NotebookStorage of the API.
+type NotebookStorage struct {
+	// NotebookID is 0 for notes outside notebooks.
+	NotebookID int64  `json:"notebook_id"`
+	Name       string `json:"name,omitempty"`
+	Count      int    `json:"count"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// PresenceRequest is handlers.PresenceRequest of the API.
+type PresenceRequest struct {
+	// Typing tells whether the caller is typing in the note.
+	Typing bool `json:"typing"`
+}
+
+// PublicLinkResponse is handlers.PublicLinkResponse of the API.
+type PublicLinkResponse struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// PutEmojiRequest is handlers.PutEmojiRequest of the API.
+type PutEmojiRequest struct {
+	URL string `json:"url"`
+}
+
+// QueryValidation is handlers.QueryValidation of the API.
+type QueryValidation struct {
+	Valid    bool        `json:"valid"`
+	Language string      `json:"language,omitempty"`
+	Query    string      `json:"query,omitempty"`
+	Error    *ParseError `json:"error,omitempty"`
+}
+
+// ReactionRequest is handlers.ReactionRequest of the API.
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// ReactionsResponse is handlers.ReactionsResponse of the API.
+type ReactionsResponse struct {
+	// Reactions counts the users behind each emoji.
+	Reactions map[string]int `json:"reactions"`
+	// Mine lists the caller's own reactions.
+	Mine []string `json:"mine"`
+}
+
+// RejectChangeRequest is handlers.RejectChangeRequest of the API.
+type RejectChangeRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// RenameTagRequest is handlers.RenameTagRequest of the API.
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// ReorderRequest is handlers.ReorderRequest of the API.
+type ReorderRequest struct {
+	NotebookID int64   `json:"notebook_id"`
+	IDS        []int64 `json:"ids"`
+}
+
+// ReviewCard is handlers.ReviewCard of the API.
+type ReviewCard struct {
+	Note Note `json:"note"`
+	// Review is nil for notes never reviewed.
+	Review *ReviewState `json:"review,omitempty"`
+}
+
+// ReviewDoneRequest is handlers.ReviewDoneRequest of the API.
+type ReviewDoneRequest struct {
+	Grade string `json:"grade,omitempty"`
+}
+
+// SearchResults is handlers.SearchResults of the API.
+type SearchResults struct {
+	Total  int    `json:"total"`
+	Notes  []Note `json:"notes"`
+	Facets Facets `json:"facets"`
+}
+
+// StartUploadRequest is handlers.StartUploadRequest of the API.
+type StartUploadRequest struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// SHA256 is the hex digest of the whole file, checked when the last
+	// chunk arrives.
+	Sha256 string `json:"sha256"`
+}
+
+// StorageStats is handlers.StorageStats of the API.
+type StorageStats struct {
+	Total     Usage             `json:"total"`
+	Notebooks []NotebookStorage `json:"notebooks"`
+	Tags      []TagStorage      `json:"tags"`
+	Blocks    []BlockStorage    `json:"blocks"`
+}
+
+// SuccessResponse is handlers.SuccessResponse of the API.
+type SuccessResponse struct {
+	Message string `json:"message"`
+}
+
+// TagChangeResponse is handlers.TagChangeResponse of the API.
+type TagChangeResponse struct {
+	Tag          string `json:"tag"`
+	NotesUpdated int    `json:"notes_updated"`
+}
+
+// TagStorage is handlers.TagStorage of the API.
+type TagStorage struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// TransitionRequest is handlers.TransitionRequest of the API.
+type TransitionRequest struct {
+	To string `json:"to"`
+}
+
+// TypeaheadHit is handlers.TypeaheadHit of the API.
+type TypeaheadHit struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// UndoResponse is handlers.UndoResponse of the API.
+type UndoResponse struct {
+	Operation string `json:"operation"`
+	Notes     []Note `json:"notes"`
+}
+
+// UpdateCommentRequest is handlers.UpdateCommentRequest of the API.
+type UpdateCommentRequest struct {
+	// Body edits the comment; only its author can.
+	Body *string `json:"body,omitempty"`
+	// Resolved resolves or reopens a thread; the author of the thread and
+	// editors of the note can.
+	Resolved *bool `json:"resolved,omitempty"`
+}
+
+// UserStorage is handlers.UserStorage of the API.
+type UserStorage struct {
+	UserID string `json:"user_id"`
+	Count  int    `json:"count"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// VaultImportResponse is handlers.VaultImportResponse of the API.
+type VaultImportResponse struct {
+	NotebooksCreated int      `json:"notebooks_created"`
+	NotesCreated     int      `json:"notes_created"`
+	Skipped          []string `json:"skipped"`
+}
+
+// WatchResponse is handlers.WatchResponse of the API.
+type WatchResponse struct {
+	Watching bool `json:"watching"`
+}
+
+// ZapierAppendRequest is handlers.ZapierAppendRequest of the API.
+type ZapierAppendRequest struct {
+	NoteID  int64  `json:"note_id"`
+	Content string `json:"content"`
+}
+
+// ZapierCreateRequest is handlers.ZapierCreateRequest of the API.
+type ZapierCreateRequest struct {
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	Tags       string `json:"tags"`
+	NotebookID int64  `json:"notebook_id"`
+}
+
+// ZapierMe is handlers.ZapierMe of the API.
+type ZapierMe struct {
+	UserID string `json:"user_id"`
+}
+
+// ZapierNote is handlers.ZapierNote of the API.
+type ZapierNote struct {
+	ID         string    `json:"id"`
+	NoteID     int64     `json:"note_id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	Tags       string    `json:"tags"`
+	NotebookID int64     `json:"notebook_id"`
+	Pinned     bool      `json:"pinned"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// JobsJob is jobs.Job of the API.
+type JobsJob struct {
+	ID         int64      `json:"id"`
+	Kind       string     `json:"kind"`
+	State      string     `json:"state"`
+	Total      int        `json:"total"`
+	Done       int        `json:"done"`
+	Errors     []string   `json:"errors"`
+	ErrorCount int        `json:"error_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// DayCount is metrics.DayCount of the API.
+type DayCount struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
+
+// StatusCount is metrics.StatusCount of the API.
+type StatusCount struct {
+	Status   int   `json:"status"`
+	Requests int64 `json:"requests"`
+}
+
+// Notification is notify.Notification of the API.
+type Notification struct {
+	ID     int64     `json:"id"`
+	Type   string    `json:"type"`
+	NoteID int64     `json:"note_id"`
+	JobID  int64     `json:"job_id,omitempty"`
+	Title  string    `json:"title"`
+	Actor  string    `json:"actor"`
+	At     time.Time `json:"at"`
+	Read   bool      `json:"read"`
+	// ChangeID is the change request of change.* notifications.
+	ChangeID int64 `json:"change_id,omitempty"`
+}
+
+// Preview is preview.Preview of the API.
+type Preview struct {
+	URL         string     `json:"url"`
+	State       string     `json:"state"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Image       string     `json:"image,omitempty"`
+	SiteName    string     `json:"site_name,omitempty"`
+	Favicon     string     `json:"favicon,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+}
+
+// BuiltinEmoji is render.BuiltinEmoji of the API.
+type BuiltinEmoji struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji"`
+}
+
+// CustomEmoji is render.CustomEmoji of the API.
+type CustomEmoji struct {
+	Name    string    `json:"name"`
+	URL     string    `json:"url"`
+	Author  string    `json:"author"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Template is render.Template of the API.
+type Template struct {
+	Name    string    `json:"name"`
+	Header  string    `json:"header,omitempty"`
+	Footer  string    `json:"footer,omitempty"`
+	Meta    string    `json:"meta,omitempty"`
+	Css     string    `json:"css,omitempty"`
+	HTML    string    `json:"html,omitempty"`
+	Author  string    `json:"author"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Usage is repo.Usage of the API.
+type Usage struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Facet is search.Facet of the API.
+type Facet struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Facets is search.Facets of the API.
+type Facets struct {
+	Tags []Facet `json:"tags"`
+	// Notebooks are by notebook ID; notes outside notebooks are not counted.
+	Notebooks []Facet `json:"notebooks"`
+	// Created is by how long ago the note was created, in the buckets
+	// today, week, month, year and older, each excluding the ones before.
+	Created []Facet `json:"created"`
+	// Authors are by owner ID.
+	Authors []Facet `json:"authors"`
+}
+
+// SearchJob is search.Job of the API.
+type SearchJob struct {
+	ID            int64      `json:"id"`
+	State         string     `json:"state"`
+	SchemaVersion int        `json:"schema_version"`
+	Total         int        `json:"total"`
+	Done          int64      `json:"done"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// ParseError is search.ParseError of the API.
+type ParseError struct {
+	Pos     int    `json:"pos"`
+	Message string `json:"message"`
+}
+
+// ReleaseAttachment calls POST /admin/attachments/{id}/release. Выпустить вложение из карантина.
+func (c *Client) ReleaseAttachment(ctx context.Context, id int64) (*Attachment, error) {
+	req := request{method: "POST", path: "/api/v1/admin/attachments/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/release"}
+	var out *Attachment
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListAuditParams are the optional parameters of ListAudit.
+type ListAuditParams struct {
+	// Записи после этого ID
+	After int64
+	// Сколько записей (по умолчанию 100, не больше 1000)
+	Limit int64
+}
+
+func (p *ListAuditParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.After != 0 {
+		req.setQuery("after", strconv.FormatInt(p.After, 10))
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+}
+
+// ListAudit calls GET /admin/audit. Журнал аудита.
+func (c *Client) ListAudit(ctx context.Context, params *ListAuditParams) ([]Entry, error) {
+	req := request{method: "GET", path: "/api/v1/admin/audit"}
+	params.apply(&req)
+	var out []Entry
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// StreamCDCParams are the optional parameters of StreamCDC.
+type StreamCDCParams struct {
+	// Номер последнего полученного изменения
+	Since int64
+}
+
+func (p *StreamCDCParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Since != 0 {
+		req.setQuery("since", strconv.FormatInt(p.Since, 10))
+	}
+}
+
+// StreamCDC calls GET /admin/cdc. Поток изменений для репликации (CDC).
+func (c *Client) StreamCDC(ctx context.Context, params *StreamCDCParams) (*CDCRecord, error) {
+	req := request{method: "GET", path: "/api/v1/admin/cdc"}
+	params.apply(&req)
+	var out *CDCRecord
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListPrintTemplates calls GET /admin/print-templates. Шаблоны печати.
+func (c *Client) ListPrintTemplates(ctx context.Context) ([]Template, error) {
+	req := request{method: "GET", path: "/api/v1/admin/print-templates"}
+	var out []Template
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeletePrintTemplate calls DELETE /admin/print-templates/{name}. Удалить шаблон печати.
+func (c *Client) DeletePrintTemplate(ctx context.Context, name string) error {
+	req := request{method: "DELETE", path: "/api/v1/admin/print-templates/" + url.PathEscape(name)}
+	return c.do(ctx, req, nil)
+}
+
+// PutPrintTemplate calls PUT /admin/print-templates/{name}. Сохранить шаблон печати.
+func (c *Client) PutPrintTemplate(ctx context.Context, name string, body Template) (*Template, error) {
+	req := request{method: "PUT", path: "/api/v1/admin/print-templates/" + url.PathEscape(name), body: body}
+	var out *Template
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ReindexStatus calls GET /admin/search/reindex. Ход перестроения индекса.
+func (c *Client) ReindexStatus(ctx context.Context) (*SearchJob, error) {
+	req := request{method: "GET", path: "/api/v1/admin/search/reindex"}
+	var out *SearchJob
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ReindexSearch calls POST /admin/search/reindex. Перестроить поисковый индекс.
+func (c *Client) ReindexSearch(ctx context.Context) (*SearchJob, error) {
+	req := request{method: "POST", path: "/api/v1/admin/search/reindex"}
+	var out *SearchJob
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// InstanceStatsParams are the optional parameters of InstanceStats.
+type InstanceStatsParams struct {
+	// Сколько дней запросов, включая сегодня (по умолчанию 14, не больше 90)
+	Days int64
+}
+
+func (p *InstanceStatsParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Days != 0 {
+		req.setQuery("days", strconv.FormatInt(p.Days, 10))
+	}
+}
+
+// InstanceStats calls GET /admin/stats. Статистика инстанса.
+func (c *Client) InstanceStats(ctx context.Context, params *InstanceStatsParams) (*InstanceStats, error) {
+	req := request{method: "GET", path: "/api/v1/admin/stats"}
+	params.apply(&req)
+	var out *InstanceStats
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetBoardParams are the optional parameters of GetBoard.
+type GetBoardParams struct {
+	// Порядок колонок через запятую; пустые колонки тоже выводятся
+	Columns string
+	// Только заметки блокнота
+	NotebookID int64
+}
+
+func (p *GetBoardParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Columns != "" {
+		req.setQuery("columns", p.Columns)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+}
+
+// GetBoard calls GET /boards/{property}. Канбан-доска.
+func (c *Client) GetBoard(ctx context.Context, property string, params *GetBoardParams) (*Board, error) {
+	req := request{method: "GET", path: "/api/v1/boards/" + url.PathEscape(property)}
+	params.apply(&req)
+	var out *Board
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// MoveCard calls PATCH /boards/{property}/cards/{id}. Переместить карточку.
+func (c *Client) MoveCard(ctx context.Context, property string, id string, body MoveCardRequest) (*Note, error) {
+	req := request{method: "PATCH", path: "/api/v1/boards/" + url.PathEscape(property) + "/cards/" + url.PathEscape(id), body: body}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListChangeRequestsParams are the optional parameters of ListChangeRequests.
+type ListChangeRequestsParams struct {
+	// Только с этим статусом
+	Status string
+	// Только запросы к заметкам блокнота
+	NotebookID int64
+}
+
+func (p *ListChangeRequestsParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Status != "" {
+		req.setQuery("status", p.Status)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+}
+
+// ListChangeRequests calls GET /change-requests. Запросы на изменение.
+func (c *Client) ListChangeRequests(ctx context.Context, params *ListChangeRequestsParams) ([]ChangeRequest, error) {
+	req := request{method: "GET", path: "/api/v1/change-requests"}
+	params.apply(&req)
+	var out []ChangeRequest
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetChangeRequest calls GET /change-requests/{id}. Получить запрос на изменение.
+func (c *Client) GetChangeRequest(ctx context.Context, id int64) (*ChangeRequest, error) {
+	req := request{method: "GET", path: "/api/v1/change-requests/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *ChangeRequest
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ApproveChangeRequest calls POST /change-requests/{id}/approve. Одобрить запрос на изменение.
+func (c *Client) ApproveChangeRequest(ctx context.Context, id int64) (*ChangeRequest, error) {
+	req := request{method: "POST", path: "/api/v1/change-requests/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/approve"}
+	var out *ChangeRequest
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetChangeDiff calls GET /change-requests/{id}/diff. Что изменит запрос.
+func (c *Client) GetChangeDiff(ctx context.Context, id int64) (*ChangeDiff, error) {
+	req := request{method: "GET", path: "/api/v1/change-requests/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/diff"}
+	var out *ChangeDiff
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// RejectChangeRequest calls POST /change-requests/{id}/reject. Отклонить запрос на изменение.
+func (c *Client) RejectChangeRequest(ctx context.Context, id int64, body RejectChangeRequest) (*ChangeRequest, error) {
+	req := request{method: "POST", path: "/api/v1/change-requests/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/reject", body: body}
+	var out *ChangeRequest
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ClipPage calls POST /clip. Сохранить веб-страницу как заметку.
+func (c *Client) ClipPage(ctx context.Context, body ClipRequest) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/clip", body: body}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListCollections calls GET /collections. Список коллекций.
+func (c *Client) ListCollections(ctx context.Context) ([]Collection, error) {
+	req := request{method: "GET", path: "/api/v1/collections"}
+	var out []Collection
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateCollection calls POST /collections. Создать коллекцию.
+func (c *Client) CreateCollection(ctx context.Context, body CollectionCreate) (*Collection, error) {
+	req := request{method: "POST", path: "/api/v1/collections", body: body}
+	var out *Collection
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteCollection calls DELETE /collections/{id}. Удалить коллекцию.
+func (c *Client) DeleteCollection(ctx context.Context, id int64) error {
+	req := request{method: "DELETE", path: "/api/v1/collections/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// GetCollection calls GET /collections/{id}. Получить коллекцию.
+func (c *Client) GetCollection(ctx context.Context, id int64) (*Collection, error) {
+	req := request{method: "GET", path: "/api/v1/collections/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *Collection
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PatchCollection calls PATCH /collections/{id}. Изменить коллекцию.
+func (c *Client) PatchCollection(ctx context.Context, id int64, body CollectionUpdate) (*Collection, error) {
+	req := request{method: "PATCH", path: "/api/v1/collections/" + url.PathEscape(strconv.FormatInt(id, 10)), body: body}
+	var out *Collection
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListCollectionNotes calls GET /collections/{id}/notes. Заметки коллекции.
+func (c *Client) ListCollectionNotes(ctx context.Context, id int64) ([]Note, error) {
+	req := request{method: "GET", path: "/api/v1/collections/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/notes"}
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetDashboard calls GET /dashboard. Дашборд.
+func (c *Client) GetDashboard(ctx context.Context) (*Dashboard, error) {
+	req := request{method: "GET", path: "/api/v1/dashboard"}
+	var out *Dashboard
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DebugEcho calls GET /debug/echo. Запрос глазами сервера.
+func (c *Client) DebugEcho(ctx context.Context) (*EchoResponse, error) {
+	req := request{method: "GET", path: "/api/v1/debug/echo"}
+	var out *EchoResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListEmoji calls GET /emoji. Эмодзи.
+func (c *Client) ListEmoji(ctx context.Context) (*EmojiList, error) {
+	req := request{method: "GET", path: "/api/v1/emoji"}
+	var out *EmojiList
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteEmoji calls DELETE /emoji/{name}. Удалить своё эмодзи.
+func (c *Client) DeleteEmoji(ctx context.Context, name string) error {
+	req := request{method: "DELETE", path: "/api/v1/emoji/" + url.PathEscape(name)}
+	return c.do(ctx, req, nil)
+}
+
+// PutEmoji calls PUT /emoji/{name}. Добавить своё эмодзи.
+func (c *Client) PutEmoji(ctx context.Context, name string, body PutEmojiRequest) (*CustomEmoji, error) {
+	req := request{method: "PUT", path: "/api/v1/emoji/" + url.PathEscape(name), body: body}
+	var out *CustomEmoji
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListErrorCodes calls GET /errors. Коды ошибок.
+func (c *Client) ListErrorCodes(ctx context.Context) ([]ErrorCode, error) {
+	req := request{method: "GET", path: "/api/v1/errors"}
+	var out []ErrorCode
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// StreamEvents calls GET /events. Поток изменений заметок (SSE).
+func (c *Client) StreamEvents(ctx context.Context) (*Event, error) {
+	req := request{method: "GET", path: "/api/v1/events"}
+	var out *Event
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateExportJobParams are the optional parameters of CreateExportJob.
+type CreateExportJobParams struct {
+	// Формат файла
+	Format string
+}
+
+func (p *CreateExportJobParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Format != "" {
+		req.setQuery("format", p.Format)
+	}
+}
+
+// CreateExportJob calls POST /export. Экспорт в фоне.
+func (c *Client) CreateExportJob(ctx context.Context, params *CreateExportJobParams) (*JobResponse, error) {
+	req := request{method: "POST", path: "/api/v1/export"}
+	params.apply(&req)
+	var out *JobResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ExportVault calls GET /export/vault. Экспорт в формате Obsidian.
+//
+// Deprecated: the route is deprecated.
+func (c *Client) ExportVault(ctx context.Context) ([]byte, error) {
+	req := request{method: "GET", path: "/api/v1/export/vault"}
+	var out []byte
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateImportJobParams are the optional parameters of CreateImportJob.
+type CreateImportJobParams struct {
+	// Формат файла
+	Format string
+	// Родительский блокнот для импорта
+	NotebookID int64
+}
+
+func (p *CreateImportJobParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Format != "" {
+		req.setQuery("format", p.Format)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+}
+
+// CreateImportJob calls POST /import/jobs. Импорт в фоне.
+func (c *Client) CreateImportJob(ctx context.Context, body []byte, params *CreateImportJobParams) (*JobResponse, error) {
+	req := request{method: "POST", path: "/api/v1/import/jobs", body: body, contentType: "application/octet-stream"}
+	params.apply(&req)
+	var out *JobResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ImportVaultParams are the optional parameters of ImportVault.
+type ImportVaultParams struct {
+	// Родительский блокнот для импорта
+	NotebookID int64
+	// Только разобрать архив: ответ 200 с тем, что было бы создано (или Prefer: dry-run)
+	DryRun bool
+}
+
+func (p *ImportVaultParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+}
+
+// ImportVault calls POST /import/vault. Импорт из формата Obsidian.
+func (c *Client) ImportVault(ctx context.Context, body []byte, params *ImportVaultParams) (*VaultImportResponse, error) {
+	req := request{method: "POST", path: "/api/v1/import/vault", body: body, contentType: "application/zip"}
+	params.apply(&req)
+	var out *VaultImportResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListInboundHooks calls GET /inbound-hooks. Входящие вебхуки пользователя.
+func (c *Client) ListInboundHooks(ctx context.Context) ([]InboundHook, error) {
+	req := request{method: "GET", path: "/api/v1/inbound-hooks"}
+	var out []InboundHook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateInboundHook calls POST /inbound-hooks. Создать входящий вебхук.
+func (c *Client) CreateInboundHook(ctx context.Context, body InboundHookInput) (*InboundHook, error) {
+	req := request{method: "POST", path: "/api/v1/inbound-hooks", body: body}
+	var out *InboundHook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteInboundHook calls DELETE /inbound-hooks/{id}. Удалить входящий вебхук.
+func (c *Client) DeleteInboundHook(ctx context.Context, id int64) error {
+	req := request{method: "DELETE", path: "/api/v1/inbound-hooks/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// ReceiveInbound calls POST /inbound/{token}. Создать заметку входящим вебхуком.
+func (c *Client) ReceiveInbound(ctx context.Context, token string, body []byte, contentType string) (*InboundResult, error) {
+	req := request{method: "POST", path: "/api/v1/inbound/" + url.PathEscape(token), body: body, contentType: contentType}
+	var out *InboundResult
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetJob calls GET /jobs/{id}. Состояние фоновой задачи.
+func (c *Client) GetJob(ctx context.Context, id int64) (*JobResponse, error) {
+	req := request{method: "GET", path: "/api/v1/jobs/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *JobResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetJobResult calls GET /jobs/{id}/result. Результат фоновой задачи.
+func (c *Client) GetJobResult(ctx context.Context, id int64) ([]byte, error) {
+	req := request{method: "GET", path: "/api/v1/jobs/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/result"}
+	var out []byte
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListJournalParams are the optional parameters of ListJournal.
+type ListJournalParams struct {
+	// С даты включительно (YYYY-MM-DD)
+	From string
+	// По дату включительно (YYYY-MM-DD)
+	To string
+}
+
+func (p *ListJournalParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.From != "" {
+		req.setQuery("from", p.From)
+	}
+	if p.To != "" {
+		req.setQuery("to", p.To)
+	}
+}
+
+// ListJournal calls GET /journal. Лента дневника.
+func (c *Client) ListJournal(ctx context.Context, params *ListJournalParams) ([]Note, error) {
+	req := request{method: "GET", path: "/api/v1/journal"}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetJournal calls GET /journal/{date}. Запись дневника за день.
+func (c *Client) GetJournal(ctx context.Context, date string) (*Note, error) {
+	req := request{method: "GET", path: "/api/v1/journal/" + url.PathEscape(date)}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateJournal calls POST /journal/{date}. Получить или создать запись дневника.
+func (c *Client) CreateJournal(ctx context.Context, date string) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/journal/" + url.PathEscape(date)}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetLifecycle calls GET /lifecycle. Жизненный цикл заметок.
+func (c *Client) GetLifecycle(ctx context.Context) (*Lifecycle, error) {
+	req := request{method: "GET", path: "/api/v1/lifecycle"}
+	var out *Lifecycle
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetPreferences calls GET /me/preferences. Мои настройки.
+func (c *Client) GetPreferences(ctx context.Context) (*Preferences, error) {
+	req := request{method: "GET", path: "/api/v1/me/preferences"}
+	var out *Preferences
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PatchPreferences calls PATCH /me/preferences. Изменить мои настройки.
+func (c *Client) PatchPreferences(ctx context.Context, body PreferencesUpdate) (*Preferences, error) {
+	req := request{method: "PATCH", path: "/api/v1/me/preferences", body: body}
+	var out *Preferences
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListNoteTemplates calls GET /note-templates. Шаблоны заметок пользователя.
+func (c *Client) ListNoteTemplates(ctx context.Context) ([]NoteTemplate, error) {
+	req := request{method: "GET", path: "/api/v1/note-templates"}
+	var out []NoteTemplate
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateNoteTemplate calls POST /note-templates. Создать шаблон заметки.
+func (c *Client) CreateNoteTemplate(ctx context.Context, body NoteTemplateInput) (*NoteTemplate, error) {
+	req := request{method: "POST", path: "/api/v1/note-templates", body: body}
+	var out *NoteTemplate
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteNoteTemplate calls DELETE /note-templates/{id}. Удалить шаблон заметки.
+func (c *Client) DeleteNoteTemplate(ctx context.Context, id int64) error {
+	req := request{method: "DELETE", path: "/api/v1/note-templates/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// GetNoteTemplate calls GET /note-templates/{id}. Шаблон заметки.
+func (c *Client) GetNoteTemplate(ctx context.Context, id int64) (*NoteTemplate, error) {
+	req := request{method: "GET", path: "/api/v1/note-templates/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *NoteTemplate
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PutNoteTemplate calls PUT /note-templates/{id}. Заменить шаблон заметки.
+func (c *Client) PutNoteTemplate(ctx context.Context, id int64, body NoteTemplateInput) (*NoteTemplate, error) {
+	req := request{method: "PUT", path: "/api/v1/note-templates/" + url.PathEscape(strconv.FormatInt(id, 10)), body: body}
+	var out *NoteTemplate
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateNoteFromTemplate calls POST /note-templates/{id}/notes. Создать заметку по шаблону.
+func (c *Client) CreateNoteFromTemplate(ctx context.Context, id int64) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/note-templates/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/notes"}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListNotebooks calls GET /notebooks. Список блокнотов.
+func (c *Client) ListNotebooks(ctx context.Context) ([]Notebook, error) {
+	req := request{method: "GET", path: "/api/v1/notebooks"}
+	var out []Notebook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateNotebook calls POST /notebooks. Создать блокнот.
+func (c *Client) CreateNotebook(ctx context.Context, body NotebookCreate) (*Notebook, error) {
+	req := request{method: "POST", path: "/api/v1/notebooks", body: body}
+	var out *Notebook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteNotebook calls DELETE /notebooks/{id}. Удалить блокнот.
+func (c *Client) DeleteNotebook(ctx context.Context, id int64) error {
+	req := request{method: "DELETE", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// GetNotebook calls GET /notebooks/{id}. Получить блокнот.
+func (c *Client) GetNotebook(ctx context.Context, id int64) (*Notebook, error) {
+	req := request{method: "GET", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *Notebook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PatchNotebook calls PATCH /notebooks/{id}. Переименовать или переместить блокнот.
+func (c *Client) PatchNotebook(ctx context.Context, id int64, body NotebookUpdate) (*Notebook, error) {
+	req := request{method: "PATCH", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10)), body: body}
+	var out *Notebook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNotebookSettings calls GET /notebooks/{id}/settings. Настройки блокнота.
+func (c *Client) GetNotebookSettings(ctx context.Context, id int64) (*NotebookSettings, error) {
+	req := request{method: "GET", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/settings"}
+	var out *NotebookSettings
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PutNotebookSettings calls PUT /notebooks/{id}/settings. Заменить настройки блокнота.
+func (c *Client) PutNotebookSettings(ctx context.Context, id int64, body NotebookSettings) (*NotebookSettings, error) {
+	req := request{method: "PUT", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/settings", body: body}
+	var out *NotebookSettings
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ShareNotebook calls PUT /notebooks/{id}/shares. Открыть доступ к блокноту.
+func (c *Client) ShareNotebook(ctx context.Context, id int64, body Share) (*Notebook, error) {
+	req := request{method: "PUT", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/shares", body: body}
+	var out *Notebook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// UnshareNotebook calls DELETE /notebooks/{id}/shares/{grantee}. Закрыть доступ к блокноту.
+func (c *Client) UnshareNotebook(ctx context.Context, id int64, grantee string) (*Notebook, error) {
+	req := request{method: "DELETE", path: "/api/v1/notebooks/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/shares/" + url.PathEscape(grantee)}
+	var out *Notebook
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListNotesParams are the optional parameters of ListNotes.
+type ListNotesParams struct {
+	// Номер страницы
+	Page int64
+	// Размер страницы; по умолчанию page_size из настроек, без него — все
+	Limit int64
+	// Тип заметки
+	Type string
+	// Тег, включая вложенные (project → project/alpha)
+	Tag string
+	// Состояния жизненного цикла через запятую, например draft,active
+	State string
+	// Только заметки блокнота, в порядке position
+	NotebookID int64
+	// Поиск на языке запросов (см. GET /search/validate); результаты по релевантности
+	Q string
+	// Фильтр по свойству, например prop.status=done; числа, даты и булевы значения сравниваются по типу
+	Prop map[string]string
+	// Только заметки с моей реакцией
+	Reacted bool
+	// Только заметки с этой реакцией (вместе с reacted — с моей)
+	Reaction string
+	// Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек
+	DateFormat string
+	// Дата из Last-Modified; 304, если изменений не было
+	IfModifiedSince string
+}
+
+func (p *ListNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Page != 0 {
+		req.setQuery("page", strconv.FormatInt(p.Page, 10))
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Type != "" {
+		req.setQuery("type", p.Type)
+	}
+	if p.Tag != "" {
+		req.setQuery("tag", p.Tag)
+	}
+	if p.State != "" {
+		req.setQuery("state", p.State)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+	if p.Q != "" {
+		req.setQuery("q", p.Q)
+	}
+	for k, v := range p.Prop {
+		req.setQuery("prop."+k, v)
+	}
+	if p.Reacted {
+		req.setQuery("reacted", "true")
+	}
+	if p.Reaction != "" {
+		req.setQuery("reaction", p.Reaction)
+	}
+	if p.DateFormat != "" {
+		req.setQuery("date_format", p.DateFormat)
+	}
+	if p.IfModifiedSince != "" {
+		req.setHeader("If-Modified-Since", p.IfModifiedSince)
+	}
+}
+
+// ListNotes calls GET /notes. Список заметок.
+func (c *Client) ListNotes(ctx context.Context, params *ListNotesParams) ([]Note, error) {
+	req := request{method: "GET", path: "/api/v1/notes"}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListNotesAll iterates over every item of ListNotes, fetching page after
+// page from params.Page on.
+func (c *Client) ListNotesAll(ctx context.Context, params *ListNotesParams) iter.Seq2[Note, error] {
+	var p ListNotesParams
+	if params != nil {
+		p = *params
+	}
+	return paginate(&p.Page, &p.Limit, func() ([]Note, error) {
+		return c.ListNotes(ctx, &p)
+	})
+}
+
+// CreateNoteParams are the optional parameters of CreateNote.
+type CreateNoteParams struct {
+	// Только проверить запрос: ответ 200 с тем, что получилось бы, без сохранения (или Prefer: dry-run)
+	DryRun bool
+	// Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек
+	DateFormat string
+}
+
+func (p *CreateNoteParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+	if p.DateFormat != "" {
+		req.setQuery("date_format", p.DateFormat)
+	}
+}
+
+// CreateNote calls POST /notes. Создать заметку.
+func (c *Client) CreateNote(ctx context.Context, body NoteCreate, params *CreateNoteParams) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/notes", body: body}
+	params.apply(&req)
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// BulkCopyNotesParams are the optional parameters of BulkCopyNotes.
+type BulkCopyNotesParams struct {
+	// Только проверить: ответ 200 с заметками, какими они стали бы (или Prefer: dry-run)
+	DryRun bool
+}
+
+func (p *BulkCopyNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+}
+
+// BulkCopyNotes calls POST /notes/copy. Скопировать несколько заметок.
+func (c *Client) BulkCopyNotes(ctx context.Context, body BulkMoveRequest, params *BulkCopyNotesParams) ([]Note, error) {
+	req := request{method: "POST", path: "/api/v1/notes/copy", body: body}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CountNotesParams are the optional parameters of CountNotes.
+type CountNotesParams struct {
+	// Тип заметки
+	Type string
+	// Тег, включая вложенные (project → project/alpha)
+	Tag string
+	// Состояния жизненного цикла через запятую, например draft,active
+	State string
+	// Только заметки блокнота
+	NotebookID int64
+	// Поиск на языке запросов (см. GET /search/validate)
+	Q string
+	// Фильтр по свойству, например prop.status=done
+	Prop map[string]string
+	// Только заметки с моей реакцией
+	Reacted bool
+	// Только заметки с этой реакцией (вместе с reacted — с моей)
+	Reaction string
+}
+
+func (p *CountNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Type != "" {
+		req.setQuery("type", p.Type)
+	}
+	if p.Tag != "" {
+		req.setQuery("tag", p.Tag)
+	}
+	if p.State != "" {
+		req.setQuery("state", p.State)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+	if p.Q != "" {
+		req.setQuery("q", p.Q)
+	}
+	for k, v := range p.Prop {
+		req.setQuery("prop."+k, v)
+	}
+	if p.Reacted {
+		req.setQuery("reacted", "true")
+	}
+	if p.Reaction != "" {
+		req.setQuery("reaction", p.Reaction)
+	}
+}
+
+// CountNotes calls GET /notes/count. Количество заметок.
+func (c *Client) CountNotes(ctx context.Context, params *CountNotesParams) (*NoteCount, error) {
+	req := request{method: "GET", path: "/api/v1/notes/count"}
+	params.apply(&req)
+	var out *NoteCount
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListFavoritesParams are the optional parameters of ListFavorites.
+type ListFavoritesParams struct {
+	// Номер страницы
+	Page int64
+	// Размер страницы; по умолчанию page_size из настроек, без него — все
+	Limit int64
+}
+
+func (p *ListFavoritesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Page != 0 {
+		req.setQuery("page", strconv.FormatInt(p.Page, 10))
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+}
+
+// ListFavorites calls GET /notes/favorites. Избранные заметки.
+func (c *Client) ListFavorites(ctx context.Context, params *ListFavoritesParams) ([]Note, error) {
+	req := request{method: "GET", path: "/api/v1/notes/favorites"}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListFavoritesAll iterates over every item of ListFavorites, fetching page after
+// page from params.Page on.
+func (c *Client) ListFavoritesAll(ctx context.Context, params *ListFavoritesParams) iter.Seq2[Note, error] {
+	var p ListFavoritesParams
+	if params != nil {
+		p = *params
+	}
+	return paginate(&p.Page, &p.Limit, func() ([]Note, error) {
+		return c.ListFavorites(ctx, &p)
+	})
+}
+
+// BulkMoveNotesParams are the optional parameters of BulkMoveNotes.
+type BulkMoveNotesParams struct {
+	// Только проверить: ответ 200 с заметками, какими они стали бы (или Prefer: dry-run)
+	DryRun bool
+}
+
+func (p *BulkMoveNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+}
+
+// BulkMoveNotes calls POST /notes/move. Переместить несколько заметок.
+func (c *Client) BulkMoveNotes(ctx context.Context, body BulkMoveRequest, params *BulkMoveNotesParams) ([]Note, error) {
+	req := request{method: "POST", path: "/api/v1/notes/move", body: body}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// NearbyNotesParams are the optional parameters of NearbyNotes.
+type NearbyNotesParams struct {
+	// Широта
+	Lat float64
+	// Долгота
+	Lon float64
+	// Радиус в метрах (по умолчанию 1000)
+	Radius float64
+}
+
+func (p *NearbyNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Lat != 0 {
+		req.setQuery("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+	}
+	if p.Lon != 0 {
+		req.setQuery("lon", strconv.FormatFloat(p.Lon, 'f', -1, 64))
+	}
+	if p.Radius != 0 {
+		req.setQuery("radius", strconv.FormatFloat(p.Radius, 'f', -1, 64))
+	}
+}
+
+// NearbyNotes calls GET /notes/nearby. Заметки рядом с точкой.
+func (c *Client) NearbyNotes(ctx context.Context, params *NearbyNotesParams) ([]NearbyNote, error) {
+	req := request{method: "GET", path: "/api/v1/notes/nearby"}
+	params.apply(&req)
+	var out []NearbyNote
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// RandomNoteParams are the optional parameters of RandomNote.
+type RandomNoteParams struct {
+	// Тег, включая вложенные
+	Tag string
+	// Только заметки блокнота
+	NotebookID int64
+	// Тип заметки
+	Type string
+}
+
+func (p *RandomNoteParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Tag != "" {
+		req.setQuery("tag", p.Tag)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+	if p.Type != "" {
+		req.setQuery("type", p.Type)
+	}
+}
+
+// RandomNote calls GET /notes/random. Случайная заметка.
+func (c *Client) RandomNote(ctx context.Context, params *RandomNoteParams) (*Note, error) {
+	req := request{method: "GET", path: "/api/v1/notes/random"}
+	params.apply(&req)
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListRecentNotesParams are the optional parameters of ListRecentNotes.
+type ListRecentNotesParams struct {
+	// Номер страницы
+	Page int64
+	// Размер страницы; по умолчанию page_size из настроек, без него — все
+	Limit int64
+}
+
+func (p *ListRecentNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Page != 0 {
+		req.setQuery("page", strconv.FormatInt(p.Page, 10))
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+}
+
+// ListRecentNotes calls GET /notes/recent. Недавно открытые заметки.
+func (c *Client) ListRecentNotes(ctx context.Context, params *ListRecentNotesParams) ([]Note, error) {
+	req := request{method: "GET", path: "/api/v1/notes/recent"}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListRecentNotesAll iterates over every item of ListRecentNotes, fetching page after
+// page from params.Page on.
+func (c *Client) ListRecentNotesAll(ctx context.Context, params *ListRecentNotesParams) iter.Seq2[Note, error] {
+	var p ListRecentNotesParams
+	if params != nil {
+		p = *params
+	}
+	return paginate(&p.Page, &p.Limit, func() ([]Note, error) {
+		return c.ListRecentNotes(ctx, &p)
+	})
+}
+
+// ReorderNotes calls PATCH /notes/reorder. Изменить порядок заметок в блокноте.
+func (c *Client) ReorderNotes(ctx context.Context, body ReorderRequest) ([]Note, error) {
+	req := request{method: "PATCH", path: "/api/v1/notes/reorder", body: body}
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNoteBySlug calls GET /notes/slug/{slug}. Получить заметку по slug.
+func (c *Client) GetNoteBySlug(ctx context.Context, slug string) (*Note, error) {
+	req := request{method: "GET", path: "/api/v1/notes/slug/" + url.PathEscape(slug)}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteNote calls DELETE /notes/{id}. Удалить заметку.
+func (c *Client) DeleteNote(ctx context.Context, id string) (*SuccessResponse, error) {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id)}
+	var out *SuccessResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNoteParams are the optional parameters of GetNote.
+type GetNoteParams struct {
+	// Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек
+	DateFormat string
+}
+
+func (p *GetNoteParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DateFormat != "" {
+		req.setQuery("date_format", p.DateFormat)
+	}
+}
+
+// GetNote calls GET /notes/{id}. Получить заметку.
+func (c *Client) GetNote(ctx context.Context, id string, params *GetNoteParams) (*Note, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id)}
+	params.apply(&req)
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// HeadNote calls HEAD /notes/{id}. Проверить, есть ли заметка.
+func (c *Client) HeadNote(ctx context.Context, id string) error {
+	req := request{method: "HEAD", path: "/api/v1/notes/" + url.PathEscape(id)}
+	return c.do(ctx, req, nil)
+}
+
+// PatchNoteParams are the optional parameters of PatchNote.
+type PatchNoteParams struct {
+	// Только проверить запрос: ответ 200 с тем, что получилось бы, без сохранения (или Prefer: dry-run)
+	DryRun bool
+	// Добавить даты для показа (Display) на языке из Accept-Language или настроек и в часовом поясе из настроек
+	DateFormat string
+}
+
+func (p *PatchNoteParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+	if p.DateFormat != "" {
+		req.setQuery("date_format", p.DateFormat)
+	}
+}
+
+// PatchNote calls PATCH /notes/{id}. Обновить заметку (частично).
+func (c *Client) PatchNote(ctx context.Context, id string, body NoteUpdate, params *PatchNoteParams) (*Note, error) {
+	req := request{method: "PATCH", path: "/api/v1/notes/" + url.PathEscape(id), body: body}
+	params.apply(&req)
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListAttachments calls GET /notes/{id}/attachments. Вложения заметки.
+func (c *Client) ListAttachments(ctx context.Context, id string) ([]Attachment, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/attachments"}
+	var out []Attachment
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteAttachment calls DELETE /notes/{id}/attachments/{aid}. Удалить вложение.
+func (c *Client) DeleteAttachment(ctx context.Context, id string, aid int64) error {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/attachments/" + url.PathEscape(strconv.FormatInt(aid, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// GetAttachment calls GET /notes/{id}/attachments/{aid}. Скачать вложение.
+func (c *Client) GetAttachment(ctx context.Context, id string, aid int64) ([]byte, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/attachments/" + url.PathEscape(strconv.FormatInt(aid, 10))}
+	var out []byte
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListCommentsParams are the optional parameters of ListComments.
+type ListCommentsParams struct {
+	// Только решённые (true) или нерешённые (false) ветки
+	Resolved bool
+}
+
+func (p *ListCommentsParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Resolved {
+		req.setQuery("resolved", "true")
+	}
+}
+
+// ListComments calls GET /notes/{id}/comments. Обсуждения заметки.
+func (c *Client) ListComments(ctx context.Context, id string, params *ListCommentsParams) ([]CommentThread, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/comments"}
+	params.apply(&req)
+	var out []CommentThread
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateComment calls POST /notes/{id}/comments. Прокомментировать заметку.
+func (c *Client) CreateComment(ctx context.Context, id string, body CreateCommentRequest) (*Comment, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/comments", body: body}
+	var out *Comment
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteComment calls DELETE /notes/{id}/comments/{cid}. Удалить комментарий.
+func (c *Client) DeleteComment(ctx context.Context, id string, cid int64) error {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/comments/" + url.PathEscape(strconv.FormatInt(cid, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// UpdateComment calls PATCH /notes/{id}/comments/{cid}. Изменить комментарий или решить ветку.
+func (c *Client) UpdateComment(ctx context.Context, id string, cid int64, body UpdateCommentRequest) (*Comment, error) {
+	req := request{method: "PATCH", path: "/api/v1/notes/" + url.PathEscape(id) + "/comments/" + url.PathEscape(strconv.FormatInt(cid, 10)), body: body}
+	var out *Comment
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CopyNoteParams are the optional parameters of CopyNote.
+type CopyNoteParams struct {
+	// Только проверить: ответ 200 с заметкой, какой она стала бы (или Prefer: dry-run)
+	DryRun bool
+}
+
+func (p *CopyNoteParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+}
+
+// CopyNote calls POST /notes/{id}/copy. Скопировать заметку в блокнот.
+func (c *Client) CopyNote(ctx context.Context, id string, body MoveNoteRequest, params *CopyNoteParams) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/copy", body: body}
+	params.apply(&req)
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// EmailNote calls POST /notes/{id}/email. Отправить заметку по почте.
+func (c *Client) EmailNote(ctx context.Context, id string, body EmailNoteRequest) (*EmailNoteResponse, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/email", body: body}
+	var out *EmailNoteResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// UnfavoriteNote calls DELETE /notes/{id}/favorite. Убрать заметку из избранного.
+func (c *Client) UnfavoriteNote(ctx context.Context, id string) (*FavoriteResponse, error) {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/favorite"}
+	var out *FavoriteResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// FavoriteNote calls POST /notes/{id}/favorite. Добавить заметку в избранное.
+func (c *Client) FavoriteNote(ctx context.Context, id string) (*FavoriteResponse, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/favorite"}
+	var out *FavoriteResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNoteHighlighted calls GET /notes/{id}/highlight. HTML с подсветкой синтаксиса для сниппета.
+func (c *Client) GetNoteHighlighted(ctx context.Context, id string) (string, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/highlight"}
+	var out string
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetLinkPreviews calls GET /notes/{id}/link-previews. Превью ссылок заметки.
+func (c *Client) GetLinkPreviews(ctx context.Context, id string) ([]Preview, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/link-previews"}
+	var out []Preview
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// UnlockNote calls DELETE /notes/{id}/lock. Снять блокировку.
+func (c *Client) UnlockNote(ctx context.Context, id string) error {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/lock"}
+	return c.do(ctx, req, nil)
+}
+
+// GetNoteLock calls GET /notes/{id}/lock. Кто заблокировал заметку.
+func (c *Client) GetNoteLock(ctx context.Context, id string) (*NoteLock, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/lock"}
+	var out *NoteLock
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// LockNote calls POST /notes/{id}/lock. Заблокировать заметку для правки.
+func (c *Client) LockNote(ctx context.Context, id string) (*NoteLock, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/lock"}
+	var out *NoteLock
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNoteMarkdown calls GET /notes/{id}/markdown. Экспорт заметки в Markdown.
+func (c *Client) GetNoteMarkdown(ctx context.Context, id string) (string, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/markdown"}
+	var out string
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// MoveNoteParams are the optional parameters of MoveNote.
+type MoveNoteParams struct {
+	// Только проверить: ответ 200 с заметкой, какой она стала бы (или Prefer: dry-run)
+	DryRun bool
+}
+
+func (p *MoveNoteParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.DryRun {
+		req.setQuery("dry_run", "true")
+	}
+}
+
+// MoveNote calls POST /notes/{id}/move. Переместить заметку в другой блокнот.
+func (c *Client) MoveNote(ctx context.Context, id string, body MoveNoteRequest, params *MoveNoteParams) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/move", body: body}
+	params.apply(&req)
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// LeavePresence calls DELETE /notes/{id}/presence. Отметить, что заметка закрыта.
+func (c *Client) LeavePresence(ctx context.Context, id string) ([]Viewer, error) {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/presence"}
+	var out []Viewer
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetPresence calls GET /notes/{id}/presence. Кто открыл заметку.
+func (c *Client) GetPresence(ctx context.Context, id string) ([]Viewer, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/presence"}
+	var out []Viewer
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// UpdatePresence calls POST /notes/{id}/presence. Отметить, что заметка открыта.
+func (c *Client) UpdatePresence(ctx context.Context, id string, body PresenceRequest) ([]Viewer, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/presence", body: body}
+	var out []Viewer
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNotePrintParams are the optional parameters of GetNotePrint.
+type GetNotePrintParams struct {
+	// Формат
+	Format string
+	// Имя шаблона
+	Template string
+}
+
+func (p *GetNotePrintParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Format != "" {
+		req.setQuery("format", p.Format)
+	}
+	if p.Template != "" {
+		req.setQuery("template", p.Template)
+	}
+}
+
+// GetNotePrint calls GET /notes/{id}/print. Заметка для печати.
+func (c *Client) GetNotePrint(ctx context.Context, id string, params *GetNotePrintParams) (string, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/print"}
+	params.apply(&req)
+	var out string
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeletePublicLink calls DELETE /notes/{id}/public-link. Отозвать публичную ссылку.
+func (c *Client) DeletePublicLink(ctx context.Context, id string) error {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/public-link"}
+	return c.do(ctx, req, nil)
+}
+
+// GetPublicLink calls GET /notes/{id}/public-link. Публичная ссылка на заметку.
+func (c *Client) GetPublicLink(ctx context.Context, id string) (*PublicLinkResponse, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/public-link"}
+	var out *PublicLinkResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreatePublicLink calls POST /notes/{id}/public-link. Опубликовать заметку.
+func (c *Client) CreatePublicLink(ctx context.Context, id string) (*PublicLinkResponse, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/public-link"}
+	var out *PublicLinkResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetPublicLinkQRParams are the optional parameters of GetPublicLinkQR.
+type GetPublicLinkQRParams struct {
+	// Пикселей на модуль (1-32)
+	Scale int64
+}
+
+func (p *GetPublicLinkQRParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Scale != 0 {
+		req.setQuery("scale", strconv.FormatInt(p.Scale, 10))
+	}
+}
+
+// GetPublicLinkQR calls GET /notes/{id}/public-link/qr.png. QR-код публичной ссылки.
+func (c *Client) GetPublicLinkQR(ctx context.Context, id string, params *GetPublicLinkQRParams) ([]byte, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/public-link/qr.png"}
+	params.apply(&req)
+	var out []byte
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// RemoveReactionParams are the optional parameters of RemoveReaction.
+type RemoveReactionParams struct {
+	// Реакция
+	Emoji string
+}
+
+func (p *RemoveReactionParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Emoji != "" {
+		req.setQuery("emoji", p.Emoji)
+	}
+}
+
+// RemoveReaction calls DELETE /notes/{id}/reactions. Снять реакцию.
+func (c *Client) RemoveReaction(ctx context.Context, id string, params *RemoveReactionParams) (*ReactionsResponse, error) {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/reactions"}
+	params.apply(&req)
+	var out *ReactionsResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// AddReaction calls POST /notes/{id}/reactions. Поставить реакцию.
+func (c *Client) AddReaction(ctx context.Context, id string, body ReactionRequest) (*ReactionsResponse, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/reactions", body: body}
+	var out *ReactionsResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNoteTransitions calls GET /notes/{id}/transitions. Состояние заметки.
+func (c *Client) GetNoteTransitions(ctx context.Context, id string) (*NoteTransitions, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/transitions"}
+	var out *NoteTransitions
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// TransitionNote calls POST /notes/{id}/transitions. Перевести заметку в другое состояние.
+func (c *Client) TransitionNote(ctx context.Context, id string, body TransitionRequest) (*Note, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/transitions", body: body}
+	var out *Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// StartUpload calls POST /notes/{id}/uploads. Начать загрузку вложения.
+func (c *Client) StartUpload(ctx context.Context, id string, body StartUploadRequest) (*Upload, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/uploads", body: body}
+	var out *Upload
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// GetNoteViews calls GET /notes/{id}/views. Кто просматривал заметку.
+func (c *Client) GetNoteViews(ctx context.Context, id string) ([]NoteView, error) {
+	req := request{method: "GET", path: "/api/v1/notes/" + url.PathEscape(id) + "/views"}
+	var out []NoteView
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// UnwatchNote calls DELETE /notes/{id}/watch. Перестать следить за заметкой.
+func (c *Client) UnwatchNote(ctx context.Context, id string) (*WatchResponse, error) {
+	req := request{method: "DELETE", path: "/api/v1/notes/" + url.PathEscape(id) + "/watch"}
+	var out *WatchResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// WatchNote calls POST /notes/{id}/watch. Следить за заметкой.
+func (c *Client) WatchNote(ctx context.Context, id string) (*WatchResponse, error) {
+	req := request{method: "POST", path: "/api/v1/notes/" + url.PathEscape(id) + "/watch"}
+	var out *WatchResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListNotificationsParams are the optional parameters of ListNotifications.
+type ListNotificationsParams struct {
+	// Только непрочитанные
+	Unread bool
+}
+
+func (p *ListNotificationsParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Unread {
+		req.setQuery("unread", "true")
+	}
+}
+
+// ListNotifications calls GET /notifications. Мои уведомления.
+func (c *Client) ListNotifications(ctx context.Context, params *ListNotificationsParams) ([]Notification, error) {
+	req := request{method: "GET", path: "/api/v1/notifications"}
+	params.apply(&req)
+	var out []Notification
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// MarkNotificationsRead calls POST /notifications/read. Отметить уведомления прочитанными.
+func (c *Client) MarkNotificationsRead(ctx context.Context, body MarkReadRequest) (*MarkReadResponse, error) {
+	req := request{method: "POST", path: "/api/v1/notifications/read", body: body}
+	var out *MarkReadResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// NextReviewParams are the optional parameters of NextReview.
+type NextReviewParams struct {
+	// Только заметки с тегом, включая вложенные
+	Tag string
+}
+
+func (p *NextReviewParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Tag != "" {
+		req.setQuery("tag", p.Tag)
+	}
+}
+
+// NextReview calls GET /review/next. Следующая заметка на повторение.
+func (c *Client) NextReview(ctx context.Context, params *NextReviewParams) (*ReviewCard, error) {
+	req := request{method: "GET", path: "/api/v1/review/next"}
+	params.apply(&req)
+	var out *ReviewCard
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ReviewDone calls POST /review/{id}/done. Отметить повторение заметки.
+func (c *Client) ReviewDone(ctx context.Context, id string, body ReviewDoneRequest) (*ReviewState, error) {
+	req := request{method: "POST", path: "/api/v1/review/" + url.PathEscape(id) + "/done", body: body}
+	var out *ReviewState
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListRules calls GET /rules. Правила пользователя.
+func (c *Client) ListRules(ctx context.Context) ([]Rule, error) {
+	req := request{method: "GET", path: "/api/v1/rules"}
+	var out []Rule
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateRule calls POST /rules. Создать правило.
+func (c *Client) CreateRule(ctx context.Context, body RuleInput) (*Rule, error) {
+	req := request{method: "POST", path: "/api/v1/rules", body: body}
+	var out *Rule
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteRule calls DELETE /rules/{id}. Удалить правило.
+func (c *Client) DeleteRule(ctx context.Context, id int64) error {
+	req := request{method: "DELETE", path: "/api/v1/rules/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// GetRule calls GET /rules/{id}. Получить правило.
+func (c *Client) GetRule(ctx context.Context, id int64) (*Rule, error) {
+	req := request{method: "GET", path: "/api/v1/rules/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *Rule
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PutRule calls PUT /rules/{id}. Заменить правило.
+func (c *Client) PutRule(ctx context.Context, id int64, body RuleInput) (*Rule, error) {
+	req := request{method: "PUT", path: "/api/v1/rules/" + url.PathEscape(strconv.FormatInt(id, 10)), body: body}
+	var out *Rule
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// SearchNotesParams are the optional parameters of SearchNotes.
+type SearchNotesParams struct {
+	// Запрос, например tag:work AND created:>2024-01-01 AND \
+	Q string
+	// Номер страницы
+	Page int64
+	// Размер страницы; по умолчанию page_size из настроек, без него — все
+	Limit int64
+	// Тип заметки
+	Type string
+	// Тег, включая вложенные (project → project/alpha)
+	Tag string
+	// Состояния жизненного цикла через запятую
+	State string
+	// Только заметки блокнота
+	NotebookID int64
+	// Фильтр по свойству, например prop.status=done
+	Prop map[string]string
+}
+
+func (p *SearchNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Q != "" {
+		req.setQuery("q", p.Q)
+	}
+	if p.Page != 0 {
+		req.setQuery("page", strconv.FormatInt(p.Page, 10))
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Type != "" {
+		req.setQuery("type", p.Type)
+	}
+	if p.Tag != "" {
+		req.setQuery("tag", p.Tag)
+	}
+	if p.State != "" {
+		req.setQuery("state", p.State)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+	for k, v := range p.Prop {
+		req.setQuery("prop."+k, v)
+	}
+}
+
+// SearchNotes calls GET /search. Поиск по всем заметкам.
+func (c *Client) SearchNotes(ctx context.Context, params *SearchNotesParams) (*SearchResults, error) {
+	req := request{method: "GET", path: "/api/v1/search"}
+	params.apply(&req)
+	var out *SearchResults
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// TypeaheadParams are the optional parameters of Typeahead.
+type TypeaheadParams struct {
+	// Начало заголовка
+	Q string
+	// Сколько подсказок вернуть (по умолчанию 8, не больше 20)
+	Limit int64
+}
+
+func (p *TypeaheadParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Q != "" {
+		req.setQuery("q", p.Q)
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+}
+
+// Typeahead calls GET /search/typeahead. Подсказки при вводе.
+func (c *Client) Typeahead(ctx context.Context, params *TypeaheadParams) ([]TypeaheadHit, error) {
+	req := request{method: "GET", path: "/api/v1/search/typeahead"}
+	params.apply(&req)
+	var out []TypeaheadHit
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ValidateSearchQueryParams are the optional parameters of ValidateSearchQuery.
+type ValidateSearchQueryParams struct {
+	// Запрос
+	Q string
+}
+
+func (p *ValidateSearchQueryParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Q != "" {
+		req.setQuery("q", p.Q)
+	}
+}
+
+// ValidateSearchQuery calls GET /search/validate. Проверить поисковый запрос.
+func (c *Client) ValidateSearchQuery(ctx context.Context, params *ValidateSearchQueryParams) (*QueryValidation, error) {
+	req := request{method: "GET", path: "/api/v1/search/validate"}
+	params.apply(&req)
+	var out *QueryValidation
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ActivityStatsParams are the optional parameters of ActivityStats.
+type ActivityStatsParams struct {
+	// Сколько дней, включая сегодня (по умолчанию 30, не больше 366)
+	Days int64
+	// Часовой пояс IANA
+	Tz string
+}
+
+func (p *ActivityStatsParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Days != 0 {
+		req.setQuery("days", strconv.FormatInt(p.Days, 10))
+	}
+	if p.Tz != "" {
+		req.setQuery("tz", p.Tz)
+	}
+}
+
+// ActivityStats calls GET /stats/activity. Активность по дням.
+func (c *Client) ActivityStats(ctx context.Context, params *ActivityStatsParams) ([]ActivityDay, error) {
+	req := request{method: "GET", path: "/api/v1/stats/activity"}
+	params.apply(&req)
+	var out []ActivityDay
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// StorageStats calls GET /stats/storage. Занятое место.
+func (c *Client) StorageStats(ctx context.Context) (*StorageStats, error) {
+	req := request{method: "GET", path: "/api/v1/stats/storage"}
+	var out *StorageStats
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// RunSync calls POST /sync/run. Запустить синхронизацию.
+func (c *Client) RunSync(ctx context.Context) (*Status, error) {
+	req := request{method: "POST", path: "/api/v1/sync/run"}
+	var out *Status
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// SyncStatus calls GET /sync/status. Состояние синхронизации.
+func (c *Client) SyncStatus(ctx context.Context) (*Status, error) {
+	req := request{method: "GET", path: "/api/v1/sync/status"}
+	var out *Status
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListTags calls GET /tags. Список тегов.
+func (c *Client) ListTags(ctx context.Context) ([]TagCount, error) {
+	req := request{method: "GET", path: "/api/v1/tags"}
+	var out []TagCount
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// MergeTags calls POST /tags/merge. Слить теги.
+func (c *Client) MergeTags(ctx context.Context, body MergeTagsRequest) (*TagChangeResponse, error) {
+	req := request{method: "POST", path: "/api/v1/tags/merge", body: body}
+	var out *TagChangeResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// TagTree calls GET /tags/tree. Дерево тегов.
+func (c *Client) TagTree(ctx context.Context) ([]TagNode, error) {
+	req := request{method: "GET", path: "/api/v1/tags/tree"}
+	var out []TagNode
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// RenameTag calls PATCH /tags/{name}. Переименовать тег.
+func (c *Client) RenameTag(ctx context.Context, name string, body RenameTagRequest) (*TagChangeResponse, error) {
+	req := request{method: "PATCH", path: "/api/v1/tags/" + url.PathEscape(name), body: body}
+	var out *TagChangeResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ApplyTag calls POST /tags/{name}/apply. Добавить тег заметкам по фильтру.
+func (c *Client) ApplyTag(ctx context.Context, name string, body BulkTagRequest) (*JobResponse, error) {
+	req := request{method: "POST", path: "/api/v1/tags/" + url.PathEscape(name) + "/apply", body: body}
+	var out *JobResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// RemoveTag calls POST /tags/{name}/remove. Снять тег с заметок по фильтру.
+func (c *Client) RemoveTag(ctx context.Context, name string, body BulkTagRequest) (*JobResponse, error) {
+	req := request{method: "POST", path: "/api/v1/tags/" + url.PathEscape(name) + "/remove", body: body}
+	var out *JobResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// UndoOperation calls POST /undo/{token}. Отменить операцию.
+func (c *Client) UndoOperation(ctx context.Context, token string) (*UndoResponse, error) {
+	req := request{method: "POST", path: "/api/v1/undo/" + url.PathEscape(token)}
+	var out *UndoResponse
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CancelUpload calls DELETE /uploads/{id}. Отменить загрузку.
+func (c *Client) CancelUpload(ctx context.Context, id string) error {
+	req := request{method: "DELETE", path: "/api/v1/uploads/" + url.PathEscape(id)}
+	return c.do(ctx, req, nil)
+}
+
+// GetUpload calls GET /uploads/{id}. Состояние загрузки.
+func (c *Client) GetUpload(ctx context.Context, id string) (*Upload, error) {
+	req := request{method: "GET", path: "/api/v1/uploads/" + url.PathEscape(id)}
+	var out *Upload
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// WriteUploadParams are the optional parameters of WriteUpload.
+type WriteUploadParams struct {
+	// Смещение куска
+	UploadOffset int64
+}
+
+func (p *WriteUploadParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.UploadOffset != 0 {
+		req.setHeader("Upload-Offset", strconv.FormatInt(p.UploadOffset, 10))
+	}
+}
+
+// WriteUpload calls PATCH /uploads/{id}. Отправить кусок файла.
+func (c *Client) WriteUpload(ctx context.Context, id string, body []byte, params *WriteUploadParams) (*Attachment, error) {
+	req := request{method: "PATCH", path: "/api/v1/uploads/" + url.PathEscape(id), body: body, contentType: "application/octet-stream"}
+	params.apply(&req)
+	var out *Attachment
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ListSavedViews calls GET /views. Представления пользователя.
+func (c *Client) ListSavedViews(ctx context.Context) ([]SavedView, error) {
+	req := request{method: "GET", path: "/api/v1/views"}
+	var out []SavedView
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// CreateSavedView calls POST /views. Сохранить представление.
+func (c *Client) CreateSavedView(ctx context.Context, body SavedViewInput) (*SavedView, error) {
+	req := request{method: "POST", path: "/api/v1/views", body: body}
+	var out *SavedView
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// DeleteSavedView calls DELETE /views/{id}. Удалить представление.
+func (c *Client) DeleteSavedView(ctx context.Context, id int64) error {
+	req := request{method: "DELETE", path: "/api/v1/views/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	return c.do(ctx, req, nil)
+}
+
+// GetSavedView calls GET /views/{id}. Представление.
+func (c *Client) GetSavedView(ctx context.Context, id int64) (*SavedView, error) {
+	req := request{method: "GET", path: "/api/v1/views/" + url.PathEscape(strconv.FormatInt(id, 10))}
+	var out *SavedView
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// PutSavedView calls PUT /views/{id}. Заменить представление.
+func (c *Client) PutSavedView(ctx context.Context, id int64, body SavedViewInput) (*SavedView, error) {
+	req := request{method: "PUT", path: "/api/v1/views/" + url.PathEscape(strconv.FormatInt(id, 10)), body: body}
+	var out *SavedView
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// SavedViewNotesParams are the optional parameters of SavedViewNotes.
+type SavedViewNotesParams struct {
+	// Номер страницы
+	Page int64
+	// Размер страницы; по умолчанию page_size из настроек, без него — все
+	Limit int64
+}
+
+func (p *SavedViewNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Page != 0 {
+		req.setQuery("page", strconv.FormatInt(p.Page, 10))
+	}
+	if p.Limit != 0 {
+		req.setQuery("limit", strconv.FormatInt(p.Limit, 10))
+	}
+}
+
+// SavedViewNotes calls GET /views/{id}/notes. Заметки представления.
+func (c *Client) SavedViewNotes(ctx context.Context, id int64, params *SavedViewNotesParams) ([]Note, error) {
+	req := request{method: "GET", path: "/api/v1/views/" + url.PathEscape(strconv.FormatInt(id, 10)) + "/notes"}
+	params.apply(&req)
+	var out []Note
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// SavedViewNotesAll iterates over every item of SavedViewNotes, fetching page after
+// page from params.Page on.
+func (c *Client) SavedViewNotesAll(ctx context.Context, id int64, params *SavedViewNotesParams) iter.Seq2[Note, error] {
+	var p SavedViewNotesParams
+	if params != nil {
+		p = *params
+	}
+	return paginate(&p.Page, &p.Limit, func() ([]Note, error) {
+		return c.SavedViewNotes(ctx, id, &p)
+	})
+}
+
+// ZapierAppendToNote calls POST /zapier/actions/append_to_note. Действие: дописать текст в заметку.
+func (c *Client) ZapierAppendToNote(ctx context.Context, body ZapierAppendRequest) (*ZapierNote, error) {
+	req := request{method: "POST", path: "/api/v1/zapier/actions/append_to_note", body: body}
+	var out *ZapierNote
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ZapierCreateNote calls POST /zapier/actions/create_note. Действие: создать заметку.
+func (c *Client) ZapierCreateNote(ctx context.Context, body ZapierCreateRequest) (*ZapierNote, error) {
+	req := request{method: "POST", path: "/api/v1/zapier/actions/create_note", body: body}
+	var out *ZapierNote
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ZapierMe calls GET /zapier/me. Проверка ключа для Zapier/IFTTT.
+func (c *Client) ZapierMe(ctx context.Context) (*ZapierMe, error) {
+	req := request{method: "GET", path: "/api/v1/zapier/me"}
+	var out *ZapierMe
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ZapierNewNotesParams are the optional parameters of ZapierNewNotes.
+type ZapierNewNotesParams struct {
+	// Только из блокнота
+	NotebookID int64
+}
+
+func (p *ZapierNewNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+}
+
+// ZapierNewNotes calls GET /zapier/triggers/new_note. Триггер: новая заметка.
+func (c *Client) ZapierNewNotes(ctx context.Context, params *ZapierNewNotesParams) ([]ZapierNote, error) {
+	req := request{method: "GET", path: "/api/v1/zapier/triggers/new_note"}
+	params.apply(&req)
+	var out []ZapierNote
+	err := c.do(ctx, req, &out)
+	return out, err
+}
+
+// ZapierNewTaggedNotesParams are the optional parameters of ZapierNewTaggedNotes.
+type ZapierNewTaggedNotesParams struct {
+	// Тег (включая вложенные)
+	Tag string
+	// Только из блокнота
+	NotebookID int64
+}
+
+func (p *ZapierNewTaggedNotesParams) apply(req *request) {
+	if p == nil {
+		return
+	}
+	if p.Tag != "" {
+		req.setQuery("tag", p.Tag)
+	}
+	if p.NotebookID != 0 {
+		req.setQuery("notebook_id", strconv.FormatInt(p.NotebookID, 10))
+	}
+}
+
+// ZapierNewTaggedNotes calls GET /zapier/triggers/new_tagged_note. Триггер: заметка получила тег.
+func (c *Client) ZapierNewTaggedNotes(ctx context.Context, params *ZapierNewTaggedNotesParams) ([]ZapierNote, error) {
+	req := request{method: "GET", path: "/api/v1/zapier/triggers/new_tagged_note"}
+	params.apply(&req)
+	var out []ZapierNote
+	err := c.do(ctx, req, &out)
+	return out, err
+}
@@ -0,0 +1,318 @@
+// Package client is a typed Go client of the Notes API, for services that
+// call it. Its types and methods, one per route, are generated from the
+// API registry into api_gen.go by "make clients"; this file is the
+// transport they share.
+//
+//	c := client.NewClient("http://localhost:8080", client.BearerToken(token))
+//	note, err := c.CreateNote(ctx, client.NoteCreate{Title: "Идея"}, nil)
+//	for n, err := range c.ListNotesAll(ctx, &client.ListNotesParams{Tag: "work"}) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Credentials authenticate the requests of a Client.
+type Credentials interface {
+	Authenticate(r *http.Request) error
+}
+
+// BearerToken is an API token, sent in the Authorization header.
+type BearerToken string
+
+func (t BearerToken) Authenticate(r *http.Request) error {
+	r.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// RetryPolicy says when a Client sends a request again. Requests are
+// retried after network errors and 502, 503 and 504 responses when their
+// method is idempotent, and after 429 responses, which the server answers
+// before doing anything, whatever the method.
+type RetryPolicy struct {
+	// Attempts is how many times a request is sent at most; 1 or less
+	// turns retries off.
+	Attempts int
+	// Backoff is the wait before the first retry, doubled for each further
+	// one up to MaxBackoff. A Retry-After of the response replaces it; a
+	// Retry-After longer than MaxBackoff is not waited for.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetry is the RetryPolicy of NewClient.
+var DefaultRetry = RetryPolicy{Attempts: 3, Backoff: 200 * time.Millisecond, MaxBackoff: 10 * time.Second}
+
+// pageSize is the limit the All iterators ask for when the parameters set
+// none.
+const pageSize = 100
+
+// Client calls the API at BaseURL, the server root without /api/v1.
+type Client struct {
+	BaseURL string
+	// Credentials, when set, authenticate every request.
+	Credentials Credentials
+	HTTPClient  *http.Client
+	Retry       RetryPolicy
+}
+
+func NewClient(baseURL string, creds Credentials) *Client {
+	return &Client{
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+		Credentials: creds,
+		HTTPClient:  http.DefaultClient,
+		Retry:       DefaultRetry,
+	}
+}
+
+// Error is a response with an error status. Code and Message are those of
+// the JSON error body, when there is one.
+type Error struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"error"`
+	// Fields maps invalid fields to what is wrong with them, for
+	// validation_failed.
+	Fields map[string]string `json:"fields,omitempty"`
+	Body   []byte            `json:"-"`
+	// RetryAfter is the Retry-After of 429 and 503 responses.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("notes api: %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("notes api: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// request is a call of a generated method. A []byte body is sent as it
+// is with contentType; other bodies as JSON.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        any
+	contentType string
+}
+
+func (r *request) setQuery(key, value string) {
+	if r.query == nil {
+		r.query = make(url.Values)
+	}
+	r.query.Set(key, value)
+}
+
+func (r *request) setHeader(key, value string) {
+	if r.header == nil {
+		r.header = make(http.Header)
+	}
+	r.header.Set(key, value)
+}
+
+// do sends req, again as the retry policy allows, and reads the response
+// into out: a *[]byte or *string takes the body as it is, anything else is
+// decoded from JSON. out is left alone for responses without a body.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	var body []byte
+	contentType := req.contentType
+	switch b := req.body.(type) {
+	case nil:
+	case []byte:
+		body = b
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		body = data
+		contentType = "application/json"
+	}
+
+	var data []byte
+	for attempt := 1; ; attempt++ {
+		var err error
+		data, err = c.send(ctx, req, body, contentType)
+		if err == nil {
+			break
+		}
+		wait, ok := c.retryAfter(req.method, err, attempt)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	switch out := out.(type) {
+	case *[]byte:
+		*out = data
+		return nil
+	case *string:
+		*out = string(data)
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// send makes one attempt of req and returns the body of a successful
+// response, or nil for 204.
+func (c *Client) send(ctx context.Context, req request, body []byte, contentType string) ([]byte, error) {
+	u := c.BaseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	hr, err := http.NewRequestWithContext(ctx, req.method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.header {
+		hr.Header[key] = values
+	}
+	if contentType != "" {
+		hr.Header.Set("Content-Type", contentType)
+	}
+	if c.Credentials != nil {
+		if err := c.Credentials.Authenticate(hr); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(hr)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		e := &Error{Status: resp.StatusCode, Body: data, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+		json.Unmarshal(data, e)
+		return nil, e
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// retryAfter returns how long to wait before sending a request that failed
+// with err on attempt again, and false if it is not to be sent again.
+func (c *Client) retryAfter(method string, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.Retry.Attempts || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusTooManyRequests:
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			if !idempotent(method) {
+				return 0, false
+			}
+		default:
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter, apiErr.RetryAfter <= c.Retry.MaxBackoff
+		}
+	} else if !idempotent(method) {
+		return 0, false
+	}
+
+	wait := c.Retry.Backoff << (attempt - 1)
+	if wait > c.Retry.MaxBackoff || wait <= 0 {
+		wait = c.Retry.MaxBackoff
+	}
+	// Jitter keeps clients that failed together from retrying together.
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header: seconds or an HTTP date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// paginate iterates over the items of a list route page by page: fetch
+// gets the page *page of *limit items, and paginate moves *page on until
+// a page comes back short.
+func paginate[T any](page, limit *int64, fetch func() ([]T, error)) iter.Seq2[T, error] {
+	if *page < 1 {
+		*page = 1
+	}
+	if *limit < 1 {
+		*limit = pageSize
+	}
+	first := *page
+	return func(yield func(T, error) bool) {
+		*page = first
+		for {
+			items, err := fetch()
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if int64(len(items)) < *limit {
+				return
+			}
+			*page++
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failing answers status to the first failures requests and 200 with an
+// empty list or object after.
+func failing(t *testing.T, failures int32, status int, retryAfter string) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"Try later","code":"unavailable"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`[]`))
+		} else {
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, BearerToken("secret"))
+	c.Retry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	return c, &calls
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	c, calls := failing(t, 2, http.StatusServiceUnavailable, "")
+	if _, err := c.ListNotes(ctx, nil); err != nil || calls.Load() != 3 {
+		t.Errorf("GET after two 503s: %v, %d calls", err, calls.Load())
+	}
+
+	c, calls = failing(t, 3, http.StatusServiceUnavailable, "")
+	_, err := c.ListNotes(ctx, nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable || apiErr.Code != "unavailable" || calls.Load() != 3 {
+		t.Errorf("GET after three 503s: %v, %d calls", err, calls.Load())
+	}
+
+	// POST is not idempotent: only a 429 is retried.
+	c, calls = failing(t, 1, http.StatusServiceUnavailable, "")
+	if _, err := c.CreateNote(ctx, NoteCreate{Title: "x"}, nil); err == nil || calls.Load() != 1 {
+		t.Errorf("POST after a 503: %v, %d calls", err, calls.Load())
+	}
+	c, calls = failing(t, 1, http.StatusTooManyRequests, "0")
+	if _, err := c.CreateNote(ctx, NoteCreate{Title: "x"}, nil); err != nil || calls.Load() != 2 {
+		t.Errorf("POST after a 429: %v, %d calls", err, calls.Load())
+	}
+
+	// A Retry-After beyond MaxBackoff is not waited for.
+	c, calls = failing(t, 1, http.StatusTooManyRequests, "3600")
+	if _, err := c.ListNotes(ctx, nil); err == nil || calls.Load() != 1 {
+		t.Errorf("GET after a 429 for an hour: %v, %d calls", err, calls.Load())
+	}
+
+	// Client errors are not retried.
+	c, calls = failing(t, 1, http.StatusNotFound, "")
+	if _, err := c.ListNotes(ctx, nil); err == nil || calls.Load() != 1 {
+		t.Errorf("GET after a 404: %v, %d calls", err, calls.Load())
+	}
+}